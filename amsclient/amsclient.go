@@ -15,8 +15,12 @@
 package amsclient
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,16 +33,39 @@ import (
 )
 
 const (
+	// subscriptionFields are the fields of subscriptions retrieved from AMS API
+	subscriptionFields = "external_cluster_id,display_name,cluster_id,managed,status,metrics,last_telemetry_date"
+
 	// defaultPageSize is the page size used when it is not defined in the configuration
 	defaultPageSize = 500
+	// defaultMaxConcurrentPages is the number of subscription pages fetched
+	// in parallel when it is not defined in the configuration
+	defaultMaxConcurrentPages = 4
+
+	// serviceLogsEndpoint is the path of OCM Service Log API receiving
+	// the entries of cluster logs
+	serviceLogsEndpoint = "/api/service_logs/v1/cluster_logs"
+
+	// forcedTokenRefresh is used to force refreshing of the access token, no
+	// token is valid for such a long time
+	forcedTokenRefresh = 365 * 24 * time.Hour
 
 	// strings for logging and errors
 	orgNoInternalID              = "Organization doesn't have proper internal ID"
 	orgMoreInternalOrgs          = "More than one internal organization for the given orgID"
 	orgIDRequestFailure          = "Request to get the organization info failed"
 	subscriptionListRequestError = "problem executing subscription list request"
+	clusterNoOrganization        = "Cluster subscription doesn't have organization ID"
+	orgNoExternalID              = "Organization doesn't have proper external ID"
+	tokenRejected                = "Access token rejected by AMS API, refreshing it"
+	tokenRefreshFailure          = "Unable to refresh access token for AMS API"
 	orgIDTag                     = "OrgID"
 	clusterIDTag                 = "ClusterID"
+	serviceLogRequestError       = "Request to send service log entry failed"
+
+	// maxSearchQueryLength is the maximal length of search query sent to AMS
+	// API when the details of given clusters are retrieved
+	maxSearchQueryLength = 2000
 
 	// StatusDeprovisioned indicates the corresponding cluster subscription status
 	StatusDeprovisioned = "Deprovisioned"
@@ -69,12 +96,28 @@ type AMSClient interface {
 	GetSingleClusterInfoForOrganization(types.OrgID, types.ClusterName) (
 		types.ClusterInfo, error,
 	)
+	GetClusterDetailsFromExternalID(types.ClusterName) (
+		clusterInfo types.ClusterInfo,
+		orgID types.OrgID,
+		err error,
+	)
+	FindClustersForOrganization(types.OrgID, string) (
+		clusterInfoList []types.ClusterInfo,
+		err error,
+	)
+	GetClustersInfoForOrganization(types.OrgID, []types.ClusterName) (
+		clusterInfoList []types.ClusterInfo,
+		err error,
+	)
+	SendServiceLog(types.ServiceLogEntry) error
 }
 
 // amsClientImpl is an implementation of the AMSClient interface
 type amsClientImpl struct {
-	connection *sdk.Connection
-	pageSize   int
+	connection         *sdk.Connection
+	pageSize           int
+	maxConcurrentPages int
+	timeout            time.Duration
 }
 
 // NewAMSClient create an AMSClient from the configuration
@@ -87,8 +130,16 @@ func NewAMSClientWithTransport(conf Configuration, transport http.RoundTripper)
 	log.Info().Msg("Creating amsclient...")
 	builder := sdk.NewConnectionBuilder().URL(conf.URL)
 
-	if transport != nil {
-		builder.TransportWrapper(func(http.RoundTripper) http.RoundTripper { return transport })
+	if transport != nil || conf.RetryLimit > 0 {
+		builder.TransportWrapper(func(wrapped http.RoundTripper) http.RoundTripper {
+			if transport != nil {
+				wrapped = transport
+			}
+			if conf.RetryLimit > 0 {
+				wrapped = newRetryTransport(wrapped, conf.RetryLimit, conf.RetryInterval)
+			}
+			return wrapped
+		})
 	}
 
 	if conf.ClientID != "" && conf.ClientSecret != "" {
@@ -112,9 +163,15 @@ func NewAMSClientWithTransport(conf Configuration, transport http.RoundTripper)
 		conf.PageSize = defaultPageSize
 	}
 
+	if conf.MaxConcurrentPages <= 0 {
+		conf.MaxConcurrentPages = defaultMaxConcurrentPages
+	}
+
 	return &amsClientImpl{
-		connection: conn,
-		pageSize:   conf.PageSize,
+		connection:         conn,
+		pageSize:           conf.PageSize,
+		maxConcurrentPages: conf.MaxConcurrentPages,
+		timeout:            conf.Timeout,
 	}, nil
 }
 
@@ -140,9 +197,7 @@ func (c *amsClientImpl) GetClustersForOrganization(orgID types.OrgID, statusFilt
 	}
 
 	searchQuery := generateSearchParameter(internalOrgID, statusFilter, statusNegativeFilter)
-	subscriptionListRequest := c.connection.AccountsMgmt().V1().Subscriptions().List()
-
-	clusterInfoList, err = c.executeSubscriptionListRequest(subscriptionListRequest, searchQuery)
+	clusterInfoList, err = c.executeSubscriptionListRequest(searchQuery)
 	if err != nil {
 		log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg(subscriptionListRequestError)
 		return
@@ -161,9 +216,7 @@ func (c *amsClientImpl) GetClusterDetailsFromExternalClusterID(externalID types.
 	tStart := time.Now()
 
 	searchQuery := fmt.Sprintf("external_cluster_id = '%s'", externalID)
-	subscriptionListRequest := c.connection.AccountsMgmt().V1().Subscriptions().List()
-
-	clusterInfoList, err := c.executeSubscriptionListRequest(subscriptionListRequest, searchQuery)
+	clusterInfoList, err := c.executeSubscriptionListRequest(searchQuery)
 	if err != nil {
 		log.Error().Err(err).Str(clusterIDTag, string(externalID)).Msg(subscriptionListRequestError)
 		return
@@ -189,8 +242,7 @@ func (c *amsClientImpl) GetSingleClusterInfoForOrganization(orgID types.OrgID, c
 
 	searchQuery := fmt.Sprintf("organization_id = '%s' and external_cluster_id = '%s'", internalOrgID, clusterID)
 
-	subscriptionListRequest := c.connection.AccountsMgmt().V1().Subscriptions().List()
-	clusterInfoList, err := c.executeSubscriptionListRequest(subscriptionListRequest, searchQuery)
+	clusterInfoList, err := c.executeSubscriptionListRequest(searchQuery)
 	if err != nil {
 		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg(subscriptionListRequestError)
 		return
@@ -205,16 +257,191 @@ func (c *amsClientImpl) GetSingleClusterInfoForOrganization(orgID types.OrgID, c
 	return clusterInfoList[0], nil
 }
 
+// GetClusterDetailsFromExternalID retrieves the details of a single cluster
+// together with the external ID of organization owning the cluster. It allows
+// to check that the cluster belongs to some organization without reading the
+// whole list of its clusters.
+func (c *amsClientImpl) GetClusterDetailsFromExternalID(externalID types.ClusterName) (
+	clusterInfo types.ClusterInfo, orgID types.OrgID, err error,
+) {
+	log.Debug().Str(clusterIDTag, string(externalID)).Msg("Looking up details and organization of the cluster")
+	tStart := time.Now()
+
+	var response *accMgmt.SubscriptionsListResponse
+	err = c.sendWithTokenRefresh(operationSubscriptions, func(ctx context.Context) (status int, err error) {
+		response, err = c.connection.AccountsMgmt().V1().Subscriptions().List().
+			Size(1).
+			Fields(subscriptionFields + ",organization_id").
+			Search(fmt.Sprintf("external_cluster_id = '%s'", externalID)).
+			SendContext(ctx)
+		return response.Status(), err
+	})
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(externalID)).Msg(subscriptionListRequestError)
+		return
+	}
+
+	if response.Items().Len() == 0 {
+		return clusterInfo, orgID, &utypes.ItemNotFoundError{ItemID: externalID}
+	}
+	subscription := response.Items().Get(0)
+
+	clusterInfo, ok := subscriptionToClusterInfo(subscription)
+	if !ok {
+		return clusterInfo, orgID, &utypes.ItemNotFoundError{ItemID: externalID}
+	}
+
+	internalOrgID, ok := subscription.GetOrganizationID()
+	if !ok || internalOrgID == "" {
+		log.Error().Str(clusterIDTag, string(externalID)).Msg(clusterNoOrganization)
+		return clusterInfo, orgID, fmt.Errorf(clusterNoOrganization)
+	}
+
+	orgID, err = c.GetExternalOrgIDFromInternal(internalOrgID)
+	if err != nil {
+		return
+	}
+
+	log.Info().Str(clusterIDTag, string(externalID)).Msgf(
+		"GetClusterDetailsFromExternalID from AMS API took %s", time.Since(tStart),
+	)
+	return
+}
+
+// FindClustersForOrganization retrieves the clusters of given organization
+// whose AMS subscription ID or display name equals the reference. Display
+// names are not unique, so more clusters can be returned. Archived,
+// deprovisioned and reserved clusters are not taken into account.
+func (c *amsClientImpl) FindClustersForOrganization(orgID types.OrgID, reference string) (
+	clusterInfoList []types.ClusterInfo,
+	err error,
+) {
+	log.Debug().Uint32(orgIDTag, uint32(orgID)).Str("reference", reference).Msg("Looking up clusters by reference")
+	tStart := time.Now()
+
+	internalOrgID, err := c.GetInternalOrgIDFromExternal(orgID)
+	if err != nil {
+		return
+	}
+
+	searchQuery := generateReferenceSearchParameter(internalOrgID, reference)
+	clusterInfoList, err = c.executeSubscriptionListRequest(searchQuery)
+	if err != nil {
+		log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg(subscriptionListRequestError)
+		return
+	}
+
+	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("FindClustersForOrganization from AMS API took %s", time.Since(tStart))
+	return
+}
+
+// GetClustersInfoForOrganization retrieves the details of given clusters of
+// the organization. The cluster IDs are sliced into more search queries, so
+// the length of each query is bounded. Clusters that are not known to AMS
+// are not returned, status of the clusters is not taken into account.
+func (c *amsClientImpl) GetClustersInfoForOrganization(orgID types.OrgID, clusterIDs []types.ClusterName) (
+	clusterInfoList []types.ClusterInfo,
+	err error,
+) {
+	log.Debug().Uint32(orgIDTag, uint32(orgID)).Int("clusters", len(clusterIDs)).Msg("Looking up details of the clusters")
+	if len(clusterIDs) == 0 {
+		return
+	}
+	tStart := time.Now()
+
+	internalOrgID, err := c.GetInternalOrgIDFromExternal(orgID)
+	if err != nil {
+		return
+	}
+
+	for _, searchQuery := range generateClusterListSearchParameters(internalOrgID, clusterIDs, maxSearchQueryLength) {
+		var clusters []types.ClusterInfo
+		clusters, err = c.executeSubscriptionListRequest(searchQuery)
+		if err != nil {
+			log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg(subscriptionListRequestError)
+			return nil, err
+		}
+		clusterInfoList = append(clusterInfoList, clusters...)
+	}
+
+	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("GetClustersInfoForOrganization from AMS API took %s", time.Since(tStart))
+	return
+}
+
+// SendServiceLog posts the entry to OCM Service Log API, the entry is shown
+// to the owners of the cluster in OpenShift Cluster Manager
+func (c *amsClientImpl) SendServiceLog(entry types.ServiceLogEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	err = c.sendWithTokenRefresh(operationServiceLogs, func(ctx context.Context) (int, error) {
+		response, err := c.connection.Post().
+			Path(serviceLogsEndpoint).
+			Bytes(body).
+			SendContext(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if response.Status() >= http.StatusMultipleChoices {
+			return response.Status(), fmt.Errorf("service log API responded with status code %d", response.Status())
+		}
+		return response.Status(), nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(entry.ClusterUUID)).Msg(serviceLogRequestError)
+		return err
+	}
+
+	log.Info().Str(clusterIDTag, string(entry.ClusterUUID)).Msg("Service log entry sent")
+	return nil
+}
+
+// GetExternalOrgIDFromInternal will retrieve the external organization ID from an internal one using AMS API
+func (c *amsClientImpl) GetExternalOrgIDFromInternal(internalOrgID string) (types.OrgID, error) {
+	var response *accMgmt.OrganizationsListResponse
+	err := c.sendWithTokenRefresh(operationOrganizations, func(ctx context.Context) (status int, err error) {
+		response, err = c.connection.AccountsMgmt().V1().Organizations().List().
+			Search(fmt.Sprintf("id = '%s'", internalOrgID)).
+			Fields("id,external_id").
+			SendContext(ctx)
+		return response.Status(), err
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg(orgIDRequestFailure)
+		return 0, err
+	}
+
+	if response.Items().Len() != 1 {
+		log.Error().Str("InternalOrgID", internalOrgID).Msg(orgMoreInternalOrgs)
+		return 0, fmt.Errorf(orgMoreInternalOrgs)
+	}
+
+	externalID, _ := response.Items().Get(0).GetExternalID()
+	orgID, err := strconv.ParseUint(externalID, 10, 32)
+	if err != nil {
+		log.Error().Str("InternalOrgID", internalOrgID).Msg(orgNoExternalID)
+		return 0, fmt.Errorf(orgNoExternalID)
+	}
+
+	return types.OrgID(orgID), nil
+}
+
 // GetInternalOrgIDFromExternal will retrieve the internal organization ID from an external one using AMS API
 func (c *amsClientImpl) GetInternalOrgIDFromExternal(orgID types.OrgID) (string, error) {
 	log.Debug().Uint32(orgIDTag, uint32(orgID)).Msg(
 		"Looking for the internal organization ID for an external one",
 	)
-	orgsListRequest := c.connection.AccountsMgmt().V1().Organizations().List()
-	response, err := orgsListRequest.
-		Search(fmt.Sprintf("external_id = %d", orgID)).
-		Fields("id,external_id").
-		Send()
+	var response *accMgmt.OrganizationsListResponse
+	err := c.sendWithTokenRefresh(operationOrganizations, func(ctx context.Context) (status int, err error) {
+		response, err = c.connection.AccountsMgmt().V1().Organizations().List().
+			Search(fmt.Sprintf("external_id = %d", orgID)).
+			Fields("id,external_id").
+			SendContext(ctx)
+		return response.Status(), err
+	})
 
 	if err != nil {
 		log.Error().Err(err).Msg(orgIDRequestFailure)
@@ -235,23 +462,39 @@ func (c *amsClientImpl) GetInternalOrgIDFromExternal(orgID types.OrgID) (string,
 	return internalID, nil
 }
 
-func (c *amsClientImpl) executeSubscriptionListRequest(
-	subscriptionListRequest *accMgmt.SubscriptionsListRequest,
-	searchQuery string,
-) (
+// executeSubscriptionListRequest reads all pages of subscriptions matching
+// the search query. The first page is read to find out the total number of
+// subscriptions, the remaining pages are then fetched concurrently. Reading
+// continues page by page until an empty page is returned, so subscriptions
+// created in the meantime are not lost.
+func (c *amsClientImpl) executeSubscriptionListRequest(searchQuery string) (
 	clusterInfoList []types.ClusterInfo,
 	err error,
 ) {
-	for pageNum := 1; ; pageNum++ {
-		var err error
-		subscriptionListRequest = subscriptionListRequest.
-			Size(c.pageSize).
-			Page(pageNum).
-			Fields("external_cluster_id,display_name,cluster_id,managed,status").
-			Search(searchQuery)
+	response, err := c.sendSubscriptionListRequest(searchQuery, 1)
+	if err != nil {
+		return clusterInfoList, err
+	}
+	if response.Size() == 0 {
+		SubscriptionPagesFetched.Observe(1)
+		return
+	}
+	clusterInfoList = appendSubscriptions(clusterInfoList, response.Items())
 
-		response, err := subscriptionListRequest.Send()
+	pageNum := 2
+	if lastPage := (response.Total() + c.pageSize - 1) / c.pageSize; lastPage >= pageNum {
+		pages, err := c.fetchSubscriptionPages(searchQuery, pageNum, lastPage)
+		if err != nil {
+			return clusterInfoList, err
+		}
+		for _, items := range pages {
+			clusterInfoList = appendSubscriptions(clusterInfoList, items)
+		}
+		pageNum = lastPage + 1
+	}
 
+	for ; ; pageNum++ {
+		response, err := c.sendSubscriptionListRequest(searchQuery, pageNum)
 		if err != nil {
 			return clusterInfoList, err
 		}
@@ -260,49 +503,185 @@ func (c *amsClientImpl) executeSubscriptionListRequest(
 		if response.Size() == 0 {
 			break
 		}
+		clusterInfoList = appendSubscriptions(clusterInfoList, response.Items())
+	}
 
-		for _, item := range response.Items().Slice() {
-			clusterIDstr, ok := item.GetExternalClusterID()
-			// we could exclude empty external_cluster_id in the query, but we want to log these special clusters
-			if !ok || clusterIDstr == "" {
-				if id, ok := item.GetID(); ok {
-					log.Warn().Str("InternalClusterID", id).Msg("cluster has no external ID")
-				} else {
-					log.Error().Msgf("No external or internal cluster ID. Cluster [%v]", item)
-				}
-
-				continue
-			}
+	// the last fetched page is the empty one
+	SubscriptionPagesFetched.Observe(float64(pageNum))
+	return
+}
 
-			if _, err := uuid.Parse(clusterIDstr); err != nil {
-				log.Error().Str(clusterIDTag, clusterIDstr).Msg("Invalid cluster UUID")
-				continue
+// fetchSubscriptionPages reads pages firstPage..lastPage (inclusive) of
+// subscriptions matching the search query using at most c.maxConcurrentPages
+// parallel requests. Pages are returned in their original order.
+func (c *amsClientImpl) fetchSubscriptionPages(searchQuery string, firstPage, lastPage int) (
+	[]*accMgmt.SubscriptionList, error,
+) {
+	pages := make([]*accMgmt.SubscriptionList, lastPage-firstPage+1)
+	errs := make([]error, len(pages))
+
+	semaphore := make(chan struct{}, c.maxConcurrentPages)
+	var wg sync.WaitGroup
+
+	for i := range pages {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			response, err := c.sendSubscriptionListRequest(searchQuery, firstPage+i)
+			if err != nil {
+				errs[i] = err
+				return
 			}
+			pages[i] = response.Items()
+		}(i)
+	}
+	wg.Wait()
 
-			displayName, ok := item.GetDisplayName()
-			if !ok {
-				displayName = string(clusterIDstr)
-			}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
 
-			managed, ok := item.GetManaged()
-			if !ok {
-				log.Warn().Str(clusterIDTag, clusterIDstr).Msg("cluster has no managed attribute")
-			}
+// sendSubscriptionListRequest reads one page of subscriptions matching the
+// search query
+func (c *amsClientImpl) sendSubscriptionListRequest(searchQuery string, pageNum int) (
+	response *accMgmt.SubscriptionsListResponse, err error,
+) {
+	err = c.sendWithTokenRefresh(operationSubscriptions, func(ctx context.Context) (status int, err error) {
+		response, err = c.connection.AccountsMgmt().V1().Subscriptions().List().
+			Size(c.pageSize).
+			Page(pageNum).
+			Fields(subscriptionFields).
+			Search(searchQuery).
+			SendContext(ctx)
+		return response.Status(), err
+	})
+	return
+}
 
-			status, ok := item.GetStatus()
-			if !ok {
-				log.Warn().Str(clusterIDTag, clusterIDstr).Msg("cannot retrieve status of cluster")
-			}
+// sendWithTokenRefresh calls the AMS API using given function, which returns
+// HTTP status code of the response. Every call is limited by configured
+// timeout and measured under given operation label. When the access token is
+// rejected by the API, it is refreshed and the call is repeated once.
+func (c *amsClientImpl) sendWithTokenRefresh(operation string, send func(ctx context.Context) (int, error)) error {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := c.callContext()
+		tStart := time.Now()
+		status, err := send(ctx)
+		RequestDuration.WithLabelValues(operation).Observe(time.Since(tStart).Seconds())
+		cancel()
+
+		if err != nil {
+			RequestErrors.WithLabelValues(operation, errorType(status, err)).Inc()
+		}
+
+		if status != http.StatusUnauthorized || attempt > 0 {
+			return err
+		}
 
-			clusterID := types.ClusterName(clusterIDstr)
-			clusterInfoList = append(clusterInfoList, types.ClusterInfo{
-				ID:          clusterID,
-				DisplayName: displayName,
-				Managed:     managed,
-				Status:      status,
-			})
+		log.Warn().Msg(tokenRejected)
+		// requesting tokens valid longer than any access token forces
+		// the connection to obtain new ones
+		if _, _, tokenErr := c.connection.Tokens(forcedTokenRefresh); tokenErr != nil {
+			log.Error().Err(tokenErr).Msg(tokenRefreshFailure)
+			return err
 		}
 	}
+}
 
-	return
+// callContext returns context for single AMS API call, limited by the
+// configured timeout
+func (c *amsClientImpl) callContext() (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// appendSubscriptions converts the subscriptions to cluster info and appends
+// them to the list. Subscriptions without valid external cluster ID are
+// skipped.
+func appendSubscriptions(clusterInfoList []types.ClusterInfo, subscriptions *accMgmt.SubscriptionList) []types.ClusterInfo {
+	for _, item := range subscriptions.Slice() {
+		if clusterInfo, ok := subscriptionToClusterInfo(item); ok {
+			clusterInfoList = append(clusterInfoList, clusterInfo)
+		}
+	}
+
+	return clusterInfoList
+}
+
+// subscriptionToClusterInfo converts the subscription to cluster info. False
+// is returned when the subscription doesn't have valid external cluster ID.
+func subscriptionToClusterInfo(item *accMgmt.Subscription) (types.ClusterInfo, bool) {
+	clusterIDstr, ok := item.GetExternalClusterID()
+	// we could exclude empty external_cluster_id in the query, but we want to log these special clusters
+	if !ok || clusterIDstr == "" {
+		if id, ok := item.GetID(); ok {
+			log.Warn().Str("InternalClusterID", id).Msg("cluster has no external ID")
+		} else {
+			log.Error().Msgf("No external or internal cluster ID. Cluster [%v]", item)
+		}
+
+		return types.ClusterInfo{}, false
+	}
+
+	if _, err := uuid.Parse(clusterIDstr); err != nil {
+		log.Error().Str(clusterIDTag, clusterIDstr).Msg("Invalid cluster UUID")
+		return types.ClusterInfo{}, false
+	}
+
+	displayName, ok := item.GetDisplayName()
+	if !ok {
+		displayName = string(clusterIDstr)
+	}
+
+	managed, ok := item.GetManaged()
+	if !ok {
+		log.Warn().Str(clusterIDTag, clusterIDstr).Msg("cluster has no managed attribute")
+	}
+
+	status, ok := item.GetStatus()
+	if !ok {
+		log.Warn().Str(clusterIDTag, clusterIDstr).Msg("cannot retrieve status of cluster")
+	}
+
+	return types.ClusterInfo{
+		ID:          types.ClusterName(clusterIDstr),
+		DisplayName: displayName,
+		Managed:     managed,
+		Status:      status,
+		Version:     subscriptionVersion(item),
+		LastSeen:    subscriptionLastSeen(item),
+	}, true
+}
+
+// subscriptionVersion returns the OpenShift version reported in subscription
+// metrics or an empty string if it is not known
+func subscriptionVersion(subscription *accMgmt.Subscription) string {
+	metrics, ok := subscription.GetMetrics()
+	if !ok || len(metrics) == 0 {
+		return ""
+	}
+	version, _ := metrics[0].GetOpenshiftVersion()
+	return version
+}
+
+// subscriptionLastSeen returns the time of last telemetry data received from
+// the cluster or an empty timestamp if the cluster was never seen
+func subscriptionLastSeen(subscription *accMgmt.Subscription) types.Timestamp {
+	lastTelemetryDate, ok := subscription.GetLastTelemetryDate()
+	if !ok || lastTelemetryDate.IsZero() {
+		return ""
+	}
+	return types.Timestamp(lastTelemetryDate.UTC().Format(time.RFC3339))
 }
@@ -15,12 +15,17 @@
 package amsclient_test
 
 import (
+	"context"
 	"crypto/rsa"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
@@ -35,16 +40,19 @@ import (
 const (
 	organizationsSearchEndpoint = "api/accounts_mgmt/v1/organizations?fields=id%%2Cexternal_id&search=external_id+%%3D+{orgID}"
 
-	subscriptionsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27&size={pageSize}")
-	subscriptionsSearchEndpointWithFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpointWithFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27+and+status+in+%%28%%27{status1}%%27%%2C%%27{status2}%%27%%29&size={pageSize}")
-	subscriptionsSearchEndpointWithDefaultFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpointWithDefaultFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27+and+status+not+in+%%28%%27{status1}%%27%%2C%%27{status2}%%27%%2C%%27{status3}%%27%%29&size={pageSize}")
-	clusterDetailsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	clusterDetailsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
 		"search=external_cluster_id+%%3D+%%27{clusterID}%%27&size={pageSize}")
-	singleClusterInfoEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	singleClusterInfoEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
 		"search=organization_id+%%3D+%%27{orgID}%%27+and+external_cluster_id+%%3D+%%27{clusterID}%%27&size={pageSize}")
+	referenceSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2Cmetrics%%2Clast_telemetry_date&page={pageNum}&" +
+		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27+and+status+not+in+%%28%%27{status1}%%27%%2C%%27{status2}%%27%%2C%%27{status3}%%27%%29" +
+		"+and+%%28id+%%3D+%%27{reference}%%27+or+display_name+%%3D+%%27{reference}%%27%%29&size={pageSize}")
 )
 
 var (
@@ -384,15 +392,208 @@ func TestClusterForOrganizationCCXDEV_8829_Reproducer(t *testing.T) {
 	assert.Equal(t, types.ClusterName(testdata.ClusterName1), clusterList[0].ID)
 }
 
+// TestClusterForOrganizationConcurrentPages checks that pages following the
+// first one are fetched based on the total number of subscriptions
+func TestClusterForOrganizationConcurrentPages(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	config := defaultConfig
+	config.PageSize = 1
+	config.MaxConcurrentPages = 2
+	c, err := amsclient.NewAMSClientWithTransport(config, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	// prepare organizations response
+	helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     organizationsSearchEndpoint,
+		EndpointArgs: []interface{}{testdata.ExternalOrgID},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.OrganizationResponse),
+	})
+
+	// every page contains one of the subscriptions, the last one is empty
+	items := testdata.SubscriptionsResponse["items"].([]map[string]interface{})
+	pages := []map[string]interface{}{}
+	for i, item := range items {
+		pages = append(pages, map[string]interface{}{
+			"kind":  "SubscriptionList",
+			"page":  i + 1,
+			"size":  1,
+			"total": len(items),
+			"items": []map[string]interface{}{item},
+		})
+	}
+	pages = append(pages, testdata.SubscriptionEmptyResponse)
+
+	for i, page := range pages {
+		helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     subscriptionsSearchEndpoint,
+			EndpointArgs: []interface{}{i + 1, testdata.InternalOrgID, config.PageSize},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: helpers.ToJSONString(page),
+		})
+	}
+
+	clusterList, err := c.GetClustersForOrganization(testdata.ExternalOrgID, nil, []string{})
+	helpers.FailOnError(t, err)
+	assert.Equal(t, testdata.OKClustersForOrganization, clusterList)
+}
+
+// TestClusterForOrganizationRetry checks that requests failed with 5xx status
+// code are retried
+func TestClusterForOrganizationRetry(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	config := defaultConfig
+	config.RetryLimit = 1
+	config.RetryInterval = time.Millisecond
+	config.Timeout = 10 * time.Second
+	c, err := amsclient.NewAMSClientWithTransport(config, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	// first attempt to get the organization fails
+	helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     organizationsSearchEndpoint,
+		EndpointArgs: []interface{}{testdata.ExternalOrgID},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Retry-After":  "0",
+		},
+		Body: `{"kind": "Error"}`,
+	})
+	helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     organizationsSearchEndpoint,
+		EndpointArgs: []interface{}{testdata.ExternalOrgID},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.OrganizationResponse),
+	})
+
+	helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     subscriptionsSearchEndpoint,
+		EndpointArgs: []interface{}{1, testdata.InternalOrgID, config.PageSize},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.SubscriptionsResponse),
+	})
+	helpers.GockExpectAPIRequest(t, config.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     subscriptionsSearchEndpoint,
+		EndpointArgs: []interface{}{2, testdata.InternalOrgID, config.PageSize},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.SubscriptionEmptyResponse),
+	})
+
+	clusterList, err := c.GetClustersForOrganization(testdata.ExternalOrgID, nil, []string{})
+	helpers.FailOnError(t, err)
+	assert.ElementsMatch(t, testdata.OKClustersForOrganization, clusterList)
+}
+
+// roundTripperFunc allows to use a function as HTTP transport
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// TestRetryIdempotentRequestsOnly checks that POST requests are repeated only
+// when they carry Idempotency-Key header and that the request of the caller
+// is not modified by the retries
+func TestRetryIdempotentRequestsOnly(t *testing.T) {
+	var sent []*http.Request
+	var bodies []string
+	transport := amsclient.NewRetryTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(request.Body)
+		helpers.FailOnError(t, err)
+		sent = append(sent, request)
+		bodies = append(bodies, string(body))
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}), 2, time.Millisecond)
+
+	request, err := http.NewRequest(http.MethodPost, "http://ams/api/service_logs/v1/cluster_logs", strings.NewReader("entry"))
+	helpers.FailOnError(t, err)
+	response, err := transport.RoundTrip(request)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, []string{"entry"}, bodies)
+
+	sent, bodies = nil, nil
+	request, err = http.NewRequest(http.MethodPost, "http://ams/api/service_logs/v1/cluster_logs", strings.NewReader("entry"))
+	helpers.FailOnError(t, err)
+	request.Header.Set("Idempotency-Key", "1")
+	body := request.Body
+	_, err = transport.RoundTrip(request)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []string{"entry", "entry", "entry"}, bodies)
+	assert.True(t, sent[0] == request)
+	assert.True(t, sent[1] != request && sent[2] != request)
+	assert.True(t, body == request.Body)
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// exponential backoff
+	assert.Equal(t, time.Second, amsclient.RetryDelay("", time.Second, 0, now))
+	assert.Equal(t, 4*time.Second, amsclient.RetryDelay("", time.Second, 2, now))
+	assert.Equal(t, time.Minute, amsclient.RetryDelay("", time.Second, 100, now))
+
+	// Retry-After header in seconds and as HTTP date
+	assert.Equal(t, 7*time.Second, amsclient.RetryDelay("7", time.Second, 2, now))
+	assert.Equal(t, 30*time.Second, amsclient.RetryDelay("Wed, 01 Mar 2023 10:00:30 GMT", time.Second, 0, now))
+	assert.Equal(t, time.Duration(0), amsclient.RetryDelay("Wed, 01 Mar 2023 09:00:00 GMT", time.Second, 0, now))
+	assert.Equal(t, time.Minute, amsclient.RetryDelay("3600", time.Second, 0, now))
+}
+
 func TestGetClustersForOrganizationOnError(t *testing.T) {
 	client, err := amsclient.NewAMSClient(defaultConfig)
 	helpers.FailOnError(t, err) // Doesn't fail because ocm-sdk doesn't perform any checks
 
+	connectionErrors := amsclient.RequestErrors.WithLabelValues("organizations", "connection")
+	errorsBefore := testutil.ToFloat64(connectionErrors)
+
 	clusters, err := client.GetClustersForOrganization(testdata.ExternalOrgID, nil, nil)
 	if err == nil {
 		t.Fail()
 	}
 	assert.Equal(t, 0, len(clusters))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(connectionErrors))
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "timeout", amsclient.ErrorType(0, context.DeadlineExceeded))
+	assert.Equal(t, "unauthorized", amsclient.ErrorType(http.StatusUnauthorized, errors.New("error")))
+	assert.Equal(t, "rate_limited", amsclient.ErrorType(http.StatusTooManyRequests, errors.New("error")))
+	assert.Equal(t, "server_error", amsclient.ErrorType(http.StatusBadGateway, errors.New("error")))
+	assert.Equal(t, "client_error", amsclient.ErrorType(http.StatusNotFound, errors.New("error")))
+	assert.Equal(t, "connection", amsclient.ErrorType(0, errors.New("connection refused")))
 }
 
 func TestGetClusterDetailsFromExternalClusterId(t *testing.T) {
@@ -436,6 +637,8 @@ func TestGetClusterDetailsFromExternalClusterId(t *testing.T) {
 		DisplayName: testdata.ClusterDisplayName1,
 		Managed:     true,
 		Status:      testdata.ActiveStatus,
+		Version:     testdata.ClusterVersion1,
+		LastSeen:    testdata.ClusterLastSeen1,
 	})
 }
 
@@ -556,4 +759,124 @@ func TestGetSingleClusterInfoForOrganization(t *testing.T) {
 	assert.Equal(t, clusterInfo.DisplayName, testdata.ClusterDisplayName1)
 	assert.Equal(t, clusterInfo.Managed, true)
 	assert.Equal(t, clusterInfo.Status, testdata.ActiveStatus)
+	assert.Equal(t, clusterInfo.Version, testdata.ClusterVersion1)
+	assert.Equal(t, clusterInfo.LastSeen, types.Timestamp(testdata.ClusterLastSeen1))
+}
+
+// TestFindClustersForOrganization checks that clusters are looked up by
+// subscription ID or display name within the organization
+func TestFindClustersForOrganization(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	c, err := amsclient.NewAMSClientWithTransport(defaultConfig, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     organizationsSearchEndpoint,
+		EndpointArgs: []interface{}{testdata.ExternalOrgID},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.OrganizationResponse),
+	})
+
+	for pageNum, body := range []interface{}{testdata.SubscriptionsResponse, testdata.SubscriptionEmptyResponse} {
+		helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: referenceSearchEndpoint,
+			EndpointArgs: []interface{}{
+				pageNum + 1, testdata.InternalOrgID,
+				amsclient.StatusArchived, amsclient.StatusDeprovisioned, amsclient.StatusReserved,
+				"prod", "prod", defaultConfig.PageSize,
+			},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: helpers.ToJSONString(body),
+		})
+	}
+
+	clusterList, err := c.FindClustersForOrganization(testdata.ExternalOrgID, "prod")
+	helpers.FailOnError(t, err)
+	assert.ElementsMatch(t, testdata.OKClustersForOrganization, clusterList)
+}
+
+// TestGenerateReferenceSearchParameter checks that quotes in the reference
+// can't change the search query
+func TestGenerateReferenceSearchParameter(t *testing.T) {
+	assert.Equal(t,
+		"organization_id is 'org' and cluster_id != '' and status not in ('Archived','Deprovisioned','Reserved')"+
+			" and (id = 'x'' or ''1''=''1' or display_name = 'x'' or ''1''=''1')",
+		amsclient.GenerateReferenceSearchParameter("org", "x' or '1'='1"),
+	)
+}
+
+// TestGenerateClusterListSearchParameters checks that the clusters are sliced
+// into search queries of bounded length and quotes can't change the queries
+func TestGenerateClusterListSearchParameters(t *testing.T) {
+	clusterIDs := []types.ClusterName{"a", "b", "c'"}
+	prefix := "organization_id is 'org' and external_cluster_id in ("
+
+	assert.Equal(t,
+		[]string{prefix + "'a','b','c''')"},
+		amsclient.GenerateClusterListSearchParameters("org", clusterIDs, 1000),
+	)
+	assert.Equal(t,
+		[]string{prefix + "'a','b')", prefix + "'c''')"},
+		amsclient.GenerateClusterListSearchParameters("org", clusterIDs, len(prefix+"'a','b')")),
+	)
+	// single cluster ID longer than the limit is still requested
+	assert.Equal(t,
+		[]string{prefix + "'a')", prefix + "'b')", prefix + "'c''')"},
+		amsclient.GenerateClusterListSearchParameters("org", clusterIDs, 1),
+	)
+	assert.Empty(t, amsclient.GenerateClusterListSearchParameters("org", nil, 1000))
+}
+
+// TestGetClustersInfoForOrganizationNoClusters checks that AMS API is not
+// called when no cluster is requested
+func TestGetClustersInfoForOrganizationNoClusters(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	c, err := amsclient.NewAMSClientWithTransport(defaultConfig, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	clusterList, err := c.GetClustersInfoForOrganization(testdata.ExternalOrgID, nil)
+	helpers.FailOnError(t, err)
+	assert.Empty(t, clusterList)
+}
+
+// TestSendServiceLog checks that the entries are posted to Service Log API
+// and that unsuccessful responses are reported as errors
+func TestSendServiceLog(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	c, err := amsclient.NewAMSClientWithTransport(defaultConfig, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	entry := types.ServiceLogEntry{
+		ClusterUUID: testdata.ClusterName1,
+		Severity:    "Warning",
+		ServiceName: "Insights Advisor",
+		Summary:     "summary",
+		Description: "description",
+	}
+
+	for _, statusCode := range []int{http.StatusCreated, http.StatusBadRequest} {
+		helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+			Method:   http.MethodPost,
+			Endpoint: "api/service_logs/v1/cluster_logs",
+		}, &helpers.APIResponse{
+			StatusCode: statusCode,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: "{}",
+		})
+	}
+
+	assert.NoError(t, c.SendServiceLog(entry))
+	assert.Error(t, c.SendServiceLog(entry))
 }
@@ -14,11 +14,17 @@
 
 package amsclient
 
+import "time"
+
 // Configuration represents the configuration of the AMS API client
 type Configuration struct {
-	Token        string `mapstructure:"token" toml:"token"`
-	ClientID     string `mapstructure:"client_id" toml:"client_id"`
-	ClientSecret string `mapstructure:"client_secret" toml:"client_secret"`
-	URL          string `mapstructure:"url" toml:"url"`
-	PageSize     int    `mapstructure:"page_size" toml:"page_size"`
+	Token              string        `mapstructure:"token" toml:"token"`
+	ClientID           string        `mapstructure:"client_id" toml:"client_id"`
+	ClientSecret       string        `mapstructure:"client_secret" toml:"client_secret"`
+	URL                string        `mapstructure:"url" toml:"url"`
+	PageSize           int           `mapstructure:"page_size" toml:"page_size"`
+	MaxConcurrentPages int           `mapstructure:"max_concurrent_pages" toml:"max_concurrent_pages"`
+	Timeout            time.Duration `mapstructure:"timeout" toml:"timeout"`
+	RetryLimit         int           `mapstructure:"retry_limit" toml:"retry_limit"`
+	RetryInterval      time.Duration `mapstructure:"retry_interval" toml:"retry_interval"`
 }
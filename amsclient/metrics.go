@@ -0,0 +1,92 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// operation labels of AMS API calls
+	operationOrganizations = "organizations"
+	operationSubscriptions = "subscriptions"
+	operationServiceLogs   = "service_logs"
+
+	// error type labels
+	errorTypeTimeout      = "timeout"
+	errorTypeUnauthorized = "unauthorized"
+	errorTypeRateLimited  = "rate_limited"
+	errorTypeServerError  = "server_error"
+	errorTypeClientError  = "client_error"
+	errorTypeConnection   = "connection"
+
+	// CacheHit is the result label used for cache lookups that found the item
+	CacheHit = "hit"
+	// CacheMiss is the result label used for cache lookups that didn't find
+	// the item
+	CacheMiss = "miss"
+)
+
+var (
+	// RequestDuration measures duration of calls to the AMS API
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "ams_request_duration_seconds",
+		Help: "Duration of requests sent to AMS API",
+	}, []string{"operation"})
+
+	// SubscriptionPagesFetched measures number of subscription pages
+	// fetched to read the list of clusters
+	SubscriptionPagesFetched = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ams_subscription_pages_fetched",
+		Help:    "Number of subscription pages fetched from AMS API to read list of clusters",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+	})
+
+	// RequestErrors counts failed calls to the AMS API by type of error
+	RequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ams_request_errors_total",
+		Help: "The total number of failed requests sent to AMS API",
+	}, []string{"operation", "type"})
+
+	// CacheLookups counts lookups into caches of data retrieved from AMS
+	// API, so hit ratio can be computed
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ams_cache_lookups_total",
+		Help: "The total number of lookups into caches of data retrieved from AMS API",
+	}, []string{"cache", "result"})
+)
+
+// errorType returns label describing the reason of failed AMS API call
+func errorType(status int, err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return errorTypeUnauthorized
+	case status == http.StatusTooManyRequests:
+		return errorTypeRateLimited
+	case status >= http.StatusInternalServerError:
+		return errorTypeServerError
+	case status >= http.StatusBadRequest:
+		return errorTypeClientError
+	default:
+		return errorTypeConnection
+	}
+}
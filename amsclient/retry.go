@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultRetryInterval is the delay before the first retry used when
+	// it is not defined in the configuration
+	defaultRetryInterval = time.Second
+
+	// maxRetryDelay is the upper limit of delay between two retries, it
+	// applies to delays requested by the API in Retry-After header too
+	maxRetryDelay = time.Minute
+
+	// idempotencyKeyHeader marks requests of non-idempotent methods that
+	// can be repeated safely
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// retryTransport retries requests that failed with 5xx or 429 status code
+// using exponential backoff. Delay requested by the Retry-After header is
+// honored. Only idempotent requests are retried.
+type retryTransport struct {
+	wrapped  http.RoundTripper
+	limit    int
+	interval time.Duration
+}
+
+// newRetryTransport wraps the transport so failed requests are retried at
+// most limit times
+func newRetryTransport(wrapped http.RoundTripper, limit int, interval time.Duration) http.RoundTripper {
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	return &retryTransport{
+		wrapped:  wrapped,
+		limit:    limit,
+		interval: interval,
+	}
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (t *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	retryable := isIdempotentRequest(request)
+	attemptRequest := request
+
+	for attempt := 0; ; attempt++ {
+		response, err := t.wrapped.RoundTrip(attemptRequest)
+		if err != nil || !retryable || !isRetryableStatus(response.StatusCode) || attempt >= t.limit {
+			return response, err
+		}
+
+		// the request of the caller must not be modified, so every retry
+		// is sent as its clone with fresh copy of the body
+		attemptRequest = request.Clone(request.Context())
+		if request.Body != nil && request.Body != http.NoBody {
+			if request.GetBody == nil {
+				return response, nil
+			}
+			body, bodyErr := request.GetBody()
+			if bodyErr != nil {
+				return response, nil
+			}
+			attemptRequest.Body = body
+		}
+
+		delay := retryDelay(response.Header.Get("Retry-After"), t.interval, attempt, time.Now())
+		log.Warn().
+			Str("url", request.URL.Path).
+			Int("status", response.StatusCode).
+			Int("attempt", attempt+1).
+			Msgf("AMS API request failed, retrying in %s", delay)
+
+		// the response is not returned, so it needs to be closed here
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+
+		select {
+		case <-time.After(delay):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+}
+
+// isIdempotentRequest returns true for requests that can be repeated without
+// side effects. Requests of other methods, like sending service log, are
+// repeated only when they carry the Idempotency-Key header.
+func isIdempotentRequest(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return request.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// isRetryableStatus returns true for status codes that indicate transient
+// failure of the AMS API
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// retryDelay returns the delay before next attempt. The value of Retry-After
+// header (number of seconds or HTTP date) takes precedence over exponential
+// backoff computed from the interval and the number of attempts.
+func retryDelay(retryAfter string, interval time.Duration, attempt int, now time.Time) time.Duration {
+	var delay time.Duration
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = date.Sub(now)
+	} else {
+		delay = interval
+		for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+			delay *= 2
+		}
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
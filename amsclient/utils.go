@@ -17,6 +17,8 @@ package amsclient
 import (
 	"fmt"
 	"strings"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
 
 // generateSearchParameter generates a search string for given org_id and desired statuses
@@ -36,3 +38,44 @@ func generateSearchParameter(orgID string, allowedStatuses, disallowedStatuses [
 	return searchQuery
 
 }
+
+// generateReferenceSearchParameter generates a search string for clusters of
+// given org_id whose subscription ID or display name equals the reference.
+// Quotes in the reference are doubled, so it can't change the query.
+func generateReferenceSearchParameter(orgID, reference string) string {
+	reference = strings.ReplaceAll(reference, "'", "''")
+	return generateSearchParameter(orgID, nil, DefaultStatusNegativeFilters) +
+		fmt.Sprintf(" and (id = '%s' or display_name = '%s')", reference, reference)
+}
+
+// generateClusterListSearchParameters generates search strings for the given
+// clusters of the org_id. The clusters are sliced, so none of the search
+// strings exceeds the maximal length unless a single cluster ID does.
+func generateClusterListSearchParameters(orgID string, clusterIDs []types.ClusterName, maxLength int) []string {
+	prefix := fmt.Sprintf("organization_id is '%s' and external_cluster_id in (", orgID)
+	const suffix = ")"
+
+	var searchQueries []string
+	var quotedIDs []string
+	length := len(prefix) + len(suffix)
+	for _, clusterID := range clusterIDs {
+		quotedID := "'" + strings.ReplaceAll(string(clusterID), "'", "''") + "'"
+		// separator is needed in front of all IDs but the first one
+		added := len(quotedID)
+		if len(quotedIDs) > 0 {
+			added++
+		}
+		if len(quotedIDs) > 0 && length+added > maxLength {
+			searchQueries = append(searchQueries, prefix+strings.Join(quotedIDs, ",")+suffix)
+			quotedIDs = nil
+			length = len(prefix) + len(suffix)
+			added = len(quotedID)
+		}
+		quotedIDs = append(quotedIDs, quotedID)
+		length += added
+	}
+	if len(quotedIDs) > 0 {
+		searchQueries = append(searchQueries, prefix+strings.Join(quotedIDs, ",")+suffix)
+	}
+	return searchQueries
+}
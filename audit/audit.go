@@ -0,0 +1,107 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit contains the interface used to publish audit events about
+// actions of users (viewed cluster reports, disabled and enabled rules) and
+// its implementation based on Kafka. It also contains the store of audit log
+// records describing all mutating operations made by users.
+package audit
+
+import (
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// Types of audit events
+const (
+	// ReportViewed is published when the user reads the cluster report
+	ReportViewed = "report_viewed"
+	// RuleDisabled is published when the user disables the rule for
+	// single cluster or acknowledges it for all clusters
+	RuleDisabled = "rule_disabled"
+	// RuleEnabled is published when the user enables the rule for single
+	// cluster or deletes its acknowledgement
+	RuleEnabled = "rule_enabled"
+)
+
+// EventsDropped counts the events that could not be published
+var EventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "audit_events_dropped",
+	Help: "The total number of audit events that could not be published",
+})
+
+// Event describes the action of the user. Cluster is empty for actions
+// affecting all clusters of the organization.
+type Event struct {
+	Type      string             `json:"type"`
+	Timestamp time.Time          `json:"timestamp"`
+	OrgID     ctypes.OrgID       `json:"org_id"`
+	UserID    ctypes.UserID      `json:"user_id"`
+	ClusterID ctypes.ClusterName `json:"cluster_id,omitempty"`
+	RuleID    ctypes.RuleID      `json:"rule_id,omitempty"`
+	ErrorKey  ctypes.ErrorKey    `json:"error_key,omitempty"`
+	Path      string             `json:"path"`
+}
+
+// Publisher publishes audit events. Publishing must not block the caller,
+// so events are dropped rather than delayed when they can't be sent. All
+// implementations are safe for concurrent use.
+type Publisher interface {
+	// Publish sends the event
+	Publish(event *Event)
+	// Close sends the pending events and releases resources
+	Close() error
+}
+
+// Configuration represents configuration of audit events publishing
+type Configuration struct {
+	// Enabled turns the publishing on
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// Brokers is the list of addresses of Kafka brokers
+	Brokers []string `mapstructure:"brokers" toml:"brokers"`
+	// Topic is the Kafka topic the events are published to
+	Topic string `mapstructure:"topic" toml:"topic"`
+	// LogStore is the type of audit log store, the audit log is disabled
+	// when it is empty
+	LogStore string `mapstructure:"log_store" toml:"log_store"`
+	// LogFile is the path to the file with the audit log when file store
+	// is used
+	LogFile string `mapstructure:"log_file" toml:"log_file"`
+}
+
+// NoopPublisher drops all events, it is used when publishing is disabled
+type NoopPublisher struct{}
+
+// Publish implements Publisher interface
+func (NoopPublisher) Publish(*Event) {}
+
+// Close implements Publisher interface
+func (NoopPublisher) Close() error {
+	return nil
+}
+
+// New constructs the publisher according to the configuration. Events are
+// dropped when publishing is disabled.
+func New(config Configuration) (Publisher, error) {
+	if !config.Enabled {
+		log.Info().Msg("Publishing of audit events is disabled")
+		return NoopPublisher{}, nil
+	}
+
+	return NewKafkaPublisher(config.Brokers, config.Topic)
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+)
+
+func TestNewDisabled(t *testing.T) {
+	publisher, err := audit.New(audit.Configuration{Enabled: false, Topic: "audit"})
+	assert.NoError(t, err)
+	assert.IsType(t, audit.NoopPublisher{}, publisher)
+	publisher.Publish(&audit.Event{Type: audit.ReportViewed})
+	assert.NoError(t, publisher.Close())
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	event := audit.Event{
+		Type:      audit.RuleDisabled,
+		Timestamp: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+		OrgID:     testdata.OrgID,
+		UserID:    testdata.UserID,
+		ClusterID: testdata.ClusterName,
+		RuleID:    testdata.Rule1ID,
+		ErrorKey:  testdata.ErrorKey1,
+		Path:      "/api/v1/clusters",
+	}
+
+	producer := mocks.NewAsyncProducer(t, nil)
+	producer.ExpectInputWithCheckerFunctionAndSucceed(func(value []byte) error {
+		var published audit.Event
+		if err := json.Unmarshal(value, &published); err != nil {
+			return err
+		}
+		assert.Equal(t, event, published)
+		return nil
+	})
+
+	publisher := audit.NewKafkaPublisherWithProducer(producer, "audit")
+	publisher.Publish(&event)
+	assert.NoError(t, publisher.Close())
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/rs/zerolog/log"
+)
+
+// KafkaPublisher publishes events to Kafka topic asynchronously
+type KafkaPublisher struct {
+	producer sarama.AsyncProducer
+	topic    string
+}
+
+// NewKafkaPublisher constructs the publisher connected to given brokers
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Errors = true
+
+	producer, err := sarama.NewAsyncProducer(brokers, config)
+	if err != nil {
+		log.Error().Err(err).Strs("brokers", brokers).Msg("Unable to create Kafka producer of audit events")
+		return nil, err
+	}
+
+	log.Info().Strs("brokers", brokers).Str("topic", topic).Msg("Audit events are published to Kafka")
+	return NewKafkaPublisherWithProducer(producer, topic), nil
+}
+
+// NewKafkaPublisherWithProducer constructs the publisher using given
+// producer. Errors of the producer need to be returned.
+func NewKafkaPublisherWithProducer(producer sarama.AsyncProducer, topic string) *KafkaPublisher {
+	go func() {
+		for err := range producer.Errors() {
+			EventsDropped.Inc()
+			log.Error().Err(err.Err).Msg("Unable to publish audit event")
+		}
+	}()
+
+	return &KafkaPublisher{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+// Publish implements Publisher interface. Events are keyed by organization,
+// so events of one organization keep their order.
+func (publisher *KafkaPublisher) Publish(event *Event) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		EventsDropped.Inc()
+		log.Error().Err(err).Msg("Unable to serialize audit event")
+		return
+	}
+
+	message := &sarama.ProducerMessage{
+		Topic: publisher.topic,
+		Key:   sarama.StringEncoder(fmt.Sprint(event.OrgID)),
+		Value: sarama.ByteEncoder(value),
+	}
+
+	select {
+	case publisher.producer.Input() <- message:
+	default:
+		EventsDropped.Inc()
+		log.Error().Str("type", event.Type).Msg("Audit event dropped, Kafka producer is busy")
+	}
+}
+
+// Close implements Publisher interface
+func (publisher *KafkaPublisher) Close() error {
+	return publisher.producer.Close()
+}
@@ -0,0 +1,192 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+)
+
+// Supported types of audit log stores
+const (
+	// LogStoreNone disables the audit log
+	LogStoreNone = ""
+	// LogStoreFile keeps the audit log in local file, one JSON record per
+	// line
+	LogStoreFile = "file"
+)
+
+// MaxPayloadSize is the maximum size of request payload stored in the record.
+// Bigger payloads are not stored.
+const MaxPayloadSize = 64 * 1024
+
+// Record describes single mutating operation made by the user
+type Record struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	OrgID      ctypes.OrgID      `json:"org_id"`
+	UserID     ctypes.UserID     `json:"user_id"`
+	Operation  string            `json:"operation"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Params     map[string]string `json:"params,omitempty"`
+	Payload    json.RawMessage   `json:"payload,omitempty"`
+	StatusCode int               `json:"status_code"`
+}
+
+// Query selects the records of the organization made in the time range.
+// Zero From and To are not checked. At most Limit records are returned,
+// zero Limit means no limit.
+type Query struct {
+	OrgID ctypes.OrgID
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// matches returns true if the record is selected by the query
+func (query *Query) matches(record *Record) bool {
+	if record.OrgID != query.OrgID {
+		return false
+	}
+	if !query.From.IsZero() && record.Timestamp.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && !record.Timestamp.Before(query.To) {
+		return false
+	}
+	return true
+}
+
+// Store persists the audit log records. All implementations are safe for
+// concurrent use.
+type Store interface {
+	// Append stores the record
+	Append(record *Record) error
+	// Query returns the records selected by the query ordered by their
+	// timestamps
+	Query(query Query) ([]Record, error)
+}
+
+// FileStore keeps the records in file, one JSON record per line. Records are
+// only appended to the file, so the file needs to be rotated externally.
+type FileStore struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// NewFileStore constructs the store writing to the file. The file is created
+// if it does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	// path is set in configuration, not by the client
+	// #nosec G304
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{path: path}, nil
+}
+
+// Append implements Store interface
+func (store *FileStore) Append(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// #nosec G304
+	file, err := os.OpenFile(store.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Query implements Store interface. Records are appended in the order they
+// were made, so the whole file is scanned.
+func (store *FileStore) Query(query Query) ([]Record, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// #nosec G304
+	file, err := os.Open(store.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error().Err(err).Str("file", store.path).Msg("Unable to close audit log")
+		}
+	}()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(file)
+	// payloads are limited, but the default buffer is too small for them
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 2*MaxPayloadSize)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("malformed audit log record: %v", err)
+		}
+		if !query.matches(&record) {
+			continue
+		}
+		records = append(records, record)
+		if query.Limit > 0 && len(records) >= query.Limit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// NewStore constructs the audit log store according to the configuration.
+// Nil is returned when the audit log is disabled.
+func NewStore(config Configuration) (Store, error) {
+	switch config.LogStore {
+	case LogStoreNone:
+		log.Info().Msg("Audit log is disabled")
+		return nil, nil
+	case LogStoreFile:
+		store, err := NewFileStore(config.LogFile)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown audit log store: %s", config.LogStore)
+	}
+}
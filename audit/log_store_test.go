@@ -0,0 +1,89 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+)
+
+func TestNewStoreDisabled(t *testing.T) {
+	store, err := audit.NewStore(audit.Configuration{})
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	_, err = audit.NewStore(audit.Configuration{LogStore: "redis"})
+	assert.Error(t, err)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := audit.NewStore(audit.Configuration{
+		LogStore: audit.LogStoreFile,
+		LogFile:  filepath.Join(dir, "audit.log"),
+	})
+	assert.NoError(t, err)
+
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []audit.Record{
+		{Timestamp: start, OrgID: testdata.OrgID, UserID: testdata.UserID, Operation: "vote"},
+		{Timestamp: start.Add(time.Hour), OrgID: 2, Operation: "ack"},
+		{
+			Timestamp:  start.Add(2 * time.Hour),
+			OrgID:      testdata.OrgID,
+			Operation:  "ack",
+			Params:     map[string]string{"rule_id": string(testdata.Rule1ID)},
+			Payload:    json.RawMessage(`{"justification":"x"}`),
+			StatusCode: 201,
+		},
+		{Timestamp: start.Add(3 * time.Hour), OrgID: testdata.OrgID, Operation: "ack_delete"},
+	}
+	for i := range records {
+		assert.NoError(t, store.Append(&records[i]))
+	}
+
+	found, err := store.Query(audit.Query{OrgID: testdata.OrgID})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Record{records[0], records[2], records[3]}, found)
+
+	// the end of the range is exclusive
+	found, err = store.Query(audit.Query{
+		OrgID: testdata.OrgID,
+		From:  start.Add(time.Hour),
+		To:    start.Add(3 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Record{records[2]}, found)
+
+	found, err = store.Query(audit.Query{OrgID: testdata.OrgID, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Record{records[0]}, found)
+
+	found, err = store.Query(audit.Query{OrgID: 12345})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache contains the interface shared by all caches of data retrieved
+// from other services, its in-memory implementation and Redis implementation
+// shared by all replicas.
+package cache
+
+import "time"
+
+// Cache is a store of values with limited lifetime. All implementations are
+// safe for concurrent use. Values are stored as they are, so callers should
+// not modify them after they are stored or retrieved.
+type Cache interface {
+	// Get returns the value stored under the key if it has not expired yet
+	Get(key string) (value interface{}, found bool)
+	// Set stores the value under the key. Zero TTL means that the default
+	// TTL of the cache is used, negative TTL means no expiration.
+	Set(key string, value interface{}, ttl time.Duration)
+	// Delete removes the value stored under the key
+	Delete(key string)
+	// TTL returns the remaining lifetime of the value stored under the key
+	TTL(key string) (ttl time.Duration, found bool)
+}
+
+// Configuration represents configuration of a cache
+type Configuration struct {
+	// Capacity is the maximal number of stored values, zero means no limit
+	Capacity int `mapstructure:"capacity" toml:"capacity"`
+	// DefaultTTL is used for values stored without explicit TTL, zero
+	// means that such values never expire
+	DefaultTTL time.Duration `mapstructure:"default_ttl" toml:"default_ttl"`
+}
+
+// New constructs the cache according to the configuration
+func New(config Configuration) Cache {
+	return NewMemoryCache(config.Capacity, config.DefaultTTL)
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a single value stored in the memory cache
+type memoryEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// expired returns true if the entry has limited lifetime which has ended
+func (entry *memoryEntry) expired(now time.Time) bool {
+	return !entry.expires.IsZero() && !now.Before(entry.expires)
+}
+
+// MemoryCache is an in-memory implementation of Cache interface. When the
+// capacity is reached, the least recently used value is evicted.
+type MemoryCache struct {
+	capacity   int
+	defaultTTL time.Duration
+
+	mutex sync.Mutex
+	// recency contains *memoryEntry, the most recently used at front
+	recency *list.List
+	entries map[string]*list.Element
+
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+// NewMemoryCache constructs new in-memory cache with given capacity (zero
+// means no limit) and TTL used for values stored without explicit TTL (zero
+// means no expiration)
+func NewMemoryCache(capacity int, defaultTTL time.Duration) *MemoryCache {
+	return &MemoryCache{
+		capacity:   capacity,
+		defaultTTL: defaultTTL,
+		recency:    list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under the key if it has not expired yet
+func (cache *MemoryCache) Get(key string) (interface{}, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element := cache.lookup(key)
+	if element == nil {
+		return nil, false
+	}
+	cache.recency.MoveToFront(element)
+	return element.Value.(*memoryEntry).value, true
+}
+
+// Set stores the value under the key. Zero TTL means that the default TTL of
+// the cache is used, negative TTL means no expiration.
+func (cache *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = cache.defaultTTL
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = cache.now().Add(ttl)
+	}
+
+	if element, found := cache.entries[key]; found {
+		element.Value = entry
+		cache.recency.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.recency.PushFront(entry)
+
+	if cache.capacity > 0 && cache.recency.Len() > cache.capacity {
+		cache.removeElement(cache.recency.Back())
+	}
+}
+
+// Delete removes the value stored under the key
+func (cache *MemoryCache) Delete(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[key]; found {
+		cache.removeElement(element)
+	}
+}
+
+// TTL returns the remaining lifetime of the value stored under the key. Zero
+// is returned for values that never expire.
+func (cache *MemoryCache) TTL(key string) (time.Duration, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element := cache.lookup(key)
+	if element == nil {
+		return 0, false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if entry.expires.IsZero() {
+		return 0, true
+	}
+	return entry.expires.Sub(cache.now()), true
+}
+
+// Len returns the number of stored values, including the expired ones that
+// have not been removed yet
+func (cache *MemoryCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.recency.Len()
+}
+
+// lookup returns the element stored under the key, expired element is
+// removed. Mutex needs to be locked by the caller.
+func (cache *MemoryCache) lookup(key string) *list.Element {
+	element, found := cache.entries[key]
+	if !found {
+		return nil
+	}
+	if element.Value.(*memoryEntry).expired(cache.now()) {
+		cache.removeElement(element)
+		return nil
+	}
+	return element
+}
+
+// removeElement removes the element from both recency list and map. Mutex
+// needs to be locked by the caller.
+func (cache *MemoryCache) removeElement(element *list.Element) {
+	cache.recency.Remove(element)
+	delete(cache.entries, element.Value.(*memoryEntry).key)
+}
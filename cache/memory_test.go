@@ -0,0 +1,129 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+)
+
+// clock returns function usable as replacement of time.Now and pointer to
+// the time it returns
+func clock() (func() time.Time, *time.Time) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, &now
+}
+
+func TestMemoryCacheGetSetDelete(t *testing.T) {
+	var c cache.Cache = cache.NewMemoryCache(0, 0)
+
+	_, found := c.Get("key")
+	assert.False(t, found)
+
+	c.Set("key", "value", 0)
+	value, found := c.Get("key")
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	c.Set("key", 42, 0)
+	value, found = c.Get("key")
+	assert.True(t, found)
+	assert.Equal(t, 42, value)
+
+	c.Delete("key")
+	_, found = c.Get("key")
+	assert.False(t, found)
+
+	// deleting missing key is no-op
+	c.Delete("key")
+}
+
+func TestMemoryCacheExpiration(t *testing.T) {
+	c := cache.NewMemoryCache(0, time.Minute)
+	now, current := clock()
+	cache.SetNow(c, now)
+
+	c.Set("default", "value", 0)
+	c.Set("explicit", "value", time.Hour)
+	c.Set("forever", "value", -1)
+
+	ttl, found := c.TTL("default")
+	assert.True(t, found)
+	assert.Equal(t, time.Minute, ttl)
+
+	ttl, found = c.TTL("explicit")
+	assert.True(t, found)
+	assert.Equal(t, time.Hour, ttl)
+
+	ttl, found = c.TTL("forever")
+	assert.True(t, found)
+	assert.Equal(t, time.Duration(0), ttl)
+
+	*current = current.Add(2 * time.Minute)
+
+	_, found = c.Get("default")
+	assert.False(t, found)
+	_, found = c.TTL("default")
+	assert.False(t, found)
+
+	ttl, found = c.TTL("explicit")
+	assert.True(t, found)
+	assert.Equal(t, 58*time.Minute, ttl)
+
+	_, found = c.Get("forever")
+	assert.True(t, found)
+
+	// expired value has been removed
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewMemoryCache(2, 0)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	// "a" becomes the most recently used one
+	_, found := c.Get("a")
+	assert.True(t, found)
+
+	c.Set("c", 3, 0)
+	assert.Equal(t, 2, c.Len())
+
+	_, found = c.Get("b")
+	assert.False(t, found)
+	_, found = c.Get("a")
+	assert.True(t, found)
+	_, found = c.Get("c")
+	assert.True(t, found)
+}
+
+func TestNew(t *testing.T) {
+	c := cache.New(cache.Configuration{Capacity: 1, DefaultTTL: time.Minute})
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	_, found := c.Get("a")
+	assert.False(t, found)
+
+	ttl, found := c.TTL("b")
+	assert.True(t, found)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+}
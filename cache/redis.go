@@ -0,0 +1,144 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+)
+
+// redisTimeout limits the time of single Redis operation, the fallback
+// cache is used when it is exceeded
+const redisTimeout = time.Second
+
+// DecodeFunc converts the JSON document stored in Redis back into the value
+type DecodeFunc func(data []byte) (interface{}, error)
+
+// RedisCache is an implementation of Cache interface keeping the values in
+// Redis, so they are shared by all replicas. Values are stored as JSON
+// documents. The fallback cache is used while Redis is not available.
+type RedisCache struct {
+	client     redis.Cmdable
+	prefix     string
+	defaultTTL time.Duration
+	decode     DecodeFunc
+	fallback   Cache
+}
+
+// NewRedisCache constructs the cache using the Redis client of the shared
+// storage. All keys are prefixed by the prefix, so more caches can use the
+// same database. Zero default TTL means that values stored without explicit
+// TTL never expire.
+func NewRedisCache(
+	client redis.Cmdable, prefix string, defaultTTL time.Duration, decode DecodeFunc, fallback Cache,
+) *RedisCache {
+	return &RedisCache{
+		client:     client,
+		prefix:     prefix,
+		defaultTTL: defaultTTL,
+		decode:     decode,
+		fallback:   fallback,
+	}
+}
+
+// Get returns the value stored under the key if it has not expired yet
+func (cache *RedisCache) Get(key string) (interface{}, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := cache.client.Get(ctx, cache.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Redis cache is not available, fallback cache is used")
+		return cache.fallback.Get(key)
+	}
+
+	value, err := cache.decode(data)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Unable to decode value stored in Redis cache")
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores the value under the key. Zero TTL means that the default TTL of
+// the cache is used, negative TTL means no expiration.
+func (cache *RedisCache) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = cache.defaultTTL
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Unable to encode value stored in Redis cache")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	// Redis client treats zero expiration as no expiration
+	expiration := ttl
+	if expiration < 0 {
+		expiration = 0
+	}
+	if err := cache.client.Set(ctx, cache.prefix+key, data, expiration).Err(); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Redis cache is not available, fallback cache is used")
+		cache.fallback.Set(key, value, ttl)
+	}
+}
+
+// Delete removes the value stored under the key from Redis and from the
+// fallback cache, which may keep it since the last outage
+func (cache *RedisCache) Delete(key string) {
+	cache.fallback.Delete(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if err := cache.client.Del(ctx, cache.prefix+key).Err(); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Unable to delete value from Redis cache")
+	}
+}
+
+// TTL returns the remaining lifetime of the value stored under the key. Zero
+// is returned for values that never expire.
+func (cache *RedisCache) TTL(key string) (time.Duration, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	ttl, err := cache.client.PTTL(ctx, cache.prefix+key).Result()
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Redis cache is not available, fallback cache is used")
+		return cache.fallback.TTL(key)
+	}
+
+	// the client returns -2 for missing key and -1 for key without
+	// expiration
+	switch ttl {
+	case -2:
+		return 0, false
+	case -1:
+		return 0, true
+	default:
+		return ttl, true
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+)
+
+// decodeString decodes values of caches storing strings
+func decodeString(data []byte) (interface{}, error) {
+	var value string
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// newRedisCache constructs the cache using the client of the shared storage
+// connected to the server, memory cache is used as fallback
+func newRedisCache(t *testing.T, server *miniredis.Miniredis) (*cache.RedisCache, *cache.MemoryCache) {
+	store := storage.NewRedisStore(storage.Configuration{Address: server.Addr()})
+	t.Cleanup(func() { _ = store.Close() })
+
+	fallback := cache.NewMemoryCache(0, 0)
+	return cache.NewRedisCache(store.Client(), "test:", time.Minute, decodeString, fallback), fallback
+}
+
+func TestRedisCacheGetSetDelete(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisCache, _ := newRedisCache(t, server)
+	var c cache.Cache = redisCache
+
+	_, found := c.Get("key")
+	assert.False(t, found)
+
+	c.Set("key", "value", 0)
+	value, found := c.Get("key")
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	// values are stored as JSON under prefixed keys
+	stored, err := server.Get("test:key")
+	assert.NoError(t, err)
+	assert.Equal(t, `"value"`, stored)
+
+	c.Delete("key")
+	_, found = c.Get("key")
+	assert.False(t, found)
+
+	// deleting missing key is no-op
+	c.Delete("key")
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	c, _ := newRedisCache(t, server)
+
+	_, found := c.TTL("key")
+	assert.False(t, found)
+
+	// default TTL
+	c.Set("key", "value", 0)
+	ttl, found := c.TTL("key")
+	assert.True(t, found)
+	assert.Equal(t, time.Minute, ttl)
+
+	c.Set("key", "value", time.Hour)
+	ttl, found = c.TTL("key")
+	assert.True(t, found)
+	assert.Equal(t, time.Hour, ttl)
+
+	// no expiration
+	c.Set("key", "value", -1)
+	ttl, found = c.TTL("key")
+	assert.True(t, found)
+	assert.Zero(t, ttl)
+
+	c.Set("key", "value", time.Second)
+	server.FastForward(2 * time.Second)
+	_, found = c.Get("key")
+	assert.False(t, found)
+}
+
+func TestRedisCacheUndecodableValue(t *testing.T) {
+	server := miniredis.RunT(t)
+	c, _ := newRedisCache(t, server)
+
+	assert.NoError(t, server.Set("test:key", "{"))
+	_, found := c.Get("key")
+	assert.False(t, found)
+}
+
+// TestRedisCacheFallback checks that the fallback cache is used while Redis
+// is not available
+func TestRedisCacheFallback(t *testing.T) {
+	server := miniredis.RunT(t)
+	c, fallback := newRedisCache(t, server)
+	server.Close()
+
+	c.Set("key", "value", time.Hour)
+	value, found := c.Get("key")
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, 1, fallback.Len())
+
+	ttl, found := c.TTL("key")
+	assert.True(t, found)
+	assert.True(t, ttl > 0 && ttl <= time.Hour)
+
+	c.Delete("key")
+	_, found = c.Get("key")
+	assert.False(t, found)
+	assert.Zero(t, fallback.Len())
+}
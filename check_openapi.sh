@@ -30,6 +30,13 @@ else
     exit 1
 fi
 
+if docker run --rm -v "${PWD}":/local/:Z openapitools/openapi-generator-cli validate -i ./local/server/api/v3/openapi.json; then
+    echo "OpenAPI spec file for API v3 is OK"
+else
+    echo "OpenAPI spec file for API v3 validation failed"
+    exit 1
+fi
+
 
 if docker run --rm -v "${PWD}":/local/:Z openapitools/openapi-generator-cli validate -i ./local/server/api/dbg/openapi.json; then
     echo "OpenAPI [DEBUG] spec file is OK"
@@ -35,16 +35,24 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
 
 	"github.com/BurntSushi/toml"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/egress"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/grpcapi"
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
 	types "github.com/RedHatInsights/insights-results-types"
@@ -64,7 +72,17 @@ const (
 
 // SetupConfiguration should only be used at startup
 type SetupConfiguration struct {
-	InternalRulesOrganizationsCSVFile string `mapstructure:"internal_rules_organizations_csv_file" toml:"internal_rules_organizations_csv_file"`
+	InternalRulesOrganizationsCSVFile string        `mapstructure:"internal_rules_organizations_csv_file" toml:"internal_rules_organizations_csv_file"`
+	ConfigWatchInterval               time.Duration `mapstructure:"config_watch_interval" toml:"config_watch_interval"`
+}
+
+// ClowderConfiguration contains names of Clowder dependencies (deployments)
+// whose endpoints replace the static URLs of upstream services when Clowder
+// is enabled. Empty name means that the static URL is kept.
+type ClowderConfiguration struct {
+	AggregatorDeployment             string `mapstructure:"aggregator_deployment" toml:"aggregator_deployment"`
+	ContentDeployment                string `mapstructure:"content_deployment" toml:"content_deployment"`
+	UpgradeRisksPredictionDeployment string `mapstructure:"upgrade_risks_prediction_deployment" toml:"upgrade_risks_prediction_deployment"`
 }
 
 // MetricsConfiguration defines configuration for metrics
@@ -74,15 +92,23 @@ type MetricsConfiguration struct {
 
 // Config has exactly the same structure as *.toml file
 var Config struct {
-	ServerConf        server.Configuration              `mapstructure:"server" toml:"server"`
-	ServicesConf      services.Configuration            `mapstructure:"services" toml:"services"`
-	SetupConf         SetupConfiguration                `mapstructure:"setup" toml:"setup"`
-	MetricsConf       MetricsConfiguration              `mapstructure:"metrics" toml:"metrics"`
-	LoggingConf       logger.LoggingConfiguration       `mapstructure:"logging" toml:"logging"`
-	CloudWatchConf    logger.CloudWatchConfiguration    `mapstructure:"cloudwatch" toml:"cloudwatch"`
-	SentryLoggingConf logger.SentryLoggingConfiguration `mapstructure:"sentry" toml:"sentry"`
-	KafkaZerologConf  logger.KafkaZerologConfiguration  `mapstructure:"kafka_zerolog" toml:"kafka_zerolog"`
-	AMSClientConf     amsclient.Configuration           `mapstructure:"amsclient" toml:"amsclient"`
+	ServerConf         server.Configuration               `mapstructure:"server" toml:"server"`
+	ServicesConf       services.Configuration             `mapstructure:"services" toml:"services"`
+	SetupConf          SetupConfiguration                 `mapstructure:"setup" toml:"setup"`
+	MetricsConf        MetricsConfiguration               `mapstructure:"metrics" toml:"metrics"`
+	LoggingConf        logger.LoggingConfiguration        `mapstructure:"logging" toml:"logging"`
+	LogSamplingConf    server.LogSamplingConfiguration    `mapstructure:"log_sampling" toml:"log_sampling"`
+	CloudWatchConf     logger.CloudWatchConfiguration     `mapstructure:"cloudwatch" toml:"cloudwatch"`
+	SentryLoggingConf  logger.SentryLoggingConfiguration  `mapstructure:"sentry" toml:"sentry"`
+	ErrorReportingConf server.ErrorReportingConfiguration `mapstructure:"error_reporting" toml:"error_reporting"`
+	KafkaZerologConf   logger.KafkaZerologConfiguration   `mapstructure:"kafka_zerolog" toml:"kafka_zerolog"`
+	AMSClientConf      amsclient.Configuration            `mapstructure:"amsclient" toml:"amsclient"`
+	EgressConf         egress.Configuration               `mapstructure:"egress" toml:"egress"`
+	FeatureFlagsConf   featureflags.Configuration         `mapstructure:"feature_flags" toml:"feature_flags"`
+	AuditConf          audit.Configuration                `mapstructure:"audit" toml:"audit"`
+	PreferencesConf    preferences.Configuration          `mapstructure:"preferences" toml:"preferences"`
+	ClowderConf        ClowderConfiguration               `mapstructure:"clowder" toml:"clowder"`
+	GRPCConf           grpcapi.Configuration              `mapstructure:"grpc" toml:"grpc"`
 }
 
 // LoadConfiguration loads configuration from defaultConfigFile, file set in
@@ -130,6 +156,9 @@ func LoadConfiguration(defaultConfigFile string) error {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix(envPrefix)
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "__"))
+	// keys that are not present in the configuration file are not known
+	// to viper, so their environment variables need to be bound explicitly
+	bindEnvVariables("", reflect.TypeOf(Config))
 
 	err = viper.Unmarshal(&Config)
 	if err != nil {
@@ -138,6 +167,7 @@ func LoadConfiguration(defaultConfigFile string) error {
 
 	if clowder.IsClowderEnabled() {
 		fmt.Println("Clowder is enabled")
+		updateConfigFromClowder(clowder.LoadedConfig)
 	} else {
 		fmt.Println("Clowder is disabled")
 	}
@@ -146,6 +176,111 @@ func LoadConfiguration(defaultConfigFile string) error {
 	return nil
 }
 
+// ConfigFileUsed returns path to the configuration file that has been loaded,
+// empty string is returned when no file has been found
+func ConfigFileUsed() string {
+	return viper.ConfigFileUsed()
+}
+
+// updateConfigFromClowder overrides the port of HTTP server and URLs of
+// upstream services by the values provided by Clowder
+func updateConfigFromClowder(clowderConfig *clowder.AppConfig) {
+	if clowderConfig == nil {
+		log.Info().Msg("Clowder configuration is not available")
+		return
+	}
+
+	if clowderConfig.WebPort != nil {
+		Config.ServerConf.Address = fmt.Sprintf(":%d", *clowderConfig.WebPort)
+	}
+
+	servicesConf := &Config.ServicesConf
+	servicesConf.AggregatorBaseEndpoint = clowderEndpointURL(
+		clowderConfig, Config.ClowderConf.AggregatorDeployment, servicesConf.AggregatorBaseEndpoint)
+	servicesConf.ContentBaseEndpoint = clowderEndpointURL(
+		clowderConfig, Config.ClowderConf.ContentDeployment, servicesConf.ContentBaseEndpoint)
+	servicesConf.UpgradeRisksPredictionEndpoint = clowderEndpointURL(
+		clowderConfig, Config.ClowderConf.UpgradeRisksPredictionDeployment, servicesConf.UpgradeRisksPredictionEndpoint)
+
+	updateAuditConfFromClowder(clowderConfig)
+}
+
+// updateAuditConfFromClowder overrides Kafka brokers and the topic of audit
+// events by the values provided by Clowder
+func updateAuditConfFromClowder(clowderConfig *clowder.AppConfig) {
+	if clowderConfig.Kafka == nil || len(clowderConfig.Kafka.Brokers) == 0 {
+		return
+	}
+
+	auditConf := &Config.AuditConf
+	brokers := make([]string, 0, len(clowderConfig.Kafka.Brokers))
+	for _, broker := range clowderConfig.Kafka.Brokers {
+		if broker.Port == nil {
+			brokers = append(brokers, broker.Hostname)
+			continue
+		}
+		brokers = append(brokers, fmt.Sprintf("%s:%d", broker.Hostname, *broker.Port))
+	}
+	auditConf.Brokers = brokers
+
+	for _, topic := range clowderConfig.Kafka.Topics {
+		if topic.RequestedName == auditConf.Topic {
+			auditConf.Topic = topic.Name
+		}
+	}
+}
+
+// clowderEndpointURL returns the configured URL with host and port replaced
+// by the ones of Clowder dependency with given name. The configured URL is
+// returned when the dependency is not found.
+func clowderEndpointURL(clowderConfig *clowder.AppConfig, deployment, configured string) string {
+	if deployment == "" {
+		return configured
+	}
+
+	for _, endpoint := range clowderConfig.Endpoints {
+		if endpoint.Name != deployment {
+			continue
+		}
+
+		endpointURL, err := url.Parse(configured)
+		if err != nil {
+			endpointURL = &url.URL{}
+		}
+		endpointURL.Scheme = "http"
+		endpointURL.Host = fmt.Sprintf("%s:%d", endpoint.Hostname, endpoint.Port)
+		return endpointURL.String()
+	}
+
+	log.Warn().Str("deployment", deployment).Msg("Clowder dependency not found, configured URL is used")
+	return configured
+}
+
+// bindEnvVariables binds environment variables to all keys of the
+// configuration structure, including keys of nested structures. Lists are
+// expected to be comma-separated in environment variables. Lists of
+// structures can be set in configuration file only.
+func bindEnvVariables(prefix string, structType reflect.Type) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := prefix + tag
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			bindEnvVariables(key+".", field.Type)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			continue
+		default:
+			// error is returned for empty key only
+			_ = viper.BindEnv(key)
+		}
+	}
+}
+
 // GetServerConfiguration returns server configuration
 func GetServerConfiguration() server.Configuration {
 	err := checkIfFileExists(Config.ServerConf.APIv1SpecFile)
@@ -158,6 +293,13 @@ func GetServerConfiguration() server.Configuration {
 		log.Fatal().Err(err).Msg("API V2: All customer facing APIs MUST serve the current OpenAPI specification")
 	}
 
+	if Config.ServerConf.APIv3Prefix != "" {
+		err = checkIfFileExists(Config.ServerConf.APIv3SpecFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("API V3: All customer facing APIs MUST serve the current OpenAPI specification")
+		}
+	}
+
 	Config.ServerConf.InternalRulesOrganizations = getInternalRulesOrganizations()
 
 	return Config.ServerConf
@@ -184,6 +326,12 @@ func GetLoggingConfiguration() logger.LoggingConfiguration {
 	return Config.LoggingConf
 }
 
+// GetLogSamplingConfiguration returns configuration of log sampling for
+// noisy log messages
+func GetLogSamplingConfiguration() server.LogSamplingConfiguration {
+	return Config.LogSamplingConf
+}
+
 // GetCloudWatchConfiguration returns cloudwatch configuration
 func GetCloudWatchConfiguration() logger.CloudWatchConfiguration {
 	return Config.CloudWatchConf
@@ -194,6 +342,12 @@ func GetSentryLoggingConfiguration() logger.SentryLoggingConfiguration {
 	return Config.SentryLoggingConf
 }
 
+// GetErrorReportingConfiguration returns configuration of reporting server
+// errors to Sentry
+func GetErrorReportingConfiguration() server.ErrorReportingConfiguration {
+	return Config.ErrorReportingConf
+}
+
 // GetKafkaZerologConfiguration returns the kafkazero log configuration
 func GetKafkaZerologConfiguration() logger.KafkaZerologConfiguration {
 	return Config.KafkaZerologConf
@@ -204,6 +358,33 @@ func GetAMSClientConfiguration() amsclient.Configuration {
 	return Config.AMSClientConf
 }
 
+// GetEgressConfiguration returns configuration of connections to upstream
+// services
+func GetEgressConfiguration() egress.Configuration {
+	return Config.EgressConf
+}
+
+// GetFeatureFlagsConfiguration returns configuration of feature flags
+func GetFeatureFlagsConfiguration() featureflags.Configuration {
+	return Config.FeatureFlagsConf
+}
+
+// GetAuditConfiguration returns configuration of audit events publishing
+func GetAuditConfiguration() audit.Configuration {
+	return Config.AuditConf
+}
+
+// GetPreferencesConfiguration returns configuration of the store of user
+// preferences
+func GetPreferencesConfiguration() preferences.Configuration {
+	return Config.PreferencesConf
+}
+
+// GetGRPCConfiguration returns configuration of the gRPC server
+func GetGRPCConfiguration() grpcapi.Configuration {
+	return Config.GRPCConf
+}
+
 // checkIfFileExists returns nil if path doesn't exist or isn't a file,
 // otherwise it returns corresponding error
 func checkIfFileExists(path string) error {
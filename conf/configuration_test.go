@@ -24,10 +24,12 @@ import (
 
 	"github.com/RedHatInsights/insights-operator-utils/tests/helpers"
 	types "github.com/RedHatInsights/insights-results-types"
+	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/conf"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
 )
@@ -126,6 +128,54 @@ func TestLoadConfigurationFromFile(t *testing.T) {
 	}, conf.GetServerConfiguration())
 }
 
+// TestLoadLogSamplingConfiguration tests loading the log sampling
+// configuration sub-tree
+func TestLoadLogSamplingConfiguration(t *testing.T) {
+	config := `[log_sampling]
+		proxied_requests = 10
+		rule_hits = 5
+	`
+
+	tmpFilename, err := GetTmpConfigFile(config)
+	helpers.FailOnError(t, err)
+
+	defer removeFile(t, tmpFilename)
+
+	os.Clearenv()
+	mustSetEnv(t, conf.ConfigFileEnvVariableName, tmpFilename)
+	mustLoadConfiguration("../tests/config1")
+
+	assert.Equal(t, server.LogSamplingConfiguration{
+		ProxiedRequests: 10,
+		RuleHits:        5,
+	}, conf.GetLogSamplingConfiguration())
+}
+
+// TestLoadFeatureFlagsConfiguration tests loading the feature flags
+// configuration sub-tree
+func TestLoadFeatureFlagsConfiguration(t *testing.T) {
+	config := `[feature_flags]
+		disabled = ["acks", "dvo_workloads"]
+		unleash_url = "http://localhost:4242/api/"
+		unleash_refresh_interval = "30s"
+	`
+
+	tmpFilename, err := GetTmpConfigFile(config)
+	helpers.FailOnError(t, err)
+
+	defer removeFile(t, tmpFilename)
+
+	os.Clearenv()
+	mustSetEnv(t, conf.ConfigFileEnvVariableName, tmpFilename)
+	mustLoadConfiguration("../tests/config1")
+
+	assert.Equal(t, featureflags.Configuration{
+		Disabled:               []string{"acks", "dvo_workloads"},
+		UnleashURL:             "http://localhost:4242/api/",
+		UnleashRefreshInterval: 30 * time.Second,
+	}, conf.GetFeatureFlagsConfiguration())
+}
+
 // TestGetInternalRulesOrganizations tests if the internal organizations CSV file gets loaded properly
 func TestGetInternalRulesOrganizations(t *testing.T) {
 	os.Clearenv()
@@ -212,6 +262,37 @@ func TestLoadConfigurationFromEnv(t *testing.T) {
 	}, conf.GetServicesConfiguration())
 }
 
+// TestLoadConfigurationFromEnvKeysNotInFile tests that environment variables
+// override keys that are not present in the configuration file, including
+// keys of nested structures
+func TestLoadConfigurationFromEnvKeysNotInFile(t *testing.T) {
+	config := `[server]
+		address = ":8080"
+	`
+
+	tmpFilename, err := GetTmpConfigFile(config)
+	helpers.FailOnError(t, err)
+
+	defer removeFile(t, tmpFilename)
+
+	os.Clearenv()
+	mustSetEnv(t, conf.ConfigFileEnvVariableName, tmpFilename)
+	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__SERVER__ORG_ACCESS__DENYLIST", "1,2")
+	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__SERVER__MAINTENANCE__RETRY_AFTER", "5m")
+	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__SERVICES__AGGREGATOR", "http://aggregator:8080/api/v1/")
+	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__AMSCLIENT__PAGE_SIZE", "50")
+	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__FEATURE_FLAGS__DISABLED", "acks,dvo_workloads")
+	mustLoadConfiguration("../tests/config1")
+
+	serverConfig := conf.Config.ServerConf
+	assert.Equal(t, ":8080", serverConfig.Address)
+	assert.Equal(t, []types.OrgID{1, 2}, serverConfig.OrgAccess.Denylist)
+	assert.Equal(t, 5*time.Minute, serverConfig.Maintenance.RetryAfter)
+	assert.Equal(t, "http://aggregator:8080/api/v1/", conf.GetServicesConfiguration().AggregatorBaseEndpoint)
+	assert.Equal(t, 50, conf.GetAMSClientConfiguration().PageSize)
+	assert.Equal(t, []string{"acks", "dvo_workloads"}, conf.GetFeatureFlagsConfiguration().Disabled)
+}
+
 // TestLoadConfigurationFromEnvVariableClowderEnabledNotSupported tests loading.
 // the config file for testing from an environment variable. Clowder config is
 // available but clowder is not supported in this environment.
@@ -234,6 +315,55 @@ func TestLoadConfigurationFromEnvVariableClowderEnabledAndSupported(t *testing.T
 	mustLoadConfiguration("CCX_NOTIFICATION_SERVICE_CONFIG_FILE")
 }
 
+// TestLoadConfigurationFromClowder tests that the web port and URLs of
+// dependencies provided by Clowder override the configured ones
+func TestLoadConfigurationFromClowder(t *testing.T) {
+	config := `[server]
+		address = ":8080"
+
+		[services]
+		aggregator = "http://localhost:8080/api/v1/"
+		content = "http://localhost:8082/api/v1/"
+
+		[clowder]
+		aggregator_deployment = "ccx-insights-results-aggregator"
+		content_deployment = "ccx-insights-content-service"
+	`
+
+	tmpFilename, err := GetTmpConfigFile(config)
+	helpers.FailOnError(t, err)
+
+	defer removeFile(t, tmpFilename)
+
+	webPort := 9000
+	originalClowderConfig := clowder.LoadedConfig
+	defer func() {
+		clowder.LoadedConfig = originalClowderConfig
+	}()
+	clowder.LoadedConfig = &clowder.AppConfig{
+		WebPort: &webPort,
+		Endpoints: []clowder.DependencyEndpoint{
+			{
+				App:      "ccx-insights-results",
+				Name:     "ccx-insights-results-aggregator",
+				Hostname: "aggregator-service",
+				Port:     8000,
+			},
+		},
+	}
+
+	os.Clearenv()
+	mustSetEnv(t, conf.ConfigFileEnvVariableName, tmpFilename)
+	// Clowder is considered enabled when ACG_CONFIG is set
+	mustSetEnv(t, "ACG_CONFIG", "tests/clowder_config.json")
+	mustLoadConfiguration("../tests/config1")
+
+	assert.Equal(t, ":9000", conf.Config.ServerConf.Address)
+	// content service is not among the dependencies, configured URL is kept
+	assert.Equal(t, "http://aggregator-service:8000/api/v1/", conf.GetServicesConfiguration().AggregatorBaseEndpoint)
+	assert.Equal(t, "http://localhost:8082/api/v1/", conf.GetServicesConfiguration().ContentBaseEndpoint)
+}
+
 func setEnvVariables(t *testing.T) {
 	os.Clearenv()
 
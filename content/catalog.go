@@ -0,0 +1,91 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"sort"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// GetAllRulesWithContent returns all rules with error keys keyed by the
+// composite rule ID ("| format")
+func (s *RulesWithContentStorage) GetAllRulesWithContent() map[ctypes.RuleID]*types.RuleWithContent {
+	s.RLock()
+	defer s.RUnlock()
+
+	rules := make(map[ctypes.RuleID]*types.RuleWithContent, len(s.recommendationsWithContent))
+	for ruleID, ruleWithContent := range s.recommendationsWithContent {
+		rules[ruleID] = ruleWithContent
+	}
+	return rules
+}
+
+// ruleGroupNames returns names of groups sharing at least one tag with the
+// rule
+func ruleGroupNames(tags []string, ruleGroups []groups.Group) []string {
+	names := []string{}
+	for _, group := range ruleGroups {
+		for _, tag := range group.Tags {
+			if collections.StringInSlice(tag, tags) {
+				names = append(names, group.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// GetRuleCatalog returns all loaded rules with error keys together with their
+// metadata and the names of groups they belong to. Rules are sorted by the
+// composite rule ID.
+func GetRuleCatalog(ruleGroups []groups.Group) ([]types.RuleCatalogEntry, error) {
+	err := WaitForContentDirectoryToBeReady()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules := rulesWithContentStorage.GetAllRulesWithContent()
+	catalog := make([]types.RuleCatalogEntry, 0, len(rules))
+	for ruleID, ruleWithContent := range rules {
+		tags := ruleWithContent.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		catalog = append(catalog, types.RuleCatalogEntry{
+			RuleID:      ruleID,
+			Description: ruleWithContent.Description,
+			TotalRisk:   ruleWithContent.TotalRisk,
+			Impact:      ruleWithContent.Impact,
+			Likelihood:  ruleWithContent.Likelihood,
+			PublishDate: ruleWithContent.PublishDate,
+			Active:      ruleWithContent.Active,
+			Internal:    ruleWithContent.Internal,
+			OSDCustomer: ruleWithContent.OSDCustomer,
+			Tags:        tags,
+			Groups:      ruleGroupNames(tags, ruleGroups),
+		})
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].RuleID < catalog[j].RuleID
+	})
+	return catalog, nil
+}
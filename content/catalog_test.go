@@ -0,0 +1,64 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestGetRuleCatalog checks that all rules are returned sorted, together with
+// the groups sharing their tags
+func TestGetRuleCatalog(t *testing.T) {
+	defer content.ResetContent()
+	content.SetRuleContentDirectory(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+
+	ruleWithContent, err := content.GetContentForRecommendation(testdata.Rule1CompositeID)
+	helpers.FailOnError(t, err)
+
+	ruleGroups := []groups.Group{
+		{Name: "Rule 1 group", Tags: ruleWithContent.Tags},
+		{Name: "Empty group", Tags: []string{}},
+	}
+	catalog, err := content.GetRuleCatalog(ruleGroups)
+	helpers.FailOnError(t, err)
+
+	assert.NotEmpty(t, catalog)
+	assert.True(t, sort.SliceIsSorted(catalog, func(i, j int) bool {
+		return catalog[i].RuleID < catalog[j].RuleID
+	}))
+
+	found := false
+	for _, rule := range catalog {
+		assert.NotContains(t, rule.Groups, "Empty group")
+		if rule.RuleID != testdata.Rule1CompositeID {
+			continue
+		}
+		found = true
+		assert.Equal(t, ruleWithContent.Description, rule.Description)
+		assert.Equal(t, ruleWithContent.TotalRisk, rule.TotalRisk)
+		assert.Equal(t, ruleWithContent.Active, rule.Active)
+		assert.Equal(t, []string{"Rule 1 group"}, rule.Groups)
+	}
+	assert.True(t, found)
+}
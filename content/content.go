@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/RedHatInsights/insights-operator-utils/generators"
@@ -27,6 +28,7 @@ import (
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog/log"
 
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
@@ -35,13 +37,18 @@ var (
 	ruleContentDirectory      *ctypes.RuleContentDirectory
 	ruleContentDirectoryReady = sync.NewCond(&sync.Mutex{})
 	stopUpdateContentLoop     = make(chan struct{})
+	updateContentMutex        = sync.Mutex{}
 	rulesWithContentStorage   = RulesWithContentStorage{
 		rules:                      map[ctypes.RuleID]*ctypes.RuleContent{},
 		rulesWithContent:           map[ruleIDAndErrorKey]*types.RuleWithContent{},
 		recommendationsWithContent: map[ctypes.RuleID]*types.RuleWithContent{},
+		osdEligible:                map[ruleIDAndErrorKey]bool{},
 	}
 	contentDirectoryTimeout = 5 * time.Second
 	dotReport               = ".report"
+	// contentCacheSize is the number of rules with error keys kept in the
+	// cache of rule content lookups, zero disables the cache
+	contentCacheSize = 0
 )
 
 type ruleIDAndErrorKey struct {
@@ -60,6 +67,15 @@ type RulesWithContentStorage struct {
 	recommendationsWithContent map[ctypes.RuleID]*types.RuleWithContent
 	internalRuleIDs            []ctypes.RuleID
 	externalRuleIDs            []ctypes.RuleID
+	// osdEligible is the index of rules with error keys that are eligible
+	// for OSD (managed) clusters, i.e. have osd_customer tag. It is built
+	// when the content is loaded.
+	osdEligible        map[ruleIDAndErrorKey]bool
+	osdEligibleRuleIDs []ctypes.RuleID
+	// contentCache contains *cache.MemoryCache with the most recently
+	// looked up rules with content. It is replaced together with the
+	// content, so it never returns outdated content.
+	contentCache atomic.Value
 }
 
 // SetRuleContentDirectory is made for easy testing fake rules etc. from other directories
@@ -132,10 +148,16 @@ func (s *RulesWithContentStorage) SetRuleWithContent(
 	s.Lock()
 	defer s.Unlock()
 
-	s.rulesWithContent[ruleIDAndErrorKey{
+	key := ruleIDAndErrorKey{
 		RuleID:   ruleID,
 		ErrorKey: errorKey,
-	}] = ruleWithContent
+	}
+	s.rulesWithContent[key] = ruleWithContent
+
+	s.osdEligible[key] = ruleWithContent.OSDCustomer
+	if ruleWithContent.OSDCustomer {
+		s.osdEligibleRuleIDs = append(s.osdEligibleRuleIDs, compositeRuleID)
+	}
 
 	if ruleWithContent.Internal {
 		s.internalRuleIDs = append(s.internalRuleIDs, compositeRuleID)
@@ -156,14 +178,52 @@ func (s *RulesWithContentStorage) SetRule(
 
 // ResetContent clear all the contents
 func (s *RulesWithContentStorage) ResetContent() {
+	s.replace(newRulesWithContentStorage())
+}
+
+// newRulesWithContentStorage constructs new empty storage
+func newRulesWithContentStorage() *RulesWithContentStorage {
+	return &RulesWithContentStorage{
+		rules:                      make(map[ctypes.RuleID]*ctypes.RuleContent),
+		rulesWithContent:           make(map[ruleIDAndErrorKey]*types.RuleWithContent),
+		recommendationsWithContent: make(map[ctypes.RuleID]*types.RuleWithContent),
+		internalRuleIDs:            make([]ctypes.RuleID, 0),
+		externalRuleIDs:            make([]ctypes.RuleID, 0),
+		osdEligible:                make(map[ruleIDAndErrorKey]bool),
+		osdEligibleRuleIDs:         make([]ctypes.RuleID, 0),
+	}
+}
+
+// replace swaps all the contents by the contents of other storage. The other
+// storage must not be modified afterwards.
+func (s *RulesWithContentStorage) replace(other *RulesWithContentStorage) {
 	s.Lock()
 	defer s.Unlock()
 
-	s.rules = make(map[ctypes.RuleID]*ctypes.RuleContent)
-	s.rulesWithContent = make(map[ruleIDAndErrorKey]*types.RuleWithContent)
-	s.recommendationsWithContent = make(map[ctypes.RuleID]*types.RuleWithContent)
-	s.internalRuleIDs = make([]ctypes.RuleID, 0)
-	s.externalRuleIDs = make([]ctypes.RuleID, 0)
+	s.rules = other.rules
+	s.rulesWithContent = other.rulesWithContent
+	s.recommendationsWithContent = other.recommendationsWithContent
+	s.internalRuleIDs = other.internalRuleIDs
+	s.externalRuleIDs = other.externalRuleIDs
+	s.osdEligible = other.osdEligible
+	s.osdEligibleRuleIDs = other.osdEligibleRuleIDs
+	s.contentCache.Store(newContentCache())
+}
+
+// newContentCache constructs the LRU cache of rule content lookups, nil is
+// returned when the cache is disabled
+func newContentCache() *cache.MemoryCache {
+	if contentCacheSize <= 0 {
+		return nil
+	}
+	return cache.NewMemoryCache(contentCacheSize, 0)
+}
+
+// getContentCache returns the cache of rule content lookups matching the
+// current content, nil is returned when the cache is disabled
+func (s *RulesWithContentStorage) getContentCache() *cache.MemoryCache {
+	contentCache, _ := s.contentCache.Load().(*cache.MemoryCache)
+	return contentCache
 }
 
 // GetRuleIDs gets rule IDs for rules (rule modules)
@@ -221,6 +281,33 @@ func (s *RulesWithContentStorage) GetExternalRuleSeverities() (
 	return
 }
 
+// IsOSDEligible returns whether the rule with error key is eligible for OSD
+// (managed) clusters. False is returned as the second value when the rule is
+// not known.
+func (s *RulesWithContentStorage) IsOSDEligible(
+	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey,
+) (eligible, found bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	eligible, found = s.osdEligible[ruleIDAndErrorKey{
+		RuleID:   ruleID,
+		ErrorKey: errorKey,
+	}]
+	return
+}
+
+// GetOSDEligibleRuleIDs returns copy of the composite rule IDs ("| format")
+// of rules eligible for OSD (managed) clusters
+func (s *RulesWithContentStorage) GetOSDEligibleRuleIDs() []ctypes.RuleID {
+	s.RLock()
+	defer s.RUnlock()
+
+	ruleIDs := make([]ctypes.RuleID, len(s.osdEligibleRuleIDs))
+	copy(ruleIDs, s.osdEligibleRuleIDs)
+	return ruleIDs
+}
+
 // GetExternalRulesManagedInfo returns a map of rule IDs and the information whether a rule is managed
 // (has osd_customer tag) or not
 func (s *RulesWithContentStorage) GetExternalRulesManagedInfo() (managedMap map[ctypes.RuleID]bool) {
@@ -236,6 +323,41 @@ func (s *RulesWithContentStorage) GetExternalRulesManagedInfo() (managedMap map[
 	return
 }
 
+// SearchRules returns rules with content matching all the given terms. The
+// terms are matched case insensitively against description, reason,
+// resolution and tags of each rule error key.
+func (s *RulesWithContentStorage) SearchRules(terms []string) map[ctypes.RuleID]*types.RuleWithContent {
+	s.RLock()
+	defer s.RUnlock()
+
+	found := make(map[ctypes.RuleID]*types.RuleWithContent)
+	for ruleID, ruleWithContent := range s.recommendationsWithContent {
+		if ruleMatchesTerms(ruleWithContent, terms) {
+			found[ruleID] = ruleWithContent
+		}
+	}
+
+	return found
+}
+
+// ruleMatchesTerms checks whether all terms are found in rule content
+func ruleMatchesTerms(ruleWithContent *types.RuleWithContent, terms []string) bool {
+	searchable := strings.ToLower(strings.Join([]string{
+		ruleWithContent.Description,
+		ruleWithContent.Reason,
+		ruleWithContent.Resolution,
+		strings.Join(ruleWithContent.Tags, " "),
+	}, "\n"))
+
+	for _, term := range terms {
+		if !strings.Contains(searchable, strings.ToLower(term)) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // RuleContentDirectoryTimeoutError is used, when the content directory is empty for too long time
 type RuleContentDirectoryTimeoutError struct{}
 
@@ -275,6 +397,19 @@ func WaitForContentDirectoryToBeReady() error {
 func GetRuleWithErrorKeyContent(
 	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey,
 ) (*types.RuleWithContent, error) {
+	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
+
+	// the cache is taken before the storage is read, so content read
+	// from the storage replaced in the meantime is stored into the
+	// discarded cache only
+	contentCache := rulesWithContentStorage.getContentCache()
+	cacheKey := string(ruleID) + "|" + string(errorKey)
+	if contentCache != nil {
+		if cached, found := contentCache.Get(cacheKey); found {
+			return cached.(*types.RuleWithContent), nil
+		}
+	}
+
 	// to be sure the data is there
 	err := WaitForContentDirectoryToBeReady()
 
@@ -282,13 +417,14 @@ func GetRuleWithErrorKeyContent(
 		return nil, err
 	}
 
-	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
-
 	res, found := rulesWithContentStorage.GetRuleWithErrorKeyContent(ruleID, errorKey)
 	if !found {
 		return nil, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v/%v", ruleID, errorKey)}
 	}
 
+	if contentCache != nil {
+		contentCache.Set(cacheKey, res, 0)
+	}
 	return res, nil
 }
 
@@ -353,6 +489,7 @@ func getRuleContent(ruleID ctypes.RuleID) (*ctypes.RuleContent, error) {
 // ResetContent clear all the content cached
 func ResetContent() {
 	rulesWithContentStorage.ResetContent()
+	localizedContentStorage.reset()
 }
 
 // GetRuleIDs returns a list of rule IDs (rule modules)
@@ -388,6 +525,38 @@ func GetExternalRuleIDs() ([]ctypes.RuleID, error) {
 	return rulesWithContentStorage.GetExternalRuleIDs(), nil
 }
 
+// IsRuleOSDEligible returns whether the rule with error key is eligible for
+// OSD (managed) clusters. The index of eligible rules is built when the
+// content is loaded, so no rule content is processed here.
+func IsRuleOSDEligible(ruleID ctypes.RuleID, errorKey ctypes.ErrorKey) (bool, error) {
+	err := WaitForContentDirectoryToBeReady()
+
+	if err != nil {
+		return false, err
+	}
+
+	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
+
+	eligible, found := rulesWithContentStorage.IsOSDEligible(ruleID, errorKey)
+	if !found {
+		return false, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v/%v", ruleID, errorKey)}
+	}
+
+	return eligible, nil
+}
+
+// GetOSDEligibleRuleIDs returns a list of composite rule IDs ("| format") of
+// rules eligible for OSD (managed) clusters
+func GetOSDEligibleRuleIDs() ([]ctypes.RuleID, error) {
+	err := WaitForContentDirectoryToBeReady()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rulesWithContentStorage.GetOSDEligibleRuleIDs(), nil
+}
+
 // GetExternalRuleSeverities returns a map of rule IDs and their severity (total risk),
 // along with a list of unique severities
 func GetExternalRuleSeverities() (
@@ -420,6 +589,19 @@ func GetExternalRulesManagedInfo() (
 	return managedMap, nil
 }
 
+// SearchRules returns rules with content matching the free-text query. The
+// query is split into terms and all of them need to match. Rules are keyed by
+// the composite rule ID (rule.module|ERROR_KEY).
+func SearchRules(query string) (map[ctypes.RuleID]*types.RuleWithContent, error) {
+	err := WaitForContentDirectoryToBeReady()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rulesWithContentStorage.SearchRules(strings.Fields(query)), nil
+}
+
 // GetAllContentV1 returns content for all the loaded rules.
 func GetAllContentV1() ([]types.RuleContentV1, error) {
 	// to be sure the data is there
@@ -444,16 +626,27 @@ func GetAllContentV2() ([]types.RuleContentV2, error) {
 	return rulesWithContentStorage.GetAllContentV2(), nil
 }
 
-// RunUpdateContentLoop runs loop which updates rules content by ticker
-func RunUpdateContentLoop(servicesConf services.Configuration) {
-	ticker := time.NewTicker(servicesConf.GroupsPollingTime)
+// RunUpdateContentLoop runs loop which periodically refreshes rules content
+// and groups configuration. The delay between refreshes is randomized by the
+// configured jitter, so all the replicas don't hit content service at once.
+func RunUpdateContentLoop(servicesConf services.Configuration, groupsStore *GroupsStore) {
+	interval := refreshInterval(servicesConf)
+	log.Info().
+		Dur("interval", interval).
+		Dur("jitter", servicesConf.ContentRefreshJitter).
+		Msg("Refreshing rules content and groups periodically")
 
 	for {
-		UpdateContent(servicesConf)
+		contentErr := UpdateContent(servicesConf)
+		UpdateLocalizedContent(servicesConf)
+		groupsErr := UpdateGroups(servicesConf, groupsStore)
+		loadSnapshotsOnFailure(servicesConf.ContentSnapshotDir, contentErr, groupsErr, groupsStore)
 
+		timer := time.NewTimer(refreshDelay(interval, servicesConf.ContentRefreshJitter))
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 		case <-stopUpdateContentLoop:
+			timer.Stop()
 			return
 		}
 	}
@@ -465,36 +658,61 @@ func SetContentDirectoryTimeout(timeout time.Duration) {
 	contentDirectoryTimeout = timeout
 }
 
+// SetContentCacheSize sets the number of rules with error keys kept in the
+// LRU cache of rule content lookups, zero disables the cache. It is applied
+// when the content is loaded next time.
+func SetContentCacheSize(size int) {
+	contentCacheSize = size
+}
+
 // StopUpdateContentLoop stops the loop
 func StopUpdateContentLoop() {
 	stopUpdateContentLoop <- struct{}{}
 }
 
-// UpdateContent function updates rule content
-func UpdateContent(servicesConf services.Configuration) {
-	var err error
+// UpdateContent function updates rule content. Concurrent updates (from the
+// refresh loop and from on-demand refresh) are serialized.
+func UpdateContent(servicesConf services.Configuration) error {
+	updateContentMutex.Lock()
+	defer updateContentMutex.Unlock()
 
-	contentServiceDirectory, err := services.GetContent(servicesConf)
+	contentServiceDirectory, version, err := services.GetContentWithVersion(servicesConf)
 	if err != nil {
 		log.Error().Err(err).Msg("Error retrieving static content")
-		return
+		contentStatus.setError(err)
+		recordRefreshFailure(refreshSourceContent)
+		return err
 	}
 
 	SetRuleContentDirectory(contentServiceDirectory)
 	err = WaitForContentDirectoryToBeReady()
 	if err != nil {
-		return
+		contentStatus.setError(err)
+		recordRefreshFailure(refreshSourceContent)
+		return err
 	}
-	ResetContent()
 	LoadRuleContent(ruleContentDirectory)
+	contentStatus.setLoaded(version, len(ruleContentDirectory.Rules))
+	recordRefreshSuccess(refreshSourceContent)
+	ContentStale.WithLabelValues(refreshSourceContent).Set(0)
+
+	if servicesConf.ContentSnapshotDir != "" {
+		err = saveContentSnapshot(servicesConf.ContentSnapshotDir, ruleContentDirectory, version)
+		if err != nil {
+			// content is loaded, the snapshot is just a fallback
+			log.Error().Err(err).Msg("Unable to store rule content snapshot")
+		}
+	}
+	return nil
 }
 
-// FetchRuleContent - fetching content for particular rule
+// FetchRuleContent - fetching content for particular rule translated to
+// given language, English content is used when the language is empty
 // Return values:
 //   - Structure with rules and content
 //   - return true if the rule has been filtered by OSDElegible field. False otherwise
 //   - return error if the one occurred during retrieval
-func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool) (
+func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool, lang string) (
 	ruleWithContentResponse *types.RuleWithContentResponse,
 	osdFiltered bool,
 	err error,
@@ -505,7 +723,16 @@ func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool) (
 	ruleWithContentResponse = nil
 	osdFiltered = false
 
-	ruleWithContent, err := GetRuleWithErrorKeyContent(ruleID, errorKey)
+	// rules that are not eligible are filtered out by the index lookup
+	// without resolving their content, unknown rules fail below
+	if OSDEligible {
+		if eligible, indexErr := IsRuleOSDEligible(ruleID, errorKey); indexErr == nil && !eligible {
+			osdFiltered = true
+			return
+		}
+	}
+
+	ruleWithContent, err := GetLocalizedRuleWithErrorKeyContent(ruleID, errorKey, lang)
 	if err != nil {
 		log.Error().Err(err).Msgf(
 			"unable to get content for rule with id %v and error key %v", ruleID, errorKey,
@@ -513,11 +740,6 @@ func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool) (
 		return
 	}
 
-	if OSDEligible && !ruleWithContent.OSDCustomer {
-		osdFiltered = true
-		return
-	}
-
 	ruleWithContentResponse = &types.RuleWithContentResponse{
 		CreatedAt:       ruleWithContent.PublishDate.UTC().Format(time.RFC3339),
 		Description:     ruleWithContent.Description,
@@ -527,6 +749,7 @@ func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool) (
 		Resolution:      ruleWithContent.Resolution,
 		MoreInfo:        ruleWithContent.MoreInfo,
 		TotalRisk:       ruleWithContent.TotalRisk,
+		ResolutionRisk:  ruleWithContent.ResolutionRisk,
 		RuleID:          ruleID,
 		TemplateData:    rule.TemplateData,
 		Tags:            ruleWithContent.Tags,
@@ -536,5 +759,31 @@ func FetchRuleContent(rule ctypes.RuleOnReport, OSDEligible bool) (
 		DisabledAt:      rule.DisabledAt,
 		Internal:        ruleWithContent.Internal,
 	}
+	interpolateRuleWithContentResponse(ruleWithContentResponse)
 	return
 }
+
+// interpolateRuleWithContentResponse fills the templates in rule content
+// with values from the extra_data of the report. Fields that can't be
+// interpolated are kept as is so the clients can still process them.
+func interpolateRuleWithContentResponse(rule *types.RuleWithContentResponse) {
+	fields := []*string{
+		&rule.Description,
+		&rule.Generic,
+		&rule.Reason,
+		&rule.Resolution,
+		&rule.MoreInfo,
+	}
+
+	for _, field := range fields {
+		interpolated, err := InterpolateTemplate(*field, rule.TemplateData)
+		if err != nil {
+			log.Warn().Err(err).Msgf(
+				"unable to interpolate content for rule with id %v and error key %v",
+				rule.RuleID, rule.ErrorKey,
+			)
+			continue
+		}
+		*field = interpolated
+	}
+}
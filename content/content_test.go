@@ -165,6 +165,36 @@ func TestUpdateContentBadTime(t *testing.T) {
 	helpers.FailOnError(t, err)
 }
 
+// TestGetRuleWithErrorKeyContentCache checks that cached rule content is
+// returned until the content is loaded again
+func TestGetRuleWithErrorKeyContentCache(t *testing.T) {
+	defer content.ResetContent()
+	content.SetContentCacheSize(10)
+	defer content.SetContentCacheSize(0)
+
+	content.SetRuleContentDirectory(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+
+	ruleWithContent1, err := content.GetRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1)
+	helpers.FailOnError(t, err)
+	ruleWithContent2, err := content.GetRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1)
+	helpers.FailOnError(t, err)
+	assert.Same(t, ruleWithContent1, ruleWithContent2)
+
+	// content without the rule replaces the cached one
+	content.LoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: ctypes.GlobalRuleConfig{
+			Impact: testdata.ImpactStrToInt,
+		},
+		Rules: map[string]ctypes.RuleContent{
+			"rc4": testdata.RuleContent4,
+		},
+	})
+
+	_, err = content.GetRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1)
+	assert.Error(t, err)
+}
+
 func TestResetContentWhenUpdating(t *testing.T) {
 	defer content.ResetContent()
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
@@ -241,7 +271,7 @@ func TestFetchRuleContent_OSDEligibleNotRequiredAdmin(t *testing.T) {
 		content.UpdateContent(helpers.DefaultServicesConfig)
 
 		rule := testdata.RuleOnReport1
-		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, true)
+		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, true, "")
 		assert.False(t, osdFiltered)
 		assert.NotNil(t, ruleContent)
 		assert.Nil(t, err)
@@ -258,6 +288,7 @@ func TestFetchRuleContent_OSDEligibleNotRequiredAdmin(t *testing.T) {
 			Resolution:      ruleWithContent.Resolution,
 			MoreInfo:        ruleWithContent.MoreInfo,
 			TotalRisk:       ruleWithContent.TotalRisk,
+			ResolutionRisk:  ruleWithContent.ResolutionRisk,
 			RuleID:          ruleID,
 			TemplateData:    rule.TemplateData,
 			Tags:            ruleWithContent.Tags,
@@ -288,7 +319,7 @@ func TestFetchRuleContent_NotOSDEligible(t *testing.T) {
 		content.UpdateContent(helpers.DefaultServicesConfig)
 
 		rule := testdata.RuleOnReport1
-		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, false)
+		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, false, "")
 		assert.False(t, osdFiltered)
 		assert.NotNil(t, ruleContent)
 		assert.Nil(t, err)
@@ -305,6 +336,7 @@ func TestFetchRuleContent_NotOSDEligible(t *testing.T) {
 			Resolution:      ruleWithContent.Resolution,
 			MoreInfo:        ruleWithContent.MoreInfo,
 			TotalRisk:       ruleWithContent.TotalRisk,
+			ResolutionRisk:  ruleWithContent.ResolutionRisk,
 			RuleID:          ruleID,
 			TemplateData:    rule.TemplateData,
 			Tags:            ruleWithContent.Tags,
@@ -344,7 +376,7 @@ func TestFetchRuleContent_DisabledRuleExist(t *testing.T) {
 			TemplateData:    testdata.Rule1ExtraData,
 		}
 
-		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, false)
+		ruleContent, osdFiltered, err := content.FetchRuleContent(rule, false, "")
 		assert.False(t, osdFiltered)
 		assert.NotNil(t, ruleContent)
 		assert.Nil(t, err)
@@ -376,7 +408,7 @@ func TestFetchRuleContent_RuleDoesNotExist(t *testing.T) {
 			TemplateData:    nil,
 		}
 
-		ruleContent, _, err := content.FetchRuleContent(rule, false)
+		ruleContent, _, err := content.FetchRuleContent(rule, false, "")
 		assert.Nil(t, ruleContent)
 		assert.NotNil(t, err)
 
@@ -566,6 +598,42 @@ func TestGetInternalRuleIDs(t *testing.T) {
 	assert.Equal(t, 3, len(internalRuleIDs))
 }
 
+// TestOSDEligibleIndex tests if the index of OSD eligible rules matches the
+// content of the rules
+func TestOSDEligibleIndex(t *testing.T) {
+	defer content.ResetContent()
+	content.SetRuleContentDirectory(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+
+	eligibleCount := 0
+	for _, rule := range []ctypes.RuleOnReport{testdata.RuleOnReport1, testdata.RuleOnReport2, testdata.RuleOnReport3} {
+		ruleWithContent, err := content.GetRuleWithErrorKeyContent(rule.Module, rule.ErrorKey)
+		helpers.FailOnError(t, err)
+
+		eligible, err := content.IsRuleOSDEligible(rule.Module, rule.ErrorKey)
+		helpers.FailOnError(t, err)
+		assert.Equal(t, ruleWithContent.OSDCustomer, eligible)
+		if eligible {
+			eligibleCount++
+		}
+	}
+
+	eligibleRuleIDs, err := content.GetOSDEligibleRuleIDs()
+	helpers.FailOnError(t, err)
+	assert.Len(t, eligibleRuleIDs, eligibleCount)
+
+	// the caller gets its own copy of the list
+	if eligibleCount > 0 {
+		eligibleRuleIDs[0] = "modified"
+		eligibleRuleIDs, err = content.GetOSDEligibleRuleIDs()
+		helpers.FailOnError(t, err)
+		assert.NotContains(t, eligibleRuleIDs, ctypes.RuleID("modified"))
+	}
+
+	_, err = content.IsRuleOSDEligible("ccx_rules_ocp.external.rules.unknown", testdata.ErrorKey1)
+	assert.Error(t, err)
+}
+
 // TestGetExternalRuleIDs tests if storage.externalRuleIDs is filled correctly
 func TestGetExternalRuleIDs(t *testing.T) {
 	defer content.ResetContent()
@@ -661,7 +729,7 @@ func TestGetExternalRuleSeverities2Unique(t *testing.T) {
 func TestContentLoop(t *testing.T) {
 	go content.RunUpdateContentLoop(services.Configuration{
 		GroupsPollingTime: 1 * time.Second,
-	})
+	}, content.NewGroupsStore())
 	content.SetContentDirectoryTimeout(1 * time.Second)
 	time.Sleep(2 * time.Second)
 	content.StopUpdateContentLoop()
@@ -689,3 +757,112 @@ func modifyPluginPythonModule(ruleContent *ctypes.RuleContent, injectStr string)
 	ruleContentCopy.Plugin.PythonModule = fmt.Sprintf("testcontent.%v.%v.rule", injectStr, random.Int())
 	*ruleContent = *ruleContentCopy
 }
+
+func TestGetStatusAfterUpdate(t *testing.T) {
+	defer content.ResetContent()
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: ics_server.AllContentEndpoint,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       helpers.MustGobSerialize(t, testdata.RuleContentDirectory3Rules),
+		})
+
+		content.UpdateContent(helpers.DefaultServicesConfig)
+
+		status := content.GetStatus()
+		assert.True(t, status.Loaded)
+		assert.NotEmpty(t, status.Version)
+		assert.Equal(t, len(testdata.RuleContentDirectory3Rules.Rules), status.RulesCount)
+		assert.False(t, status.LastRefresh.IsZero())
+		assert.Empty(t, status.LastError)
+	}, testTimeout)
+}
+
+func TestGetStatusAfterFailedUpdate(t *testing.T) {
+	defer content.ResetContent()
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: ics_server.AllContentEndpoint,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusInternalServerError,
+		})
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: ics_server.AllContentEndpoint,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       helpers.MustGobSerialize(t, testdata.RuleContentDirectory3Rules),
+		})
+
+		failedRefreshes := content.GetStatus().FailedRefreshes
+		assert.Error(t, content.UpdateContent(helpers.DefaultServicesConfig))
+
+		status := content.GetStatus()
+		assert.NotEmpty(t, status.LastError)
+		assert.NotNil(t, status.LastErrorAt)
+		assert.Equal(t, failedRefreshes+1, status.FailedRefreshes)
+
+		assert.NoError(t, content.UpdateContent(helpers.DefaultServicesConfig))
+
+		status = content.GetStatus()
+		assert.Empty(t, status.LastError)
+		assert.NotNil(t, status.LastErrorAt)
+		assert.Zero(t, status.FailedRefreshes)
+	}, testTimeout)
+}
+
+func TestRefreshInterval(t *testing.T) {
+	assert.Equal(t, time.Minute, content.RefreshInterval(services.Configuration{
+		GroupsPollingTime: time.Minute,
+	}))
+	assert.Equal(t, 2*time.Minute, content.RefreshInterval(services.Configuration{
+		GroupsPollingTime:      time.Minute,
+		ContentRefreshInterval: 2 * time.Minute,
+	}))
+}
+
+func TestRefreshDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, content.RefreshDelay(time.Minute, 0))
+
+	for i := 0; i < 100; i++ {
+		delay := content.RefreshDelay(time.Minute, 10*time.Second)
+		assert.GreaterOrEqual(t, int64(delay), int64(time.Minute))
+		assert.Less(t, int64(delay), int64(time.Minute+10*time.Second))
+	}
+}
+
+func TestSearchRules(t *testing.T) {
+	defer content.ResetContent()
+
+	ruleContent := testdata.RuleContent4
+	ek := ruleContent.ErrorKeys[testdata.ErrorKey4]
+	original := ek
+	ek.Metadata.Description = "Unique SEARCHABLE phrase"
+	ruleContent.ErrorKeys[testdata.ErrorKey4] = ek
+	defer func() { ruleContent.ErrorKeys[testdata.ErrorKey4] = original }()
+
+	content.LoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: ctypes.GlobalRuleConfig{
+			Impact: testdata.ImpactStrToInt,
+		},
+		Rules: map[string]ctypes.RuleContent{
+			"rc4": ruleContent,
+		},
+	})
+
+	found, err := content.SearchRules("searchable UNIQUE")
+	helpers.FailOnError(t, err)
+	assert.Len(t, found, 1)
+	for _, rule := range found {
+		assert.Equal(t, testdata.Rule4ID, rule.Module)
+	}
+
+	found, err = content.SearchRules("searchable nonexistent")
+	helpers.FailOnError(t, err)
+	assert.Empty(t, found)
+}
@@ -26,4 +26,7 @@ package content
 // symbols (externally invisible) in unit tests.
 var (
 	RuleContentDirectoryReady = ruleContentDirectoryReady
+	RefreshInterval           = refreshInterval
+	RefreshDelay              = refreshDelay
+	SaveGroupsSnapshot        = saveGroupsSnapshot
 )
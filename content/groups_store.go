@@ -0,0 +1,104 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+)
+
+// groupsSnapshot is an immutable state of the groups configuration stored in
+// GroupsStore. A new snapshot is created on every update.
+type groupsSnapshot struct {
+	groups      []groups.Group
+	err         error
+	lastRefresh time.Time
+	stale       bool
+}
+
+// GroupsStore is a thread safe storage of the latest groups configuration
+// retrieved from content service. Updates are done by the goroutine polling
+// content service, reads from handlers don't need to acquire any lock.
+type GroupsStore struct {
+	snapshot atomic.Value
+}
+
+// NewGroupsStore constructs new empty GroupsStore
+func NewGroupsStore() *GroupsStore {
+	store := &GroupsStore{}
+	store.snapshot.Store(groupsSnapshot{})
+	return store
+}
+
+// SetGroups method replaces stored groups configuration by the new one and
+// clears the error recorded by previous update, if any
+func (s *GroupsStore) SetGroups(newGroups []groups.Group) {
+	s.snapshot.Store(groupsSnapshot{
+		groups:      newGroups,
+		lastRefresh: time.Now().UTC(),
+	})
+}
+
+// SetStaleGroups method replaces stored groups configuration by the one
+// loaded from the snapshot saved at given time. The groups are reported as
+// stale until they are replaced by SetGroups.
+func (s *GroupsStore) SetStaleGroups(newGroups []groups.Group, savedAt time.Time) {
+	previous := s.load()
+	s.snapshot.Store(groupsSnapshot{
+		groups:      newGroups,
+		err:         previous.err,
+		lastRefresh: savedAt,
+		stale:       true,
+	})
+}
+
+// SetError method records error that occurred during groups retrieval. The
+// previously retrieved groups are kept in the store.
+func (s *GroupsStore) SetError(err error) {
+	previous := s.load()
+	s.snapshot.Store(groupsSnapshot{
+		groups:      previous.groups,
+		err:         err,
+		lastRefresh: previous.lastRefresh,
+		stale:       previous.stale,
+	})
+}
+
+// Groups method returns the latest groups configuration or the error that
+// occurred during the last update. Nil groups are returned when nothing has
+// been retrieved yet.
+func (s *GroupsStore) Groups() ([]groups.Group, error) {
+	snapshot := s.load()
+	return snapshot.groups, snapshot.err
+}
+
+// LastRefresh method returns the time of the last successful update or zero
+// time if groups have not been retrieved yet
+func (s *GroupsStore) LastRefresh() time.Time {
+	return s.load().lastRefresh
+}
+
+// Stale method returns true if the groups have been loaded from the snapshot
+// and not retrieved from content service yet
+func (s *GroupsStore) Stale() bool {
+	return s.load().stale
+}
+
+func (s *GroupsStore) load() groupsSnapshot {
+	snapshot, _ := s.snapshot.Load().(groupsSnapshot)
+	return snapshot
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+func TestGroupsStoreEmpty(t *testing.T) {
+	store := content.NewGroupsStore()
+
+	retrievedGroups, err := store.Groups()
+	assert.Nil(t, retrievedGroups)
+	assert.NoError(t, err)
+	assert.True(t, store.LastRefresh().IsZero())
+}
+
+func TestGroupsStoreSetGroups(t *testing.T) {
+	store := content.NewGroupsStore()
+	expected := []groups.Group{{Description: "group"}}
+
+	store.SetGroups(expected)
+
+	retrievedGroups, err := store.Groups()
+	assert.Equal(t, expected, retrievedGroups)
+	assert.NoError(t, err)
+	assert.False(t, store.LastRefresh().IsZero())
+}
+
+func TestGroupsStoreSetErrorKeepsGroups(t *testing.T) {
+	store := content.NewGroupsStore()
+	expected := []groups.Group{{Description: "group"}}
+	expectedErr := errors.New("content service unavailable")
+
+	store.SetGroups(expected)
+	lastRefresh := store.LastRefresh()
+	store.SetError(expectedErr)
+
+	retrievedGroups, err := store.Groups()
+	assert.Equal(t, expected, retrievedGroups)
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, lastRefresh, store.LastRefresh())
+
+	// successful update clears the error
+	store.SetGroups(expected)
+	_, err = store.Groups()
+	assert.NoError(t, err)
+}
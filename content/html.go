@@ -0,0 +1,209 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// Rule content is written in a subset of Markdown. Only the constructs used
+// in rule content are supported: paragraphs, headings, bullet and numbered
+// lists, fenced code blocks, inline code, links, bold and italic text.
+var (
+	headingRegexp     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletItemRegexp  = regexp.MustCompile(`^\s*[*+-]\s+(.*)$`)
+	orderedItemRegexp = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	linkRegexp        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldRegexp        = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicRegexp      = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+
+	// htmlPolicy removes everything that's not safe to be embedded into
+	// web page, for example scripts or javascript: links
+	htmlPolicy = bluemonday.UGCPolicy()
+)
+
+// markdownRenderer keeps state of the block being rendered
+type markdownRenderer struct {
+	out       strings.Builder
+	paragraph []string
+	listTag   string
+}
+
+// RenderMarkdownToHTML converts rule content written in Markdown into
+// sanitized HTML
+func RenderMarkdownToHTML(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	r := markdownRenderer{}
+	inCodeBlock := false
+	var codeBlock []string
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inCodeBlock {
+				r.out.WriteString("<pre><code>" + html.EscapeString(strings.Join(codeBlock, "\n")) + "</code></pre>\n")
+				codeBlock = nil
+			} else {
+				r.closeBlocks()
+			}
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			codeBlock = append(codeBlock, line)
+			continue
+		}
+		r.renderLine(line)
+	}
+
+	if inCodeBlock {
+		r.out.WriteString("<pre><code>" + html.EscapeString(strings.Join(codeBlock, "\n")) + "</code></pre>\n")
+	}
+	r.closeBlocks()
+
+	return strings.TrimSpace(htmlPolicy.Sanitize(r.out.String()))
+}
+
+func (r *markdownRenderer) renderLine(line string) {
+	if strings.TrimSpace(line) == "" {
+		r.closeBlocks()
+		return
+	}
+
+	if match := headingRegexp.FindStringSubmatch(line); match != nil {
+		r.closeBlocks()
+		level := len(match[1])
+		fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", level, renderInline(match[2]), level)
+		return
+	}
+
+	if match := bulletItemRegexp.FindStringSubmatch(line); match != nil {
+		r.listItem("ul", match[1])
+		return
+	}
+
+	if match := orderedItemRegexp.FindStringSubmatch(line); match != nil {
+		r.listItem("ol", match[1])
+		return
+	}
+
+	r.closeList()
+	r.paragraph = append(r.paragraph, strings.TrimSpace(line))
+}
+
+func (r *markdownRenderer) listItem(tag, text string) {
+	r.closeParagraph()
+	if r.listTag != tag {
+		r.closeList()
+		r.out.WriteString("<" + tag + ">\n")
+		r.listTag = tag
+	}
+	r.out.WriteString("<li>" + renderInline(text) + "</li>\n")
+}
+
+func (r *markdownRenderer) closeParagraph() {
+	if len(r.paragraph) == 0 {
+		return
+	}
+	r.out.WriteString("<p>" + renderInline(strings.Join(r.paragraph, "\n")) + "</p>\n")
+	r.paragraph = nil
+}
+
+func (r *markdownRenderer) closeList() {
+	if r.listTag == "" {
+		return
+	}
+	r.out.WriteString("</" + r.listTag + ">\n")
+	r.listTag = ""
+}
+
+func (r *markdownRenderer) closeBlocks() {
+	r.closeParagraph()
+	r.closeList()
+}
+
+// renderInline converts inline Markdown constructs into HTML. Text enclosed
+// in backticks is rendered as code without any further processing.
+func renderInline(text string) string {
+	parts := strings.Split(text, "`")
+	for i := range parts {
+		escaped := html.EscapeString(parts[i])
+		// odd parts are enclosed in backticks, but only when the closing
+		// backtick exists
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + escaped + "</code>"
+			continue
+		}
+		if i%2 == 1 {
+			escaped = "`" + escaped
+		}
+		escaped = linkRegexp.ReplaceAllString(escaped, `<a href="$2">$1</a>`)
+		escaped = boldRegexp.ReplaceAllString(escaped, "<strong>$1$2</strong>")
+		escaped = italicRegexp.ReplaceAllString(escaped, "<em>$1$2</em>")
+		parts[i] = escaped
+	}
+	return strings.Join(parts, "")
+}
+
+// RenderRuleWithContentHTML returns copy of the rule content with all the
+// Markdown fields rendered into HTML
+func RenderRuleWithContentHTML(ruleWithContent *types.RuleWithContent) *types.RuleWithContent {
+	rendered := *ruleWithContent
+	rendered.Generic = RenderMarkdownToHTML(rendered.Generic)
+	rendered.Summary = RenderMarkdownToHTML(rendered.Summary)
+	rendered.Reason = RenderMarkdownToHTML(rendered.Reason)
+	rendered.Resolution = RenderMarkdownToHTML(rendered.Resolution)
+	rendered.MoreInfo = RenderMarkdownToHTML(rendered.MoreInfo)
+	return &rendered
+}
+
+// RenderRuleWithContentResponseHTML renders all the Markdown fields of the
+// rule in report into HTML
+func RenderRuleWithContentResponseHTML(rule *types.RuleWithContentResponse) {
+	rule.Generic = RenderMarkdownToHTML(rule.Generic)
+	rule.Reason = RenderMarkdownToHTML(rule.Reason)
+	rule.Resolution = RenderMarkdownToHTML(rule.Resolution)
+	rule.MoreInfo = RenderMarkdownToHTML(rule.MoreInfo)
+}
+
+// RenderRuleContentV1HTML renders all the Markdown fields of the rule content
+// and its error keys into HTML
+func RenderRuleContentV1HTML(ruleContent *types.RuleContentV1) {
+	ruleContent.Generic = RenderMarkdownToHTML(ruleContent.Generic)
+	ruleContent.Summary = RenderMarkdownToHTML(ruleContent.Summary)
+	ruleContent.Reason = RenderMarkdownToHTML(ruleContent.Reason)
+	ruleContent.Resolution = RenderMarkdownToHTML(ruleContent.Resolution)
+	ruleContent.MoreInfo = RenderMarkdownToHTML(ruleContent.MoreInfo)
+
+	errorKeys := make(map[string]types.RuleErrorKeyContentV1, len(ruleContent.ErrorKeys))
+	for errorKey, errorKeyContent := range ruleContent.ErrorKeys {
+		errorKeyContent.Generic = RenderMarkdownToHTML(errorKeyContent.Generic)
+		errorKeyContent.Summary = RenderMarkdownToHTML(errorKeyContent.Summary)
+		errorKeyContent.Reason = RenderMarkdownToHTML(errorKeyContent.Reason)
+		errorKeyContent.Resolution = RenderMarkdownToHTML(errorKeyContent.Resolution)
+		errorKeyContent.MoreInfo = RenderMarkdownToHTML(errorKeyContent.MoreInfo)
+		errorKeys[errorKey] = errorKeyContent
+	}
+	ruleContent.ErrorKeys = errorKeys
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+func TestRenderMarkdownToHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"paragraph", "Simple text", "<p>Simple text</p>"},
+		{"two paragraphs", "First\n\nSecond", "<p>First</p>\n<p>Second</p>"},
+		{"heading", "## Title", "<h2>Title</h2>"},
+		{"bold and italic", "**bold** and *italic*", "<p><strong>bold</strong> and <em>italic</em></p>"},
+		{"inline code", "run `oc get **nodes**`", "<p>run <code>oc get **nodes**</code></p>"},
+		{"bullet list", "* one\n* two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>"},
+		{"code block", "```\n<b>x</b>\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>"},
+		{"html is escaped", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, content.RenderMarkdownToHTML(tc.markdown))
+		})
+	}
+}
+
+func TestRenderMarkdownToHTMLLinks(t *testing.T) {
+	rendered := content.RenderMarkdownToHTML("[docs](https://docs.openshift.com)")
+	assert.Contains(t, rendered, `href="https://docs.openshift.com"`)
+	assert.Contains(t, rendered, ">docs</a>")
+
+	// unsafe links are removed by sanitizer
+	rendered = content.RenderMarkdownToHTML("[click](javascript:alert(1))")
+	assert.NotContains(t, rendered, "javascript:")
+}
@@ -0,0 +1,238 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"sort"
+	"sync"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/language"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// DefaultLanguage is the language of rule content as retrieved from content
+// service
+const DefaultLanguage = "en"
+
+// localizedFields contains translated text fields of rule error key
+type localizedFields struct {
+	Description string
+	Generic     string
+	Summary     string
+	Reason      string
+	Resolution  string
+	MoreInfo    string
+}
+
+// LocalizedContentStorage is a thread safe storage of translated rule
+// content, indexed by language
+type LocalizedContentStorage struct {
+	sync.RWMutex
+	languages map[string]map[ruleIDAndErrorKey]localizedFields
+}
+
+var localizedContentStorage = LocalizedContentStorage{
+	languages: map[string]map[ruleIDAndErrorKey]localizedFields{},
+}
+
+// SetLanguage replaces all translations for given language
+func (s *LocalizedContentStorage) SetLanguage(lang string, contentDir *ctypes.RuleContentDirectory) {
+	translations := make(map[ruleIDAndErrorKey]localizedFields)
+
+	for _, rule := range contentDir.Rules {
+		ruleID := ctypes.RuleID(rule.Plugin.PythonModule)
+		for errorKey, errorProperties := range rule.ErrorKeys {
+			translations[ruleIDAndErrorKey{
+				RuleID:   ruleID,
+				ErrorKey: ctypes.ErrorKey(errorKey),
+			}] = localizedFields{
+				Description: errorProperties.Metadata.Description,
+				Generic:     errorProperties.Generic,
+				Summary:     errorProperties.Summary,
+				Reason:      errorProperties.Reason,
+				Resolution:  errorProperties.Resolution,
+				MoreInfo:    errorProperties.MoreInfo,
+			}
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.languages == nil {
+		s.languages = make(map[string]map[ruleIDAndErrorKey]localizedFields)
+	}
+	s.languages[lang] = translations
+}
+
+// reset removes all translations
+func (s *LocalizedContentStorage) reset() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.languages = make(map[string]map[ruleIDAndErrorKey]localizedFields)
+}
+
+// Languages returns sorted list of languages with available translations
+func (s *LocalizedContentStorage) Languages() []string {
+	s.RLock()
+	defer s.RUnlock()
+
+	languages := make([]string, 0, len(s.languages))
+	for lang := range s.languages {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
+
+// Localize returns copy of the rule content with text fields translated to
+// given language. Fields which are not translated are kept in English.
+func (s *LocalizedContentStorage) Localize(
+	ruleWithContent *types.RuleWithContent, lang string,
+) (*types.RuleWithContent, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	translations, found := s.languages[lang]
+	if !found {
+		return ruleWithContent, false
+	}
+
+	fields, found := translations[ruleIDAndErrorKey{
+		RuleID:   ruleWithContent.Module,
+		ErrorKey: ruleWithContent.ErrorKey,
+	}]
+	if !found {
+		return ruleWithContent, false
+	}
+
+	localized := *ruleWithContent
+	localized.Description = translatedOrDefault(fields.Description, localized.Description)
+	localized.Generic = translatedOrDefault(fields.Generic, localized.Generic)
+	localized.Summary = translatedOrDefault(fields.Summary, localized.Summary)
+	localized.Reason = translatedOrDefault(fields.Reason, localized.Reason)
+	localized.Resolution = translatedOrDefault(fields.Resolution, localized.Resolution)
+	localized.MoreInfo = translatedOrDefault(fields.MoreInfo, localized.MoreInfo)
+
+	return &localized, true
+}
+
+func translatedOrDefault(translated, defaultValue string) string {
+	if translated == "" {
+		return defaultValue
+	}
+	return translated
+}
+
+// UpdateLocalizedContent retrieves localized rule content for all languages
+// configured in services configuration. Translations for language that can't
+// be retrieved are kept from the previous update, if any.
+func UpdateLocalizedContent(servicesConf services.Configuration) {
+	for _, lang := range servicesConf.ContentLanguages {
+		contentDir, err := services.GetLocalizedContent(servicesConf, lang)
+		if err != nil {
+			log.Error().Err(err).Str("language", lang).Msg("Error retrieving localized content")
+			continue
+		}
+		localizedContentStorage.SetLanguage(lang, contentDir)
+	}
+}
+
+// LocalizedLanguages returns list of languages with available translations
+// of rule content
+func LocalizedLanguages() []string {
+	return localizedContentStorage.Languages()
+}
+
+// LocalizeRuleWithContent returns rule content translated to given language.
+// The original content is returned when no translation is available.
+func LocalizeRuleWithContent(ruleWithContent *types.RuleWithContent, lang string) (*types.RuleWithContent, bool) {
+	return localizedContentStorage.Localize(ruleWithContent, lang)
+}
+
+// SetLocalizedContent replaces all translations for given language, it is
+// made for easy testing
+func SetLocalizedContent(lang string, contentDir *ctypes.RuleContentDirectory) {
+	localizedContentStorage.SetLanguage(lang, contentDir)
+}
+
+// PreferredLanguage returns language of rule content that best matches the
+// value of Accept-Language header. English is returned when the header is
+// empty or when there's no suitable translation.
+func PreferredLanguage(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLanguage
+	}
+
+	desired, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(desired) == 0 {
+		return DefaultLanguage
+	}
+
+	available := []string{DefaultLanguage}
+	available = append(available, LocalizedLanguages()...)
+
+	supported := make([]language.Tag, 0, len(available))
+	for _, lang := range available {
+		supported = append(supported, language.Make(lang))
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(desired...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+
+	return available[index]
+}
+
+// localize returns rule content translated to given language, the original
+// content is returned for English and when no translation is available
+func localize(ruleWithContent *types.RuleWithContent, lang string) *types.RuleWithContent {
+	if lang == "" || lang == DefaultLanguage {
+		return ruleWithContent
+	}
+	localized, _ := localizedContentStorage.Localize(ruleWithContent, lang)
+	return localized
+}
+
+// GetLocalizedRuleWithErrorKeyContent returns content for rule with provided
+// rule ID and error key translated to given language
+func GetLocalizedRuleWithErrorKeyContent(
+	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey, lang string,
+) (*types.RuleWithContent, error) {
+	ruleWithContent, err := GetRuleWithErrorKeyContent(ruleID, errorKey)
+	if err != nil {
+		return nil, err
+	}
+	return localize(ruleWithContent, lang), nil
+}
+
+// GetLocalizedContentForRecommendation returns content for rule with provided
+// composite rule ID translated to given language
+func GetLocalizedContentForRecommendation(
+	ruleID ctypes.RuleID, lang string,
+) (*types.RuleWithContent, error) {
+	ruleWithContent, err := GetContentForRecommendation(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return localize(ruleWithContent, lang), nil
+}
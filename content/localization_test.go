@@ -0,0 +1,110 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func TestLocalizedContentStorage(t *testing.T) {
+	storage := content.LocalizedContentStorage{}
+	storage.SetLanguage("ja", &testdata.RuleContentDirectory3Rules)
+
+	assert.Equal(t, []string{"ja"}, storage.Languages())
+
+	original := &types.RuleWithContent{
+		Module:      testdata.Rule1ID,
+		ErrorKey:    testdata.ErrorKey1,
+		Description: "original description",
+	}
+
+	_, found := storage.Localize(original, "de")
+	assert.False(t, found)
+
+	localized, found := storage.Localize(original, "ja")
+	assert.True(t, found)
+	assert.Equal(t, testdata.Rule1ID, localized.Module)
+	// the original content must be left intact
+	assert.Equal(t, "original description", original.Description)
+
+	unknown := &types.RuleWithContent{
+		Module:   ctypes.RuleID("unknown.rule"),
+		ErrorKey: testdata.ErrorKey1,
+	}
+	_, found = storage.Localize(unknown, "ja")
+	assert.False(t, found)
+}
+
+// translatedContent contains translation of the first rule of the test
+// content directory
+var translatedContent = ctypes.RuleContentDirectory{
+	Rules: map[string]ctypes.RuleContent{
+		"rule1": {
+			Plugin: ctypes.RulePluginInfo{PythonModule: string(testdata.Rule1ID)},
+			ErrorKeys: map[string]ctypes.RuleErrorKeyContent{
+				string(testdata.ErrorKey1): {
+					Metadata: ctypes.ErrorKeyMetadata{Description: "translated description"},
+					Reason:   "translated reason",
+				},
+			},
+		},
+	},
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	defer content.ResetContent()
+	content.SetLocalizedContent("ja", &translatedContent)
+
+	assert.Equal(t, "en", content.PreferredLanguage(""))
+	assert.Equal(t, "en", content.PreferredLanguage("de-DE,de;q=0.9"))
+	assert.Equal(t, "ja", content.PreferredLanguage("ja-JP,ja;q=0.9,en;q=0.8"))
+	assert.Equal(t, "en", content.PreferredLanguage("en-US,ja;q=0.5"))
+	assert.Equal(t, "en", content.PreferredLanguage("not a language;;"))
+}
+
+func TestGetLocalizedRuleWithErrorKeyContent(t *testing.T) {
+	defer content.ResetContent()
+	content.SetRuleContentDirectory(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	content.SetLocalizedContent("ja", &translatedContent)
+
+	english, err := content.GetLocalizedRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1, content.DefaultLanguage)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "translated description", english.Description)
+
+	localized, err := content.GetLocalizedRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1, "ja")
+	assert.NoError(t, err)
+	assert.Equal(t, "translated description", localized.Description)
+	assert.Equal(t, "translated reason", localized.Reason)
+	// untranslated fields are kept in English
+	assert.Equal(t, english.Resolution, localized.Resolution)
+
+	// the cached content must be left intact
+	english, err = content.GetLocalizedRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1, "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "translated description", english.Description)
+
+	rule := testdata.RuleOnReport1
+	response, _, err := content.FetchRuleContent(rule, false, "ja")
+	assert.NoError(t, err)
+	assert.Equal(t, "translated description", response.Description)
+}
@@ -40,8 +40,12 @@ var (
 
 // TODO: consider moving parsing to content service
 
-// LoadRuleContent loads the parsed rule content into the storage
+// LoadRuleContent loads the parsed rule content into the storage. The new
+// content is prepared aside and swapped with the current one at once, so
+// readers never see partially loaded content.
 func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
+	storage := newRulesWithContentStorage()
+
 	for i, rule := range contentDir.Rules {
 		ruleID := ctypes.RuleID(rule.Plugin.PythonModule)
 
@@ -67,6 +71,7 @@ func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
 			}
 
 			totalRisk := calculateTotalRisk(impact.Impact, errorProperties.Metadata.Likelihood)
+			minOCPVersion, maxOCPVersion := parseOCPVersionConstraints(errorProperties.Metadata.Tags)
 
 			ruleTmp := contentDir.Rules[i]
 			if ruleTmpErrorKey, ok := ruleTmp.ErrorKeys[errorKey]; ok {
@@ -74,9 +79,9 @@ func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
 				ruleTmp.ErrorKeys[errorKey] = ruleTmpErrorKey
 			}
 			// sets "plugin" level, containing usual fields + list of error keys
-			rulesWithContentStorage.SetRule(ruleID, ruleTmp)
+			storage.SetRule(ruleID, ruleTmp)
 
-			rulesWithContentStorage.SetRuleWithContent(ruleID, ctypes.ErrorKey(errorKey), &types.RuleWithContent{
+			storage.SetRuleWithContent(ruleID, ctypes.ErrorKey(errorKey), &types.RuleWithContent{
 				Module:         ruleID,
 				Name:           rule.Plugin.Name,
 				Generic:        errorProperties.Generic,
@@ -95,9 +100,13 @@ func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
 				Internal:       IsRuleInternal(ruleID),
 				Tags:           errorProperties.Metadata.Tags,
 				OSDCustomer:    collections.StringInSlice("osd_customer", errorProperties.Metadata.Tags),
+				MinOCPVersion:  minOCPVersion,
+				MaxOCPVersion:  maxOCPVersion,
 			})
 		}
 	}
+
+	rulesWithContentStorage.replace(storage)
 }
 
 // According to rule content specification, it's explicitly defined as floor((impact + likelihood) / 2), which
@@ -0,0 +1,113 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+const (
+	// refreshSourceContent is the metric label used for rules content
+	refreshSourceContent = "content"
+	// refreshSourceGroups is the metric label used for groups configuration
+	refreshSourceGroups = "groups"
+)
+
+var (
+	// ContentRefreshLastSuccess shows time of the last successful refresh
+	// of rules content or groups
+	ContentRefreshLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "content_refresh_last_success_timestamp_seconds",
+		Help: "Time of the last successful refresh of data retrieved from content service",
+	}, []string{"source"})
+
+	// ContentRefreshLastFailure shows time of the last failed refresh of
+	// rules content or groups
+	ContentRefreshLastFailure = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "content_refresh_last_failure_timestamp_seconds",
+		Help: "Time of the last failed refresh of data retrieved from content service",
+	}, []string{"source"})
+
+	// ContentRefreshFailures counts failed refreshes of rules content or
+	// groups
+	ContentRefreshFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "content_refresh_failures_total",
+		Help: "The total number of failed refreshes of data retrieved from content service",
+	}, []string{"source"})
+)
+
+// UpdateGroups function retrieves groups configuration from content service
+// and stores it into groupsStore. In case of error, the previously retrieved
+// groups are kept in the store together with the error.
+func UpdateGroups(servicesConf services.Configuration, groupsStore *GroupsStore) error {
+	if groupsStore == nil {
+		return nil
+	}
+
+	retrievedGroups, err := services.GetGroups(servicesConf)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving groups")
+		groupsStore.SetError(err)
+		recordRefreshFailure(refreshSourceGroups)
+		return err
+	}
+
+	groupsStore.SetGroups(retrievedGroups)
+	recordRefreshSuccess(refreshSourceGroups)
+	ContentStale.WithLabelValues(refreshSourceGroups).Set(0)
+
+	if servicesConf.ContentSnapshotDir != "" {
+		err = saveGroupsSnapshot(servicesConf.ContentSnapshotDir, retrievedGroups)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to store groups snapshot")
+		}
+	}
+	return nil
+}
+
+// refreshInterval returns the configured interval between refreshes. The
+// groups polling time is used when no refresh interval is configured.
+func refreshInterval(servicesConf services.Configuration) time.Duration {
+	if servicesConf.ContentRefreshInterval > 0 {
+		return servicesConf.ContentRefreshInterval
+	}
+	return servicesConf.GroupsPollingTime
+}
+
+// refreshDelay returns the interval prolonged by random duration up to the
+// jitter
+func refreshDelay(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	// #nosec G404 -- cryptographically secure random numbers are not needed
+	return interval + time.Duration(rand.Int63n(int64(jitter)))
+}
+
+func recordRefreshSuccess(source string) {
+	ContentRefreshLastSuccess.WithLabelValues(source).SetToCurrentTime()
+}
+
+func recordRefreshFailure(source string) {
+	ContentRefreshLastFailure.WithLabelValues(source).SetToCurrentTime()
+	ContentRefreshFailures.WithLabelValues(source).Inc()
+}
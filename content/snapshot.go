@@ -0,0 +1,205 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// contentSnapshotFile is the name of file with the rule content
+	// snapshot in the snapshot directory
+	contentSnapshotFile = "rules_content.gob"
+	// groupsSnapshotFile is the name of file with the groups snapshot in
+	// the snapshot directory
+	groupsSnapshotFile = "groups.json"
+)
+
+// ContentStale is set to 1 while the rule content or groups are served from
+// the on-disk snapshot, because content service was unreachable at startup
+var ContentStale = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "content_stale",
+	Help: "Indicates that data served are read from the snapshot, not from content service",
+}, []string{"source"})
+
+// contentSnapshot is the rule content stored on disk together with the
+// version reported by content service
+type contentSnapshot struct {
+	Version   string
+	SavedAt   time.Time
+	Directory ctypes.RuleContentDirectory
+}
+
+// groupsSnapshotData is the groups configuration stored on disk
+type groupsSnapshotData struct {
+	SavedAt time.Time      `json:"saved_at"`
+	Groups  []groups.Group `json:"groups"`
+}
+
+// writeFileAtomically writes data into temporary file in the same directory
+// and renames it afterwards, so readers never see a partially written file
+func writeFileAtomically(path string, data []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmpFile.Name()
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
+
+// saveContentSnapshot stores the rule content into the snapshot directory
+func saveContentSnapshot(dir string, directory *ctypes.RuleContentDirectory, version string) error {
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(contentSnapshot{
+		Version:   version,
+		SavedAt:   time.Now().UTC(),
+		Directory: *directory,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(filepath.Join(dir, contentSnapshotFile), buffer.Bytes())
+}
+
+// saveGroupsSnapshot stores the groups configuration into the snapshot
+// directory
+func saveGroupsSnapshot(dir string, retrievedGroups []groups.Group) error {
+	data, err := json.Marshal(groupsSnapshotData{
+		SavedAt: time.Now().UTC(),
+		Groups:  retrievedGroups,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(filepath.Join(dir, groupsSnapshotFile), data)
+}
+
+// LoadContentSnapshot function loads the rule content from the snapshot
+// directory. The content is marked as stale until it is refreshed from
+// content service successfully.
+func LoadContentSnapshot(dir string) error {
+	updateContentMutex.Lock()
+	defer updateContentMutex.Unlock()
+
+	// path is set in configuration, not by the client
+	// #nosec G304
+	data, err := ioutil.ReadFile(filepath.Join(dir, contentSnapshotFile))
+	if err != nil {
+		return err
+	}
+
+	var snapshot contentSnapshot
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot)
+	if err != nil {
+		return err
+	}
+
+	SetRuleContentDirectory(&snapshot.Directory)
+	LoadRuleContent(&snapshot.Directory)
+	contentStatus.setLoadedFromSnapshot(snapshot.Version, len(snapshot.Directory.Rules), snapshot.SavedAt)
+	ContentStale.WithLabelValues(refreshSourceContent).Set(1)
+
+	log.Warn().
+		Str("version", snapshot.Version).
+		Time("saved_at", snapshot.SavedAt).
+		Msg("Rule content loaded from snapshot")
+	return nil
+}
+
+// LoadGroupsSnapshot function loads the groups configuration from the
+// snapshot directory into groupsStore. The groups are marked as stale until
+// they are refreshed from content service successfully.
+func LoadGroupsSnapshot(dir string, groupsStore *GroupsStore) error {
+	if groupsStore == nil {
+		return nil
+	}
+
+	// path is set in configuration, not by the client
+	// #nosec G304
+	data, err := ioutil.ReadFile(filepath.Join(dir, groupsSnapshotFile))
+	if err != nil {
+		return err
+	}
+
+	var snapshot groupsSnapshotData
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	groupsStore.SetStaleGroups(snapshot.Groups, snapshot.SavedAt)
+	ContentStale.WithLabelValues(refreshSourceGroups).Set(1)
+
+	log.Warn().
+		Int("groups", len(snapshot.Groups)).
+		Time("saved_at", snapshot.SavedAt).
+		Msg("Groups loaded from snapshot")
+	return nil
+}
+
+// loadSnapshotsOnFailure loads the snapshots of data that could not be
+// retrieved from content service and that have not been loaded before
+func loadSnapshotsOnFailure(dir string, contentErr, groupsErr error, groupsStore *GroupsStore) {
+	if dir == "" {
+		return
+	}
+
+	if contentErr != nil && !GetStatus().Loaded {
+		if err := LoadContentSnapshot(dir); err != nil {
+			log.Error().Err(err).Str("dir", dir).Msg("Unable to load rule content snapshot")
+		}
+	}
+
+	if groupsErr != nil && groupsStore != nil {
+		if retrievedGroups, _ := groupsStore.Groups(); retrievedGroups == nil {
+			if err := LoadGroupsSnapshot(dir, groupsStore); err != nil {
+				log.Error().Err(err).Str("dir", dir).Msg("Unable to load groups snapshot")
+			}
+		}
+	}
+}
+
+// IsStale function returns true if the rule content or groups are served
+// from the snapshot
+func IsStale(groupsStore *GroupsStore) bool {
+	if GetStatus().Stale {
+		return true
+	}
+	return groupsStore != nil && groupsStore.Stale()
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	ics_server "github.com/RedHatInsights/insights-content-service/server"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+func expectContentRequest(t testing.TB) {
+	helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+		Method:   http.MethodGet,
+		Endpoint: ics_server.AllContentEndpoint,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       helpers.MustGobSerialize(t, testdata.RuleContentDirectory3Rules),
+	})
+}
+
+func TestContentSnapshot(t *testing.T) {
+	defer content.ResetContent()
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		servicesConf := helpers.DefaultServicesConfig
+		servicesConf.ContentSnapshotDir = t.TempDir()
+
+		// successful update stores the snapshot
+		expectContentRequest(t)
+		helpers.FailOnError(t, content.UpdateContent(servicesConf))
+		version := content.GetStatus().Version
+
+		content.ResetContent()
+		helpers.FailOnError(t, content.LoadContentSnapshot(servicesConf.ContentSnapshotDir))
+
+		status := content.GetStatus()
+		assert.True(t, status.Loaded)
+		assert.True(t, status.Stale)
+		assert.Equal(t, version, status.Version)
+		assert.Equal(t, len(testdata.RuleContentDirectory3Rules.Rules), status.RulesCount)
+		assert.True(t, content.IsStale(nil))
+		testGetRuleContentV2(t)
+
+		// next successful update clears the flag
+		expectContentRequest(t)
+		helpers.FailOnError(t, content.UpdateContent(servicesConf))
+		assert.False(t, content.GetStatus().Stale)
+	}, testTimeout)
+}
+
+func TestLoadContentSnapshotMissing(t *testing.T) {
+	err := content.LoadContentSnapshot(t.TempDir())
+	assert.Error(t, err)
+	assert.False(t, content.GetStatus().Stale)
+}
+
+func TestGroupsSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	expected := []groups.Group{{Name: "group", Tags: []string{"tag"}}}
+	helpers.FailOnError(t, content.SaveGroupsSnapshot(dir, expected))
+
+	store := content.NewGroupsStore()
+	store.SetError(errors.New("content service unavailable"))
+	helpers.FailOnError(t, content.LoadGroupsSnapshot(dir, store))
+
+	retrievedGroups, err := store.Groups()
+	assert.Equal(t, expected, retrievedGroups)
+	assert.Error(t, err)
+	assert.True(t, store.Stale())
+	assert.False(t, store.LastRefresh().IsZero())
+	assert.True(t, content.IsStale(store))
+
+	store.SetGroups(expected)
+	assert.False(t, store.Stale())
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"sync"
+	"time"
+)
+
+// Status represents the state of rule content retrieved from content service.
+// Handlers read a copy of it, so no reader can consume the error recorded by
+// the refresh loop before other readers see it.
+type Status struct {
+	Loaded      bool      `json:"loaded"`
+	Version     string    `json:"version"`
+	RulesCount  int       `json:"rules_count"`
+	LastRefresh time.Time `json:"last_refresh"`
+	LastError   string    `json:"last_error,omitempty"`
+	// LastErrorAt is the time of the last failed refresh, it is kept after
+	// successful refresh
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// FailedRefreshes is the number of failed refreshes since the last
+	// successful one
+	FailedRefreshes int `json:"failed_refreshes"`
+	// Stale is set when the content has been loaded from the on-disk
+	// snapshot and has not been refreshed from content service yet
+	Stale bool `json:"stale"`
+}
+
+// statusStorage is a thread safe holder of the content status
+type statusStorage struct {
+	sync.RWMutex
+	status Status
+}
+
+var contentStatus = statusStorage{}
+
+// setLoaded records successful refresh of the rule content
+func (s *statusStorage) setLoaded(version string, rulesCount int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.status.Loaded = true
+	s.status.Version = version
+	s.status.RulesCount = rulesCount
+	s.status.LastRefresh = time.Now().UTC()
+	s.status.LastError = ""
+	s.status.FailedRefreshes = 0
+	s.status.Stale = false
+}
+
+// setLoadedFromSnapshot records that the rule content has been loaded from
+// the snapshot saved at given time. The error of the failed refresh is kept.
+func (s *statusStorage) setLoadedFromSnapshot(version string, rulesCount int, savedAt time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.status.Loaded = true
+	s.status.Version = version
+	s.status.RulesCount = rulesCount
+	s.status.LastRefresh = savedAt
+	s.status.Stale = true
+}
+
+// setError records failed refresh of the rule content. Previously loaded
+// content is still served, so the loaded flag is kept as is.
+func (s *statusStorage) setError(err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	failedAt := time.Now().UTC()
+	s.status.LastError = err.Error()
+	s.status.LastErrorAt = &failedAt
+	s.status.FailedRefreshes++
+}
+
+// GetStatus returns the current state of rule content
+func GetStatus() Status {
+	contentStatus.RLock()
+	defer contentStatus.RUnlock()
+
+	return contentStatus.status
+}
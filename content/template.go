@@ -0,0 +1,438 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+// Rule content fields are doT.js templates (https://olado.github.io/doT/)
+// expecting the extra_data from the report in the pydata variable. Only the
+// subset of doT syntax used in rule content is implemented here:
+//
+//     {{=expr}}                      interpolation
+//     {{!expr}}                      interpolation with HTML encoding
+//     {{? expr}} {{?? expr}} {{??}} {{?}}   conditionals
+//     {{~ expr :value:index}} {{~}}  iteration over arrays
+//
+// where expr is a path like pydata.nodes[0].name, optionally negated by !.
+// Templates containing any other construct (arbitrary JavaScript) are left
+// unchanged, so the clients can still interpolate them.
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	templateTagOpen  = "{{"
+	templateTagClose = "}}"
+
+	// templateDataVariable is the name of variable containing extra_data
+	templateDataVariable = "pydata"
+	// templateRootVariable is the doT name of the data passed to template
+	templateRootVariable = "it"
+)
+
+var (
+	pathSegmentRegexp = regexp.MustCompile(`^[A-Za-z_$][\w$]*`)
+	iterateTagRegexp  = regexp.MustCompile(`^~\s*(.+?)\s*:\s*([A-Za-z_$][\w$]*)\s*(?::\s*([A-Za-z_$][\w$]*)\s*)?$`)
+)
+
+// TemplateError is returned when the template can't be interpolated
+type TemplateError struct {
+	reason string
+}
+
+func (e *TemplateError) Error() string {
+	return "unable to interpolate template: " + e.reason
+}
+
+// templateNode is a node of parsed template
+type templateNode interface {
+	render(out *strings.Builder, scope templateScope) error
+}
+
+type textNode string
+
+type outputNode struct {
+	expr   string
+	encode bool
+}
+
+type conditionalBranch struct {
+	expr  string
+	nodes []templateNode
+}
+
+type conditionalNode struct {
+	branches []conditionalBranch
+}
+
+type iterateNode struct {
+	expr      string
+	valueName string
+	indexName string
+	nodes     []templateNode
+}
+
+// templateScope maps variable names to their values
+type templateScope map[string]interface{}
+
+// InterpolateTemplate renders the doT template using the data as pydata
+// variable. The template is returned unchanged together with an error when
+// it uses unsupported constructs.
+func InterpolateTemplate(template string, data interface{}) (string, error) {
+	if !strings.Contains(template, templateTagOpen) {
+		return template, nil
+	}
+
+	nodes, rest, terminator, err := parseTemplate(template)
+	if err != nil {
+		return template, err
+	}
+	if terminator != "" || rest != "" {
+		return template, &TemplateError{reason: "unexpected " + terminator}
+	}
+
+	normalized, err := normalizeTemplateData(data)
+	if err != nil {
+		return template, err
+	}
+
+	scope := templateScope{
+		templateDataVariable: normalized,
+		templateRootVariable: normalized,
+	}
+
+	var out strings.Builder
+	if err := renderNodes(&out, nodes, scope); err != nil {
+		return template, err
+	}
+	return out.String(), nil
+}
+
+// normalizeTemplateData converts data to the generic structure produced by
+// JSON decoder, so the template can access all the values in the same way
+func normalizeTemplateData(data interface{}) (interface{}, error) {
+	var raw []byte
+	switch value := data.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		raw = value
+	case []byte:
+		raw = value
+	default:
+		var err error
+		raw, err = json.Marshal(value)
+		if err != nil {
+			return nil, &TemplateError{reason: err.Error()}
+		}
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, &TemplateError{reason: err.Error()}
+	}
+	return normalized, nil
+}
+
+// parseTemplate parses template until the end or until block terminating tag
+// is found. The terminating tag and the unparsed rest of the template are
+// returned too.
+func parseTemplate(template string) (nodes []templateNode, rest, terminator string, err error) {
+	rest = template
+	for rest != "" {
+		start := strings.Index(rest, templateTagOpen)
+		if start < 0 {
+			nodes = append(nodes, textNode(rest))
+			return nodes, "", "", nil
+		}
+		if start > 0 {
+			nodes = append(nodes, textNode(rest[:start]))
+		}
+
+		end := strings.Index(rest[start:], templateTagClose)
+		if end < 0 {
+			return nil, "", "", &TemplateError{reason: "unterminated tag"}
+		}
+		tag := strings.TrimSpace(rest[start+len(templateTagOpen) : start+end])
+		rest = rest[start+end+len(templateTagClose):]
+
+		switch {
+		case strings.HasPrefix(tag, "="):
+			nodes = append(nodes, outputNode{expr: strings.TrimSpace(tag[1:])})
+		case strings.HasPrefix(tag, "!"):
+			nodes = append(nodes, outputNode{expr: strings.TrimSpace(tag[1:]), encode: true})
+		case tag == "?" || strings.HasPrefix(tag, "??"):
+			return nodes, rest, tag, nil
+		case strings.HasPrefix(tag, "?"):
+			var node templateNode
+			node, rest, err = parseConditional(strings.TrimSpace(tag[1:]), rest)
+			if err != nil {
+				return nil, "", "", err
+			}
+			nodes = append(nodes, node)
+		case tag == "~":
+			return nodes, rest, tag, nil
+		case strings.HasPrefix(tag, "~"):
+			var node templateNode
+			node, rest, err = parseIterate(tag, rest)
+			if err != nil {
+				return nil, "", "", err
+			}
+			nodes = append(nodes, node)
+		default:
+			return nil, "", "", &TemplateError{reason: fmt.Sprintf("unsupported tag {{%s}}", tag)}
+		}
+	}
+	return nodes, "", "", nil
+}
+
+func parseConditional(expr, template string) (templateNode, string, error) {
+	node := conditionalNode{}
+	rest := template
+
+	for {
+		nodes, remaining, terminator, err := parseTemplate(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		node.branches = append(node.branches, conditionalBranch{expr: expr, nodes: nodes})
+		rest = remaining
+
+		switch {
+		case terminator == "?":
+			return node, rest, nil
+		case strings.HasPrefix(terminator, "??"):
+			// empty expression means else branch
+			expr = strings.TrimSpace(terminator[2:])
+		default:
+			return nil, "", &TemplateError{reason: "unterminated conditional"}
+		}
+	}
+}
+
+func parseIterate(tag, template string) (templateNode, string, error) {
+	match := iterateTagRegexp.FindStringSubmatch(tag)
+	if match == nil {
+		return nil, "", &TemplateError{reason: fmt.Sprintf("unsupported tag {{%s}}", tag)}
+	}
+
+	nodes, rest, terminator, err := parseTemplate(template)
+	if err != nil {
+		return nil, "", err
+	}
+	if terminator != "~" {
+		return nil, "", &TemplateError{reason: "unterminated iteration"}
+	}
+
+	return iterateNode{
+		expr:      match[1],
+		valueName: match[2],
+		indexName: match[3],
+		nodes:     nodes,
+	}, rest, nil
+}
+
+func renderNodes(out *strings.Builder, nodes []templateNode, scope templateScope) error {
+	for _, node := range nodes {
+		if err := node.render(out, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n textNode) render(out *strings.Builder, _ templateScope) error {
+	out.WriteString(string(n))
+	return nil
+}
+
+func (n outputNode) render(out *strings.Builder, scope templateScope) error {
+	value, err := evaluateExpression(n.expr, scope)
+	if err != nil {
+		return err
+	}
+
+	formatted := formatTemplateValue(value)
+	if n.encode {
+		formatted = html.EscapeString(formatted)
+	}
+	out.WriteString(formatted)
+	return nil
+}
+
+func (n conditionalNode) render(out *strings.Builder, scope templateScope) error {
+	for _, branch := range n.branches {
+		if branch.expr == "" {
+			return renderNodes(out, branch.nodes, scope)
+		}
+
+		value, err := evaluateExpression(branch.expr, scope)
+		if err != nil {
+			return err
+		}
+		if isTruthy(value) {
+			return renderNodes(out, branch.nodes, scope)
+		}
+	}
+	return nil
+}
+
+func (n iterateNode) render(out *strings.Builder, scope templateScope) error {
+	value, err := evaluateExpression(n.expr, scope)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return &TemplateError{reason: fmt.Sprintf("%s is not an array", n.expr)}
+	}
+
+	for i, item := range items {
+		itemScope := make(templateScope, len(scope)+2)
+		for name, value := range scope {
+			itemScope[name] = value
+		}
+		itemScope[n.valueName] = item
+		if n.indexName != "" {
+			itemScope[n.indexName] = float64(i)
+		}
+
+		if err := renderNodes(out, n.nodes, itemScope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateExpression evaluates path expression, optionally negated by !
+func evaluateExpression(expr string, scope templateScope) (interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "!") {
+		value, err := evaluateExpression(expr[1:], scope)
+		if err != nil {
+			return nil, err
+		}
+		return !isTruthy(value), nil
+	}
+
+	name := pathSegmentRegexp.FindString(expr)
+	if name == "" {
+		return nil, &TemplateError{reason: fmt.Sprintf("unsupported expression %s", expr)}
+	}
+	value, found := scope[name]
+	if !found {
+		return nil, &TemplateError{reason: fmt.Sprintf("unknown variable %s", name)}
+	}
+
+	rest := expr[len(name):]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			segment := pathSegmentRegexp.FindString(rest[1:])
+			if segment == "" {
+				return nil, &TemplateError{reason: fmt.Sprintf("unsupported expression %s", expr)}
+			}
+			value = lookupTemplateValue(value, segment)
+			rest = rest[1+len(segment):]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, &TemplateError{reason: fmt.Sprintf("unsupported expression %s", expr)}
+			}
+			key := strings.Trim(strings.TrimSpace(rest[1:end]), `"'`)
+			value = lookupTemplateValue(value, key)
+			rest = rest[end+1:]
+		default:
+			return nil, &TemplateError{reason: fmt.Sprintf("unsupported expression %s", expr)}
+		}
+	}
+
+	return value, nil
+}
+
+// lookupTemplateValue returns attribute of object, item of array or length
+// of array or string. Nil is returned for nonexistent attributes, the same
+// as undefined in JavaScript.
+func lookupTemplateValue(value interface{}, key string) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return typed[key]
+	case []interface{}:
+		if key == "length" {
+			return float64(len(typed))
+		}
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(typed) {
+			return nil
+		}
+		return typed[index]
+	case string:
+		if key == "length" {
+			return float64(len(typed))
+		}
+	}
+	return nil
+}
+
+// isTruthy evaluates value the same way as JavaScript does in conditions
+func isTruthy(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case float64:
+		return typed != 0
+	case string:
+		return typed != ""
+	default:
+		return true
+	}
+}
+
+// formatTemplateValue converts value to string the same way as JavaScript
+// does for simple values
+func formatTemplateValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, formatTemplateValue(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
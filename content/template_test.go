@@ -0,0 +1,94 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+const templateTestData = `{
+	"node": "master-0",
+	"count": 3,
+	"ratio": 0.5,
+	"degraded": true,
+	"empty": "",
+	"nodes": [{"name": "worker-0"}, {"name": "worker-1"}],
+	"html": "<b>x</b>"
+}`
+
+func TestInterpolateTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"no tags", "Plain text", "Plain text"},
+		{"interpolation", "Node {{=pydata.node}} has {{=pydata.count}} pods", "Node master-0 has 3 pods"},
+		{"float", "{{= pydata.ratio }}", "0.5"},
+		{"root variable", "{{=it.node}}", "master-0"},
+		{"missing value", "[{{=pydata.unknown}}]", "[]"},
+		{"encoding", "{{!pydata.html}}", "&lt;b&gt;x&lt;/b&gt;"},
+		{"index", "{{=pydata.nodes[1].name}}", "worker-1"},
+		{"length", "{{=pydata.nodes.length}}", "2"},
+		{"condition true", "{{?pydata.degraded}}degraded{{?}}", "degraded"},
+		{"condition false", "{{?pydata.empty}}empty{{?}}", ""},
+		{"negation", "{{?!pydata.empty}}not empty{{?}}", "not empty"},
+		{"else if", "{{?pydata.empty}}a{{??pydata.count}}b{{??}}c{{?}}", "b"},
+		{"else", "{{?pydata.empty}}a{{??}}c{{?}}", "c"},
+		{"iteration", "{{~pydata.nodes :node:i}}{{=i}}={{=node.name}};{{~}}", "0=worker-0;1=worker-1;"},
+		{"nested", "{{~pydata.nodes :n}}{{?n.name}}{{=n.name}} {{?}}{{~}}", "worker-0 worker-1 "},
+	}
+
+	var data interface{}
+	assert.NoError(t, json.Unmarshal([]byte(templateTestData), &data))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := content.InterpolateTemplate(tc.template, data)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestInterpolateTemplateRawData(t *testing.T) {
+	result, err := content.InterpolateTemplate(
+		"Node {{=pydata.node}}", json.RawMessage(templateTestData),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "Node master-0", result)
+}
+
+func TestInterpolateTemplateUnsupported(t *testing.T) {
+	templates := []string{
+		"{{ for (var i in pydata.nodes) { }}x{{ } }}",
+		"{{=pydata.count + 1}}",
+		"{{?pydata.degraded}}unterminated",
+		"{{=pydata.node",
+		"{{=unknown.value}}",
+		"{{~pydata.node :n}}x{{~}}",
+	}
+
+	for _, template := range templates {
+		result, err := content.InterpolateTemplate(template, map[string]interface{}{"node": "x"})
+		assert.Error(t, err, template)
+		assert.Equal(t, template, result)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"strconv"
+	"strings"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// Rule content metadata have no dedicated attributes for OCP versions the
+// rule applies to, so the constraints are read from tags, the same way as
+// osd_customer flag. For example "ocp_min_version:4.10" and
+// "ocp_max_version:4.12" tags mean that the rule applies to all 4.10, 4.11
+// and 4.12 releases.
+const (
+	minOCPVersionTagPrefix = "ocp_min_version:"
+	maxOCPVersionTagPrefix = "ocp_max_version:"
+)
+
+// parseOCPVersionConstraints reads the minimal and maximal OCP version from
+// the tags, empty string is returned for missing constraint
+func parseOCPVersionConstraints(tags []string) (minVersion, maxVersion string) {
+	for _, tag := range tags {
+		switch {
+		case strings.HasPrefix(tag, minOCPVersionTagPrefix):
+			minVersion = strings.TrimPrefix(tag, minOCPVersionTagPrefix)
+		case strings.HasPrefix(tag, maxOCPVersionTagPrefix):
+			maxVersion = strings.TrimPrefix(tag, maxOCPVersionTagPrefix)
+		}
+	}
+	return
+}
+
+// parseVersion returns numeric components of the version, pre-release and
+// build suffixes are ignored. False is returned for unparsable version.
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(version, ".")
+	components := make([]int, len(parts))
+	for i, part := range parts {
+		component, err := strconv.Atoi(part)
+		if err != nil || component < 0 {
+			return nil, false
+		}
+		components[i] = component
+	}
+	return components, true
+}
+
+// compareVersionWithConstraint compares the version with the constraint on
+// the components present in the constraint only, so 4.12.5 equals to 4.12
+// constraint. It returns -1, 0 or 1 when the version is lower, equal or
+// higher.
+func compareVersionWithConstraint(version, constraint []int) int {
+	for i, component := range constraint {
+		versionComponent := 0
+		if i < len(version) {
+			versionComponent = version[i]
+		}
+		if versionComponent < component {
+			return -1
+		}
+		if versionComponent > component {
+			return 1
+		}
+	}
+	return 0
+}
+
+// RuleAppliesToVersion returns whether the rule applies to the cluster
+// running given OCP version. Rules apply to clusters with unknown or
+// unparsable version, unparsable constraints are ignored.
+func RuleAppliesToVersion(rule *types.RuleWithContent, clusterVersion string) bool {
+	version, ok := parseVersion(clusterVersion)
+	if !ok {
+		return true
+	}
+
+	if minVersion, ok := parseVersion(rule.MinOCPVersion); ok &&
+		compareVersionWithConstraint(version, minVersion) < 0 {
+		return false
+	}
+	if maxVersion, ok := parseVersion(rule.MaxOCPVersion); ok &&
+		compareVersionWithConstraint(version, maxVersion) > 0 {
+		return false
+	}
+	return true
+}
+
+// IsRuleApplicableToVersion returns whether the rule with the error key
+// applies to the cluster running given OCP version
+func IsRuleApplicableToVersion(ruleID ctypes.RuleID, errorKey ctypes.ErrorKey, clusterVersion string) (bool, error) {
+	rule, err := GetRuleWithErrorKeyContent(ruleID, errorKey)
+	if err != nil {
+		return false, err
+	}
+	return RuleAppliesToVersion(rule, clusterVersion), nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestRuleAppliesToVersion checks the OCP version constraints of rules
+func TestRuleAppliesToVersion(t *testing.T) {
+	rule := &types.RuleWithContent{MinOCPVersion: "4.10", MaxOCPVersion: "4.12"}
+
+	for version, expected := range map[string]bool{
+		"":             true,
+		"unknown":      true,
+		"4.9.59":       false,
+		"4.10.0":       true,
+		"4.11.3":       true,
+		"4.12.45":      true,
+		"4.12.0-rc.1":  true,
+		"4.13.0":       false,
+		"5.0":          false,
+		"v4.11":        true,
+		"4.10.0+build": true,
+	} {
+		assert.Equal(t, expected, content.RuleAppliesToVersion(rule, version), version)
+	}
+
+	assert.True(t, content.RuleAppliesToVersion(&types.RuleWithContent{}, "4.12.1"))
+	assert.True(t, content.RuleAppliesToVersion(&types.RuleWithContent{MinOCPVersion: "latest"}, "4.12.1"))
+}
+
+// TestIsRuleApplicableToVersion checks that the constraints are read from
+// tags of the rule content
+func TestIsRuleApplicableToVersion(t *testing.T) {
+	defer content.ResetContent()
+
+	ruleContent := testdata.RuleContent1
+	ek := ruleContent.ErrorKeys[testdata.ErrorKey1]
+	ek.Metadata.Tags = append([]string{"ocp_min_version:4.11"}, ek.Metadata.Tags...)
+	// the error keys of shared test data must not be modified
+	ruleContent.ErrorKeys = map[string]ctypes.RuleErrorKeyContent{testdata.ErrorKey1: ek}
+
+	directory := ctypes.RuleContentDirectory{
+		Config: ctypes.GlobalRuleConfig{
+			Impact: testdata.ImpactStrToInt,
+		},
+		Rules: map[string]ctypes.RuleContent{
+			"rc1": ruleContent,
+		},
+	}
+	content.SetRuleContentDirectory(&directory)
+	content.LoadRuleContent(&directory)
+
+	applicable, err := content.IsRuleApplicableToVersion(testdata.Rule1ID, testdata.ErrorKey1, "4.10.5")
+	helpers.FailOnError(t, err)
+	assert.False(t, applicable)
+
+	applicable, err = content.IsRuleApplicableToVersion(testdata.Rule1ID, testdata.ErrorKey1, "4.11.0")
+	helpers.FailOnError(t, err)
+	assert.True(t, applicable)
+
+	_, err = content.IsRuleApplicableToVersion(testdata.Rule5ID, testdata.ErrorKey5, "4.11.0")
+	assert.Error(t, err)
+}
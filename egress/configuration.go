@@ -0,0 +1,41 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import "time"
+
+// HostOverride is a static mapping of host name to IP address, it takes
+// precedence over DNS
+type HostOverride struct {
+	Host string `mapstructure:"host" toml:"host"`
+	IP   string `mapstructure:"ip" toml:"ip"`
+}
+
+// Configuration represents configuration of connections to upstream
+// services. Default transport is used when nothing is configured, it honours
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+type Configuration struct {
+	// ProxyURL is the URL of egress proxy all connections go through
+	ProxyURL string `mapstructure:"proxy_url" toml:"proxy_url"`
+	// NoProxy lists hosts and domains connected directly, in the format
+	// of NO_PROXY environment variable
+	NoProxy []string `mapstructure:"no_proxy" toml:"no_proxy"`
+	// DNSServer is the address (host:port) of DNS server resolving host
+	// names, system resolver is used when empty
+	DNSServer string `mapstructure:"dns_server" toml:"dns_server"`
+	// DialTimeout limits the time of establishing the connection
+	DialTimeout time.Duration  `mapstructure:"dial_timeout" toml:"dial_timeout"`
+	Hosts       []HostOverride `mapstructure:"hosts" toml:"hosts"`
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package egress contains the HTTP transport used for connections to
+// upstream services (aggregator, content service and AMS API). It supports
+// egress proxy, custom DNS server and static overrides of host addresses.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// defaultDialTimeout is used when the dial timeout is not configured,
+	// it is the same as the one of the default transport
+	defaultDialTimeout = 30 * time.Second
+	// keepAlive is the period of keep-alive probes of connections
+	keepAlive = 30 * time.Second
+)
+
+// configured returns true when the configuration differs from the default
+// transport
+func (conf *Configuration) configured() bool {
+	return conf.ProxyURL != "" || conf.DNSServer != "" || conf.DialTimeout > 0 || len(conf.Hosts) > 0
+}
+
+// proxyFunc returns the function selecting the proxy for requests
+func (conf *Configuration) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if conf.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	if _, err := url.Parse(conf.ProxyURL); err != nil {
+		return nil, fmt.Errorf("invalid egress proxy URL: %w", err)
+	}
+	proxyConfig := httpproxy.Config{
+		HTTPProxy:  conf.ProxyURL,
+		HTTPSProxy: conf.ProxyURL,
+		NoProxy:    strings.Join(conf.NoProxy, ","),
+	}
+	proxy := proxyConfig.ProxyFunc()
+	return func(request *http.Request) (*url.URL, error) {
+		return proxy(request.URL)
+	}, nil
+}
+
+// dialContext returns the function establishing connections. Host names are
+// replaced by overridden IP addresses or resolved by the configured DNS
+// server.
+func (conf *Configuration) dialContext() (func(ctx context.Context, network, address string) (net.Conn, error), error) {
+	timeout := conf.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: keepAlive,
+	}
+
+	if conf.DNSServer != "" {
+		if _, _, err := net.SplitHostPort(conf.DNSServer); err != nil {
+			return nil, fmt.Errorf("invalid DNS server address: %w", err)
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dnsDialer := net.Dialer{Timeout: timeout}
+				return dnsDialer.DialContext(ctx, network, conf.DNSServer)
+			},
+		}
+	}
+
+	hosts := make(map[string]string, len(conf.Hosts))
+	for _, override := range conf.Hosts {
+		if net.ParseIP(override.IP) == nil {
+			return nil, fmt.Errorf("invalid IP address %q of host %q", override.IP, override.Host)
+		}
+		hosts[strings.ToLower(override.Host)] = override.IP
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if ip, found := hosts[strings.ToLower(host)]; found {
+				address = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, address)
+	}, nil
+}
+
+// NewTransport constructs the transport for connections to upstream
+// services. Nil is returned when nothing is configured, so the default
+// transport can be kept.
+func NewTransport(conf Configuration) (http.RoundTripper, error) {
+	if !conf.configured() {
+		return nil, nil
+	}
+
+	proxy, err := conf.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	dialContext, err := conf.dialContext()
+	if err != nil {
+		return nil, err
+	}
+
+	// default transport can be wrapped already, its settings are used
+	// otherwise
+	transport := &http.Transport{
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	}
+	transport.Proxy = proxy
+	transport.DialContext = dialContext
+	return transport, nil
+}
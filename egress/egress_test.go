@@ -0,0 +1,94 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/egress"
+)
+
+// TestNewTransportDefault checks that default transport is kept when nothing
+// is configured
+func TestNewTransportDefault(t *testing.T) {
+	transport, err := egress.NewTransport(egress.Configuration{})
+	assert.NoError(t, err)
+	assert.Nil(t, transport)
+}
+
+// TestNewTransportInvalid checks that invalid configuration is refused
+func TestNewTransportInvalid(t *testing.T) {
+	for _, conf := range []egress.Configuration{
+		{ProxyURL: "://proxy"},
+		{DNSServer: "10.0.0.1"},
+		{Hosts: []egress.HostOverride{{Host: "aggregator", IP: "not-ip"}}},
+	} {
+		_, err := egress.NewTransport(conf)
+		assert.Error(t, err)
+	}
+}
+
+// TestNewTransportHostOverride checks that connections to overridden hosts
+// go to the configured address
+func TestNewTransportHostOverride(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(request.Host))
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	assert.NoError(t, err)
+
+	transport, err := egress.NewTransport(egress.Configuration{
+		Hosts: []egress.HostOverride{{Host: "Aggregator.Example", IP: upstreamURL.Hostname()}},
+	})
+	assert.NoError(t, err)
+
+	client := http.Client{Transport: transport}
+	response, err := client.Get("http://aggregator.example:" + upstreamURL.Port() + "/api/v1/")
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "aggregator.example:"+upstreamURL.Port(), string(body))
+}
+
+// TestNewTransportProxy checks that requests are sent through the egress
+// proxy, except the ones for hosts connected directly
+func TestNewTransportProxy(t *testing.T) {
+	const proxy = "http://proxy.example:3128"
+	transport, err := egress.NewTransport(egress.Configuration{
+		ProxyURL: proxy,
+		NoProxy:  []string{"content.example"},
+	})
+	assert.NoError(t, err)
+
+	proxyFunc := transport.(*http.Transport).Proxy
+	request := httptest.NewRequest(http.MethodGet, "http://aggregator.example/api/v1/", http.NoBody)
+	proxyURL, err := proxyFunc(request)
+	assert.NoError(t, err)
+	assert.Equal(t, proxy, proxyURL.String())
+
+	request = httptest.NewRequest(http.MethodGet, "http://content.example/api/v1/", http.NoBody)
+	proxyURL, err = proxyFunc(request)
+	assert.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
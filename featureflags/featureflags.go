@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featureflags contains the interface used to decide whether optional
+// features (groups of REST API endpoints) are enabled, its implementation
+// based on the configuration file and its implementation backed by Unleash.
+package featureflags
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Features that can be turned on and off. Features not known to the
+// provider are enabled.
+const (
+	// DVOWorkloads is the group of endpoints returning DVO (Deployment
+	// Validation Operator) workload recommendations
+	DVOWorkloads = "dvo_workloads"
+	// UpgradeRisksPrediction is the endpoint returning the upgrade risks
+	// prediction retrieved from the Data Engineering Service
+	UpgradeRisksPrediction = "upgrade_risks_prediction"
+	// Acknowledgements is the group of endpoints manipulating rule
+	// acknowledgements
+	Acknowledgements = "acks"
+	// Webhooks is the group of endpoints managing webhooks notified about
+	// new critical recommendations together with the periodic scan
+	Webhooks = "webhooks"
+	// GraphQL is the endpoint resolving GraphQL queries over clusters,
+	// rules hitting them and rule content
+	GraphQL = "graphql"
+	// ReportEvents is the endpoint streaming server-sent events about new
+	// reports of the cluster
+	ReportEvents = "report_events"
+	// AdvisorAliases is the group of endpoints returning systems, hits and
+	// acknowledgements in the shapes of RHEL Insights Advisor API
+	AdvisorAliases = "advisor_aliases"
+)
+
+// Provider decides whether a feature is enabled. All implementations are safe
+// for concurrent use.
+type Provider interface {
+	// IsEnabled returns true if the feature is enabled
+	IsEnabled(feature string) bool
+}
+
+// Configuration represents configuration of feature flags
+type Configuration struct {
+	// Disabled is the list of features that are disabled. It is used as
+	// fallback for features not defined in Unleash when Unleash is used.
+	Disabled []string `mapstructure:"disabled" toml:"disabled"`
+	// UnleashURL is the base URL of Unleash client API, Unleash is not
+	// used when it is empty
+	UnleashURL string `mapstructure:"unleash_url" toml:"unleash_url"`
+	// UnleashToken is the client API token sent to Unleash
+	UnleashToken string `mapstructure:"unleash_token" toml:"unleash_token"`
+	// UnleashRefreshInterval is the time between refreshes of the flags
+	// retrieved from Unleash, zero means the default interval
+	UnleashRefreshInterval time.Duration `mapstructure:"unleash_refresh_interval" toml:"unleash_refresh_interval"`
+}
+
+// New constructs the provider according to the configuration. When Unleash is
+// configured, flags are read from it before the function returns and then
+// refreshed periodically in background.
+func New(config Configuration) Provider {
+	static := NewStaticProvider(config.Disabled)
+	if config.UnleashURL == "" {
+		return static
+	}
+
+	unleash := NewUnleashProvider(config.UnleashURL, config.UnleashToken, static)
+	if err := unleash.Refresh(); err != nil {
+		log.Error().Err(err).Msg("Unable to read feature flags from Unleash, configured flags are used")
+	}
+	go unleash.Run(config.UnleashRefreshInterval)
+	return unleash
+}
+
+// Reconfigure applies the list of disabled features from the configuration to
+// the provider constructed by New. Changes of Unleash settings require
+// restart.
+func Reconfigure(provider Provider, config Configuration) {
+	switch typed := provider.(type) {
+	case *StaticProvider:
+		typed.SetDisabled(config.Disabled)
+	case *UnleashProvider:
+		Reconfigure(typed.fallback, config)
+	}
+}
+
+// StaticProvider is the provider with flags set in configuration. Flags can
+// be changed at runtime by SetEnabled.
+type StaticProvider struct {
+	mutex    sync.RWMutex
+	disabled map[string]bool
+}
+
+// NewStaticProvider constructs the provider with given features disabled
+func NewStaticProvider(disabled []string) *StaticProvider {
+	provider := &StaticProvider{}
+	provider.SetDisabled(disabled)
+	return provider
+}
+
+// IsEnabled implements Provider interface
+func (provider *StaticProvider) IsEnabled(feature string) bool {
+	provider.mutex.RLock()
+	defer provider.mutex.RUnlock()
+
+	return !provider.disabled[feature]
+}
+
+// SetDisabled replaces the list of disabled features
+func (provider *StaticProvider) SetDisabled(features []string) {
+	disabled := make(map[string]bool)
+	for _, feature := range features {
+		disabled[feature] = true
+	}
+
+	provider.mutex.Lock()
+	provider.disabled = disabled
+	provider.mutex.Unlock()
+}
+
+// SetEnabled turns the feature on or off
+func (provider *StaticProvider) SetEnabled(feature string, enabled bool) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if enabled {
+		delete(provider.disabled, feature)
+	} else {
+		provider.disabled[feature] = true
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflags_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider := featureflags.NewStaticProvider([]string{featureflags.Acknowledgements})
+
+	assert.False(t, provider.IsEnabled(featureflags.Acknowledgements))
+	assert.True(t, provider.IsEnabled(featureflags.DVOWorkloads))
+	// unknown features are enabled
+	assert.True(t, provider.IsEnabled("unknown"))
+
+	provider.SetEnabled(featureflags.Acknowledgements, true)
+	provider.SetEnabled(featureflags.DVOWorkloads, false)
+	assert.True(t, provider.IsEnabled(featureflags.Acknowledgements))
+	assert.False(t, provider.IsEnabled(featureflags.DVOWorkloads))
+}
+
+func TestNewWithoutUnleash(t *testing.T) {
+	provider := featureflags.New(featureflags.Configuration{
+		Disabled: []string{featureflags.UpgradeRisksPrediction},
+	})
+
+	assert.IsType(t, &featureflags.StaticProvider{}, provider)
+	assert.False(t, provider.IsEnabled(featureflags.UpgradeRisksPrediction))
+	assert.True(t, provider.IsEnabled(featureflags.DVOWorkloads))
+}
+
+func TestReconfigure(t *testing.T) {
+	static := featureflags.NewStaticProvider([]string{featureflags.Acknowledgements})
+	unleash := featureflags.NewUnleashProvider("http://localhost", "", static)
+
+	featureflags.Reconfigure(unleash, featureflags.Configuration{
+		Disabled: []string{featureflags.DVOWorkloads},
+	})
+
+	assert.True(t, unleash.IsEnabled(featureflags.Acknowledgements))
+	assert.False(t, unleash.IsEnabled(featureflags.DVOWorkloads))
+	assert.False(t, static.IsEnabled(featureflags.DVOWorkloads))
+}
+
+func TestUnleashProvider(t *testing.T) {
+	features := `{"version": 1, "features": [
+		{"name": "dvo_workloads", "enabled": false},
+		{"name": "acks", "enabled": true}
+	]}`
+	unleash := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/api/client/features", request.URL.Path)
+		assert.Equal(t, "token", request.Header.Get("Authorization"))
+		_, _ = writer.Write([]byte(features))
+	}))
+	defer unleash.Close()
+
+	fallback := featureflags.NewStaticProvider([]string{
+		featureflags.Acknowledgements,
+		featureflags.UpgradeRisksPrediction,
+	})
+	provider := featureflags.NewUnleashProvider(unleash.URL+"/api", "token", fallback)
+
+	// fallback is used until the flags are read
+	assert.True(t, provider.IsEnabled(featureflags.DVOWorkloads))
+	assert.False(t, provider.IsEnabled(featureflags.Acknowledgements))
+
+	assert.NoError(t, provider.Refresh())
+	assert.False(t, provider.IsEnabled(featureflags.DVOWorkloads))
+	assert.True(t, provider.IsEnabled(featureflags.Acknowledgements))
+	// not defined in Unleash
+	assert.False(t, provider.IsEnabled(featureflags.UpgradeRisksPrediction))
+}
+
+func TestUnleashProviderKeepsStateOnError(t *testing.T) {
+	status := http.StatusOK
+	unleash := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(status)
+		_, _ = writer.Write([]byte(`{"features": [{"name": "acks", "enabled": false}]}`))
+	}))
+	defer unleash.Close()
+
+	provider := featureflags.NewUnleashProvider(unleash.URL, "", featureflags.NewStaticProvider(nil))
+	assert.NoError(t, provider.Refresh())
+	assert.False(t, provider.IsEnabled(featureflags.Acknowledgements))
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, provider.Refresh())
+	assert.False(t, provider.IsEnabled(featureflags.Acknowledgements))
+}
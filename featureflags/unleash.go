@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// unleashFeaturesEndpoint is the endpoint of Unleash client API
+	// returning definitions of all toggles
+	unleashFeaturesEndpoint = "client/features"
+
+	// unleashAppName is sent to Unleash to identify this service
+	unleashAppName = "insights-results-smart-proxy"
+
+	// defaultUnleashRefreshInterval is used when the interval is not
+	// defined in the configuration
+	defaultUnleashRefreshInterval = 15 * time.Second
+
+	// unleashTimeout limits the time spent by every request to Unleash
+	unleashTimeout = 10 * time.Second
+)
+
+// unleashFeatures is the part of Unleash client API response used by the
+// provider. Activation strategies are not evaluated, only the global state
+// of toggles is taken into account.
+type unleashFeatures struct {
+	Features []struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+	} `json:"features"`
+}
+
+// UnleashProvider is the provider with flags read from Unleash client API.
+// Features not defined in Unleash, or all features until the flags are read
+// for the first time, are decided by the fallback provider.
+type UnleashProvider struct {
+	client   http.Client
+	url      string
+	token    string
+	fallback Provider
+
+	mutex   sync.RWMutex
+	toggles map[string]bool
+}
+
+// NewUnleashProvider constructs the provider reading flags from Unleash
+// running at given URL
+func NewUnleashProvider(url, token string, fallback Provider) *UnleashProvider {
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+
+	return &UnleashProvider{
+		client:   http.Client{Timeout: unleashTimeout},
+		url:      url,
+		token:    token,
+		fallback: fallback,
+	}
+}
+
+// IsEnabled implements Provider interface
+func (provider *UnleashProvider) IsEnabled(feature string) bool {
+	provider.mutex.RLock()
+	enabled, found := provider.toggles[feature]
+	provider.mutex.RUnlock()
+
+	if !found {
+		return provider.fallback.IsEnabled(feature)
+	}
+	return enabled
+}
+
+// Refresh reads the current state of toggles from Unleash. The previous state
+// is kept when the request fails.
+func (provider *UnleashProvider) Refresh() error {
+	request, err := http.NewRequest(http.MethodGet, provider.url+unleashFeaturesEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", provider.token)
+	request.Header.Set("UNLEASH-APPNAME", unleashAppName)
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d returned by Unleash", response.StatusCode)
+	}
+
+	var features unleashFeatures
+	if err := json.NewDecoder(response.Body).Decode(&features); err != nil {
+		return err
+	}
+
+	toggles := make(map[string]bool, len(features.Features))
+	for _, feature := range features.Features {
+		toggles[feature.Name] = feature.Enabled
+	}
+
+	provider.mutex.Lock()
+	provider.toggles = toggles
+	provider.mutex.Unlock()
+
+	return nil
+}
+
+// Run refreshes the toggles periodically. It never returns, so it is supposed
+// to be started in separate goroutine.
+func (provider *UnleashProvider) Run(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultUnleashRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := provider.Refresh(); err != nil {
+			log.Error().Err(err).Msg("Unable to refresh feature flags from Unleash")
+		}
+	}
+}
@@ -9,15 +9,27 @@ require (
 	github.com/RedHatInsights/insights-results-aggregator v1.3.4
 	github.com/RedHatInsights/insights-results-aggregator-data v1.3.8
 	github.com/RedHatInsights/insights-results-types v1.3.22
+	github.com/Shopify/sarama v1.27.1
+	github.com/alicebob/miniredis/v2 v2.30.0
+	github.com/getsentry/sentry-go v0.6.1
+	github.com/go-redis/redis/v8 v8.11.5
 	github.com/golang-jwt/jwt/v4 v4.2.0
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
+	github.com/graph-gophers/graphql-go v1.3.0
+	github.com/lib/pq v1.10.4
+	github.com/microcosm-cc/bluemonday v1.0.21
 	github.com/openshift-online/ocm-sdk-go v0.1.238
+	github.com/opentracing/opentracing-go v1.2.0 // indirect
 	github.com/prometheus/client_golang v1.14.0
 	github.com/redhatinsights/app-common-go v1.6.3
 	github.com/rs/zerolog v1.26.1
 	github.com/spf13/viper v1.9.0
 	github.com/stretchr/testify v1.8.0
+	golang.org/x/net v0.7.0
+	golang.org/x/text v0.7.0
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.28.1
 	gopkg.in/h2non/gock.v1 v1.1.2
 )
@@ -0,0 +1,247 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcapi contains the gRPC interface providing reports, clusters and
+// rule content to internal consumers. Protocol buffers definitions are in
+// smart_proxy.proto file, the Go code is generated from them by protoc-gen-go
+// and protoc-gen-go-grpc plugins.
+//
+// The RPCs are served by the same handlers as REST API V2, so the
+// authentication, the validation and the returned data are the same for
+// both interfaces.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// Configuration represents configuration of gRPC server
+type Configuration struct {
+	// Address is the address the gRPC server listens on, the server is
+	// not started when it is empty
+	Address string `mapstructure:"address" toml:"address"`
+}
+
+// forwardedMetadata lists metadata forwarded to REST API handlers as HTTP
+// headers
+var forwardedMetadata = []string{"x-rh-identity", "authorization"}
+
+// grpcCodes maps HTTP status codes returned by REST API handlers to gRPC
+// status codes, codes.Internal is used for other HTTP status codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusServiceUnavailable: codes.Unavailable,
+	http.StatusGatewayTimeout:     codes.DeadlineExceeded,
+}
+
+// Server implements SmartProxyServer by calling REST API V2 handlers
+type Server struct {
+	UnimplementedSmartProxyServer
+	handler   http.Handler
+	apiPrefix string
+}
+
+// NewServer constructs the gRPC service calling given REST API handler with
+// API V2 endpoints registered under the prefix
+func NewServer(handler http.Handler, apiPrefix string) *Server {
+	return &Server{
+		handler:   handler,
+		apiPrefix: apiPrefix,
+	}
+}
+
+// Serve listens on the configured address and serves gRPC requests. It
+// returns when the listener fails.
+func Serve(config Configuration, smartProxy SmartProxyServer) error {
+	listener, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	RegisterSmartProxyServer(grpcServer, smartProxy)
+
+	log.Info().Msgf("Starting gRPC server at '%s'", config.Address)
+	return grpcServer.Serve(listener)
+}
+
+// responseBuffer keeps the response of REST API handler in memory
+type responseBuffer struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (buffer *responseBuffer) Header() http.Header {
+	return buffer.header
+}
+
+func (buffer *responseBuffer) Write(data []byte) (int, error) {
+	if buffer.statusCode == 0 {
+		buffer.statusCode = http.StatusOK
+	}
+	return buffer.body.Write(data)
+}
+
+func (buffer *responseBuffer) WriteHeader(statusCode int) {
+	if buffer.statusCode == 0 {
+		buffer.statusCode = statusCode
+	}
+}
+
+// statusError converts the error response of REST API handler into gRPC
+// status
+func statusError(statusCode int, body []byte) error {
+	code, found := grpcCodes[statusCode]
+	if !found {
+		code = codes.Internal
+	}
+
+	// problem details and responses proxied from aggregator are supported
+	var response struct {
+		Title  string      `json:"title"`
+		Detail string      `json:"detail"`
+		Status interface{} `json:"status"`
+	}
+	message := http.StatusText(statusCode)
+	if err := json.Unmarshal(body, &response); err == nil {
+		if statusMessage, ok := response.Status.(string); ok && statusMessage != "" {
+			message = statusMessage
+		}
+		if response.Title != "" {
+			message = response.Title
+		}
+		if response.Detail != "" {
+			message = response.Detail
+		}
+	}
+	return status.Error(code, message)
+}
+
+// call serves GET request to the endpoint by REST API handler and reads the
+// value under the key in the response into the message, whole response is
+// read when the key is empty. Identity metadata of the RPC are sent as HTTP
+// headers.
+func (service *Server) call(
+	ctx context.Context, endpoint string, query url.Values, key string, message proto.Message,
+) error {
+	endpointURL := service.apiPrefix + endpoint
+	if len(query) > 0 {
+		endpointURL += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL, nil)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if incoming, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, name := range forwardedMetadata {
+			if values := incoming.Get(name); len(values) > 0 {
+				request.Header.Set(name, values[0])
+			}
+		}
+	}
+
+	response := &responseBuffer{header: make(http.Header)}
+	service.handler.ServeHTTP(response, request)
+	if response.statusCode == 0 {
+		response.statusCode = http.StatusOK
+	}
+	if response.statusCode != http.StatusOK {
+		return statusError(response.statusCode, response.body.Bytes())
+	}
+
+	value := response.body.Bytes()
+	if key != "" {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(value, &data); err != nil {
+			log.Error().Err(err).Str("endpoint", endpoint).Msg("Unable to decode REST API response")
+			return status.Error(codes.Internal, err.Error())
+		}
+		var found bool
+		if value, found = data[key]; !found {
+			return status.Errorf(codes.Internal, "%s is missing in REST API response", key)
+		}
+	}
+
+	// fields of the messages are named after JSON attributes, attributes
+	// added to REST API later are skipped
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(value, message)
+	if err != nil {
+		log.Error().Err(err).Str("endpoint", endpoint).Msg("Unable to convert REST API response")
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// GetReport implements SmartProxyServer interface
+func (service *Server) GetReport(ctx context.Context, request *GetReportRequest) (*Report, error) {
+	if request.ClusterId == "" {
+		return nil, status.Error(codes.InvalidArgument, "cluster_id is required")
+	}
+
+	endpoint := strings.Replace(server.ReportEndpointV2, "{cluster}", url.PathEscape(request.ClusterId), 1)
+	report := &Report{}
+	if err := service.call(ctx, endpoint, nil, "report", report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListClusters implements SmartProxyServer interface
+func (service *Server) ListClusters(ctx context.Context, request *ListClustersRequest) (*ListClustersResponse, error) {
+	query := url.Values{}
+	if request.IncludeInactive {
+		query.Set(server.IncludeInactiveParam, "true")
+	}
+
+	// clusters are in data attribute of the response, like in the message
+	response := &ListClustersResponse{}
+	if err := service.call(ctx, server.ClustersRecommendationsEndpoint, query, "", response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetRuleContent implements SmartProxyServer interface
+func (service *Server) GetRuleContent(ctx context.Context, request *GetRuleContentRequest) (*RuleContent, error) {
+	if request.RuleId == "" {
+		return nil, status.Error(codes.InvalidArgument, "rule_id is required")
+	}
+
+	endpoint := strings.Replace(server.RuleContentV2, "{rule_id}", url.PathEscape(request.RuleId), 1)
+	ruleContent := &RuleContent{}
+	if err := service.call(ctx, endpoint, nil, "content", ruleContent); err != nil {
+		return nil, err
+	}
+	return ruleContent, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcapi_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/grpcapi"
+)
+
+const (
+	apiPrefix  = "/api/v2/"
+	identity   = "eyJpZGVudGl0eSI6IHt9fQ=="
+	clusterID  = "34c3ecc5-624a-49a5-bab8-4fdc5e51a266"
+	ruleID     = "ccx_rules_ocp.external.rules.nodes_kubelet_version_check|NODE_KUBELET_VERSION"
+	escapedURI = "/api/v2/rule/ccx_rules_ocp.external.rules.nodes_kubelet_version_check%7CNODE_KUBELET_VERSION/content"
+)
+
+// restAPI returns handler checking the request and responding by the body
+func restAPI(t *testing.T, expectedURI string, statusCode int, body string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, http.MethodGet, request.Method)
+		assert.Equal(t, expectedURI, request.URL.RequestURI())
+		assert.Equal(t, identity, request.Header.Get("x-rh-identity"))
+
+		writer.WriteHeader(statusCode)
+		_, err := writer.Write([]byte(body))
+		assert.NoError(t, err)
+	})
+}
+
+// identityContext returns context of RPC with identity metadata
+func identityContext() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-rh-identity", identity))
+}
+
+func TestGetReport(t *testing.T) {
+	service := grpcapi.NewServer(restAPI(t, "/api/v2/cluster/"+clusterID+"/reports", http.StatusOK, `{
+		"report": {
+			"meta": {"cluster_name": "My cluster", "managed": false, "count": 1, "gathered_at": "2023-01-01T10:00:00Z", "new_attribute": 42},
+			"data": [{
+				"rule_id": "`+ruleID+`",
+				"description": "Description",
+				"total_risk": 3,
+				"disabled": false,
+				"user_vote": 1,
+				"extra_data": {"type": "rule", "nodes": [{"name": "node1"}]},
+				"tags": ["security"]
+			}]
+		},
+		"status": "ok"
+	}`), apiPrefix)
+
+	report, err := service.GetReport(identityContext(), &grpcapi.GetReportRequest{ClusterId: clusterID})
+	assert.NoError(t, err)
+	assert.Equal(t, "My cluster", report.Meta.ClusterName)
+	assert.Equal(t, int32(1), report.Meta.Count)
+	assert.Equal(t, "2023-01-01T10:00:00Z", report.Meta.GatheredAt)
+	assert.Len(t, report.Data, 1)
+	assert.Equal(t, ruleID, report.Data[0].RuleId)
+	assert.Equal(t, int32(3), report.Data[0].TotalRisk)
+	assert.Equal(t, int32(1), report.Data[0].UserVote)
+	assert.Equal(t, "rule", report.Data[0].ExtraData.GetStructValue().Fields["type"].GetStringValue())
+	assert.Equal(t, []string{"security"}, report.Data[0].Tags)
+}
+
+func TestListClusters(t *testing.T) {
+	service := grpcapi.NewServer(restAPI(t, "/api/v2/clusters?include_inactive=true", http.StatusOK, `{
+		"data": [{
+			"cluster_id": "`+clusterID+`",
+			"cluster_name": "My cluster",
+			"managed": true,
+			"total_hit_count": 3,
+			"hits_by_total_risk": {"1": 2, "4": 1}
+		}],
+		"meta": {"count": 1},
+		"status": "ok"
+	}`), apiPrefix)
+
+	response, err := service.ListClusters(identityContext(), &grpcapi.ListClustersRequest{IncludeInactive: true})
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, clusterID, response.Data[0].ClusterId)
+	assert.True(t, response.Data[0].Managed)
+	assert.Equal(t, uint32(3), response.Data[0].TotalHitCount)
+	assert.Equal(t, map[int32]int32{1: 2, 4: 1}, response.Data[0].HitsByTotalRisk)
+}
+
+func TestGetRuleContent(t *testing.T) {
+	service := grpcapi.NewServer(restAPI(t, escapedURI, http.StatusOK, `{
+		"content": {"rule_id": "`+ruleID+`", "description": "Description", "total_risk": 2, "publish_date": "2020-04-08T00:42:00Z"},
+		"groups": [],
+		"status": "ok"
+	}`), apiPrefix)
+
+	content, err := service.GetRuleContent(identityContext(), &grpcapi.GetRuleContentRequest{RuleId: ruleID})
+	assert.NoError(t, err)
+	assert.Equal(t, ruleID, content.RuleId)
+	assert.Equal(t, "Description", content.Description)
+	assert.Equal(t, int32(2), content.TotalRisk)
+	assert.Equal(t, "2020-04-08T00:42:00Z", content.PublishDate)
+}
+
+func TestRESTAPIErrors(t *testing.T) {
+	service := grpcapi.NewServer(restAPI(t, escapedURI, http.StatusNotFound,
+		`{"title": "Not Found", "detail": "Rule not found", "status": 404}`,
+	), apiPrefix)
+	_, err := service.GetRuleContent(identityContext(), &grpcapi.GetRuleContentRequest{RuleId: ruleID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "Rule not found", status.Convert(err).Message())
+
+	// responses proxied from aggregator
+	service = grpcapi.NewServer(restAPI(t, "/api/v2/cluster/"+clusterID+"/reports", http.StatusServiceUnavailable,
+		`{"status": "Aggregator service is unavailable"}`,
+	), apiPrefix)
+	_, err = service.GetReport(identityContext(), &grpcapi.GetReportRequest{ClusterId: clusterID})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "Aggregator service is unavailable", status.Convert(err).Message())
+}
+
+func TestMissingArguments(t *testing.T) {
+	service := grpcapi.NewServer(http.NotFoundHandler(), apiPrefix)
+
+	_, err := service.GetReport(identityContext(), &grpcapi.GetReportRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = service.GetRuleContent(identityContext(), &grpcapi.GetRuleContentRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,183 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SmartProxyClient is the client API for SmartProxy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SmartProxyClient interface {
+	// GetReport returns the report of the cluster together with the content
+	// of rules hitting it
+	GetReport(ctx context.Context, in *GetReportRequest, opts ...grpc.CallOption) (*Report, error)
+	// ListClusters returns clusters of the organization with the number of
+	// rules hitting them
+	ListClusters(ctx context.Context, in *ListClustersRequest, opts ...grpc.CallOption) (*ListClustersResponse, error)
+	// GetRuleContent returns the content of the rule
+	GetRuleContent(ctx context.Context, in *GetRuleContentRequest, opts ...grpc.CallOption) (*RuleContent, error)
+}
+
+type smartProxyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSmartProxyClient(cc grpc.ClientConnInterface) SmartProxyClient {
+	return &smartProxyClient{cc}
+}
+
+func (c *smartProxyClient) GetReport(ctx context.Context, in *GetReportRequest, opts ...grpc.CallOption) (*Report, error) {
+	out := new(Report)
+	err := c.cc.Invoke(ctx, "/smartproxy.v1.SmartProxy/GetReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *smartProxyClient) ListClusters(ctx context.Context, in *ListClustersRequest, opts ...grpc.CallOption) (*ListClustersResponse, error) {
+	out := new(ListClustersResponse)
+	err := c.cc.Invoke(ctx, "/smartproxy.v1.SmartProxy/ListClusters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *smartProxyClient) GetRuleContent(ctx context.Context, in *GetRuleContentRequest, opts ...grpc.CallOption) (*RuleContent, error) {
+	out := new(RuleContent)
+	err := c.cc.Invoke(ctx, "/smartproxy.v1.SmartProxy/GetRuleContent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SmartProxyServer is the server API for SmartProxy service.
+// All implementations must embed UnimplementedSmartProxyServer
+// for forward compatibility
+type SmartProxyServer interface {
+	// GetReport returns the report of the cluster together with the content
+	// of rules hitting it
+	GetReport(context.Context, *GetReportRequest) (*Report, error)
+	// ListClusters returns clusters of the organization with the number of
+	// rules hitting them
+	ListClusters(context.Context, *ListClustersRequest) (*ListClustersResponse, error)
+	// GetRuleContent returns the content of the rule
+	GetRuleContent(context.Context, *GetRuleContentRequest) (*RuleContent, error)
+	mustEmbedUnimplementedSmartProxyServer()
+}
+
+// UnimplementedSmartProxyServer must be embedded to have forward compatible implementations.
+type UnimplementedSmartProxyServer struct {
+}
+
+func (UnimplementedSmartProxyServer) GetReport(context.Context, *GetReportRequest) (*Report, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReport not implemented")
+}
+func (UnimplementedSmartProxyServer) ListClusters(context.Context, *ListClustersRequest) (*ListClustersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClusters not implemented")
+}
+func (UnimplementedSmartProxyServer) GetRuleContent(context.Context, *GetRuleContentRequest) (*RuleContent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRuleContent not implemented")
+}
+func (UnimplementedSmartProxyServer) mustEmbedUnimplementedSmartProxyServer() {}
+
+// UnsafeSmartProxyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SmartProxyServer will
+// result in compilation errors.
+type UnsafeSmartProxyServer interface {
+	mustEmbedUnimplementedSmartProxyServer()
+}
+
+func RegisterSmartProxyServer(s grpc.ServiceRegistrar, srv SmartProxyServer) {
+	s.RegisterService(&SmartProxy_ServiceDesc, srv)
+}
+
+func _SmartProxy_GetReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SmartProxyServer).GetReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/smartproxy.v1.SmartProxy/GetReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SmartProxyServer).GetReport(ctx, req.(*GetReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SmartProxy_ListClusters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListClustersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SmartProxyServer).ListClusters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/smartproxy.v1.SmartProxy/ListClusters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SmartProxyServer).ListClusters(ctx, req.(*ListClustersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SmartProxy_GetRuleContent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRuleContentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SmartProxyServer).GetRuleContent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/smartproxy.v1.SmartProxy/GetRuleContent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SmartProxyServer).GetRuleContent(ctx, req.(*GetRuleContentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SmartProxy_ServiceDesc is the grpc.ServiceDesc for SmartProxy service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SmartProxy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "smartproxy.v1.SmartProxy",
+	HandlerType: (*SmartProxyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetReport",
+			Handler:    _SmartProxy_GetReport_Handler,
+		},
+		{
+			MethodName: "ListClusters",
+			Handler:    _SmartProxy_ListClusters_Handler,
+		},
+		{
+			MethodName: "GetRuleContent",
+			Handler:    _SmartProxy_GetRuleContent_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "smart_proxy.proto",
+}
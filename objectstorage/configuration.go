@@ -0,0 +1,43 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import "time"
+
+// Configuration represents configuration of S3-compatible object storage.
+// The storage is disabled when the bucket is not set.
+type Configuration struct {
+	// Endpoint is the base URL of the storage, like
+	// https://s3.us-east-1.amazonaws.com
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint"`
+	Region   string `mapstructure:"region" toml:"region"`
+	Bucket   string `mapstructure:"bucket" toml:"bucket"`
+	// Prefix is prepended to the keys of all stored objects
+	Prefix          string `mapstructure:"prefix" toml:"prefix"`
+	AccessKeyID     string `mapstructure:"access_key_id" toml:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key" toml:"secret_access_key"`
+	// PathStyle puts the bucket into the URL path instead of the host
+	// name, it is usually needed by storages other than AWS S3
+	PathStyle bool          `mapstructure:"path_style" toml:"path_style"`
+	Timeout   time.Duration `mapstructure:"timeout" toml:"timeout"`
+	// PresignExpiry is the lifetime of presigned download URLs
+	PresignExpiry time.Duration `mapstructure:"presign_expiry" toml:"presign_expiry"`
+	// Retention is the time after which the stored objects are deleted,
+	// zero keeps them until they are deleted by the bucket lifecycle
+	Retention time.Duration `mapstructure:"retention" toml:"retention"`
+	// CleanupInterval is the time between deletions of objects older than
+	// the retention
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval" toml:"cleanup_interval"`
+}
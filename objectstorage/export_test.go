@@ -0,0 +1,28 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import "time"
+
+// Export for testing
+//
+// This source file contains helpers that give unit tests access to
+// package-private parts of the client, because unit tests belong to
+// different package.
+
+// SetNow replaces the clock used to sign requests
+func SetNow(client *Client, now func() time.Time) {
+	client.now = now
+}
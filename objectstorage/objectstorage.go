@@ -0,0 +1,232 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package objectstorage contains minimal client of S3-compatible object
+// storage. Requests are authenticated by AWS Signature Version 4.
+package objectstorage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultTimeout is used when the timeout is not configured
+	defaultTimeout = 30 * time.Second
+	// defaultPresignExpiry is used when the lifetime of presigned URLs is
+	// not configured
+	defaultPresignExpiry = 15 * time.Minute
+	// maxErrorBodySize is the maximal part of error response included in
+	// the error message
+	maxErrorBodySize = 512
+)
+
+// Object describes single object stored in the bucket
+type Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// listBucketResult is the response of ListObjectsV2 operation
+type listBucketResult struct {
+	Contents              []Object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// Client is a client of single bucket. It is safe for concurrent use.
+type Client struct {
+	config     Configuration
+	endpoint   *url.URL
+	httpClient http.Client
+
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+// New constructs the client of the bucket from the configuration
+func New(config Configuration) (*Client, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("object storage endpoint %q is not absolute URL", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("object storage bucket is not set")
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Client{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: http.Client{Timeout: timeout},
+		now:        time.Now,
+	}, nil
+}
+
+// Prefix returns the prefix of keys of all stored objects
+func (client *Client) Prefix() string {
+	return client.config.Prefix
+}
+
+// bucketURL returns the URL of given path in the bucket
+func (client *Client) bucketURL(path string) *url.URL {
+	bucketURL := *client.endpoint
+	basePath := strings.TrimSuffix(bucketURL.Path, "/")
+	if client.config.PathStyle {
+		path = basePath + "/" + client.config.Bucket + "/" + path
+	} else {
+		bucketURL.Host = client.config.Bucket + "." + bucketURL.Host
+		path = basePath + "/" + path
+	}
+	bucketURL.Path = path
+	bucketURL.RawPath = uriEncode(path, false)
+	bucketURL.RawQuery = ""
+	return &bucketURL
+}
+
+// do signs and sends the request, error is returned for responses with
+// other than 2xx status codes
+func (client *Client) do(method string, requestURL *url.URL, body []byte, contentType string) ([]byte, error) {
+	request, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	client.signRequest(request, hashSHA256(body))
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Error().Err(err).Msg("Unable to close object storage response body")
+		}
+	}()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		if len(responseBody) > maxErrorBodySize {
+			responseBody = responseBody[:maxErrorBodySize]
+		}
+		return nil, fmt.Errorf(
+			"object storage responded to %s with status code %d: %s", method, response.StatusCode, responseBody,
+		)
+	}
+	return responseBody, nil
+}
+
+// Put stores the object under given full key
+func (client *Client) Put(key, contentType string, data []byte) error {
+	_, err := client.do(http.MethodPut, client.bucketURL(key), data, contentType)
+	return err
+}
+
+// Delete deletes the object with given full key
+func (client *Client) Delete(key string) error {
+	_, err := client.do(http.MethodDelete, client.bucketURL(key), nil, "")
+	return err
+}
+
+// List returns all objects whose full key starts with the prefix of the
+// client
+func (client *Client) List() ([]Object, error) {
+	var objects []Object
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if client.config.Prefix != "" {
+			query.Set("prefix", client.config.Prefix)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		listURL := client.bucketURL("")
+		listURL.RawQuery = canonicalQuery(query)
+
+		body, err := client.do(http.MethodGet, listURL, nil, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		objects = append(objects, result.Contents...)
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// PresignGet returns the URL allowing anyone to download the object with
+// given full key within the configured time
+func (client *Client) PresignGet(key string) string {
+	expires := client.config.PresignExpiry
+	if expires <= 0 {
+		expires = defaultPresignExpiry
+	}
+	return client.presignURL(client.bucketURL(key), expires)
+}
+
+// DeleteExpired deletes the objects older than the configured retention and
+// returns the number of deleted objects. Nothing is deleted when the
+// retention is not configured.
+func (client *Client) DeleteExpired() (int, error) {
+	if client.config.Retention <= 0 {
+		return 0, nil
+	}
+
+	objects, err := client.List()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	threshold := client.now().Add(-client.config.Retention)
+	for _, object := range objects {
+		if !object.LastModified.Before(threshold) {
+			continue
+		}
+		if err := client.Delete(object.Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 constants
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	signingService   = "s3"
+	signingRequest   = "aws4_request"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+
+	amzDateFormat      = "20060102T150405Z"
+	amzShortDateFormat = "20060102"
+
+	amzDateHeader          = "X-Amz-Date"
+	amzContentSHA256Header = "X-Amz-Content-Sha256"
+)
+
+// uriEncode encodes the string as required by Signature Version 4, all
+// characters except the unreserved ones are percent-encoded. Slashes are
+// kept when encoding paths.
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// canonicalQuery returns the query parameters sorted by name and encoded
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func hashSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// credentialScope returns the scope of the signature made at given time
+func (client *Client) credentialScope(now time.Time) string {
+	return strings.Join([]string{
+		now.Format(amzShortDateFormat), client.config.Region, signingService, signingRequest,
+	}, "/")
+}
+
+// signature signs the canonical request by the key derived from the secret
+// access key
+func (client *Client) signature(now time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(amzDateFormat),
+		client.credentialScope(now),
+		hashSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+client.config.SecretAccessKey), now.Format(amzShortDateFormat))
+	key = hmacSHA256(key, client.config.Region)
+	key = hmacSHA256(key, signingService)
+	key = hmacSHA256(key, signingRequest)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// signRequest adds the Authorization header to the request. The host, date
+// and payload hash headers are signed.
+func (client *Client) signRequest(request *http.Request, payloadHash string) {
+	now := client.now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	request.Header.Set(amzDateHeader, amzDate)
+	request.Header.Set(amzContentSHA256Header, payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalQuery(request.URL.Query()),
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, client.config.AccessKeyID, client.credentialScope(now),
+		signedHeaders, client.signature(now, canonicalRequest),
+	))
+}
+
+// presignURL adds the query parameters authorizing GET request of the URL
+// for given time
+func (client *Client) presignURL(objectURL *url.URL, expires time.Duration) string {
+	now := client.now().UTC()
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", client.config.AccessKeyID+"/"+client.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	presigned := *objectURL
+	presigned.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + client.signature(now, canonicalRequest)
+	return presigned.String()
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preferences
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// FileStore keeps the preferences of all users in memory and writes them
+// into JSON file on every change
+type FileStore struct {
+	mutex       sync.RWMutex
+	path        string
+	preferences map[string]types.UserPreferences
+}
+
+// NewFileStore constructs the store backed by the file. Preferences stored
+// in the file are loaded, the file is created by the first change if it does
+// not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{
+		path:        path,
+		preferences: make(map[string]types.UserPreferences),
+	}
+
+	// path is set in configuration, not by the client
+	// #nosec G304
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Info().Str("file", path).Msg("No user preferences stored yet")
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.preferences); err != nil {
+		return nil, err
+	}
+	log.Info().Str("file", path).Int("users", len(store.preferences)).Msg("User preferences loaded")
+	return store, nil
+}
+
+// Get implements Store interface
+func (store *FileStore) Get(key Key) (types.UserPreferences, bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	preferences, found := store.preferences[key.String()]
+	return preferences, found, nil
+}
+
+// Set implements Store interface
+func (store *FileStore) Set(key Key, preferences types.UserPreferences) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	previous, found := store.preferences[key.String()]
+	store.preferences[key.String()] = preferences
+	if err := store.save(); err != nil {
+		// keep the memory consistent with the file
+		if found {
+			store.preferences[key.String()] = previous
+		} else {
+			delete(store.preferences, key.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete implements Store interface
+func (store *FileStore) Delete(key Key) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	previous, found := store.preferences[key.String()]
+	if !found {
+		return nil
+	}
+	delete(store.preferences, key.String())
+	if err := store.save(); err != nil {
+		store.preferences[key.String()] = previous
+		return err
+	}
+	return nil
+}
+
+// save writes all preferences into temporary file in the same directory and
+// renames it afterwards, so the file is never written partially. It needs
+// to be called with the mutex locked.
+func (store *FileStore) save() error {
+	data, err := json.Marshal(store.preferences)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmpFile.Name()
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, store.path)
+}
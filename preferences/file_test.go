@@ -0,0 +1,103 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preferences_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func TestNewDisabled(t *testing.T) {
+	store, err := preferences.New(preferences.Configuration{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	_, err = preferences.New(preferences.Configuration{Store: "redis"}, nil)
+	assert.Error(t, err)
+
+	// shared store needs the shared storage
+	_, err = preferences.New(preferences.Configuration{Store: preferences.StoreShared}, nil)
+	assert.Error(t, err)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "account/123/42", preferences.Key{AccountNumber: "123", OrgID: 1, UserID: "42"}.String())
+	// anemic tenants
+	assert.Equal(t, "org/1/42", preferences.Key{OrgID: 1, UserID: "42"}.String())
+	assert.Equal(t, "org/1/42", preferences.Key{AccountNumber: "0", OrgID: 1, UserID: "42"}.String())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preferences")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "preferences.json")
+
+	store, err := preferences.New(preferences.Configuration{Store: preferences.StoreFile, File: path}, nil)
+	assert.NoError(t, err)
+
+	key := preferences.Key{AccountNumber: "123", OrgID: testdata.OrgID, UserID: testdata.UserID}
+	_, found, err := store.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	stored := types.UserPreferences{
+		SuppressLowRisk: true,
+		DefaultFilters:  map[string]string{"total_risk": "3,4"},
+		Rules: map[types.RuleID]types.RulePreferences{
+			testdata.Rule1CompositeID: {Hidden: true},
+		},
+	}
+	assert.NoError(t, store.Set(key, stored))
+
+	// preferences are loaded from the file
+	reopened, err := preferences.NewFileStore(path)
+	assert.NoError(t, err)
+	loaded, found, err := reopened.Get(key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, stored, loaded)
+
+	// other users are not affected
+	_, found, err = reopened.Get(preferences.Key{AccountNumber: "123", OrgID: testdata.OrgID, UserID: "other"})
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, reopened.Delete(key))
+	reopened, err = preferences.NewFileStore(path)
+	assert.NoError(t, err)
+	_, found, err = reopened.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestFileStoreMalformedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preferences")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "preferences.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+	_, err = preferences.NewFileStore(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preferences contains the interface of the store of Advisor
+// preferences of users and its implementations.
+package preferences
+
+import (
+	"fmt"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// Supported types of preferences stores
+const (
+	// StoreNone disables the preferences
+	StoreNone = ""
+	// StoreFile keeps the preferences in local JSON file
+	StoreFile = "file"
+	// StoreShared keeps the preferences in the storage shared by all
+	// replicas
+	StoreShared = "shared"
+)
+
+// Key identifies the user whose preferences are stored. Preferences are
+// keyed by the account number. Organization ID is used for accounts without
+// account number.
+type Key struct {
+	AccountNumber string
+	OrgID         ctypes.OrgID
+	UserID        ctypes.UserID
+}
+
+// String returns the key as it is used by the stores
+func (key Key) String() string {
+	if key.AccountNumber == "" || key.AccountNumber == "0" {
+		return fmt.Sprintf("org/%v/%v", key.OrgID, key.UserID)
+	}
+	return fmt.Sprintf("account/%v/%v", key.AccountNumber, key.UserID)
+}
+
+// Store persists the preferences of users. All implementations are safe for
+// concurrent use.
+type Store interface {
+	// Get returns the preferences of the user. False is returned when
+	// the user has not stored any preferences.
+	Get(key Key) (types.UserPreferences, bool, error)
+	// Set replaces the preferences of the user
+	Set(key Key, preferences types.UserPreferences) error
+	// Delete removes the preferences of the user, so the defaults are
+	// used again
+	Delete(key Key) error
+}
+
+// Configuration represents configuration of preferences store
+type Configuration struct {
+	// Store is the type of the store, preferences are disabled when it is
+	// empty
+	Store string `mapstructure:"store" toml:"store"`
+	// File is the path to the file with preferences when file store is
+	// used
+	File string `mapstructure:"file" toml:"file"`
+}
+
+// New constructs the store according to the configuration. Nil is returned
+// when the preferences are disabled. The shared storage is used by the
+// shared store only, it can be nil otherwise.
+func New(config Configuration, shared storage.Store) (Store, error) {
+	switch config.Store {
+	case StoreNone:
+		log.Info().Msg("User preferences are disabled")
+		return nil, nil
+	case StoreFile:
+		store, err := NewFileStore(config.File)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case StoreShared:
+		if shared == nil {
+			return nil, fmt.Errorf("shared storage is not configured")
+		}
+		return NewSharedStore(shared), nil
+	default:
+		return nil, fmt.Errorf("unknown preferences store: %s", config.Store)
+	}
+}
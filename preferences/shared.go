@@ -0,0 +1,64 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preferences
+
+import (
+	"encoding/json"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// sharedKeyPrefix is prepended to the keys of preferences in the shared
+// storage
+const sharedKeyPrefix = "preferences/"
+
+// SharedStore keeps the preferences as JSON documents in the storage shared
+// by all replicas of the service
+type SharedStore struct {
+	storage storage.Store
+}
+
+// NewSharedStore constructs the store using the shared storage
+func NewSharedStore(shared storage.Store) *SharedStore {
+	return &SharedStore{storage: shared}
+}
+
+// Get returns the preferences of the user
+func (store *SharedStore) Get(key Key) (types.UserPreferences, bool, error) {
+	var preferences types.UserPreferences
+	data, found, err := store.storage.Get(sharedKeyPrefix + key.String())
+	if err != nil || !found {
+		return preferences, false, err
+	}
+	if err := json.Unmarshal(data, &preferences); err != nil {
+		return preferences, false, err
+	}
+	return preferences, true, nil
+}
+
+// Set replaces the preferences of the user
+func (store *SharedStore) Set(key Key, preferences types.UserPreferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+	return store.storage.Set(sharedKeyPrefix+key.String(), data, 0)
+}
+
+// Delete removes the preferences of the user
+func (store *SharedStore) Delete(key Key) error {
+	return store.storage.Delete(sharedKeyPrefix + key.String())
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preferences_test
+
+import (
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func TestSharedStore(t *testing.T) {
+	shared := storage.NewMemoryStore()
+	store, err := preferences.New(preferences.Configuration{Store: preferences.StoreShared}, shared)
+	assert.NoError(t, err)
+
+	key := preferences.Key{AccountNumber: "123", OrgID: testdata.OrgID, UserID: testdata.UserID}
+	_, found, err := store.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	stored := types.UserPreferences{
+		SuppressLowRisk: true,
+		DefaultFilters:  map[string]string{"total_risk": "3,4"},
+	}
+	assert.NoError(t, store.Set(key, stored))
+
+	// preferences are visible to other replicas using the same storage
+	loaded, found, err := preferences.NewSharedStore(shared).Get(key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, stored, loaded)
+
+	_, found, err = shared.Get("preferences/" + key.String())
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	assert.NoError(t, store.Delete(key))
+	_, found, err = store.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
@@ -28,6 +28,8 @@ import (
 
 	"github.com/RedHatInsights/insights-operator-utils/parsers"
 	types "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
 )
 
 // HTTP response-related constants
@@ -196,7 +198,11 @@ func (server *HTTPServer) acknowledgePost(writer http.ResponseWriter, request *h
 	if err != nil {
 		log.Error().Err(err).Msg(improperRuleSelectorFormat)
 		// return HTTP code 400 to client
-		http.Error(writer, err.Error(), http.StatusBadRequest)
+		handleServerError(writer, &RouterParsingError{
+			paramName:  "rule_id",
+			paramValue: parameters.RuleSelector,
+			errString:  err.Error(),
+		})
 		return
 	}
 
@@ -226,9 +232,13 @@ func (server *HTTPServer) acknowledgePost(writer http.ResponseWriter, request *h
 		err := server.ackRuleSystemWide(ruleID, errorKey, orgID, parameters.Value)
 		if err != nil {
 			log.Error().Err(err).Msg(readRuleJustificationError)
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			err = sendProblem(writer, newProblem(ErrorCodeInvalidBody, err.Error()))
+			if err != nil {
+				log.Error().Err(err).Msg(responseDataError)
+			}
 			return
 		}
+		server.publishAuditEvent(request, audit.RuleDisabled, "", types.RuleID(ruleID), errorKey)
 	}
 
 	// Aggregator REST API is source of truth - let's re-read rule status
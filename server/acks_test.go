@@ -69,7 +69,7 @@ func TestHTTPServer_TestReadAckListNoResult(t *testing.T) {
 		}
 	}
 	`
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -145,7 +145,7 @@ func TestHTTPServer_TestReadAckList1Result(t *testing.T) {
 	`
 	ackListResponse = fmt.Sprintf(ackListResponse, testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -247,7 +247,7 @@ func TestHTTPServer_TestReadAckList2Results(t *testing.T) {
 		testdata.Rule2CompositeID, justificationNote2, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -288,10 +288,14 @@ func TestHTTPServer_TestReadAckListInvalidToken(t *testing.T) {
 
 	ackListResponse := `
 	{
-		"status": "Malformed authentication token"
+		"type": "urn:insights-results-smart-proxy:error:authentication_failed",
+		"title": "Authentication failed",
+		"status": 403,
+		"detail": "Malformed authentication token",
+		"code": "authentication_failed"
 	}
 	`
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: badJWTAuthBearer,
@@ -321,7 +325,7 @@ func TestHTTPServer_TestReadAckListAggregatorError(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -351,7 +355,7 @@ func TestHTTPServer_TestReadAckListUnparsableAggregatorJSON(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckListEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -389,7 +393,7 @@ func TestHTTPServer_TestGetAcknowledgeNotFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -419,7 +423,7 @@ func TestHTTPServer_TestGetAcknowledgeAggregatorError(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -450,7 +454,7 @@ func TestHTTPServer_TestGetAcknowledgeUnparsableAggregatorJSON(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -522,7 +526,7 @@ func TestHTTPServer_TestGetAcknowledgeFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -581,7 +585,7 @@ func TestHTTPServer_TestGetAcknowledgeInvalidRuleIDBadRequest(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -612,7 +616,7 @@ func TestHTTPServer_TestGetAcknowledgeInvalidToken(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodGet,
 		Endpoint:           server.AckGetEndpoint,
 		AuthorizationToken: invalidJWTAuthBearer,
@@ -706,7 +710,7 @@ func TestHTTPServer_TestAcknowledgePostFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -826,7 +830,7 @@ func TestHTTPServer_TestAcknowledgePostNewAck(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -855,7 +859,7 @@ func TestHTTPServer_TestAcknowledgePostMissingParam(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -882,7 +886,7 @@ func TestHTTPServer_TestAcknowledgePostBadCompositeRuleID(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -922,7 +926,7 @@ func TestHTTPServer_TestAcknowledgePostAggregatorError1stCall(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -1009,7 +1013,7 @@ func TestHTTPServer_TestAcknowledgePostAggregatorError2ndCall(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: goodJWTAuthBearer,
@@ -1034,7 +1038,7 @@ func TestHTTPServer_TestAcknowledgePostInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPost,
 		Endpoint:           server.AckAcknowledgePostEndpoint,
 		AuthorizationToken: invalidJWTAuthBearer,
@@ -1082,7 +1086,7 @@ func TestHTTPServer_TestAcknowledgeUpdateNotFound(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1218,7 +1222,7 @@ func TestHTTPServer_TestAcknowledgeUpdateFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationUpdated, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1247,7 +1251,7 @@ func TestHTTPServer_TestAcknowledgeUpdateBadCompositeRuleID(t *testing.T) {
 
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{"invalid rule id"},
@@ -1287,7 +1291,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError1st(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1372,7 +1376,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError2nd(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1471,7 +1475,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError3rd(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1496,7 +1500,7 @@ func TestHTTPServer_TestAcknowledgeUpdateInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.AckUpdateEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1569,7 +1573,7 @@ func TestHTTPServer_TestAcknowledgeDeleteFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1606,7 +1610,7 @@ func TestHTTPServer_TestAcknowledgeDeleteNotFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1623,7 +1627,7 @@ func TestHTTPServer_TestAcknowledgeDeleteBadRequest(t *testing.T) {
 	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
 	assert.Nil(t, err)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{"invalid rule id"},
@@ -1647,7 +1651,7 @@ func TestHTTPServer_TestAcknowledgeDeleteInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1685,7 +1689,7 @@ func TestHTTPServer_TestAcknowledgeDeleteAggregatorError1st(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
@@ -1757,7 +1761,7 @@ func TestHTTPServer_TestAcknowledgeDeleteAggregatorError2nd(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:             http.MethodDelete,
 		Endpoint:           server.AckDeleteEndpoint,
 		EndpointArgs:       []interface{}{testdata.Rule1CompositeID},
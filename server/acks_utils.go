@@ -71,8 +71,14 @@ func readRuleSelectorAndJustificationFromBody(writer http.ResponseWriter, reques
 
 	if err != nil {
 		log.Error().Err(err).Msg("wrong payload provided by client")
+		if _, ok := bodyDecodingError(err).(*RequestBodyTooLargeError); ok {
+			handleServerError(writer, err)
+			return parameters, err
+		}
 		// return HTTP code 400 to client
-		http.Error(writer, err.Error(), http.StatusBadRequest)
+		if sendErr := sendProblem(writer, newProblem(ErrorCodeInvalidBody, err.Error())); sendErr != nil {
+			log.Error().Err(sendErr).Msg(responseDataError)
+		}
 		return parameters, err
 	}
 
@@ -107,7 +113,7 @@ func (server *HTTPServer) ackRuleSystemWide(
 
 	// try to ack rule via Insights Aggregator REST API
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorEndpoint(orgID),
 		ira_server.DisableRuleSystemWide,
 		ruleID, errorKey, orgID,
 	)
@@ -153,7 +159,7 @@ func (server *HTTPServer) updateAckRuleSystemWide(
 
 	// try to ack rule via Insights Aggregator REST API
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorEndpoint(orgID),
 		ira_server.UpdateRuleSystemWide,
 		ruleID, errorKey, orgID,
 	)
@@ -193,7 +199,7 @@ func (server *HTTPServer) deleteAckRuleSystemWide(
 
 	// try to ack rule via Insights Aggregator REST API
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorEndpoint(orgID),
 		ira_server.EnableRuleSystemWide,
 		ruleID, errorKey, orgID,
 	)
@@ -235,7 +241,7 @@ func (server *HTTPServer) readListOfAckedRules(
 
 	// try to read rule list from Insights Aggregator
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ListOfDisabledRulesSystemWide,
 		orgID,
 	)
@@ -284,7 +290,7 @@ func (server *HTTPServer) readRuleDisableStatus(
 
 	// try to read rule disable status from aggregator
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ReadRuleSystemWide,
 		ruleID, errorKey, orgID,
 	)
@@ -0,0 +1,327 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// Endpoints compatible with RHEL Insights Advisor API, so shared console
+// components can consume both services by the same client code. Clusters are
+// returned as systems.
+const (
+	// AdvisorSystemsEndpoint returns paginated list of clusters in the
+	// shape of systems
+	AdvisorSystemsEndpoint = "advisor/system"
+	// AdvisorSystemReportsEndpoint returns rules hitting the cluster in the
+	// shape of system reports
+	AdvisorSystemReportsEndpoint = "advisor/system/{cluster}/reports"
+	// AdvisorHitsEndpoint returns all rules hitting clusters of the
+	// organization in the shape of exported hits
+	AdvisorHitsEndpoint = "advisor/export/hits"
+	// AdvisorAckListEndpoint is alias of AckListEndpoint
+	AdvisorAckListEndpoint = "advisor/ack"
+	// AdvisorAckEndpoint is alias of AckGetEndpoint
+	AdvisorAckEndpoint = "advisor/ack/{rule_id}"
+)
+
+// advisorSystemsListContract describes fields of AdvisorSystem
+var advisorSystemsListContract = listContract{
+	sortable: []string{
+		"system_uuid", "display_name", "last_seen", "hits",
+		"critical_hits", "important_hits", "moderate_hits", "low_hits",
+	},
+	filterable: []string{
+		"system_uuid", "display_name", "version",
+	},
+	defaultSort: "display_name",
+}
+
+// addAdvisorAliasesToRouter registers the endpoints compatible with RHEL
+// Insights Advisor API. Acknowledgements are served by the same handlers as
+// API V2 acks endpoints, whose shapes are compatible already.
+func (server *HTTPServer) addAdvisorAliasesToRouter(router *mux.Router, apiPrefix string) {
+	advisorRouter := server.featureRouter(router, featureflags.AdvisorAliases)
+	if advisorRouter == nil {
+		return
+	}
+
+	advisorRouter.HandleFunc(apiPrefix+AdvisorSystemsEndpoint, server.getAdvisorSystems).Methods(http.MethodGet)
+	advisorRouter.HandleFunc(apiPrefix+AdvisorSystemReportsEndpoint, server.getAdvisorSystemReports).Methods(http.MethodGet)
+	advisorRouter.HandleFunc(apiPrefix+AdvisorHitsEndpoint, server.getAdvisorHits).Methods(http.MethodGet)
+
+	if acksRouter := server.featureRouter(advisorRouter, featureflags.Acknowledgements); acksRouter != nil {
+		acksRouter.HandleFunc(apiPrefix+AdvisorAckListEndpoint, server.readAckList).Methods(http.MethodGet)
+		acksRouter.HandleFunc(apiPrefix+AdvisorAckListEndpoint, server.auditLogged(auditOperationAck, server.acknowledgePost)).Methods(http.MethodPost)
+		acksRouter.HandleFunc(apiPrefix+AdvisorAckEndpoint, server.getAcknowledge).Methods(http.MethodGet)
+		acksRouter.HandleFunc(apiPrefix+AdvisorAckEndpoint, server.auditLogged(auditOperationAckUpdate, server.updateAcknowledge)).Methods(http.MethodPut)
+		acksRouter.HandleFunc(apiPrefix+AdvisorAckEndpoint, server.auditLogged(auditOperationAckDelete, server.deleteAcknowledge)).Methods(http.MethodDelete)
+	}
+}
+
+// newAdvisorSystem converts the cluster with numbers of rule hits into the
+// shape of system
+func newAdvisorSystem(cluster *types.ClusterListView) types.AdvisorSystem {
+	system := types.AdvisorSystem{
+		SystemUUID:    cluster.ClusterID,
+		DisplayName:   cluster.ClusterName,
+		LastSeen:      cluster.LastCheckedAt,
+		Hits:          int(cluster.TotalHitCount),
+		CriticalHits:  cluster.HitsByTotalRisk[4],
+		ImportantHits: cluster.HitsByTotalRisk[3],
+		ModerateHits:  cluster.HitsByTotalRisk[2],
+		LowHits:       cluster.HitsByTotalRisk[1],
+		Version:       string(cluster.Version),
+	}
+	if system.DisplayName == "" {
+		system.DisplayName = string(cluster.ClusterID)
+	}
+	return system
+}
+
+// newAdvisorReports converts the rules hitting the cluster into the shape
+// of system reports. Disabled rules are left out, the most risky issues go
+// first.
+func newAdvisorReports(rules []types.RuleWithContentResponse) []types.AdvisorReport {
+	reports := make([]types.AdvisorReport, 0, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Disabled {
+			continue
+		}
+
+		reports = append(reports, types.AdvisorReport{
+			Rule: types.AdvisorRule{
+				RuleID:         ctypes.RuleSelector(string(rule.RuleID) + "|" + string(rule.ErrorKey)),
+				Description:    rule.Description,
+				Generic:        rule.Generic,
+				Reason:         rule.Reason,
+				MoreInfo:       rule.MoreInfo,
+				TotalRisk:      rule.TotalRisk,
+				ResolutionRisk: rule.ResolutionRisk,
+				Tags:           strings.Join(rule.Tags, " "),
+			},
+			Details: rule.TemplateData,
+			Resolution: types.AdvisorResolution{
+				Resolution: rule.Resolution,
+				ResolutionRisk: types.AdvisorResolutionRisk{
+					Name: riskLabel(rule.ResolutionRisk),
+					Risk: rule.ResolutionRisk,
+				},
+			},
+			ImpactedDate: rule.Impacted,
+		})
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].Rule.TotalRisk > reports[j].Rule.TotalRisk
+	})
+	return reports
+}
+
+// advisorHits converts recommendations hitting the clusters of the
+// organization into the shape of exported hits. Disabled rules and rules not
+// relevant for managed clusters are left out, the same as in organization
+// overview.
+func advisorHits(
+	clusterInfoList []types.ClusterInfo,
+	clusterRecommendationsMap ctypes.ClusterRecommendationMap,
+	systemWideDisabledRules map[ctypes.RuleID]bool,
+	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
+) ([]types.AdvisorHit, error) {
+	hits := make([]types.AdvisorHit, 0)
+
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+
+		hittingRecommendations, found := clusterRecommendationsMap[clusterInfo.ID]
+		if !found {
+			continue
+		}
+
+		hostname := clusterInfo.DisplayName
+		if hostname == "" {
+			hostname = string(clusterInfo.ID)
+		}
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			hittingRecommendations.Recommendations, clusterInfo.ID,
+			systemWideDisabledRules, disabledRulesPerCluster,
+		)
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					return nil, err
+				}
+				// missing rule content, the rule can't be displayed
+				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
+				continue
+			}
+
+			if clusterInfo.Managed && !ruleContent.OSDCustomer {
+				continue
+			}
+
+			hits = append(hits, types.AdvisorHit{
+				Hostname:    hostname,
+				UUID:        clusterInfo.ID,
+				LastSeen:    types.Timestamp(hittingRecommendations.CreatedAt.UTC().Format(time.RFC3339)),
+				RuleID:      ctypes.RuleSelector(ruleID),
+				Title:       ruleContent.Description,
+				TotalRisk:   ruleContent.TotalRisk,
+				Likelihood:  ruleContent.Likelihood,
+				PublishDate: ruleContent.PublishDate,
+			})
+		}
+	}
+	return hits, nil
+}
+
+// getAdvisorSystems returns page of clusters of the organization in the
+// shape of systems
+func (server *HTTPServer) getAdvisorSystems(writer http.ResponseWriter, request *http.Request) {
+	query, err := readListQuery(request, advisorSystemsListContract)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	clustersView, err := server.readClustersView(writer, orgID, userID, statusFilter)
+	if err != nil {
+		// server error has been handled already
+		return
+	}
+
+	systems := make([]types.AdvisorSystem, len(clustersView))
+	for i := range clustersView {
+		systems[i] = newAdvisorSystem(&clustersView[i])
+	}
+	sendListEnvelope(writer, request, systems, query)
+}
+
+// getAdvisorSystemReports returns rules hitting the cluster in the shape of
+// system reports. The list is sent as it is, without the envelope, the same
+// as by RHEL Insights Advisor API.
+func (server *HTTPServer) getAdvisorSystemReports(writer http.ResponseWriter, request *http.Request) {
+	aggregatorResponse, _, successful, clusterID := server.fetchAggregatorReport(writer, request, reportCacheEndpointAdvisor)
+	if !successful {
+		return
+	}
+
+	clusterInfo := server.getClusterInfo(clusterID)
+
+	// managed status read from AMS API can be overridden by the osd_eligible parameter
+	osdFlag := readOSDEligibleOrDefault(request, clusterInfo.Managed)
+
+	rules, _, err := server.buildReportEndpointResponse(writer, request, aggregatorResponse, clusterID, osdFlag)
+	if err != nil {
+		// error has been handled already
+		return
+	}
+	fillImpacted(rules, aggregatorResponse.Report)
+
+	if err := responses.Send(http.StatusOK, writer, newAdvisorReports(rules)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// getAdvisorHits returns all rules hitting clusters of the organization in
+// the shape of exported hits
+func (server *HTTPServer) getAdvisorHits(writer http.ResponseWriter, request *http.Request) {
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	var (
+		waitGroup               sync.WaitGroup
+		ackedRulesMap           map[ctypes.RuleID]bool
+		disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID
+	)
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	// the goroutines must not outlive the request
+	defer waitGroup.Wait()
+
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterRecommendationMap, err := server.getClustersAndRecommendations(
+		writer, orgID, userID, types.GetClusterNames(clusterInfoList),
+	)
+	if err != nil {
+		// error has been handled already
+		return
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRecommendationMap)
+
+	waitGroup.Wait()
+
+	hits, err := advisorHits(clusterInfoList, clusterRecommendationMap, ackedRulesMap, disabledRulesPerCluster)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if err := responses.Send(http.StatusOK, writer, hits); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"testing"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestNewAdvisorSystem checks that hits of the cluster are counted by total
+// risk the same way as by RHEL Insights Advisor
+func TestNewAdvisorSystem(t *testing.T) {
+	system := server.NewAdvisorSystem(&types.ClusterListView{
+		ClusterID:       "cluster-1",
+		LastCheckedAt:   "2023-05-01T00:00:00Z",
+		TotalHitCount:   4,
+		HitsByTotalRisk: map[int]int{1: 1, 2: 0, 3: 1, 4: 2},
+		Version:         "4.13.0",
+	})
+
+	assert.Equal(t, types.AdvisorSystem{
+		SystemUUID:    "cluster-1",
+		DisplayName:   "cluster-1",
+		LastSeen:      "2023-05-01T00:00:00Z",
+		Hits:          4,
+		CriticalHits:  2,
+		ImportantHits: 1,
+		ModerateHits:  0,
+		LowHits:       1,
+		Version:       "4.13.0",
+	}, system)
+}
+
+// TestNewAdvisorReports checks that disabled rules are left out and that
+// the most risky rules go first
+func TestNewAdvisorReports(t *testing.T) {
+	reports := server.NewAdvisorReports([]types.RuleWithContentResponse{
+		{RuleID: "ccx.rule_a", ErrorKey: "KEY", TotalRisk: 1, Tags: []string{"security", "service_availability"}},
+		{RuleID: "ccx.rule_b", ErrorKey: "KEY", TotalRisk: 4, Disabled: true},
+		{RuleID: "ccx.rule_c", ErrorKey: "KEY", TotalRisk: 3, ResolutionRisk: 2, Resolution: "Upgrade"},
+	})
+
+	assert.Len(t, reports, 2)
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_c|KEY"), reports[0].Rule.RuleID)
+	assert.Equal(t, "Upgrade", reports[0].Resolution.Resolution)
+	assert.Equal(t, types.AdvisorResolutionRisk{Name: "Moderate", Risk: 2}, reports[0].Resolution.ResolutionRisk)
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_a|KEY"), reports[1].Rule.RuleID)
+	assert.Equal(t, "security service_availability", reports[1].Rule.Tags)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+)
+
+// SetAuditPublisher replaces the publisher of audit events. Events are
+// dropped until it is called.
+func (server *HTTPServer) SetAuditPublisher(publisher audit.Publisher) {
+	server.auditPublisher = publisher
+}
+
+// publishAuditEvent publishes the event about action of the requester
+func (server *HTTPServer) publishAuditEvent(
+	request *http.Request,
+	eventType string,
+	clusterID ctypes.ClusterName,
+	ruleID ctypes.RuleID,
+	errorKey ctypes.ErrorKey,
+) {
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Error().Err(err).Str("type", eventType).Msg("Unable to publish audit event without identity")
+		return
+	}
+
+	server.auditPublisher.Publish(&audit.Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		OrgID:     orgID,
+		UserID:    userID,
+		ClusterID: clusterID,
+		RuleID:    ruleID,
+		ErrorKey:  errorKey,
+		Path:      request.URL.Path,
+	})
+}
+
+// statusRecorder remembers the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (recorder *statusRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+// audited publishes the audit event when the handler succeeds. Cluster, rule
+// and error key are taken from the path of the request.
+func (server *HTTPServer) audited(eventType string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodOptions {
+			handler(writer, request)
+			return
+		}
+
+		// the handler may modify the variables
+		vars := mux.Vars(request)
+		clusterID := ctypes.ClusterName(vars["cluster"])
+		ruleID := ctypes.RuleID(vars["rule_id"])
+		errorKey := ctypes.ErrorKey(vars["error_key"])
+
+		// the handler may rewrite the URL when the request is proxied, the
+		// event contains the path requested by the client
+		recorder := &statusRecorder{ResponseWriter: writer}
+		handler(recorder, request.Clone(request.Context()))
+
+		if recorder.statusCode >= http.StatusOK && recorder.statusCode < http.StatusMultipleChoices {
+			server.publishAuditEvent(request, eventType, clusterID, ruleID, errorKey)
+		}
+	}
+}
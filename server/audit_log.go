@@ -0,0 +1,222 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+)
+
+const (
+	// AuditOrgIDParam selects the organization whose audit log is
+	// returned
+	AuditOrgIDParam = "org_id"
+	// AuditFromParam is the beginning of the time range of the audit log
+	// in RFC 3339 format
+	AuditFromParam = "from"
+	// AuditToParam is the end (exclusive) of the time range of the audit
+	// log in RFC 3339 format
+	AuditToParam = "to"
+
+	// defaultAuditLogLimit is used when the limit parameter is not
+	// provided
+	defaultAuditLogLimit = 100
+	// auditLogRecordsKey is the key of records in the response
+	auditLogRecordsKey = "records"
+)
+
+// Operations recorded in the audit log
+const (
+	auditOperationVote        = "vote"
+	auditOperationFeedback    = "feedback"
+	auditOperationDisable     = "disable"
+	auditOperationEnable      = "enable"
+	auditOperationAck         = "ack"
+	auditOperationAckUpdate   = "ack_update"
+	auditOperationAckDelete   = "ack_delete"
+	auditOperationPreferences = "preferences"
+	auditOperationServiceLog  = "service_log"
+)
+
+// SetAuditLogStore sets the store of audit log records. Mutating operations
+// are not recorded until it is called. It needs to be called before the
+// router is initialized.
+func (server *HTTPServer) SetAuditLogStore(store audit.Store) {
+	server.auditLog = store
+}
+
+// readAuditPayload reads the beginning of the request body. The body is
+// restored, so the handler reads it whole. Payload that is too big or that
+// is not JSON is not returned.
+func readAuditPayload(request *http.Request) json.RawMessage {
+	if request.Body == nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(request.Body, audit.MaxPayloadSize+1))
+	request.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(data), request.Body))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	if len(data) > audit.MaxPayloadSize || !json.Valid(data) {
+		log.Debug().Str("path", request.URL.Path).Msg("Payload is too big or it is not JSON, it is not recorded")
+		return nil
+	}
+	return json.RawMessage(data)
+}
+
+// auditLogged stores the record of the operation made by the handler into the
+// audit log. Operations are recorded whether they succeed or not.
+func (server *HTTPServer) auditLogged(operation string, handler http.HandlerFunc) http.HandlerFunc {
+	if server.auditLog == nil {
+		return handler
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodOptions {
+			handler(writer, request)
+			return
+		}
+
+		// the handler may modify the variables
+		params := make(map[string]string)
+		for name, value := range mux.Vars(request) {
+			params[name] = value
+		}
+		payload := readAuditPayload(request)
+
+		// the handler may rewrite the URL when the request is proxied, the
+		// record contains the path requested by the client
+		recorder := &statusRecorder{ResponseWriter: writer}
+		handler(recorder, request.Clone(request.Context()))
+
+		orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+		if err != nil {
+			log.Error().Err(err).Str("operation", operation).Msg("Unable to record operation without identity")
+			return
+		}
+
+		statusCode := recorder.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		err = server.auditLog.Append(&audit.Record{
+			Timestamp:  time.Now().UTC(),
+			OrgID:      orgID,
+			UserID:     userID,
+			Operation:  operation,
+			Method:     request.Method,
+			Path:       request.URL.Path,
+			Params:     params,
+			Payload:    payload,
+			StatusCode: statusCode,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("operation", operation).Msg("Unable to store audit log record")
+		}
+	}
+}
+
+// readAuditTimeParam reads optional time query parameter
+func readAuditTimeParam(request *http.Request, paramName string) (time.Time, error) {
+	value := request.URL.Query().Get(paramName)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &RouterParsingError{
+			paramName:  paramName,
+			paramValue: value,
+			errString:  "time in RFC 3339 format is expected",
+		}
+	}
+	return parsed, nil
+}
+
+// readAuditLogQuery reads the organization, time range and limit of the
+// audit log query
+func readAuditLogQuery(request *http.Request) (query audit.Query, err error) {
+	if query.OrgID, err = readOrgIDQueryParam(request, AuditOrgIDParam); err != nil {
+		return
+	}
+
+	if query.From, err = readAuditTimeParam(request, AuditFromParam); err != nil {
+		return
+	}
+	if query.To, err = readAuditTimeParam(request, AuditToParam); err != nil {
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		err = &RouterParsingError{
+			paramName:  AuditToParam,
+			paramValue: request.URL.Query().Get(AuditToParam),
+			errString:  "the end of the time range must follow its beginning",
+		}
+		return
+	}
+
+	query.Limit, err = readNonNegativeIntParam(request, LimitParam, defaultAuditLogLimit)
+	if err != nil {
+		return
+	}
+	if query.Limit == 0 || query.Limit > maxListLimit {
+		err = &RouterParsingError{
+			paramName:  LimitParam,
+			paramValue: query.Limit,
+			errString:  "value between 1 and " + strconv.Itoa(maxListLimit) + " is expected",
+		}
+	}
+	return
+}
+
+// getAuditLog returns the audit log records of the organization made in the
+// time range, oldest first
+func (server *HTTPServer) getAuditLog(writer http.ResponseWriter, request *http.Request) {
+	if err := server.checkInternalEndpointPermissions(request); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	query, err := readAuditLogQuery(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	records, err := server.auditLog.Query(query)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to read audit log")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData(auditLogRecordsKey, records))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
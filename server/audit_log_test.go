@@ -0,0 +1,130 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestAuditLog checks that the feedback is recorded with its payload, even
+// when it fails, and that the records can be read by internal endpoint
+func TestAuditLog(t *testing.T) {
+	const feedback = `{"message":"not relevant"}`
+
+	aggregatorStatus := http.StatusOK
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, err := ioutil.ReadAll(request.Body)
+		assert.NoError(t, err)
+		// the payload is passed to aggregator unchanged
+		assert.Equal(t, feedback, string(body))
+		writer.WriteHeader(aggregatorStatus)
+		_, _ = writer.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer aggregator.Close()
+
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	helpers.FailOnError(t, err)
+
+	dir, err := ioutil.TempDir("", "audit")
+	helpers.FailOnError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := audit.NewFileStore(filepath.Join(dir, "audit.log"))
+	helpers.FailOnError(t, err)
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL + "/"
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, nil, nil)
+	testServer.SetAuditLogStore(store)
+	router := testServer.Initialize()
+
+	endpoint := serverConfigJWT.APIv1Prefix + strings.NewReplacer(
+		"{cluster}", string(testdata.ClusterName),
+		"{rule_id}", string(testdata.Rule1ID),
+		"{error_key}", string(testdata.ErrorKey1),
+	).Replace(server.DisableRuleFeedbackEndpoint)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		aggregatorStatus = status
+		request := httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(feedback))
+		request.Header.Set("Authorization", goodJWTAuthBearer)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, status, recorder.Code)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, serverConfigJWT.APIv2Prefix+server.AuditLogEndpoint+"?org_id=1", nil)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Records []audit.Record `json:"records"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Records, 2)
+	for i, status := range []int{http.StatusOK, http.StatusNotFound} {
+		record := response.Records[i]
+		assert.Equal(t, "feedback", record.Operation)
+		assert.Equal(t, testdata.OrgID, record.OrgID)
+		assert.Equal(t, http.MethodPost, record.Method)
+		assert.Equal(t, endpoint, record.Path)
+		assert.Equal(t, string(testdata.Rule1ID), record.Params["rule_id"])
+		assert.JSONEq(t, feedback, string(record.Payload))
+		assert.Equal(t, status, record.StatusCode)
+	}
+}
+
+func TestAuditLogEndpointBadParameters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	helpers.FailOnError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := audit.NewFileStore(filepath.Join(dir, "audit.log"))
+	helpers.FailOnError(t, err)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetAuditLogStore(store)
+	router := testServer.Initialize()
+
+	for _, query := range []string{
+		"",
+		"?org_id=abc",
+		"?org_id=1&from=yesterday",
+		"?org_id=1&from=2023-05-02T00:00:00Z&to=2023-05-01T00:00:00Z",
+		"?org_id=1&limit=0",
+	} {
+		request := httptest.NewRequest(http.MethodGet, serverConfigJWT.APIv1Prefix+server.AuditLogEndpoint+query, nil)
+		request.Header.Set("Authorization", goodJWTAuthBearer)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// recordingPublisher remembers the published audit events
+type recordingPublisher struct {
+	mutex  sync.Mutex
+	events []audit.Event
+}
+
+func (publisher *recordingPublisher) Publish(event *audit.Event) {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.events = append(publisher.events, *event)
+}
+
+func (publisher *recordingPublisher) Close() error {
+	return nil
+}
+
+// TestAuditRuleDisabled checks that the event is published when the rule is
+// disabled successfully only
+func TestAuditRuleDisabled(t *testing.T) {
+	aggregatorStatus := http.StatusOK
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(aggregatorStatus)
+		_, _ = writer.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer aggregator.Close()
+
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	helpers.FailOnError(t, err)
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL + "/"
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, nil, nil)
+	publisher := &recordingPublisher{}
+	testServer.SetAuditPublisher(publisher)
+	router := testServer.Initialize()
+
+	endpoint := serverConfigJWT.APIv1Prefix + strings.NewReplacer(
+		"{cluster}", string(testdata.ClusterName),
+		"{rule_id}", string(testdata.Rule1ID),
+		"{error_key}", string(testdata.ErrorKey1),
+	).Replace(server.DisableRuleForClusterEndpoint)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		aggregatorStatus = status
+		request := httptest.NewRequest(http.MethodPut, endpoint, nil)
+		request.Header.Set("Authorization", goodJWTAuthBearer)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, status, recorder.Code)
+	}
+
+	assert.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, audit.RuleDisabled, event.Type)
+	assert.Equal(t, testdata.OrgID, event.OrgID)
+	assert.Equal(t, testdata.ClusterName, event.ClusterID)
+	assert.Equal(t, testdata.Rule1ID, event.RuleID)
+	assert.Equal(t, ctypes.ErrorKey(testdata.ErrorKey1), event.ErrorKey)
+	assert.Equal(t, endpoint, event.Path)
+}
@@ -19,7 +19,6 @@ limitations under the License.
 package server
 
 import (
-	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -50,59 +49,29 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 			return
 		}
 
-		// try to read auth. header from HTTP request (if provided by client)
-		token, isTokenValid := server.getAuthTokenHeader(w, r)
-		if !isTokenValid {
-			// everything has been handled already
+		// identities decoded recently are not decoded again
+		cacheKey := server.identityCacheKey(r)
+		if tk, roles, found := server.getCachedIdentity(cacheKey); found {
+			ctx := contextWithIdentityRoles(r.Context(), roles)
+			next.ServeHTTP(w, r.WithContext(ContextWithIdentity(ctx, tk.Identity)))
 			return
 		}
 
-		if server.Config.LogAuthToken {
-			log.Info().Msgf("Authentication token: %s", token)
+		var (
+			tk    *types.Token
+			roles []string
+			ok    bool
+		)
+		if bearerToken, found := server.getBearerToken(r); found {
+			tk, ok = server.readBearerIdentity(w, r, bearerToken)
+		} else {
+			tk, roles, ok = server.readIdentityHeader(w, r)
 		}
-
-		// decode auth. token to JSON string
-		decoded, err := base64.StdEncoding.DecodeString(token)
-
-		// if token is malformed return HTTP code 403 to client
-		if err != nil {
-			// malformed token, returns with HTTP code 403 as usual
-			log.Error().Err(err).Msg(malformedTokenMessage)
-			handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
+		if !ok {
+			// everything has been handled already
 			return
 		}
 
-		tk := &types.Token{}
-		// if we took JWT token, it has different structure than x-rh-identity
-		// JWT isn't/can't used in any real environment
-		if server.Config.AuthType == "jwt" {
-			jwtPayload := &types.JWTPayload{}
-			err = json.Unmarshal(decoded, jwtPayload)
-			if err != nil {
-				// malformed token, returns with HTTP code 403 as usual
-				log.Error().Err(err).Msg(malformedTokenMessage)
-				handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
-				return
-			}
-			// Map JWT token to inner token
-			tk.Identity = types.Identity{
-				AccountNumber: jwtPayload.AccountNumber,
-				OrgID:         jwtPayload.OrgID,
-				User: types.User{
-					UserID: jwtPayload.UserID,
-				},
-			}
-		} else {
-			// auth type is xrh (x-rh-identity header)
-			err = json.Unmarshal(decoded, tk)
-			if err != nil {
-				// malformed token, returns with HTTP code 403 as usual
-				log.Error().Err(err).Msg(malformedTokenMessage)
-				handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
-				return
-			}
-		}
-
 		if tk.Identity.AccountNumber == "" || tk.Identity.AccountNumber == "0" {
 			log.Info().Msgf("anemic tenant found! org_id %v, user data [%+v]",
 				tk.Identity.OrgID, tk.Identity.User,
@@ -122,19 +91,83 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 		if tk.Identity.User.UserID == "" {
 			tk.Identity.User.UserID = "0"
 		}
+		server.setCachedIdentity(cacheKey, tk.Identity, roles)
 
 		// Everything went well, proceed with the request and set the
 		// caller to the user retrieved from the parsed token
-		ctx := context.WithValue(r.Context(), types.ContextKeyUser, tk.Identity)
-		r = r.WithContext(ctx)
+		ctx := contextWithIdentityRoles(r.Context(), roles)
+		r = r.WithContext(ContextWithIdentity(ctx, tk.Identity))
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// readIdentityHeader decodes the identity from x-rh-identity header or from
+// the JWT token, depending on the configured auth type. Roles of associate
+// are read from x-rh-identity header only, JWT tokens don't contain them.
+// Error response is sent when the header is missing or malformed.
+func (server *HTTPServer) readIdentityHeader(w http.ResponseWriter, r *http.Request) (*types.Token, []string, bool) {
+	// try to read auth. header from HTTP request (if provided by client)
+	token, isTokenValid := server.getAuthTokenHeader(w, r)
+	if !isTokenValid {
+		// everything has been handled already
+		return nil, nil, false
+	}
+
+	if server.Config.LogAuthToken {
+		log.Info().Msgf("Authentication token: %s", token)
+	}
+
+	// decode auth. token to JSON string
+	decoded, err := base64.StdEncoding.DecodeString(token)
+
+	// if token is malformed return HTTP code 403 to client
+	if err != nil {
+		// malformed token, returns with HTTP code 403 as usual
+		log.Error().Err(err).Msg(malformedTokenMessage)
+		handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
+		return nil, nil, false
+	}
+
+	tk := &types.Token{}
+	var roles []string
+	// if we took JWT token, it has different structure than x-rh-identity
+	// JWT isn't/can't used in any real environment
+	if server.Config.AuthType == "jwt" {
+		jwtPayload := &types.JWTPayload{}
+		err = json.Unmarshal(decoded, jwtPayload)
+		if err != nil {
+			// malformed token, returns with HTTP code 403 as usual
+			log.Error().Err(err).Msg(malformedTokenMessage)
+			handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
+			return nil, nil, false
+		}
+		// Map JWT token to inner token
+		tk.Identity = types.Identity{
+			AccountNumber: jwtPayload.AccountNumber,
+			OrgID:         jwtPayload.OrgID,
+			User: types.User{
+				UserID: jwtPayload.UserID,
+			},
+		}
+	} else {
+		// auth type is xrh (x-rh-identity header)
+		err = json.Unmarshal(decoded, tk)
+		if err != nil {
+			// malformed token, returns with HTTP code 403 as usual
+			log.Error().Err(err).Msg(malformedTokenMessage)
+			handleServerError(w, &AuthenticationError{errString: malformedTokenMessage})
+			return nil, nil, false
+		}
+		roles = associateRoles(decoded)
+	}
+
+	return tk, roles, true
+}
+
 // GetCurrentUserID retrieves current user's id from request
 func (server *HTTPServer) GetCurrentUserID(request *http.Request) (types.UserID, error) {
-	identity, err := server.GetAuthToken(request)
+	identity, err := requestIdentity(request)
 	if err != nil {
 		return types.UserID(""), err
 	}
@@ -144,7 +177,7 @@ func (server *HTTPServer) GetCurrentUserID(request *http.Request) (types.UserID,
 
 // GetCurrentOrgID retrieves the ID of the organization the user belongs to
 func (server *HTTPServer) GetCurrentOrgID(request *http.Request) (types.OrgID, error) {
-	identity, err := server.GetAuthToken(request)
+	identity, err := requestIdentity(request)
 	if err != nil {
 		return types.OrgID(0), err
 	}
@@ -157,7 +190,7 @@ func (server *HTTPServer) GetCurrentOrgID(request *http.Request) (types.OrgID, e
 func (server *HTTPServer) GetCurrentOrgIDUserIDFromToken(request *http.Request) (
 	types.OrgID, types.UserID, error,
 ) {
-	identity, err := server.GetAuthToken(request)
+	identity, err := requestIdentity(request)
 	if err != nil {
 		log.Err(err).Msg("error retrieving identity from token")
 		return types.OrgID(0), types.UserID("0"), err
@@ -166,19 +199,13 @@ func (server *HTTPServer) GetCurrentOrgIDUserIDFromToken(request *http.Request)
 	return identity.OrgID, identity.User.UserID, nil
 }
 
-// GetAuthToken returns current authentication token
+// GetAuthToken returns the identity of the requester stored in the request
+// context by authentication middleware
 func (server *HTTPServer) GetAuthToken(request *http.Request) (*types.Identity, error) {
-	i := request.Context().Value(types.ContextKeyUser)
-
-	if i == nil {
-		return nil, &AuthenticationError{errString: "token is not provided"}
-	}
-
-	identity, ok := i.(types.Identity)
-	if !ok {
-		return nil, &AuthenticationError{errString: "contextKeyUser has wrong type"}
+	identity, err := requestIdentity(request)
+	if err != nil {
+		return nil, err
 	}
-
 	return &identity, nil
 }
 
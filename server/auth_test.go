@@ -208,7 +208,7 @@ func TestGetAuthTokenHeaderMalformed(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfig,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil,
+		nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -230,7 +230,7 @@ func TestGetAuthTokenHeaderMissing(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfig,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil,
+		nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,167 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// bearerPrefix precedes the token in Authorization header
+	bearerPrefix = "Bearer "
+	// defaultIntrospectionTimeout is used when the timeout of token
+	// introspection is not configured
+	defaultIntrospectionTimeout = 5 * time.Second
+)
+
+// BearerAuthConfiguration represents configuration of authentication by
+// Bearer tokens issued by SSO. The tokens are accepted in addition to
+// x-rh-identity header, they are validated by the token introspection
+// endpoint of SSO (RFC 7662).
+type BearerAuthConfiguration struct {
+	Enabled          bool          `mapstructure:"enabled" toml:"enabled"`
+	IntrospectionURL string        `mapstructure:"introspection_url" toml:"introspection_url"`
+	ClientID         string        `mapstructure:"client_id" toml:"client_id"`
+	ClientSecret     string        `mapstructure:"client_secret" toml:"client_secret"`
+	Timeout          time.Duration `mapstructure:"timeout" toml:"timeout"`
+}
+
+// tokenIntrospection is the response of token introspection endpoint. Org
+// ID is sent as string by SSO, but number is accepted too.
+type tokenIntrospection struct {
+	Active        bool        `json:"active"`
+	Subject       string      `json:"sub"`
+	UserID        string      `json:"user_id"`
+	OrgID         json.Number `json:"org_id"`
+	AccountNumber string      `json:"account_number"`
+}
+
+// tokenIntrospector validates Bearer tokens by SSO
+type tokenIntrospector struct {
+	config BearerAuthConfiguration
+	client *http.Client
+}
+
+// newTokenIntrospector constructs the introspector. Nil is returned when
+// Bearer authentication is disabled.
+func newTokenIntrospector(config BearerAuthConfiguration) *tokenIntrospector {
+	if !config.Enabled || config.IntrospectionURL == "" {
+		return nil
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultIntrospectionTimeout
+	}
+	return &tokenIntrospector{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// introspect asks SSO whether the token is active and maps its claims to the
+// identity used by the rest of the service
+func (introspector *tokenIntrospector) introspect(ctx context.Context, token string) (types.Identity, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+	request, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, introspector.config.IntrospectionURL, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return types.Identity{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if introspector.config.ClientID != "" {
+		request.SetBasicAuth(introspector.config.ClientID, introspector.config.ClientSecret)
+	}
+
+	response, err := introspector.client.Do(request)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to introspect Bearer token")
+		return types.Identity{}, &SSOUnavailableError{}
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		log.Error().Int("status", response.StatusCode).Msg("Unexpected response of token introspection")
+		return types.Identity{}, &SSOUnavailableError{}
+	}
+
+	var introspection tokenIntrospection
+	if err := json.NewDecoder(response.Body).Decode(&introspection); err != nil {
+		log.Error().Err(err).Msg("Unable to decode response of token introspection")
+		return types.Identity{}, &SSOUnavailableError{}
+	}
+
+	if !introspection.Active {
+		return types.Identity{}, &AuthenticationError{errString: "Bearer token is not active"}
+	}
+	orgID, err := strconv.ParseUint(introspection.OrgID.String(), 10, 32)
+	if err != nil {
+		return types.Identity{}, &AuthenticationError{errString: "Bearer token doesn't contain valid org_id"}
+	}
+
+	userID := introspection.UserID
+	if userID == "" {
+		userID = introspection.Subject
+	}
+	return types.Identity{
+		AccountNumber: types.UserID(introspection.AccountNumber),
+		OrgID:         types.OrgID(orgID),
+		User: types.User{
+			UserID: types.UserID(userID),
+		},
+	}, nil
+}
+
+// getBearerToken returns the Bearer token sent in Authorization header when
+// Bearer authentication is enabled. The x-rh-identity header takes precedence
+// when both are sent. JWT auth type uses Authorization header on its own.
+func (server *HTTPServer) getBearerToken(r *http.Request) (string, bool) {
+	if server.introspector == nil || server.Config.AuthType == "jwt" || r.Header.Get("x-rh-identity") != "" {
+		return "", false
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix))
+	return token, token != ""
+}
+
+// readBearerIdentity validates the Bearer token by SSO and returns the
+// identity of its owner. Error response is sent when the token is not valid.
+func (server *HTTPServer) readBearerIdentity(w http.ResponseWriter, r *http.Request, token string) (*types.Token, bool) {
+	identity, err := server.introspector.introspect(r.Context(), token)
+	if err != nil {
+		handleServerError(w, err)
+		return nil, false
+	}
+	return &types.Token{Identity: identity}, true
+}
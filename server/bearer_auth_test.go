@@ -0,0 +1,112 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// newBearerAuthHandler returns handler authenticating requests by Bearer
+// tokens introspected by the given SSO server. Identity of the requester is
+// returned in the response.
+func newBearerAuthHandler(t *testing.T, introspectionURL string, identityCacheTTL time.Duration) http.Handler {
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	config.IdentityCacheTTL = identityCacheTTL
+	config.BearerAuth = server.BearerAuthConfiguration{
+		Enabled:          true,
+		IntrospectionURL: introspectionURL,
+		ClientID:         "smart-proxy",
+		ClientSecret:     "secret",
+	}
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
+
+	return testServer.Authentication(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		identity, err := testServer.GetAuthToken(request)
+		helpers.FailOnError(t, err)
+		assert.Equal(t, ctypes.OrgID(42), identity.OrgID)
+		assert.Equal(t, ctypes.UserID("user-1"), identity.User.UserID)
+		writer.WriteHeader(http.StatusOK)
+	}), nil)
+}
+
+// TestBearerAuthentication checks that Bearer tokens are validated by SSO
+// token introspection
+func TestBearerAuthentication(t *testing.T) {
+	sso := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		clientID, clientSecret, _ := request.BasicAuth()
+		assert.Equal(t, "smart-proxy", clientID)
+		assert.Equal(t, "secret", clientSecret)
+
+		switch request.FormValue("token") {
+		case "active":
+			_, _ = writer.Write([]byte(`{"active":true,"sub":"user-1","org_id":"42","account_number":"1"}`))
+		case "broken":
+			writer.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = writer.Write([]byte(`{"active":false}`))
+		}
+	}))
+	defer sso.Close()
+
+	handler := newBearerAuthHandler(t, sso.URL, 0)
+	for token, expectedStatus := range map[string]int{
+		"active":   http.StatusOK,
+		"inactive": http.StatusForbidden,
+		"broken":   http.StatusServiceUnavailable,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", http.NoBody)
+		request.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, expectedStatus, recorder.Code, token)
+	}
+
+	// requests without any token are still refused
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v2/clusters", http.NoBody))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+// TestBearerAuthenticationCached checks that the token is introspected once
+// when identities are cached
+func TestBearerAuthenticationCached(t *testing.T) {
+	var introspections int32
+	sso := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&introspections, 1)
+		_, _ = writer.Write([]byte(`{"active":true,"sub":"user-1","org_id":"42"}`))
+	}))
+	defer sso.Close()
+
+	handler := newBearerAuthHandler(t, sso.URL, time.Minute)
+	for i := 0; i < 3; i++ {
+		request := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", http.NoBody)
+		request.Header.Set("Authorization", "Bearer active")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspections))
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+const (
+	// canaryModeRoute means the report request is answered by the canary
+	canaryModeRoute = "route"
+	// canaryModeMirror means the copy of report request is sent to the
+	// canary in background
+	canaryModeMirror = "mirror"
+
+	// results of comparison of responses of aggregator and the canary
+	canaryResultMatch          = "match"
+	canaryResultStatusMismatch = "status_mismatch"
+	canaryResultBodyMismatch   = "body_mismatch"
+	canaryResultError          = "error"
+
+	// defaultCanaryTimeout is used when the timeout of mirrored requests is
+	// not configured
+	defaultCanaryTimeout = 10 * time.Second
+)
+
+var (
+	// CanaryRequests counts report requests sent to the canary aggregator
+	// by mode and status code of the response, code is empty when no
+	// response was received
+	CanaryRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "canary_requests_total",
+		Help: "The total number of report requests sent to the canary aggregator",
+	}, []string{"mode", "code"})
+
+	// CanaryComparisons counts mirrored report requests by the result of
+	// comparison of responses of aggregator and the canary
+	CanaryComparisons = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "canary_comparisons_total",
+		Help: "The total number of compared responses of aggregator and the canary aggregator",
+	}, []string{"result"})
+)
+
+// CanaryConfiguration represents configuration of the canary aggregator
+// receiving a percentage of report requests. The requests are either routed
+// to the canary or mirrored to it, so the responses can be compared.
+type CanaryConfiguration struct {
+	// Endpoint is the base endpoint of the canary aggregator, the canary
+	// is disabled when it is empty
+	Endpoint   string  `mapstructure:"endpoint" toml:"endpoint"`
+	Percentage float64 `mapstructure:"percentage" toml:"percentage"`
+	// Mirror sends the copies of requests to the canary, users get the
+	// responses of regular aggregator
+	Mirror bool `mapstructure:"mirror" toml:"mirror"`
+	// Timeout limits the time of mirrored requests
+	Timeout time.Duration `mapstructure:"timeout" toml:"timeout"`
+}
+
+// canaryMode returns the mode the report request is sent to the canary in,
+// empty string is returned when the request is not selected for the canary
+func (server HTTPServer) canaryMode() string {
+	canary := server.Config.Canary
+	if canary.Endpoint == "" || canary.Percentage <= 0 {
+		return ""
+	}
+	// #nosec G404 -- cryptographically secure random numbers are not needed
+	if rand.Float64()*100 >= canary.Percentage {
+		return ""
+	}
+
+	if canary.Mirror {
+		return canaryModeMirror
+	}
+	return canaryModeRoute
+}
+
+// recordCanaryRequest counts the request sent to the canary
+func recordCanaryRequest(mode string, response *http.Response) {
+	code := ""
+	if response != nil {
+		code = strconv.Itoa(response.StatusCode)
+	}
+	CanaryRequests.WithLabelValues(mode, code).Inc()
+}
+
+// mirrorReportRequest sends the copy of report request to the canary and
+// compares its response with the one of regular aggregator
+func (server HTTPServer) mirrorReportRequest(canaryURL string, statusCode int, body []byte) {
+	timeout := server.Config.Canary.Timeout
+	if timeout <= 0 {
+		timeout = defaultCanaryTimeout
+	}
+	client := http.Client{Timeout: timeout}
+
+	response, err := client.Get(canaryURL)
+	if err != nil {
+		recordCanaryRequest(canaryModeMirror, nil)
+		CanaryComparisons.WithLabelValues(canaryResultError).Inc()
+		log.Warn().Err(err).Msg("Mirrored request to canary aggregator failed")
+		return
+	}
+	defer services.CloseResponseBody(response)
+	recordCanaryRequest(canaryModeMirror, response)
+
+	canaryBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		CanaryComparisons.WithLabelValues(canaryResultError).Inc()
+		log.Warn().Err(err).Msg("Unable to read response of canary aggregator")
+		return
+	}
+
+	result := compareReportResponses(statusCode, body, response.StatusCode, canaryBody)
+	CanaryComparisons.WithLabelValues(result).Inc()
+	if result != canaryResultMatch {
+		log.Warn().Str("result", result).Str("url", canaryURL).Msg("Response of canary aggregator differs")
+	}
+}
+
+// compareReportResponses compares responses of aggregator and the canary.
+// Reports are compared after decoding, so formatting doesn't matter.
+func compareReportResponses(statusCode int, body []byte, canaryStatusCode int, canaryBody []byte) string {
+	if statusCode != canaryStatusCode {
+		return canaryResultStatusMismatch
+	}
+	if statusCode != http.StatusOK {
+		return canaryResultMatch
+	}
+
+	var expected, actual struct {
+		Report *ctypes.ReportResponse `json:"report"`
+	}
+	if json.Unmarshal(body, &expected) != nil || json.Unmarshal(canaryBody, &actual) != nil {
+		return canaryResultBodyMismatch
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return canaryResultBodyMismatch
+	}
+	return canaryResultMatch
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// TestCompareReportResponses checks comparison of responses of aggregator
+// and the canary aggregator
+func TestCompareReportResponses(t *testing.T) {
+	report := []byte(`{"report":{"meta":{"count":1},"reports":[{"component":"ccx_rules_ocp.external.rules.rule","key":"ERROR_KEY"}]},"status":"ok"}`)
+	reformatted := []byte(`{"status": "ok", "report": {"reports": [{"key": "ERROR_KEY", "component": "ccx_rules_ocp.external.rules.rule"}], "meta": {"count": 1}}}`)
+	different := []byte(`{"report":{"meta":{"count":0},"reports":[]},"status":"ok"}`)
+
+	for _, testCase := range []struct {
+		name             string
+		statusCode       int
+		body             []byte
+		canaryStatusCode int
+		canaryBody       []byte
+		expected         string
+	}{
+		{"same report", http.StatusOK, report, http.StatusOK, reformatted, "match"},
+		{"different report", http.StatusOK, report, http.StatusOK, different, "body_mismatch"},
+		{"invalid body", http.StatusOK, report, http.StatusOK, []byte("{"), "body_mismatch"},
+		{"different status", http.StatusOK, report, http.StatusNotFound, nil, "status_mismatch"},
+		{"same error", http.StatusNotFound, nil, http.StatusNotFound, []byte("{}"), "match"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := server.CompareReportResponses(
+				testCase.statusCode, testCase.body, testCase.canaryStatusCode, testCase.canaryBody,
+			)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// clusterListParam is the path parameter with comma-separated list of
+	// clusters
+	clusterListParam = "cluster_list"
+
+	minComparedClusters = 2
+)
+
+// uniqueClusterNames returns cluster names in the order of their first
+// occurrence, duplicates are dropped
+func uniqueClusterNames(clusterList []string) []ctypes.ClusterName {
+	seen := make(map[string]bool, len(clusterList))
+	clusterNames := make([]ctypes.ClusterName, 0, len(clusterList))
+	for _, clusterName := range clusterList {
+		if seen[clusterName] {
+			continue
+		}
+		seen[clusterName] = true
+		clusterNames = append(clusterNames, ctypes.ClusterName(clusterName))
+	}
+	return clusterNames
+}
+
+// newComparedRule constructs compared rule from its content
+func newComparedRule(ruleID ctypes.RuleID, ruleContent *types.RuleWithContent) types.ComparedRule {
+	return types.ComparedRule{
+		RuleID:      ruleID,
+		Description: ruleContent.Description,
+		TotalRisk:   ruleContent.TotalRisk,
+		Impact:      ruleContent.Impact,
+		Likelihood:  ruleContent.Likelihood,
+		PublishDate: ruleContent.PublishDate,
+		Tags:        ruleContent.Tags,
+	}
+}
+
+// sortComparedRules sorts the rules by total risk, the most risky ones go
+// first
+func sortComparedRules(rules []types.ComparedRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].TotalRisk != rules[j].TotalRisk {
+			return rules[i].TotalRisk > rules[j].TotalRisk
+		}
+		return rules[i].RuleID < rules[j].RuleID
+	})
+}
+
+// compareClusters computes the difference of rule hits between given
+// clusters. Disabled rules, rules without content and rules not relevant
+// for managed clusters are not taken into account.
+func compareClusters(
+	clusterInfoList []types.ClusterInfo,
+	clusterRecommendationsMap ctypes.ClusterRecommendationMap,
+	systemWideDisabledRules map[ctypes.RuleID]bool,
+	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
+) (*types.ClusterComparison, error) {
+	comparison := &types.ClusterComparison{
+		Clusters:    make([]types.ComparedCluster, 0, len(clusterInfoList)),
+		SharedHits:  []types.ComparedRule{},
+		UniqueHits:  make(map[types.ClusterName][]types.ComparedRule, len(clusterInfoList)),
+		PartialHits: []types.ComparedRule{},
+	}
+
+	// rules in order of first hit, so the output does not depend on map
+	// iteration
+	var hitRules []ctypes.RuleID
+	rulesByID := make(map[ctypes.RuleID]*types.ComparedRule)
+
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+		comparedCluster := types.ComparedCluster{
+			ClusterID:   clusterInfo.ID,
+			DisplayName: clusterInfo.DisplayName,
+			Managed:     clusterInfo.Managed,
+		}
+		comparison.UniqueHits[clusterInfo.ID] = []types.ComparedRule{}
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			clusterRecommendationsMap[clusterInfo.ID].Recommendations, clusterInfo.ID,
+			systemWideDisabledRules, disabledRulesPerCluster,
+		)
+
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					return nil, err
+				}
+				// missing rule content, the rule can't be displayed
+				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
+				continue
+			}
+
+			if clusterInfo.Managed && !ruleContent.OSDCustomer {
+				continue
+			}
+
+			comparedRule, found := rulesByID[ruleID]
+			if !found {
+				rule := newComparedRule(ruleID, ruleContent)
+				comparedRule = &rule
+				rulesByID[ruleID] = comparedRule
+				hitRules = append(hitRules, ruleID)
+			}
+			comparedRule.Clusters = append(comparedRule.Clusters, clusterInfo.ID)
+			comparedCluster.HitCount++
+		}
+
+		comparison.Clusters = append(comparison.Clusters, comparedCluster)
+	}
+
+	for _, ruleID := range hitRules {
+		comparedRule := rulesByID[ruleID]
+		switch len(comparedRule.Clusters) {
+		case len(clusterInfoList):
+			comparison.SharedHits = append(comparison.SharedHits, *comparedRule)
+		case 1:
+			clusterID := comparedRule.Clusters[0]
+			comparison.UniqueHits[clusterID] = append(comparison.UniqueHits[clusterID], *comparedRule)
+		default:
+			comparison.PartialHits = append(comparison.PartialHits, *comparedRule)
+		}
+	}
+
+	sortComparedRules(comparison.SharedHits)
+	sortComparedRules(comparison.PartialHits)
+	for _, uniqueHits := range comparison.UniqueHits {
+		sortComparedRules(uniqueHits)
+	}
+
+	return comparison, nil
+}
+
+// clusterCheckResult is the result of organization check of single cluster
+type clusterCheckResult struct {
+	clusterName ctypes.ClusterName
+	err         error
+}
+
+// lookupClustersOrganization starts the organization checks of the clusters
+// in AMS API, at most maxConcurrentClusterLookups of them run in parallel. The
+// returned channel is buffered, so the checks finishing after the caller
+// stops reading don't block.
+func (server HTTPServer) lookupClustersOrganization(
+	orgID ctypes.OrgID, clusterNames []ctypes.ClusterName,
+) <-chan clusterCheckResult {
+	results := make(chan clusterCheckResult, len(clusterNames))
+	semaphore := make(chan struct{}, maxConcurrentClusterLookups)
+	for _, clusterName := range clusterNames {
+		go func(clusterName ctypes.ClusterName) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results <- clusterCheckResult{clusterName, server.checkClusterOrganization(orgID, clusterName)}
+		}(clusterName)
+	}
+	return results
+}
+
+// checkClustersOrganization verifies that the clusters belong to the
+// organization. Clusters are looked up in AMS API in parallel. When the
+// configured time budget is exceeded, the clusters checked so far are
+// returned together with the clusters whose check has not finished. Error
+// is returned when any of the clusters belongs to another organization or
+// when no cluster has been checked in time.
+func (server HTTPServer) checkClustersOrganization(
+	orgID ctypes.OrgID, clusterNames []ctypes.ClusterName,
+) (checked, timedOut []ctypes.ClusterName, err error) {
+	results := server.lookupClustersOrganization(orgID, clusterNames)
+
+	// nil channel never fires, so there is no limit when the budget is not
+	// configured
+	var deadline <-chan time.Time
+	if budget := server.currentConfig().MultiClusterTimeBudget; budget > 0 {
+		timer := time.NewTimer(budget)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	done := make(map[ctypes.ClusterName]bool, len(clusterNames))
+collect:
+	for len(done) < len(clusterNames) {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				return nil, nil, result.err
+			}
+			done[result.clusterName] = true
+		case <-deadline:
+			break collect
+		}
+	}
+
+	for _, clusterName := range clusterNames {
+		if done[clusterName] {
+			checked = append(checked, clusterName)
+		} else {
+			timedOut = append(timedOut, clusterName)
+		}
+	}
+
+	if len(checked) == 0 {
+		log.Error().Int(orgIDTag, int(orgID)).Msg("no cluster checked within the time budget")
+		return nil, nil, &AMSAPIUnavailableError{}
+	}
+	if len(timedOut) > 0 {
+		log.Warn().Int(orgIDTag, int(orgID)).Int("timed_out", len(timedOut)).Msg("time budget exceeded, returning partial result")
+	}
+	return checked, timedOut, nil
+}
+
+// compareClustersEndpoint returns the difference of rule hits between two or
+// more clusters given in the path
+func (server HTTPServer) compareClustersEndpoint(writer http.ResponseWriter, request *http.Request) {
+	clusterList, successful := httputils.ReadClusterListFromPath(writer, request)
+	// Error message handled by function
+	if !successful {
+		return
+	}
+
+	clusterNames := uniqueClusterNames(clusterList)
+	if len(clusterNames) < minComparedClusters {
+		handleServerError(writer, &RouterParsingError{
+			paramName:  clusterListParam,
+			paramValue: clusterList,
+			errString:  "at least " + strconv.Itoa(minComparedClusters) + " different clusters are expected",
+		})
+		return
+	}
+
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterNames, timedOutClusters, err := server.checkClustersOrganization(orgID, clusterNames)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterInfoList := make([]types.ClusterInfo, 0, len(clusterNames))
+	for _, clusterName := range clusterNames {
+		clusterInfoList = append(clusterInfoList, server.getClusterInfo(clusterName))
+	}
+
+	systemWideDisabledRules := server.getRuleAcksMap(orgID)
+	disabledRulesPerCluster := server.getUserDisabledRulesPerCluster(orgID)
+
+	clusterRecommendationMap, err := server.getClustersAndRecommendations(writer, orgID, userID, clusterNames)
+	if err != nil {
+		// error has been handled already
+		return
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRecommendationMap)
+
+	comparison, err := compareClusters(
+		clusterInfoList, clusterRecommendationMap, systemWideDisabledRules, disabledRulesPerCluster,
+	)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	response := responses.BuildOkResponseWithData("comparison", comparison)
+	response["meta"] = types.PartialResultMeta{
+		Partial:          len(timedOutClusters) > 0,
+		TimedOutClusters: timedOutClusters,
+	}
+	if err = responses.SendOK(writer, response); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func comparedRuleIDs(rules []types.ComparedRule) []ctypes.RuleID {
+	ruleIDs := make([]ctypes.RuleID, 0, len(rules))
+	for _, rule := range rules {
+		ruleIDs = append(ruleIDs, rule.RuleID)
+	}
+	return ruleIDs
+}
+
+// TestCompareClusters checks how the rule hits are split into shared, unique
+// and partial ones
+func TestCompareClusters(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{
+				testdata.RuleContent1,
+				testdata.RuleContent2,
+				testdata.RuleContent3,
+			},
+		),
+	)
+	assert.Nil(t, err)
+
+	clusters := []types.ClusterInfo{
+		{ID: testdata.GetRandomClusterID()},
+		{ID: testdata.GetRandomClusterID()},
+		{ID: testdata.GetRandomClusterID()},
+	}
+	recommendations := ctypes.ClusterRecommendationMap{
+		clusters[0].ID: {Recommendations: []ctypes.RuleID{
+			testdata.Rule1CompositeID, testdata.Rule2CompositeID,
+		}},
+		clusters[1].ID: {Recommendations: []ctypes.RuleID{
+			testdata.Rule1CompositeID, testdata.Rule2CompositeID, testdata.Rule3CompositeID,
+		}},
+		clusters[2].ID: {Recommendations: []ctypes.RuleID{
+			testdata.Rule2CompositeID,
+		}},
+	}
+
+	comparison, err := server.CompareClusters(clusters, recommendations, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule2CompositeID}, comparedRuleIDs(comparison.SharedHits))
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule1CompositeID}, comparedRuleIDs(comparison.PartialHits))
+	assert.Equal(t, []ctypes.ClusterName{clusters[0].ID, clusters[1].ID}, comparison.PartialHits[0].Clusters)
+	assert.Empty(t, comparison.UniqueHits[clusters[0].ID])
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule3CompositeID}, comparedRuleIDs(comparison.UniqueHits[clusters[1].ID]))
+	assert.Empty(t, comparison.UniqueHits[clusters[2].ID])
+
+	hitCounts := []int{}
+	for _, cluster := range comparison.Clusters {
+		hitCounts = append(hitCounts, cluster.HitCount)
+	}
+	assert.Equal(t, []int{2, 3, 1}, hitCounts)
+
+	// rule disabled for one cluster is unique for the other one
+	comparison, err = server.CompareClusters(clusters[:2], recommendations, nil,
+		map[ctypes.ClusterName][]ctypes.RuleID{
+			clusters[0].ID: {testdata.Rule1CompositeID},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule2CompositeID}, comparedRuleIDs(comparison.SharedHits))
+	assert.ElementsMatch(t,
+		[]ctypes.RuleID{testdata.Rule1CompositeID, testdata.Rule3CompositeID},
+		comparedRuleIDs(comparison.UniqueHits[clusters[1].ID]),
+	)
+	assert.Empty(t, comparison.PartialHits)
+}
+
+// TestHTTPServer_ClustersComparisonSingleCluster checks that at least two
+// different clusters are required
+func TestHTTPServer_ClustersComparisonSingleCluster(t *testing.T) {
+	helpers.AssertAPIv2Request(
+		t,
+		&serverConfigJWT,
+		nil,
+		nil,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ClustersComparisonEndpoint,
+			EndpointArgs:       []interface{}{fmt.Sprintf("%v,%v", testdata.ClusterName, testdata.ClusterName)},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		},
+	)
+}
+
+// slowAMSClient delays the lookup of one cluster
+type slowAMSClient struct {
+	amsclient.AMSClient
+	slowCluster ctypes.ClusterName
+}
+
+func (client slowAMSClient) GetClusterDetailsFromExternalID(
+	clusterID ctypes.ClusterName,
+) (types.ClusterInfo, ctypes.OrgID, error) {
+	if clusterID == client.slowCluster {
+		time.Sleep(time.Second)
+	}
+	return client.AMSClient.GetClusterDetailsFromExternalID(clusterID)
+}
+
+// TestHTTPServer_ClustersComparisonTimeBudget checks that the clusters not
+// looked up within the time budget are reported and left out of comparison
+func TestHTTPServer_ClustersComparisonTimeBudget(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := make([]types.ClusterInfo, 3)
+		for i := range clusterInfoList {
+			clusterInfoList[i] = data.GetRandomClusterInfo()
+		}
+		clusterNames := types.GetClusterNames(clusterInfoList)
+		reqBody, err := json.Marshal(clusterNames[:2])
+		helpers.FailOnError(t, err)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       `{"clusters": {}, "status": "ok"}`,
+			},
+		)
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
+
+		config := serverConfigJWT
+		config.ValidateClusterOrganization = true
+		config.MultiClusterTimeBudget = 100 * time.Millisecond
+		amsClient := slowAMSClient{
+			AMSClient:   helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList),
+			slowCluster: clusterNames[2],
+		}
+		router := helpers.CreateHTTPServer(&config, nil, amsClient, nil).Initialize()
+
+		recorder := serveV2Request(router, strings.Replace(
+			server.ClustersComparisonEndpoint, "{cluster_list}",
+			fmt.Sprintf("%v,%v,%v", clusterNames[0], clusterNames[1], clusterNames[2]), 1,
+		))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response struct {
+			Comparison types.ClusterComparison `json:"comparison"`
+			Meta       types.PartialResultMeta `json:"meta"`
+		}
+		helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.True(t, response.Meta.Partial)
+		assert.Equal(t, []types.ClusterName{clusterNames[2]}, response.Meta.TimedOutClusters)
+		assert.Len(t, response.Comparison.Clusters, 2)
+	}, testTimeout)
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// clusterInfoCacheName is the cache label used in cache lookup metrics
+const clusterInfoCacheName = "cluster_info"
+
+// clusterInfoCacheEntry is a single cached cluster info with its expiration
+type clusterInfoCacheEntry struct {
+	info    types.ClusterInfo
+	expires time.Time
+}
+
+// clusterInfoCache stores cluster information retrieved from AMS API, so the
+// display names don't have to be requested for every response. Nil cache or
+// cache with zero TTL doesn't store anything.
+type clusterInfoCache struct {
+	ttl       time.Duration
+	mutex     sync.RWMutex
+	entries   map[types.ClusterName]clusterInfoCacheEntry
+	lastPurge time.Time
+}
+
+// newClusterInfoCache constructs new cache with given TTL of its entries
+func newClusterInfoCache(ttl time.Duration) *clusterInfoCache {
+	return &clusterInfoCache{
+		ttl:       ttl,
+		entries:   make(map[types.ClusterName]clusterInfoCacheEntry),
+		lastPurge: time.Now(),
+	}
+}
+
+func (cache *clusterInfoCache) enabled() bool {
+	return cache != nil && cache.ttl > 0
+}
+
+// get returns cached info about the cluster if it has not expired yet
+func (cache *clusterInfoCache) get(clusterID types.ClusterName) (types.ClusterInfo, bool) {
+	if !cache.enabled() {
+		return types.ClusterInfo{}, false
+	}
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	entry, found := cache.entries[clusterID]
+	if !found || time.Now().After(entry.expires) {
+		amsclient.CacheLookups.WithLabelValues(clusterInfoCacheName, amsclient.CacheMiss).Inc()
+		return types.ClusterInfo{}, false
+	}
+	amsclient.CacheLookups.WithLabelValues(clusterInfoCacheName, amsclient.CacheHit).Inc()
+	return entry.info, true
+}
+
+// set stores info about clusters. Expired entries are purged at most once
+// per TTL to keep the size of cache bounded.
+func (cache *clusterInfoCache) set(clustersInfo ...types.ClusterInfo) {
+	if !cache.enabled() {
+		return
+	}
+
+	now := time.Now()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if now.Sub(cache.lastPurge) > cache.ttl {
+		for clusterID, entry := range cache.entries {
+			if now.After(entry.expires) {
+				delete(cache.entries, clusterID)
+			}
+		}
+		cache.lastPurge = now
+	}
+
+	for _, info := range clustersInfo {
+		cache.entries[info.ID] = clusterInfoCacheEntry{
+			info:    info,
+			expires: now.Add(cache.ttl),
+		}
+	}
+}
+
+// getClusterInfo returns display name and managed status of given cluster.
+// The cluster ID is used as display name when AMS API is not available or
+// it doesn't know the cluster.
+func (server HTTPServer) getClusterInfo(clusterID types.ClusterName) types.ClusterInfo {
+	if info, found := server.clusterInfoCache.get(clusterID); found {
+		return info
+	}
+
+	info := types.ClusterInfo{ID: clusterID}
+	if server.amsClient != nil {
+		info = server.amsClient.GetClusterDetailsFromExternalClusterID(clusterID)
+		info.ID = clusterID
+		if info.DisplayName != "" {
+			server.clusterInfoCache.set(info)
+		}
+	}
+
+	if info.DisplayName == "" {
+		info.DisplayName = string(clusterID)
+	}
+	return info
+}
+
+// getClustersInfo returns display names and managed status of clusters that
+// belong to given organization. Only the clusters that are not cached are
+// requested from AMS API. Nil is returned when AMS API is not available.
+func (server HTTPServer) getClustersInfo(
+	orgID types.OrgID, clusterIDs []types.ClusterName,
+) map[types.ClusterName]types.ClusterInfo {
+	if server.amsClient == nil {
+		return nil
+	}
+
+	result := make(map[types.ClusterName]types.ClusterInfo, len(clusterIDs))
+	var missing []types.ClusterName
+	for _, clusterID := range clusterIDs {
+		if info, found := server.clusterInfoCache.get(clusterID); found {
+			result[clusterID] = info
+		} else {
+			missing = append(missing, clusterID)
+		}
+	}
+
+	if len(missing) > 0 {
+		clustersInfo, err := server.amsClient.GetClustersInfoForOrganization(orgID, missing)
+		if err != nil {
+			log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg("unable to retrieve cluster info from AMS API")
+		}
+		server.clusterInfoCache.set(clustersInfo...)
+		clusterInfoMap := types.ClusterInfoArrayToMap(clustersInfo)
+
+		for _, clusterID := range missing {
+			info, found := clusterInfoMap[clusterID]
+			if !found {
+				info = types.ClusterInfo{ID: clusterID}
+			}
+			if info.DisplayName == "" {
+				info.DisplayName = string(clusterID)
+			}
+			result[clusterID] = info
+		}
+	}
+
+	return result
+}
+
+// checkClusterOrganization verifies that the cluster belongs to the
+// organization using single cluster lookup in AMS API. Clusters of other
+// organizations are reported as not found. Nothing is checked when the
+// validation is disabled or AMS client is not available.
+func (server HTTPServer) checkClusterOrganization(orgID types.OrgID, clusterID types.ClusterName) error {
+	if !server.Config.ValidateClusterOrganization || server.amsClient == nil {
+		return nil
+	}
+
+	clusterInfo, clusterOrgID, err := server.amsClient.GetClusterDetailsFromExternalID(clusterID)
+	if err != nil {
+		if _, notFound := err.(*utypes.ItemNotFoundError); notFound {
+			return err
+		}
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("unable to retrieve cluster organization from AMS API")
+		return &AMSAPIUnavailableError{}
+	}
+
+	if clusterOrgID != orgID {
+		log.Warn().Int(orgIDTag, int(orgID)).Str(clusterIDTag, string(clusterID)).Msgf(
+			"cluster belongs to organization %d", clusterOrgID,
+		)
+		return &utypes.ItemNotFoundError{ItemID: clusterID}
+	}
+
+	server.clusterInfoCache.set(clusterInfo)
+	return nil
+}
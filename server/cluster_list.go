@@ -0,0 +1,99 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// defaultMaxClustersInRequest is used when the maximal number of
+	// clusters in request body is not configured
+	defaultMaxClustersInRequest = 1000
+
+	// reasons of rejection of clusters from the list in request body
+	rejectedInvalidClusterID = "invalid_cluster_id"
+	rejectedClusterNotFound  = "cluster_not_found"
+)
+
+// maxClustersInRequest returns the configured maximal number of clusters in
+// request body
+func (server HTTPServer) maxClustersInRequest() int {
+	if server.currentConfig().MaxClustersInRequest <= 0 {
+		return defaultMaxClustersInRequest
+	}
+	return server.currentConfig().MaxClustersInRequest
+}
+
+// validateClusterList deduplicates the clusters from request body and checks
+// that their IDs are well-formed and that they belong to the organization.
+// The clusters are returned in the order of their first occurrence together
+// with the validation report of rejected clusters. Detail of the problem is
+// returned when there are too many clusters.
+func (server HTTPServer) validateClusterList(orgID ctypes.OrgID, clusterList []string) (
+	clusterNames []ctypes.ClusterName, rejected []types.RejectedCluster, detail string, err error,
+) {
+	uniqueNames := uniqueClusterNames(clusterList)
+	if maxClusters := server.maxClustersInRequest(); len(uniqueNames) > maxClusters {
+		return nil, nil, fmt.Sprintf("at most %d clusters can be requested", maxClusters), nil
+	}
+
+	// reasons of rejection by cluster, the report keeps the order of the
+	// request
+	reasons := make(map[ctypes.ClusterName]string)
+	wellFormed := make([]ctypes.ClusterName, 0, len(uniqueNames))
+	for _, clusterName := range uniqueNames {
+		if _, err := uuid.Parse(string(clusterName)); err != nil {
+			reasons[clusterName] = rejectedInvalidClusterID
+			continue
+		}
+		wellFormed = append(wellFormed, clusterName)
+	}
+
+	results := server.lookupClustersOrganization(orgID, wellFormed)
+	for range wellFormed {
+		result := <-results
+		if result.err == nil {
+			continue
+		}
+		if _, notFound := result.err.(*utypes.ItemNotFoundError); !notFound {
+			return nil, nil, "", result.err
+		}
+		reasons[result.clusterName] = rejectedClusterNotFound
+	}
+
+	for _, clusterName := range uniqueNames {
+		if reason, found := reasons[clusterName]; found {
+			rejected = append(rejected, types.RejectedCluster{
+				ClusterID: string(clusterName),
+				Reason:    reason,
+			})
+			continue
+		}
+		clusterNames = append(clusterNames, clusterName)
+	}
+
+	if len(rejected) > 0 {
+		log.Warn().Int(orgIDTag, int(orgID)).Int("rejected", len(rejected)).Msg("clusters in request body rejected")
+	}
+	return clusterNames, rejected, "", nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// postClusterListWithAMS sends the body to v1 clusters/reports endpoint of
+// server validating cluster organization in AMS API
+func postClusterListWithAMS(config server.Configuration, clusters []types.ClusterInfo, body string) *httptest.ResponseRecorder {
+	config.ValidateClusterOrganization = true
+	router := helpers.CreateHTTPServer(
+		&config, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, clusters), nil,
+	).Initialize()
+
+	request := httptest.NewRequest(
+		http.MethodPost, config.APIv1Prefix+server.ReportForListOfClustersPayloadEndpoint, strings.NewReader(body),
+	)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestClusterListDeduplicated checks that duplicate clusters are forwarded to
+// aggregator only once
+func TestClusterListDeduplicated(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ReportForListOfClustersPayloadEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID},
+				Body:         `{"clusters":["` + data.ClusterName1 + `"]}`,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       helpers.ToJSONString(data.AggregatorReportForClusterList),
+			},
+		)
+
+		recorder := postClusterListWithAMS(serverConfigJWT,
+			[]types.ClusterInfo{{ID: data.ClusterName1}},
+			`{"clusters": ["`+data.ClusterName1+`", "`+data.ClusterName1+`"]}`,
+		)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}, testTimeout)
+}
+
+// TestClusterListRejectedClusters checks that malformed clusters and clusters
+// of other organizations are reported and that aggregator is not called
+func TestClusterListRejectedClusters(t *testing.T) {
+	recorder := postClusterListWithAMS(serverConfigJWT,
+		[]types.ClusterInfo{{ID: data.ClusterName1}},
+		`{"clusters": ["`+data.ClusterName1+`", "not-a-uuid", "`+data.ClusterName2+`", "not-a-uuid"]}`,
+	)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var problem server.Problem
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	assert.Equal(t, server.ErrorCodeInvalidBody, problem.Code)
+	assert.Equal(t, []types.RejectedCluster{
+		{ClusterID: "not-a-uuid", Reason: "invalid_cluster_id"},
+		{ClusterID: data.ClusterName2, Reason: "cluster_not_found"},
+	}, problem.RejectedClusters)
+}
+
+// TestClusterListTooManyClusters checks the limit of number of clusters
+func TestClusterListTooManyClusters(t *testing.T) {
+	config := serverConfigJWT
+	config.MaxClustersInRequest = 1
+
+	recorder := postClusterListWithAMS(config, nil,
+		`{"clusters": ["`+data.ClusterName1+`", "`+data.ClusterName2+`"]}`,
+	)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "at most 1 clusters can be requested")
+}
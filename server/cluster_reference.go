@@ -0,0 +1,85 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// clusterReferenceParam is the path parameter with external cluster ID, AMS
+// subscription ID or display name of the cluster
+const clusterReferenceParam = "cluster_ref"
+
+// resolveClusterReference returns the external ID of the cluster given by its
+// external ID, AMS subscription ID or display name. Only clusters of the
+// organization are looked up in AMS API.
+func (server HTTPServer) resolveClusterReference(orgID ctypes.OrgID, reference string) (ctypes.ClusterName, error) {
+	if _, err := uuid.Parse(reference); err == nil {
+		return ctypes.ClusterName(reference), nil
+	}
+
+	if server.amsClient == nil {
+		return "", &AMSAPIUnavailableError{}
+	}
+
+	clusters, err := server.amsClient.FindClustersForOrganization(orgID, reference)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("unable to look up cluster by reference in AMS API")
+		return "", &AMSAPIUnavailableError{}
+	}
+
+	switch len(clusters) {
+	case 0:
+		return "", &utypes.ItemNotFoundError{ItemID: reference}
+	case 1:
+		server.clusterInfoCache.set(clusters[0])
+		return clusters[0].ID, nil
+	default:
+		clusterIDs := make([]string, len(clusters))
+		for i := range clusters {
+			clusterIDs[i] = string(clusters[i].ID)
+		}
+		return "", &AmbiguousClusterReferenceError{reference: reference, clusters: clusterIDs}
+	}
+}
+
+// clusterReferenceReportEndpoint returns the report of cluster given by its
+// external ID, AMS subscription ID or display name, so clients knowing only
+// display names, like ACM, don't need their own mapping to external IDs
+func (server HTTPServer) clusterReferenceReportEndpoint(writer http.ResponseWriter, request *http.Request) {
+	// the parameter is always set by router
+	reference := mux.Vars(request)[clusterReferenceParam]
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterID, err := server.resolveClusterReference(orgID, reference)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	server.sendClusterReportV2(writer, request, clusterID)
+}
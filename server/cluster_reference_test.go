@@ -0,0 +1,107 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// clusterReferenceEndpoint returns the report endpoint for given reference
+func clusterReferenceEndpoint(reference string) string {
+	return strings.Replace(server.ClusterReferenceReportEndpoint, "{cluster_ref}", reference, 1)
+}
+
+// TestClusterReferenceByDisplayName checks that the report of cluster is
+// found by its display name
+func TestClusterReferenceByDisplayName(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := data.GetRandomClusterInfoList(2)
+		clusterInfoList[0].DisplayName = "prod-cluster"
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, clusterInfoList[0].ID, userIDOnGoodJWTAuthBearer},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report1RuleExpectedResponse,
+		})
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		router := helpers.CreateHTTPServer(
+			&serverConfigJWT, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList), nil,
+		).Initialize()
+
+		recorder := serveV2Request(router, clusterReferenceEndpoint("prod-cluster"))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response struct {
+			Report types.SmartProxyReportV2 `json:"report"`
+		}
+		helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, "prod-cluster", response.Report.Meta.DisplayName)
+	}, testTimeout)
+}
+
+// TestClusterReferenceAmbiguous checks that display name shared by more
+// clusters is refused
+func TestClusterReferenceAmbiguous(t *testing.T) {
+	clusterInfoList := data.GetRandomClusterInfoList(2)
+	clusterInfoList[0].DisplayName = "prod-cluster"
+	clusterInfoList[1].DisplayName = "prod-cluster"
+
+	router := helpers.CreateHTTPServer(
+		&serverConfigJWT, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList), nil,
+	).Initialize()
+
+	recorder := serveV2Request(router, clusterReferenceEndpoint("prod-cluster"))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"ambiguous_cluster_reference"`)
+}
+
+// TestClusterReferenceNotFound checks that unknown display name is not found
+// and that AMS API is needed to resolve display names
+func TestClusterReferenceNotFound(t *testing.T) {
+	router := helpers.CreateHTTPServer(
+		&serverConfigJWT, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, data.GetRandomClusterInfoList(2)), nil,
+	).Initialize()
+
+	recorder := serveV2Request(router, clusterReferenceEndpoint("unknown-cluster"))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	router = helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder = serveV2Request(router, clusterReferenceEndpoint("unknown-cluster"))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
@@ -17,24 +17,60 @@ limitations under the License.
 package server
 
 import (
+	"time"
+
 	types "github.com/RedHatInsights/insights-results-types"
 )
 
 // Configuration represents configuration of REST API HTTP server
 type Configuration struct {
-	Address                          string        `mapstructure:"address" toml:"address"`
-	APIdbgPrefix                     string        `mapstructure:"api_dbg_prefix" toml:"api_dbg_prefix"`
-	APIv1Prefix                      string        `mapstructure:"api_v1_prefix" toml:"api_v1_prefix"`
-	APIv2Prefix                      string        `mapstructure:"api_v2_prefix" toml:"api_v2_prefix"`
-	APIv1SpecFile                    string        `mapstructure:"api_v1_spec_file" toml:"api_v1_spec_file"`
-	APIv2SpecFile                    string        `mapstructure:"api_v2_spec_file" toml:"api_v2_spec_file"`
-	Debug                            bool          `mapstructure:"debug" toml:"debug"`
-	Auth                             bool          `mapstructure:"auth" toml:"auth"`
-	AuthType                         string        `mapstructure:"auth_type" toml:"auth_type"`
-	UseHTTPS                         bool          `mapstructure:"use_https" toml:"use_https"`
-	EnableCORS                       bool          `mapstructure:"enable_cors" toml:"enable_cors"`
-	EnableInternalRulesOrganizations bool          `mapstructure:"enable_internal_rules_organizations" toml:"enable_internal_rules_organizations"`
-	InternalRulesOrganizations       []types.OrgID `mapstructure:"internal_rules_organizations" toml:"internal_rules_organizations"`
-	LogAuthToken                     bool          `mapstructure:"log_auth_token" toml:"log_auth_token"`
-	UseOrgClustersFallback           bool          `mapstructure:"org_clusters_fallback" toml:"org_clusters_fallback"`
+	Address                          string                          `mapstructure:"address" toml:"address"`
+	APIdbgPrefix                     string                          `mapstructure:"api_dbg_prefix" toml:"api_dbg_prefix"`
+	APIv1Prefix                      string                          `mapstructure:"api_v1_prefix" toml:"api_v1_prefix"`
+	APIv2Prefix                      string                          `mapstructure:"api_v2_prefix" toml:"api_v2_prefix"`
+	APIv3Prefix                      string                          `mapstructure:"api_v3_prefix" toml:"api_v3_prefix"`
+	APIv1SpecFile                    string                          `mapstructure:"api_v1_spec_file" toml:"api_v1_spec_file"`
+	APIv2SpecFile                    string                          `mapstructure:"api_v2_spec_file" toml:"api_v2_spec_file"`
+	APIv3SpecFile                    string                          `mapstructure:"api_v3_spec_file" toml:"api_v3_spec_file"`
+	Debug                            bool                            `mapstructure:"debug" toml:"debug"`
+	Auth                             bool                            `mapstructure:"auth" toml:"auth"`
+	AuthType                         string                          `mapstructure:"auth_type" toml:"auth_type"`
+	UseHTTPS                         bool                            `mapstructure:"use_https" toml:"use_https"`
+	EnableHTTP2                      bool                            `mapstructure:"enable_http2" toml:"enable_http2"`
+	EnableH2C                        bool                            `mapstructure:"enable_h2c" toml:"enable_h2c"`
+	EnableCORS                       bool                            `mapstructure:"enable_cors" toml:"enable_cors"`
+	CORS                             CORSConfiguration               `mapstructure:"cors" toml:"cors"`
+	EnableInternalRulesOrganizations bool                            `mapstructure:"enable_internal_rules_organizations" toml:"enable_internal_rules_organizations"`
+	InternalRulesOrganizations       []types.OrgID                   `mapstructure:"internal_rules_organizations" toml:"internal_rules_organizations"`
+	LogAuthToken                     bool                            `mapstructure:"log_auth_token" toml:"log_auth_token"`
+	UseOrgClustersFallback           bool                            `mapstructure:"org_clusters_fallback" toml:"org_clusters_fallback"`
+	ClusterInfoCacheTTL              time.Duration                   `mapstructure:"cluster_info_cache_ttl" toml:"cluster_info_cache_ttl"`
+	NoReportCacheTTL                 time.Duration                   `mapstructure:"no_report_cache_ttl" toml:"no_report_cache_ttl"`
+	OrgOverviewCacheTTL              time.Duration                   `mapstructure:"org_overview_cache_ttl" toml:"org_overview_cache_ttl"`
+	IdentityCacheTTL                 time.Duration                   `mapstructure:"identity_cache_ttl" toml:"identity_cache_ttl"`
+	IncludeInactiveClusters          bool                            `mapstructure:"include_inactive_clusters" toml:"include_inactive_clusters"`
+	ExcludedClusterStatuses          []string                        `mapstructure:"excluded_cluster_statuses" toml:"excluded_cluster_statuses"`
+	ValidateClusterOrganization      bool                            `mapstructure:"validate_cluster_organization" toml:"validate_cluster_organization"`
+	ResponseModifiers                []ResponseModifierConfiguration `mapstructure:"response_modifiers" toml:"response_modifiers"`
+	OrgAccess                        OrgAccessConfiguration          `mapstructure:"org_access" toml:"org_access"`
+	Entitlements                     EntitlementsConfiguration       `mapstructure:"entitlements" toml:"entitlements"`
+	Routing                          RoutingConfiguration            `mapstructure:"routing" toml:"routing"`
+	StartupTimeout                   time.Duration                   `mapstructure:"startup_timeout" toml:"startup_timeout"`
+	MultiClusterTimeBudget           time.Duration                   `mapstructure:"multi_cluster_time_budget" toml:"multi_cluster_time_budget"`
+	MaxRequestBodySize               int64                           `mapstructure:"max_request_body_size" toml:"max_request_body_size"`
+	MaxClustersInRequest             int                             `mapstructure:"max_clusters_in_request" toml:"max_clusters_in_request"`
+	Maintenance                      MaintenanceConfiguration        `mapstructure:"maintenance" toml:"maintenance"`
+	Webhooks                         WebhooksConfiguration           `mapstructure:"webhooks" toml:"webhooks"`
+	ReportEvents                     ReportEventsConfiguration       `mapstructure:"report_events" toml:"report_events"`
+	UpstreamHealth                   UpstreamHealthConfiguration     `mapstructure:"upstream_health" toml:"upstream_health"`
+	Exports                          ExportsConfiguration            `mapstructure:"exports" toml:"exports"`
+	DebugListener                    DebugListenerConfiguration      `mapstructure:"debug_listener" toml:"debug_listener"`
+	WarmUp                           WarmUpConfiguration             `mapstructure:"warm_up" toml:"warm_up"`
+	ReportCache                      ReportCacheConfiguration        `mapstructure:"report_cache" toml:"report_cache"`
+	BearerAuth                       BearerAuthConfiguration         `mapstructure:"bearer_auth" toml:"bearer_auth"`
+	Impersonation                    ImpersonationConfiguration      `mapstructure:"impersonation" toml:"impersonation"`
+	ProxyHeaders                     ProxyHeadersConfiguration       `mapstructure:"proxy_headers" toml:"proxy_headers"`
+	Canary                           CanaryConfiguration             `mapstructure:"canary" toml:"canary"`
+	Shadow                           ShadowConfiguration             `mapstructure:"shadow" toml:"shadow"`
+	ServiceLog                       ServiceLogConfiguration         `mapstructure:"service_log" toml:"service_log"`
 }
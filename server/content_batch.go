@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// maxRuleContentBatchSize is the maximal number of rules requested at once
+const maxRuleContentBatchSize = 1000
+
+// recommendationContent converts the rule content into the format returned
+// by content endpoints
+func recommendationContent(ruleID ctypes.RuleID, ruleContent *types.RuleWithContent) types.RecommendationContent {
+	return types.RecommendationContent{
+		// RuleID in rule.module|ERROR_KEY format
+		RuleSelector: ctypes.RuleSelector(ruleID),
+		Description:  ruleContent.Description,
+		Generic:      ruleContent.Generic,
+		Reason:       ruleContent.Reason,
+		Resolution:   ruleContent.Resolution,
+		MoreInfo:     ruleContent.MoreInfo,
+		TotalRisk:    uint8(ruleContent.TotalRisk),
+		Impact:       uint8(ruleContent.Impact),
+		Likelihood:   uint8(ruleContent.Likelihood),
+		PublishDate:  ruleContent.PublishDate,
+		Tags:         ruleContent.Tags,
+	}
+}
+
+// validateRuleContentBatch checks the number and format of requested rule
+// selectors and returns them without duplicates in the requested order.
+// Detail of the problem is returned when the request is invalid.
+func validateRuleContentBatch(batch *types.RuleContentBatchRequest) ([]ctypes.RuleID, string) {
+	if len(batch.Rules) == 0 {
+		return nil, "at least one rule must be requested"
+	}
+	if len(batch.Rules) > maxRuleContentBatchSize {
+		return nil, fmt.Sprintf("at most %d rules can be requested at once", maxRuleContentBatchSize)
+	}
+
+	ruleIDs := make([]ctypes.RuleID, 0, len(batch.Rules))
+	seen := make(map[ctypes.RuleSelector]bool, len(batch.Rules))
+	for _, selector := range batch.Rules {
+		if !compositeRuleIDValidator.MatchString(string(selector)) {
+			return nil, fmt.Sprintf("rule %q must be in the format 'rule.plugin.module|ERROR_KEY'", selector)
+		}
+		if seen[selector] {
+			continue
+		}
+		seen[selector] = true
+		ruleIDs = append(ruleIDs, ctypes.RuleID(selector))
+	}
+	return ruleIDs, ""
+}
+
+// getRuleContentBatch returns static content of rules selected in the request
+// body along with rule groups. Unknown rules and internal rules the user
+// can't access are listed in not_found attribute of the response.
+func (server HTTPServer) getRuleContentBatch(writer http.ResponseWriter, request *http.Request) {
+	var batch types.RuleContentBatchRequest
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&batch); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	ruleIDs, detail := validateRuleContentBatch(&batch)
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	renderHTML, err := readHTMLFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	includeInternal := server.includeInternalRules(request)
+
+	rules := make([]types.RecommendationContent, 0, len(ruleIDs))
+	notFound := make([]ctypes.RuleSelector, 0)
+	for _, ruleID := range ruleIDs {
+		if content.IsRuleInternal(ruleID) && !includeInternal {
+			notFound = append(notFound, ctypes.RuleSelector(ruleID))
+			continue
+		}
+
+		ruleContent, err := content.GetContentForRecommendation(ruleID)
+		if err != nil {
+			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+				handleServerError(writer, err)
+				return
+			}
+			notFound = append(notFound, ctypes.RuleSelector(ruleID))
+			continue
+		}
+		if renderHTML {
+			ruleContent = content.RenderRuleWithContentHTML(ruleContent)
+		}
+		rules = append(rules, recommendationContent(ruleID, ruleContent))
+	}
+
+	// retrieve the latest groups configuration
+	ruleGroups, err := server.getGroupsConfig()
+	if err != nil {
+		log.Error().Msgf("error retrieving rule groups")
+		handleServerError(writer, err)
+		return
+	}
+
+	responseContent := make(map[string]interface{})
+	responseContent["status"] = OkMsg
+	responseContent["groups"] = ruleGroups
+	responseContent["content"] = rules
+	responseContent["not_found"] = notFound
+
+	err = responses.SendOK(writer, responseContent)
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
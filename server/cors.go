@@ -0,0 +1,143 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// corsAnyOrigin allows CORS requests from any origin
+const corsAnyOrigin = "*"
+
+// defaultCORSHeaders are allowed in CORS requests when no headers are
+// configured
+var defaultCORSHeaders = []string{
+	"Content-Type",
+	"Content-Length",
+	"Accept-Encoding",
+	"X-CSRF-Token",
+	"Authorization",
+}
+
+// defaultCORSMethods are allowed in CORS requests when no methods are
+// configured
+var defaultCORSMethods = []string{
+	http.MethodPost,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+// CORSConfiguration represents configuration of CORS middleware, it is used
+// when CORS is enabled
+type CORSConfiguration struct {
+	// AllowedOrigins lists origins allowed to send CORS requests, "*"
+	// allows any origin. Any origin is allowed when neither origins nor
+	// origin patterns are configured.
+	AllowedOrigins []string `mapstructure:"allowed_origins" toml:"allowed_origins"`
+	// AllowedOriginPatterns lists regular expressions matching whole
+	// origins allowed to send CORS requests
+	AllowedOriginPatterns []string `mapstructure:"allowed_origin_patterns" toml:"allowed_origin_patterns"`
+	AllowedHeaders        []string `mapstructure:"allowed_headers" toml:"allowed_headers"`
+	AllowedMethods        []string `mapstructure:"allowed_methods" toml:"allowed_methods"`
+	// AllowCredentials is not applied when any origin is allowed
+	AllowCredentials bool `mapstructure:"allow_credentials" toml:"allow_credentials"`
+}
+
+// allowsAnyOrigin returns true when CORS requests from any origin are allowed
+func (config CORSConfiguration) allowsAnyOrigin() bool {
+	if len(config.AllowedOrigins) == 0 && len(config.AllowedOriginPatterns) == 0 {
+		return true
+	}
+	return collections.StringInSlice(corsAnyOrigin, config.AllowedOrigins)
+}
+
+// newOriginValidator constructs function checking that the origin is listed
+// in allowed origins or that it matches any of allowed origin patterns.
+// Patterns that are not valid regular expressions are skipped.
+func newOriginValidator(origins, patterns []string) handlers.OriginValidator {
+	var expressions []*regexp.Regexp
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			log.Error().Err(err).Str("pattern", pattern).Msg("invalid CORS origin pattern")
+			continue
+		}
+		expressions = append(expressions, expression)
+	}
+
+	return func(origin string) bool {
+		if collections.StringInSlice(origin, origins) {
+			return true
+		}
+		for _, expression := range expressions {
+			if expression.MatchString(origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// corsMiddleware constructs CORS middleware from the configuration
+func (server *HTTPServer) corsMiddleware() mux.MiddlewareFunc {
+	config := server.Config.CORS
+
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	options := []handlers.CORSOption{
+		handlers.AllowedHeaders(headers),
+		handlers.AllowedMethods(methods),
+	}
+
+	if config.allowsAnyOrigin() {
+		// credentials must not be sent to any origin
+		if config.AllowCredentials {
+			log.Warn().Msg("CORS credentials are not allowed, because CORS requests from any origin are allowed")
+		}
+		return handlers.CORS(append(options, handlers.AllowedOrigins([]string{corsAnyOrigin}))...)
+	}
+
+	options = append(options, handlers.AllowedOriginValidator(
+		newOriginValidator(config.AllowedOrigins, config.AllowedOriginPatterns),
+	))
+	if config.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+	cors := handlers.CORS(options...)
+
+	return func(next http.Handler) http.Handler {
+		corsHandler := cors(next)
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			// the origin is sent back, so responses differ by origin
+			writer.Header().Add("Vary", "Origin")
+			corsHandler.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// sendCORSPreflight sends CORS preflight request from the origin to API V2
+// info endpoint
+func sendCORSPreflight(cors server.CORSConfiguration, origin string) *httptest.ResponseRecorder {
+	config := serverConfigJWT
+	config.EnableCORS = true
+	config.CORS = cors
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	request := httptest.NewRequest(http.MethodOptions, config.APIv2Prefix+server.InfoEndpoint, nil)
+	request.Header.Set("Origin", origin)
+	request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestCORSOriginPatterns checks that origins matching configured patterns
+// are allowed and that other origins are not
+func TestCORSOriginPatterns(t *testing.T) {
+	cors := server.CORSConfiguration{
+		AllowedOriginPatterns: []string{`https://[a-z]+\.example\.com`, `(invalid`},
+		AllowCredentials:      true,
+	}
+
+	recorder := sendCORSPreflight(cors, "https://console.example.com")
+	assert.Equal(t, "https://console.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", recorder.Header().Get("Vary"))
+
+	recorder = sendCORSPreflight(cors, "https://console.example.com.evil.org")
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+// TestCORSAnyOriginWithoutCredentials checks that credentials are not
+// allowed together with any origin
+func TestCORSAnyOriginWithoutCredentials(t *testing.T) {
+	recorder := sendCORSPreflight(server.CORSConfiguration{
+		AllowedOrigins:   []string{"*"},
+		AllowCredentials: true,
+	}, "https://evil.org")
+	assert.Equal(t, "*", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Credentials"))
+}
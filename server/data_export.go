@@ -0,0 +1,314 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// jsonFormat is the default format of report and recommendation lists
+	jsonFormat = "json"
+	// csvFormat means that the list is exported as CSV file
+	csvFormat = "csv"
+	// xlsxFormat means that the list is exported as Excel workbook
+	xlsxFormat = "xlsx"
+
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	contentDispositionHeader = "Content-Disposition"
+
+	// formulaTriggers are the characters starting formulas in spreadsheet
+	// applications
+	formulaTriggers = "=+-@\t\r"
+)
+
+// exportTable represents tabular data to be exported. Cells can contain
+// strings, numbers or booleans.
+type exportTable struct {
+	header []string
+	rows   [][]interface{}
+}
+
+// readExportFormatParam returns the requested export format according to
+// the "format" parameter in query. Empty string means that the usual JSON
+// response should be sent.
+func readExportFormatParam(request *http.Request) (string, error) {
+	value := strings.ToLower(request.URL.Query().Get(FormatParam))
+	switch value {
+	case "", jsonFormat:
+		return "", nil
+	case csvFormat, xlsxFormat:
+		return value, nil
+	default:
+		return "", &RouterParsingError{
+			paramName:  FormatParam,
+			paramValue: value,
+			errString:  "Unsupported format, use json, csv or xlsx",
+		}
+	}
+}
+
+// reportExportTable converts rules from the cluster report into table
+func reportExportTable(
+	clusterID types.ClusterName, clusterName string, rules []types.RuleWithContentResponse,
+) exportTable {
+	table := exportTable{
+		header: []string{
+			"cluster_id", "cluster_name", "rule_id", "error_key",
+			"description", "total_risk", "created_at", "disabled",
+		},
+		rows: make([][]interface{}, 0, len(rules)),
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		table.rows = append(table.rows, []interface{}{
+			string(clusterID), clusterName, string(rule.RuleID), string(rule.ErrorKey),
+			rule.Description, rule.TotalRisk, rule.CreatedAt, rule.Disabled,
+		})
+	}
+	return table
+}
+
+// recommendationsExportTable converts list of recommendations into table
+func recommendationsExportTable(recommendations []types.RecommendationListView) exportTable {
+	table := exportTable{
+		header: []string{
+			"rule_id", "description", "total_risk", "impacted_clusters_count",
+			"publish_date", "disabled",
+		},
+		rows: make([][]interface{}, 0, len(recommendations)),
+	}
+
+	for i := range recommendations {
+		recommendation := &recommendations[i]
+		table.rows = append(table.rows, []interface{}{
+			string(recommendation.RuleID), recommendation.Description,
+			recommendation.TotalRisk, recommendation.ImpactedClustersCnt,
+			recommendation.PublishDate.UTC().Format(time.RFC3339), recommendation.Disabled,
+		})
+	}
+	return table
+}
+
+// sendExport streams the table to the client in the requested format as an
+// attachment with given file name (without extension)
+func sendExport(writer http.ResponseWriter, format, filename string, table exportTable) {
+	var err error
+	switch format {
+	case csvFormat:
+		writer.Header().Set(contentTypeHeader, csvContentType)
+		writer.Header().Set(contentDispositionHeader, attachmentDisposition(filename, format))
+		writer.WriteHeader(http.StatusOK)
+		err = writeCSV(writer, table)
+	case xlsxFormat:
+		writer.Header().Set(contentTypeHeader, xlsxContentType)
+		writer.Header().Set(contentDispositionHeader, attachmentDisposition(filename, format))
+		writer.WriteHeader(http.StatusOK)
+		err = writeXLSX(writer, table)
+	default:
+		err = fmt.Errorf("unsupported export format %s", format)
+		handleServerError(writer, err)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("format", format).Msg("Unable to export data")
+	}
+}
+
+func attachmentDisposition(filename, format string) string {
+	return fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format)
+}
+
+// formatExportCell converts the cell into text. Texts that spreadsheet
+// applications would evaluate as formulas are escaped.
+func formatExportCell(cell interface{}) string {
+	switch value := cell.(type) {
+	case bool:
+		return strconv.FormatBool(value)
+	case int, uint8, uint32, int64, float64:
+		return fmt.Sprint(value)
+	default:
+		return escapeFormula(fmt.Sprint(value))
+	}
+}
+
+// escapeFormula prefixes the text starting with a formula trigger
+// character by apostrophe, so it is displayed as text by spreadsheet
+// applications (CSV/formula injection)
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune(formulaTriggers, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// writeCSV writes table header and all rows in CSV format
+func writeCSV(w io.Writer, table exportTable) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(table.header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.header))
+	for _, row := range table.rows {
+		for i, cell := range row {
+			record[i] = formatExportCell(cell)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// static parts of the minimal Office Open XML workbook with one sheet
+const (
+	xlsxContentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookXML = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetPath = "xl/worksheets/sheet1.xml"
+)
+
+// writeXLSX writes table into minimal Excel workbook. Numbers and booleans
+// are stored as typed cells, everything else as inline strings.
+func writeXLSX(w io.Writer, table exportTable) error {
+	archive := zip.NewWriter(w)
+
+	staticParts := []struct {
+		path    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypesXML},
+		{"_rels/.rels", xlsxRelsXML},
+		{"xl/workbook.xml", xlsxWorkbookXML},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelsXML},
+	}
+	for _, part := range staticParts {
+		partWriter, err := archive.Create(part.path)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheetWriter, err := archive.Create(xlsxSheetPath)
+	if err != nil {
+		return err
+	}
+	if err := writeXLSXSheet(sheetWriter, table); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeXLSXSheet(w io.Writer, table exportTable) error {
+	var sheet strings.Builder
+
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(table.header))
+	for i, name := range table.header {
+		header[i] = name
+	}
+	writeXLSXRow(&sheet, 1, header)
+	if _, err := io.WriteString(w, sheet.String()); err != nil {
+		return err
+	}
+
+	// rows are written one by one to not keep whole sheet in memory
+	for i, row := range table.rows {
+		sheet.Reset()
+		writeXLSXRow(&sheet, i+2, row)
+		if _, err := io.WriteString(w, sheet.String()); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, `</sheetData></worksheet>`)
+	return err
+}
+
+func writeXLSXRow(sheet *strings.Builder, rowNumber int, cells []interface{}) {
+	fmt.Fprintf(sheet, `<row r="%d">`, rowNumber)
+	for i, cell := range cells {
+		reference := xlsxColumnName(i) + strconv.Itoa(rowNumber)
+		switch value := cell.(type) {
+		case int, uint8, uint32, int64, float64:
+			fmt.Fprintf(sheet, `<c r="%s"><v>%v</v></c>`, reference, value)
+		case bool:
+			boolValue := 0
+			if value {
+				boolValue = 1
+			}
+			fmt.Fprintf(sheet, `<c r="%s" t="b"><v>%d</v></c>`, reference, boolValue)
+		default:
+			fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, reference)
+			// writing into strings.Builder never fails
+			_ = xml.EscapeText(sheet, []byte(formatExportCell(value)))
+			sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	sheet.WriteString(`</row>`)
+}
+
+// xlsxColumnName converts zero based column index into spreadsheet column
+// name (A, B, ..., Z, AA, AB, ...)
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,176 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var exportedRules = []types.RuleWithContentResponse{
+	{
+		RuleID:      "ccx_rules_ocp.external.rules.nodes_kubelet_version_check",
+		ErrorKey:    "NODE_KUBELET_VERSION",
+		Description: "Nodes \"worker\", <b>bold</b>",
+		TotalRisk:   2,
+		CreatedAt:   "2020-04-08T00:42:00Z",
+	},
+	{
+		RuleID:      "ccx_rules_ocp.external.rules.formula",
+		ErrorKey:    "FORMULA",
+		Description: "@SUM(A1:A2)",
+		TotalRisk:   1,
+		CreatedAt:   "2020-04-08T00:42:00Z",
+	},
+}
+
+func TestReadExportFormatParam(t *testing.T) {
+	for query, expected := range map[string]string{
+		"":             "",
+		"?format=json": "",
+		"?format=CSV":  "csv",
+		"?format=xlsx": "xlsx",
+	} {
+		request, err := http.NewRequest(http.MethodGet, "/report"+query, http.NoBody)
+		assert.NoError(t, err)
+
+		format, err := server.ReadExportFormatParam(request)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, "/report?format=pdf", http.NoBody)
+	assert.NoError(t, err)
+	_, err = server.ReadExportFormatParam(request)
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	table := server.ReportExportTable("cluster-id", "my cluster", exportedRules)
+
+	assert.NoError(t, server.WriteCSV(&buffer, table))
+	assert.Equal(t,
+		"cluster_id,cluster_name,rule_id,error_key,description,total_risk,created_at,disabled\n"+
+			"cluster-id,my cluster,ccx_rules_ocp.external.rules.nodes_kubelet_version_check,"+
+			"NODE_KUBELET_VERSION,\"Nodes \"\"worker\"\", <b>bold</b>\",2,2020-04-08T00:42:00Z,false\n"+
+			"cluster-id,my cluster,ccx_rules_ocp.external.rules.formula,FORMULA,'@SUM(A1:A2),1,2020-04-08T00:42:00Z,false\n",
+		buffer.String(),
+	)
+}
+
+func TestWriteCSVRecommendations(t *testing.T) {
+	var buffer bytes.Buffer
+	table := server.RecommendationsExportTable([]types.RecommendationListView{
+		{
+			RuleID:              "rule.module|ERROR_KEY",
+			Description:         "description",
+			PublishDate:         time.Date(2020, 4, 8, 0, 42, 0, 0, time.UTC),
+			TotalRisk:           3,
+			ImpactedClustersCnt: 5,
+		},
+	})
+
+	assert.NoError(t, server.WriteCSV(&buffer, table))
+	assert.Equal(t,
+		"rule_id,description,total_risk,impacted_clusters_count,publish_date,disabled\n"+
+			"rule.module|ERROR_KEY,description,3,5,2020-04-08T00:42:00Z,false\n",
+		buffer.String(),
+	)
+}
+
+// TestWriteCSVFormulaInjection checks that texts which would be evaluated as
+// formulas by spreadsheet applications are escaped
+func TestWriteCSVFormulaInjection(t *testing.T) {
+	var buffer bytes.Buffer
+	table := server.RecommendationsExportTable([]types.RecommendationListView{
+		{
+			RuleID:      "rule.module|ERROR_KEY",
+			Description: "=HYPERLINK(\"http://example.com\")",
+			PublishDate: time.Date(2020, 4, 8, 0, 42, 0, 0, time.UTC),
+			TotalRisk:   3,
+		},
+	})
+
+	assert.NoError(t, server.WriteCSV(&buffer, table))
+	assert.Equal(t,
+		"rule_id,description,total_risk,impacted_clusters_count,publish_date,disabled\n"+
+			"rule.module|ERROR_KEY,\"'=HYPERLINK(\"\"http://example.com\"\")\",3,0,2020-04-08T00:42:00Z,false\n",
+		buffer.String(),
+	)
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for text, expected := range map[string]string{
+		"":             "",
+		"description":  "description",
+		"a=b":          "a=b",
+		"=1+2":         "'=1+2",
+		"+1":           "'+1",
+		"-1":           "'-1",
+		"@SUM(A1:A2)":  "'@SUM(A1:A2)",
+		"\tcmd":        "'\tcmd",
+		"\rcmd":        "'\rcmd",
+		"'quoted text": "'quoted text",
+	} {
+		assert.Equal(t, expected, server.EscapeFormula(text), text)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buffer bytes.Buffer
+	table := server.ReportExportTable("cluster-id", "my cluster", exportedRules)
+
+	assert.NoError(t, server.WriteXLSX(&buffer, table))
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">cluster_id</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">my cluster</t></is></c>`)
+	assert.Contains(t, sheet, `&lt;b&gt;bold&lt;/b&gt;`)
+	assert.Contains(t, sheet, `<c r="F2"><v>2</v></c>`)
+	assert.Contains(t, sheet, `<c r="H2" t="b"><v>0</v></c>`)
+	assert.Contains(t, sheet, `<c r="E3" t="inlineStr"><is><t xml:space="preserve">&#39;@SUM(A1:A2)</t></is></c>`)
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	assert.Equal(t, "A", server.XLSXColumnName(0))
+	assert.Equal(t, "Z", server.XLSXColumnName(25))
+	assert.Equal(t, "AA", server.XLSXColumnName(26))
+	assert.Equal(t, "BA", server.XLSXColumnName(52))
+}
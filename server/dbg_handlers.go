@@ -0,0 +1,190 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// redactedValue replaces values of sensitive configuration options
+const redactedValue = "[REDACTED]"
+
+// sensitiveOptionNames are parts of names of configuration options whose
+// values are never returned
+var sensitiveOptionNames = []string{"secret", "password", "token", "dsn", "key"}
+
+// RouteInfo describes the route registered in the router
+type RouteInfo struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+}
+
+// registeredRoutes returns all routes registered in the router sorted by
+// their paths
+func registeredRoutes(router *mux.Router) ([]RouteInfo, error) {
+	routes := []RouteInfo{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			// routes without path, like the ones matching headers only
+			return nil
+		}
+		// routes without methods match all of them
+		methods, _ := route.GetMethods()
+		routes = append(routes, RouteInfo{Path: path, Methods: methods})
+		return nil
+	})
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, err
+}
+
+// dbgRoutes returns the handler listing all routes of the router
+func (server HTTPServer) dbgRoutes(router *mux.Router) func(http.ResponseWriter, *http.Request) {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if err := server.checkInternalEndpointPermissions(request); err != nil {
+			handleServerError(writer, err)
+			return
+		}
+
+		routes, err := registeredRoutes(router)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to list registered routes")
+			handleServerError(writer, err)
+			return
+		}
+
+		err = responses.SendOK(writer, responses.BuildOkResponseWithData("routes", routes))
+		if err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+	}
+}
+
+// dbgConfig returns the effective configuration of the server and of the
+// services, values of sensitive options are redacted. The configuration is
+// returned to internal organizations only, even when authentication is
+// disabled.
+func (server HTTPServer) dbgConfig(writer http.ResponseWriter, request *http.Request) {
+	if err := server.checkInternalOrganization(request); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	config := map[string]interface{}{
+		"server":   sanitizeConfiguration(reflect.ValueOf(*server.currentConfig())),
+		"services": sanitizeConfiguration(reflect.ValueOf(*server.currentServicesConfig())),
+	}
+	err := responses.SendOK(writer, responses.BuildOkResponseWithData("config", config))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// isSensitiveOption returns true if the option can contain credentials
+func isSensitiveOption(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveOptionNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeURL removes user name and password from the URL. Values that are
+// not URLs are returned unchanged.
+func sanitizeURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// sanitizeOption returns sanitized value of the option. Values of sensitive
+// options are redacted whatever their type is, unless they are not set.
+func sanitizeOption(name string, value reflect.Value) interface{} {
+	if isSensitiveOption(name) && value.IsValid() && !value.IsZero() {
+		return redactedValue
+	}
+	return sanitizeConfiguration(value)
+}
+
+// sanitizeConfiguration converts the configuration into the structure of
+// maps keyed by names of the options used in configuration file. Values of
+// sensitive options are redacted, credentials are removed from URLs.
+func sanitizeConfiguration(value reflect.Value) interface{} {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(value.Int()).String()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		options := make(map[string]interface{}, value.NumField())
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported field
+				continue
+			}
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			options[name] = sanitizeOption(name, value.Field(i))
+		}
+		return options
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = sanitizeConfiguration(value.Index(i))
+		}
+		return items
+	case reflect.Map:
+		items := make(map[string]interface{}, value.Len())
+		for _, key := range value.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			items[name] = sanitizeOption(name, value.MapIndex(key))
+		}
+		return items
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return sanitizeConfiguration(value.Elem())
+	case reflect.String:
+		return sanitizeURL(value.String())
+	case reflect.Func, reflect.Chan:
+		return nil
+	default:
+		return value.Interface()
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DebugPprofPrefix is the prefix of pprof endpoints served by the debug
+	// listener
+	DebugPprofPrefix = "/debug/pprof/"
+	// DebugProfilingEndpoint returns and changes the rates of mutex and
+	// block profiling
+	DebugProfilingEndpoint = "/debug/profiling"
+)
+
+// DebugListenerConfiguration represents configuration of the listener
+// serving pprof endpoints. The listener is not started unless the address is
+// configured, the endpoints are not exposed by the API server at all.
+type DebugListenerConfiguration struct {
+	// Address of the listener, it should not be reachable from outside
+	// of the cluster
+	Address string `mapstructure:"address" toml:"address"`
+	// MutexProfileFraction is the initial rate of mutex contention
+	// events reported in mutex profile, zero disables the profile
+	MutexProfileFraction int `mapstructure:"mutex_profile_fraction" toml:"mutex_profile_fraction"`
+	// BlockProfileRate is the initial rate of blocking events reported in
+	// block profile, zero disables the profile
+	BlockProfileRate int `mapstructure:"block_profile_rate" toml:"block_profile_rate"`
+}
+
+// ProfilingRates are the rates of mutex and block profiling. Both can be
+// changed in runtime through the debug listener.
+type ProfilingRates struct {
+	MutexProfileFraction int `json:"mutex_profile_fraction"`
+	BlockProfileRate     int `json:"block_profile_rate"`
+}
+
+// profilingRates holds the current rates, runtime doesn't allow reading the
+// block profile rate
+var profilingRates = struct {
+	sync.Mutex
+	ProfilingRates
+}{}
+
+// setProfilingRates applies the rates to the runtime
+func setProfilingRates(rates ProfilingRates) {
+	profilingRates.Lock()
+	defer profilingRates.Unlock()
+
+	runtime.SetMutexProfileFraction(rates.MutexProfileFraction)
+	runtime.SetBlockProfileRate(rates.BlockProfileRate)
+	profilingRates.ProfilingRates = rates
+}
+
+// getProfilingRates returns the current rates
+func getProfilingRates() ProfilingRates {
+	profilingRates.Lock()
+	defer profilingRates.Unlock()
+
+	return profilingRates.ProfilingRates
+}
+
+// newDebugHandler constructs the handler of the debug listener
+func newDebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(DebugPprofPrefix, pprof.Index)
+	mux.HandleFunc(DebugPprofPrefix+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(DebugPprofPrefix+"profile", pprof.Profile)
+	mux.HandleFunc(DebugPprofPrefix+"symbol", pprof.Symbol)
+	mux.HandleFunc(DebugPprofPrefix+"trace", pprof.Trace)
+	mux.HandleFunc(DebugProfilingEndpoint, profilingRatesHandler)
+	return mux
+}
+
+// profilingRatesHandler returns the profiling rates and changes them when
+// new rates are sent by PUT request
+func profilingRatesHandler(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var rates ProfilingRates
+		if err := json.NewDecoder(request.Body).Decode(&rates); err != nil {
+			log.Error().Err(err).Msg("wrong payload provided by client")
+			handleServerError(writer, bodyDecodingError(err))
+			return
+		}
+		if rates.MutexProfileFraction < 0 || rates.BlockProfileRate < 0 {
+			if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, "profiling rates must not be negative")); err != nil {
+				log.Error().Err(err).Msg(responseDataError)
+			}
+			return
+		}
+		setProfilingRates(rates)
+		log.Warn().
+			Int("mutex_profile_fraction", rates.MutexProfileFraction).
+			Int("block_profile_rate", rates.BlockProfileRate).
+			Msg("Profiling rates changed")
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := responses.SendOK(writer, responses.BuildOkResponseWithData("profiling", getProfilingRates()))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// StartDebugListener serves pprof endpoints on the dedicated address. It
+// returns immediately when the address is not configured.
+func (server *HTTPServer) StartDebugListener() error {
+	config := server.Config.DebugListener
+	if config.Address == "" {
+		log.Info().Msg("Debug listener is disabled")
+		return nil
+	}
+
+	setProfilingRates(ProfilingRates{
+		MutexProfileFraction: config.MutexProfileFraction,
+		BlockProfileRate:     config.BlockProfileRate,
+	})
+
+	log.Info().Msgf("Starting debug listener at '%s'", config.Address)
+	listener := &http.Server{
+		Addr:              config.Address,
+		Handler:           newDebugHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	err := listener.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Error().Err(err).Msg("Unable to start debug listener")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// serveDebugRequest sends the request to the handler of debug listener
+func serveDebugRequest(method, endpoint, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, endpoint, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	server.NewDebugHandler().ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestPprofNotExposedByAPIServer checks that profiling endpoints are served
+// by the debug listener only
+func TestPprofNotExposedByAPIServer(t *testing.T) {
+	config := serverConfigJWT
+	config.Debug = true
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	request := httptest.NewRequest(http.MethodGet, server.DebugPprofPrefix, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.NotEqual(t, http.StatusOK, recorder.Code)
+
+	recorder = serveDebugRequest(http.MethodGet, server.DebugPprofPrefix, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// TestProfilingRates checks that profiling rates can be changed in runtime
+func TestProfilingRates(t *testing.T) {
+	defer serveDebugRequest(http.MethodPut, server.DebugProfilingEndpoint, `{}`)
+
+	recorder := serveDebugRequest(http.MethodPut, server.DebugProfilingEndpoint,
+		`{"mutex_profile_fraction": 5, "block_profile_rate": 10000}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveDebugRequest(http.MethodGet, server.DebugProfilingEndpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"mutex_profile_fraction":5`)
+	assert.Contains(t, recorder.Body.String(), `"block_profile_rate":10000`)
+
+	recorder = serveDebugRequest(http.MethodPut, server.DebugProfilingEndpoint, `{"block_profile_rate": -1}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveDebugRequest(http.MethodPost, server.DebugProfilingEndpoint, `{}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
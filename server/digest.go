@@ -0,0 +1,282 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// DigestOrgIDParam selects the organization whose digest is returned
+	DigestOrgIDParam = "org_id"
+
+	defaultDigestDays = 7
+	maxDigestDays     = 30
+
+	// digestUserID is sent to aggregator instead of ID of the requester,
+	// the digest doesn't depend on the user
+	digestUserID = ctypes.UserID("digest")
+)
+
+// readDigestDays reads the number of days considered by the digest
+func readDigestDays(request *http.Request) (int, error) {
+	days, err := readNonNegativeIntParam(request, DaysParam, defaultDigestDays)
+	if err != nil {
+		return 0, err
+	}
+	if days < 1 || days > maxDigestDays {
+		return 0, &RouterParsingError{
+			paramName:  DaysParam,
+			paramValue: request.URL.Query().Get(DaysParam),
+			errString:  "value between 1 and " + strconv.Itoa(maxDigestDays) + " is expected",
+		}
+	}
+	return days, nil
+}
+
+// digestSnippet renders the first paragraph of the generic content of the
+// rule into HTML, so it can be put into email as it is
+func digestSnippet(ruleContent *types.RuleWithContent) string {
+	generic := strings.TrimSpace(ruleContent.Generic)
+	if generic == "" {
+		generic = strings.TrimSpace(ruleContent.Summary)
+	}
+	paragraph := strings.SplitN(generic, "\n\n", 2)[0]
+	return content.RenderMarkdownToHTML(paragraph)
+}
+
+// buildDigest groups the clusters newly hit by recommendations by total risk
+// of the recommendations. All severities are returned, the most risky first,
+// so the email template doesn't need to handle missing ones.
+func buildDigest(
+	orgID ctypes.OrgID,
+	since time.Time,
+	ruleContents map[ctypes.RuleID]*types.RuleWithContent,
+	newHits map[ctypes.RuleID][]types.DigestCluster,
+) types.Digest {
+	digest := types.Digest{
+		OrgID:      orgID,
+		Since:      since,
+		BySeverity: make([]types.DigestSeverity, 0, len(riskLabels)),
+	}
+	severityIndex := make(map[int]int, len(riskLabels))
+	for _, level := range riskLabels {
+		severityIndex[level.totalRisk] = len(digest.BySeverity)
+		digest.BySeverity = append(digest.BySeverity, types.DigestSeverity{
+			TotalRisk:       level.totalRisk,
+			Severity:        level.label,
+			Recommendations: []types.DigestRecommendation{},
+		})
+	}
+
+	clustersHit := make(map[ctypes.ClusterName]bool)
+	for ruleID, clusters := range newHits {
+		ruleContent := ruleContents[ruleID]
+		index, found := severityIndex[ruleContent.TotalRisk]
+		if !found || len(clusters) == 0 {
+			continue
+		}
+
+		for _, cluster := range clusters {
+			clustersHit[cluster.ClusterID] = true
+		}
+		severity := &digest.BySeverity[index]
+		severity.Hits += len(clusters)
+		severity.Recommendations = append(severity.Recommendations, types.DigestRecommendation{
+			RuleID:      ctypes.RuleSelector(ruleID),
+			Description: ruleContent.Description,
+			Snippet:     digestSnippet(ruleContent),
+			Clusters:    clusters,
+		})
+		digest.Hits += len(clusters)
+	}
+	digest.ClustersHit = len(clustersHit)
+
+	// recommendations hitting more clusters go first
+	for i := range digest.BySeverity {
+		recommendations := digest.BySeverity[i].Recommendations
+		sort.Slice(recommendations, func(a, b int) bool {
+			if len(recommendations[a].Clusters) != len(recommendations[b].Clusters) {
+				return len(recommendations[a].Clusters) > len(recommendations[b].Clusters)
+			}
+			return recommendations[a].RuleID < recommendations[b].RuleID
+		})
+	}
+	return digest
+}
+
+// fetchDigestHits returns the clusters hit by each recommendation since the
+// given time. Disabled rules and rules not relevant for managed clusters are
+// not returned, the same as in organization overview. Errors are sent to the
+// writer.
+func (server HTTPServer) fetchDigestHits(
+	writer http.ResponseWriter,
+	orgID ctypes.OrgID,
+	since time.Time,
+) (map[ctypes.RuleID]*types.RuleWithContent, map[ctypes.RuleID][]types.DigestCluster, bool) {
+	var (
+		waitGroup               sync.WaitGroup
+		ackedRulesMap           map[ctypes.RuleID]bool
+		disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID
+	)
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	defer waitGroup.Wait()
+
+	statusFilter := server.clusterStatusFilter(server.Config.IncludeInactiveClusters, nil)
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return nil, nil, false
+	}
+
+	clusterRecommendationMap, err := server.getClustersAndRecommendations(
+		writer, orgID, digestUserID, types.GetClusterNames(clusterInfoList),
+	)
+	if err != nil {
+		// error has been handled already
+		return nil, nil, false
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRecommendationMap)
+
+	waitGroup.Wait()
+
+	// clusters hit by each enabled recommendation
+	ruleContents := make(map[ctypes.RuleID]*types.RuleWithContent)
+	ruleClusters := make(map[ctypes.RuleID][]types.ClusterInfo)
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+
+		hittingRecommendations, found := clusterRecommendationMap[clusterInfo.ID]
+		if !found {
+			continue
+		}
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			hittingRecommendations.Recommendations, clusterInfo.ID,
+			ackedRulesMap, disabledRulesPerCluster,
+		)
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					handleServerError(writer, err)
+					return nil, nil, false
+				}
+				// missing rule content, the rule can't be displayed
+				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
+				continue
+			}
+
+			if clusterInfo.Managed && !ruleContent.OSDCustomer {
+				continue
+			}
+			ruleContents[ruleID] = ruleContent
+			ruleClusters[ruleID] = append(ruleClusters[ruleID], *clusterInfo)
+		}
+	}
+
+	// the time of the first hit is known per recommendation only
+	newHits := make(map[ctypes.RuleID][]types.DigestCluster, len(ruleClusters))
+	for ruleID, clusters := range ruleClusters {
+		impactedClusters, err := server.getImpactedClusters(
+			writer, orgID, digestUserID, ctypes.RuleSelector(ruleID), clusters, false,
+		)
+		if err != nil {
+			log.Error().Err(err).Int(orgIDTag, int(orgID)).Str(selectorStr, string(ruleID)).
+				Msg("Couldn't get impacted clusters for given rule selector")
+			handleServerError(writer, err)
+			return nil, nil, false
+		}
+
+		displayNames := make(map[ctypes.ClusterName]string, len(clusters))
+		for _, cluster := range clusters {
+			displayNames[cluster.ID] = cluster.DisplayName
+		}
+		for _, impactedCluster := range impactedClusters {
+			displayName, found := displayNames[impactedCluster.Cluster]
+			if !found {
+				continue
+			}
+			impacted, err := time.Parse(time.RFC3339, impactedCluster.ImpactedSince)
+			if err != nil {
+				log.Warn().Err(err).Msgf("invalid time format %v", impactedCluster.ImpactedSince)
+				continue
+			}
+			if impacted.Before(since) {
+				continue
+			}
+			newHits[ruleID] = append(newHits[ruleID], types.DigestCluster{
+				ClusterID:   impactedCluster.Cluster,
+				DisplayName: displayName,
+				Impacted:    impactedCluster.ImpactedSince,
+			})
+		}
+	}
+	return ruleContents, newHits, true
+}
+
+// getDigest returns the payload of email digest about recommendations which
+// started to hit clusters of the organization in the last days. It is used
+// by the notifications service, so the digest content is assembled in one
+// place.
+func (server HTTPServer) getDigest(writer http.ResponseWriter, request *http.Request) {
+	if err := server.checkInternalEndpointPermissions(request); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	orgID, err := readOrgIDQueryParam(request, DigestOrgIDParam)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	days, err := readDigestDays(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	ruleContents, newHits, successful := server.fetchDigestHits(writer, orgID, since)
+	if !successful {
+		return
+	}
+
+	digest := buildDigest(orgID, since, ruleContents, newHits)
+	if err = responses.SendOK(writer, responses.BuildOkResponseWithData("digest", digest)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestBuildDigest checks that new hits are grouped by severity and that all
+// severities are returned
+func TestBuildDigest(t *testing.T) {
+	since := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	ruleContents := map[ctypes.RuleID]*types.RuleWithContent{
+		"ccx.rule_a|KEY": {Description: "Rule A", TotalRisk: 4, Generic: "First *paragraph*.\n\nSecond paragraph."},
+		"ccx.rule_b|KEY": {Description: "Rule B", TotalRisk: 2, Generic: "Rule B content."},
+		"ccx.rule_c|KEY": {Description: "Rule C", TotalRisk: 2, Generic: "Rule C content."},
+	}
+	newHits := map[ctypes.RuleID][]types.DigestCluster{
+		"ccx.rule_a|KEY": {{ClusterID: "cluster-1", DisplayName: "one"}},
+		"ccx.rule_b|KEY": {{ClusterID: "cluster-1", DisplayName: "one"}},
+		"ccx.rule_c|KEY": {{ClusterID: "cluster-1", DisplayName: "one"}, {ClusterID: "cluster-2", DisplayName: "two"}},
+	}
+
+	digest := server.BuildDigest(42, since, ruleContents, newHits)
+	assert.Equal(t, ctypes.OrgID(42), digest.OrgID)
+	assert.Equal(t, since, digest.Since)
+	assert.Equal(t, 2, digest.ClustersHit)
+	assert.Equal(t, 4, digest.Hits)
+
+	assert.Len(t, digest.BySeverity, 4)
+	critical := digest.BySeverity[0]
+	assert.Equal(t, "Critical", critical.Severity)
+	assert.Equal(t, 1, critical.Hits)
+	assert.Len(t, critical.Recommendations, 1)
+	assert.Equal(t, "<p>First <em>paragraph</em>.</p>", critical.Recommendations[0].Snippet)
+
+	moderate := digest.BySeverity[2]
+	assert.Equal(t, "Moderate", moderate.Severity)
+	assert.Equal(t, 3, moderate.Hits)
+	// recommendation hitting more clusters goes first
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_c|KEY"), moderate.Recommendations[0].RuleID)
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_b|KEY"), moderate.Recommendations[1].RuleID)
+
+	assert.Equal(t, 0, digest.BySeverity[1].Hits)
+	assert.Empty(t, digest.BySeverity[3].Recommendations)
+}
+
+// TestDigestEndpointBadParameters checks validation of the organization and
+// the number of days
+func TestDigestEndpointBadParameters(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	for _, query := range []string{
+		"",
+		"?org_id=abc",
+		"?org_id=1&days=0",
+		"?org_id=1&days=31",
+	} {
+		request := httptest.NewRequest(http.MethodGet, serverConfigJWT.APIv2Prefix+server.DigestEndpoint+query, http.NoBody)
+		request.Header.Set("Authorization", goodJWTAuthBearer)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// aggregatorDVONamespacesEndpoint returns all namespaces with DVO
+	// recommendations for given organization
+	aggregatorDVONamespacesEndpoint = "organization/{org_id}/workloads"
+
+	// aggregatorDVONamespaceEndpoint returns DVO recommendations for
+	// single namespace in given cluster
+	aggregatorDVONamespaceEndpoint = "organization/{org_id}/namespace/{namespace}/cluster/{cluster}/workloads"
+
+	namespaceParam = "namespace"
+	workloadParam  = "workload"
+)
+
+// readDVOWorkloadsFromAggregator reads DVO workloads from given aggregator
+// URL into the target structure. Errors are handled and false is returned
+// when the workloads can't be read.
+func readDVOWorkloadsFromAggregator(
+	writer http.ResponseWriter, aggregatorURL string, target interface{},
+) bool {
+	// #nosec G107
+	aggregatorResp, err := http.Get(aggregatorURL)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			handleServerError(writer, &AggregatorServiceUnavailableError{})
+		} else {
+			handleServerError(writer, err)
+		}
+		return false
+	}
+
+	defer services.CloseResponseBody(aggregatorResp)
+
+	responseBytes, err := io.ReadAll(aggregatorResp.Body)
+	if err != nil {
+		handleServerError(writer, err)
+		return false
+	}
+
+	if aggregatorResp.StatusCode != http.StatusOK {
+		err := responses.Send(aggregatorResp.StatusCode, writer, responseBytes)
+		if err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return false
+	}
+
+	response := struct {
+		Status    string      `json:"status"`
+		Workloads interface{} `json:"workloads"`
+	}{Workloads: target}
+
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		handleServerError(writer, err)
+		return false
+	}
+	return true
+}
+
+// readNamespaceAndCluster reads the namespace and cluster ID from request path
+func readNamespaceAndCluster(writer http.ResponseWriter, request *http.Request) (
+	namespace string, clusterID types.ClusterName, successful bool,
+) {
+	clusterID, successful = httputils.ReadClusterName(writer, request)
+	// error handled by function
+	if !successful {
+		return
+	}
+
+	namespace = mux.Vars(request)[namespaceParam]
+	if namespace == "" {
+		handleServerError(writer, &RouterMissingParamError{paramName: namespaceParam})
+		return "", "", false
+	}
+	return namespace, clusterID, true
+}
+
+// fetchDVONamespaceWorkloads reads DVO recommendations for the namespace in
+// the cluster specified in request path
+func (server HTTPServer) fetchDVONamespaceWorkloads(writer http.ResponseWriter, request *http.Request) (
+	*types.DVOWorkloadsForCluster, bool,
+) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return nil, false
+	}
+
+	namespace, clusterID, successful := readNamespaceAndCluster(writer, request)
+	if !successful {
+		return nil, false
+	}
+
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		aggregatorDVONamespaceEndpoint,
+		orgID,
+		namespace,
+		clusterID,
+	)
+
+	var workloads types.DVOWorkloadsForCluster
+	if !readDVOWorkloadsFromAggregator(writer, aggregatorURL, &workloads) {
+		return nil, false
+	}
+
+	if workloads.Cluster.DisplayName == "" {
+		workloads.Cluster.DisplayName = server.getClusterInfo(clusterID).DisplayName
+	}
+	return &workloads, true
+}
+
+// getDVONamespacesForCluster returns list of namespaces in cluster with DVO
+// workload recommendations
+func (server HTTPServer) getDVONamespacesForCluster(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// error handled by function
+	if !successful {
+		return
+	}
+
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		aggregatorDVONamespacesEndpoint,
+		orgID,
+	)
+
+	var workloads []types.DVOWorkloadsForNamespace
+	if !readDVOWorkloadsFromAggregator(writer, aggregatorURL, &workloads) {
+		return
+	}
+
+	clusterInfo := server.getClusterInfo(clusterID)
+	namespaces := make([]types.DVOWorkloadsForNamespace, 0)
+	for _, namespace := range workloads {
+		if namespace.Cluster.UUID != clusterID {
+			continue
+		}
+		if namespace.Cluster.DisplayName == "" {
+			namespace.Cluster.DisplayName = clusterInfo.DisplayName
+		}
+		namespaces = append(namespaces, namespace)
+	}
+
+	log.Info().Int(orgIDTag, int(orgID)).Str(clusterIDTag, string(clusterID)).
+		Msgf("number of namespaces with DVO recommendations: %d", len(namespaces))
+
+	if err = responses.SendOK(writer, responses.BuildOkResponseWithData("namespaces", namespaces)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// getDVOWorkloadsForNamespace returns list of workloads in the namespace hit
+// by DVO recommendations
+func (server HTTPServer) getDVOWorkloadsForNamespace(writer http.ResponseWriter, request *http.Request) {
+	workloads, successful := server.fetchDVONamespaceWorkloads(writer, request)
+	if !successful {
+		return
+	}
+
+	recommendationsPerObject := make(map[string]*types.DVOWorkload)
+	for _, recommendation := range workloads.Recommendations {
+		for _, object := range recommendation.Objects {
+			workload, found := recommendationsPerObject[object.UID]
+			if !found {
+				workload = &types.DVOWorkload{DVOObject: object}
+				recommendationsPerObject[object.UID] = workload
+			}
+			workload.Recommendations++
+		}
+	}
+
+	list := make([]types.DVOWorkload, 0, len(recommendationsPerObject))
+	for _, workload := range recommendationsPerObject {
+		list = append(list, *workload)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UID < list[j].UID
+	})
+
+	response := map[string]interface{}{
+		"status":    OkMsg,
+		"cluster":   workloads.Cluster,
+		"namespace": workloads.Namespace,
+		"metadata":  workloads.Metadata,
+		"workloads": list,
+	}
+	if err := responses.SendOK(writer, response); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// getDVOWorkloadDetail returns all DVO recommendations hitting single
+// workload, including the rule content
+func (server HTTPServer) getDVOWorkloadDetail(writer http.ResponseWriter, request *http.Request) {
+	workloadUID := mux.Vars(request)[workloadParam]
+	if workloadUID == "" {
+		handleServerError(writer, &RouterMissingParamError{paramName: workloadParam})
+		return
+	}
+
+	workloads, successful := server.fetchDVONamespaceWorkloads(writer, request)
+	if !successful {
+		return
+	}
+
+	detail := types.DVOWorkloadDetail{
+		Cluster:         workloads.Cluster,
+		Namespace:       workloads.Namespace,
+		Recommendations: make([]types.DVORecommendationWithContent, 0),
+	}
+
+	for i := range workloads.Recommendations {
+		recommendation := &workloads.Recommendations[i]
+		for _, object := range recommendation.Objects {
+			if object.UID == workloadUID {
+				detail.Workload = object
+				detail.Recommendations = append(detail.Recommendations, dvoRecommendationWithContent(recommendation))
+				break
+			}
+		}
+	}
+
+	if len(detail.Recommendations) == 0 {
+		handleServerError(writer, &utypes.ItemNotFoundError{ItemID: workloadUID})
+		return
+	}
+
+	if err := responses.SendOK(writer, responses.BuildOkResponseWithData("workload", detail)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// dvoRecommendationWithContent fills in the rule content for DVO
+// recommendation. Texts returned by aggregator are kept when the content is
+// not available.
+func dvoRecommendationWithContent(recommendation *types.DVORecommendation) types.DVORecommendationWithContent {
+	result := types.DVORecommendationWithContent{
+		Check:      recommendation.Check,
+		Details:    recommendation.Details,
+		Resolution: recommendation.Resolution,
+		MoreInfo:   recommendation.MoreInfo,
+		Modified:   recommendation.Modified,
+	}
+
+	ruleID, errorKey := recommendation.Check, ""
+	if separator := strings.Index(ruleID, "|"); separator >= 0 {
+		ruleID, errorKey = ruleID[:separator], ruleID[separator+1:]
+	}
+
+	ruleContent, err := content.GetRuleWithErrorKeyContent(ctypes.RuleID(ruleID), ctypes.ErrorKey(errorKey))
+	if err != nil {
+		log.Warn().Err(err).Str("check", recommendation.Check).Msg("unable to get content for DVO recommendation")
+		return result
+	}
+
+	result.Description = ruleContent.Description
+	result.Details = ruleContent.Generic
+	result.Reason = ruleContent.Reason
+	result.Resolution = ruleContent.Resolution
+	result.MoreInfo = ruleContent.MoreInfo
+	result.TotalRisk = ruleContent.TotalRisk
+
+	// fields that can't be interpolated are kept as they are
+	for _, field := range []*string{&result.Description, &result.Details, &result.Reason, &result.Resolution} {
+		if interpolated, err := content.InterpolateTemplate(*field, recommendation.TemplateData); err == nil {
+			*field = interpolated
+		}
+	}
+	return result
+}
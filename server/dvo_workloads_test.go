@@ -0,0 +1,246 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	dvoNamespace = "namespace-uuid"
+	dvoWorkload  = "workload-uid"
+)
+
+var (
+	dvoCluster = types.DVOCluster{UUID: testdata.ClusterName}
+
+	dvoNamespaceInfo = types.DVONamespace{UUID: dvoNamespace, Name: "my-namespace"}
+
+	dvoMetadata = types.DVOMetadata{
+		Recommendations: 1,
+		Objects:         2,
+		HighestSeverity: 2,
+	}
+
+	dvoRecommendation = types.DVORecommendation{
+		Check:      string(testdata.Rule1CompositeID),
+		Details:    "aggregator details",
+		Resolution: "aggregator resolution",
+		Objects: []types.DVOObject{
+			{Kind: "Deployment", UID: dvoWorkload},
+			{Kind: "DaemonSet", UID: "other-uid"},
+		},
+	}
+)
+
+func expectDVONamespaceWorkloads(t testing.TB) {
+	helpers.GockExpectAPIRequest(
+		t,
+		helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+		&helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     "organization/{org_id}/namespace/{namespace}/cluster/{cluster}/workloads",
+			EndpointArgs: []interface{}{testdata.OrgID, dvoNamespace, testdata.ClusterName},
+		},
+		&helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(map[string]interface{}{
+				"status": "ok",
+				"workloads": types.DVOWorkloadsForCluster{
+					Cluster:         dvoCluster,
+					Namespace:       dvoNamespaceInfo,
+					Metadata:        dvoMetadata,
+					Recommendations: []types.DVORecommendation{dvoRecommendation},
+				},
+			}),
+		},
+	)
+}
+
+func TestHTTPServer_DVONamespacesForCluster(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	otherCluster := types.DVOWorkloadsForNamespace{
+		Cluster:   types.DVOCluster{UUID: testdata.GetRandomClusterID(), DisplayName: "other"},
+		Namespace: dvoNamespaceInfo,
+		Metadata:  dvoMetadata,
+	}
+	thisCluster := types.DVOWorkloadsForNamespace{
+		Cluster:   dvoCluster,
+		Namespace: dvoNamespaceInfo,
+		Metadata:  dvoMetadata,
+	}
+
+	helpers.GockExpectAPIRequest(
+		t,
+		helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+		&helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     "organization/{org_id}/workloads",
+			EndpointArgs: []interface{}{testdata.OrgID},
+		},
+		&helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(map[string]interface{}{
+				"status":    "ok",
+				"workloads": []types.DVOWorkloadsForNamespace{otherCluster, thisCluster},
+			}),
+		},
+	)
+
+	// display name is filled in from the cluster ID as AMS is not available
+	thisCluster.Cluster.DisplayName = string(testdata.ClusterName)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(
+		t,
+		testServer,
+		serverConfigJWT.APIv2Prefix,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.DVONamespacesForClusterEndpoint,
+			EndpointArgs:       []interface{}{testdata.ClusterName},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(map[string]interface{}{
+				"status":     "ok",
+				"namespaces": []types.DVOWorkloadsForNamespace{thisCluster},
+			}),
+		},
+	)
+}
+
+func TestHTTPServer_DVOWorkloadsForNamespace(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	expectDVONamespaceWorkloads(t)
+
+	cluster := dvoCluster
+	cluster.DisplayName = string(testdata.ClusterName)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(
+		t,
+		testServer,
+		serverConfigJWT.APIv2Prefix,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.DVOWorkloadsForNamespaceEndpoint,
+			EndpointArgs:       []interface{}{dvoNamespace, testdata.ClusterName},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(map[string]interface{}{
+				"status":    "ok",
+				"cluster":   cluster,
+				"namespace": dvoNamespaceInfo,
+				"metadata":  dvoMetadata,
+				"workloads": []types.DVOWorkload{
+					{DVOObject: types.DVOObject{Kind: "DaemonSet", UID: "other-uid"}, Recommendations: 1},
+					{DVOObject: types.DVOObject{Kind: "Deployment", UID: dvoWorkload}, Recommendations: 1},
+				},
+			}),
+		},
+	)
+}
+
+func TestHTTPServer_DVOWorkloadDetail(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	ruleContent, err := content.GetRuleWithErrorKeyContent(testdata.Rule1ID, testdata.ErrorKey1)
+	assert.Nil(t, err)
+
+	defer helpers.CleanAfterGock(t)
+
+	expectDVONamespaceWorkloads(t)
+
+	cluster := dvoCluster
+	cluster.DisplayName = string(testdata.ClusterName)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(
+		t,
+		testServer,
+		serverConfigJWT.APIv2Prefix,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.DVOWorkloadDetailEndpoint,
+			EndpointArgs:       []interface{}{dvoNamespace, testdata.ClusterName, dvoWorkload},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(map[string]interface{}{
+				"status": "ok",
+				"workload": types.DVOWorkloadDetail{
+					Cluster:   cluster,
+					Namespace: dvoNamespaceInfo,
+					Workload:  types.DVOObject{Kind: "Deployment", UID: dvoWorkload},
+					Recommendations: []types.DVORecommendationWithContent{
+						{
+							Check:       string(testdata.Rule1CompositeID),
+							Description: ruleContent.Description,
+							Details:     ruleContent.Generic,
+							Reason:      ruleContent.Reason,
+							Resolution:  ruleContent.Resolution,
+							MoreInfo:    ruleContent.MoreInfo,
+							TotalRisk:   ruleContent.TotalRisk,
+						},
+					},
+				},
+			}),
+		},
+	)
+}
+
+func TestHTTPServer_DVOWorkloadDetailNotFound(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	expectDVONamespaceWorkloads(t)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(
+		t,
+		testServer,
+		serverConfigJWT.APIv2Prefix,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.DVOWorkloadDetailEndpoint,
+			EndpointArgs:       []interface{}{dvoNamespace, testdata.ClusterName, "unknown-uid"},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusNotFound,
+			Body: `{
+				"type": "urn:insights-results-smart-proxy:error:not_found",
+				"title": "Item not found",
+				"status": 404,
+				"detail": "Item with ID unknown-uid was not found in the storage",
+				"code": "not_found"
+			}`,
+		},
+	)
+}
@@ -15,9 +15,10 @@
 package server
 
 import (
+	"net/http"
+
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
 	"github.com/gorilla/mux"
-	"net/http"
 )
 
 const (
@@ -29,12 +30,16 @@ const (
 	DbgDeleteClustersEndpoint = ira_server.DeleteClustersEndpoint
 	// DbgGetVoteOnRuleEndpoint is an endpoint to get vote on rule. DEBUG only
 	DbgGetVoteOnRuleEndpoint = "clusters/{cluster}/rules/{rule_id}/error_key/{error_key}/get_vote"
+	// DbgRoutesEndpoint lists all registered routes with their methods. DEBUG only
+	DbgRoutesEndpoint = "routes"
+	// DbgConfigEndpoint returns sanitized effective configuration. DEBUG only
+	DbgConfigEndpoint = "config"
 )
 
 // adddbgEndpointsToRouter adds API dbg specific endpoints to the router
 func (server *HTTPServer) adddbgEndpointsToRouter(router *mux.Router) {
 	apiPrefix := server.Config.APIdbgPrefix
-	aggregatorBaseEndpoint := server.ServicesConfig.AggregatorBaseEndpoint
+	aggregatorBaseEndpoint := server.currentServicesConfig().AggregatorBaseEndpoint
 
 	router.HandleFunc(apiPrefix+DbgOrganizationsEndpoint, server.proxyTo(aggregatorBaseEndpoint, nil)).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+DbgDeleteOrganizationsEndpoint, server.proxyTo(aggregatorBaseEndpoint, nil)).Methods(http.MethodDelete)
@@ -47,6 +52,6 @@ func (server *HTTPServer) adddbgEndpointsToRouter(router *mux.Router) {
 		}},
 	)).Methods(http.MethodGet)
 
-	// endpoints for pprof - needed for profiling, ie. usually in debug mode
-	router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
+	router.HandleFunc(apiPrefix+DbgRoutesEndpoint, server.dbgRoutes(router)).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+DbgConfigEndpoint, server.dbgConfig).Methods(http.MethodGet)
 }
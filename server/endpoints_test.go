@@ -15,6 +15,7 @@
 package server_test
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
@@ -73,7 +74,7 @@ func TestHTTPServer_ProxyTo_VoteEndpointsExtractUserID(t *testing.T) {
 					Body:       `{"status": "ok"}`,
 				})
 
-				helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 					Method:             testCase.method,
 					Endpoint:           testCase.endpoint,
 					EndpointArgs:       []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1},
@@ -91,9 +92,60 @@ func TestHTTPServer_ProxyTo_VoteEndpointsExtractUserID(t *testing.T) {
 
 // TODO: test that proxying is done correctly including request / response modifiers for all endpoints
 
+// TestHTTPServer_ProxyTo_ConfiguredResponseModifiers checks that modifiers
+// configured for proxied endpoint are chained on the response
+func TestHTTPServer_ProxyTo_ConfiguredResponseModifiers(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		defer content.ResetContent()
+		err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+		assert.Nil(t, err)
+
+		config := helpers.DefaultServerConfig
+		config.ResponseModifiers = []server.ResponseModifierConfiguration{
+			{Endpoint: server.LikeRuleEndpoint, Modifier: server.StripFieldsModifier, Args: []string{"debug"}},
+			{Endpoint: server.LikeRuleEndpoint, Modifier: server.RewriteURLsModifier, Args: []string{"http://aggregator/", "https://console/"}},
+			{Endpoint: server.LikeRuleEndpoint, Modifier: "unknown"},
+		}
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodPut,
+			Endpoint:     ira_server.LikeRuleEndpoint,
+			EndpointArgs: []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1, testdata.OrgID, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       `{"status": "ok", "debug": {"took": 12}, "items": [{"link": "http://aggregator/rule", "debug": true}]}`,
+		})
+
+		helpers.AssertAPIRequest(t, &config, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodPut,
+			Endpoint:           server.LikeRuleEndpoint,
+			EndpointArgs:       []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       `{"status": "ok", "items": [{"link": "https://console/rule"}]}`,
+		})
+	}, testTimeout)
+}
+
+func TestModifyJSONBodyNotJSON(t *testing.T) {
+	modifiers := []server.JSONModifier{
+		func(_ *http.Request, _ interface{}) (interface{}, error) {
+			return nil, errors.New("modifier should not be called")
+		},
+	}
+
+	body, err := server.ModifyJSONBody(modifiers, nil, []byte("not JSON"))
+	assert.NoError(t, err)
+	assert.Equal(t, "not JSON", string(body))
+}
+
 func TestHTTPServer_ProxyTo_VoteEndpointBadCharacter(t *testing.T) {
 	badClusterName := "00000000000000000000000000000000000%1F"
-	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfig, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfig, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 		Method:             http.MethodPut,
 		Endpoint:           server.LikeRuleEndpoint,
 		EndpointArgs:       []interface{}{badClusterName, testdata.Rule1ID, testdata.ErrorKey1},
@@ -102,6 +154,12 @@ func TestHTTPServer_ProxyTo_VoteEndpointBadCharacter(t *testing.T) {
 		AuthorizationToken: goodJWTAuthBearer,
 	}, &helpers.APIResponse{
 		StatusCode: http.StatusBadRequest,
-		Body:       `{"status":"the parameters contains invalid characters and cannot be used"}`,
+		Body: `{
+			"type": "urn:insights-results-smart-proxy:error:invalid_parameter",
+			"title": "Invalid parameter",
+			"status": 400,
+			"detail": "the parameters contains invalid characters and cannot be used",
+			"code": "invalid_parameter"
+		}`,
 	})
 }
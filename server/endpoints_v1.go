@@ -18,10 +18,10 @@ import (
 	"net/http"
 	"path/filepath"
 
-	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
 	"github.com/gorilla/mux"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
 )
 
 const (
@@ -83,13 +83,29 @@ const (
 	// InfoEndpoint returns basic information about content service
 	// version, utils repository version, commit hash etc.
 	InfoEndpoint = "info"
+
+	// StatusEndpoint returns state of rule content and groups configuration
+	// retrieved from content service
+	StatusEndpoint = "status"
+
+	// ContentRefreshEndpoint triggers immediate refresh of rule content and
+	// groups configuration from content service
+	ContentRefreshEndpoint = "internal/content/refresh"
+
+	// MaintenanceEndpoint returns and changes the state of maintenance
+	// mode
+	MaintenanceEndpoint = "internal/maintenance"
+
+	// AuditLogEndpoint returns the audit log records of mutating
+	// operations made in the organization
+	AuditLogEndpoint = "internal/audit"
 )
 
 // addV1EndpointsToRouter adds API V1 specific endpoints to the router
 func (server *HTTPServer) addV1EndpointsToRouter(router *mux.Router) {
 	apiPrefix := server.Config.APIv1Prefix
 	openAPIURL := apiPrefix + filepath.Base(server.Config.APIv1SpecFile)
-	aggregatorBaseEndpoint := server.ServicesConfig.AggregatorBaseEndpoint
+	aggregatorBaseEndpoint := server.currentServicesConfig().AggregatorBaseEndpoint
 
 	// It is possible to use special REST API endpoints in debug mode
 	if server.Config.Debug {
@@ -98,10 +114,19 @@ func (server *HTTPServer) addV1EndpointsToRouter(router *mux.Router) {
 
 	// Common REST API endpoints
 	router.HandleFunc(apiPrefix+MainEndpoint, server.mainEndpoint).Methods(http.MethodGet)
-	router.HandleFunc(apiPrefix+ClustersForOrganizationEndpoint, server.getClustersForOrg).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ClustersForOrganizationEndpoint, server.shadowed(
+		ShadowClustersForOrganization, server.getClustersForOrg, server.getClustersForOrgNative,
+	)).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+OverviewEndpoint, server.overviewEndpoint).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+OverviewEndpoint, server.overviewEndpointWithClusterIDs).Methods(http.MethodPost)
 	router.HandleFunc(apiPrefix+InfoEndpoint, server.infoMap).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiPrefix+StatusEndpoint, server.statusEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ContentRefreshEndpoint, server.refreshContent).Methods(http.MethodPost)
+	router.HandleFunc(apiPrefix+MaintenanceEndpoint, server.getMaintenance).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+MaintenanceEndpoint, server.setMaintenance).Methods(http.MethodPut)
+	if server.auditLog != nil {
+		router.HandleFunc(apiPrefix+AuditLogEndpoint, server.getAuditLog).Methods(http.MethodGet)
+	}
 
 	// Reports endpoints
 	server.addV1ReportsEndpointsToRouter(router, apiPrefix, aggregatorBaseEndpoint)
@@ -113,20 +138,20 @@ func (server *HTTPServer) addV1EndpointsToRouter(router *mux.Router) {
 	server.addV1RuleEndpointsToRouter(router, apiPrefix, aggregatorBaseEndpoint)
 
 	// Prometheus metrics
-	router.Handle(apiPrefix+MetricsEndpoint, promhttp.Handler()).Methods(http.MethodGet)
+	router.Handle(apiPrefix+MetricsEndpoint, metricsHandler()).Methods(http.MethodGet)
 
 	// OpenAPI specs
-	router.HandleFunc(
+	router.Handle(
 		openAPIURL,
-		httputils.CreateOpenAPIHandler(server.Config.APIv1SpecFile, server.Config.Debug, true),
+		newOpenAPISpecHandler(router, apiPrefix, server.Config.APIv1SpecFile, server.Config.Debug),
 	).Methods(http.MethodGet)
 }
 
 // addV1ReportsEndpointsToRouter method registers handlers for endpoints that
 // return cluster report or reports to client
 func (server *HTTPServer) addV1ReportsEndpointsToRouter(router *mux.Router, apiPrefix, aggregatorBaseURL string) {
-	router.HandleFunc(apiPrefix+OldReportEndpoint, server.reportEndpointV1).Methods(http.MethodGet, http.MethodOptions)
-	router.HandleFunc(apiPrefix+ReportEndpoint, server.reportEndpointV1).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiPrefix+OldReportEndpoint, server.audited(audit.ReportViewed, server.reportEndpointV1)).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiPrefix+ReportEndpoint, server.audited(audit.ReportViewed, server.reportEndpointV1)).Methods(http.MethodGet, http.MethodOptions)
 	router.HandleFunc(apiPrefix+ReportMetainfoEndpoint, server.reportMetainfoEndpoint).Methods(http.MethodGet, http.MethodOptions)
 	router.HandleFunc(apiPrefix+ReportForListOfClustersEndpoint, server.reportForListOfClustersEndpoint).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+ReportForListOfClustersPayloadEndpoint, server.reportForListOfClustersPayloadEndpoint).Methods(http.MethodPost)
@@ -151,53 +176,53 @@ func (server *HTTPServer) addV1DebugEndpointsToRouter(router *mux.Router, apiPre
 func (server *HTTPServer) addV1RuleEndpointsToRouter(router *mux.Router, apiPrefix, aggregatorBaseEndpoint string) {
 	router.HandleFunc(apiPrefix+SingleRuleEndpoint, server.singleRuleEndpoint).Methods(http.MethodGet, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+LikeRuleEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+LikeRuleEndpoint, server.auditLogged(auditOperationVote, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractUserIDOrgIDFromTokenToURLRequestModifier(ira_server.LikeRuleEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPut, http.MethodOptions)
+	))).Methods(http.MethodPut, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+DislikeRuleEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+DislikeRuleEndpoint, server.auditLogged(auditOperationVote, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractUserIDOrgIDFromTokenToURLRequestModifier(ira_server.DislikeRuleEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPut, http.MethodOptions)
+	))).Methods(http.MethodPut, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+ResetVoteOnRuleEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+ResetVoteOnRuleEndpoint, server.auditLogged(auditOperationVote, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractUserIDOrgIDFromTokenToURLRequestModifier(ira_server.ResetVoteOnRuleEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPut, http.MethodOptions)
+	))).Methods(http.MethodPut, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+DisableRuleForClusterEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+DisableRuleForClusterEndpoint, server.auditLogged(auditOperationDisable, server.audited(audit.RuleDisabled, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractOrgIDFromTokenToURLRequestModifier(ira_server.DisableRuleForClusterEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPut, http.MethodOptions)
+	)))).Methods(http.MethodPut, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+EnableRuleForClusterEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+EnableRuleForClusterEndpoint, server.auditLogged(auditOperationEnable, server.audited(audit.RuleEnabled, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractOrgIDFromTokenToURLRequestModifier(ira_server.EnableRuleForClusterEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPut, http.MethodOptions)
+	)))).Methods(http.MethodPut, http.MethodOptions)
 
-	router.HandleFunc(apiPrefix+DisableRuleFeedbackEndpoint, server.proxyTo(
+	router.HandleFunc(apiPrefix+DisableRuleFeedbackEndpoint, server.auditLogged(auditOperationFeedback, server.proxyTo(
 		aggregatorBaseEndpoint,
 		&ProxyOptions{RequestModifiers: []RequestModifier{
 			server.extractUserIDOrgIDFromTokenToURLRequestModifier(ira_server.DisableRuleFeedbackEndpoint),
 			checkRuleIDAndErrorKeyAreValid(),
 		}},
-	)).Methods(http.MethodPost, http.MethodOptions)
+	))).Methods(http.MethodPost, http.MethodOptions)
 }
 
 // addV1ContentEndpointsToRouter method registers handlers for endpoints that
@@ -18,16 +18,32 @@ import (
 	"net/http"
 	"path/filepath"
 
-	httputils "github.com/RedHatInsights/insights-operator-utils/http"
-
 	"github.com/gorilla/mux"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
 )
 
 const (
 	// ReportEndpointV2 https://issues.redhat.com/browse/CCXDEV-5097
 	ReportEndpointV2 = "cluster/{cluster}/reports"
 
+	// OCMReportEndpoint returns the cluster report in the format expected
+	// by OpenShift Cluster Manager UI
+	OCMReportEndpoint = "cluster/{cluster}/reports/ocm"
+
+	// ClusterReferenceReportEndpoint returns the report of cluster given
+	// by its external ID, AMS subscription ID or display name
+	ClusterReferenceReportEndpoint = "clusters/lookup/{cluster_ref}/reports"
+
+	// ReportHistoryEndpoint returns number of rules hitting the cluster
+	// per day
+	ReportHistoryEndpoint = "cluster/{cluster}/report/history"
+
+	// ReportEventsEndpoint streams server-sent events about new reports of
+	// the cluster
+	ReportEventsEndpoint = "cluster/{cluster}/report/events"
+
 	// ClusterInfoEndpoint provides information about given cluster retrieved from AMS API
 	ClusterInfoEndpoint = "cluster/{cluster}/info"
 
@@ -35,6 +51,34 @@ const (
 	// the given cluster.
 	UpgradeRisksPredictionEndpoint = "cluster/{cluster}/upgrade-risks-prediction"
 
+	// ClusterRequestsEndpoint returns archives uploaded by the cluster and
+	// processed by the external data pipeline
+	ClusterRequestsEndpoint = "cluster/{cluster}/requests"
+
+	// ClusterRequestReportEndpoint returns rules found in the processed
+	// archive
+	ClusterRequestReportEndpoint = "cluster/{cluster}/request/{request_id}/report"
+
+	// PlaybookEndpoint generates Ansible remediation playbook for selected
+	// rules hitting the cluster
+	PlaybookEndpoint = "cluster/{cluster}/playbook"
+
+	// ServiceLogEndpoint sends the rule hitting the cluster to OCM Service
+	// Log, so the cluster owners are notified about it
+	ServiceLogEndpoint = "cluster/{cluster}/rules/{rule_id}/service_log"
+
+	// DVONamespacesForClusterEndpoint returns namespaces in cluster with
+	// DVO (Deployment Validation Operator) workload recommendations
+	DVONamespacesForClusterEndpoint = "cluster/{cluster}/namespaces/dvo"
+
+	// DVOWorkloadsForNamespaceEndpoint returns workloads in namespace hit
+	// by DVO recommendations
+	DVOWorkloadsForNamespaceEndpoint = "namespaces/dvo/{namespace}/cluster/{cluster}/workloads"
+
+	// DVOWorkloadDetailEndpoint returns all DVO recommendations, including
+	// their content, hitting single workload
+	DVOWorkloadDetailEndpoint = "namespaces/dvo/{namespace}/cluster/{cluster}/workloads/{workload}"
+
 	// ClustersDetail https://issues.redhat.com/browse/CCXDEV-5088
 	ClustersDetail = "rule/{rule_selector}/clusters_detail"
 
@@ -44,17 +88,38 @@ const (
 	// ClustersRecommendationsEndpoint returns a list of all clusters, number of impacting rules and number of rules by total risk
 	ClustersRecommendationsEndpoint = "clusters"
 
+	// ClustersComparisonEndpoint returns difference of rule hits between
+	// two or more clusters
+	ClustersComparisonEndpoint = "clusters/{cluster_list}/compare"
+
 	// RuleContentV2 https://issues.redhat.com/browse/CCXDEV-5094
 	// additionally group info is added too
 	// https://github.com/RedHatInsights/insights-results-smart-proxy/pull/604
 	RuleContentV2 = "rule/{rule_id}/content"
 
+	// RuleContentByErrorKeyV2 returns the same content as RuleContentV2, but
+	// rule module and error key are separate path parameters, so the
+	// documentation can link to the rule pages
+	RuleContentByErrorKeyV2 = "rule/{rule_id}/content/{error_key}"
+
 	// RuleContentWithUserData returns same as RuleContentV2, but includes user-specific data
 	RuleContentWithUserData = "rule/{rule_id}"
 
 	// ContentV2 returns all the static content available for the user
 	ContentV2 = "content"
 
+	// RuleSearchEndpoint returns rules with content matching free-text
+	// query provided in q parameter
+	RuleSearchEndpoint = "rules/search"
+
+	// RuleCatalogEndpoint returns paginated catalog of all rules with their
+	// groups, tags, total risk and publish status
+	RuleCatalogEndpoint = "content/rules"
+
+	// RuleContentBatchEndpoint returns static content of rules selected in
+	// the request body, so clients don't need to request rules one by one
+	RuleContentBatchEndpoint = "content/rules:batch"
+
 	// Endpoints to acknowledge rule and to manipulate with
 	// acknowledgements.
 
@@ -82,15 +147,58 @@ const (
 	// ID. If the ack existed, it is deleted and a 204 is returned.
 	// Otherwise, a 404 is returned.
 	AckDeleteEndpoint = "ack/{rule_id}"
+
+	// WebhooksEndpoint lists webhooks of the organization and registers
+	// new ones. Webhooks are notified about new critical recommendations.
+	WebhooksEndpoint = "webhooks"
+
+	// WebhookEndpoint deletes the webhook
+	WebhookEndpoint = "webhooks/{webhook_id}"
+
 	// Rating endpoint will get/modify the vote for a rule id by the user
 	Rating = "rating"
+
+	// RuleRatingsEndpoint returns ratings of all rules stored for the
+	// organization together with the rule content and sets the rating of
+	// a rule
+	RuleRatingsEndpoint = "rules/ratings"
+
+	// RuleVoteEndpointV2 returns the vote of the user on the rule together
+	// with the rule description and changes the vote
+	RuleVoteEndpointV2 = "clusters/{cluster}/rules/{rule_id}/error_key/{error_key}/vote"
+
+	// RuleFeedbackEndpointV2 stores free-text feedback of the user on the
+	// rule
+	RuleFeedbackEndpointV2 = "clusters/{cluster}/rules/{rule_id}/error_key/{error_key}/feedback"
+
+	// PreferencesEndpoint returns, replaces and resets Advisor preferences
+	// of the user
+	PreferencesEndpoint = "preferences"
+
+	// GraphQLEndpoint resolves GraphQL queries over clusters of the
+	// organization, rules hitting them and rule content
+	GraphQLEndpoint = "graphql"
+
+	// ExportJobsEndpoint submits the export of reports of all clusters of
+	// the organization, the export runs in background
+	ExportJobsEndpoint = "export"
+
+	// ExportJobEndpoint returns the state of the export job
+	ExportJobEndpoint = "export/{job_id}"
+
+	// ExportJobDownloadEndpoint returns the file exported by the export job
+	ExportJobDownloadEndpoint = "export/{job_id}/download"
+
+	// DigestEndpoint returns the payload of email digest about
+	// recommendations newly hitting clusters of the organization
+	DigestEndpoint = "internal/digest"
 )
 
 // addV2EndpointsToRouter adds API V2 specific endpoints to the router
 func (server *HTTPServer) addV2EndpointsToRouter(router *mux.Router) {
 	apiV2Prefix := server.Config.APIv2Prefix
 	openAPIv2URL := apiV2Prefix + filepath.Base(server.Config.APIv2SpecFile)
-	aggregatorBaseEndpoint := server.ServicesConfig.AggregatorBaseEndpoint
+	aggregatorBaseEndpoint := server.currentServicesConfig().AggregatorBaseEndpoint
 
 	// Common REST API endpoints
 	router.HandleFunc(apiV2Prefix+MainEndpoint, server.mainEndpoint).Methods(http.MethodGet)
@@ -104,26 +212,105 @@ func (server *HTTPServer) addV2EndpointsToRouter(router *mux.Router) {
 	// Rules related endpoints
 	server.addV2RuleEndpointsToRouter(router, apiV2Prefix, aggregatorBaseEndpoint)
 
+	// RHEL Insights Advisor compatible endpoints
+	server.addAdvisorAliasesToRouter(router, apiV2Prefix)
+
 	// Prometheus metrics
-	router.Handle(apiV2Prefix+MetricsEndpoint, promhttp.Handler()).Methods(http.MethodGet)
+	router.Handle(apiV2Prefix+MetricsEndpoint, metricsHandler()).Methods(http.MethodGet)
 
 	router.HandleFunc(apiV2Prefix+InfoEndpoint, server.infoMap).Methods(http.MethodGet, http.MethodOptions)
-	router.HandleFunc(apiV2Prefix+UpgradeRisksPredictionEndpoint, server.upgradeRisksPrediction).Methods(http.MethodGet)
+	router.HandleFunc(apiV2Prefix+StatusEndpoint, server.statusEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(apiV2Prefix+ContentRefreshEndpoint, server.refreshContent).Methods(http.MethodPost)
+	router.HandleFunc(apiV2Prefix+MaintenanceEndpoint, server.getMaintenance).Methods(http.MethodGet)
+	router.HandleFunc(apiV2Prefix+MaintenanceEndpoint, server.setMaintenance).Methods(http.MethodPut)
+	if server.auditLog != nil {
+		router.HandleFunc(apiV2Prefix+AuditLogEndpoint, server.getAuditLog).Methods(http.MethodGet)
+	}
+	router.HandleFunc(apiV2Prefix+DigestEndpoint, server.getDigest).Methods(http.MethodGet)
+
+	if server.exportJobs != nil {
+		router.HandleFunc(apiV2Prefix+ExportJobsEndpoint, server.submitExport).Methods(http.MethodPost)
+		router.HandleFunc(apiV2Prefix+ExportJobEndpoint, server.getExport).Methods(http.MethodGet)
+		router.HandleFunc(apiV2Prefix+ExportJobDownloadEndpoint, server.downloadExport).Methods(http.MethodGet)
+	}
+
+	if server.currentServicesConfig().RemediationsEndpoint != "" {
+		router.HandleFunc(apiV2Prefix+PlaybookEndpoint, server.generatePlaybook).Methods(http.MethodPost)
+	}
+
+	if server.Config.ServiceLog.Enabled {
+		router.HandleFunc(apiV2Prefix+ServiceLogEndpoint, server.auditLogged(auditOperationServiceLog, server.sendServiceLog)).Methods(http.MethodPost)
+	}
+
+	if server.preferences != nil {
+		router.HandleFunc(apiV2Prefix+PreferencesEndpoint, server.getPreferences).Methods(http.MethodGet)
+		router.HandleFunc(apiV2Prefix+PreferencesEndpoint, server.auditLogged(auditOperationPreferences, server.putPreferences)).Methods(http.MethodPut)
+		router.HandleFunc(apiV2Prefix+PreferencesEndpoint, server.auditLogged(auditOperationPreferences, server.deletePreferences)).Methods(http.MethodDelete)
+	}
+
+	if upgradeRisksRouter := server.featureRouter(router, featureflags.UpgradeRisksPrediction); upgradeRisksRouter != nil {
+		upgradeRisksRouter.HandleFunc(apiV2Prefix+UpgradeRisksPredictionEndpoint, server.upgradeRisksPrediction).Methods(http.MethodGet)
+	}
+
+	if reportEventsRouter := server.featureRouter(router, featureflags.ReportEvents); reportEventsRouter != nil {
+		reportEventsRouter.HandleFunc(apiV2Prefix+ReportEventsEndpoint, server.reportEvents).Methods(http.MethodGet)
+	}
+
+	if graphqlRouter := server.featureRouter(router, featureflags.GraphQL); graphqlRouter != nil {
+		graphqlRouter.HandleFunc(apiV2Prefix+GraphQLEndpoint, server.graphqlHandler()).Methods(http.MethodPost)
+	}
+
+	if webhooksRouter := server.featureRouter(router, featureflags.Webhooks); webhooksRouter != nil {
+		// webhooks are kept in aggregator unless the shared storage is set
+		if server.storage != nil {
+			webhooksRouter.HandleFunc(apiV2Prefix+WebhooksEndpoint, server.listSharedWebhooks).Methods(http.MethodGet)
+			webhooksRouter.HandleFunc(apiV2Prefix+WebhookEndpoint, server.orgAdminOnly(server.deleteSharedWebhook)).Methods(http.MethodDelete)
+		} else {
+			webhooksRouter.HandleFunc(apiV2Prefix+WebhooksEndpoint, server.proxyTo(
+				aggregatorBaseEndpoint,
+				&ProxyOptions{Rewrite: identityRewrite(aggregatorWebhooksEndpoint)},
+			)).Methods(http.MethodGet)
+			webhooksRouter.HandleFunc(apiV2Prefix+WebhookEndpoint, server.orgAdminOnly(server.proxyTo(
+				aggregatorBaseEndpoint,
+				&ProxyOptions{Rewrite: identityRewrite(aggregatorWebhookEndpoint)},
+			))).Methods(http.MethodDelete)
+		}
+		webhooksRouter.HandleFunc(apiV2Prefix+WebhooksEndpoint, server.orgAdminOnly(server.registerWebhook)).Methods(http.MethodPost)
+	}
 
 	// OpenAPI specs
-	router.HandleFunc(
+	router.Handle(
 		openAPIv2URL,
-		httputils.CreateOpenAPIHandler(server.Config.APIv2SpecFile, server.Config.Debug, true),
+		newOpenAPISpecHandler(router, apiV2Prefix, server.Config.APIv2SpecFile, server.Config.Debug),
 	).Methods(http.MethodGet)
 }
 
 // addV2ReportsEndpointsToRouter method registers handlers for endpoints that
 // return cluster report or reports to client
 func (server *HTTPServer) addV2ReportsEndpointsToRouter(router *mux.Router, apiPrefix, aggregatorBaseURL string) {
-	router.HandleFunc(apiPrefix+ReportEndpointV2, server.reportEndpointV2).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiPrefix+ReportEndpointV2, server.audited(audit.ReportViewed, server.reportEndpointV2)).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiPrefix+OCMReportEndpoint, server.audited(audit.ReportViewed, server.ocmReportEndpoint)).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ClusterReferenceReportEndpoint, server.clusterReferenceReportEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ReportHistoryEndpoint, server.getReportHistory).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+ClusterInfoEndpoint, server.getSingleClusterInfo).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+RecommendationsListEndpoint, server.getRecommendations).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+ClustersRecommendationsEndpoint, server.getClustersView).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+OverviewEndpoint, server.orgOverviewV2).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ClustersComparisonEndpoint, server.compareClustersEndpoint).Methods(http.MethodGet)
+
+	// the status of archives is written by the pipeline into Redis, which
+	// needs to be used as the shared storage
+	if _, ok := server.requestStore(); ok {
+		router.HandleFunc(apiPrefix+ClusterRequestsEndpoint, server.getClusterRequests).Methods(http.MethodGet)
+		router.HandleFunc(apiPrefix+ClusterRequestReportEndpoint, server.getClusterRequestReport).Methods(http.MethodGet)
+	}
+
+	// DVO workload recommendations
+	if dvoRouter := server.featureRouter(router, featureflags.DVOWorkloads); dvoRouter != nil {
+		dvoRouter.HandleFunc(apiPrefix+DVONamespacesForClusterEndpoint, server.getDVONamespacesForCluster).Methods(http.MethodGet)
+		dvoRouter.HandleFunc(apiPrefix+DVOWorkloadsForNamespaceEndpoint, server.getDVOWorkloadsForNamespace).Methods(http.MethodGet)
+		dvoRouter.HandleFunc(apiPrefix+DVOWorkloadDetailEndpoint, server.getDVOWorkloadDetail).Methods(http.MethodGet)
+	}
 }
 
 // addV2RuleEndpointsToRouter method registers handlers for endpoints that handle
@@ -132,12 +319,19 @@ func (server *HTTPServer) addV2RuleEndpointsToRouter(router *mux.Router, apiPref
 	// Acknowledgement-related endpoints. Please look into acks_handlers.go
 	// and acks_utils.go for more information about these endpoints
 	// prepared to be compatible with RHEL Insights Advisor.
-	router.HandleFunc(apiPrefix+AckListEndpoint, server.readAckList).Methods(http.MethodGet)
-	router.HandleFunc(apiPrefix+AckGetEndpoint, server.getAcknowledge).Methods(http.MethodGet)
-	router.HandleFunc(apiPrefix+AckAcknowledgePostEndpoint, server.acknowledgePost).Methods(http.MethodPost)
-	router.HandleFunc(apiPrefix+AckUpdateEndpoint, server.updateAcknowledge).Methods(http.MethodPut)
-	router.HandleFunc(apiPrefix+AckDeleteEndpoint, server.deleteAcknowledge).Methods(http.MethodDelete)
-	router.HandleFunc(apiPrefix+Rating, server.postRating).Methods(http.MethodPost)
+	if acksRouter := server.featureRouter(router, featureflags.Acknowledgements); acksRouter != nil {
+		acksRouter.HandleFunc(apiPrefix+AckListEndpoint, server.readAckList).Methods(http.MethodGet)
+		acksRouter.HandleFunc(apiPrefix+AckGetEndpoint, server.getAcknowledge).Methods(http.MethodGet)
+		acksRouter.HandleFunc(apiPrefix+AckAcknowledgePostEndpoint, server.auditLogged(auditOperationAck, server.acknowledgePost)).Methods(http.MethodPost)
+		acksRouter.HandleFunc(apiPrefix+AckUpdateEndpoint, server.auditLogged(auditOperationAckUpdate, server.updateAcknowledge)).Methods(http.MethodPut)
+		acksRouter.HandleFunc(apiPrefix+AckDeleteEndpoint, server.auditLogged(auditOperationAckDelete, server.audited(audit.RuleEnabled, server.deleteAcknowledge))).Methods(http.MethodDelete)
+	}
+	router.HandleFunc(apiPrefix+Rating, server.auditLogged(auditOperationVote, server.postRating)).Methods(http.MethodPost)
+	router.HandleFunc(apiPrefix+RuleRatingsEndpoint, server.getRuleRatings).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleRatingsEndpoint, server.auditLogged(auditOperationVote, server.putRuleRating)).Methods(http.MethodPut)
+	router.HandleFunc(apiPrefix+RuleVoteEndpointV2, server.getRuleVoteV2).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleVoteEndpointV2, server.auditLogged(auditOperationVote, server.putRuleVoteV2)).Methods(http.MethodPut)
+	router.HandleFunc(apiPrefix+RuleFeedbackEndpointV2, server.auditLogged(auditOperationFeedback, server.postRuleFeedbackV2)).Methods(http.MethodPost)
 	// Clusters for given recommendation endpoint
 	router.HandleFunc(apiPrefix+ClustersDetail, server.getClustersDetailForRule).Methods(http.MethodGet)
 }
@@ -146,6 +340,10 @@ func (server *HTTPServer) addV2RuleEndpointsToRouter(router *mux.Router, apiPref
 // returns content to clients
 func (server HTTPServer) addV2ContentEndpointsToRouter(router *mux.Router, apiPrefix string) {
 	router.HandleFunc(apiPrefix+RuleContentV2, server.getRecommendationContent).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleContentByErrorKeyV2, server.getRecommendationContentByErrorKey).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+RuleContentWithUserData, server.getRecommendationContentWithUserData).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+ContentV2, server.getContentWithGroups).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleSearchEndpoint, server.searchRules).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleCatalogEndpoint, server.getRuleCatalog).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+RuleContentBatchEndpoint, server.getRuleContentBatch).Methods(http.MethodPost)
 }
@@ -0,0 +1,52 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// ClustersEndpointV3 returns paginated list of clusters with number of
+	// rule hits
+	ClustersEndpointV3 = "clusters"
+
+	// RecommendationsEndpointV3 returns paginated list of recommendations
+	// with number of impacted clusters
+	RecommendationsEndpointV3 = "recommendations"
+)
+
+// addV3EndpointsToRouter adds API V3 specific endpoints to the router. All
+// list endpoints of API V3 share the same pagination, sorting and filtering
+// parameters and return the same envelope.
+func (server *HTTPServer) addV3EndpointsToRouter(router *mux.Router) {
+	apiV3Prefix := server.Config.APIv3Prefix
+
+	router.HandleFunc(apiV3Prefix+MainEndpoint, server.mainEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(apiV3Prefix+InfoEndpoint, server.infoMap).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc(apiV3Prefix+StatusEndpoint, server.statusEndpoint).Methods(http.MethodGet)
+
+	router.HandleFunc(apiV3Prefix+ClustersEndpointV3, server.getClustersV3).Methods(http.MethodGet)
+	router.HandleFunc(apiV3Prefix+RecommendationsEndpointV3, server.getRecommendationsV3).Methods(http.MethodGet)
+
+	// OpenAPI specs
+	router.Handle(
+		apiV3Prefix+filepath.Base(server.Config.APIv3SpecFile),
+		newOpenAPISpecHandler(router, apiV3Prefix, server.Config.APIv3SpecFile, server.Config.Debug),
+	).Methods(http.MethodGet)
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EntitlementsConfiguration represents configuration of the entitlement
+// check. When it is enabled, organizations need to be entitled to at least
+// one of the required services to use Advisor.
+type EntitlementsConfiguration struct {
+	Enabled  bool     `mapstructure:"enabled" toml:"enabled"`
+	Required []string `mapstructure:"required" toml:"required"`
+}
+
+// EntitlementRequiredError error is used when the organization of the
+// requester is not entitled to any of the required services
+type EntitlementRequiredError struct {
+	required []string
+}
+
+func (e *EntitlementRequiredError) Error() string {
+	return fmt.Sprintf("Organization is not entitled to any of: %v", strings.Join(e.required, ", "))
+}
+
+// entitlement is a single service entitlement of the organization as sent in
+// x-rh-identity header. Organizations with expired trial are not entitled.
+type entitlement struct {
+	IsEntitled bool `json:"is_entitled"`
+	IsTrial    bool `json:"is_trial"`
+}
+
+// identityEntitlements returns the entitlements read from x-rh-identity
+// header. False is returned when the header is not sent.
+func identityEntitlements(request *http.Request) (map[string]entitlement, bool) {
+	header := request.Header.Get("x-rh-identity")
+	if header == "" {
+		return nil, false
+	}
+
+	var token struct {
+		Entitlements map[string]entitlement `json:"entitlements"`
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil, true
+	}
+	if err := json.Unmarshal(decoded, &token); err != nil {
+		return nil, true
+	}
+	return token.Entitlements, true
+}
+
+// isEntitled returns true if any of the required services is entitled
+func isEntitled(entitlements map[string]entitlement, required []string) bool {
+	for _, service := range required {
+		if entitlements[service].IsEntitled {
+			return true
+		}
+	}
+	return false
+}
+
+// entitlementsMiddleware refuses requests of organizations that are not
+// entitled to any of the required services. It needs to be used after
+// authentication, requests without identity are passed through. Requests
+// without x-rh-identity header (authenticated by JWT or Bearer token) don't
+// contain entitlements, so they are passed through too, as well as
+// impersonated requests made by support engineers.
+func (server *HTTPServer) entitlementsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		orgID, err := server.GetCurrentOrgID(request)
+		if err != nil || request.Context().Value(impersonatorKey{}) != nil {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		entitlements, found := identityEntitlements(request)
+		if !found {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		required := server.Config.Entitlements.Required
+		if !isEntitled(entitlements, required) {
+			log.Warn().Int(orgIDTag, int(orgID)).Msg("Request of organization without required entitlement refused")
+			handleServerError(writer, &EntitlementRequiredError{required: required})
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
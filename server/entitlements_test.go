@@ -0,0 +1,73 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// entitledIdentity returns x-rh-identity header with given entitlements
+// section
+func entitledIdentity(entitlements string) string {
+	return base64.StdEncoding.EncodeToString([]byte(
+		`{"identity":{"account_number":"1","org_id":"1","user":{"user_id":"1"}}` + entitlements + `}`,
+	))
+}
+
+// TestEntitlements checks that requests of organizations without required
+// entitlement are refused
+func TestEntitlements(t *testing.T) {
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	config.Entitlements = server.EntitlementsConfiguration{
+		Enabled:  true,
+		Required: []string{"insights", "openshift"},
+	}
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	for _, testCase := range []struct {
+		name           string
+		endpoint       string
+		entitlements   string
+		expectedStatus int
+	}{
+		{"entitled", server.MainEndpoint, `,"entitlements":{"openshift":{"is_entitled":true}}`, http.StatusOK},
+		{"trial", server.MainEndpoint, `,"entitlements":{"insights":{"is_entitled":true,"is_trial":true}}`, http.StatusOK},
+		{"expired trial", server.MainEndpoint, `,"entitlements":{"insights":{"is_entitled":false,"is_trial":true}}`, http.StatusForbidden},
+		{"other service", server.MainEndpoint, `,"entitlements":{"ansible":{"is_entitled":true}}`, http.StatusForbidden},
+		{"no entitlements", server.MainEndpoint, ``, http.StatusForbidden},
+		// endpoints without authentication are not affected
+		{"no authentication", server.InfoEndpoint, ``, http.StatusOK},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, config.APIv2Prefix+testCase.endpoint, http.NoBody)
+			request.Header.Set("x-rh-identity", entitledIdentity(testCase.entitlements))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+			if testCase.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, recorder.Body.String(), `"code":"entitlement_required"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+const (
+	// upstream names used to tag reported errors
+	upstreamAggregator      = "aggregator"
+	upstreamContentService  = "content-service"
+	upstreamAMSAPI          = "ams-api"
+	upstreamUpgradesDataEng = "upgrades-data-eng"
+	upstreamRemediations    = "remediations"
+
+	// errorReportingFlushTimeout is the maximum time spent sending the
+	// buffered events when the service stops
+	errorReportingFlushTimeout = 2 * time.Second
+)
+
+// ErrorReportingConfiguration represents configuration of the error
+// reporting subsystem. When enabled, all errors resulting in HTTP 5xx
+// responses are sent to Sentry (or compatible service like GlitchTip).
+type ErrorReportingConfiguration struct {
+	Enabled     bool   `mapstructure:"enabled" toml:"enabled"`
+	DSN         string `mapstructure:"dsn" toml:"dsn"`
+	Environment string `mapstructure:"environment" toml:"environment"`
+}
+
+// errorReportingEnabled is set when the Sentry client has been initialized
+var errorReportingEnabled = false
+
+// InitErrorReporting function initializes the Sentry client used to report
+// server errors. Nothing is done if error reporting is disabled in
+// configuration.
+func InitErrorReporting(config ErrorReportingConfiguration, release string) error {
+	if !config.Enabled {
+		log.Info().Msg("Error reporting is disabled")
+		return nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         config.DSN,
+		Environment: config.Environment,
+		Release:     release,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to initialize error reporting")
+		return err
+	}
+
+	errorReportingEnabled = true
+	log.Info().Str("environment", config.Environment).Msg("Error reporting is enabled")
+	return nil
+}
+
+// FlushErrorReporting function waits until all buffered events are sent to
+// the error reporting service
+func FlushErrorReporting() {
+	if errorReportingEnabled {
+		sentry.Flush(errorReportingFlushTimeout)
+	}
+}
+
+// errorReportingWriter wraps the response writer to make the request
+// accessible from handleServerError
+type errorReportingWriter struct {
+	http.ResponseWriter
+	request *http.Request
+}
+
+// Flush sends buffered data to the client, it is needed by streamed
+// responses
+func (writer *errorReportingWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// errorReportingMiddleware makes the handled request available for error
+// reporting. It needs to be registered after the authentication middleware
+// so the organization ID is available in request context.
+func errorReportingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !errorReportingEnabled {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		next.ServeHTTP(&errorReportingWriter{ResponseWriter: writer, request: request}, request)
+	})
+}
+
+// upstreamForError returns name of the upstream service that caused given
+// error or an empty string when the error is not related to any upstream
+func upstreamForError(err error) string {
+	switch err.(type) {
+	case *AggregatorServiceUnavailableError:
+		return upstreamAggregator
+	case *ContentServiceUnavailableError, *content.RuleContentDirectoryTimeoutError:
+		return upstreamContentService
+	case *AMSAPIUnavailableError:
+		return upstreamAMSAPI
+	case *UpgradesDataEngServiceUnavailableError:
+		return upstreamUpgradesDataEng
+	case *RemediationsServiceUnavailableError:
+		return upstreamRemediations
+	default:
+		return ""
+	}
+}
+
+// reportServerError sends the error that resulted in 5xx response to the
+// error reporting service along with the request context
+func reportServerError(writer http.ResponseWriter, statusCode int, err error) {
+	if !errorReportingEnabled {
+		return
+	}
+
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("status_code", strconv.Itoa(statusCode))
+
+		if upstream := upstreamForError(err); upstream != "" {
+			scope.SetTag("upstream", upstream)
+		}
+
+		if w, ok := writer.(*errorReportingWriter); ok {
+			scope.SetTag("method", w.request.Method)
+			scope.SetExtra("url", w.request.URL.String())
+
+			if identity, found := w.request.Context().Value(types.ContextKeyUser).(types.Identity); found {
+				scope.SetTag(orgIDTag, fmt.Sprint(identity.OrgID))
+			}
+		}
+
+		sentry.CaptureException(err)
+	})
+}
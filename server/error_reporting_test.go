@@ -0,0 +1,43 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// TestUpstreamForError checks that errors are tagged with the upstream
+// service that caused them
+func TestUpstreamForError(t *testing.T) {
+	assert.Equal(t, "aggregator", server.UpstreamForError(&server.AggregatorServiceUnavailableError{}))
+	assert.Equal(t, "content-service", server.UpstreamForError(&server.ContentServiceUnavailableError{}))
+	assert.Equal(t, "content-service", server.UpstreamForError(&content.RuleContentDirectoryTimeoutError{}))
+	assert.Equal(t, "ams-api", server.UpstreamForError(&server.AMSAPIUnavailableError{}))
+	assert.Equal(t, "upgrades-data-eng", server.UpstreamForError(&server.UpgradesDataEngServiceUnavailableError{}))
+	assert.Equal(t, "", server.UpstreamForError(errors.New("unknown error")))
+}
+
+// TestInitErrorReportingDisabled checks that nothing is initialized when
+// error reporting is disabled
+func TestInitErrorReportingDisabled(t *testing.T) {
+	err := server.InitErrorReporting(server.ErrorReportingConfiguration{Enabled: false}, "")
+	assert.NoError(t, err)
+}
@@ -80,6 +80,27 @@ func (*BadBodyContent) Error() string {
 	return "client didn't provide a valid request body"
 }
 
+// RequestBodyTooLargeError error is used when the request body exceeds the
+// configured limit
+type RequestBodyTooLargeError struct {
+	limit int64
+}
+
+func (e *RequestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds the limit of %d bytes", e.limit)
+}
+
+// AmbiguousClusterReferenceError error is used when more clusters of the
+// organization match the display name used to look up the cluster
+type AmbiguousClusterReferenceError struct {
+	reference string
+	clusters  []string
+}
+
+func (e *AmbiguousClusterReferenceError) Error() string {
+	return fmt.Sprintf("cluster reference '%s' matches clusters %v", e.reference, e.clusters)
+}
+
 // ContentServiceUnavailableError error is used when the content service cannot be reached
 type ContentServiceUnavailableError struct{}
 
@@ -101,6 +122,14 @@ func (*UpgradesDataEngServiceUnavailableError) Error() string {
 	return "Upgrade Failure Prediction service is unreachable"
 }
 
+// RemediationsServiceUnavailableError error is used when the remediations
+// service cannot be reached
+type RemediationsServiceUnavailableError struct{}
+
+func (*RemediationsServiceUnavailableError) Error() string {
+	return "Remediations service is unreachable"
+}
+
 // AMSAPIUnavailableError error is used when AMS API is not available and is the only source of data
 type AMSAPIUnavailableError struct{}
 
@@ -108,6 +137,14 @@ func (*AMSAPIUnavailableError) Error() string {
 	return "AMS API is unreachable"
 }
 
+// SSOUnavailableError error is used when Bearer token can't be validated,
+// because SSO token introspection cannot be reached
+type SSOUnavailableError struct{}
+
+func (*SSOUnavailableError) Error() string {
+	return "SSO token introspection is unreachable"
+}
+
 // ParamsParsingError error meaning that the cluster name cannot be handled
 type ParamsParsingError struct{}
 
@@ -115,29 +152,85 @@ func (*ParamsParsingError) Error() string {
 	return "the parameters contains invalid characters and cannot be used"
 }
 
-// handleServerError handles separate server errors and sends appropriate responses
-func handleServerError(writer http.ResponseWriter, err error) {
-	log.Error().Err(err).Msg("handleServerError()")
+// FeatureDisabledError error is used when the requested endpoint belongs to
+// a feature that has been disabled
+type FeatureDisabledError struct {
+	feature string
+}
 
-	var respErr error
+func (e *FeatureDisabledError) Error() string {
+	return fmt.Sprintf("Feature '%v' is disabled", e.feature)
+}
 
+// errorCodeForError returns the code from error catalog and the detail
+// message describing given error
+func errorCodeForError(err error) (code, detail string) {
 	switch err := err.(type) {
-	case *RouterMissingParamError, *RouterParsingError, *json.SyntaxError, *NoBodyError, *ParamsParsingError, *BadBodyContent:
-		respErr = responses.SendBadRequest(writer, err.Error())
+	case *RouterParsingError, *ParamsParsingError:
+		return ErrorCodeInvalidParameter, err.Error()
+	case *RouterMissingParamError:
+		return ErrorCodeMissingParameter, err.Error()
+	case *json.SyntaxError, *NoBodyError, *BadBodyContent:
+		return ErrorCodeInvalidBody, err.Error()
 	case *json.UnmarshalTypeError:
-		respErr = responses.SendBadRequest(writer, "bad type in json data")
+		return ErrorCodeInvalidBody, "bad type in json data"
+	case *RequestBodyTooLargeError:
+		return ErrorCodePayloadTooLarge, err.Error()
 	case *types.ItemNotFoundError:
-		respErr = responses.SendNotFound(writer, err.Error())
-	case *types.NoContentError:
-		respErr = responses.SendNoContent(writer)
+		return ErrorCodeNotFound, err.Error()
+	case *FeatureDisabledError:
+		return ErrorCodeFeatureDisabled, err.Error()
 	case *AuthenticationError:
-		respErr = responses.SendForbidden(writer, err.Error())
-	case *ContentServiceUnavailableError, *AggregatorServiceUnavailableError,
-		*AMSAPIUnavailableError, *content.RuleContentDirectoryTimeoutError,
-		*UpgradesDataEngServiceUnavailableError:
-		respErr = responses.SendServiceUnavailable(writer, err.Error())
+		return ErrorCodeAuthenticationFailed, err.Error()
+	case *OrgAccessDeniedError:
+		return ErrorCodeOrganizationDenied, err.Error()
+	case *EntitlementRequiredError:
+		return ErrorCodeEntitlementRequired, err.Error()
+	case *OrgAdminRequiredError:
+		return ErrorCodeOrgAdminRequired, err.Error()
+	case *ImpersonationDeniedError:
+		return ErrorCodeImpersonationDenied, err.Error()
+	case *InternalRoleRequiredError:
+		return ErrorCodeInternalRoleRequired, err.Error()
+	case *AggregatorServiceUnavailableError:
+		return ErrorCodeAggregatorUnavailable, err.Error()
+	case *ContentServiceUnavailableError, *content.RuleContentDirectoryTimeoutError:
+		return ErrorCodeContentServiceUnavailable, err.Error()
+	case *AMSAPIUnavailableError:
+		return ErrorCodeAMSAPIUnavailable, err.Error()
+	case *UpgradesDataEngServiceUnavailableError:
+		return ErrorCodeUpgradesDataEngUnavailable, err.Error()
+	case *RemediationsServiceUnavailableError:
+		return ErrorCodeRemediationsUnavailable, err.Error()
+	case *SSOUnavailableError:
+		return ErrorCodeSSOUnavailable, err.Error()
+	case *ExportQueueFullError:
+		return ErrorCodeExportQueueFull, err.Error()
+	case *ExportNotReadyError:
+		return ErrorCodeExportNotReady, err.Error()
+	case *AmbiguousClusterReferenceError:
+		return ErrorCodeAmbiguousClusterReference, err.Error()
 	default:
-		respErr = responses.SendInternalServerError(writer, "Internal Server Error")
+		// details of unexpected errors are not exposed to clients
+		return ErrorCodeInternalServerError, ""
+	}
+}
+
+// handleServerError handles separate server errors and sends appropriate
+// responses in RFC 7807 (problem+json) format
+func handleServerError(writer http.ResponseWriter, err error) {
+	log.Error().Err(err).Msg("handleServerError()")
+
+	var respErr error
+
+	if _, ok := err.(*types.NoContentError); ok {
+		respErr = responses.SendNoContent(writer)
+	} else {
+		problem := newProblem(errorCodeForError(err))
+		if problem.Status >= http.StatusInternalServerError {
+			reportServerError(writer, problem.Status, err)
+		}
+		respErr = sendProblem(writer, problem)
 	}
 
 	if respErr != nil {
@@ -0,0 +1,544 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/objectstorage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// exportJobIDParam is the ID of the export job in the path
+	exportJobIDParam = "job_id"
+
+	// defaultExportQueueSize is used when the queue size is not configured
+	defaultExportQueueSize = 100
+	// defaultExportResultTTL is used when the result TTL is not configured
+	defaultExportResultTTL = time.Hour
+	// exportJobsCacheCapacity is the maximal number of jobs kept in memory
+	exportJobsCacheCapacity = 1000
+	// exportJobsKeyPrefix is prepended to the IDs of jobs in the shared
+	// storage
+	exportJobsKeyPrefix = "exports/"
+
+	exportJobPending = "pending"
+	exportJobRunning = "running"
+	exportJobDone    = "done"
+	exportJobFailed  = "failed"
+)
+
+// ExportsConfiguration represents configuration of the asynchronous exports
+// of reports of all clusters of the organization
+type ExportsConfiguration struct {
+	// Workers is the number of exports running in parallel, zero
+	// disables the asynchronous exports
+	Workers int `mapstructure:"workers" toml:"workers"`
+	// QueueSize is the maximal number of exports waiting for a worker
+	QueueSize int `mapstructure:"queue_size" toml:"queue_size"`
+	// ResultTTL is the time for which the job state and the exported file
+	// are kept after the job is submitted
+	ResultTTL time.Duration `mapstructure:"result_ttl" toml:"result_ttl"`
+}
+
+// ExportQueueFullError error is used when the export job can't be submitted,
+// because too many jobs are waiting for a worker
+type ExportQueueFullError struct{}
+
+func (*ExportQueueFullError) Error() string {
+	return "Too many exports are waiting, try again later"
+}
+
+// ExportNotReadyError error is used when the file of export job that has not
+// finished successfully is requested
+type ExportNotReadyError struct {
+	status string
+}
+
+func (e *ExportNotReadyError) Error() string {
+	return fmt.Sprintf("Export is %s, the file is not available", e.status)
+}
+
+// exportJob is the state of single export. Jobs are stored in the cache as
+// values, so every change stores a new copy of the job.
+type exportJob struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Format      string     `json:"format"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+
+	orgID        ctypes.OrgID
+	userID       ctypes.UserID
+	statusFilter []string
+	// data is the exported file kept in memory, objectKey is its key in
+	// object storage when the storage is configured
+	data      []byte
+	objectKey string
+}
+
+// sharedExportJob is the state of export job stored in the shared storage,
+// so the job is visible to all replicas. The exported file itself is shared
+// only when object storage is configured.
+type sharedExportJob struct {
+	exportJob
+	OrgID     ctypes.OrgID  `json:"org_id"`
+	UserID    ctypes.UserID `json:"user_id"`
+	ObjectKey string        `json:"object_key,omitempty"`
+}
+
+// exportJobs holds the state of export jobs and the queue of jobs waiting
+// for a worker
+type exportJobs struct {
+	workers   int
+	resultTTL time.Duration
+	jobs      cache.Cache
+	queue     chan exportJob
+	// storage is nil when the exported files are kept in memory
+	storage *objectstorage.Client
+	// shared is nil when the job states are kept in memory of the replica
+	// running the job only
+	shared storage.Store
+}
+
+// newExportJobs constructs the holder of export jobs, nil is returned when
+// the asynchronous exports are disabled
+func newExportJobs(config ExportsConfiguration, storageConfig objectstorage.Configuration) *exportJobs {
+	if config.Workers <= 0 {
+		return nil
+	}
+
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultExportQueueSize
+	}
+	resultTTL := config.ResultTTL
+	if resultTTL <= 0 {
+		resultTTL = defaultExportResultTTL
+	}
+
+	return &exportJobs{
+		workers:   config.Workers,
+		resultTTL: resultTTL,
+		jobs:      cache.NewMemoryCache(exportJobsCacheCapacity, resultTTL),
+		queue:     make(chan exportJob, queueSize),
+		storage:   newExportStorage(storageConfig),
+	}
+}
+
+// get returns the job of the organization with given ID
+func (exports *exportJobs) get(orgID ctypes.OrgID, jobID string) (exportJob, bool) {
+	value, found := exports.jobs.Get(jobID)
+	if !found {
+		return exports.getShared(orgID, jobID)
+	}
+	job := value.(exportJob)
+	// jobs of other organizations are not visible
+	return job, job.orgID == orgID
+}
+
+// getShared returns the job stored in the shared storage, usually by other
+// replica
+func (exports *exportJobs) getShared(orgID ctypes.OrgID, jobID string) (exportJob, bool) {
+	if exports.shared == nil {
+		return exportJob{}, false
+	}
+
+	data, found, err := exports.shared.Get(exportJobsKeyPrefix + jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Unable to read export job from shared storage")
+		return exportJob{}, false
+	}
+	if !found {
+		return exportJob{}, false
+	}
+
+	var shared sharedExportJob
+	if err := json.Unmarshal(data, &shared); err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Unable to parse export job from shared storage")
+		return exportJob{}, false
+	}
+	job := shared.exportJob
+	job.orgID = shared.OrgID
+	job.userID = shared.UserID
+	job.objectKey = shared.ObjectKey
+	return job, job.orgID == orgID
+}
+
+// store stores the job, the job expires after the result TTL measured from
+// its creation
+func (exports *exportJobs) store(job exportJob) {
+	ttl := exports.resultTTL - time.Since(job.CreatedAt)
+	if ttl <= 0 {
+		exports.jobs.Delete(job.ID)
+	} else {
+		exports.jobs.Set(job.ID, job, ttl)
+	}
+	exports.storeShared(job, ttl)
+}
+
+// storeShared stores the job into the shared storage when it is configured,
+// the job is deleted when the TTL is not positive. Failures are only logged,
+// the job is still available on this replica.
+func (exports *exportJobs) storeShared(job exportJob, ttl time.Duration) {
+	if exports.shared == nil {
+		return
+	}
+
+	key := exportJobsKeyPrefix + job.ID
+	var err error
+	if ttl <= 0 {
+		err = exports.shared.Delete(key)
+	} else {
+		var data []byte
+		data, err = json.Marshal(sharedExportJob{
+			exportJob: job,
+			OrgID:     job.orgID,
+			UserID:    job.userID,
+			ObjectKey: job.objectKey,
+		})
+		if err == nil {
+			err = exports.shared.Set(key, data, ttl)
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Unable to store export job into shared storage")
+	}
+}
+
+// submit stores the new job and puts it into the queue. The job is stored
+// first, so the pending state can't overwrite the state stored by the worker.
+func (exports *exportJobs) submit(job exportJob) error {
+	exports.store(job)
+	select {
+	case exports.queue <- job:
+		return nil
+	default:
+		exports.jobs.Delete(job.ID)
+		exports.storeShared(job, 0)
+		return &ExportQueueFullError{}
+	}
+}
+
+// orgReportExportTable converts recommendations hitting the clusters of the
+// organization into table. Disabled rules and rules not relevant for managed
+// clusters are not exported, the same as in organization overview.
+func orgReportExportTable(
+	clusterInfoList []types.ClusterInfo,
+	clusterRecommendationsMap ctypes.ClusterRecommendationMap,
+	systemWideDisabledRules map[ctypes.RuleID]bool,
+	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
+) (exportTable, error) {
+	table := exportTable{
+		header: []string{
+			"cluster_id", "cluster_name", "rule_id", "error_key",
+			"description", "total_risk", "created_at",
+		},
+	}
+
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+
+		hittingRecommendations, found := clusterRecommendationsMap[clusterInfo.ID]
+		if !found {
+			continue
+		}
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			hittingRecommendations.Recommendations, clusterInfo.ID,
+			systemWideDisabledRules, disabledRulesPerCluster,
+		)
+
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					return table, err
+				}
+				// missing rule content, the rule can't be displayed
+				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
+				continue
+			}
+
+			if clusterInfo.Managed && !ruleContent.OSDCustomer {
+				continue
+			}
+
+			table.rows = append(table.rows, []interface{}{
+				string(clusterInfo.ID), clusterInfo.DisplayName,
+				string(ruleContent.Module), string(ruleContent.ErrorKey),
+				ruleContent.Description, ruleContent.TotalRisk,
+				hittingRecommendations.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+	return table, nil
+}
+
+// fetchOrgReportExport reads reports of all clusters of the organization
+// and converts them into table. Errors are sent to the writer.
+func (server HTTPServer) fetchOrgReportExport(
+	writer http.ResponseWriter,
+	orgID ctypes.OrgID,
+	userID ctypes.UserID,
+	statusFilter []string,
+) (exportTable, bool) {
+	var (
+		waitGroup               sync.WaitGroup
+		ackedRulesMap           map[ctypes.RuleID]bool
+		disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID
+	)
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	defer waitGroup.Wait()
+
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return exportTable{}, false
+	}
+
+	clusterRecommendationMap, err := server.getClustersAndRecommendations(
+		writer, orgID, userID, types.GetClusterNames(clusterInfoList),
+	)
+	if err != nil {
+		// error has been handled already
+		return exportTable{}, false
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRecommendationMap)
+
+	waitGroup.Wait()
+
+	table, err := orgReportExportTable(
+		clusterInfoList, clusterRecommendationMap, ackedRulesMap, disabledRulesPerCluster,
+	)
+	if err != nil {
+		handleServerError(writer, err)
+		return exportTable{}, false
+	}
+	return table, true
+}
+
+// runExportJob runs single export job and stores its result
+func (server HTTPServer) runExportJob(job exportJob) {
+	exports := server.exportJobs
+	tStart := time.Now()
+
+	job.Status = exportJobRunning
+	exports.store(job)
+
+	writer := newRecordingWriter()
+	table, successful := server.fetchOrgReportExport(writer, job.orgID, job.userID, job.statusFilter)
+
+	var data bytes.Buffer
+	var err error
+	if successful {
+		if job.Format == xlsxFormat {
+			err = writeXLSX(&data, table)
+		} else {
+			err = writeCSV(&data, table)
+		}
+	}
+
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	switch {
+	case !successful:
+		job.Status = exportJobFailed
+		job.Error = writer.errorMessage()
+	case err != nil:
+		job.Status = exportJobFailed
+		job.Error = err.Error()
+	default:
+		if err = exports.storeFile(&job, data.Bytes()); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID).Msg("Unable to store exported file")
+			job.Status = exportJobFailed
+			job.Error = "Unable to store the exported file"
+			break
+		}
+		job.Status = exportJobDone
+		job.DownloadURL = server.Config.APIv2Prefix +
+			strings.Replace(ExportJobDownloadEndpoint, "{"+exportJobIDParam+"}", job.ID, 1)
+	}
+	exports.store(job)
+
+	log.Info().
+		Int(orgIDTag, int(job.orgID)).
+		Str("job_id", job.ID).
+		Str("status", job.Status).
+		Msgf("Export job took %s", time.Since(tStart))
+}
+
+// RunExportWorkers runs the workers processing export jobs. It returns
+// immediately when the asynchronous exports are disabled.
+func (server *HTTPServer) RunExportWorkers() {
+	if server.exportJobs == nil {
+		log.Info().Msg("Asynchronous exports are disabled")
+		return
+	}
+
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(server.exportJobs.workers)
+	for i := 0; i < server.exportJobs.workers; i++ {
+		go func() {
+			defer waitGroup.Done()
+			for job := range server.exportJobs.queue {
+				server.runExportJob(job)
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// submitExport submits the export of reports of all clusters of the
+// organization. The job state is returned with 202 Accepted status code.
+func (server HTTPServer) submitExport(writer http.ResponseWriter, request *http.Request) {
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	format, err := readExportFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	if format == "" {
+		format = csvFormat
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	job := exportJob{
+		ID:           uuid.New().String(),
+		Status:       exportJobPending,
+		Format:       format,
+		CreatedAt:    time.Now().UTC(),
+		orgID:        orgID,
+		userID:       userID,
+		statusFilter: statusFilter,
+	}
+	if err = server.exportJobs.submit(job); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	log.Info().Int(orgIDTag, int(orgID)).Str("job_id", job.ID).Msg("Export job submitted")
+
+	writer.Header().Set("Location", server.Config.APIv2Prefix+
+		strings.Replace(ExportJobEndpoint, "{"+exportJobIDParam+"}", job.ID, 1))
+	if err = responses.Send(http.StatusAccepted, writer, responses.BuildOkResponseWithData("export", job)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// readExportJob returns the export job of the requester's organization with
+// ID given in the path. Errors are sent to the writer.
+func (server HTTPServer) readExportJob(writer http.ResponseWriter, request *http.Request) (exportJob, bool) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return exportJob{}, false
+	}
+
+	jobID := mux.Vars(request)[exportJobIDParam]
+	job, found := server.exportJobs.get(orgID, jobID)
+	if !found {
+		handleServerError(writer, &utypes.ItemNotFoundError{ItemID: jobID})
+		return exportJob{}, false
+	}
+	return job, true
+}
+
+// getExport returns the state of the export job
+func (server HTTPServer) getExport(writer http.ResponseWriter, request *http.Request) {
+	job, found := server.readExportJob(writer, request)
+	if !found {
+		return
+	}
+	if job.objectKey != "" {
+		// presigned URLs expire, so new one is generated on every request
+		job.DownloadURL = server.exportJobs.storage.PresignGet(job.objectKey)
+	}
+
+	if err := responses.SendOK(writer, responses.BuildOkResponseWithData("export", job)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// downloadExport sends the file exported by the finished export job
+func (server HTTPServer) downloadExport(writer http.ResponseWriter, request *http.Request) {
+	job, found := server.readExportJob(writer, request)
+	if !found {
+		return
+	}
+	if job.Status != exportJobDone {
+		handleServerError(writer, &ExportNotReadyError{status: job.Status})
+		return
+	}
+	if job.objectKey != "" {
+		http.Redirect(writer, request, server.exportJobs.storage.PresignGet(job.objectKey), http.StatusFound)
+		return
+	}
+	if job.data == nil {
+		// the job was run by other replica keeping the file in its memory
+		handleServerError(writer, &ExportNotReadyError{status: "kept by other replica"})
+		return
+	}
+
+	contentType := csvContentType
+	if job.Format == xlsxFormat {
+		contentType = xlsxContentType
+	}
+	writer.Header().Set(contentTypeHeader, contentType)
+	writer.Header().Set(contentDispositionHeader,
+		attachmentDisposition(fmt.Sprintf("report-%d", job.orgID), job.Format))
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write(job.data); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
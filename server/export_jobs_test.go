@@ -0,0 +1,187 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+type exportJobResponse struct {
+	Export struct {
+		ID          string `json:"id"`
+		Status      string `json:"status"`
+		Error       string `json:"error"`
+		DownloadURL string `json:"download_url"`
+	} `json:"export"`
+}
+
+// waitForExport polls the export job until it finishes
+func waitForExport(t testing.TB, router http.Handler, jobID string) exportJobResponse {
+	endpoint := strings.Replace(server.ExportJobEndpoint, "{job_id}", jobID, 1)
+	var response exportJobResponse
+	for i := 0; i < 100; i++ {
+		recorder := serveV2Request(router, endpoint)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		if response.Export.Status == "done" || response.Export.Status == "failed" {
+			return response
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export job %s has not finished", jobID)
+	return response
+}
+
+// TestExportDisabled checks that export endpoints are not registered when
+// there are no workers
+func TestExportDisabled(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder := serveV2RequestWithMethod(router, http.MethodPost, server.ExportJobsEndpoint)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestExport checks that the export is processed in background and that the
+// exported file can be downloaded when it is done
+func TestExport(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := []types.ClusterInfo{data.GetRandomClusterInfo()}
+		reqBody, err := json.Marshal(types.GetClusterNames(clusterInfoList))
+		helpers.FailOnError(t, err)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       `{"clusters": {}, "status": "ok"}`,
+			},
+		)
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
+
+		config := serverConfigJWT
+		config.Exports.Workers = 1
+		testServer := helpers.CreateHTTPServer(
+			&config, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList), nil,
+		)
+		go testServer.RunExportWorkers()
+		router := testServer.Initialize()
+
+		recorder := serveV2RequestWithMethod(router, http.MethodPost, server.ExportJobsEndpoint)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		var submitted exportJobResponse
+		helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &submitted))
+		assert.Equal(t, config.APIv2Prefix+"export/"+submitted.Export.ID, recorder.Header().Get("Location"))
+
+		finished := waitForExport(t, router, submitted.Export.ID)
+		assert.Equal(t, "done", finished.Export.Status)
+		assert.Equal(t, config.APIv2Prefix+"export/"+submitted.Export.ID+"/download", finished.Export.DownloadURL)
+
+		recorder = serveV2Request(router, strings.TrimPrefix(finished.Export.DownloadURL, config.APIv2Prefix))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "cluster_id,cluster_name,rule_id,error_key,description,total_risk,created_at\n", recorder.Body.String())
+	}, testTimeout)
+}
+
+// TestExportFailed checks that the reason of the failure is reported and that
+// the file of failed export is not available
+func TestExportFailed(t *testing.T) {
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = "http://localhost:1/api/v1/"
+
+	config := serverConfigJWT
+	config.Exports.Workers = 1
+	testServer := helpers.CreateHTTPServer(
+		&config, &servicesConfig, helpers.AMSClientWithOrgResults(testdata.OrgID, nil), nil,
+	)
+	go testServer.RunExportWorkers()
+	router := testServer.Initialize()
+
+	recorder := serveV2RequestWithMethod(router, http.MethodPost, server.ExportJobsEndpoint+"?format=xlsx")
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var submitted exportJobResponse
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &submitted))
+
+	finished := waitForExport(t, router, submitted.Export.ID)
+	assert.Equal(t, "failed", finished.Export.Status)
+	assert.Contains(t, finished.Export.Error, "Aggregator service unavailable")
+
+	recorder = serveV2Request(router, strings.Replace(server.ExportJobDownloadEndpoint, "{job_id}", submitted.Export.ID, 1))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"export_not_ready"`)
+}
+
+// TestExportNotFound checks that unknown export jobs are not found
+func TestExportNotFound(t *testing.T) {
+	config := serverConfigJWT
+	config.Exports.Workers = 1
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	recorder := serveV2Request(router, strings.Replace(server.ExportJobEndpoint, "{job_id}", "unknown", 1))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestExportSharedStorage checks that export jobs are visible to other
+// replicas using the same shared storage
+func TestExportSharedStorage(t *testing.T) {
+	shared := storage.NewMemoryStore()
+	config := serverConfigJWT
+	config.Exports.Workers = 1
+
+	// workers are not running, so the job stays pending
+	submitting := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	submitting.SetStorage(shared)
+	recorder := serveV2RequestWithMethod(submitting.Initialize(), http.MethodPost, server.ExportJobsEndpoint)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var submitted exportJobResponse
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &submitted))
+
+	other := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	other.SetStorage(shared)
+	router := other.Initialize()
+
+	recorder = serveV2Request(router, strings.Replace(server.ExportJobEndpoint, "{job_id}", submitted.Export.ID, 1))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var job exportJobResponse
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &job))
+	assert.Equal(t, "pending", job.Export.Status)
+
+	// replicas without the shared storage don't see the job
+	router = helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+	recorder = serveV2Request(router, strings.Replace(server.ExportJobEndpoint, "{job_id}", submitted.Export.ID, 1))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/objectstorage"
+)
+
+// defaultExportCleanupInterval is used when the interval of deletions of
+// expired exported files is not configured
+const defaultExportCleanupInterval = time.Hour
+
+// newExportStorage constructs the client of object storage for exported
+// files. Nil is returned when the storage is not configured or when the
+// configuration is invalid, the files are kept in memory then.
+func newExportStorage(config objectstorage.Configuration) *objectstorage.Client {
+	if config.Bucket == "" {
+		return nil
+	}
+
+	storage, err := objectstorage.New(config)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid export storage configuration, exported files will be kept in memory")
+		return nil
+	}
+	return storage
+}
+
+// storeFile stores the exported file in object storage when it is
+// configured, otherwise the file is kept in the job
+func (exports *exportJobs) storeFile(job *exportJob, data []byte) error {
+	if exports.storage == nil {
+		job.data = data
+		return nil
+	}
+
+	contentType := csvContentType
+	if job.Format == xlsxFormat {
+		contentType = xlsxContentType
+	}
+	key := fmt.Sprintf("%s%d/%s.%s", exports.storage.Prefix(), job.orgID, job.ID, job.Format)
+	if err := exports.storage.Put(key, contentType, data); err != nil {
+		return err
+	}
+	job.objectKey = key
+	return nil
+}
+
+// RunExportStorageCleanupLoop periodically deletes exported files older than
+// the retention configured for export storage. It returns immediately when
+// the storage or the retention is not configured.
+func (server *HTTPServer) RunExportStorageCleanupLoop() {
+	config := server.ServicesConfig.ExportStorage
+	if server.exportJobs == nil || server.exportJobs.storage == nil || config.Retention <= 0 {
+		log.Info().Msg("Cleanup of export storage is disabled")
+		return
+	}
+
+	interval := config.CleanupInterval
+	if interval <= 0 {
+		interval = defaultExportCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := server.exportJobs.storage.DeleteExpired()
+		if err != nil {
+			log.Error().Err(err).Int("deleted", deleted).Msg("Unable to delete expired exported files")
+			continue
+		}
+		log.Info().Int("deleted", deleted).Msg("Expired exported files deleted")
+	}
+}
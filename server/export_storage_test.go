@@ -0,0 +1,93 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/objectstorage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestExportToObjectStorage checks that the exported file is stored in
+// object storage and that presigned URL of the file is returned
+func TestExportToObjectStorage(t *testing.T) {
+	// acks and disabled rules are not returned, which is only logged
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodPost {
+			_, _ = io.WriteString(writer, `{"clusters": {}, "status": "ok"}`)
+			return
+		}
+		writer.WriteHeader(http.StatusNotFound)
+	}))
+	defer aggregator.Close()
+
+	var storedKey, storedBody string
+	storage := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, http.MethodPut, request.Method)
+		assert.Equal(t, "text/csv; charset=utf-8", request.Header.Get("Content-Type"))
+		body, err := io.ReadAll(request.Body)
+		helpers.FailOnError(t, err)
+		storedKey, storedBody = request.URL.Path, string(body)
+	}))
+	defer storage.Close()
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL
+	servicesConfig.ExportStorage = objectstorage.Configuration{
+		Endpoint:        storage.URL,
+		Region:          "us-east-1",
+		Bucket:          "exports",
+		Prefix:          "smart-proxy/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}
+
+	config := serverConfigJWT
+	config.Exports.Workers = 1
+	testServer := helpers.CreateHTTPServer(
+		&config, &servicesConfig, helpers.AMSClientWithOrgResults(testdata.OrgID, nil), nil,
+	)
+	go testServer.RunExportWorkers()
+	router := testServer.Initialize()
+
+	recorder := serveV2RequestWithMethod(router, http.MethodPost, server.ExportJobsEndpoint)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var submitted exportJobResponse
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &submitted))
+
+	finished := waitForExport(t, router, submitted.Export.ID)
+	assert.Equal(t, "done", finished.Export.Status)
+
+	expectedKey := fmt.Sprintf("/exports/smart-proxy/%v/%s.csv", testdata.OrgID, submitted.Export.ID)
+	assert.Equal(t, expectedKey, storedKey)
+	assert.Equal(t, "cluster_id,cluster_name,rule_id,error_key,description,total_risk,created_at\n", storedBody)
+	assert.True(t, strings.HasPrefix(finished.Export.DownloadURL, storage.URL+expectedKey+"?X-Amz-Algorithm="))
+
+	recorder = serveV2Request(router, strings.Replace(server.ExportJobDownloadEndpoint, "{job_id}", submitted.Export.ID, 1))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("Location"), storage.URL+expectedKey+"?X-Amz-Algorithm="))
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package server
 
+import "time"
+
 // Export for testing
 //
 // This source file contains name aliases of all package-private functions
@@ -27,6 +29,85 @@ package server
 // to see why this trick is needed.
 
 var (
-	FillImpacted       = fillImpacted
-	GetAuthTokenHeader = (*HTTPServer).getAuthTokenHeader
+	FillImpacted           = fillImpacted
+	GetAuthTokenHeader     = (*HTTPServer).getAuthTokenHeader
+	RewriteURL             = HTTPServer.rewriteURL
+	CopyHeader             = copyHeader
+	HeaderPolicy           = HTTPServer.headerPolicy
+	AddForwardingHeaders   = addForwardingHeaders
+	CompareReportResponses = compareReportResponses
+	CompareShadowResponses = compareShadowResponses
+	UpstreamForError       = upstreamForError
+	RecoveryMiddleware     = recoveryMiddleware
+	SanitizeConfiguration  = sanitizeConfiguration
+
+	ReadClusterStatusFilter = HTTPServer.readClusterStatusFilter
+	ModifyJSONBody          = modifyJSONBody
+
+	FieldSelectionMiddleware = fieldSelectionMiddleware
+
+	SetPlaybookAvailability = HTTPServer.setPlaybookAvailability
+
+	ServiceLogEntry = HTTPServer.serviceLogEntry
+
+	BuildDigest = buildDigest
+
+	NewAdvisorSystem  = newAdvisorSystem
+	NewAdvisorReports = newAdvisorReports
+
+	MergeRuleRatings = mergeRuleRatings
+
+	ReadListQuery               = readListQuery
+	NewListEnvelope             = newListEnvelope
+	RecommendationsListContract = recommendationsListContract
+
+	GenerateOpenAPISpec  = generateOpenAPISpec
+	RegisteredOperations = registeredOperations
+
+	ReadExportFormatParam      = readExportFormatParam
+	ReportExportTable          = reportExportTable
+	RecommendationsExportTable = recommendationsExportTable
+	WriteCSV                   = writeCSV
+	WriteXLSX                  = writeXLSX
+	XLSXColumnName             = xlsxColumnName
+	EscapeFormula              = escapeFormula
+
+	CompareClusters             = compareClusters
+	FilterRulesInResponse       = filterRulesInResponse
+	FilterRulesByResolutionRisk = filterRulesByResolutionRisk
+	ReadResolutionRiskParam     = readResolutionRiskParam
+
+	NewWebhookNotifier     = newWebhookNotifier
+	WebhookNotifierNewHits = (*webhookNotifier).newHits
+
+	NewUpstreamHealth    = newUpstreamHealth
+	UpstreamHealthRecord = (*upstreamHealth).record
+	UpstreamHealthIsDown = (*upstreamHealth).isDown
+	ProbeUpstream        = probeUpstream
+
+	NewUpstreamMetricsTransport = newUpstreamMetricsTransport
+	TraceIDFromHeader           = traceIDFromHeader
+
+	NewDebugHandler = newDebugHandler
+
+	WarmUpOrganization = HTTPServer.warmUpOrganization
+
+	NewOCMReport = newOCMReport
+
+	NewClusterInfoCache = newClusterInfoCache
+	ClusterInfoCacheGet = (*clusterInfoCache).get
+	ClusterInfoCacheSet = (*clusterInfoCache).set
+
+	LowercaseUUIDSegments = lowercaseUUIDSegments
+
+	NewReportCache        = newReportCache
+	ReportCacheGet        = (*reportCache).get
+	ReportCacheSet        = (*reportCache).set
+	ReportCacheRefresh    = (*reportCache).refresh
+	ReportCacheRevalidate = (*reportCache).revalidate
 )
+
+// SetReportCacheNow replaces the clock used by the report cache
+func SetReportCacheNow(reports *reportCache, now func() time.Time) {
+	reports.now = now
+}
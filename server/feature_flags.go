@@ -0,0 +1,53 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+)
+
+// SetFeatureFlags replaces the provider of feature flags that decides which
+// endpoint groups are available. It needs to be called before Initialize.
+func (server *HTTPServer) SetFeatureFlags(provider featureflags.Provider) {
+	server.featureFlags = provider
+}
+
+// featureRouter returns router for registering endpoints of the feature.
+// Endpoints of features disabled at startup are not registered at all, so
+// nil is returned for them. Features enabled at startup are checked again
+// for every request, so they can be turned off at runtime.
+func (server *HTTPServer) featureRouter(router *mux.Router, feature string) *mux.Router {
+	if !server.featureFlags.IsEnabled(feature) {
+		log.Info().Str("feature", feature).Msg("Feature is disabled, its endpoints won't be registered")
+		return nil
+	}
+
+	featureRouter := router.NewRoute().Subrouter()
+	featureRouter.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if !server.featureFlags.IsEnabled(feature) {
+				handleServerError(writer, &FeatureDisabledError{feature: feature})
+				return
+			}
+			next.ServeHTTP(writer, request)
+		})
+	})
+	return featureRouter
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// serveV2Request sends authenticated GET request to the API V2 endpoint
+func serveV2Request(router http.Handler, endpoint string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, serverConfigJWT.APIv2Prefix+endpoint, nil)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestFeatureDisabledAtStartup checks that endpoints of features disabled at
+// startup are not registered
+func TestFeatureDisabledAtStartup(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetFeatureFlags(featureflags.NewStaticProvider([]string{featureflags.Acknowledgements}))
+	router := testServer.Initialize()
+
+	recorder := serveV2Request(router, server.AckListEndpoint)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	// not handled by the feature flag check, the route does not exist
+	assert.NotContains(t, recorder.Body.String(), server.ErrorCodeFeatureDisabled)
+}
+
+// TestFeatureDisabledAtRuntime checks that endpoints of features turned off
+// after the server has been initialized respond by feature_disabled problem
+func TestFeatureDisabledAtRuntime(t *testing.T) {
+	provider := featureflags.NewStaticProvider(nil)
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetFeatureFlags(provider)
+	router := testServer.Initialize()
+
+	provider.SetEnabled(featureflags.DVOWorkloads, false)
+
+	endpoint := strings.Replace(server.DVONamespacesForClusterEndpoint, "{cluster}", string(testdata.ClusterName), 1)
+	recorder := serveV2Request(router, endpoint)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `"code":"feature_disabled"`)
+}
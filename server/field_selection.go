@@ -0,0 +1,191 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// statusField is kept in all responses regardless of the selected
+	// fields
+	statusField = "status"
+	// jsonMediaType is the prefix of content type of responses the field
+	// selection is applied to
+	jsonMediaType = "application/json"
+)
+
+// fieldSelection is a tree of selected fields. Field mapped to empty
+// selection is selected as a whole.
+type fieldSelection map[string]fieldSelection
+
+// parseFieldSelection parses comma-separated list of dot-separated paths
+func parseFieldSelection(value string) fieldSelection {
+	selection := fieldSelection{}
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := selection
+		for _, name := range strings.Split(path, ".") {
+			child, found := node[name]
+			if !found {
+				child = fieldSelection{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return selection
+}
+
+// project removes the fields that are not selected from the parsed JSON
+// value. Arrays are projected item by item.
+func (selection fieldSelection) project(value interface{}) interface{} {
+	if len(selection) == 0 {
+		return value
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			child, found := selection[key]
+			if !found {
+				delete(typed, key)
+				continue
+			}
+			typed[key] = child.project(item)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = selection.project(item)
+		}
+	}
+	return value
+}
+
+// newFieldSelectionModifier constructs JSON modifier which keeps only the
+// selected fields of the response. The status field is always kept. When
+// the payload is wrapped in single object (like the report), the paths are
+// relative to that object.
+func newFieldSelectionModifier(selection fieldSelection) JSONModifier {
+	return func(_ *http.Request, body interface{}) (interface{}, error) {
+		object, ok := body.(map[string]interface{})
+		if !ok {
+			return selection.project(body), nil
+		}
+
+		if wrapped, key := wrappedPayload(object); wrapped != nil {
+			object[key] = selection.project(wrapped)
+			return object, nil
+		}
+
+		status, hasStatus := object[statusField]
+		object = selection.project(object).(map[string]interface{})
+		if hasStatus {
+			object[statusField] = status
+		}
+		return object, nil
+	}
+}
+
+// wrappedPayload returns the only object in the response besides the status
+// together with its key
+func wrappedPayload(object map[string]interface{}) (map[string]interface{}, string) {
+	var payload map[string]interface{}
+	var payloadKey string
+	for key, value := range object {
+		if key == statusField {
+			continue
+		}
+		if payload != nil {
+			return nil, ""
+		}
+		typed, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		payload, payloadKey = typed, key
+	}
+	return payload, payloadKey
+}
+
+// bufferedResponseWriter keeps the response in memory, so it can be modified
+// before it is sent
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (writer *bufferedResponseWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if writer.statusCode == 0 {
+		writer.statusCode = http.StatusOK
+	}
+	return writer.body.Write(data)
+}
+
+func (writer *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if writer.statusCode == 0 {
+		writer.statusCode = statusCode
+	}
+}
+
+// fieldSelectionMiddleware applies the projection given by the "fields"
+// parameter on successful JSON responses
+func fieldSelectionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		selection := parseFieldSelection(request.URL.Query().Get(FieldsParam))
+		if len(selection) == 0 {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		buffered := &bufferedResponseWriter{header: writer.Header()}
+		next.ServeHTTP(buffered, request)
+		if buffered.statusCode == 0 {
+			buffered.statusCode = http.StatusOK
+		}
+
+		body := buffered.body.Bytes()
+		if buffered.statusCode == http.StatusOK &&
+			strings.HasPrefix(writer.Header().Get(contentTypeHeader), jsonMediaType) {
+			modified, err := modifyJSONBody(
+				[]JSONModifier{newFieldSelectionModifier(selection)}, request, body,
+			)
+			if err != nil {
+				log.Error().Err(err).Msg("unable to apply field selection")
+			} else {
+				body = modified
+				writer.Header().Del("Content-Length")
+			}
+		}
+
+		writer.WriteHeader(buffered.statusCode)
+		if _, err := writer.Write(body); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// serveWithFieldSelection sends the response through field selection
+// middleware
+func serveWithFieldSelection(statusCode int, response interface{}, query string) *httptest.ResponseRecorder {
+	handler := server.FieldSelectionMiddleware(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_ = responses.Send(statusCode, writer, response)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/endpoint?"+query, nil))
+	return recorder
+}
+
+func TestFieldSelectionList(t *testing.T) {
+	response := map[string]interface{}{
+		"status": "ok",
+		"meta":   map[string]interface{}{"count": 2, "offset": 0},
+		"data": []interface{}{
+			map[string]interface{}{"cluster_id": "c1", "total_risk": 2, "cluster_name": "first"},
+			map[string]interface{}{"cluster_id": "c2", "total_risk": 3, "cluster_name": "second"},
+		},
+	}
+
+	recorder := serveWithFieldSelection(http.StatusOK, response, "fields=meta.count,data.cluster_id")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"meta": {"count": 2},
+		"data": [{"cluster_id": "c1"}, {"cluster_id": "c2"}]
+	}`, recorder.Body.String())
+}
+
+func TestFieldSelectionWrappedPayload(t *testing.T) {
+	response := map[string]interface{}{
+		"status": "ok",
+		"report": map[string]interface{}{
+			"meta": map[string]interface{}{"count": 1},
+			"data": []interface{}{
+				map[string]interface{}{"rule_id": "rule", "total_risk": 1, "description": "text"},
+			},
+		},
+	}
+
+	recorder := serveWithFieldSelection(http.StatusOK, response, "fields=meta,data.rule_id,data.total_risk")
+
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"report": {
+			"meta": {"count": 1},
+			"data": [{"rule_id": "rule", "total_risk": 1}]
+		}
+	}`, recorder.Body.String())
+}
+
+func TestFieldSelectionNotAppliedOnError(t *testing.T) {
+	response := map[string]interface{}{"status": "Item not found", "detail": "cluster"}
+
+	recorder := serveWithFieldSelection(http.StatusNotFound, response, "fields=data")
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(t, `{"status": "Item not found", "detail": "cluster"}`, recorder.Body.String())
+}
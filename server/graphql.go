@@ -0,0 +1,353 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// graphqlMaxDepth is the maximal depth of GraphQL queries, the deepest
+// meaningful query is clusters -> hits -> field
+const graphqlMaxDepth = 5
+
+// graphqlSchema describes clusters of the organization, enabled rules hitting
+// them and the rule content
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# clusters of the organization
+	clusters: [Cluster!]!
+	# cluster of the organization, null when the organization has no such cluster
+	cluster(id: ID!): Cluster
+	# rule identified by "rule.module|ERROR_KEY", null when it is not known
+	rule(id: ID!): Rule
+}
+
+type Cluster {
+	id: ID!
+	displayName: String!
+	managed: Boolean!
+	status: String!
+	version: String!
+	# enabled rules hitting the cluster
+	hits: [Rule!]!
+}
+
+type Rule {
+	id: ID!
+	description: String!
+	generic: String!
+	reason: String!
+	resolution: String!
+	moreInfo: String!
+	totalRisk: Int!
+	resolutionRisk: Int!
+	impact: Int!
+	likelihood: Int!
+	tags: [String!]!
+	publishDate: String!
+}
+`
+
+// graphqlRequestDataKey is the key of graphqlRequestData in the context of
+// the GraphQL query
+type graphqlRequestDataKey struct{}
+
+// graphqlRequestData contains the identity of the requester and the data read
+// from AMS API and aggregator while resolving the query. Data are read at most
+// once per query, only when some field needs them.
+type graphqlRequestData struct {
+	server          *HTTPServer
+	orgID           types.OrgID
+	userID          types.UserID
+	statusFilter    []string
+	includeInternal bool
+
+	clustersOnce sync.Once
+	clusters     []types.ClusterInfo
+	clustersErr  error
+
+	hitsOnce        sync.Once
+	recommendations ctypes.ClusterRecommendationMap
+	ackedRules      map[ctypes.RuleID]bool
+	disabledRules   map[ctypes.ClusterName][]ctypes.RuleID
+	hitsErr         error
+}
+
+// readClusters reads the list of clusters of the organization
+func (data *graphqlRequestData) readClusters() error {
+	data.clustersOnce.Do(func() {
+		data.clusters, data.clustersErr = data.server.readClusterInfoForOrgID(data.orgID, data.statusFilter)
+		if data.clustersErr != nil {
+			log.Error().Err(data.clustersErr).Int(orgIDTag, int(data.orgID)).Msg("problem reading cluster list for org")
+		}
+	})
+	return data.clustersErr
+}
+
+// readHits reads the recommendations hitting all clusters of the
+// organization together with the rules disabled by the user
+func (data *graphqlRequestData) readHits() error {
+	data.hitsOnce.Do(func() {
+		if data.hitsErr = data.readClusters(); data.hitsErr != nil {
+			return
+		}
+
+		data.recommendations, data.hitsErr = data.server.readRecommendationsForClusters(
+			data.orgID, data.userID, types.GetClusterNames(data.clusters),
+		)
+		if data.hitsErr != nil {
+			log.Error().Err(data.hitsErr).Int(orgIDTag, int(data.orgID)).Msg("problem reading recommendations for clusters")
+			return
+		}
+
+		data.ackedRules = data.server.getRuleAcksMap(data.orgID)
+		data.disabledRules = data.server.getUserDisabledRulesPerCluster(data.orgID)
+	})
+	return data.hitsErr
+}
+
+// rule returns the rule resolver, nil is returned for rules without content
+// and for internal rules the organization has no access to
+func (data *graphqlRequestData) rule(ruleID ctypes.RuleID) (*graphqlRule, error) {
+	ruleContent, err := content.GetContentForRecommendation(ruleID)
+	if err != nil {
+		if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+			return nil, err
+		}
+		// missing rule content, the rule can't be displayed
+		return nil, nil
+	}
+
+	if ruleContent.Internal && !data.includeInternal {
+		return nil, nil
+	}
+	return &graphqlRule{id: ruleID, content: ruleContent}, nil
+}
+
+// graphqlRoot resolves the fields of Query type
+type graphqlRoot struct{}
+
+// requestData returns the data of the query stored in the context
+func requestData(ctx context.Context) *graphqlRequestData {
+	return ctx.Value(graphqlRequestDataKey{}).(*graphqlRequestData)
+}
+
+// Clusters resolves the clusters of the organization
+func (*graphqlRoot) Clusters(ctx context.Context) ([]*graphqlCluster, error) {
+	data := requestData(ctx)
+	if err := data.readClusters(); err != nil {
+		return nil, err
+	}
+
+	clusters := make([]*graphqlCluster, len(data.clusters))
+	for i := range data.clusters {
+		clusters[i] = &graphqlCluster{info: &data.clusters[i], data: data}
+	}
+	return clusters, nil
+}
+
+// Cluster resolves single cluster of the organization
+func (*graphqlRoot) Cluster(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlCluster, error) {
+	data := requestData(ctx)
+	if err := data.readClusters(); err != nil {
+		return nil, err
+	}
+
+	for i := range data.clusters {
+		if string(data.clusters[i].ID) == string(args.ID) {
+			return &graphqlCluster{info: &data.clusters[i], data: data}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Rule resolves the rule with its content
+func (*graphqlRoot) Rule(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlRule, error) {
+	return requestData(ctx).rule(ctypes.RuleID(args.ID))
+}
+
+// graphqlCluster resolves the fields of Cluster type
+type graphqlCluster struct {
+	info *types.ClusterInfo
+	data *graphqlRequestData
+}
+
+// ID resolves the cluster ID
+func (cluster *graphqlCluster) ID() graphql.ID {
+	return graphql.ID(cluster.info.ID)
+}
+
+// DisplayName resolves the display name of the cluster
+func (cluster *graphqlCluster) DisplayName() string {
+	return cluster.info.DisplayName
+}
+
+// Managed resolves whether the cluster is managed
+func (cluster *graphqlCluster) Managed() bool {
+	return cluster.info.Managed
+}
+
+// Status resolves the status of the cluster in AMS API
+func (cluster *graphqlCluster) Status() string {
+	return cluster.info.Status
+}
+
+// Version resolves the version of the cluster
+func (cluster *graphqlCluster) Version() string {
+	return cluster.info.Version
+}
+
+// Hits resolves the rules hitting the cluster. Acked rules, rules disabled
+// for the cluster and rules not meant for managed clusters are filtered out.
+func (cluster *graphqlCluster) Hits() ([]*graphqlRule, error) {
+	if err := cluster.data.readHits(); err != nil {
+		return nil, err
+	}
+
+	enabledOnlyRecommendations := filterOutDisabledRules(
+		cluster.data.recommendations[cluster.info.ID].Recommendations, cluster.info.ID,
+		cluster.data.ackedRules, cluster.data.disabledRules,
+	)
+
+	hits := make([]*graphqlRule, 0, len(enabledOnlyRecommendations))
+	for _, ruleID := range enabledOnlyRecommendations {
+		rule, err := cluster.data.rule(ruleID)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil || (cluster.info.Managed && !rule.content.OSDCustomer) {
+			continue
+		}
+		hits = append(hits, rule)
+	}
+	return hits, nil
+}
+
+// graphqlRule resolves the fields of Rule type
+type graphqlRule struct {
+	id      ctypes.RuleID
+	content *types.RuleWithContent
+}
+
+// ID resolves the rule ID in "rule.module|ERROR_KEY" format
+func (rule *graphqlRule) ID() graphql.ID {
+	return graphql.ID(rule.id)
+}
+
+// Description resolves the description of the rule
+func (rule *graphqlRule) Description() string {
+	return rule.content.Description
+}
+
+// Generic resolves the generic description of the rule
+func (rule *graphqlRule) Generic() string {
+	return rule.content.Generic
+}
+
+// Reason resolves the reason template of the rule
+func (rule *graphqlRule) Reason() string {
+	return rule.content.Reason
+}
+
+// Resolution resolves the resolution template of the rule
+func (rule *graphqlRule) Resolution() string {
+	return rule.content.Resolution
+}
+
+// MoreInfo resolves the additional info about the rule
+func (rule *graphqlRule) MoreInfo() string {
+	return rule.content.MoreInfo
+}
+
+// TotalRisk resolves the total risk of the rule
+func (rule *graphqlRule) TotalRisk() int32 {
+	return int32(rule.content.TotalRisk)
+}
+
+// ResolutionRisk resolves the resolution risk of the rule
+func (rule *graphqlRule) ResolutionRisk() int32 {
+	return int32(rule.content.ResolutionRisk)
+}
+
+// Impact resolves the impact of the rule
+func (rule *graphqlRule) Impact() int32 {
+	return int32(rule.content.Impact)
+}
+
+// Likelihood resolves the likelihood of the rule
+func (rule *graphqlRule) Likelihood() int32 {
+	return int32(rule.content.Likelihood)
+}
+
+// Tags resolves the tags of the rule
+func (rule *graphqlRule) Tags() []string {
+	if rule.content.Tags == nil {
+		return []string{}
+	}
+	return rule.content.Tags
+}
+
+// PublishDate resolves the publish date of the rule in RFC 3339 format
+func (rule *graphqlRule) PublishDate() string {
+	return rule.content.PublishDate.Format(time.RFC3339)
+}
+
+// graphqlHandler returns the handler of GraphQL queries. The schema is parsed
+// once, when the handler is constructed.
+func (server *HTTPServer) graphqlHandler() http.HandlerFunc {
+	handler := &relay.Handler{
+		Schema: graphql.MustParseSchema(graphqlSchema, &graphqlRoot{}, graphql.MaxDepth(graphqlMaxDepth)),
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+		if err != nil {
+			log.Err(err).Msg(orgIDTokenError)
+			handleServerError(writer, err)
+			return
+		}
+
+		statusFilter, err := server.readClusterStatusFilter(request)
+		if err != nil {
+			handleServerError(writer, err)
+			return
+		}
+
+		data := &graphqlRequestData{
+			server:          server,
+			orgID:           orgID,
+			userID:          userID,
+			statusFilter:    statusFilter,
+			includeInternal: server.includeInternalRules(request),
+		}
+		ctx := context.WithValue(request.Context(), graphqlRequestDataKey{}, data)
+		handler.ServeHTTP(writer, request.WithContext(ctx))
+	}
+}
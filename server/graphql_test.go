@@ -0,0 +1,130 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// graphqlQuery encodes the GraphQL query into the request body
+func graphqlQuery(t testing.TB, query string) string {
+	body, err := json.Marshal(map[string]string{"query": query})
+	helpers.FailOnError(t, err)
+	return string(body)
+}
+
+// TestGraphQLClustersWithHits checks that clusters and rules hitting them are
+// resolved by single query
+func TestGraphQLClustersWithHits(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		defer content.ResetContent()
+		helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+		clusterInfoList := data.GetRandomClusterInfoListAllUnManaged(2)
+		reqBody, _ := json.Marshal(types.GetClusterNames(clusterInfoList))
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body: fmt.Sprintf(`{
+					"clusters":{
+						"%v": {"created_at": "%v", "recommendations": ["%v"]},
+						"%v": {"created_at": "%v", "recommendations": []}
+					}
+				}`,
+					clusterInfoList[0].ID, testTimeStr, testdata.Rule1CompositeID,
+					clusterInfoList[1].ID, testTimeStr,
+				),
+			},
+		)
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
+
+		amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
+		router := testServer.Initialize()
+
+		recorder := serveJWTRequest(router, http.MethodPost, serverConfigJWT.APIv2Prefix+server.GraphQLEndpoint,
+			graphqlQuery(t, `{ clusters { id hits { id description } } }`))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"data": {"clusters": [
+				{"id": "%v", "hits": [{"id": "%v", "description": "%v"}]},
+				{"id": "%v", "hits": []}
+			]}}`,
+			clusterInfoList[0].ID, testdata.Rule1CompositeID, testdata.RuleErrorKey1.Description,
+			clusterInfoList[1].ID,
+		), recorder.Body.String())
+	}, testTimeout)
+}
+
+// TestGraphQLRule checks that the rule content is resolved without reading
+// clusters of the organization
+func TestGraphQLRule(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	router := testServer.Initialize()
+	endpoint := serverConfigJWT.APIv2Prefix + server.GraphQLEndpoint
+
+	recorder := serveJWTRequest(router, http.MethodPost, endpoint,
+		graphqlQuery(t, `{ rule(id: "`+string(testdata.Rule1CompositeID)+`") { id description } }`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"data": {"rule": {"id": "%v", "description": "%v"}}}`,
+		testdata.Rule1CompositeID, testdata.RuleErrorKey1.Description,
+	), recorder.Body.String())
+
+	// unknown rule
+	recorder = serveJWTRequest(router, http.MethodPost, endpoint,
+		graphqlQuery(t, `{ rule(id: "unknown.rule|UNKNOWN_KEY") { id } }`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data": {"rule": null}}`, recorder.Body.String())
+}
+
+// TestGraphQLInvalidQuery checks that invalid queries are reported in errors
+func TestGraphQLInvalidQuery(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	router := testServer.Initialize()
+
+	recorder := serveJWTRequest(router, http.MethodPost, serverConfigJWT.APIv2Prefix+server.GraphQLEndpoint,
+		graphqlQuery(t, `{ unknownField }`))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Errors []interface{} `json:"errors"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Errors)
+}
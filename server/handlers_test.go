@@ -336,9 +336,9 @@ func TestHTTPServer_ReportEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -350,6 +350,178 @@ func TestHTTPServer_ReportEndpoint(t *testing.T) {
 	}, testTimeout)
 }
 
+// TestHTTPServer_ReportEndpointInvalidIncludeMissingContent checks that
+// unparsable include_missing_content parameter is refused as bad request
+func TestHTTPServer_ReportEndpointInvalidIncludeMissingContent(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report3RulesExpectedResponse,
+		})
+
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportEndpoint + "?" + server.IncludeMissingContentParam + "=maybe",
+			EndpointArgs:       []interface{}{testdata.ClusterName},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: helpers.ToJSONString(server.Problem{
+				Type:   "urn:insights-results-smart-proxy:error:invalid_parameter",
+				Title:  "Invalid parameter",
+				Status: http.StatusBadRequest,
+				Detail: "Error during parsing param 'include_missing_content' with value 'maybe'. Error: 'Unparsable boolean value'",
+				Code:   server.ErrorCodeInvalidParameter,
+			}),
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ReportEndpointInvalidAllVersions checks that unparsable
+// all_versions parameter is refused as bad request
+func TestHTTPServer_ReportEndpointInvalidAllVersions(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report3RulesExpectedResponse,
+		})
+
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportEndpoint + "?" + server.AllVersionsParam + "=all",
+			EndpointArgs:       []interface{}{testdata.ClusterName},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: helpers.ToJSONString(server.Problem{
+				Type:   "urn:insights-results-smart-proxy:error:invalid_parameter",
+				Title:  "Invalid parameter",
+				Status: http.StatusBadRequest,
+				Detail: "Error during parsing param 'all_versions' with value 'all'. Error: 'Unparsable boolean value'",
+				Code:   server.ErrorCodeInvalidParameter,
+			}),
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ReportEndpointSlim checks that reason, resolution and more
+// info are not sent unless verbose report is requested
+func TestHTTPServer_ReportEndpointSlim(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	slimData := make([]types.RuleWithContentResponse, len(Report3RulesData))
+	copy(slimData, Report3RulesData)
+	for i := range slimData {
+		slimData[i].Reason = ""
+		slimData[i].Resolution = ""
+		slimData[i].MoreInfo = ""
+	}
+	slimReport := v1Report3Rules
+	slimReport.Data = slimData
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report3RulesExpectedResponse,
+		})
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportEndpoint,
+			EndpointArgs:       []interface{}{testdata.ClusterName},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(struct {
+				Status string                    `json:"status"`
+				Report *types.SmartProxyReportV1 `json:"report"`
+			}{
+				Status: "ok",
+				Report: &slimReport,
+			}),
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ReportEndpointResolutionRisk checks that the report can be
+// filtered by resolution risk and that all issues are still counted
+func TestHTTPServer_ReportEndpointResolutionRisk(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	filteredData := []types.RuleWithContentResponse{Report3RulesData[0]}
+	filteredData[0].ResolutionRisk = testdata.RuleErrorKey1.ResolutionRisk
+	filteredReport := v1Report3Rules
+	filteredReport.Data = filteredData
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report3RulesExpectedResponse,
+		})
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+			Method: http.MethodGet,
+			Endpoint: server.ReportEndpoint + "?" + server.VerboseParam + "=true&" +
+				server.ResolutionRiskParam + "=" + fmt.Sprint(testdata.RuleErrorKey1.ResolutionRisk),
+			EndpointArgs:       []interface{}{testdata.ClusterName},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: helpers.ToJSONString(struct {
+				Status string                    `json:"status"`
+				Report *types.SmartProxyReportV1 `json:"report"`
+			}{
+				Status: "ok",
+				Report: &filteredReport,
+			}),
+		})
+	}, testTimeout)
+}
+
 func TestHTTPServer_ReportEndpoint_UnavailableContentService(t *testing.T) {
 	var emptyResponse *ctypes.RuleContentDirectory
 	err := loadMockRuleContentDir(emptyResponse)
@@ -357,7 +529,11 @@ func TestHTTPServer_ReportEndpoint_UnavailableContentService(t *testing.T) {
 
 	expectedBody := `
 		{
-		   "status" : "Content directory cache has been empty for too long time; timeout triggered"
+		"type": "urn:insights-results-smart-proxy:error:content_service_unavailable",
+		"title": "Content service unavailable",
+		"status": 503,
+		"detail": "Content directory cache has been empty for too long time; timeout triggered",
+		"code": "content_service_unavailable"
 		}
 	`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
@@ -373,7 +549,7 @@ func TestHTTPServer_ReportEndpoint_UnavailableContentService(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName},
@@ -408,9 +584,9 @@ func TestHTTPServer_ReportEndpointNoContent(t *testing.T) {
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
 		// previously was InternalServerError, but it was changed as an edge-case which will appear as "No issues found"
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -438,7 +614,7 @@ func TestHTTPServer_ReportEndpointV2NoContent(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -455,7 +631,7 @@ func TestHTTPServer_ReportEndpointV2NoContent(t *testing.T) {
 		// previously was InternalServerError, but it was changed as an edge-case which will appear as "No issues found"
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpointV2,
+			Endpoint:           server.ReportEndpointV2 + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             types.UserID(userIDOnGoodJWTAuthBearer),
 			OrgID:              testdata.OrgID,
@@ -484,7 +660,7 @@ func TestHTTPServer_ReportEndpointV2TestAMSData(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -505,7 +681,7 @@ func TestHTTPServer_ReportEndpointV2TestAMSData(t *testing.T) {
 
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpointV2,
+			Endpoint:           server.ReportEndpointV2 + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
 			UserID:             types.UserID(userIDOnGoodJWTAuthBearer),
 			OrgID:              testdata.OrgID,
@@ -517,6 +693,79 @@ func TestHTTPServer_ReportEndpointV2TestAMSData(t *testing.T) {
 	}, testTimeout)
 }
 
+// TestHTTPServer_ReportEndpointV2ClusterOrganizationValidated checks that
+// report is returned when the cluster belongs to organization of the caller
+func TestHTTPServer_ReportEndpointV2ClusterOrganizationValidated(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := data.GetRandomClusterInfoList(3)
+		amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList)
+
+		config := serverConfigJWT
+		config.ValidateClusterOrganization = true
+		testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, clusterInfoList[0].ID, userIDOnGoodJWTAuthBearer},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report1RuleExpectedResponse,
+		})
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		resp := SmartProxyV2ReportResponse1RuleNoContent
+		resp.Report.Meta.DisplayName = clusterInfoList[0].DisplayName
+		resp.Report.Meta.Managed = clusterInfoList[0].Managed
+
+		iou_helpers.AssertAPIRequest(t, testServer, config.APIv2Prefix, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportEndpointV2 + "?" + server.VerboseParam + "=true",
+			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
+			UserID:             types.UserID(userIDOnGoodJWTAuthBearer),
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       helpers.ToJSONString(resp),
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ReportEndpointV2ClusterOfAnotherOrganization checks that
+// report of cluster belonging to another organization is not requested from
+// aggregator
+func TestHTTPServer_ReportEndpointV2ClusterOfAnotherOrganization(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := data.GetRandomClusterInfoList(1)
+		amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID+1, clusterInfoList)
+
+		config := serverConfigJWT
+		config.ValidateClusterOrganization = true
+		testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil)
+
+		iou_helpers.AssertAPIRequest(t, testServer, config.APIv2Prefix, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportEndpointV2,
+			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
+			UserID:             types.UserID(userIDOnGoodJWTAuthBearer),
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusNotFound,
+		})
+	}, testTimeout)
+}
+
 func TestHTTPServer_ReportEndpointV2TestManagedClustersRules(t *testing.T) {
 	defer content.ResetContent()
 	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
@@ -533,7 +782,7 @@ func TestHTTPServer_ReportEndpointV2TestManagedClustersRules(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		// 3 rules, only 1 of which is managed
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
@@ -555,7 +804,7 @@ func TestHTTPServer_ReportEndpointV2TestManagedClustersRules(t *testing.T) {
 
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpointV2,
+			Endpoint:           server.ReportEndpointV2 + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
 			UserID:             types.UserID(userIDOnGoodJWTAuthBearer),
 			OrgID:              testdata.OrgID,
@@ -588,9 +837,9 @@ func TestHTTPServer_ReportEndpointNoContentFor2Rules(t *testing.T) {
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
 		// 1 rule returned, but count = 3
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -621,9 +870,9 @@ func TestHTTPServer_ReportEndpoint_WithOnlyOSDEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint + "?" + server.OSDEligibleParam + "=true",
+			Endpoint:           server.ReportEndpoint + "?" + server.OSDEligibleParam + "=true&" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -656,7 +905,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentManagedCluster(t *te
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -675,7 +924,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentManagedCluster(t *te
 
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv1Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -710,7 +959,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentNonManagedCluster(t
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -730,7 +979,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentNonManagedCluster(t
 
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv1Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{clusterInfoList[0].ID},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -743,6 +992,59 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentNonManagedCluster(t
 	}, testTimeout)
 }
 
+// TestHTTPServer_ReportEndpoint_ManagedClusterFromAMS checks that rules for
+// managed clusters are returned by default when AMS API reports the cluster
+// as managed and that the osd_eligible parameter overrides it
+func TestHTTPServer_ReportEndpoint_ManagedClusterFromAMS(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	for _, testCase := range []struct {
+		TestName         string
+		Query            string
+		ExpectedResponse interface{}
+	}{
+		{"managed status from AMS", "", SmartProxyV1ReportResponse3RulesWithOnlyOSD},
+		{"managed status overridden", "&" + server.OSDEligibleParam + "=false", SmartProxyV1ReportResponse3Rules},
+	} {
+		t.Run(testCase.TestName, func(t *testing.T) {
+			helpers.RunTestWithTimeout(t, func(t testing.TB) {
+				defer helpers.CleanAfterGock(t)
+
+				clusterInfoList := []types.ClusterInfo{data.GetRandomClusterInfo()}
+				clusterInfoList[0].Managed = true
+				amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList)
+
+				testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
+
+				helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+					Method:       http.MethodGet,
+					Endpoint:     ira_server.ReportEndpoint,
+					EndpointArgs: []interface{}{testdata.OrgID, clusterInfoList[0].ID, testdata.UserID},
+				}, &helpers.APIResponse{
+					StatusCode: http.StatusOK,
+					Body:       testdata.Report3RulesExpectedResponse,
+				})
+
+				expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+				iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv1Prefix, &helpers.APIRequest{
+					Method:             http.MethodGet,
+					Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true" + testCase.Query,
+					EndpointArgs:       []interface{}{clusterInfoList[0].ID},
+					UserID:             testdata.UserID,
+					OrgID:              testdata.OrgID,
+					AuthorizationToken: goodJWTAuthBearer,
+				}, &helpers.APIResponse{
+					StatusCode: http.StatusOK,
+					Body:       helpers.ToJSONString(testCase.ExpectedResponse),
+				})
+			}, testTimeout)
+		})
+	}
+}
+
 func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 	defer content.ResetContent()
 	err := loadMockRuleContentDir(&testdata.RuleContentDirectory5Rules)
@@ -764,9 +1066,9 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 			expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 		}
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=false",
+			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=false&" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -777,9 +1079,9 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 		})
 
 		// Not using the parameter gets the same result as using with =false
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -790,9 +1092,9 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 		})
 
 		// Enabling the parameter
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=true",
+			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=true&" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -823,9 +1125,9 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForClusterAndMissingContent(
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -864,9 +1166,9 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 			})
 		}
 		// Get report with get_disabled = false
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=false",
+			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=false&" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -877,9 +1179,9 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 		})
 
 		// Get report without specifying get_disabled => same result as above
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint,
+			Endpoint:           server.ReportEndpoint + "?" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -891,9 +1193,9 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 
 		// Get report with get_disabled = true
 		// => Report contains disabled rules for cluster and org-wide disabled rules
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
-			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=true",
+			Endpoint:           server.ReportEndpoint + "?" + server.GetDisabledParam + "=true&" + server.VerboseParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName},
 			UserID:             testdata.UserID,
 			OrgID:              testdata.OrgID,
@@ -935,7 +1237,7 @@ func TestHTTPServer_ReportMetainfoEndpointNoReports(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportMetainfoEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName},
@@ -979,7 +1281,7 @@ func TestHTTPServer_ReportMetainfoEndpointTwoReports(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportMetainfoEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName},
@@ -1011,7 +1313,7 @@ func TestHTTPServer_ReportMetainfoEndpointForbidden(t *testing.T) {
 			Body:       "",
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportMetainfoEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName},
@@ -1046,7 +1348,7 @@ func TestHTTPServer_ReportMetainfoEndpointImproperJSON(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportMetainfoEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName},
@@ -1092,7 +1394,7 @@ func TestHTTPServer_ReportMetainfoEndpointWrongClusterName(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ReportMetainfoEndpoint,
 			EndpointArgs:       []interface{}{clusterName},
@@ -1129,7 +1431,7 @@ func TestHTTPServer_RuleEndpoint(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.SingleRuleEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey)},
@@ -1150,7 +1452,11 @@ func TestHTTPServer_RuleEndpoint_UnavailableContentService(t *testing.T) {
 
 	expectedBody := `
 		{
-		   "status" : "Content directory cache has been empty for too long time; timeout triggered"
+		"type": "urn:insights-results-smart-proxy:error:content_service_unavailable",
+		"title": "Content service unavailable",
+		"status": 503,
+		"detail": "Content directory cache has been empty for too long time; timeout triggered",
+		"code": "content_service_unavailable"
 		}
 	`
 
@@ -1170,7 +1476,7 @@ func TestHTTPServer_RuleEndpoint_UnavailableContentService(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.SingleRuleEndpoint,
 			EndpointArgs:       []interface{}{testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey)},
@@ -1206,7 +1512,7 @@ func TestHTTPServer_RuleEndpoint_WithOSD(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.SingleRuleEndpoint + "?" + server.OSDEligibleParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey)},
@@ -1242,7 +1548,7 @@ func TestHTTPServer_RuleEndpoint_WithNotOSDRule(t *testing.T) {
 			Body:       testdata.Report3SingleRule2ExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.SingleRuleEndpoint + "?" + server.OSDEligibleParam + "=true",
 			EndpointArgs:       []interface{}{testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey2.RuleModule, testdata.RuleErrorKey2.ErrorKey)},
@@ -1263,7 +1569,7 @@ func TestHTTPServer_GetContent(t *testing.T) {
 	assert.Nil(t, err)
 
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.Content,
 			AuthorizationToken: goodJWTAuthBearer,
@@ -1338,7 +1644,7 @@ func TestHTTPServer_OverviewEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1420,7 +1726,7 @@ func TestHTTPServer_OverviewEndpointManagedClustersRules(t *testing.T) {
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
 
 		// managed cluster; 1 managed rule, 2 non-managed rules == only 1 rule must count
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1445,7 +1751,11 @@ func TestHTTPServer_OverviewEndpoint_UnavailableContentService(t *testing.T) {
 
 	expectedBody := `
 		{
-		   "status" : "Content directory cache has been empty for too long time; timeout triggered"
+		"type": "urn:insights-results-smart-proxy:error:content_service_unavailable",
+		"title": "Content service unavailable",
+		"status": 503,
+		"detail": "Content directory cache has been empty for too long time; timeout triggered",
+		"code": "content_service_unavailable"
 		}
 	`
 
@@ -1498,7 +1808,7 @@ func TestHTTPServer_OverviewEndpoint_UnavailableContentService(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, helpers.DefaultServerConfig.APIv1Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.OverviewEndpoint,
@@ -1571,7 +1881,7 @@ func TestHTTPServer_OverviewGetEndpointDisabledRule(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1689,7 +1999,7 @@ func TestHTTPServer_OverviewEndpointWithFallback(t *testing.T) {
 
 		config := helpers.DefaultServerConfig
 		config.UseOrgClustersFallback = true
-		testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1753,6 +2063,12 @@ func TestInternalOrganizations(t *testing.T) {
 			http.StatusOK,
 			goodJWTAuthBearer,
 		},
+		{
+			"Internal organizations enabled, Rule redacted for other organization",
+			&serverConfigInternalOrganizations2,
+			http.StatusNotFound,
+			goodJWTAuthBearer,
+		},
 		{
 			"Internal organizations disabled, Request allowed",
 			&serverConfigJWT,
@@ -1762,7 +2078,7 @@ func TestInternalOrganizations(t *testing.T) {
 	} {
 		t.Run(testCase.TestName, func(t *testing.T) {
 			helpers.RunTestWithTimeout(t, func(t testing.TB) {
-				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:             http.MethodGet,
 					Endpoint:           server.RuleContent,
 					EndpointArgs:       []interface{}{internalTestRuleModule},
@@ -1798,7 +2114,7 @@ func TestRuleNames(t *testing.T) {
 	} {
 		t.Run(testCase.TestName, func(t *testing.T) {
 			helpers.RunTestWithTimeout(t, func(t testing.TB) {
-				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:             http.MethodGet,
 					Endpoint:           server.RuleIDs,
 					AuthorizationToken: testCase.MockAuthToken,
@@ -1827,7 +2143,7 @@ func TestRuleNamesResponse(t *testing.T) {
 		}
 	`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations1, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations1, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RuleIDs,
 			AuthorizationToken: goodJWTAuthBearer,
@@ -1844,7 +2160,7 @@ func TestRuleNamesResponse(t *testing.T) {
 			"status": "ok"
 		}`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations2, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations2, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RuleIDs,
 			AuthorizationToken: goodJWTAuthBearer,
@@ -1880,7 +2196,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodPost,
 			Endpoint:           server.OverviewEndpoint,
 			OrgID:              testdata.OrgID,
@@ -1902,7 +2218,11 @@ func TestHTTPServer_OverviewWithClusterIDsEndpoint_UnavailableContentService(t *
 
 	expectedBody := `
 		{
-		   "status" : "Content directory cache has been empty for too long time; timeout triggered"
+		"type": "urn:insights-results-smart-proxy:error:content_service_unavailable",
+		"title": "Content service unavailable",
+		"status": 503,
+		"detail": "Content directory cache has been empty for too long time; timeout triggered",
+		"code": "content_service_unavailable"
 		}`
 
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
@@ -1923,7 +2243,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpoint_UnavailableContentService(t *
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodPost,
 			Endpoint:           server.OverviewEndpoint,
 			OrgID:              testdata.OrgID,
@@ -1969,7 +2289,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpointDisabledRules(t *testing.T) {
 			Body:       helpers.ToJSONString(ResponseRule1DisabledSystemWide),
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodPost,
 			Endpoint:           server.OverviewEndpoint,
 			OrgID:              testdata.OrgID,
@@ -2005,7 +2325,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpointDisabledRules(t *testing.T) {
 			Body:       helpers.ToJSONString(ResponseRule2DisabledSystemWide),
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodPost,
 			Endpoint:           server.OverviewEndpoint,
 			OrgID:              testdata.OrgID,
@@ -2082,7 +2402,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules_ImpactingMissing(t *testin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2192,7 +2512,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules_ImpactingMissing1RuleDisab
 		)
 
 		// one rule acked; one rule user disabled (not counted as impacting)
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2268,7 +2588,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules1MissingContent(t *testing.
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2335,7 +2655,7 @@ func TestHTTPServer_RecommendationsListEndpoint_NoRuleContent(t *testing.T) {
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2406,7 +2726,7 @@ func TestHTTPServer_RecommendationsListEndpoint3Rules1Internal0Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=true",
@@ -2477,7 +2797,7 @@ func TestHTTPServer_RecommendationsListEndpoint3Rules1Internal0Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=false",
@@ -2552,7 +2872,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules1Internal2Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2632,7 +2952,7 @@ func TestHTTPServer_RecommendationsListEndpoint4Rules1Internal2Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2650,7 +2970,7 @@ func TestHTTPServer_RecommendationsListEndpoint_BadToken(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
 			AuthorizationToken: badJWTAuthBearer,
@@ -2665,7 +2985,7 @@ func TestHTTPServer_RecommendationsListEndpoint_BadImpactingParam(t *testing.T)
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=badbool",
 			AuthorizationToken: goodJWTAuthBearer,
@@ -2738,7 +3058,7 @@ func TestHTTPServer_RecommendationsListEndpointAMSManagedClusters(t *testing.T)
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RecommendationsListEndpoint,
@@ -2783,10 +3103,10 @@ func TestHTTPServer_GetRecommendationContent(t *testing.T) {
 			nil,
 		},
 		{
-			"internal forbidden",
+			"internal redacted",
 			&serverConfigInternalOrganizations2,
 			internalRuleID,
-			http.StatusForbidden,
+			http.StatusNotFound,
 			nil,
 		},
 		{
@@ -2818,7 +3138,7 @@ func TestHTTPServer_GetRecommendationContent(t *testing.T) {
 					}
 				}
 
-				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:             http.MethodGet,
 					Endpoint:           server.RuleContentV2,
 					EndpointArgs:       []interface{}{testCase.RuleID},
@@ -2829,6 +3149,86 @@ func TestHTTPServer_GetRecommendationContent(t *testing.T) {
 	}
 }
 
+// TestHTTPServer_GetRecommendationContentByErrorKey checks that rule module
+// and error key can be passed as separate path parameters
+func TestHTTPServer_GetRecommendationContentByErrorKey(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{testdata.RuleContent1, RuleContentInternal1},
+		),
+	)
+	assert.Nil(t, err)
+
+	for _, testCase := range []struct {
+		TestName           string
+		ServerConfig       *server.Configuration
+		RuleModule         string
+		ErrorKey           ctypes.ErrorKey
+		ExpectedStatusCode int
+		ExpectedResponse   interface{}
+	}{
+		{
+			"ok",
+			&serverConfigJWT,
+			string(testdata.Rule1ID),
+			testdata.ErrorKey1,
+			http.StatusOK,
+			GetRuleContentRecommendationContent1,
+		},
+		{
+			"ok with report suffix",
+			&serverConfigJWT,
+			string(testdata.Rule1ID) + dotReportRuleModuleSuffix,
+			testdata.ErrorKey1,
+			http.StatusOK,
+			GetRuleContentRecommendationContent1,
+		},
+		{
+			"internal redacted",
+			&serverConfigInternalOrganizations2,
+			internalTestRuleModule,
+			testdata.ErrorKey1,
+			http.StatusNotFound,
+			nil,
+		},
+		{
+			"unknown error key",
+			&serverConfigJWT,
+			string(testdata.Rule1ID),
+			"UNKNOWN_KEY",
+			http.StatusNotFound,
+			nil,
+		},
+		{
+			"invalid error key",
+			&serverConfigJWT,
+			string(testdata.Rule1ID),
+			"invalid-key",
+			http.StatusBadRequest,
+			nil,
+		},
+	} {
+		t.Run(testCase.TestName, func(t *testing.T) {
+			helpers.RunTestWithTimeout(t, func(t testing.TB) {
+				response := helpers.APIResponse{
+					StatusCode: testCase.ExpectedStatusCode,
+				}
+				if testCase.ExpectedResponse != nil {
+					response.Body = helpers.ToJSONString(testCase.ExpectedResponse)
+				}
+
+				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
+					Method:             http.MethodGet,
+					Endpoint:           server.RuleContentByErrorKeyV2,
+					EndpointArgs:       []interface{}{testCase.RuleModule, testCase.ErrorKey},
+					AuthorizationToken: goodJWTAuthBearer,
+				}, &response)
+			}, testTimeout)
+		})
+	}
+}
+
 // TestHTTPServer_GetRecommendationContentWithUserData
 func TestHTTPServer_GetRecommendationContentWithUserData(t *testing.T) {
 	defer content.ResetContent()
@@ -2880,11 +3280,11 @@ func TestHTTPServer_GetRecommendationContentWithUserData(t *testing.T) {
 			nil,
 		},
 		{
-			"internal forbidden",
+			"internal redacted",
 			&serverConfigInternalOrganizations2,
 			ctypes.UserVoteDislike,
 			internalRuleID,
-			http.StatusForbidden,
+			http.StatusNotFound,
 			nil,
 		},
 		{
@@ -2949,7 +3349,7 @@ func TestHTTPServer_GetRecommendationContentWithUserData(t *testing.T) {
 					}
 				}
 
-				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:             http.MethodGet,
 					Endpoint:           server.RuleContentWithUserData,
 					EndpointArgs:       []interface{}{testCase.RuleID},
@@ -2997,7 +3397,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoClusters(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3056,7 +3456,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_ClustersFoundNoInsights(t *t
 			resp.Clusters[i].LastCheckedAt = "" // will be empty because we don't have the cluster in our DB
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3128,7 +3528,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoRuleHits(t *testing.T) {
 			resp.Clusters[i].LastCheckedAt = testTimestamp
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3187,7 +3587,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoReportInDB(t *testing.T) {
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3272,7 +3672,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2ClustersFilled(t *testing.T
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3357,7 +3757,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2Clusters1Managed(t *testing
 		}
 
 		// cluster 1 is managed, so must only show 1 rule. cluster 2 will show both rules.
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3445,7 +3845,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2Clusters1WithVersion(t *tes
 		}
 
 		// cluster 1 is managed, so must only show 1 rule. cluster 2 will show both rules.
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3550,7 +3950,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_AckedRule(t *testing.T) {
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3659,7 +4059,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledRuleSingleCluster(t
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3789,7 +4189,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledAndAcked(t *testing.
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersRecommendationsEndpoint,
@@ -3803,13 +4203,8 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledAndAcked(t *testing.
 }
 
 func TestHTTPServer_GroupsEndpoint(t *testing.T) {
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
-
-	records := make([]groups.Group, 1)
-	go func() { groupsChannel <- records }()
-	go func() { errorFoundChannel <- false }()
+	groupsStore := content.NewGroupsStore()
+	groupsStore.SetGroups(make([]groups.Group, 1))
 
 	expectedBody := `
 		{
@@ -3823,7 +4218,7 @@ func TestHTTPServer_GroupsEndpoint(t *testing.T) {
 			"status": "ok"
 		}`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, groupsChannel, errorFoundChannel, errorChannel, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, groupsStore, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RuleGroupsEndpoint,
 			OrgID:              testdata.OrgID,
@@ -3836,20 +4231,20 @@ func TestHTTPServer_GroupsEndpoint(t *testing.T) {
 }
 
 func TestHTTPServer_GroupsEndpoint_UnavailableContentService(t *testing.T) {
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
-
-	go func() { errorFoundChannel <- true }()
-	go func() { errorChannel <- &content.RuleContentDirectoryTimeoutError{} }()
+	groupsStore := content.NewGroupsStore()
+	groupsStore.SetError(&content.RuleContentDirectoryTimeoutError{})
 
 	expectedBody := `
 		{
-			"status" : "Content directory cache has been empty for too long time; timeout triggered"
+			"type": "urn:insights-results-smart-proxy:error:content_service_unavailable",
+			"title": "Content service unavailable",
+			"status": 503,
+			"detail": "Content directory cache has been empty for too long time; timeout triggered",
+			"code": "content_service_unavailable"
 		}`
 
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, groupsChannel, errorFoundChannel, errorChannel, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, groupsStore, &helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.RuleGroupsEndpoint,
 			OrgID:              testdata.OrgID,
@@ -3863,10 +4258,53 @@ func TestHTTPServer_GroupsEndpoint_UnavailableContentService(t *testing.T) {
 
 // TestServeInfoMap checks the REST API server behaviour for info endpoint
 func TestServeInfoMap(t *testing.T) {
-	helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 		Method:   http.MethodGet,
 		Endpoint: "info",
 	}, &helpers.APIResponse{
 		StatusCode: http.StatusOK,
 	})
 }
+
+// TestHTTPServer_ContentRefreshEndpoint_Forbidden checks that organizations
+// not allowed to access internal rules can't trigger content refresh
+func TestHTTPServer_ContentRefreshEndpoint_Forbidden(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations2, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodPost,
+			Endpoint:           server.ContentRefreshEndpoint,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusForbidden,
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ContentRefreshEndpoint_UnavailableContentService checks
+// that the refresh fails when content service can't be reached
+func TestHTTPServer_ContentRefreshEndpoint_UnavailableContentService(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: "content",
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusInternalServerError,
+		})
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: "groups",
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusInternalServerError,
+		})
+
+		helpers.AssertAPIRequest(t, &serverConfigJWT, nil, content.NewGroupsStore(), &helpers.APIRequest{
+			Method:             http.MethodPost,
+			Endpoint:           server.ContentRefreshEndpoint,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusServiceUnavailable,
+		})
+	}, testTimeout)
+}
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
@@ -41,6 +43,24 @@ type infoEndpointStruct struct {
 	Info   map[string]string `json:"info"`
 }
 
+// groupsStatus represents the state of groups configuration retrieved from
+// content service
+type groupsStatus struct {
+	Loaded      bool      `json:"loaded"`
+	GroupsCount int       `json:"groups_count"`
+	LastRefresh time.Time `json:"last_refresh"`
+	LastError   string    `json:"last_error,omitempty"`
+	Stale       bool      `json:"stale"`
+}
+
+// statusResponse represents response for /status endpoint
+type statusResponse struct {
+	Status    string                    `json:"status"`
+	Content   content.Status            `json:"content"`
+	Groups    groupsStatus              `json:"groups"`
+	Upstreams map[string]upstreamStatus `json:"upstreams,omitempty"`
+}
+
 // getGroups sends the latest valid groups configuration to the client in
 // standard HTTP response
 func (server *HTTPServer) getGroups(writer http.ResponseWriter, _ *http.Request) {
@@ -69,19 +89,26 @@ func (server HTTPServer) getContentForRuleV1(writer http.ResponseWriter, request
 		return
 	}
 
+	renderHTML, err := readHTMLFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	ruleContent, err := content.GetRuleContentV1(ruleID)
 	if err != nil {
 		handleServerError(writer, err)
 		return
 	}
 
-	// check for internal rule permissions
-	if internal := content.IsRuleInternal(ruleID); internal {
-		err := server.checkInternalRulePermissions(request)
-		if err != nil {
-			handleServerError(writer, err)
-			return
-		}
+	err = server.redactInternalRule(request, content.IsRuleInternal(ruleID), ruleID)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if renderHTML {
+		content.RenderRuleContentV1HTML(ruleContent)
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("content", ruleContent))
@@ -104,14 +131,14 @@ func (server HTTPServer) getContentV1(writer http.ResponseWriter, request *http.
 
 	var rules []sptypes.RuleContentV1
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
+	if server.includeInternalRules(request) {
+		rules = allRules
+	} else {
 		for _, rule := range allRules {
 			if !content.IsRuleInternal(types.RuleID(rule.Plugin.PythonModule)) {
 				rules = append(rules, rule)
 			}
 		}
-	} else {
-		rules = allRules
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("content", rules))
@@ -130,7 +157,28 @@ func (server HTTPServer) getClustersForOrg(writer http.ResponseWriter, request *
 		return
 	}
 
-	server.proxyTo(server.ServicesConfig.AggregatorBaseEndpoint, nil)(writer, request)
+	server.proxyTo(server.currentServicesConfig().AggregatorBaseEndpoint, nil)(writer, request)
+}
+
+// getClustersForOrgNative retrieves the list of clusters belonging to this
+// organization without proxying the request to aggregator. It is run in
+// shadow mode alongside getClustersForOrg.
+func (server HTTPServer) getClustersForOrgNative(writer http.ResponseWriter, request *http.Request) {
+	orgID, successful := httputils.ReadOrganizationID(writer, request, server.Config.Auth)
+	if !successful {
+		return
+	}
+
+	clusterIDs, err := server.readClusterIDsForOrgID(orgID, nil)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("clusters", clusterIDs))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
 }
 
 // getRuleIDs returns a list of the names of the rules
@@ -145,14 +193,14 @@ func (server HTTPServer) getRuleIDs(writer http.ResponseWriter, request *http.Re
 
 	var ruleIDs []string
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
+	if server.includeInternalRules(request) {
+		ruleIDs = allRuleIDs
+	} else {
 		for _, rule := range allRuleIDs {
 			if !content.IsRuleInternal(types.RuleID(rule)) {
 				ruleIDs = append(ruleIDs, rule)
 			}
 		}
-	} else {
-		ruleIDs = allRuleIDs
 	}
 
 	if err := responses.SendOK(writer, responses.BuildOkResponseWithData("rules", ruleIDs)); err != nil {
@@ -236,11 +284,19 @@ func (server HTTPServer) overviewEndpoint(writer http.ResponseWriter, request *h
 	}
 	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("getClustersView start")
 
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	clusterList, clusterRuleHits, ackedRulesMap, disabledRules := server.getClusterListAndUserData(
 		writer,
 		orgID,
 		userID,
+		statusFilter,
 	)
+	server.redactInternalClusterRecommendations(orgID, clusterRuleHits)
 
 	overview, err := server.getOrganizationOverview(clusterList, clusterRuleHits, ackedRulesMap, disabledRules)
 	if err != nil {
@@ -349,13 +405,30 @@ func generateOrgOverview(
 }
 
 // infoMap returns map of additional information about this service, Insights
-// Results Aggregator, and Smart Proxy
+// Results Aggregator, and Smart Proxy. Aggregator and Content Service are
+// asked concurrently, so the response is not delayed by the slower one more
+// than necessary.
 func (server *HTTPServer) infoMap(writer http.ResponseWriter, request *http.Request) {
+	var contentServiceInfo, aggregatorInfo map[string]string
+
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		contentServiceInfo = server.fillInContentServiceInfoParams()
+	}()
+	go func() {
+		defer waitGroup.Done()
+		aggregatorInfo = server.fillInAggregatorInfoParams()
+	}()
+	smartProxyInfo := server.fillInSmartProxyInfoParams()
+	waitGroup.Wait()
+
 	// prepare response data structure
 	response := sptypes.InfoResponse{
-		SmartProxy:     server.fillInSmartProxyInfoParams(),
-		ContentService: server.fillInContentServiceInfoParams(),
-		Aggregator:     server.fillInAggregatorInfoParams(),
+		SmartProxy:     smartProxyInfo,
+		ContentService: contentServiceInfo,
+		Aggregator:     aggregatorInfo,
 	}
 
 	// try to send the response to client
@@ -367,6 +440,75 @@ func (server *HTTPServer) infoMap(writer http.ResponseWriter, request *http.Requ
 	}
 }
 
+// statusEndpoint reports whether the rule content and groups configuration
+// have been successfully loaded from content service. HTTP code 503 is
+// returned when any of them is not available, so the endpoint can be used
+// by health checks.
+func (server *HTTPServer) statusEndpoint(writer http.ResponseWriter, _ *http.Request) {
+	response := statusResponse{
+		Status:    filledIn,
+		Content:   content.GetStatus(),
+		Groups:    server.getGroupsStatus(),
+		Upstreams: server.upstreamHealth.get(),
+	}
+
+	statusCode := http.StatusOK
+	if !response.Content.Loaded || !response.Groups.Loaded {
+		response.Status = "unavailable"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	err := responses.Send(statusCode, writer, response)
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+		handleServerError(writer, err)
+	}
+}
+
+// refreshContent method re-fetches rule content and groups configuration
+// from content service immediately, without waiting for the periodic refresh.
+// Only organizations allowed to access internal rules can trigger it.
+func (server *HTTPServer) refreshContent(writer http.ResponseWriter, request *http.Request) {
+	err := server.checkInternalEndpointPermissions(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	log.Info().Msg("Refreshing rule content and groups on demand")
+
+	contentErr := content.UpdateContent(server.ServicesConfig)
+	content.UpdateLocalizedContent(server.ServicesConfig)
+	groupsErr := content.UpdateGroups(server.ServicesConfig, server.GroupsStore)
+	if contentErr != nil || groupsErr != nil {
+		handleServerError(writer, &ContentServiceUnavailableError{})
+		return
+	}
+
+	server.statusEndpoint(writer, request)
+}
+
+// getGroupsStatus method retrieves state of the groups configuration from
+// the groups store
+func (server *HTTPServer) getGroupsStatus() groupsStatus {
+	if server.GroupsStore == nil {
+		return groupsStatus{}
+	}
+
+	groupsConfig, err := server.GroupsStore.Groups()
+	status := groupsStatus{
+		Loaded:      groupsConfig != nil,
+		GroupsCount: len(groupsConfig),
+		LastRefresh: server.GroupsStore.LastRefresh(),
+		Stale:       server.GroupsStore.Stale(),
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+
+	return status
+}
+
 // fillInSmartProxyInfoParams method fills-in info parameters needed for /info
 // REST API endpoint for the Smart Proxy itself
 func (server *HTTPServer) fillInSmartProxyInfoParams() map[string]string {
@@ -382,8 +524,12 @@ func (server *HTTPServer) fillInSmartProxyInfoParams() map[string]string {
 		return m
 	}
 
-	// info params for Smart Proxy is filled-in properly
-	m := server.InfoParams
+	// info params for Smart Proxy is filled-in properly, they are copied,
+	// because the map is shared by concurrent requests
+	m := make(map[string]string, len(server.InfoParams)+1)
+	for key, value := range server.InfoParams {
+		m[key] = value
+	}
 	m["status"] = filledIn
 	return m
 }
@@ -403,7 +549,7 @@ func (server *HTTPServer) fillInContentServiceInfoParams() map[string]string {
 func (server *HTTPServer) fillInAggregatorInfoParams() map[string]string {
 	// try to access Insights Results Aggregator
 	url := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorBaseEndpoint,
 		infoEndpoint)
 	return infoFromService(url)
 }
@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -53,23 +54,20 @@ const (
 	selectorStr = "selector"
 )
 
-// getContentCheckInternal retrieves static content for the given ruleID and if the rule is internal,
-// checks if user has permissions to access it.
-func (server HTTPServer) getContentCheckInternal(ruleID ctypes.RuleID, request *http.Request) (
+// getContentCheckInternal retrieves static content for the given ruleID translated to
+// given language. Internal rule is reported as not found when the user has no permissions
+// to access it.
+func (server HTTPServer) getContentCheckInternal(ruleID ctypes.RuleID, request *http.Request, lang string) (
 	ruleContent *types.RuleWithContent,
 	err error,
 ) {
-	ruleContent, err = content.GetContentForRecommendation(ruleID)
+	ruleContent, err = content.GetLocalizedContentForRecommendation(ruleID, lang)
 	if err != nil {
 		return
 	}
 
-	// check for internal rule permissions
-	if internal := content.IsRuleInternal(ruleID); internal {
-		err = server.checkInternalRulePermissions(request)
-		if err != nil {
-			return
-		}
+	if err = server.redactInternalRule(request, content.IsRuleInternal(ruleID), ruleID); err != nil {
+		ruleContent = nil
 	}
 
 	return
@@ -85,12 +83,20 @@ func (server HTTPServer) getRuleWithGroups(
 	ruleGroups []groups.Group,
 	err error,
 ) {
-	ruleContent, err = server.getContentCheckInternal(ruleID, request)
+	ruleContent, err = server.getContentCheckInternal(ruleID, request, preferredContentLanguage(writer, request))
 	if err != nil {
 		log.Error().Msgf("error retrieving rule content for rule ID %v", ruleID)
 		return
 	}
 
+	renderHTML, err := readHTMLFormatParam(request)
+	if err != nil {
+		return
+	}
+	if renderHTML {
+		ruleContent = content.RenderRuleWithContentHTML(ruleContent)
+	}
+
 	// retrieve the latest groups configuration
 	ruleGroups, err = server.getGroupsConfig()
 	if err != nil {
@@ -111,6 +117,27 @@ func (server HTTPServer) getRecommendationContent(writer http.ResponseWriter, re
 		return
 	}
 
+	server.sendRecommendationContent(writer, request, ruleID)
+}
+
+// getRecommendationContentByErrorKey retrieves the same content as
+// getRecommendationContent, but rule module and error key are separate path
+// parameters, so the rule pages can be linked without encoding the composite
+// rule ID
+func (server HTTPServer) getRecommendationContentByErrorKey(writer http.ResponseWriter, request *http.Request) {
+	ruleID, err := readRuleIDAndErrorKey(request)
+	if err != nil {
+		log.Error().Err(err).Msgf("error retrieving rule ID from request")
+		handleServerError(writer, err)
+		return
+	}
+
+	server.sendRecommendationContent(writer, request, ruleID)
+}
+
+// sendRecommendationContent sends the static content for the given ruleID
+// tied with groups info. The content doesn't depend on any cluster report.
+func (server HTTPServer) sendRecommendationContent(writer http.ResponseWriter, request *http.Request, ruleID ctypes.RuleID) {
 	ruleContent, ruleGroups, err := server.getRuleWithGroups(writer, request, ruleID)
 	if err != nil {
 		log.Error().Err(err).Msgf("error retrieving rule content and groups for rule ID %v", ruleID)
@@ -118,20 +145,7 @@ func (server HTTPServer) getRecommendationContent(writer http.ResponseWriter, re
 		return
 	}
 
-	contentResponse := types.RecommendationContent{
-		// RuleID in rule.module|ERROR_KEY format
-		RuleSelector: ctypes.RuleSelector(ruleID),
-		Description:  ruleContent.Description,
-		Generic:      ruleContent.Generic,
-		Reason:       ruleContent.Reason,
-		Resolution:   ruleContent.Resolution,
-		MoreInfo:     ruleContent.MoreInfo,
-		TotalRisk:    uint8(ruleContent.TotalRisk),
-		Impact:       uint8(ruleContent.Impact),
-		Likelihood:   uint8(ruleContent.Likelihood),
-		PublishDate:  ruleContent.PublishDate,
-		Tags:         ruleContent.Tags,
-	}
+	contentResponse := recommendationContent(ruleID, ruleContent)
 
 	// prepare data structure for building response
 	responseContent := make(map[string]interface{})
@@ -250,12 +264,65 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 	}
 	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("getRecommendations start")
 
-	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID)
+	exportFormat, err := readExportFormatParam(request)
 	if err != nil {
-		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
 		handleServerError(writer, err)
 		return
 	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	recommendationList, err = server.readRecommendationList(writer, orgID, userID, impactingFlag, statusFilter)
+	if err != nil {
+		// server error has been handled already
+		return
+	}
+	log.Info().
+		Int(orgIDTag, int(orgID)).
+		Str(userIDTag, string(userID)).
+		Msgf("number of final recommendations: %d", len(recommendationList))
+
+	if exportFormat != "" {
+		sendExport(writer, exportFormat, fmt.Sprintf("recommendations-%d", orgID),
+			recommendationsExportTable(recommendationList))
+		return
+	}
+
+	resp := make(map[string]interface{})
+	resp["status"] = OkMsg
+	resp["recommendations"] = recommendationList
+
+	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf(
+		"getRecommendations took %s", time.Since(tStart),
+	)
+	err = responses.SendOK(writer, resp)
+	if err != nil {
+		log.Error().Err(err).Msgf(problemSendingResponseError)
+		handleServerError(writer, err)
+		return
+	}
+}
+
+// readRecommendationList reads all recommendations with a count of impacted
+// clusters of the organization. Errors are handled (sent to the client) by
+// this method.
+func (server HTTPServer) readRecommendationList(
+	writer http.ResponseWriter,
+	orgID types.OrgID,
+	userID types.UserID,
+	impactingFlag types.ImpactingFlag,
+	statusFilter []string,
+) ([]types.RecommendationListView, error) {
+	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return nil, err
+	}
 	clusterIDList := types.GetClusterNames(activeClustersInfo)
 
 	tStartImpacting := time.Now()
@@ -269,11 +336,12 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 			Int(orgIDTag, int(orgID)).
 			Msgf("problem getting impacting recommendations from aggregator for cluster list: %v", clusterIDList)
 
-		return
+		return nil, err
 	}
 	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf(
 		"getRecommendations get impacting recommendations from aggregator took %s", time.Since(tStartImpacting),
 	)
+	server.redactInternalRecommendations(orgID, impactingRecommendations)
 
 	// get a map of acknowledged rules
 	ackedRulesMap := server.getRuleAcksMap(orgID)
@@ -283,36 +351,20 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 	if err != nil {
 		log.Error().Err(err).Msg("problem getting user disabled rules for list of clusters")
 		// server error has been handled already
-		return
+		return nil, err
 	}
 
-	recommendationList, err = getFilteredRecommendationsList(
+	recommendationList, err := getFilteredRecommendationsList(
 		activeClustersInfo, impactingRecommendations, impactingFlag, ackedRulesMap, disabledClustersForRules,
 	)
 
 	if err != nil {
 		log.Error().Err(err).Msg("problem getting recommendation content")
 		handleServerError(writer, err)
-		return
+		return nil, err
 	}
-	log.Info().
-		Int(orgIDTag, int(orgID)).
-		Str(userIDTag, string(userID)).
-		Msgf("number of final recommendations: %d", len(recommendationList))
-
-	resp := make(map[string]interface{})
-	resp["status"] = OkMsg
-	resp["recommendations"] = recommendationList
 
-	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf(
-		"getRecommendations took %s", time.Since(tStart),
-	)
-	err = responses.SendOK(writer, resp)
-	if err != nil {
-		log.Error().Err(err).Msgf(problemSendingResponseError)
-		handleServerError(writer, err)
-		return
-	}
+	return recommendationList, nil
 }
 
 func (server HTTPServer) getRuleAcksMap(orgID types.OrgID) (
@@ -377,11 +429,19 @@ func (server HTTPServer) getClustersView(writer http.ResponseWriter, request *ht
 	}
 	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("getClustersView start")
 
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	clusterList, clusterRuleHits, ackedRulesMap, disabledRules := server.getClusterListAndUserData(
 		writer,
 		orgID,
 		userID,
+		statusFilter,
 	)
+	server.redactInternalClusterRecommendations(orgID, clusterRuleHits)
 
 	clusterViewResponse, err := matchClusterInfoAndUserData(
 		clusterList, clusterRuleHits, ackedRulesMap, disabledRules,
@@ -510,6 +570,9 @@ func matchClusterInfoAndUserData(
 				if ruleSeverity, found := recommendationSeverities[ruleID]; found {
 					clusterViewItem.HitsByTotalRisk[ruleSeverity]++
 					clusterViewItem.TotalHitCount++
+					if ruleSeverity > clusterViewItem.HighestTotalRisk {
+						clusterViewItem.HighestTotalRisk = ruleSeverity
+					}
 				} else {
 					// rule content is missing for this rule; mimicking behaviour of other apps such as OCM = skip rule
 					log.Error().Msgf("rule content was not found for following rule ID. Skipping rule %v.", ruleID)
@@ -717,7 +780,7 @@ func (server HTTPServer) getImpactingRecommendations(
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.RecommendationsListEndpoint,
 		orgID,
 		userID,
@@ -781,7 +844,7 @@ func (server HTTPServer) getClustersAndRecommendations(
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ClustersRecommendationsListEndpoint,
 		orgID,
 		userID,
@@ -845,14 +908,14 @@ func (server HTTPServer) getContentWithGroups(writer http.ResponseWriter, reques
 
 	var rules []types.RuleContentV2
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
+	if server.includeInternalRules(request) {
+		rules = allRules
+	} else {
 		for _, rule := range allRules {
 			if !content.IsRuleInternal(ctypes.RuleID(rule.Plugin.PythonModule)) {
 				rules = append(rules, rule)
 			}
 		}
-	} else {
-		rules = allRules
 	}
 
 	// retrieve the latest groups configuration
@@ -875,6 +938,107 @@ func (server HTTPServer) getContentWithGroups(writer http.ResponseWriter, reques
 	}
 }
 
+// searchRules returns rules with content matching free-text query together
+// with groups info. Internal rules are returned only to organizations allowed
+// to access them.
+func (server HTTPServer) searchRules(writer http.ResponseWriter, request *http.Request) {
+	query := strings.TrimSpace(request.URL.Query().Get(SearchQueryParam))
+	if query == "" {
+		handleServerError(writer, &RouterMissingParamError{paramName: SearchQueryParam})
+		return
+	}
+
+	foundRules, err := content.SearchRules(query)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	includeInternal := server.includeInternalRules(request)
+
+	ruleIDs := make([]string, 0, len(foundRules))
+	for ruleID, ruleContent := range foundRules {
+		if ruleContent.Internal && !includeInternal {
+			continue
+		}
+		ruleIDs = append(ruleIDs, string(ruleID))
+	}
+	sort.Strings(ruleIDs)
+
+	rules := make([]types.RecommendationContent, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		rules = append(rules, recommendationContent(ctypes.RuleID(ruleID), foundRules[ctypes.RuleID(ruleID)]))
+	}
+
+	// retrieve the latest groups configuration
+	ruleGroups, err := server.getGroupsConfig()
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	// prepare data structure for building response
+	responseContent := make(map[string]interface{})
+	responseContent["status"] = OkMsg
+	responseContent["groups"] = ruleGroups
+	responseContent["content"] = rules
+
+	// send response to client
+	err = responses.SendOK(writer, responseContent)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+}
+
+// ruleCatalogListContract describes fields of RuleCatalogEntry
+var ruleCatalogListContract = listContract{
+	sortable: []string{
+		"rule_id", "description", "total_risk", "impact", "likelihood", "publish_date",
+	},
+	filterable: []string{
+		"rule_id", "total_risk", "impact", "likelihood", "active", "osd_customer",
+		"tags", "groups",
+	},
+	defaultSort: "rule_id",
+}
+
+// getRuleCatalog returns page of all rules with their groups, tags, total
+// risk and publish status. Internal rules are returned only to organizations
+// allowed to access them.
+func (server HTTPServer) getRuleCatalog(writer http.ResponseWriter, request *http.Request) {
+	query, err := readListQuery(request, ruleCatalogListContract)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	// retrieve the latest groups configuration
+	ruleGroups, err := server.getGroupsConfig()
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	catalog, err := content.GetRuleCatalog(ruleGroups)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if !server.includeInternalRules(request) {
+		external := make([]types.RuleCatalogEntry, 0, len(catalog))
+		for _, rule := range catalog {
+			if !rule.Internal {
+				external = append(external, rule)
+			}
+		}
+		catalog = external
+	}
+
+	sendListEnvelope(writer, request, catalog, query)
+}
+
 // getImpactedClustersFromAggregator sends GET to aggregator with or without content
 // depending on the list of active clusters provided by the AMS client.
 func getImpactedClustersFromAggregator(
@@ -930,7 +1094,7 @@ func (server HTTPServer) getImpactedClusters(
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.RuleClusterDetailEndpoint,
 		selector,
 		orgID,
@@ -982,14 +1146,23 @@ func (server HTTPServer) getClustersDetailForRule(writer http.ResponseWriter, re
 	}
 
 	recommendation, err := content.GetContentForRecommendation(ctypes.RuleID(selector))
+	if err == nil {
+		err = server.redactInternalRule(request, recommendation.Internal, selector)
+	}
 	if err != nil {
 		// The given rule selector does not exit
 		handleServerError(writer, err)
 		return
 	}
 
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	// Get list of clusters for given organization
-	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID)
+	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID, statusFilter)
 	if err != nil {
 		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Error retrieving cluster IDs from AMS API. Will retrieve cluster list from aggregator.")
 		useAggregatorFallback = true
@@ -1049,7 +1222,7 @@ func (server *HTTPServer) getListOfDisabledClusters(
 
 	// rules disabled using v1 enable/disable endpoints include '.report' in the module
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ListOfDisabledClusters,
 		splitRuleID[0]+dotReport,
 		splitRuleID[1],
@@ -15,11 +15,13 @@
 package server_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/RedHatInsights/insights-content-service/groups"
 	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
 	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
@@ -63,8 +65,6 @@ func TestHTTPServer_SetRating(t *testing.T) {
 		&serverConfigJWT,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:             http.MethodPost,
 			Endpoint:           server.Rating,
@@ -191,7 +191,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk(t *testing.T) {
 		clusters[1], data.ClusterDisplayName2, disabledAt, justificationNote,
 	)
 
-	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -302,7 +302,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk_ImpactedClusterDi
 
 	expectedResponse = fmt.Sprintf(expectedResponse, clusters[0], data.ClusterDisplayName1, disabledAt, justificationNote)
 
-	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -417,7 +417,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk_DisabledClusterNo
 	// 2nd cluster is there
 	expectedResponse = fmt.Sprintf(expectedResponse, clusters[1], data.ClusterDisplayName2)
 
-	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -487,7 +487,7 @@ func TestHTTPServer_ClustersDetailEndpointAMSManagedClusters(t *testing.T) {
 				"status":"ok"
 			}
 			`
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		// cluster is managed, but rule is not == must not show as hitting
 		iou_helpers.AssertAPIRequest(
@@ -558,8 +558,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse400(t *testing.T) {
 		&serverConfigJWT,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersDetail,
@@ -627,8 +625,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse404(t *testing.T) {
 		&serverConfigJWT,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersDetail,
@@ -685,8 +681,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse500(t *testing.T) {
 		&serverConfigJWT,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:             http.MethodGet,
 			Endpoint:           server.ClustersDetail,
@@ -694,7 +688,12 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse500(t *testing.T) {
 			AuthorizationToken: goodJWTAuthBearer,
 		}, &helpers.APIResponse{
 			StatusCode: http.StatusInternalServerError,
-			Body:       aggregatorResponse,
+			Body: `{
+				"type": "urn:insights-results-smart-proxy:error:internal_server_error",
+				"title": "Internal server error",
+				"status": 500,
+				"code": "internal_server_error"
+			}`,
 		},
 	)
 }
@@ -725,7 +724,7 @@ func TestHTTPServer_GetSingleClusterInfo(t *testing.T) {
 		expectedResponse = fmt.Sprintf(expectedResponse, clusterInfoList[0].ID, clusterInfoList[0].DisplayName,
 			clusterInfoList[0].Managed, clusterInfoList[0].Status,
 		)
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -754,7 +753,7 @@ func TestHTTPServer_GetSingleClusterInfoClusterNotFound(t *testing.T) {
 			[]types.ClusterInfo{},
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -771,3 +770,157 @@ func TestHTTPServer_GetSingleClusterInfoClusterNotFound(t *testing.T) {
 		)
 	}, testTimeout)
 }
+
+func TestHTTPServer_ReportForListOfClustersWithClusterInfo(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	clusterList := data.AggregatorReportForClusterList.ClusterList
+
+	helpers.GockExpectAPIRequest(
+		t,
+		helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+		&helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportForListOfClustersEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, fmt.Sprintf("%v,%v,%v", clusterList[0], clusterList[1], clusterList[2])},
+		},
+		&helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       helpers.ToJSONString(data.AggregatorReportForClusterList),
+		},
+	)
+
+	amsClientMock := helpers.AMSClientWithOrgResults(
+		testdata.OrgID,
+		[]types.ClusterInfo{
+			{ID: clusterList[0], DisplayName: data.ClusterDisplayName1, Managed: true},
+		},
+	)
+
+	expectedResponse := types.ClusterReports{
+		ClusterReports: &data.AggregatorReportForClusterList,
+		ClustersInfo: map[types.ClusterName]types.ClusterInfo{
+			clusterList[0]: {ID: clusterList[0], DisplayName: data.ClusterDisplayName1, Managed: true},
+			clusterList[1]: {ID: clusterList[1], DisplayName: string(clusterList[1])},
+			clusterList[2]: {ID: clusterList[2], DisplayName: string(clusterList[2])},
+		},
+	}
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
+
+	iou_helpers.AssertAPIRequest(
+		t,
+		testServer,
+		serverConfigJWT.APIv1Prefix,
+		&helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ReportForListOfClustersEndpoint,
+			EndpointArgs:       []interface{}{fmt.Sprintf("%v,%v,%v", clusterList[0], clusterList[1], clusterList[2])},
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       helpers.ToJSONString(expectedResponse),
+		},
+	)
+}
+
+func TestClusterInfoCache(t *testing.T) {
+	info := types.ClusterInfo{ID: testdata.ClusterName, DisplayName: data.ClusterDisplayName1}
+
+	cache := server.NewClusterInfoCache(time.Minute)
+	_, found := server.ClusterInfoCacheGet(cache, testdata.ClusterName)
+	assert.False(t, found)
+
+	server.ClusterInfoCacheSet(cache, info)
+	cached, found := server.ClusterInfoCacheGet(cache, testdata.ClusterName)
+	assert.True(t, found)
+	assert.Equal(t, info, cached)
+
+	// zero TTL disables the cache
+	cache = server.NewClusterInfoCache(0)
+	server.ClusterInfoCacheSet(cache, info)
+	_, found = server.ClusterInfoCacheGet(cache, testdata.ClusterName)
+	assert.False(t, found)
+
+	// entries expire
+	cache = server.NewClusterInfoCache(time.Nanosecond)
+	server.ClusterInfoCacheSet(cache, info)
+	time.Sleep(time.Millisecond)
+	_, found = server.ClusterInfoCacheGet(cache, testdata.ClusterName)
+	assert.False(t, found)
+}
+
+// TestHTTPServer_RuleCatalog checks that the catalog of rules is filtered by
+// the group and paginated
+func TestHTTPServer_RuleCatalog(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	ruleWithContent, err := content.GetContentForRecommendation(testdata.Rule1CompositeID)
+	helpers.FailOnError(t, err)
+
+	groupsStore := content.NewGroupsStore()
+	groupsStore.SetGroups([]groups.Group{{Name: "rule1", Tags: ruleWithContent.Tags}})
+
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, groupsStore).Initialize()
+	recorder := serveJWTRequest(router, http.MethodGet,
+		serverConfigJWT.APIv2Prefix+server.RuleCatalogEndpoint+"?filter[groups]=rule1&limit=1000", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var envelope struct {
+		Data []types.RuleCatalogEntry `json:"data"`
+		Meta server.ListMeta          `json:"meta"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+
+	assert.Equal(t, len(envelope.Data), envelope.Meta.Count)
+	ruleIDs := make([]ctypes.RuleID, 0, len(envelope.Data))
+	for _, rule := range envelope.Data {
+		assert.Contains(t, rule.Groups, "rule1")
+		ruleIDs = append(ruleIDs, rule.RuleID)
+	}
+	assert.Contains(t, ruleIDs, testdata.Rule1CompositeID)
+
+	// unknown filter is rejected
+	recorder = serveJWTRequest(router, http.MethodGet,
+		serverConfigJWT.APIv2Prefix+server.RuleCatalogEndpoint+"?filter[reason]=x", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// TestHTTPServer_RuleContentBatch checks that content of all requested rules
+// is returned at once and unknown rules are listed separately
+func TestHTTPServer_RuleContentBatch(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	groupsStore := content.NewGroupsStore()
+	groupsStore.SetGroups([]groups.Group{{Name: "rule1"}})
+
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, groupsStore).Initialize()
+	body := fmt.Sprintf(`{"rules":[%q,%q,%q]}`,
+		testdata.Rule1CompositeID, testdata.Rule5CompositeID, testdata.Rule1CompositeID)
+	recorder := serveJWTRequest(router, http.MethodPost,
+		serverConfigJWT.APIv2Prefix+server.RuleContentBatchEndpoint, body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Content  []types.RecommendationContent `json:"content"`
+		NotFound []ctypes.RuleSelector         `json:"not_found"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	// duplicates are returned once
+	assert.Len(t, response.Content, 1)
+	assert.Equal(t, ctypes.RuleSelector(testdata.Rule1CompositeID), response.Content[0].RuleSelector)
+	assert.Equal(t, []ctypes.RuleSelector{ctypes.RuleSelector(testdata.Rule5CompositeID)}, response.NotFound)
+
+	for _, invalidBody := range []string{
+		`{"rules":[]}`,
+		`{"rules":["not a selector"]}`,
+		`{"rules":["rule.module|KEY"],"other":1}`,
+	} {
+		recorder = serveJWTRequest(router, http.MethodPost,
+			serverConfigJWT.APIv2Prefix+server.RuleContentBatchEndpoint, invalidBody)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, invalidBody)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var (
+	// clustersListContract describes fields of ClusterListView
+	clustersListContract = listContract{
+		sortable: []string{
+			"cluster_id", "cluster_name", "managed", "last_checked_at",
+			"total_hit_count", "highest_total_risk", "cluster_version",
+		},
+		filterable: []string{
+			"cluster_id", "cluster_name", "managed", "highest_total_risk", "cluster_version",
+		},
+		defaultSort: "cluster_name",
+	}
+
+	// recommendationsListContract describes fields of RecommendationListView
+	recommendationsListContract = listContract{
+		sortable: []string{
+			"rule_id", "description", "publish_date", "total_risk", "resolution_risk",
+			"impact", "likelihood", "impacted_clusters_count",
+		},
+		filterable: []string{
+			"rule_id", "total_risk", "resolution_risk", "impact", "likelihood",
+			"tags", "disabled",
+		},
+		defaultSort: "-total_risk,rule_id",
+	}
+)
+
+// sendListEnvelope sends the page of items requested by the list query
+func sendListEnvelope(writer http.ResponseWriter, request *http.Request, items interface{}, query listQuery) {
+	envelope, err := newListEnvelope(request, items, query)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to construct list response")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.Send(http.StatusOK, writer, envelope)
+	if err != nil {
+		log.Error().Err(err).Msg(problemSendingResponseError)
+		handleServerError(writer, err)
+	}
+}
+
+// readClustersView reads the list of clusters of the organization with
+// number of rules hitting them. Errors are handled (sent to the client) by
+// this method.
+func (server *HTTPServer) readClustersView(
+	writer http.ResponseWriter,
+	orgID types.OrgID,
+	userID types.UserID,
+	statusFilter []string,
+) ([]types.ClusterListView, error) {
+	var (
+		waitGroup               sync.WaitGroup
+		ackedRulesMap           map[types.RuleID]bool
+		disabledRulesPerCluster map[types.ClusterName][]types.RuleID
+	)
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	// the goroutines must not outlive the request
+	defer waitGroup.Wait()
+
+	clusterList, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return nil, err
+	}
+
+	clusterRuleHits, err := server.getClustersAndRecommendations(writer, orgID, userID, types.GetClusterNames(clusterList))
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem getting clusters and impacting recommendations")
+		// server error has been handled already
+		return nil, err
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRuleHits)
+
+	waitGroup.Wait()
+	clustersView, err := matchClusterInfoAndUserData(
+		clusterList, clusterRuleHits, ackedRulesMap, disabledRulesPerCluster,
+	)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem generating cluster list")
+		handleServerError(writer, err)
+		return nil, err
+	}
+
+	return clustersView, nil
+}
+
+// getClustersV3 returns page of clusters of the organization with number of
+// rules hitting them
+func (server *HTTPServer) getClustersV3(writer http.ResponseWriter, request *http.Request) {
+	query, err := readListQuery(request, clustersListContract)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	clustersView, err := server.readClustersView(writer, orgID, userID, statusFilter)
+	if err != nil {
+		// server error has been handled already
+		return
+	}
+
+	sendListEnvelope(writer, request, clustersView, query)
+}
+
+// getRecommendationsV3 returns page of recommendations with number of
+// impacted clusters of the organization
+func (server *HTTPServer) getRecommendationsV3(writer http.ResponseWriter, request *http.Request) {
+	query, err := readListQuery(request, recommendationsListContract)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	userID, orgID, impactingFlag, err := server.readParamsGetRecommendations(writer, request)
+	if err != nil {
+		// everything handled
+		return
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	recommendationList, err := server.readRecommendationList(writer, orgID, userID, impactingFlag, statusFilter)
+	if err != nil {
+		// server error has been handled already
+		return
+	}
+
+	sendListEnvelope(writer, request, recommendationList, query)
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// clusterListEnvelope is the response of API V3 clusters endpoint
+type clusterListEnvelope struct {
+	Data  []types.ClusterListView `json:"data"`
+	Meta  server.ListMeta         `json:"meta"`
+	Links server.ListLinks        `json:"links"`
+}
+
+// TestHTTPServer_ClustersEndpointV3 checks that the clusters are paginated
+// and wrapped in the list envelope
+func TestHTTPServer_ClustersEndpointV3(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := data.GetRandomClusterInfoList(2)
+		// clusters are sorted by their names by default; the list is shared
+		// with the AMS client mock, so it has to be sorted before use
+		sort.Slice(clusterInfoList, func(i, j int) bool {
+			return clusterInfoList[i].DisplayName < clusterInfoList[j].DisplayName
+		})
+
+		reqBody, _ := json.Marshal(types.GetClusterNames(clusterInfoList))
+
+		respBody := fmt.Sprintf(`{
+			"clusters":{
+				"%v": {"created_at": "%v", "recommendations": []},
+				"%v": {"created_at": "%v", "recommendations": []}
+			}
+		}`,
+			clusterInfoList[0].ID, testTimeStr,
+			clusterInfoList[1].ID, testTimeStr,
+		)
+
+		amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       respBody,
+			},
+		)
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
+
+		config := serverConfigJWT
+		config.APIv3Prefix = "/api/v3/"
+		config.APIv3SpecFile = "server/api/v3/openapi.json"
+
+		testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil)
+		iou_helpers.AssertAPIRequest(t, testServer, config.APIv3Prefix, &helpers.APIRequest{
+			Method:             http.MethodGet,
+			Endpoint:           server.ClustersEndpointV3 + "?" + server.LimitParam + "=1",
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			// checked by BodyChecker
+			Body: "{}",
+			BodyChecker: func(t testing.TB, _, got []byte) {
+				var envelope clusterListEnvelope
+				helpers.FailOnError(t, json.Unmarshal(got, &envelope))
+
+				assert.Len(t, envelope.Data, 1)
+				assert.Equal(t, clusterInfoList[0].ID, envelope.Data[0].ClusterID)
+				assert.Equal(t, server.ListMeta{Count: 2, Limit: 1, Offset: 0}, envelope.Meta)
+				assert.Equal(t, "/api/v3/clusters?limit=1&offset=1", envelope.Links.Next)
+			},
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ClustersEndpointV3BadSort checks that sorting by unknown
+// field is rejected
+func TestHTTPServer_ClustersEndpointV3BadSort(t *testing.T) {
+	config := serverConfigJWT
+	config.APIv3Prefix = "/api/v3/"
+
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(t, testServer, config.APIv3Prefix, &helpers.APIRequest{
+		Method:             http.MethodGet,
+		Endpoint:           server.ClustersEndpointV3 + "?" + server.SortParam + "=hits_by_total_risk",
+		AuthorizationToken: goodJWTAuthBearer,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusBadRequest,
+	})
+}
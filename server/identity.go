@@ -0,0 +1,68 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	types "github.com/RedHatInsights/insights-results-types"
+)
+
+// ContextWithIdentity returns the copy of the context carrying the identity
+// of the requester. Authentication middleware stores the identity once per
+// request, handlers read it by IdentityFromContext.
+func ContextWithIdentity(ctx context.Context, identity types.Identity) context.Context {
+	return context.WithValue(ctx, types.ContextKeyUser, identity)
+}
+
+// IdentityFromContext returns the identity of the requester stored by
+// authentication middleware. AuthenticationError is returned when there is
+// none, so all handlers report missing identity the same way.
+func IdentityFromContext(ctx context.Context) (types.Identity, error) {
+	value := ctx.Value(types.ContextKeyUser)
+	if value == nil {
+		return types.Identity{}, &AuthenticationError{errString: "token is not provided"}
+	}
+
+	identity, ok := value.(types.Identity)
+	if !ok {
+		return types.Identity{}, &AuthenticationError{errString: "contextKeyUser has wrong type"}
+	}
+	return identity, nil
+}
+
+// identityRolesKey is the context key of the roles of the associate read from
+// the authenticated x-rh-identity header
+type identityRolesKey struct{}
+
+// contextWithIdentityRoles returns the copy of the context carrying the roles
+// of the authenticated requester
+func contextWithIdentityRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, identityRolesKey{}, roles)
+}
+
+// requestIdentityRoles returns the roles of the requester stored by
+// authentication middleware. Requests authenticated by JWT or Bearer token
+// have no roles.
+func requestIdentityRoles(request *http.Request) []string {
+	roles, _ := request.Context().Value(identityRolesKey{}).([]string)
+	return roles
+}
+
+// requestIdentity returns the identity of the requester of the request
+func requestIdentity(request *http.Request) (types.Identity, error) {
+	return IdentityFromContext(request.Context())
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+)
+
+// identityCacheCapacity limits the number of identities that are remembered
+// at once, the least recently used ones are evicted first
+const identityCacheCapacity = 10000
+
+// newIdentityCache constructs the cache of identities decoded from
+// authentication headers. Nil is returned when the cache is disabled by zero
+// TTL.
+func newIdentityCache(ttl time.Duration) cache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.NewMemoryCache(identityCacheCapacity, ttl)
+}
+
+// identityCacheKey returns the key of identity authenticated by the request
+// headers. The headers are hashed, so the tokens are not kept in memory.
+// Empty key is returned when the cache is disabled or the request contains
+// no credentials.
+func (server *HTTPServer) identityCacheKey(r *http.Request) string {
+	if server.identityCache == nil {
+		return ""
+	}
+
+	header := r.Header.Get("x-rh-identity")
+	if server.Config.AuthType == "jwt" || header == "" {
+		header = r.Header.Get("Authorization")
+	}
+	if header == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(server.Config.AuthType + "\n" + header))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedIdentity is the authenticated identity together with the roles of
+// the requester
+type cachedIdentity struct {
+	identity types.Identity
+	roles    []string
+}
+
+// getCachedIdentity returns the identity and roles authenticated by the same
+// headers recently
+func (server *HTTPServer) getCachedIdentity(key string) (*types.Token, []string, bool) {
+	if key == "" {
+		return nil, nil, false
+	}
+	value, found := server.identityCache.Get(key)
+	if !found {
+		return nil, nil, false
+	}
+	cached := value.(cachedIdentity)
+	return &types.Token{Identity: cached.identity}, cached.roles, true
+}
+
+// setCachedIdentity remembers the authenticated identity and roles
+func (server *HTTPServer) setCachedIdentity(key string, identity types.Identity, roles []string) {
+	if key == "" {
+		return
+	}
+	server.identityCache.Set(key, cachedIdentity{identity: identity, roles: roles}, 0)
+}
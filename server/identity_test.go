@@ -0,0 +1,50 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestIdentityFromContext checks that the identity stored in the context is
+// returned by the accessor
+func TestIdentityFromContext(t *testing.T) {
+	expected := ctypes.Identity{
+		OrgID: ctypes.OrgID(42),
+		User:  ctypes.User{UserID: ctypes.UserID("user-1")},
+	}
+
+	identity, err := server.IdentityFromContext(server.ContextWithIdentity(context.Background(), expected))
+	helpers.FailOnError(t, err)
+	assert.Equal(t, expected, identity)
+}
+
+// TestIdentityFromContextMissing checks that authentication error is
+// returned when there is no identity in the context
+func TestIdentityFromContextMissing(t *testing.T) {
+	_, err := server.IdentityFromContext(context.Background())
+	assert.IsType(t, &server.AuthenticationError{}, err)
+
+	ctx := context.WithValue(context.Background(), ctypes.ContextKeyUser, "user-1")
+	_, err = server.IdentityFromContext(ctx)
+	assert.IsType(t, &server.AuthenticationError{}, err)
+}
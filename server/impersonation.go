@@ -0,0 +1,191 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+)
+
+const (
+	// ImpersonateOrgHeader contains the ID of organization the support
+	// engineer acts on behalf of
+	ImpersonateOrgHeader = "X-Impersonate-Org"
+
+	// auditOperationImpersonate is recorded for every impersonated request
+	auditOperationImpersonate = "impersonate"
+)
+
+// ImpersonationConfiguration represents configuration of the impersonation
+// mode. Users with any of the configured roles can act on behalf of other
+// organizations, impersonated requests are read-only.
+type ImpersonationConfiguration struct {
+	Enabled bool     `mapstructure:"enabled" toml:"enabled"`
+	Roles   []string `mapstructure:"roles" toml:"roles"`
+}
+
+// ImpersonationDeniedError error is used when the requester is not allowed
+// to act on behalf of other organization
+type ImpersonationDeniedError struct {
+	reason string
+}
+
+func (e *ImpersonationDeniedError) Error() string {
+	return fmt.Sprintf("Impersonation is denied: %v", e.reason)
+}
+
+// impersonatorKey is the context key of the identity of the support engineer
+// who made the impersonated request
+type impersonatorKey struct{}
+
+// associateRoles returns the roles of the associate read from decoded
+// x-rh-identity header
+func associateRoles(decoded []byte) []string {
+	var token struct {
+		Identity struct {
+			Associate struct {
+				Roles []string `json:"Role"`
+			} `json:"associate"`
+		} `json:"identity"`
+	}
+	if err := json.Unmarshal(decoded, &token); err != nil {
+		return nil
+	}
+	return token.Identity.Associate.Roles
+}
+
+// hasAnyRole returns true if the requester has any of the given roles. The
+// roles are taken from the identity checked by authentication middleware.
+func hasAnyRole(request *http.Request, roles []string) bool {
+	for _, role := range requestIdentityRoles(request) {
+		if collections.StringInSlice(role, roles) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSupportRole returns true if the requester has any of the roles allowed
+// to impersonate
+func (server *HTTPServer) hasSupportRole(request *http.Request) bool {
+	return hasAnyRole(request, server.Config.Impersonation.Roles)
+}
+
+// readImpersonatedOrgID validates the requester and the organization from
+// the impersonation header
+func (server *HTTPServer) readImpersonatedOrgID(request *http.Request, value string) (types.OrgID, error) {
+	if !server.Config.Impersonation.Enabled {
+		return 0, &ImpersonationDeniedError{reason: "impersonation is disabled"}
+	}
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return 0, &ImpersonationDeniedError{reason: "impersonated requests are read-only"}
+	}
+	if !server.hasSupportRole(request) {
+		return 0, &ImpersonationDeniedError{reason: "support role is required"}
+	}
+
+	orgID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || orgID == 0 {
+		return 0, &RouterParsingError{
+			paramName:  ImpersonateOrgHeader,
+			paramValue: value,
+			errString:  "positive integer is expected",
+		}
+	}
+	return types.OrgID(orgID), nil
+}
+
+// impersonationMiddleware swaps the organization of the requester for the
+// one from the impersonation header, so all handlers and calls of upstream
+// services use it. The original identity is kept in the context and every
+// impersonated request is recorded in the audit log. It needs to be used
+// after authentication, requests without the header are passed through.
+func (server *HTTPServer) impersonationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		value := request.Header.Get(ImpersonateOrgHeader)
+		if value == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		identity, err := requestIdentity(request)
+		if err != nil {
+			handleServerError(writer, err)
+			return
+		}
+		orgID, err := server.readImpersonatedOrgID(request, value)
+		if err != nil {
+			log.Warn().Err(err).Int(orgIDTag, int(identity.OrgID)).Str("user_id", string(identity.User.UserID)).
+				Msg("Impersonation refused")
+			handleServerError(writer, err)
+			return
+		}
+
+		impersonated := identity
+		impersonated.OrgID = orgID
+		ctx := context.WithValue(request.Context(), impersonatorKey{}, identity)
+		request = request.WithContext(ContextWithIdentity(ctx, impersonated))
+
+		log.Info().
+			Int(orgIDTag, int(orgID)).
+			Int("impersonator_org_id", int(identity.OrgID)).
+			Str("impersonator_user_id", string(identity.User.UserID)).
+			Str("path", request.URL.Path).
+			Msg("Impersonated request")
+
+		recorder := &statusRecorder{ResponseWriter: writer}
+		next.ServeHTTP(recorder, request)
+		server.recordImpersonation(request, &identity, orgID, recorder.statusCode)
+	})
+}
+
+// recordImpersonation stores the impersonated request into the audit log of
+// the impersonated organization
+func (server *HTTPServer) recordImpersonation(
+	request *http.Request, impersonator *types.Identity, orgID types.OrgID, statusCode int,
+) {
+	if server.auditLog == nil {
+		return
+	}
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	err := server.auditLog.Append(&audit.Record{
+		Timestamp: time.Now().UTC(),
+		OrgID:     orgID,
+		UserID:    impersonator.User.UserID,
+		Operation: auditOperationImpersonate,
+		Method:    request.Method,
+		Path:      request.URL.Path,
+		Params: map[string]string{
+			"impersonator_org_id": fmt.Sprint(impersonator.OrgID),
+		},
+		StatusCode: statusCode,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("operation", auditOperationImpersonate).Msg("Unable to store audit log record")
+	}
+}
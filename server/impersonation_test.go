@@ -0,0 +1,114 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// supportIdentity returns x-rh-identity header of associate with the role
+func supportIdentity(role string) string {
+	return base64.StdEncoding.EncodeToString([]byte(
+		`{"identity":{"account_number":"1","org_id":"1","user":{"user_id":"1"},"associate":{"Role":["` + role + `"]}}}`,
+	))
+}
+
+// TestImpersonation checks that only support engineers can act on behalf of
+// other organization and that their requests are recorded in audit log
+func TestImpersonation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	helpers.FailOnError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := audit.NewFileStore(filepath.Join(dir, "audit.log"))
+	helpers.FailOnError(t, err)
+
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	config.Impersonation = server.ImpersonationConfiguration{
+		Enabled: true,
+		Roles:   []string{"advisor-support"},
+	}
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	testServer.SetAuditLogStore(store)
+	router := testServer.Initialize()
+
+	for _, testCase := range []struct {
+		name           string
+		method         string
+		endpoint       string
+		role           string
+		orgID          string
+		expectedStatus int
+	}{
+		{"support engineer", http.MethodGet, server.MainEndpoint, "advisor-support", "42", http.StatusOK},
+		{"other role", http.MethodGet, server.MainEndpoint, "other", "42", http.StatusForbidden},
+		{"write request", http.MethodPost, server.RuleContentBatchEndpoint, "advisor-support", "42", http.StatusForbidden},
+		{"invalid organization", http.MethodGet, server.MainEndpoint, "advisor-support", "x", http.StatusBadRequest},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(testCase.method, config.APIv2Prefix+testCase.endpoint, http.NoBody)
+			request.Header.Set("x-rh-identity", supportIdentity(testCase.role))
+			request.Header.Set(server.ImpersonateOrgHeader, testCase.orgID)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+
+	// only the allowed request is recorded, in the log of impersonated
+	// organization
+	records, err := store.Query(audit.Query{OrgID: 42})
+	helpers.FailOnError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "impersonate", records[0].Operation)
+	assert.Equal(t, ctypes.UserID("1"), records[0].UserID)
+	assert.Equal(t, "1", records[0].Params["impersonator_org_id"])
+	assert.Equal(t, http.StatusOK, records[0].StatusCode)
+}
+
+// TestImpersonationForgedIdentityHeader checks that the roles are not read
+// from x-rh-identity header sent along with JWT token, which is the header
+// used for authentication then
+func TestImpersonationForgedIdentityHeader(t *testing.T) {
+	config := serverConfigJWT
+	config.Impersonation = server.ImpersonationConfiguration{
+		Enabled: true,
+		Roles:   []string{"advisor-support"},
+	}
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	request := httptest.NewRequest(http.MethodGet, config.APIv2Prefix+server.MainEndpoint, http.NoBody)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+	request.Header.Set("x-rh-identity", supportIdentity("advisor-support"))
+	request.Header.Set(server.ImpersonateOrgHeader, "42")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), server.ErrorCodeImpersonationDenied)
+}
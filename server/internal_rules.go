@@ -0,0 +1,132 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+// Internal rules are visible to the organizations listed in
+// InternalRulesOrganizations only. For other organizations they are redacted
+// from all responses: list endpoints and reports skip them and detail
+// endpoints respond as if the rule did not exist, so even the existence of
+// internal rules is not disclosed. All handlers use the methods below instead
+// of checking the organization on their own.
+
+// internalRulesAllowedForOrg returns whether the organization can access
+// internal rules. All organizations can access them when the internal rules
+// organizations are not enabled.
+func (server HTTPServer) internalRulesAllowedForOrg(orgID types.OrgID) bool {
+	if !server.Config.EnableInternalRulesOrganizations || !server.Config.Auth {
+		return true
+	}
+
+	for _, allowedID := range server.Config.InternalRulesOrganizations {
+		if orgID == allowedID {
+			log.Debug().Msgf("Organization %v is allowed access to internal rules", orgID)
+			return true
+		}
+	}
+
+	log.Debug().Msgf("Internal rules are redacted for organization %v", orgID)
+	return false
+}
+
+// internalRulesAllowed returns whether the organization of the current user
+// can access internal rules. Error is returned when the organization can't
+// be read from the request.
+func (server HTTPServer) internalRulesAllowed(request *http.Request) (bool, error) {
+	if !server.Config.EnableInternalRulesOrganizations || !server.Config.Auth {
+		return true, nil
+	}
+
+	identity, err := requestIdentity(request)
+	if err != nil {
+		log.Error().Err(err).Msg("error retrieving org_id from token")
+		return false, err
+	}
+
+	return server.internalRulesAllowedForOrg(identity.OrgID), nil
+}
+
+// includeInternalRules returns whether internal rules are included in lists
+// and reports returned to the current user
+func (server HTTPServer) includeInternalRules(request *http.Request) bool {
+	allowed, err := server.internalRulesAllowed(request)
+	return err == nil && allowed
+}
+
+// redactInternalRule returns an error when the rule is internal and the
+// current user can't access it. Not found error for the item is returned in
+// such case, error reading the organization is returned as is.
+func (server HTTPServer) redactInternalRule(request *http.Request, internal bool, itemID interface{}) error {
+	if !internal {
+		return nil
+	}
+
+	allowed, err := server.internalRulesAllowed(request)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &utypes.ItemNotFoundError{ItemID: itemID}
+	}
+	return nil
+}
+
+// redactInternalRecommendations removes internal rules from the
+// recommendations impacting clusters of the organization, unless the
+// organization can access them
+func (server HTTPServer) redactInternalRecommendations(
+	orgID types.OrgID, recommendations types.RecommendationImpactedClusters,
+) {
+	if server.internalRulesAllowedForOrg(orgID) {
+		return
+	}
+
+	for ruleID := range recommendations {
+		if content.IsRuleInternal(ruleID) {
+			delete(recommendations, ruleID)
+		}
+	}
+}
+
+// redactInternalClusterRecommendations removes internal rules from the
+// recommendations hitting the clusters of the organization, unless the
+// organization can access them
+func (server HTTPServer) redactInternalClusterRecommendations(
+	orgID types.OrgID, clusterRecommendations types.ClusterRecommendationMap,
+) {
+	if server.internalRulesAllowedForOrg(orgID) {
+		return
+	}
+
+	for clusterID, recommendations := range clusterRecommendations {
+		external := make([]types.RuleID, 0, len(recommendations.Recommendations))
+		for _, ruleID := range recommendations.Recommendations {
+			if !content.IsRuleInternal(ruleID) {
+				external = append(external, ruleID)
+			}
+		}
+		recommendations.Recommendations = external
+		clusterRecommendations[clusterID] = recommendations
+	}
+}
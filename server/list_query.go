@@ -0,0 +1,362 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+)
+
+const (
+	// LimitParam is the maximal number of items returned by list endpoint
+	LimitParam = "limit"
+	// OffsetParam is the number of items skipped by list endpoint
+	OffsetParam = "offset"
+	// SortParam is the comma separated list of fields used to sort the
+	// items. Field prefixed by "-" sorts in descending order.
+	SortParam = "sort"
+	// FilterParamPrefix starts the name of parameters used to filter the
+	// items, for example filter[total_risk]=3,4
+	FilterParamPrefix = "filter["
+
+	// defaultListLimit is used when the limit parameter is not provided
+	defaultListLimit = 50
+	// maxListLimit is the upper bound of the limit parameter
+	maxListLimit = 1000
+
+	descendingSortPrefix = "-"
+	filterParamSuffix    = "]"
+	listValuesSeparator  = ","
+)
+
+// listContract describes fields of items returned by list endpoint that can
+// be used for sorting and filtering
+type listContract struct {
+	sortable    []string
+	filterable  []string
+	defaultSort string
+}
+
+// sortField is single field the items are sorted by
+type sortField struct {
+	name       string
+	descending bool
+}
+
+// listQuery contains pagination, sorting and filtering requested by client
+type listQuery struct {
+	limit   int
+	offset  int
+	sort    []sortField
+	filters map[string][]string
+}
+
+// ListMeta contains information about the whole list of items
+type ListMeta struct {
+	Count  int `json:"count"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// ListLinks contains links to other pages of the list
+type ListLinks struct {
+	First    string `json:"first"`
+	Previous string `json:"previous,omitempty"`
+	Next     string `json:"next,omitempty"`
+	Last     string `json:"last"`
+}
+
+// ListEnvelope is the response of all API v3 list endpoints
+type ListEnvelope struct {
+	Data  []interface{} `json:"data"`
+	Meta  ListMeta      `json:"meta"`
+	Links ListLinks     `json:"links"`
+}
+
+// readNonNegativeIntParam reads optional integer query parameter
+func readNonNegativeIntParam(request *http.Request, paramName string, defaultValue int) (int, error) {
+	value := request.URL.Query().Get(paramName)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, &RouterParsingError{
+			paramName:  paramName,
+			paramValue: value,
+			errString:  "non-negative integer is expected",
+		}
+	}
+	return number, nil
+}
+
+// readListQuery reads pagination, sorting and filtering parameters and checks
+// them against the contract of the endpoint
+func readListQuery(request *http.Request, contract listContract) (query listQuery, err error) {
+	query.limit, err = readNonNegativeIntParam(request, LimitParam, defaultListLimit)
+	if err != nil {
+		return
+	}
+	if query.limit == 0 || query.limit > maxListLimit {
+		err = &RouterParsingError{
+			paramName:  LimitParam,
+			paramValue: query.limit,
+			errString:  fmt.Sprintf("value between 1 and %d is expected", maxListLimit),
+		}
+		return
+	}
+
+	query.offset, err = readNonNegativeIntParam(request, OffsetParam, 0)
+	if err != nil {
+		return
+	}
+
+	sortValue := request.URL.Query().Get(SortParam)
+	if sortValue == "" {
+		sortValue = contract.defaultSort
+	}
+	for _, name := range splitListValues(sortValue) {
+		field := sortField{
+			name:       strings.TrimPrefix(name, descendingSortPrefix),
+			descending: strings.HasPrefix(name, descendingSortPrefix),
+		}
+		if !collections.StringInSlice(field.name, contract.sortable) {
+			err = &RouterParsingError{
+				paramName:  SortParam,
+				paramValue: sortValue,
+				errString:  fmt.Sprintf("sorting by '%s' is not supported", field.name),
+			}
+			return
+		}
+		query.sort = append(query.sort, field)
+	}
+
+	query.filters = make(map[string][]string)
+	for param, values := range request.URL.Query() {
+		if !strings.HasPrefix(param, FilterParamPrefix) || !strings.HasSuffix(param, filterParamSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(param, FilterParamPrefix), filterParamSuffix)
+		if !collections.StringInSlice(name, contract.filterable) {
+			err = &RouterParsingError{
+				paramName:  param,
+				paramValue: strings.Join(values, listValuesSeparator),
+				errString:  fmt.Sprintf("filtering by '%s' is not supported", name),
+			}
+			return
+		}
+		for _, value := range values {
+			query.filters[name] = append(query.filters[name], splitListValues(value)...)
+		}
+	}
+
+	return
+}
+
+// splitListValues splits comma separated values, empty values are skipped
+func splitListValues(value string) (values []string) {
+	for _, item := range strings.Split(value, listValuesSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return
+}
+
+// toJSONObjects converts slice of items to their JSON representation, so
+// they can be filtered and sorted by names of their JSON fields
+func toJSONObjects(items interface{}) ([]map[string]interface{}, error) {
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// matchesFilter checks if the value of field matches any of the filter
+// values. Items of array fields (like tags) are matched separately.
+func matchesFilter(value interface{}, filterValues []string) bool {
+	if array, ok := value.([]interface{}); ok {
+		for _, item := range array {
+			if matchesFilter(item, filterValues) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if value == nil {
+		return false
+	}
+	for _, filterValue := range filterValues {
+		if strings.EqualFold(fmt.Sprint(value), filterValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareJSONValues returns negative number, zero or positive number when the
+// first value is less than, equal to or greater than the second one
+func compareJSONValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if numberA, ok := a.(json.Number); ok {
+		if numberB, ok := b.(json.Number); ok {
+			floatA, errA := numberA.Float64()
+			floatB, errB := numberB.Float64()
+			if errA == nil && errB == nil {
+				switch {
+				case floatA < floatB:
+					return -1
+				case floatA > floatB:
+					return 1
+				default:
+					return 0
+				}
+			}
+		}
+	}
+
+	if boolA, ok := a.(bool); ok {
+		if boolB, ok := b.(bool); ok {
+			switch {
+			case boolA == boolB:
+				return 0
+			case boolB:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// applyListQuery filters, sorts and paginates the items. Total number of
+// items matching the filters is returned together with the requested page.
+func applyListQuery(items interface{}, query listQuery) (page []interface{}, total int, err error) {
+	objects, err := toJSONObjects(items)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filtered := objects[:0]
+	for _, object := range objects {
+		matches := true
+		for field, values := range query.filters {
+			if !matchesFilter(object[field], values) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, object)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		for _, field := range query.sort {
+			result := compareJSONValues(filtered[i][field.name], filtered[j][field.name])
+			if result == 0 {
+				continue
+			}
+			if field.descending {
+				return result > 0
+			}
+			return result < 0
+		}
+		return false
+	})
+
+	total = len(filtered)
+	page = []interface{}{}
+	for i := query.offset; i < total && i < query.offset+query.limit; i++ {
+		page = append(page, filtered[i])
+	}
+	return page, total, nil
+}
+
+// listPageURL returns URL of the list page starting at given offset
+func listPageURL(request *http.Request, offset, limit int) string {
+	pageURL := *request.URL
+	query := pageURL.Query()
+	query.Set(OffsetParam, strconv.Itoa(offset))
+	query.Set(LimitParam, strconv.Itoa(limit))
+	pageURL.RawQuery = query.Encode()
+	return pageURL.RequestURI()
+}
+
+// newListEnvelope constructs the response containing requested page of items
+func newListEnvelope(request *http.Request, items interface{}, query listQuery) (ListEnvelope, error) {
+	page, total, err := applyListQuery(items, query)
+	if err != nil {
+		return ListEnvelope{}, err
+	}
+
+	lastOffset := 0
+	if total > 0 {
+		lastOffset = (total - 1) / query.limit * query.limit
+	}
+
+	envelope := ListEnvelope{
+		Data: page,
+		Meta: ListMeta{
+			Count:  total,
+			Limit:  query.limit,
+			Offset: query.offset,
+		},
+		Links: ListLinks{
+			First: listPageURL(request, 0, query.limit),
+			Last:  listPageURL(request, lastOffset, query.limit),
+		},
+	}
+
+	if query.offset > 0 {
+		previous := query.offset - query.limit
+		if previous < 0 {
+			previous = 0
+		}
+		envelope.Links.Previous = listPageURL(request, previous, query.limit)
+	}
+	if query.offset+query.limit < total {
+		envelope.Links.Next = listPageURL(request, query.offset+query.limit, query.limit)
+	}
+
+	return envelope, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var listQueryRecommendations = []types.RecommendationListView{
+	{RuleID: "rule.a|KEY", TotalRisk: 1, ImpactedClustersCnt: 5, Tags: []string{"security"}},
+	{RuleID: "rule.b|KEY", TotalRisk: 3, ImpactedClustersCnt: 1, Tags: []string{"performance"}},
+	{RuleID: "rule.c|KEY", TotalRisk: 4, ImpactedClustersCnt: 2, Tags: []string{"security"}},
+	{RuleID: "rule.d|KEY", TotalRisk: 3, ImpactedClustersCnt: 7, Tags: []string{"security", "performance"}},
+}
+
+func listQueryRuleIDs(envelope server.ListEnvelope) (ruleIDs []interface{}) {
+	for _, item := range envelope.Data {
+		ruleIDs = append(ruleIDs, item.(map[string]interface{})["rule_id"])
+	}
+	return
+}
+
+// TestListEnvelopeDefaults checks the default sorting and pagination
+func TestListEnvelopeDefaults(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/v3/recommendations", nil)
+
+	query, err := server.ReadListQuery(request, server.RecommendationsListContract)
+	assert.NoError(t, err)
+
+	envelope, err := server.NewListEnvelope(request, listQueryRecommendations, query)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"rule.c|KEY", "rule.b|KEY", "rule.d|KEY", "rule.a|KEY"}, listQueryRuleIDs(envelope))
+	assert.Equal(t, server.ListMeta{Count: 4, Limit: 50, Offset: 0}, envelope.Meta)
+	assert.Equal(t, server.ListLinks{
+		First: "/api/v3/recommendations?limit=50&offset=0",
+		Last:  "/api/v3/recommendations?limit=50&offset=0",
+	}, envelope.Links)
+}
+
+// TestListEnvelopeFilterSortPage checks that filters, sorting and pagination
+// are applied together
+func TestListEnvelopeFilterSortPage(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet,
+		"/api/v3/recommendations?filter[tags]=security&sort=-impacted_clusters_count&limit=1&offset=1", nil)
+
+	query, err := server.ReadListQuery(request, server.RecommendationsListContract)
+	assert.NoError(t, err)
+
+	envelope, err := server.NewListEnvelope(request, listQueryRecommendations, query)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"rule.a|KEY"}, listQueryRuleIDs(envelope))
+	assert.Equal(t, server.ListMeta{Count: 3, Limit: 1, Offset: 1}, envelope.Meta)
+	assert.Contains(t, envelope.Links.Previous, "offset=0")
+	assert.Contains(t, envelope.Links.Next, "offset=2")
+	assert.Contains(t, envelope.Links.Last, "offset=2")
+}
+
+// TestReadListQueryInvalidParams checks that unsupported parameters are
+// rejected
+func TestReadListQueryInvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"limit=0",
+		"limit=1001",
+		"offset=-1",
+		"offset=x",
+		"sort=generic",
+		"filter[description]=x",
+	} {
+		t.Run(query, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v3/recommendations?"+query, nil)
+			_, err := server.ReadListQuery(request, server.RecommendationsListContract)
+			assert.IsType(t, &server.RouterParsingError{}, err)
+		})
+	}
+}
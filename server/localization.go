@@ -0,0 +1,35 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+const (
+	acceptLanguageHeader  = "Accept-Language"
+	contentLanguageHeader = "Content-Language"
+)
+
+// preferredContentLanguage returns language of rule content that best
+// matches the Accept-Language header of the request and sets the
+// Content-Language header of the response accordingly
+func preferredContentLanguage(writer http.ResponseWriter, request *http.Request) string {
+	lang := content.PreferredLanguage(request.Header.Get(acceptLanguageHeader))
+	writer.Header().Set(contentLanguageHeader, lang)
+	return lang
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// translatedContent contains translation of the first rule of the test
+// content directory
+var translatedContent = ctypes.RuleContentDirectory{
+	Rules: map[string]ctypes.RuleContent{
+		"rule1": {
+			Plugin: ctypes.RulePluginInfo{PythonModule: string(testdata.Rule1ID)},
+			ErrorKeys: map[string]ctypes.RuleErrorKeyContent{
+				string(testdata.ErrorKey1): {
+					Metadata: ctypes.ErrorKeyMetadata{Description: "translated description"},
+				},
+			},
+		},
+	},
+}
+
+// TestReportEndpointLocalized checks that rule content in cluster report is
+// translated according to Accept-Language header
+func TestReportEndpointLocalized(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+	content.SetLocalizedContent("ja", &translatedContent)
+
+	for _, lang := range []string{"ja", "en"} {
+		helpers.RunTestWithTimeout(t, func(t testing.TB) {
+			defer helpers.CleanAfterGock(t)
+			helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     ira_server.ReportEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       testdata.Report3RulesExpectedResponse,
+			})
+			expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+			router := helpers.CreateHTTPServer(&helpers.DefaultServerConfig, nil, nil, nil).Initialize()
+			endpoint := strings.Replace(server.ReportEndpoint, "{cluster}", string(testdata.ClusterName), 1)
+			request := httptest.NewRequest(http.MethodGet, helpers.DefaultServerConfig.APIv1Prefix+endpoint, http.NoBody)
+			request.Header.Set("Authorization", goodJWTAuthBearer)
+			request.Header.Set("Accept-Language", lang)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, lang, recorder.Header().Get("Content-Language"))
+			assert.Equal(t, lang == "ja", strings.Contains(recorder.Body.String(), "translated description"))
+		}, testTimeout)
+	}
+}
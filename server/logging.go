@@ -0,0 +1,69 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LogSamplingConfiguration represents configuration of log sampling for
+// messages that are emitted for every handled request and which tend to
+// overwhelm the log aggregation stack. Sampling rate N means that only every
+// N-th message is written into the log. Values 0 and 1 disable the sampling.
+type LogSamplingConfiguration struct {
+	ProxiedRequests uint32 `mapstructure:"proxied_requests" toml:"proxied_requests"`
+	RuleHits        uint32 `mapstructure:"rule_hits" toml:"rule_hits"`
+}
+
+var (
+	// proxiedRequestsSampler is used for "Handling response as a proxy"
+	// messages, nil means that sampling is disabled
+	proxiedRequestsSampler zerolog.Sampler
+
+	// ruleHitsSampler is used for messages with list of rule hits found in
+	// aggregator reports, nil means that sampling is disabled
+	ruleHitsSampler zerolog.Sampler
+)
+
+// SetLogSampling function configures sampling for the noisy log messages. It
+// needs to be called after the global logger is initialized.
+func SetLogSampling(config LogSamplingConfiguration) {
+	proxiedRequestsSampler = newBasicSampler(config.ProxiedRequests)
+	ruleHitsSampler = newBasicSampler(config.RuleHits)
+
+	log.Info().
+		Uint32("proxied_requests", config.ProxiedRequests).
+		Uint32("rule_hits", config.RuleHits).
+		Msg("Log sampling configured")
+}
+
+// newBasicSampler returns sampler that lets through every n-th message or nil
+// if the sampling should be disabled
+func newBasicSampler(n uint32) zerolog.Sampler {
+	if n <= 1 {
+		return nil
+	}
+	return &zerolog.BasicSampler{N: n}
+}
+
+// sampledLogger returns the global logger with given sampler applied
+func sampledLogger(sampler zerolog.Sampler) *zerolog.Logger {
+	if sampler == nil {
+		return &log.Logger
+	}
+	logger := log.Sample(sampler)
+	return &logger
+}
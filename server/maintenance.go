@@ -0,0 +1,167 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultMaintenanceMessage is used as the detail of 503 responses when no
+// message is configured
+const defaultMaintenanceMessage = "The service is under maintenance, please try again later"
+
+// MaintenanceConfiguration represents configuration of maintenance mode
+// used when the service starts. It can be changed at runtime by the
+// internal maintenance endpoint.
+type MaintenanceConfiguration struct {
+	Enabled    bool          `mapstructure:"enabled" toml:"enabled"`
+	Message    string        `mapstructure:"message" toml:"message"`
+	RetryAfter time.Duration `mapstructure:"retry_after" toml:"retry_after"`
+}
+
+// MaintenanceState is the current state of maintenance mode as returned and
+// accepted by the internal maintenance endpoint
+type MaintenanceState struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+	// RetryAfter is the number of seconds sent in Retry-After header,
+	// zero means the header is not sent
+	RetryAfter int `json:"retry_after"`
+}
+
+// maintenanceMode holds the maintenance state shared by all requests
+type maintenanceMode struct {
+	mutex sync.RWMutex
+	state MaintenanceState
+}
+
+// newMaintenanceMode constructs the maintenance mode in the configured state
+func newMaintenanceMode(config MaintenanceConfiguration) *maintenanceMode {
+	return &maintenanceMode{state: MaintenanceState{
+		Enabled:    config.Enabled,
+		Message:    config.Message,
+		RetryAfter: int(config.RetryAfter / time.Second),
+	}}
+}
+
+// get returns the current state
+func (mode *maintenanceMode) get() MaintenanceState {
+	mode.mutex.RLock()
+	defer mode.mutex.RUnlock()
+
+	return mode.state
+}
+
+// set replaces the current state
+func (mode *maintenanceMode) set(state MaintenanceState) {
+	mode.mutex.Lock()
+	defer mode.mutex.Unlock()
+
+	mode.state = state
+}
+
+// maintenanceMiddleware responds with 503 to all requests except the ones for
+// exempted URLs while the maintenance mode is enabled
+func (server *HTTPServer) maintenanceMiddleware(next http.Handler, exemptURLs []string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		state := server.maintenance.get()
+		if !state.Enabled || collections.StringInSlice(request.URL.Path, exemptURLs) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		message := state.Message
+		if message == "" {
+			message = defaultMaintenanceMessage
+		}
+		if state.RetryAfter > 0 {
+			writer.Header().Set("Retry-After", strconv.Itoa(state.RetryAfter))
+		}
+
+		// not sent through handleServerError, maintenance is not an error
+		// that should be reported
+		if err := sendProblem(writer, newProblem(ErrorCodeMaintenance, message)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+	})
+}
+
+// maintenanceExemptURLs returns URLs of health endpoints and of the
+// maintenance endpoint itself for all API versions
+func (server *HTTPServer) maintenanceExemptURLs() []string {
+	var urls []string
+	for _, prefix := range []string{server.Config.APIv1Prefix, server.Config.APIv2Prefix, server.Config.APIv3Prefix} {
+		if prefix == "" {
+			continue
+		}
+		urls = append(urls,
+			prefix+InfoEndpoint,
+			prefix+StatusEndpoint,
+			prefix+MetricsEndpoint,
+			prefix+MaintenanceEndpoint,
+		)
+	}
+	return urls
+}
+
+// getMaintenance method returns the current state of maintenance mode
+func (server *HTTPServer) getMaintenance(writer http.ResponseWriter, request *http.Request) {
+	if err := server.checkInternalEndpointPermissions(request); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	err := responses.SendOK(writer, responses.BuildOkResponseWithData("maintenance", server.maintenance.get()))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// setMaintenance method turns the maintenance mode on or off
+func (server *HTTPServer) setMaintenance(writer http.ResponseWriter, request *http.Request) {
+	if err := server.checkInternalEndpointPermissions(request); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	var state MaintenanceState
+	if err := json.NewDecoder(request.Body).Decode(&state); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+	if state.RetryAfter < 0 {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, "retry_after must not be negative")); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	server.maintenance.set(state)
+	log.Warn().Bool("enabled", state.Enabled).Msg("Maintenance mode changed")
+
+	err := responses.SendOK(writer, responses.BuildOkResponseWithData("maintenance", state))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// setMaintenance sends the new maintenance state to the internal endpoint
+func setMaintenance(router http.Handler, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodPut, serverConfigJWT.APIv2Prefix+server.MaintenanceEndpoint, strings.NewReader(body),
+	)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestMaintenanceModeConfigured checks that all endpoints except the health
+// ones respond with 503 when maintenance mode is enabled in configuration
+func TestMaintenanceModeConfigured(t *testing.T) {
+	config := serverConfigJWT
+	config.Maintenance = server.MaintenanceConfiguration{
+		Enabled:    true,
+		Message:    "Database upgrade in progress",
+		RetryAfter: 2 * time.Minute,
+	}
+
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	recorder := serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "120", recorder.Header().Get("Retry-After"))
+	assert.Contains(t, recorder.Body.String(), `"code":"maintenance"`)
+	assert.Contains(t, recorder.Body.String(), `"detail":"Database upgrade in progress"`)
+
+	recorder = serveV2Request(router, server.InfoEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// TestMaintenanceModeToggle checks that maintenance mode can be turned on and
+// off at runtime
+func TestMaintenanceModeToggle(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder := setMaintenance(router, `{"enabled": true}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Retry-After"))
+
+	recorder = serveV2Request(router, server.MaintenanceEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"maintenance": {"enabled": true, "message": "", "retry_after": 0}
+	}`, recorder.Body.String())
+
+	recorder = setMaintenance(router, `{"enabled": false}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// TestMaintenanceModeToggleForbidden checks that only internal organizations
+// can change maintenance mode
+func TestMaintenanceModeToggleForbidden(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigInternalOrganizations2, nil, nil, nil).Initialize()
+
+	recorder := setMaintenance(router, `{"enabled": true}`)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
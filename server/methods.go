@@ -0,0 +1,91 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// corsRequestMethodHeader is sent by browsers in CORS preflight requests
+const corsRequestMethodHeader = "Access-Control-Request-Method"
+
+// routedMethods are the methods looked up in the router when the methods
+// allowed for the endpoint are listed
+var routedMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// routeMatches returns true when the router has a route for the URL of the
+// request and given method
+func routeMatches(router *mux.Router, request *http.Request, method string) bool {
+	probe := request.Clone(request.Context())
+	probe.Method = method
+
+	var match mux.RouteMatch
+	return router.Match(probe, &match) && match.MatchErr == nil
+}
+
+// allowedMethods returns methods of the routes registered for the URL of the
+// request. HEAD and OPTIONS are allowed for all endpoints with GET method.
+func allowedMethods(router *mux.Router, request *http.Request) []string {
+	var methods []string
+	for _, method := range routedMethods {
+		if routeMatches(router, request, method) {
+			methods = append(methods, method)
+			if method == http.MethodGet {
+				methods = append(methods, http.MethodHead)
+			}
+		}
+	}
+	if len(methods) > 0 {
+		methods = append(methods, http.MethodOptions)
+	}
+	return methods
+}
+
+// methodsHandler serves HEAD and OPTIONS requests for all endpoints. HEAD
+// requests are served by GET handlers, net/http server sends the headers and
+// the status code only. OPTIONS requests get the list of allowed methods in
+// Allow header. CORS preflight requests are left to the CORS middleware when
+// it is enabled.
+func (server *HTTPServer) methodsHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodHead:
+			if !routeMatches(router, request, http.MethodHead) && routeMatches(router, request, http.MethodGet) {
+				request = request.Clone(request.Context())
+				request.Method = http.MethodGet
+			}
+		case http.MethodOptions:
+			if server.Config.EnableCORS && request.Header.Get(corsRequestMethodHeader) != "" {
+				break
+			}
+			if methods := allowedMethods(router, request); len(methods) > 0 {
+				writer.Header().Set("Allow", strings.Join(methods, ", "))
+				writer.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		router.ServeHTTP(writer, request)
+	})
+}
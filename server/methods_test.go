@@ -0,0 +1,63 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// serveV2RequestWithMethod sends the request with given method to the API V2
+// endpoint
+func serveV2RequestWithMethod(router http.Handler, method, endpoint string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, serverConfigJWT.APIv2Prefix+endpoint, nil)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestHeadRequest checks that HEAD requests are served by GET handlers
+func TestHeadRequest(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder := serveV2RequestWithMethod(router, http.MethodHead, server.InfoEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveV2RequestWithMethod(router, http.MethodHead, "unknown")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestOptionsRequest checks that OPTIONS requests are responded by methods
+// allowed for the endpoint
+func TestOptionsRequest(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder := serveV2RequestWithMethod(router, http.MethodOptions, server.InfoEndpoint)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", recorder.Header().Get("Allow"))
+	assert.Empty(t, recorder.Body.String())
+
+	recorder = serveV2RequestWithMethod(router, http.MethodOptions, server.MaintenanceEndpoint)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "GET, HEAD, PUT, OPTIONS", recorder.Header().Get("Allow"))
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+)
+
+// noReportCacheCapacity limits the number of clusters without report that
+// are remembered at once
+const noReportCacheCapacity = 100000
+
+// newNoReportCache constructs the cache of aggregator responses for clusters
+// without report. Nil is returned when the cache is disabled by zero TTL.
+func newNoReportCache(ttl time.Duration) cache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.NewMemoryCache(noReportCacheCapacity, ttl)
+}
+
+func noReportCacheKey(orgID ctypes.OrgID, clusterID ctypes.ClusterName) string {
+	return fmt.Sprintf("%v/%v", orgID, clusterID)
+}
+
+// sendCachedNoReport sends the cached "not found" response of aggregator if
+// it is known that the cluster had no report recently. Returns true if the
+// response has been sent.
+func (server HTTPServer) sendCachedNoReport(
+	writer http.ResponseWriter, orgID ctypes.OrgID, clusterID ctypes.ClusterName,
+) bool {
+	if server.noReportCache == nil {
+		return false
+	}
+
+	responseBytes, found := server.noReportCache.Get(noReportCacheKey(orgID, clusterID))
+	if !found {
+		return false
+	}
+
+	log.Debug().Str(clusterIDTag, string(clusterID)).Msg("No report for cluster found in cache")
+	err := responses.Send(http.StatusNotFound, writer, responseBytes)
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+	return true
+}
+
+// cacheNoReport remembers the "not found" response of aggregator for the
+// cluster
+func (server HTTPServer) cacheNoReport(
+	orgID ctypes.OrgID, clusterID ctypes.ClusterName, responseBytes []byte,
+) {
+	if server.noReportCache == nil {
+		return
+	}
+	server.noReportCache.Set(noReportCacheKey(orgID, clusterID), responseBytes, 0)
+}
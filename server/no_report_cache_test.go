@@ -0,0 +1,66 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+const noReportResponse = `{"status": "Item with ID 1/84f7eedc-0dd8-49cd-9d4d-f6646df3a5bc was not found in the storage"}`
+
+// TestNoReportCache checks that "not found" response of aggregator is
+// returned from cache on the second request
+func TestNoReportCache(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		// aggregator is asked just once
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusNotFound,
+			Body:       noReportResponse,
+		})
+
+		config := serverConfigJWT
+		config.NoReportCacheTTL = time.Minute
+		router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+		endpoint := serverConfigJWT.APIv1Prefix +
+			strings.Replace(server.ReportEndpoint, "{cluster}", string(testdata.ClusterName), 1)
+		for i := 0; i < 2; i++ {
+			request := httptest.NewRequest(http.MethodGet, endpoint, nil)
+			request.Header.Set("Authorization", goodJWTAuthBearer)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.JSONEq(t, noReportResponse, recorder.Body.String())
+		}
+	}, testTimeout)
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// incidentTag marks rules detecting an incident on the cluster
+	incidentTag = "incident"
+
+	criticalTotalRisk = 4
+)
+
+// riskLabels are the names of total risk levels used by Advisor UI, the most
+// risky level goes first
+var riskLabels = []struct {
+	totalRisk int
+	label     string
+}{
+	{4, "Critical"},
+	{3, "Important"},
+	{2, "Moderate"},
+	{1, "Low"},
+}
+
+// riskLabel returns the name of total risk level
+func riskLabel(totalRisk int) string {
+	for _, level := range riskLabels {
+		if level.totalRisk == totalRisk {
+			return level.label
+		}
+	}
+	return ""
+}
+
+// hasTag checks if the rule has given tag
+func hasTag(rule *types.RuleWithContentResponse, tag string) bool {
+	for _, ruleTag := range rule.Tags {
+		if ruleTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// newOCMReport transforms the rules hitting the cluster into the report in
+// format expected by OpenShift Cluster Manager UI. Disabled rules are left
+// out, the most risky issues go first.
+func newOCMReport(
+	clusterInfo types.ClusterInfo, lastCheckedAt types.Timestamp, rules []types.RuleWithContentResponse,
+) types.OCMReport {
+	report := types.OCMReport{
+		ClusterID:     clusterInfo.ID,
+		DisplayName:   clusterInfo.DisplayName,
+		LastCheckedAt: lastCheckedAt,
+		Issues:        []types.OCMIssue{},
+	}
+	if report.DisplayName == "" {
+		report.DisplayName = string(clusterInfo.ID)
+	}
+
+	counts := make(map[int]int, len(riskLabels))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Disabled {
+			continue
+		}
+
+		issue := types.OCMIssue{
+			RuleSelector: ctypes.RuleSelector(string(rule.RuleID) + "|" + string(rule.ErrorKey)),
+			Description:  rule.Description,
+			TotalRisk:    rule.TotalRisk,
+			RiskLabel:    riskLabel(rule.TotalRisk),
+			Incident:     hasTag(rule, incidentTag),
+			Impacted:     rule.Impacted,
+		}
+		report.Issues = append(report.Issues, issue)
+
+		counts[rule.TotalRisk]++
+		report.Badges.Total++
+		if rule.TotalRisk == criticalTotalRisk {
+			report.Badges.Critical++
+		}
+		if issue.Incident {
+			report.Badges.Incidents++
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].TotalRisk > report.Issues[j].TotalRisk
+	})
+
+	report.RiskSummary = make([]types.OCMRiskSummary, 0, len(riskLabels))
+	for _, level := range riskLabels {
+		report.RiskSummary = append(report.RiskSummary, types.OCMRiskSummary{
+			TotalRisk: level.totalRisk,
+			Label:     level.label,
+			Count:     counts[level.totalRisk],
+		})
+	}
+	return report
+}
+
+// ocmReportEndpoint returns the cluster report in the format expected by
+// OpenShift Cluster Manager UI, so OCM doesn't have to maintain its own
+// mapping from Advisor format
+func (server HTTPServer) ocmReportEndpoint(writer http.ResponseWriter, request *http.Request) {
+	aggregatorResponse, stale, successful, clusterID := server.fetchAggregatorReport(writer, request, reportCacheEndpointOCM)
+	if !successful {
+		return
+	}
+
+	clusterInfo := server.getClusterInfo(clusterID)
+
+	// managed status read from AMS API can be overridden by the osd_eligible parameter
+	osdFlag := readOSDEligibleOrDefault(request, clusterInfo.Managed)
+
+	rules, _, err := server.buildReportEndpointResponse(writer, request, aggregatorResponse, clusterID, osdFlag)
+	if err != nil {
+		// error has been handled already
+		return
+	}
+	fillImpacted(rules, aggregatorResponse.Report)
+
+	report := newOCMReport(clusterInfo, aggregatorResponse.Meta.LastCheckedAt, rules)
+	report.IsStale = stale
+	sendReportReponse(writer, report)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestNewOCMReport checks the risk summary, badges and order of issues in
+// the report for OCM
+func TestNewOCMReport(t *testing.T) {
+	clusterInfo := types.ClusterInfo{ID: "00000000-bbbb-cccc-dddd-eeeeeeeeeeee", DisplayName: "prod"}
+	rules := []types.RuleWithContentResponse{
+		{RuleID: "ccx.rule_low", ErrorKey: "LOW", Description: "low", TotalRisk: 1},
+		{RuleID: "ccx.rule_critical", ErrorKey: "CRITICAL", Description: "critical", TotalRisk: 4, Tags: []string{"incident"}},
+		{RuleID: "ccx.rule_disabled", ErrorKey: "DISABLED", TotalRisk: 4, Disabled: true},
+		{RuleID: "ccx.rule_moderate", ErrorKey: "MODERATE", Description: "moderate", TotalRisk: 2},
+	}
+
+	report := server.NewOCMReport(clusterInfo, "2023-05-24T12:00:00Z", rules)
+
+	assert.Equal(t, types.OCMReport{
+		ClusterID:     clusterInfo.ID,
+		DisplayName:   "prod",
+		LastCheckedAt: "2023-05-24T12:00:00Z",
+		RiskSummary: []types.OCMRiskSummary{
+			{TotalRisk: 4, Label: "Critical", Count: 1},
+			{TotalRisk: 3, Label: "Important", Count: 0},
+			{TotalRisk: 2, Label: "Moderate", Count: 1},
+			{TotalRisk: 1, Label: "Low", Count: 1},
+		},
+		Badges: types.OCMBadges{Total: 3, Critical: 1, Incidents: 1},
+		Issues: []types.OCMIssue{
+			{RuleSelector: "ccx.rule_critical|CRITICAL", Description: "critical", TotalRisk: 4, RiskLabel: "Critical", Incident: true},
+			{RuleSelector: "ccx.rule_moderate|MODERATE", Description: "moderate", TotalRisk: 2, RiskLabel: "Moderate"},
+			{RuleSelector: "ccx.rule_low|LOW", Description: "low", TotalRisk: 1, RiskLabel: "Low"},
+		},
+	}, report)
+}
+
+// TestNewOCMReportNoIssues checks that the cluster ID is used when the
+// display name is not known and that the lists are not null
+func TestNewOCMReportNoIssues(t *testing.T) {
+	report := server.NewOCMReport(types.ClusterInfo{ID: "00000000-bbbb-cccc-dddd-eeeeeeeeeeee"}, "", nil)
+
+	assert.Equal(t, "00000000-bbbb-cccc-dddd-eeeeeeeeeeee", report.DisplayName)
+	assert.Equal(t, []types.OCMIssue{}, report.Issues)
+	assert.Len(t, report.RiskSummary, 4)
+	assert.Equal(t, types.OCMBadges{}, report.Badges)
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// pathParameterRegexp matches path parameters in both OpenAPI paths and
+// router path templates (which can contain regular expression as well)
+var pathParameterRegexp = regexp.MustCompile(`\{[^}]*\}`)
+
+// openAPIMethods are keys of OpenAPI path item that represent operations
+var openAPIMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+// openAPISpecHandler serves the OpenAPI specification read from the file,
+// but only with the paths and operations that are registered in the router.
+// Endpoints disabled by configuration are therefore not published.
+type openAPISpecHandler struct {
+	router   *mux.Router
+	prefix   string
+	specFile string
+	// reload the specification file on every request (used in debug mode)
+	reload bool
+
+	mutex sync.Mutex
+	spec  []byte
+}
+
+// newOpenAPISpecHandler constructs handler serving the specification of API
+// with given prefix. The specification is generated on the first request,
+// so all routes are registered at that time.
+func newOpenAPISpecHandler(router *mux.Router, prefix, specFile string, reload bool) *openAPISpecHandler {
+	return &openAPISpecHandler{
+		router:   router,
+		prefix:   prefix,
+		specFile: specFile,
+		reload:   reload,
+	}
+}
+
+// ServeHTTP implements http.Handler interface
+func (handler *openAPISpecHandler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	spec, err := handler.getSpec()
+	if err != nil {
+		log.Error().Err(err).Str("file", handler.specFile).Msg("unable to generate OpenAPI specification")
+		handleServerError(writer, err)
+		return
+	}
+
+	writer.Header().Set(contentTypeHeader, JSONContentType)
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write(spec); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// getSpec returns the generated specification
+func (handler *openAPISpecHandler) getSpec() ([]byte, error) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.spec != nil && !handler.reload {
+		return handler.spec, nil
+	}
+
+	content, err := os.ReadFile(handler.specFile)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := generateOpenAPISpec(content, registeredOperations(handler.router, handler.prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	handler.spec = spec
+	return spec, nil
+}
+
+// normalizeOpenAPIPath removes names of path parameters, so paths from the
+// specification can be compared with router path templates
+func normalizeOpenAPIPath(path string) string {
+	return pathParameterRegexp.ReplaceAllString(path, "{}")
+}
+
+// registeredOperations returns HTTP methods registered in the router for all
+// paths with given prefix. The paths are relative to the prefix and
+// normalized.
+func registeredOperations(router *mux.Router, prefix string) map[string][]string {
+	operations := make(map[string][]string)
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, prefix) {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path := normalizeOpenAPIPath("/" + strings.TrimPrefix(template, prefix))
+		operations[path] = append(operations[path], methods...)
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("unable to read registered routes")
+	}
+
+	return operations
+}
+
+// generateOpenAPISpec removes paths and operations that are not registered
+// from the OpenAPI specification
+func generateOpenAPISpec(content []byte, operations map[string][]string) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return nil, err
+	}
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	if !ok {
+		return json.MarshalIndent(spec, "", "  ")
+	}
+
+	for path, item := range paths {
+		pathItem, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		methods := operations[normalizeOpenAPIPath(path)]
+		remaining := 0
+		for key := range pathItem {
+			method := strings.ToUpper(key)
+			if !collections.StringInSlice(method, openAPIMethods) {
+				continue
+			}
+			if collections.StringInSlice(method, methods) {
+				remaining++
+			} else {
+				delete(pathItem, key)
+			}
+		}
+
+		if remaining == 0 {
+			log.Debug().Str("path", path).Msg("endpoint is not registered, removed from OpenAPI specification")
+			delete(paths, path)
+		}
+	}
+
+	return json.MarshalIndent(spec, "", "  ")
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+const openAPISpecWithUnregisteredPaths = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/clusters/{clusterId}/report": {
+			"parameters": [],
+			"get": {"operationId": "getReport"},
+			"delete": {"operationId": "deleteReport"}
+		},
+		"/debug/endpoint": {
+			"get": {"operationId": "debugEndpoint"}
+		}
+	}
+}`
+
+func noopHandler(http.ResponseWriter, *http.Request) {}
+
+// TestRegisteredOperations checks that routes are read from router
+func TestRegisteredOperations(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/clusters/{cluster}/report", noopHandler).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/clusters/{cluster}/report", noopHandler).Methods(http.MethodPut)
+	router.HandleFunc("/api/v2/clusters", noopHandler).Methods(http.MethodGet)
+
+	assert.Equal(t, map[string][]string{
+		"/clusters/{}/report": {http.MethodGet, http.MethodPut},
+	}, server.RegisteredOperations(router, "/api/v1/"))
+}
+
+// TestGenerateOpenAPISpec checks that paths and operations that are not
+// registered are removed from the specification
+func TestGenerateOpenAPISpec(t *testing.T) {
+	spec, err := server.GenerateOpenAPISpec([]byte(openAPISpecWithUnregisteredPaths), map[string][]string{
+		"/clusters/{}/report": {http.MethodGet},
+	})
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"openapi": "3.0.3",
+		"paths": {
+			"/clusters/{clusterId}/report": {
+				"parameters": [],
+				"get": {"operationId": "getReport"}
+			}
+		}
+	}`, string(spec))
+}
+
+// TestGenerateOpenAPISpecInvalidJSON checks that invalid specification
+// is reported
+func TestGenerateOpenAPISpecInvalidJSON(t *testing.T) {
+	_, err := server.GenerateOpenAPISpec([]byte("not JSON"), nil)
+	assert.Error(t, err)
+}
+
+// TestHTTPServer_OpenAPISpecV3 checks that the specification contains the
+// registered endpoints
+func TestHTTPServer_OpenAPISpecV3(t *testing.T) {
+	config := helpers.DefaultServerConfig
+	config.APIv3Prefix = "/api/v3/"
+	config.APIv3SpecFile = "api/v3/openapi.json"
+
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	iou_helpers.AssertAPIRequest(t, testServer, config.APIv3Prefix, &helpers.APIRequest{
+		Method:   http.MethodGet,
+		Endpoint: "openapi.json",
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		// checked by BodyChecker
+		Body: "{}",
+		BodyChecker: func(t testing.TB, _, got []byte) {
+			var spec struct {
+				Paths map[string]interface{} `json:"paths"`
+			}
+			helpers.FailOnError(t, json.Unmarshal(got, &spec))
+
+			assert.Contains(t, spec.Paths, "/"+server.ClustersEndpointV3)
+			assert.Contains(t, spec.Paths, "/"+server.RecommendationsEndpointV3)
+			assert.Contains(t, spec.Paths, "/openapi.json")
+		},
+	})
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+)
+
+// OrgAccessConfiguration represents configuration of organization level
+// access control. Organizations can be listed directly or in CSV files with
+// the same format as the internal rules organizations file. The files are
+// re-read when they change.
+type OrgAccessConfiguration struct {
+	Allowlist      []types.OrgID `mapstructure:"allowlist" toml:"allowlist"`
+	Denylist       []types.OrgID `mapstructure:"denylist" toml:"denylist"`
+	AllowlistFile  string        `mapstructure:"allowlist_file" toml:"allowlist_file"`
+	DenylistFile   string        `mapstructure:"denylist_file" toml:"denylist_file"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval" toml:"reload_interval"`
+}
+
+// enabled returns true if any organization can be refused
+func (config OrgAccessConfiguration) enabled() bool {
+	return len(config.Allowlist) != 0 || len(config.Denylist) != 0 ||
+		config.AllowlistFile != "" || config.DenylistFile != ""
+}
+
+// OrgAccessDeniedError error is used when the organization of the requester
+// is refused by the organizations access list
+type OrgAccessDeniedError struct {
+	orgID types.OrgID
+}
+
+func (e *OrgAccessDeniedError) Error() string {
+	return fmt.Sprintf("Access of organization %v is denied", e.orgID)
+}
+
+// orgIDsFile is the CSV file with list of organizations together with the
+// content read during the last reload
+type orgIDsFile struct {
+	path     string
+	modified time.Time
+	orgIDs   map[types.OrgID]bool
+}
+
+// reload reads the file if it has been modified since the last reload. The
+// previous content is kept when the file can't be read.
+func (file *orgIDsFile) reload() {
+	if file.path == "" {
+		return
+	}
+
+	info, err := os.Stat(file.path)
+	if err != nil {
+		log.Error().Err(err).Str("file", file.path).Msg("Unable to read organizations access list")
+		return
+	}
+	if !info.ModTime().After(file.modified) {
+		return
+	}
+
+	orgIDs, err := readOrgIDsFile(file.path)
+	if err != nil {
+		log.Error().Err(err).Str("file", file.path).Msg("Unable to read organizations access list")
+		return
+	}
+
+	file.orgIDs = orgIDs
+	file.modified = info.ModTime()
+	log.Info().Str("file", file.path).Int("organizations", len(orgIDs)).Msg("Organizations access list reloaded")
+}
+
+// readOrgIDsFile reads organization IDs from the first column of CSV file
+// with header
+func readOrgIDsFile(path string) (map[types.OrgID]bool, error) {
+	// path is set in configuration, not by the client
+	// #nosec G304
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	lines, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs := make(map[types.OrgID]bool)
+	for index, line := range lines {
+		if index == 0 {
+			continue // skip header
+		}
+
+		orgID, err := strconv.ParseUint(line[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"organization ID on line %v in CSV is not numerical. Found value: %v",
+				index+1, line[0],
+			)
+		}
+		orgIDs[types.OrgID(orgID)] = true
+	}
+
+	return orgIDs, nil
+}
+
+// orgAccessList decides which organizations can use the service
+type orgAccessList struct {
+	allowlist      map[types.OrgID]bool
+	denylist       map[types.OrgID]bool
+	reloadInterval time.Duration
+
+	mutex         sync.RWMutex
+	allowlistFile orgIDsFile
+	denylistFile  orgIDsFile
+	lastReload    time.Time
+}
+
+// newOrgAccessList constructs the access list and reads the configured
+// files
+func newOrgAccessList(config OrgAccessConfiguration) *orgAccessList {
+	list := &orgAccessList{
+		allowlist:      make(map[types.OrgID]bool),
+		denylist:       make(map[types.OrgID]bool),
+		reloadInterval: config.ReloadInterval,
+		allowlistFile:  orgIDsFile{path: config.AllowlistFile},
+		denylistFile:   orgIDsFile{path: config.DenylistFile},
+	}
+	for _, orgID := range config.Allowlist {
+		list.allowlist[orgID] = true
+	}
+	for _, orgID := range config.Denylist {
+		list.denylist[orgID] = true
+	}
+
+	list.reload(time.Now())
+	return list
+}
+
+// reload re-reads the files, but at most once per reload interval
+func (list *orgAccessList) reload(now time.Time) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if !list.lastReload.IsZero() && now.Sub(list.lastReload) < list.reloadInterval {
+		return
+	}
+	list.lastReload = now
+
+	list.allowlistFile.reload()
+	list.denylistFile.reload()
+}
+
+// isAllowed returns true if the organization is not denied and either it is
+// allowed or no organizations are allowed explicitly
+func (list *orgAccessList) isAllowed(orgID types.OrgID) bool {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if list.denylist[orgID] || list.denylistFile.orgIDs[orgID] {
+		return false
+	}
+	if len(list.allowlist) == 0 && list.allowlistFile.path == "" {
+		return true
+	}
+	return list.allowlist[orgID] || list.allowlistFile.orgIDs[orgID]
+}
+
+// orgAccessMiddleware refuses requests of organizations that are not allowed.
+// It needs to be used after authentication, requests without identity are
+// passed through.
+func (server *HTTPServer) orgAccessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		orgID, err := server.GetCurrentOrgID(request)
+		if err != nil {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		server.orgAccess.reload(time.Now())
+		if !server.orgAccess.isAllowed(orgID) {
+			log.Warn().Int(orgIDTag, int(orgID)).Msg("Request of organization refused by access list")
+			handleServerError(writer, &OrgAccessDeniedError{orgID: orgID})
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestOrgAccessDenylist checks that requests of denied organization are
+// refused
+func TestOrgAccessDenylist(t *testing.T) {
+	config := serverConfigJWT
+	config.OrgAccess.Denylist = []ctypes.OrgID{1}
+
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+	recorder := serveV2Request(router, server.MainEndpoint)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"organization_denied"`)
+
+	// endpoints without authentication are not affected
+	recorder = serveV2Request(router, server.InfoEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// TestOrgAccessAllowlistFileReload checks that changes of the allowlist file
+// are applied without restart
+func TestOrgAccessAllowlistFileReload(t *testing.T) {
+	allowlistFile := filepath.Join(t.TempDir(), "allowlist.csv")
+	helpers.FailOnError(t, os.WriteFile(allowlistFile, []byte("OrgID\n2\n"), 0600))
+
+	config := serverConfigJWT
+	config.OrgAccess.AllowlistFile = allowlistFile
+
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+	recorder := serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	helpers.FailOnError(t, os.WriteFile(allowlistFile, []byte("OrgID\n1\n2\n"), 0600))
+	// make sure the modification time changes even on coarse-grained
+	// file systems
+	modified := time.Now().Add(time.Minute)
+	helpers.FailOnError(t, os.Chtimes(allowlistFile, modified, modified))
+
+	recorder = serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
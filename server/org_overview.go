@@ -0,0 +1,219 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// orgOverviewCacheCapacity limits the number of organizations with cached
+// overview
+const orgOverviewCacheCapacity = 10000
+
+// newOrgOverviewCache constructs the cache of organization overviews. Nil is
+// returned when the cache is disabled by zero TTL.
+func newOrgOverviewCache(ttl time.Duration) cache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.NewMemoryCache(orgOverviewCacheCapacity, ttl)
+}
+
+// orgOverviewCacheKey returns the key of overview computed for the
+// organization with clusters filtered by statuses
+func orgOverviewCacheKey(orgID ctypes.OrgID, statusFilter []string) string {
+	statuses := append([]string(nil), statusFilter...)
+	sort.Strings(statuses)
+	return fmt.Sprintf("%v/%s", orgID, strings.Join(statuses, ","))
+}
+
+// computeOrgOverview computes the overview from clusters of the organization
+// and their hitting recommendations. Disabled rules and rules not relevant for
+// managed clusters are not counted.
+func computeOrgOverview(
+	clusterInfoList []types.ClusterInfo,
+	clusterRecommendationsMap ctypes.ClusterRecommendationMap,
+	systemWideDisabledRules map[ctypes.RuleID]bool,
+	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
+) (*types.OrgOverviewV2, error) {
+	overview := &types.OrgOverviewV2{
+		Clusters:                   len(clusterInfoList),
+		ClustersByHighestTotalRisk: make(map[int]int),
+		HitsByTotalRisk:            make(map[int]int),
+		HitsByTag:                  make(map[string]int),
+		DisabledRules: types.DisabledRulesCount{
+			SystemWide: len(systemWideDisabledRules),
+		},
+	}
+	for _, rules := range disabledRulesPerCluster {
+		overview.DisabledRules.PerCluster += len(rules)
+	}
+
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+
+		hittingRecommendations, found := clusterRecommendationsMap[clusterInfo.ID]
+		if !found {
+			continue
+		}
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			hittingRecommendations.Recommendations, clusterInfo.ID,
+			systemWideDisabledRules, disabledRulesPerCluster,
+		)
+
+		highestTotalRisk := 0
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					return nil, err
+				}
+				// missing rule content, the rule can't be displayed
+				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
+				continue
+			}
+
+			if clusterInfo.Managed && !ruleContent.OSDCustomer {
+				continue
+			}
+
+			overview.HitsByTotalRisk[ruleContent.TotalRisk]++
+			for _, tag := range ruleContent.Tags {
+				overview.HitsByTag[tag]++
+			}
+			if ruleContent.TotalRisk > highestTotalRisk {
+				highestTotalRisk = ruleContent.TotalRisk
+			}
+		}
+
+		if highestTotalRisk > 0 {
+			overview.ClustersHit++
+			overview.ClustersByHighestTotalRisk[highestTotalRisk]++
+		}
+	}
+
+	return overview, nil
+}
+
+// fetchOrgOverview reads the data needed by the organization overview. Rule
+// acknowledgements and rules disabled per cluster are read from aggregator
+// while the list of clusters and their recommendations are being retrieved.
+func (server HTTPServer) fetchOrgOverview(
+	writer http.ResponseWriter,
+	orgID ctypes.OrgID,
+	userID ctypes.UserID,
+	statusFilter []string,
+) (*types.OrgOverviewV2, bool) {
+	var (
+		waitGroup               sync.WaitGroup
+		ackedRulesMap           map[ctypes.RuleID]bool
+		disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID
+	)
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	// the goroutines must not outlive the request
+	defer waitGroup.Wait()
+
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, statusFilter)
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
+		handleServerError(writer, err)
+		return nil, false
+	}
+	// cluster info is needed by report endpoints opened from the overview
+	server.clusterInfoCache.set(clusterInfoList...)
+
+	clusterRecommendationMap, err := server.getClustersAndRecommendations(
+		writer, orgID, userID, types.GetClusterNames(clusterInfoList),
+	)
+	if err != nil {
+		// error has been handled already
+		return nil, false
+	}
+	server.redactInternalClusterRecommendations(orgID, clusterRecommendationMap)
+
+	waitGroup.Wait()
+
+	overview, err := computeOrgOverview(
+		clusterInfoList, clusterRecommendationMap, ackedRulesMap, disabledRulesPerCluster,
+	)
+	if err != nil {
+		handleServerError(writer, err)
+		return nil, false
+	}
+	return overview, true
+}
+
+// orgOverviewV2 returns counts of clusters by the highest total risk of the
+// hitting rules, numbers of hits by total risk and by tag and numbers of
+// disabled rules in the organization
+func (server HTTPServer) orgOverviewV2(writer http.ResponseWriter, request *http.Request) {
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	statusFilter, err := server.readClusterStatusFilter(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	cacheKey := orgOverviewCacheKey(orgID, statusFilter)
+	var overview *types.OrgOverviewV2
+	if server.orgOverviewCache != nil {
+		if cached, found := server.orgOverviewCache.Get(cacheKey); found {
+			overview = cached.(*types.OrgOverviewV2)
+		}
+	}
+
+	if overview == nil {
+		var successful bool
+		overview, successful = server.fetchOrgOverview(writer, orgID, userID, statusFilter)
+		if !successful {
+			return
+		}
+		if server.orgOverviewCache != nil {
+			server.orgOverviewCache.Set(cacheKey, overview, 0)
+		}
+	}
+
+	if err = responses.SendOK(writer, responses.BuildOkResponseWithData("overview", overview)); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
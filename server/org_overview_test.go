@@ -0,0 +1,124 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestHTTPServer_OrgOverviewV2Endpoint checks the organization overview
+// computed from one hitting and one healthy cluster
+func TestHTTPServer_OrgOverviewV2Endpoint(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{
+				testdata.RuleContent1,
+				testdata.RuleContent2,
+				testdata.RuleContent3,
+			},
+		),
+	)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := make([]types.ClusterInfo, 2)
+		for i := range clusterInfoList {
+			clusterInfoList[i] = data.GetRandomClusterInfo()
+		}
+		reqBody, _ := json.Marshal(types.GetClusterNames(clusterInfoList))
+
+		respBody := fmt.Sprintf(`{
+			"clusters":{
+				"%v": {
+					"created_at": "%v",
+					"recommendations": ["%v","%v","%v"]
+				}
+			}
+		}`,
+			clusterInfoList[0].ID, testTimeStr, testdata.Rule1CompositeID,
+			testdata.Rule2CompositeID, testdata.Rule3CompositeID,
+		)
+
+		amsClientMock := helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       respBody,
+			},
+		)
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, types.UserID(userIDOnGoodJWTAuthBearer))
+
+		expectedResponse := struct {
+			Status   string              `json:"status"`
+			Overview types.OrgOverviewV2 `json:"overview"`
+		}{
+			Status: "ok",
+			Overview: types.OrgOverviewV2{
+				Clusters:                   2,
+				ClustersHit:                1,
+				ClustersByHighestTotalRisk: map[int]int{2: 1},
+				HitsByTotalRisk:            map[int]int{1: 1, 2: 2},
+				HitsByTag: map[string]int{
+					"openshift":            1,
+					"osd_customer":         1,
+					"service_availability": 1,
+				},
+			},
+		}
+
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
+		iou_helpers.AssertAPIRequest(
+			t,
+			testServer,
+			helpers.DefaultServerConfig.APIv2Prefix,
+			&helpers.APIRequest{
+				Method:             http.MethodGet,
+				Endpoint:           server.OverviewEndpoint,
+				AuthorizationToken: goodJWTAuthBearer,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       helpers.ToJSONString(expectedResponse),
+			},
+		)
+	}, testTimeout)
+}
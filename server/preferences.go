@@ -0,0 +1,170 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// maxPreferencesRules is the maximal number of rules with settings
+	maxPreferencesRules = 1000
+	// maxDefaultFilters is the maximal number of default filters
+	maxDefaultFilters = 50
+	// maxDefaultFilterLength is the maximal length of name and value of
+	// default filter
+	maxDefaultFilterLength = 256
+	// preferencesKey is the key of preferences in the responses
+	preferencesKey = "preferences"
+)
+
+// SetPreferencesStore sets the store of user preferences. Preferences
+// endpoints are not available until it is called. It needs to be called
+// before the router is initialized.
+func (server *HTTPServer) SetPreferencesStore(store preferences.Store) {
+	server.preferences = store
+}
+
+// readPreferencesKey returns the key identifying the requester
+func (server *HTTPServer) readPreferencesKey(request *http.Request) (preferences.Key, error) {
+	identity, err := server.GetAuthToken(request)
+	if err != nil {
+		return preferences.Key{}, err
+	}
+
+	return preferences.Key{
+		AccountNumber: string(identity.AccountNumber),
+		OrgID:         identity.OrgID,
+		UserID:        identity.User.UserID,
+	}, nil
+}
+
+// validatePreferences checks the limits of the preferences and that the
+// rules are known. Detail of the problem is returned when the preferences are
+// invalid.
+func validatePreferences(userPreferences *types.UserPreferences) (string, error) {
+	if len(userPreferences.DefaultFilters) > maxDefaultFilters {
+		return fmt.Sprintf("at most %d default filters can be set", maxDefaultFilters), nil
+	}
+	for name, value := range userPreferences.DefaultFilters {
+		if name == "" || len(name) > maxDefaultFilterLength || len(value) > maxDefaultFilterLength {
+			return fmt.Sprintf("default filter %q is not valid", name), nil
+		}
+	}
+
+	if len(userPreferences.Rules) > maxPreferencesRules {
+		return fmt.Sprintf("settings of at most %d rules can be set", maxPreferencesRules), nil
+	}
+	for ruleID := range userPreferences.Rules {
+		if _, err := content.GetContentForRecommendation(ruleID); err != nil {
+			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+				return "", err
+			}
+			return fmt.Sprintf("rule %v is not known", ruleID), nil
+		}
+	}
+	return "", nil
+}
+
+// getPreferences returns the preferences of the user, defaults are returned
+// when the user has not stored any
+func (server *HTTPServer) getPreferences(writer http.ResponseWriter, request *http.Request) {
+	key, err := server.readPreferencesKey(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	userPreferences, _, err := server.preferences.Get(key)
+	if err != nil {
+		log.Error().Err(err).Str("key", key.String()).Msg("Unable to read user preferences")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData(preferencesKey, userPreferences))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// putPreferences replaces the preferences of the user
+func (server *HTTPServer) putPreferences(writer http.ResponseWriter, request *http.Request) {
+	key, err := server.readPreferencesKey(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	var userPreferences types.UserPreferences
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&userPreferences); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	detail, err := validatePreferences(&userPreferences)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	if err := server.preferences.Set(key, userPreferences); err != nil {
+		log.Error().Err(err).Str("key", key.String()).Msg("Unable to store user preferences")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData(preferencesKey, userPreferences))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// deletePreferences resets the preferences of the user to defaults
+func (server *HTTPServer) deletePreferences(writer http.ResponseWriter, request *http.Request) {
+	key, err := server.readPreferencesKey(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if err := server.preferences.Delete(key); err != nil {
+		log.Error().Err(err).Str("key", key.String()).Msg("Unable to delete user preferences")
+		handleServerError(writer, err)
+		return
+	}
+
+	if err := responses.SendOK(writer, responses.BuildOkResponse()); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
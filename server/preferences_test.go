@@ -0,0 +1,101 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+func TestPreferencesEndpoint(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	dir, err := ioutil.TempDir("", "preferences")
+	helpers.FailOnError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := preferences.NewFileStore(filepath.Join(dir, "preferences.json"))
+	helpers.FailOnError(t, err)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetPreferencesStore(store)
+	router := testServer.Initialize()
+	endpoint := serverConfigJWT.APIv2Prefix + server.PreferencesEndpoint
+
+	// defaults
+	recorder := serveJWTRequest(router, http.MethodGet, endpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok", "preferences": {"suppress_low_risk": false}}`, recorder.Body.String())
+
+	stored := `{
+		"suppress_low_risk": true,
+		"default_filters": {"total_risk": "3,4"},
+		"rules": {"` + string(testdata.Rule1CompositeID) + `": {"hidden": true, "mute_notifications": false}}
+	}`
+	recorder = serveJWTRequest(router, http.MethodPut, endpoint, stored)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveJWTRequest(router, http.MethodGet, endpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok", "preferences": `+stored+`}`, recorder.Body.String())
+
+	recorder = serveJWTRequest(router, http.MethodDelete, endpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveJWTRequest(router, http.MethodGet, endpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok", "preferences": {"suppress_low_risk": false}}`, recorder.Body.String())
+}
+
+func TestPreferencesEndpointInvalid(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	dir, err := ioutil.TempDir("", "preferences")
+	helpers.FailOnError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := preferences.NewFileStore(filepath.Join(dir, "preferences.json"))
+	helpers.FailOnError(t, err)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetPreferencesStore(store)
+	router := testServer.Initialize()
+	endpoint := serverConfigJWT.APIv2Prefix + server.PreferencesEndpoint
+
+	for _, body := range []string{
+		"",
+		`{"unknown_field": true}`,
+		`{"rules": {"unknown.rule|UNKNOWN_KEY": {"hidden": true}}}`,
+		`{"default_filters": {"": "3,4"}}`,
+	} {
+		recorder := serveJWTRequest(router, http.MethodPut, endpoint, body)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+	}
+
+	// nothing has been stored
+	recorder := serveJWTRequest(router, http.MethodGet, endpoint, "")
+	assert.JSONEq(t, `{"status": "ok", "preferences": {"suppress_low_risk": false}}`, recorder.Body.String())
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// problemContentType is the media type of error responses as defined
+	// by RFC 7807
+	problemContentType = "application/problem+json"
+
+	// problemTypePrefix is prepended to the error code to construct the
+	// type URI of the problem
+	problemTypePrefix = "urn:insights-results-smart-proxy:error:"
+)
+
+// Error codes returned in the code field of error responses. The codes are
+// stable, so clients can rely on them instead of the detail message.
+const (
+	ErrorCodeInvalidParameter           = "invalid_parameter"
+	ErrorCodeMissingParameter           = "missing_parameter"
+	ErrorCodeInvalidBody                = "invalid_body"
+	ErrorCodePayloadTooLarge            = "payload_too_large"
+	ErrorCodeNotFound                   = "not_found"
+	ErrorCodeFeatureDisabled            = "feature_disabled"
+	ErrorCodeAuthenticationFailed       = "authentication_failed"
+	ErrorCodeOrganizationDenied         = "organization_denied"
+	ErrorCodeEntitlementRequired        = "entitlement_required"
+	ErrorCodeOrgAdminRequired           = "org_admin_required"
+	ErrorCodeImpersonationDenied        = "impersonation_denied"
+	ErrorCodeInternalRoleRequired       = "internal_role_required"
+	ErrorCodeAggregatorUnavailable      = "aggregator_unavailable"
+	ErrorCodeContentServiceUnavailable  = "content_service_unavailable"
+	ErrorCodeAMSAPIUnavailable          = "ams_api_unavailable"
+	ErrorCodeUpgradesDataEngUnavailable = "upgrades_data_eng_unavailable"
+	ErrorCodeRemediationsUnavailable    = "remediations_unavailable"
+	ErrorCodeSSOUnavailable             = "sso_unavailable"
+	ErrorCodeMaintenance                = "maintenance"
+	ErrorCodeStarting                   = "service_starting"
+	ErrorCodeExportQueueFull            = "export_queue_full"
+	ErrorCodeExportNotReady             = "export_not_ready"
+	ErrorCodeAmbiguousClusterReference  = "ambiguous_cluster_reference"
+	ErrorCodeInternalServerError        = "internal_server_error"
+)
+
+// errorCodeDefinition contains the title and HTTP status code of problems
+// with given error code
+type errorCodeDefinition struct {
+	title  string
+	status int
+}
+
+// errorCodes is the catalog of all error codes
+var errorCodes = map[string]errorCodeDefinition{
+	ErrorCodeInvalidParameter:           {"Invalid parameter", http.StatusBadRequest},
+	ErrorCodeMissingParameter:           {"Missing parameter", http.StatusBadRequest},
+	ErrorCodeInvalidBody:                {"Invalid request body", http.StatusBadRequest},
+	ErrorCodePayloadTooLarge:            {"Request body too large", http.StatusRequestEntityTooLarge},
+	ErrorCodeNotFound:                   {"Item not found", http.StatusNotFound},
+	ErrorCodeFeatureDisabled:            {"Feature disabled", http.StatusNotFound},
+	ErrorCodeAuthenticationFailed:       {"Authentication failed", http.StatusForbidden},
+	ErrorCodeOrganizationDenied:         {"Organization access denied", http.StatusForbidden},
+	ErrorCodeEntitlementRequired:        {"Entitlement required", http.StatusForbidden},
+	ErrorCodeOrgAdminRequired:           {"Organization administrator required", http.StatusForbidden},
+	ErrorCodeImpersonationDenied:        {"Impersonation denied", http.StatusForbidden},
+	ErrorCodeInternalRoleRequired:       {"Internal role required", http.StatusForbidden},
+	ErrorCodeAggregatorUnavailable:      {"Aggregator service unavailable", http.StatusServiceUnavailable},
+	ErrorCodeContentServiceUnavailable:  {"Content service unavailable", http.StatusServiceUnavailable},
+	ErrorCodeAMSAPIUnavailable:          {"AMS API unavailable", http.StatusServiceUnavailable},
+	ErrorCodeUpgradesDataEngUnavailable: {"Upgrade Failure Prediction service unavailable", http.StatusServiceUnavailable},
+	ErrorCodeRemediationsUnavailable:    {"Remediations service unavailable", http.StatusServiceUnavailable},
+	ErrorCodeSSOUnavailable:             {"SSO unavailable", http.StatusServiceUnavailable},
+	ErrorCodeMaintenance:                {"Service under maintenance", http.StatusServiceUnavailable},
+	ErrorCodeStarting:                   {"Service is starting", http.StatusServiceUnavailable},
+	ErrorCodeExportQueueFull:            {"Export queue full", http.StatusServiceUnavailable},
+	ErrorCodeExportNotReady:             {"Export not ready", http.StatusConflict},
+	ErrorCodeAmbiguousClusterReference:  {"Ambiguous cluster reference", http.StatusConflict},
+	ErrorCodeInternalServerError:        {"Internal server error", http.StatusInternalServerError},
+}
+
+// Problem represents error response in RFC 7807 format extended by the
+// error code from the catalog
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+
+	// RejectedClusters is the validation report of list of clusters in
+	// request body
+	RejectedClusters []types.RejectedCluster `json:"rejected_clusters,omitempty"`
+}
+
+// newProblem constructs the problem for given error code. Unknown codes are
+// reported as internal server errors.
+func newProblem(code, detail string) Problem {
+	definition, found := errorCodes[code]
+	if !found {
+		code = ErrorCodeInternalServerError
+		definition = errorCodes[code]
+	}
+
+	return Problem{
+		Type:   problemTypePrefix + code,
+		Title:  definition.title,
+		Status: definition.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// sendProblem writes the problem as the response
+func sendProblem(writer http.ResponseWriter, problem Problem) error {
+	writer.Header().Set(contentTypeHeader, problemContentType)
+	writer.WriteHeader(problem.Status)
+	return json.NewEncoder(writer).Encode(problem)
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// defaultViaPseudonym identifies the proxy in Via header when the pseudonym
+// is not configured
+const defaultViaPseudonym = "insights-results-smart-proxy"
+
+// strippedHeaders are never forwarded to upstream services. Hop-by-hop
+// headers (RFC 7230) are meant for the proxy only and cookies belong to the
+// console session of the user.
+var strippedHeaders = []string{
+	"Connection",
+	"Cookie",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// credentialHeaders carry credentials meant for this service, they are
+// forwarded only when the policy of the upstream service allows them
+// explicitly
+var credentialHeaders = []string{
+	"Authorization",
+}
+
+// HeaderPolicyConfiguration represents the policy of request headers
+// forwarded to an upstream service. All headers are forwarded, except the
+// denied ones and the credentials, when the allowlist is empty.
+type HeaderPolicyConfiguration struct {
+	Allow []string `mapstructure:"allow" toml:"allow"`
+	Deny  []string `mapstructure:"deny" toml:"deny"`
+}
+
+// ProxyHeadersConfiguration represents configuration of headers of requests
+// proxied to upstream services. Policies are keyed by the name of upstream
+// service, "aggregator" or "content-service".
+type ProxyHeadersConfiguration struct {
+	Via       string                               `mapstructure:"via" toml:"via"`
+	Upstreams map[string]HeaderPolicyConfiguration `mapstructure:"upstreams" toml:"upstreams"`
+}
+
+// headerSet returns set of canonical names of given headers
+func headerSet(headers []string) map[string]bool {
+	set := make(map[string]bool, len(headers))
+	for _, header := range headers {
+		set[http.CanonicalHeaderKey(header)] = true
+	}
+	return set
+}
+
+// headerPolicy returns the policy of headers forwarded to the upstream
+// service with given base URL
+func (server HTTPServer) headerPolicy(baseURL string) HeaderPolicyConfiguration {
+	switch baseURL {
+	case server.currentServicesConfig().AggregatorBaseEndpoint, server.currentServicesConfig().AggregatorReadBaseEndpoint:
+		return server.Config.ProxyHeaders.Upstreams[upstreamAggregator]
+	case server.ServicesConfig.ContentBaseEndpoint:
+		return server.Config.ProxyHeaders.Upstreams[upstreamContentService]
+	}
+	return HeaderPolicyConfiguration{}
+}
+
+// copyHeader copies the headers of inbound request allowed by the policy to
+// the request sent to upstream service. Headers listed in Connection header
+// are hop-by-hop too, so they are not copied either.
+func copyHeader(srcHeaders, dstHeaders http.Header, policy HeaderPolicyConfiguration) {
+	allowed := headerSet(policy.Allow)
+	denied := headerSet(policy.Deny)
+	for _, header := range strippedHeaders {
+		denied[header] = true
+	}
+	for _, header := range credentialHeaders {
+		if !allowed[header] {
+			denied[header] = true
+		}
+	}
+	for _, connectionValue := range srcHeaders.Values("Connection") {
+		for _, header := range strings.Split(connectionValue, ",") {
+			denied[http.CanonicalHeaderKey(strings.TrimSpace(header))] = true
+		}
+	}
+
+	for headerKey, headerValues := range srcHeaders {
+		headerKey = http.CanonicalHeaderKey(headerKey)
+		if denied[headerKey] || (len(allowed) > 0 && !allowed[headerKey]) {
+			continue
+		}
+		for _, value := range headerValues {
+			dstHeaders.Add(headerKey, value)
+		}
+	}
+}
+
+// addForwardingHeaders adds the client address to X-Forwarded-For header and
+// the proxy to Via header of the request sent to upstream service. Values
+// sent by previous proxies are kept when the policy forwards them.
+func addForwardingHeaders(request *http.Request, dstHeaders http.Header, via string) {
+	if clientIP, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		forwardedFor := append(dstHeaders.Values("X-Forwarded-For"), clientIP)
+		dstHeaders.Set("X-Forwarded-For", strings.Join(forwardedFor, ", "))
+	}
+	if dstHeaders.Get("X-Forwarded-Host") == "" && request.Host != "" {
+		dstHeaders.Set("X-Forwarded-Host", request.Host)
+	}
+	if dstHeaders.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if request.TLS != nil {
+			proto = "https"
+		}
+		dstHeaders.Set("X-Forwarded-Proto", proto)
+	}
+
+	if via == "" {
+		via = defaultViaPseudonym
+	}
+	dstHeaders.Add("Via", fmt.Sprintf("%d.%d %s", request.ProtoMajor, request.ProtoMinor, via))
+}
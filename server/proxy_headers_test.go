@@ -0,0 +1,94 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestCopyHeaderPolicy checks filtering of headers forwarded to upstream
+// services
+func TestCopyHeaderPolicy(t *testing.T) {
+	src := http.Header{}
+	src.Set("Authorization", "Bearer token")
+	src.Set("Cookie", "session=1")
+	src.Set("Connection", "X-Hop")
+	src.Set("X-Hop", "1")
+	src.Set("X-Rh-Identity", "identity")
+	src.Set("Accept", "application/json")
+
+	// credentials are stripped by default
+	dst := http.Header{}
+	server.CopyHeader(src, dst, server.HeaderPolicyConfiguration{})
+	assert.Equal(t, http.Header{
+		"X-Rh-Identity": {"identity"},
+		"Accept":        {"application/json"},
+	}, dst)
+
+	dst = http.Header{}
+	server.CopyHeader(src, dst, server.HeaderPolicyConfiguration{Deny: []string{"accept"}})
+	assert.Equal(t, http.Header{"X-Rh-Identity": {"identity"}}, dst)
+
+	dst = http.Header{}
+	server.CopyHeader(src, dst, server.HeaderPolicyConfiguration{Allow: []string{"x-rh-identity", "cookie"}})
+	assert.Equal(t, http.Header{"X-Rh-Identity": {"identity"}}, dst)
+
+	dst = http.Header{}
+	server.CopyHeader(src, dst, server.HeaderPolicyConfiguration{Allow: []string{"authorization"}})
+	assert.Equal(t, http.Header{"Authorization": {"Bearer token"}}, dst)
+}
+
+// TestHeaderPolicy checks that the policies are found by the configured
+// base URLs of upstream services
+func TestHeaderPolicy(t *testing.T) {
+	aggregatorPolicy := server.HeaderPolicyConfiguration{Deny: []string{"Accept"}}
+	contentPolicy := server.HeaderPolicyConfiguration{Allow: []string{"X-Rh-Identity"}}
+	config := helpers.DefaultServerConfig
+	config.ProxyHeaders.Upstreams = map[string]server.HeaderPolicyConfiguration{
+		"aggregator":      aggregatorPolicy,
+		"content-service": contentPolicy,
+	}
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorReadBaseEndpoint = "http://aggregator-read:8080/api/v1/"
+	testServer := helpers.CreateHTTPServer(&config, &servicesConfig, nil, nil)
+
+	assert.Equal(t, aggregatorPolicy, server.HeaderPolicy(*testServer, servicesConfig.AggregatorBaseEndpoint))
+	assert.Equal(t, aggregatorPolicy, server.HeaderPolicy(*testServer, servicesConfig.AggregatorReadBaseEndpoint))
+	assert.Equal(t, contentPolicy, server.HeaderPolicy(*testServer, servicesConfig.ContentBaseEndpoint))
+	assert.Equal(t, server.HeaderPolicyConfiguration{}, server.HeaderPolicy(*testServer, "http://unknown/"))
+}
+
+// TestAddForwardingHeaders checks the standard forwarding headers added to
+// proxied requests
+func TestAddForwardingHeaders(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://console.redhat.com/api/v2/clusters", http.NoBody)
+	request.RemoteAddr = "10.0.0.2:1234"
+
+	dst := http.Header{}
+	dst.Set("X-Forwarded-For", "10.0.0.1")
+	server.AddForwardingHeaders(request, dst, "")
+
+	assert.Equal(t, "10.0.0.1, 10.0.0.2", dst.Get("X-Forwarded-For"))
+	assert.Equal(t, "console.redhat.com", dst.Get("X-Forwarded-Host"))
+	assert.Equal(t, "http", dst.Get("X-Forwarded-Proto"))
+	assert.Equal(t, "1.1 insights-results-smart-proxy", dst.Get("Via"))
+}
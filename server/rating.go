@@ -19,17 +19,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
 
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 	utypes "github.com/RedHatInsights/insights-operator-utils/types"
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog/log"
 )
 
+// aggregatorRatingsEndpoint returns ratings of all rules stored for the
+// organization
+const aggregatorRatingsEndpoint = "rules/organizations/{org_id}/ratings"
+
 // postRating handles the POST method for Rating endpoint
 func (server *HTTPServer) postRating(writer http.ResponseWriter, request *http.Request) {
 	log.Info().Msg("postRating")
@@ -65,7 +74,7 @@ func (server HTTPServer) postRatingToAggregator(
 	orgID ctypes.OrgID, request *http.Request, writer http.ResponseWriter,
 ) (*ctypes.RuleRating, bool) {
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorEndpoint(orgID),
 		ira_server.Rating,
 		orgID,
 	)
@@ -117,7 +126,7 @@ func (server HTTPServer) getRatingForRecommendation(
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.GetRating,
 		ruleID,
 		orgID,
@@ -162,3 +171,157 @@ func (server HTTPServer) getRatingForRecommendation(
 
 	return aggregatorResponse.Rating, nil
 }
+
+// mergeRuleRatings adds the content of rules to their ratings. Rules without
+// content are skipped, the ratings are sorted by rule selectors.
+func mergeRuleRatings(
+	ratings []ctypes.RuleRating, ruleContents map[ctypes.RuleID]*types.RuleWithContent,
+) []types.RuleRatingContent {
+	merged := make([]types.RuleRatingContent, 0, len(ratings))
+	for _, rating := range ratings {
+		ruleContent, found := ruleContents[ctypes.RuleID(rating.Rule)]
+		if !found {
+			continue
+		}
+		merged = append(merged, types.RuleRatingContent{
+			RuleSelector: ctypes.RuleSelector(rating.Rule),
+			Rating:       rating.Rating,
+			Description:  ruleContent.Description,
+			TotalRisk:    uint8(ruleContent.TotalRisk),
+			PublishDate:  ruleContent.PublishDate,
+			Tags:         ruleContent.Tags,
+		})
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].RuleSelector < merged[j].RuleSelector
+	})
+	return merged
+}
+
+// getRuleRatings returns ratings of rules stored for the organization of the
+// current user together with the rule content. Ratings of rules that are not
+// available anymore and of internal rules the user can't access are omitted.
+func (server HTTPServer) getRuleRatings(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		aggregatorRatingsEndpoint,
+		orgID,
+	)
+
+	// #nosec G107
+	aggregatorResp, err := http.Get(aggregatorURL)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			handleServerError(writer, &AggregatorServiceUnavailableError{})
+		} else {
+			handleServerError(writer, err)
+		}
+		return
+	}
+	defer services.CloseResponseBody(aggregatorResp)
+
+	responseBytes, err := io.ReadAll(aggregatorResp.Body)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if aggregatorResp.StatusCode != http.StatusOK {
+		err := responses.Send(aggregatorResp.StatusCode, writer, responseBytes)
+		if err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	var aggregatorResponse struct {
+		Ratings []ctypes.RuleRating `json:"ratings"`
+	}
+	if err := json.Unmarshal(responseBytes, &aggregatorResponse); err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("unable to parse rule ratings")
+		handleServerError(writer, err)
+		return
+	}
+
+	includeInternal := server.includeInternalRules(request)
+	ruleContents := make(map[ctypes.RuleID]*types.RuleWithContent, len(aggregatorResponse.Ratings))
+	for _, rating := range aggregatorResponse.Ratings {
+		ruleID := ctypes.RuleID(rating.Rule)
+		if content.IsRuleInternal(ruleID) && !includeInternal {
+			continue
+		}
+
+		ruleContent, err := content.GetContentForRecommendation(ruleID)
+		if err != nil {
+			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+				handleServerError(writer, err)
+				return
+			}
+			log.Warn().Err(err).Str("ruleID", rating.Rule).Msg("rated rule without content")
+			continue
+		}
+		ruleContents[ruleID] = ruleContent
+	}
+
+	ratings := mergeRuleRatings(aggregatorResponse.Ratings, ruleContents)
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("ratings", ratings))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// putRuleRating sets the rating of the rule for the current user. The rating
+// is validated before it's stored by aggregator.
+func (server *HTTPServer) putRuleRating(writer http.ResponseWriter, request *http.Request) {
+	var rating ctypes.RuleRating
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rating); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	var detail string
+	if !compositeRuleIDValidator.MatchString(rating.Rule) {
+		detail = fmt.Sprintf("rule %q must be in the format 'rule.plugin.module|ERROR_KEY'", rating.Rule)
+	} else if _, found := voteEndpoints[rating.Rating]; !found {
+		detail = fmt.Sprintf("rating must be %d, %d or %d", types.UserVoteDislike, types.UserVoteNone, types.UserVoteLike)
+	}
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	body, err := json.Marshal(rating)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+
+	server.proxyTo(server.currentServicesConfig().AggregatorBaseEndpoint, &ProxyOptions{
+		RequestModifiers: []RequestModifier{methodRewrite(http.MethodPost)},
+		Rewrite:          identityRewrite(ira_server.Rating),
+	})(writer, request)
+}
+
+// methodRewrite returns request modifier changing the method of the request
+// sent to upstream service
+func methodRewrite(method string) RequestModifier {
+	return func(request *http.Request) (*http.Request, error) {
+		request = request.Clone(request.Context())
+		request.Method = method
+		return request, nil
+	}
+}
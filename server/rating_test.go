@@ -0,0 +1,107 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestMergeRuleRatings checks that ratings are merged with the rule content
+// and that rules without content are skipped
+func TestMergeRuleRatings(t *testing.T) {
+	ratings := []ctypes.RuleRating{
+		{Rule: "ccx.rule_b|KEY", Rating: types.UserVoteDislike},
+		{Rule: "ccx.rule_removed|KEY", Rating: types.UserVoteLike},
+		{Rule: "ccx.rule_a|KEY", Rating: types.UserVoteLike},
+	}
+	ruleContents := map[ctypes.RuleID]*types.RuleWithContent{
+		"ccx.rule_a|KEY": {Description: "Rule A", TotalRisk: 3, Tags: []string{"security"}},
+		"ccx.rule_b|KEY": {Description: "Rule B", TotalRisk: 1},
+	}
+
+	merged := server.MergeRuleRatings(ratings, ruleContents)
+	assert.Len(t, merged, 2)
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_a|KEY"), merged[0].RuleSelector)
+	assert.Equal(t, types.UserVoteLike, merged[0].Rating)
+	assert.Equal(t, "Rule A", merged[0].Description)
+	assert.Equal(t, uint8(3), merged[0].TotalRisk)
+	assert.Equal(t, []string{"security"}, merged[0].Tags)
+	assert.Equal(t, ctypes.RuleSelector("ccx.rule_b|KEY"), merged[1].RuleSelector)
+	assert.Equal(t, types.UserVoteDislike, merged[1].Rating)
+}
+
+// TestHTTPServer_PutRuleRating checks that the rating is stored by
+// aggregator
+func TestHTTPServer_PutRuleRating(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	rating := `{"rule":"ccx.rule_a|KEY","rating":1}`
+	aggregatorResponse := `{"status":"ok","ratings":` + rating + `}`
+
+	helpers.GockExpectAPIRequest(
+		t,
+		helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+		&helpers.APIRequest{
+			Method:       http.MethodPost,
+			Endpoint:     ira_server.Rating,
+			EndpointArgs: []interface{}{testdata.OrgID},
+			Body:         rating,
+		},
+		&helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       aggregatorResponse,
+		},
+	)
+
+	helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, &helpers.APIRequest{
+		Method:             http.MethodPut,
+		Endpoint:           server.RuleRatingsEndpoint,
+		Body:               rating,
+		AuthorizationToken: goodJWTAuthBearer,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       aggregatorResponse,
+	})
+}
+
+// TestHTTPServer_PutRuleRatingBadBody checks validation of the rule and the
+// rating
+func TestHTTPServer_PutRuleRatingBadBody(t *testing.T) {
+	for _, body := range []string{
+		`{"rule":"ccx.rule_a","rating":1}`,
+		`{"rule":"ccx.rule_a|KEY","rating":5}`,
+		`{"rule":"ccx.rule_a|KEY","rating":1,"user":"1"}`,
+		`not a JSON`,
+	} {
+		helpers.AssertAPIv2Request(t, &serverConfigJWT, nil, nil, &helpers.APIRequest{
+			Method:             http.MethodPut,
+			Endpoint:           server.RuleRatingsEndpoint,
+			Body:               body,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// recordingWriter records the response written by handlers and by functions
+// shared with synchronous endpoints when they are called without a client,
+// e.g. by export jobs, cache warm-up, background refresh or shadow mode
+type recordingWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecordingWriter() *recordingWriter {
+	return &recordingWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (writer *recordingWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	return writer.body.Write(data)
+}
+
+func (writer *recordingWriter) WriteHeader(status int) {
+	writer.status = status
+}
+
+// errorMessage returns the description of the recorded error response
+func (writer *recordingWriter) errorMessage() string {
+	var problem Problem
+	if err := json.Unmarshal(writer.body.Bytes(), &problem); err == nil && problem.Title != "" {
+		if problem.Detail != "" {
+			return problem.Title + ": " + problem.Detail
+		}
+		return problem.Title
+	}
+	return fmt.Sprintf("upstream service responded with status code %d", writer.status)
+}
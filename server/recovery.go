@@ -0,0 +1,84 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// unknownEndpoint is the endpoint label used when the panic happened outside
+// of any registered route
+const unknownEndpoint = "unknown"
+
+// PanicsRecovered counts panics recovered while handling requests
+var PanicsRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_panics_recovered_total",
+	Help: "The total number of panics recovered while handling requests",
+}, []string{"endpoint"})
+
+// PanicError is used when the request handler panicked
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic while handling request: %v", e.Value)
+}
+
+// recoveryMiddleware converts panics raised by the request handlers into
+// 500 responses, so the connection is not dropped without any response. It
+// needs to be registered as the first middleware to guard the others too.
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// used by net/http to abort the response intentionally
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			endpoint := unknownEndpoint
+			if route := mux.CurrentRoute(request); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					endpoint = template
+				}
+			}
+			PanicsRecovered.WithLabelValues(endpoint).Inc()
+
+			log.Error().
+				Str("endpoint", endpoint).
+				Str("method", request.Method).
+				Str("stack", string(debug.Stack())).
+				Msgf("Recovered from panic: %v", recovered)
+
+			if errorReportingEnabled {
+				writer = &errorReportingWriter{ResponseWriter: writer, request: request}
+			}
+			handleServerError(writer, &PanicError{Value: recovered})
+		}()
+
+		next.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// TestRecoveryMiddleware checks that panic in the handler results in 500
+// response and is counted
+func TestRecoveryMiddleware(t *testing.T) {
+	before := testutil.ToFloat64(server.PanicsRecovered.WithLabelValues("unknown"))
+
+	handler := server.RecoveryMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("handler failure")
+	}))
+
+	recorder := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `"code":"internal_server_error"`)
+	assert.Equal(t, before+1, testutil.ToFloat64(server.PanicsRecovered.WithLabelValues("unknown")))
+}
+
+// TestRecoveryMiddlewareNoPanic checks that responses of handlers that don't
+// panic are not changed
+func TestRecoveryMiddlewareNoPanic(t *testing.T) {
+	handler := server.RecoveryMiddleware(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
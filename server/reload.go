@@ -0,0 +1,142 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+// handlerBox wraps the handler, so values of the same type are always stored
+// into atomic.Value
+type handlerBox struct {
+	http.Handler
+}
+
+// swappableHandler delegates requests to the handler that has been set last.
+// Requests that are already being handled are not affected by the swap.
+type swappableHandler struct {
+	once    sync.Once
+	mutex   sync.Mutex
+	current atomic.Value
+}
+
+// set replaces the handler
+func (handler *swappableHandler) set(next http.Handler) {
+	handler.current.Store(handlerBox{next})
+}
+
+// ServeHTTP implements http.Handler interface
+func (handler *swappableHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	handler.current.Load().(handlerBox).ServeHTTP(writer, request)
+}
+
+// Handler returns the handler serving all requests. The router is constructed
+// by the first call, it is replaced by Reload later.
+func (server *HTTPServer) Handler() http.Handler {
+	server.handler.once.Do(func() {
+		server.handler.set(server.Initialize())
+	})
+	return server.handler
+}
+
+// reloadedSettings keeps the configuration applied by the last Reload. The
+// configuration is replaced as a whole, so handlers that are running never
+// see partially updated configuration.
+type reloadedSettings struct {
+	snapshot atomic.Value
+}
+
+// configSnapshot is the configuration of HTTP server and of services applied
+// by Reload. Snapshots are never modified after they are stored.
+type configSnapshot struct {
+	config         Configuration
+	servicesConfig services.Configuration
+}
+
+// load returns the last stored snapshot, nil is returned when the
+// configuration has not been reloaded yet
+func (settings *reloadedSettings) load() *configSnapshot {
+	if settings == nil {
+		return nil
+	}
+	snapshot, _ := settings.snapshot.Load().(*configSnapshot)
+	return snapshot
+}
+
+// currentConfig returns the configuration of HTTP server including the
+// settings changed by the last Reload
+func (server *HTTPServer) currentConfig() *Configuration {
+	if snapshot := server.settings.load(); snapshot != nil {
+		return &snapshot.config
+	}
+	return &server.Config
+}
+
+// currentServicesConfig returns the configuration of services including the
+// settings changed by the last Reload
+func (server *HTTPServer) currentServicesConfig() *services.Configuration {
+	if snapshot := server.settings.load(); snapshot != nil {
+		return &snapshot.servicesConfig
+	}
+	return &server.ServicesConfig
+}
+
+// Reload applies the settings that can be changed without restart, URLs of
+// upstream services and limits of requests, and registers the endpoints again.
+// The configuration fields of the server are not modified, the new settings
+// are stored as a snapshot which is swapped atomically.
+func (server *HTTPServer) Reload(config Configuration, servicesConfig services.Configuration) {
+	// make sure the handler is initialized, so it is not replaced by
+	// the router with the original configuration later
+	server.Handler()
+
+	server.handler.mutex.Lock()
+	defer server.handler.mutex.Unlock()
+
+	snapshot := &configSnapshot{
+		config:         *server.currentConfig(),
+		servicesConfig: *server.currentServicesConfig(),
+	}
+
+	snapshot.servicesConfig.AggregatorBaseEndpoint = servicesConfig.AggregatorBaseEndpoint
+	snapshot.servicesConfig.AggregatorReadBaseEndpoint = servicesConfig.AggregatorReadBaseEndpoint
+	snapshot.servicesConfig.AggregatorShards = servicesConfig.AggregatorShards
+	snapshot.servicesConfig.UpgradeRisksPredictionEndpoint = servicesConfig.UpgradeRisksPredictionEndpoint
+	snapshot.servicesConfig.RemediationsEndpoint = servicesConfig.RemediationsEndpoint
+
+	snapshot.config.MaxRequestBodySize = config.MaxRequestBodySize
+	snapshot.config.MaxClustersInRequest = config.MaxClustersInRequest
+	snapshot.config.MultiClusterTimeBudget = config.MultiClusterTimeBudget
+
+	server.settings.snapshot.Store(snapshot)
+	server.upstreamHealth.setTargets(snapshot.servicesConfig)
+
+	// the router is registered again, because it captures the URLs of
+	// upstream services
+	server.handler.set(server.Initialize())
+
+	log.Info().
+		Str("aggregator", servicesConfig.AggregatorBaseEndpoint).
+		Str("upgrade_risks_prediction", servicesConfig.UpgradeRisksPredictionEndpoint).
+		Int64("max_request_body_size", config.MaxRequestBodySize).
+		Int("max_clusters_in_request", config.MaxClustersInRequest).
+		Msg("HTTP server configuration reloaded")
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// currentHandler makes the handler returned by Handler method usable by
+// helpers that call Initialize
+type currentHandler struct {
+	server *server.HTTPServer
+}
+
+func (handler currentHandler) Initialize() http.Handler {
+	return handler.server.Handler()
+}
+
+// TestHTTPServerReload checks that requests are proxied to the new
+// aggregator URL after the configuration is reloaded
+func TestHTTPServerReload(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		defer content.ResetContent()
+		err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+		assert.Nil(t, err)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfig, nil, nil, nil)
+		// the router with the original configuration
+		testServer.Handler()
+
+		servicesConfig := helpers.DefaultServicesConfig
+		servicesConfig.AggregatorBaseEndpoint = "http://reloaded-aggregator:8080/api/v1/"
+		testServer.Reload(helpers.DefaultServerConfig, servicesConfig)
+
+		helpers.GockExpectAPIRequest(t, servicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodPut,
+			Endpoint:     ira_server.LikeRuleEndpoint,
+			EndpointArgs: []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1, testdata.OrgID, testdata.UserID},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       `{"status": "ok"}`,
+		})
+
+		iou_helpers.AssertAPIRequest(t, currentHandler{testServer}, helpers.DefaultServerConfig.APIv1Prefix, &helpers.APIRequest{
+			Method:             http.MethodPut,
+			Endpoint:           server.LikeRuleEndpoint,
+			EndpointArgs:       []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1},
+			UserID:             testdata.UserID,
+			OrgID:              testdata.OrgID,
+			AuthorizationToken: goodJWTAuthBearer,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       `{"status": "ok"}`,
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServerReloadLimits checks that the limits of requests are applied
+// after the configuration is reloaded
+func TestHTTPServerReloadLimits(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	body := `{"clusters": ["` + strings.Repeat("0", 100) + `"]}`
+
+	config := serverConfigJWT
+	config.MaxRequestBodySize = 64
+	testServer.Reload(config, helpers.DefaultServicesConfig)
+
+	request := httptest.NewRequest(http.MethodPost, config.APIv1Prefix+server.ReportForListOfClustersPayloadEndpoint, strings.NewReader(body))
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+	recorder := httptest.NewRecorder()
+	testServer.Handler().ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+// TestHTTPServerReloadConcurrentRequests checks that the configuration can
+// be reloaded while requests are being handled. It is meant to be run with
+// race detector enabled.
+func TestHTTPServerReloadConcurrentRequests(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	handler := testServer.Handler()
+	body := `{"clusters": ["` + strings.Repeat("0", 1000) + `"]}`
+
+	const requests = 50
+	var wg sync.WaitGroup
+	wg.Add(requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodPost, serverConfigJWT.APIv1Prefix+server.ReportForListOfClustersPayloadEndpoint, strings.NewReader(body))
+			request.Header.Set("Authorization", goodJWTAuthBearer)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			// the body exceeds the limit before and after every reload
+			assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		config := serverConfigJWT
+		config.MaxRequestBodySize = int64(64 + i)
+		servicesConfig := helpers.DefaultServicesConfig
+		servicesConfig.AggregatorBaseEndpoint = fmt.Sprintf("http://reloaded-aggregator-%d:8080/api/v1/", i)
+		testServer.Reload(config, servicesConfig)
+	}
+	wg.Wait()
+}
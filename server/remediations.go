@@ -0,0 +1,246 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// RemediationsResolutionsEndpoint returns the resolutions of the
+	// issues listed in request body
+	RemediationsResolutionsEndpoint = "resolutions"
+	// RemediationsPlaybookEndpoint generates the playbook resolving the
+	// issues listed in request body
+	RemediationsPlaybookEndpoint = "playbook"
+
+	// remediationsIssuePrefix precedes the rule selector in the issue IDs
+	// used by remediations service
+	remediationsIssuePrefix = "advisor:"
+
+	// remediationsTimeout limits the time of requests to remediations
+	// service
+	remediationsTimeout = 10 * time.Second
+
+	// playbookCacheCapacity limits the number of rules whose playbook
+	// availability is remembered at once
+	playbookCacheCapacity = 10000
+)
+
+// remediationsIssue is the issue whose playbook is requested, systems are the
+// clusters the issue is resolved on
+type remediationsIssue struct {
+	ID      string               `json:"id"`
+	Systems []ctypes.ClusterName `json:"systems"`
+}
+
+// newPlaybookCache constructs the cache of playbook availability of rules.
+// Nil is returned when the cache is disabled by zero TTL.
+func newPlaybookCache(ttl time.Duration) cache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.NewMemoryCache(playbookCacheCapacity, ttl)
+}
+
+// remediationsIssueID returns ID of the issue of the rule used by
+// remediations service
+func remediationsIssueID(ruleID ctypes.RuleID, errorKey ctypes.ErrorKey) string {
+	return fmt.Sprintf("%v%v|%v", remediationsIssuePrefix, ruleID, errorKey)
+}
+
+// postToRemediations sends the request to remediations service on behalf of
+// the user, identity of the user is taken from the original request
+func (server HTTPServer) postToRemediations(request *http.Request, endpoint string, body interface{}) (*http.Response, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	remediationsURL := httputils.MakeURLToEndpoint(server.currentServicesConfig().RemediationsEndpoint, endpoint)
+	remediationsRequest, err := http.NewRequest(http.MethodPost, remediationsURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	remediationsRequest.Header.Set(contentTypeHeader, JSONContentType)
+	for _, header := range []string{"x-rh-identity", "Authorization"} {
+		if value := request.Header.Get(header); value != "" {
+			remediationsRequest.Header.Set(header, value)
+		}
+	}
+
+	client := http.Client{Timeout: remediationsTimeout}
+	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	response, err := client.Do(remediationsRequest)
+	if err != nil {
+		log.Error().Err(err).Str("url", remediationsURL).Msg("error reaching the remediations service")
+		return nil, &RemediationsServiceUnavailableError{}
+	}
+	return response, nil
+}
+
+// fetchPlaybookAvailability asks remediations service which of the issues
+// have a playbook. Only the issues with resolutions are returned by it.
+func (server HTTPServer) fetchPlaybookAvailability(request *http.Request, issues []string) (map[string]bool, error) {
+	response, err := server.postToRemediations(request, RemediationsResolutionsEndpoint, map[string][]string{
+		"issues": issues,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer services.CloseResponseBody(response)
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remediations service responded with status code %d", response.StatusCode)
+	}
+
+	var resolutions map[string]json.RawMessage
+	if err := json.NewDecoder(response.Body).Decode(&resolutions); err != nil {
+		return nil, err
+	}
+
+	availability := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		_, available := resolutions[issue]
+		availability[issue] = available
+	}
+	return availability, nil
+}
+
+// setPlaybookAvailability sets playbook_available flag of the rules. Only the
+// rules not found in cache are looked up in remediations service, all at
+// once. The flags are not set when remediations service is not configured or
+// when it can't be reached.
+func (server HTTPServer) setPlaybookAvailability(request *http.Request, rules []types.RuleWithContentResponse) {
+	if server.currentServicesConfig().RemediationsEndpoint == "" || len(rules) == 0 {
+		return
+	}
+
+	availability := make(map[string]bool, len(rules))
+	var missing []string
+	for i := range rules {
+		issue := remediationsIssueID(rules[i].RuleID, rules[i].ErrorKey)
+		if server.playbookCache != nil {
+			if available, found := server.playbookCache.Get(issue); found {
+				availability[issue] = available.(bool)
+				continue
+			}
+		}
+		missing = append(missing, issue)
+	}
+
+	if len(missing) > 0 {
+		fetched, err := server.fetchPlaybookAvailability(request, missing)
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to retrieve playbook availability from remediations service")
+			return
+		}
+		for issue, available := range fetched {
+			availability[issue] = available
+			if server.playbookCache != nil {
+				server.playbookCache.Set(issue, available, 0)
+			}
+		}
+	}
+
+	for i := range rules {
+		available := availability[remediationsIssueID(rules[i].RuleID, rules[i].ErrorKey)]
+		rules[i].PlaybookAvailable = &available
+	}
+}
+
+// generatePlaybook requests the Ansible playbook resolving the selected rules
+// on the cluster from remediations service. The playbook is sent to client
+// as returned by remediations service.
+func (server HTTPServer) generatePlaybook(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	if !successful {
+		// error has been handled already
+		return
+	}
+
+	var playbookRequest types.PlaybookRequest
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&playbookRequest); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	ruleIDs, detail := validateRuleContentBatch(&types.RuleContentBatchRequest{Rules: playbookRequest.Rules})
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	if err := server.checkClusterOrganization(orgID, clusterID); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	issues := make([]remediationsIssue, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		issues = append(issues, remediationsIssue{
+			ID:      remediationsIssuePrefix + string(ruleID),
+			Systems: []ctypes.ClusterName{clusterID},
+		})
+	}
+
+	response, err := server.postToRemediations(request, RemediationsPlaybookEndpoint, map[string]interface{}{
+		"issues": issues,
+	})
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	defer services.CloseResponseBody(response)
+
+	playbook, err := io.ReadAll(response.Body)
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("unable to read the playbook")
+		handleServerError(writer, &RemediationsServiceUnavailableError{})
+		return
+	}
+
+	if contentType := response.Header.Get(contentTypeHeader); contentType != "" {
+		writer.Header().Set(contentTypeHeader, contentType)
+	}
+	writer.WriteHeader(response.StatusCode)
+	if _, err := writer.Write(playbook); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestSetPlaybookAvailability checks that playbook availability of rules is
+// read from remediations service and cached
+func TestSetPlaybookAvailability(t *testing.T) {
+	var lookups int32
+	remediations := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&lookups, 1)
+		assert.Equal(t, "/"+server.RemediationsResolutionsEndpoint, request.URL.Path)
+
+		var body struct {
+			Issues []string `json:"issues"`
+		}
+		helpers.FailOnError(t, json.NewDecoder(request.Body).Decode(&body))
+		assert.ElementsMatch(t, []string{"advisor:ccx.rule_a|KEY", "advisor:ccx.rule_b|KEY"}, body.Issues)
+		_, _ = writer.Write([]byte(`{"advisor:ccx.rule_a|KEY":{"id":"advisor:ccx.rule_a|KEY","resolutions":[{"id":"fix"}]}}`))
+	}))
+	defer remediations.Close()
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.RemediationsEndpoint = remediations.URL + "/"
+	servicesConfig.RemediationsCacheTTL = time.Minute
+	testServer := helpers.CreateHTTPServer(nil, &servicesConfig, nil, nil)
+
+	for i := 0; i < 2; i++ {
+		rules := []types.RuleWithContentResponse{
+			{RuleID: "ccx.rule_a", ErrorKey: "KEY"},
+			{RuleID: "ccx.rule_b", ErrorKey: "KEY"},
+		}
+		request := httptest.NewRequest(http.MethodGet, "/api/v2/cluster/x/reports", http.NoBody)
+		server.SetPlaybookAvailability(*testServer, request, rules)
+
+		assert.NotNil(t, rules[0].PlaybookAvailable)
+		assert.True(t, *rules[0].PlaybookAvailable)
+		assert.NotNil(t, rules[1].PlaybookAvailable)
+		assert.False(t, *rules[1].PlaybookAvailable)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+}
+
+// TestGeneratePlaybook checks that the playbook for selected rules is
+// requested from remediations service on behalf of the user
+func TestGeneratePlaybook(t *testing.T) {
+	identity := supportIdentity("other")
+	remediations := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/"+server.RemediationsPlaybookEndpoint, request.URL.Path)
+		assert.Equal(t, identity, request.Header.Get("x-rh-identity"))
+
+		var body struct {
+			Issues []struct {
+				ID      string   `json:"id"`
+				Systems []string `json:"systems"`
+			} `json:"issues"`
+		}
+		helpers.FailOnError(t, json.NewDecoder(request.Body).Decode(&body))
+		assert.Len(t, body.Issues, 1)
+		assert.Equal(t, "advisor:ccx.rule_a|KEY", body.Issues[0].ID)
+		assert.Equal(t, []string{string(testdata.ClusterName)}, body.Issues[0].Systems)
+
+		writer.Header().Set("Content-Type", "text/vnd.yaml")
+		_, _ = writer.Write([]byte("- name: fix\n"))
+	}))
+	defer remediations.Close()
+
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.RemediationsEndpoint = remediations.URL + "/"
+	router := helpers.CreateHTTPServer(&config, &servicesConfig, nil, nil).Initialize()
+
+	endpoint := config.APIv2Prefix + strings.Replace(server.PlaybookEndpoint, "{cluster}", string(testdata.ClusterName), 1)
+	for body, expectedStatus := range map[string]int{
+		`{"rules":["ccx.rule_a|KEY"]}`: http.StatusOK,
+		`{"rules":[]}`:                 http.StatusBadRequest,
+		`{"rules":["ccx.rule_a"]}`:     http.StatusBadRequest,
+	} {
+		request := httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		request.Header.Set("x-rh-identity", identity)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, expectedStatus, recorder.Code, body)
+		if expectedStatus == http.StatusOK {
+			assert.Equal(t, "text/vnd.yaml", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, "- name: fix\n", recorder.Body.String())
+		}
+	}
+}
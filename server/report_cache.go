@@ -0,0 +1,172 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
+)
+
+const (
+	// names of endpoints whose caching of reports can be configured
+	reportCacheEndpointV1      = "report_v1"
+	reportCacheEndpointV2      = "report_v2"
+	reportCacheEndpointOCM     = "ocm_report"
+	reportCacheEndpointAdvisor = "advisor_reports"
+
+	// defaultReportCacheCapacity is used when the capacity of the report
+	// cache is not configured
+	defaultReportCacheCapacity = 10000
+)
+
+// ReportCacheConfiguration represents configuration of the cache of reports
+// read from aggregator. Reports are cached only for the configured
+// endpoints.
+type ReportCacheConfiguration struct {
+	// Capacity is the maximal number of cached reports
+	Capacity int `mapstructure:"capacity" toml:"capacity"`
+	// Endpoints contains the caching settings by name of endpoint
+	Endpoints map[string]ReportCacheEndpointConfiguration `mapstructure:"endpoints" toml:"endpoints"`
+}
+
+// ReportCacheEndpointConfiguration represents caching settings of single
+// endpoint. The report is fresh for TTL after it has been read from
+// aggregator. Then it is stale for StaleTTL: it is still served, but it is
+// refreshed in background.
+type ReportCacheEndpointConfiguration struct {
+	TTL      time.Duration `mapstructure:"ttl" toml:"ttl"`
+	StaleTTL time.Duration `mapstructure:"stale_ttl" toml:"stale_ttl"`
+}
+
+// cachedReport is the report read from aggregator with the time of reading
+type cachedReport struct {
+	report    *ctypes.ReportResponse
+	fetchedAt time.Time
+}
+
+// reportCache stores reports read from aggregator, so they can be served
+// while aggregator is slow. The reports are shared by all endpoints, only the
+// freshness of reports differs.
+type reportCache struct {
+	cache     cache.Cache
+	endpoints map[string]ReportCacheEndpointConfiguration
+	// refreshing contains keys of reports being refreshed in background
+	refreshing sync.Map
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+// newReportCache constructs the cache of reports. Nil is returned when no
+// endpoint is configured to use it.
+func newReportCache(config ReportCacheConfiguration) *reportCache {
+	endpoints := make(map[string]ReportCacheEndpointConfiguration)
+	var lifetime time.Duration
+	for endpoint, endpointConfig := range config.Endpoints {
+		if endpointConfig.TTL <= 0 {
+			continue
+		}
+		if endpointConfig.StaleTTL < 0 {
+			endpointConfig.StaleTTL = 0
+		}
+		endpoints[endpoint] = endpointConfig
+		if total := endpointConfig.TTL + endpointConfig.StaleTTL; total > lifetime {
+			lifetime = total
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	capacity := config.Capacity
+	if capacity <= 0 {
+		capacity = defaultReportCacheCapacity
+	}
+	return &reportCache{
+		cache:     cache.NewMemoryCache(capacity, lifetime),
+		endpoints: endpoints,
+		now:       time.Now,
+	}
+}
+
+// reportCacheKey returns the key of report read by the user
+func reportCacheKey(orgID ctypes.OrgID, clusterID ctypes.ClusterName, userID ctypes.UserID) string {
+	return fmt.Sprintf("%v/%v/%v", orgID, clusterID, userID)
+}
+
+// enabled returns true if the reports are cached for the endpoint
+func (reports *reportCache) enabled(endpoint string) bool {
+	if reports == nil {
+		return false
+	}
+	_, found := reports.endpoints[endpoint]
+	return found
+}
+
+// get returns the cached report if it can still be served by the endpoint.
+// The report is stale when its TTL has elapsed.
+func (reports *reportCache) get(endpoint, key string) (report *ctypes.ReportResponse, stale, found bool) {
+	if !reports.enabled(endpoint) {
+		return nil, false, false
+	}
+
+	value, found := reports.cache.Get(key)
+	if !found {
+		return nil, false, false
+	}
+	cached := value.(cachedReport)
+	config := reports.endpoints[endpoint]
+
+	age := reports.now().Sub(cached.fetchedAt)
+	if age > config.TTL+config.StaleTTL {
+		return nil, false, false
+	}
+	return cached.report, age > config.TTL, true
+}
+
+// set stores the report read from aggregator
+func (reports *reportCache) set(key string, report *ctypes.ReportResponse) {
+	if reports == nil {
+		return
+	}
+	reports.cache.Set(key, cachedReport{report: report, fetchedAt: reports.now()}, 0)
+}
+
+// refresh reads the report again in background unless it is being refreshed
+// already
+func (reports *reportCache) refresh(key string, read func() (*ctypes.ReportResponse, bool)) {
+	if _, running := reports.refreshing.LoadOrStore(key, true); running {
+		return
+	}
+	go func() {
+		defer reports.refreshing.Delete(key)
+		reports.revalidate(key, read)
+	}()
+}
+
+// revalidate reads the report again. The report is stored only when it is
+// read successfully, so the stale copy is served when aggregator fails.
+func (reports *reportCache) revalidate(key string, read func() (*ctypes.ReportResponse, bool)) {
+	if report, successful := read(); successful {
+		reports.set(key, report)
+		return
+	}
+	log.Warn().Str("key", key).Msg("Unable to refresh stale report")
+}
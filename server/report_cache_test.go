@@ -0,0 +1,161 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestReportCacheFreshness checks that the report is fresh for TTL, stale
+// for stale TTL and not served afterwards
+func TestReportCacheFreshness(t *testing.T) {
+	reports := server.NewReportCache(server.ReportCacheConfiguration{
+		Endpoints: map[string]server.ReportCacheEndpointConfiguration{
+			"report_v2":  {TTL: time.Minute, StaleTTL: 10 * time.Minute},
+			"ocm_report": {TTL: time.Minute},
+		},
+	})
+	now := time.Now()
+	server.SetReportCacheNow(reports, func() time.Time { return now })
+
+	report := &ctypes.ReportResponse{}
+	server.ReportCacheSet(reports, "key", report)
+
+	cached, stale, found := server.ReportCacheGet(reports, "report_v2", "key")
+	assert.True(t, found)
+	assert.False(t, stale)
+	assert.Equal(t, report, cached)
+
+	_, _, found = server.ReportCacheGet(reports, "report_v1", "key")
+	assert.False(t, found, "endpoint without caching")
+
+	now = now.Add(5 * time.Minute)
+	_, stale, found = server.ReportCacheGet(reports, "report_v2", "key")
+	assert.True(t, found)
+	assert.True(t, stale)
+	_, _, found = server.ReportCacheGet(reports, "ocm_report", "key")
+	assert.False(t, found, "endpoint without stale TTL")
+
+	now = now.Add(10 * time.Minute)
+	_, _, found = server.ReportCacheGet(reports, "report_v2", "key")
+	assert.False(t, found)
+}
+
+// TestReportCacheDisabled checks that no cache is constructed unless some
+// endpoint uses it
+func TestReportCacheDisabled(t *testing.T) {
+	assert.Nil(t, server.NewReportCache(server.ReportCacheConfiguration{}))
+	assert.Nil(t, server.NewReportCache(server.ReportCacheConfiguration{
+		Endpoints: map[string]server.ReportCacheEndpointConfiguration{"report_v2": {}},
+	}))
+}
+
+// TestReportCacheRevalidate checks that failed refresh keeps the stale
+// report and successful one replaces it
+func TestReportCacheRevalidate(t *testing.T) {
+	reports := server.NewReportCache(server.ReportCacheConfiguration{
+		Endpoints: map[string]server.ReportCacheEndpointConfiguration{
+			"report_v2": {TTL: time.Minute, StaleTTL: time.Hour},
+		},
+	})
+	stale := &ctypes.ReportResponse{}
+	refreshed := &ctypes.ReportResponse{}
+	server.ReportCacheSet(reports, "key", stale)
+
+	server.ReportCacheRevalidate(reports, "key", func() (*ctypes.ReportResponse, bool) {
+		return nil, false
+	})
+	cached, _, _ := server.ReportCacheGet(reports, "report_v2", "key")
+	assert.Same(t, stale, cached)
+
+	server.ReportCacheRevalidate(reports, "key", func() (*ctypes.ReportResponse, bool) {
+		return refreshed, true
+	})
+	cached, _, _ = server.ReportCacheGet(reports, "report_v2", "key")
+	assert.Same(t, refreshed, cached)
+}
+
+// TestReportCacheRefreshOnce checks that the report is not refreshed again
+// while it is being refreshed
+func TestReportCacheRefreshOnce(t *testing.T) {
+	reports := server.NewReportCache(server.ReportCacheConfiguration{
+		Endpoints: map[string]server.ReportCacheEndpointConfiguration{
+			"report_v2": {TTL: time.Minute, StaleTTL: time.Hour},
+		},
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server.ReportCacheRefresh(reports, "key", func() (*ctypes.ReportResponse, bool) {
+		close(started)
+		<-release
+		return nil, false
+	})
+	<-started
+
+	server.ReportCacheRefresh(reports, "key", func() (*ctypes.ReportResponse, bool) {
+		t.Error("report refreshed twice")
+		return nil, false
+	})
+	close(release)
+}
+
+// TestReportEndpointV2Cached checks that the cached report is served without
+// calling aggregator again
+func TestReportEndpointV2Cached(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     ira_server.ReportEndpoint,
+			EndpointArgs: []interface{}{testdata.OrgID, testdata.ClusterName, userIDOnGoodJWTAuthBearer},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report1RuleExpectedResponse,
+		})
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		config := serverConfigJWT
+		config.ReportCache.Endpoints = map[string]server.ReportCacheEndpointConfiguration{
+			"report_v2": {TTL: time.Hour},
+		}
+		router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+		endpoint := "cluster/" + string(testdata.ClusterName) + "/reports"
+		first := serveV2Request(router, endpoint)
+		assert.Equal(t, http.StatusOK, first.Code)
+
+		second := serveV2Request(router, endpoint)
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+	}, testTimeout)
+}
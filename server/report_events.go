@@ -0,0 +1,238 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// defaultReportEventsPollInterval is used when the poll interval is
+	// not configured
+	defaultReportEventsPollInterval = 10 * time.Second
+	// defaultReportEventsStreamDuration is used when the stream duration
+	// is not configured. It needs to be shorter than the write timeout of
+	// the HTTP server.
+	defaultReportEventsStreamDuration = 25 * time.Second
+
+	// eventStreamContentType is the content type of server-sent events
+	eventStreamContentType = "text/event-stream"
+	// lastEventIDHeader is sent by clients reconnecting to the stream
+	lastEventIDHeader = "Last-Event-ID"
+
+	// reportEventSubscribed is the first event of new subscription, it
+	// carries the time when the current report was gathered
+	reportEventSubscribed = "subscribed"
+	// reportEventNewReport is sent when newer report becomes available
+	reportEventNewReport = "report"
+)
+
+// ReportEventsConfiguration represents configuration of the stream of
+// server-sent events about new reports
+type ReportEventsConfiguration struct {
+	// PollInterval is the time between reads of the report from
+	// aggregator
+	PollInterval time.Duration `mapstructure:"poll_interval" toml:"poll_interval"`
+	// StreamDuration limits the time of single stream, clients reconnect
+	// afterwards. It needs to be shorter than the write timeout of the HTTP
+	// server (30 seconds).
+	StreamDuration time.Duration `mapstructure:"stream_duration" toml:"stream_duration"`
+}
+
+// flushableWriter adds Flush to the response writer of the request logging
+// middleware. Flush is sent directly to the writer of the HTTP server.
+type flushableWriter struct {
+	http.ResponseWriter
+	flusher http.ResponseWriter
+}
+
+// Flush sends buffered data to the client, it is needed by streamed
+// responses
+func (writer *flushableWriter) Flush() {
+	if flusher, ok := writer.flusher.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// logRequestMiddleware logs the request and counts the responses like
+// httputils.LogRequest, but the response writer passed to the handlers stays
+// flushable, so the events can be streamed
+func logRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		httputils.LogRequest(http.HandlerFunc(func(logging http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(&flushableWriter{ResponseWriter: logging, flusher: writer}, request)
+		})).ServeHTTP(writer, request)
+	})
+}
+
+// reportEvent is the data of events sent to the subscribers
+type reportEvent struct {
+	ClusterID  types.ClusterName `json:"cluster"`
+	GatheredAt types.Timestamp   `json:"gathered_at"`
+}
+
+// readReportGatheredAt reads the time when the latest report of the cluster
+// was gathered. Empty timestamp is returned when the cluster has no report.
+func (server HTTPServer) readReportGatheredAt(
+	orgID types.OrgID, clusterID types.ClusterName, userID types.UserID,
+) (types.Timestamp, error) {
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		ira_server.ReportEndpoint,
+		orgID,
+		clusterID,
+		userID,
+	)
+
+	// #nosec G107
+	response, err := http.Get(aggregatorURL)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			return "", &AggregatorServiceUnavailableError{}
+		}
+		return "", err
+	}
+	defer services.CloseResponseBody(response)
+
+	if response.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("aggregator responded with status code %d", response.StatusCode)
+	}
+
+	var aggregatorResponse struct {
+		Report struct {
+			Meta struct {
+				GatheredAt types.Timestamp `json:"gathered_at"`
+			} `json:"meta"`
+		} `json:"report"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&aggregatorResponse)
+	return aggregatorResponse.Report.Meta.GatheredAt, err
+}
+
+// writeReportEvent sends the event to the subscriber. The time when the
+// report was gathered is used as event ID, so reconnecting clients are not
+// notified about the same report again.
+func writeReportEvent(writer http.ResponseWriter, name string, event reportEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "event: %s\nid: %s\ndata: %s\n\n", name, event.GatheredAt, data)
+	writer.(http.Flusher).Flush()
+	return err
+}
+
+// reportEvents streams server-sent events about new reports of the cluster.
+// Aggregator is polled for the latest report and an event is sent when the
+// report was gathered at different time than the last one seen by the client.
+// The stream is closed after configured time and the client is expected to
+// reconnect with Last-Event-ID header.
+func (server *HTTPServer) reportEvents(writer http.ResponseWriter, request *http.Request) {
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// Error message handled by function
+	if !successful {
+		return
+	}
+
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	if err = server.checkClusterOrganization(orgID, clusterID); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if _, ok := writer.(http.Flusher); !ok {
+		handleServerError(writer, errors.New("streaming of the response is not supported"))
+		return
+	}
+
+	pollInterval := server.Config.ReportEvents.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultReportEventsPollInterval
+	}
+	streamDuration := server.Config.ReportEvents.StreamDuration
+	if streamDuration <= 0 {
+		streamDuration = defaultReportEventsStreamDuration
+	}
+
+	// errors are reported by HTTP status until the stream starts
+	gatheredAt, err := server.readReportGatheredAt(orgID, clusterID, userID)
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("Unable to read report for report events")
+		handleServerError(writer, err)
+		return
+	}
+
+	writer.Header().Set(contentTypeHeader, eventStreamContentType)
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+	// clients wait for the poll interval before they reconnect
+	if _, err = fmt.Fprintf(writer, "retry: %d\n\n", pollInterval.Milliseconds()); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+		return
+	}
+
+	lastSeen := types.Timestamp(request.Header.Get(lastEventIDHeader))
+	if lastSeen == "" {
+		err = writeReportEvent(writer, reportEventSubscribed, reportEvent{ClusterID: clusterID, GatheredAt: gatheredAt})
+		lastSeen = gatheredAt
+	}
+
+	deadline := time.NewTimer(streamDuration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for err == nil {
+		if gatheredAt != "" && gatheredAt != lastSeen {
+			err = writeReportEvent(writer, reportEventNewReport, reportEvent{ClusterID: clusterID, GatheredAt: gatheredAt})
+			lastSeen = gatheredAt
+			continue
+		}
+
+		select {
+		case <-request.Context().Done():
+			return
+		case <-deadline.C:
+			return
+		case <-ticker.C:
+			gatheredAt, err = server.readReportGatheredAt(orgID, clusterID, userID)
+		}
+	}
+
+	// the client reconnects and receives the error by HTTP status
+	log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("Report events stream closed")
+}
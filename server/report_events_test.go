@@ -0,0 +1,123 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+const (
+	reportGatheredAt1 = "2023-01-01T10:00:00Z"
+	reportGatheredAt2 = "2023-01-01T12:00:00Z"
+)
+
+// newReportEventsTestRouter returns router of the server with aggregator
+// responding by reports gathered at given times, one per request. Aggregator
+// responds by an error when all reports have been sent.
+func newReportEventsTestRouter(t *testing.T, gatheredAt ...string) (http.Handler, func()) {
+	var mutex sync.Mutex
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if len(gatheredAt) == 0 {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, err := fmt.Fprintf(writer, `{"report": {"meta": {"gathered_at": "%s"}, "reports": []}, "status": "ok"}`, gatheredAt[0])
+		helpers.FailOnError(t, err)
+		gatheredAt = gatheredAt[1:]
+	}))
+
+	config := serverConfigJWT
+	config.ReportEvents = server.ReportEventsConfiguration{
+		PollInterval:   10 * time.Millisecond,
+		StreamDuration: 5 * time.Second,
+	}
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL
+	testServer := helpers.CreateHTTPServer(&config, &servicesConfig, nil, nil)
+	return testServer.Initialize(), aggregator.Close
+}
+
+// reportEventsRequest returns request subscribing to the events of the
+// cluster
+func reportEventsRequest(lastEventID string) *http.Request {
+	endpoint := serverConfigJWT.APIv2Prefix + strings.Replace(server.ReportEventsEndpoint, "{cluster}", string(testdata.ClusterName), 1)
+	request := httptest.NewRequest(http.MethodGet, endpoint, nil)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	return request
+}
+
+// TestReportEvents checks that the subscriber is notified about the new
+// report
+func TestReportEvents(t *testing.T) {
+	router, closeAggregator := newReportEventsTestRouter(t, reportGatheredAt1, reportGatheredAt1, reportGatheredAt2)
+	defer closeAggregator()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, reportEventsRequest(""))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "retry: 10\n\n"+
+		"event: subscribed\nid: "+reportGatheredAt1+"\n"+
+		`data: {"cluster":"`+string(testdata.ClusterName)+`","gathered_at":"`+reportGatheredAt1+`"}`+"\n\n"+
+		"event: report\nid: "+reportGatheredAt2+"\n"+
+		`data: {"cluster":"`+string(testdata.ClusterName)+`","gathered_at":"`+reportGatheredAt2+`"}`+"\n\n",
+		recorder.Body.String())
+}
+
+// TestReportEventsReconnect checks that the reconnecting client is notified
+// about report it has not seen yet
+func TestReportEventsReconnect(t *testing.T) {
+	router, closeAggregator := newReportEventsTestRouter(t, reportGatheredAt2)
+	defer closeAggregator()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, reportEventsRequest(reportGatheredAt1))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "retry: 10\n\n"+
+		"event: report\nid: "+reportGatheredAt2+"\n"+
+		`data: {"cluster":"`+string(testdata.ClusterName)+`","gathered_at":"`+reportGatheredAt2+`"}`+"\n\n",
+		recorder.Body.String())
+}
+
+// TestReportEventsAggregatorError checks that errors are reported by HTTP
+// status before the stream starts
+func TestReportEventsAggregatorError(t *testing.T) {
+	router, closeAggregator := newReportEventsTestRouter(t)
+	defer closeAggregator()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, reportEventsRequest(""))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
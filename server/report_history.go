@@ -0,0 +1,161 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// aggregatorReportHistoryEndpoint returns number of rule hits per day
+	// for given cluster
+	aggregatorReportHistoryEndpoint = "organizations/{org_id}/clusters/{cluster}/report/history"
+
+	// DaysParam parameter containing number of days returned by the report
+	// history endpoint
+	DaysParam = "days"
+
+	defaultReportHistoryDays = 30
+	maxReportHistoryDays     = 90
+	reportHistoryDateFormat  = "2006-01-02"
+)
+
+// readReportHistoryDays reads number of days of the history from query
+func readReportHistoryDays(request *http.Request) (int, error) {
+	days, err := readNonNegativeIntParam(request, DaysParam, defaultReportHistoryDays)
+	if err != nil {
+		return 0, err
+	}
+	if days < 1 || days > maxReportHistoryDays {
+		return 0, &RouterParsingError{
+			paramName:  DaysParam,
+			paramValue: request.URL.Query().Get(DaysParam),
+			errString:  "value between 1 and " + strconv.Itoa(maxReportHistoryDays) + " is expected",
+		}
+	}
+	return days, nil
+}
+
+// fillReportHistory returns one item for each of the last days ending by
+// today, oldest first. Days missing in the history retrieved from aggregator
+// are returned with zero hits.
+func fillReportHistory(history []types.ReportHistoryDay, days int, now time.Time) []types.ReportHistoryDay {
+	byDate := make(map[string]types.ReportHistoryDay, len(history))
+	for _, day := range history {
+		byDate[day.Date] = day
+	}
+
+	today := now.UTC()
+	filled := make([]types.ReportHistoryDay, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(reportHistoryDateFormat)
+		day, found := byDate[date]
+		if !found {
+			day = types.ReportHistoryDay{Date: date}
+		}
+		if day.HitsByTotalRisk == nil {
+			day.HitsByTotalRisk = map[string]int{}
+		}
+		filled = append(filled, day)
+	}
+	return filled
+}
+
+// getReportHistory returns number of rules hitting the cluster per day, so
+// the trend can be charted
+func (server HTTPServer) getReportHistory(writer http.ResponseWriter, request *http.Request) {
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// error handled by function
+	if !successful {
+		return
+	}
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	days, err := readReportHistoryDays(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if err = server.checkClusterOrganization(orgID, clusterID); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		aggregatorReportHistoryEndpoint,
+		orgID,
+		clusterID,
+	) + "?" + DaysParam + "=" + strconv.Itoa(days)
+
+	// #nosec G107
+	aggregatorResp, err := http.Get(aggregatorURL)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			handleServerError(writer, &AggregatorServiceUnavailableError{})
+		} else {
+			handleServerError(writer, err)
+		}
+		return
+	}
+	defer services.CloseResponseBody(aggregatorResp)
+
+	responseBytes, err := io.ReadAll(aggregatorResp.Body)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if aggregatorResp.StatusCode != http.StatusOK {
+		err := responses.Send(aggregatorResp.StatusCode, writer, responseBytes)
+		if err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	var aggregatorResponse struct {
+		History []types.ReportHistoryDay `json:"history"`
+	}
+	if err := json.Unmarshal(responseBytes, &aggregatorResponse); err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("unable to parse report history")
+		handleServerError(writer, err)
+		return
+	}
+
+	history := fillReportHistory(aggregatorResponse.History, days, time.Now())
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("history", history))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func reportHistoryEndpoint(query string) string {
+	return strings.Replace(server.ReportHistoryEndpoint, "{cluster}", string(testdata.ClusterName), 1) + query
+}
+
+// TestReportHistory checks that the days missing in the history retrieved
+// from aggregator are filled in
+func TestReportHistory(t *testing.T) {
+	today := time.Now().UTC().Format("2006-01-02")
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t,
+			fmt.Sprintf("/organizations/%v/clusters/%v/report/history", testdata.OrgID, testdata.ClusterName),
+			request.URL.Path)
+		assert.Equal(t, "7", request.URL.Query().Get(server.DaysParam))
+		_, _ = fmt.Fprintf(writer, `{"status": "ok", "history": [
+			{"date": "%s", "hit_count": 3, "hits_by_total_risk": {"1": 1, "3": 2}, "report_found": true}
+		]}`, today)
+	}))
+	defer aggregator.Close()
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL
+	router := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, nil, nil).Initialize()
+
+	recorder := serveV2Request(router, reportHistoryEndpoint("?days=7"))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		History []types.ReportHistoryDay `json:"history"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	assert.Len(t, response.History, 7)
+	assert.Equal(t, types.ReportHistoryDay{
+		Date:            today,
+		HitCount:        3,
+		HitsByTotalRisk: map[string]int{"1": 1, "3": 2},
+		ReportFound:     true,
+	}, response.History[6])
+	assert.Equal(t, 0, response.History[0].HitCount)
+	assert.False(t, response.History[0].ReportFound)
+}
+
+// TestReportHistoryInvalidDays checks that number of days out of the
+// supported range is refused
+func TestReportHistoryInvalidDays(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	for _, days := range []string{"0", "91", "week"} {
+		recorder := serveV2Request(router, reportHistoryEndpoint("?days="+days))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, days)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+)
+
+const (
+	// defaultMaxRequestBodySize is used when the limit of request body
+	// size is not configured
+	defaultMaxRequestBodySize = 1024 * 1024
+
+	// maxBytesReaderError is the message of error returned by
+	// http.MaxBytesReader when the limit is exceeded
+	maxBytesReaderError = "http: request body too large"
+)
+
+// maxRequestBodySize returns the configured limit of request body size
+func (server *HTTPServer) maxRequestBodySize() int64 {
+	if server.currentConfig().MaxRequestBodySize <= 0 {
+		return defaultMaxRequestBodySize
+	}
+	return server.currentConfig().MaxRequestBodySize
+}
+
+// limitedBody reports exceeded limit of http.MaxBytesReader as
+// RequestBodyTooLargeError, so it can be recognized by handlers and proxy
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if err != nil && err.Error() == maxBytesReaderError {
+		err = &RequestBodyTooLargeError{limit: body.limit}
+	}
+	return n, err
+}
+
+// bodySizeLimitMiddleware refuses requests whose declared body size exceeds
+// the limit and stops reading bodies of other requests at the limit, so the
+// whole body is never read into memory
+func (server *HTTPServer) bodySizeLimitMiddleware(next http.Handler) http.Handler {
+	limit := server.maxRequestBodySize()
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.ContentLength > limit {
+			handleServerError(writer, &RequestBodyTooLargeError{limit: limit})
+			return
+		}
+		if request.Body != nil && request.Body != http.NoBody {
+			request.Body = &limitedBody{
+				ReadCloser: http.MaxBytesReader(writer, request.Body, limit),
+				limit:      limit,
+			}
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// bodyDecodingError returns the error reported to client when the request
+// body can't be decoded. Exceeded limit of body size is reported as is,
+// other problems as bad content of the body.
+func bodyDecodingError(err error) error {
+	var tooLarge *RequestBodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		return tooLarge
+	}
+	return &BadBodyContent{}
+}
+
+// readClusterListFromBody decodes the list of clusters in the request body.
+// The body must be single JSON object containing the clusters attribute,
+// other attributes are not allowed. Detail of the problem is returned when
+// the body is malformed, the cluster IDs are validated separately.
+func readClusterListFromBody(request *http.Request) (ctypes.ClusterListInRequest, string, error) {
+	var clusterList ctypes.ClusterListInRequest
+
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&clusterList); err != nil {
+		if err == io.EOF {
+			return clusterList, "", &NoBodyError{}
+		}
+		var tooLarge *RequestBodyTooLargeError
+		if errors.As(err, &tooLarge) {
+			return clusterList, "", tooLarge
+		}
+		return clusterList, fmt.Sprintf("invalid cluster list: %v", err), nil
+	}
+	if decoder.More() {
+		return clusterList, "request body must contain single JSON object", nil
+	}
+
+	if clusterList.Clusters == nil {
+		return clusterList, "clusters attribute is required", nil
+	}
+	return clusterList, "", nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// postClusterList sends the body to v1 clusters/reports endpoint
+func postClusterList(config server.Configuration, body io.Reader) *httptest.ResponseRecorder {
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	request := httptest.NewRequest(http.MethodPost, config.APIv1Prefix+server.ReportForListOfClustersPayloadEndpoint, body)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestRequestBodyTooLarge checks that bodies exceeding the limit are refused
+// regardless of the declared length
+func TestRequestBodyTooLarge(t *testing.T) {
+	config := serverConfigJWT
+	config.MaxRequestBodySize = 64
+	body := `{"clusters": ["` + strings.Repeat("0", 100) + `"]}`
+
+	recorder := postClusterList(config, strings.NewReader(body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"payload_too_large"`)
+
+	// body of unknown length
+	recorder = postClusterList(config, io.MultiReader(strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"payload_too_large"`)
+}
+
+// TestInvalidClusterListBody checks that malformed lists of clusters are not
+// forwarded to aggregator
+func TestInvalidClusterListBody(t *testing.T) {
+	for _, body := range []string{
+		`{"clusters": ["not-a-uuid"]}`,
+		`{"clusters": ["00000000-bbbb-cccc-dddd-eeeeeeeeeeee"], "org_id": 1}`,
+		`{"clusters": "00000000-bbbb-cccc-dddd-eeeeeeeeeeee"}`,
+		`{}`,
+		`{"clusters": []} {"clusters": []}`,
+	} {
+		recorder := postClusterList(serverConfigJWT, strings.NewReader(body))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_body"`, body)
+	}
+}
@@ -28,13 +28,13 @@ import (
 
 func (server HTTPServer) newExtractUserIDFromTokenToURLRequestModifier(newEndpoint string) RequestModifier {
 	return func(request *http.Request) (*http.Request, error) {
-		userID, err := server.GetCurrentUserID(request)
+		identity, err := requestIdentity(request)
 		if err != nil {
 			return nil, err
 		}
 
 		vars := mux.Vars(request)
-		vars["user_id"] = fmt.Sprintf("%v", userID)
+		vars["user_id"] = fmt.Sprintf("%v", identity.User.UserID)
 
 		newURL := httputils.MakeURLToEndpointMapString(server.Config.APIv1Prefix, newEndpoint, vars)
 		request.URL, err = url.Parse(newURL)
@@ -50,14 +50,14 @@ func (server HTTPServer) newExtractUserIDFromTokenToURLRequestModifier(newEndpoi
 
 func (server HTTPServer) extractUserIDOrgIDFromTokenToURLRequestModifier(newEndpoint string) RequestModifier {
 	return func(request *http.Request) (*http.Request, error) {
-		orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+		identity, err := requestIdentity(request)
 		if err != nil {
-			return nil, &ParamsParsingError{}
+			return nil, err
 		}
 
 		vars := mux.Vars(request)
-		vars["user_id"] = string(userID)
-		vars["org_id"] = fmt.Sprintf("%v", orgID)
+		vars["user_id"] = string(identity.User.UserID)
+		vars["org_id"] = fmt.Sprintf("%v", identity.OrgID)
 
 		newURL := httputils.MakeURLToEndpointMapString(server.Config.APIv1Prefix, newEndpoint, vars)
 		request.URL, err = url.Parse(newURL)
@@ -73,13 +73,13 @@ func (server HTTPServer) extractUserIDOrgIDFromTokenToURLRequestModifier(newEndp
 
 func (server HTTPServer) extractOrgIDFromTokenToURLRequestModifier(newEndpoint string) RequestModifier {
 	return func(request *http.Request) (*http.Request, error) {
-		orgID, err := server.GetCurrentOrgID(request)
+		identity, err := requestIdentity(request)
 		if err != nil {
-			return nil, &ParamsParsingError{}
+			return nil, err
 		}
 
 		vars := mux.Vars(request)
-		vars["org_id"] = fmt.Sprintf("%v", orgID)
+		vars["org_id"] = fmt.Sprintf("%v", identity.OrgID)
 
 		newURL := httputils.MakeURLToEndpointMapString(server.Config.APIv1Prefix, newEndpoint, vars)
 		request.URL, err = url.Parse(newURL)
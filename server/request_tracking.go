@@ -0,0 +1,212 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// requestKeyFormat is the key of hash written by the external data
+	// pipeline for every processed archive
+	requestKeyFormat = "organization:%d:cluster:%s:request:%s"
+
+	// fields of the hash written by the pipeline, rule hits are comma
+	// separated rule selectors
+	requestReceivedField  = "received_timestamp"
+	requestProcessedField = "processed_timestamp"
+	requestRuleHitsField  = "rule_hits"
+
+	// RequestIDParam parameter containing the ID of the archive
+	RequestIDParam = "request_id"
+)
+
+// requestIDValidator matches IDs of archives assigned by ingress service
+var requestIDValidator = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// readRequestID reads the ID of the archive from the path
+func readRequestID(request *http.Request) (string, error) {
+	requestID := mux.Vars(request)[RequestIDParam]
+	if !requestIDValidator.MatchString(requestID) {
+		return "", &RouterParsingError{
+			paramName:  RequestIDParam,
+			paramValue: requestID,
+			errString:  "alphanumeric value is expected",
+		}
+	}
+	return requestID, nil
+}
+
+// requestStore returns the shared storage when it is able to read the hashes
+// written by the pipeline. The endpoints are not registered otherwise.
+func (server HTTPServer) requestStore() (storage.HashStore, bool) {
+	store, ok := server.storage.(storage.HashStore)
+	return store, ok
+}
+
+// clusterRequest converts the hash written by the pipeline to the state of
+// the archive
+func clusterRequest(requestID string, fields map[string]string) types.ClusterRequest {
+	return types.ClusterRequest{
+		RequestID: requestID,
+		Received:  fields[requestReceivedField],
+		Processed: fields[requestProcessedField],
+	}
+}
+
+// getClusterRequests returns the archives of the cluster processed by the
+// pipeline, the most recently received first
+func (server HTTPServer) getClusterRequests(writer http.ResponseWriter, request *http.Request) {
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// error handled by function
+	if !successful {
+		return
+	}
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	store, _ := server.requestStore()
+	prefix := fmt.Sprintf(requestKeyFormat, orgID, clusterID, "")
+	keys, err := store.Keys(prefix + "*")
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("Unable to list processed archives")
+		handleServerError(writer, err)
+		return
+	}
+
+	requests := make([]types.ClusterRequest, 0, len(keys))
+	for _, key := range keys {
+		fields, found, err := store.GetHash(key)
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("Unable to read processed archive")
+			handleServerError(writer, err)
+			return
+		}
+		// the hash may expire in the meantime
+		if found {
+			requests = append(requests, clusterRequest(strings.TrimPrefix(key, prefix), fields))
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Received > requests[j].Received
+	})
+
+	response := responses.BuildOkResponseWithData("requests", requests)
+	response["cluster"] = clusterID
+	if err = responses.SendOK(writer, response); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// requestRuleHits returns the rules found in the archive with their content.
+// Rules without content and internal rules the user can't access are left
+// out.
+func (server HTTPServer) requestRuleHits(ruleHits string, includeInternal bool) ([]types.RequestRuleHit, error) {
+	hits := make([]types.RequestRuleHit, 0)
+	for _, selector := range strings.Split(ruleHits, ",") {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+
+		ruleID := ctypes.RuleID(selector)
+		if content.IsRuleInternal(ruleID) && !includeInternal {
+			continue
+		}
+		ruleContent, err := content.GetContentForRecommendation(ruleID)
+		if err != nil {
+			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+				return nil, err
+			}
+			log.Warn().Err(err).Str("rule", selector).Msg("Content of rule found in processed archive is missing")
+			continue
+		}
+
+		hits = append(hits, types.RequestRuleHit{
+			RuleSelector: ctypes.RuleSelector(selector),
+			Description:  ruleContent.Description,
+			TotalRisk:    uint8(ruleContent.TotalRisk),
+		})
+	}
+	return hits, nil
+}
+
+// getClusterRequestReport returns the rules found in the archive processed
+// by the pipeline
+func (server HTTPServer) getClusterRequestReport(writer http.ResponseWriter, request *http.Request) {
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// error handled by function
+	if !successful {
+		return
+	}
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	requestID, err := readRequestID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	store, _ := server.requestStore()
+	key := fmt.Sprintf(requestKeyFormat, orgID, clusterID, requestID)
+	fields, found, err := store.GetHash(key)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Unable to read processed archive")
+		handleServerError(writer, err)
+		return
+	}
+	if !found {
+		handleServerError(writer, &utypes.ItemNotFoundError{ItemID: requestID})
+		return
+	}
+
+	includeInternal := server.includeInternalRules(request)
+	hits, err := server.requestRuleHits(fields[requestRuleHitsField], includeInternal)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	response := responses.BuildOkResponseWithData("report", hits)
+	response["cluster"] = clusterID
+	response["request"] = clusterRequest(requestID, fields)
+	if err = responses.SendOK(writer, response); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// serveRequestTrackingRequest sends GET request to the API V2 endpoint of the
+// server
+func serveRequestTrackingRequest(router http.Handler, endpoint string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, serverConfigJWT.APIv2Prefix+endpoint, nil)
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestClusterRequests checks that the archives processed by the pipeline are
+// read from Redis
+func TestClusterRequests(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	redis := miniredis.RunT(t)
+	key := fmt.Sprintf("organization:%v:cluster:%v:request:", testdata.OrgID, testdata.ClusterName)
+	redis.HSet(key+"r1",
+		"received_timestamp", "2023-01-01T10:00:00Z",
+		"processed_timestamp", "2023-01-01T10:00:05Z",
+		"rule_hits", string(testdata.Rule1CompositeID)+",unknown.rule|KEY",
+	)
+	redis.HSet(key+"r2", "received_timestamp", "2023-01-02T10:00:00Z")
+	// archive of other organization
+	redis.HSet(fmt.Sprintf("organization:2:cluster:%v:request:r3", testdata.ClusterName), "received_timestamp", "x")
+
+	store := storage.NewRedisStore(storage.Configuration{Address: redis.Addr()})
+	defer store.Close()
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetStorage(store)
+	router := testServer.Initialize()
+
+	recorder := serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/requests", testdata.ClusterName))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var requests struct {
+		Requests []types.ClusterRequest `json:"requests"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &requests))
+	assert.Equal(t, []types.ClusterRequest{
+		{RequestID: "r2", Received: "2023-01-02T10:00:00Z"},
+		{RequestID: "r1", Received: "2023-01-01T10:00:00Z", Processed: "2023-01-01T10:00:05Z"},
+	}, requests.Requests)
+
+	recorder = serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/request/r1/report", testdata.ClusterName))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var report struct {
+		Report []types.RequestRuleHit `json:"report"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	// rules without content are left out
+	assert.Len(t, report.Report, 1)
+	assert.Equal(t, string(testdata.Rule1CompositeID), string(report.Report[0].RuleSelector))
+
+	recorder = serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/request/r3/report", testdata.ClusterName))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/request/r*/report", testdata.ClusterName))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// TestClusterRequestsWithoutRedis checks that the endpoints are not
+// available when Redis is not used as the shared storage
+func TestClusterRequestsWithoutRedis(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	recorder := serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/requests", testdata.ClusterName))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// other storages can't read the hashes written by the pipeline
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetStorage(storage.NewMemoryStore())
+	router = testServer.Initialize()
+
+	recorder = serveRequestTrackingRequest(router, fmt.Sprintf("cluster/%v/requests", testdata.ClusterName))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
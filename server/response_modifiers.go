@@ -0,0 +1,241 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// StripFieldsModifier removes all fields with given names from the
+	// response
+	StripFieldsModifier = "strip_fields"
+	// RewriteURLsModifier replaces the URL prefix given as the first
+	// argument by the second argument in all string values of the response
+	RewriteURLsModifier = "rewrite_urls"
+	// InjectDisplayNamesModifier adds cluster_name field to all objects of
+	// the response that contain cluster or cluster_id field
+	InjectDisplayNamesModifier = "inject_display_names"
+
+	clusterNameField = "cluster_name"
+)
+
+// JSONModifier is a type of function which modifies the parsed JSON body of
+// response when proxying. Objects are represented by
+// map[string]interface{}, arrays by []interface{} and numbers by
+// json.Number.
+type JSONModifier func(request *http.Request, body interface{}) (interface{}, error)
+
+// ResponseModifierConfiguration represents single modifier applied to JSON
+// responses of proxied endpoint. Modifiers configured for the same endpoint
+// are chained in order of their definition.
+type ResponseModifierConfiguration struct {
+	Endpoint string   `mapstructure:"endpoint" toml:"endpoint"`
+	Modifier string   `mapstructure:"modifier" toml:"modifier"`
+	Args     []string `mapstructure:"args" toml:"args"`
+}
+
+// jsonModifierFactory constructs the modifier from its configured arguments
+type jsonModifierFactory func(server HTTPServer, args []string) (JSONModifier, error)
+
+// jsonModifierFactories contains all modifiers that can be used in
+// configuration
+var jsonModifierFactories = map[string]jsonModifierFactory{
+	StripFieldsModifier:        newStripFieldsModifier,
+	RewriteURLsModifier:        newRewriteURLsModifier,
+	InjectDisplayNamesModifier: newInjectDisplayNamesModifier,
+}
+
+// buildResponsePipelines constructs chains of JSON modifiers for endpoints
+// from the configuration. Modifiers with unknown name or wrong arguments are
+// skipped.
+func (server HTTPServer) buildResponsePipelines() map[string][]JSONModifier {
+	pipelines := make(map[string][]JSONModifier)
+
+	for _, config := range server.Config.ResponseModifiers {
+		factory, found := jsonModifierFactories[config.Modifier]
+		if !found {
+			log.Error().Str("endpoint", config.Endpoint).Msgf("unknown response modifier %s", config.Modifier)
+			continue
+		}
+
+		modifier, err := factory(server, config.Args)
+		if err != nil {
+			log.Error().Err(err).Str("endpoint", config.Endpoint).Msgf("unable to construct response modifier %s", config.Modifier)
+			continue
+		}
+
+		endpoint := strings.TrimPrefix(config.Endpoint, "/")
+		pipelines[endpoint] = append(pipelines[endpoint], modifier)
+	}
+
+	return pipelines
+}
+
+// jsonModifiersForRequest returns modifiers set in proxy options followed by
+// the modifiers configured for the matched route
+func (server HTTPServer) jsonModifiersForRequest(request *http.Request, options *ProxyOptions) []JSONModifier {
+	var modifiers []JSONModifier
+	if options != nil {
+		modifiers = append(modifiers, options.JSONModifiers...)
+	}
+
+	if len(server.responsePipelines) == 0 {
+		return modifiers
+	}
+
+	route := mux.CurrentRoute(request)
+	if route == nil {
+		return modifiers
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return modifiers
+	}
+
+	for _, prefix := range []string{server.Config.APIv1Prefix, server.Config.APIv2Prefix} {
+		if prefix != "" && strings.HasPrefix(template, prefix) {
+			return append(modifiers, server.responsePipelines[strings.TrimPrefix(template, prefix)]...)
+		}
+	}
+	return modifiers
+}
+
+// modifyJSONBody applies the modifiers on JSON body of the response. Bodies
+// that are not valid JSON are returned as they are.
+func modifyJSONBody(modifiers []JSONModifier, request *http.Request, body []byte) ([]byte, error) {
+	if len(modifiers) == 0 {
+		return body, nil
+	}
+
+	var parsed interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err != nil {
+		log.Debug().Err(err).Msg("response body is not JSON, it won't be modified")
+		return body, nil
+	}
+
+	for _, modifier := range modifiers {
+		var err error
+		parsed, err = modifier(request, parsed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(parsed)
+}
+
+// walkJSONObjects calls the function for every object found in the parsed
+// JSON value, including nested ones
+func walkJSONObjects(value interface{}, function func(object map[string]interface{})) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		function(typed)
+		for _, item := range typed {
+			walkJSONObjects(item, function)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			walkJSONObjects(item, function)
+		}
+	}
+}
+
+// mapJSONStrings replaces every string found in the parsed JSON value by
+// result of the function
+func mapJSONStrings(value interface{}, function func(string) string) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return function(typed)
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = mapJSONStrings(item, function)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = mapJSONStrings(item, function)
+		}
+	}
+	return value
+}
+
+// newStripFieldsModifier constructs modifier that removes fields with names
+// given in arguments from all objects
+func newStripFieldsModifier(_ HTTPServer, args []string) (JSONModifier, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s requires at least one field name", StripFieldsModifier)
+	}
+
+	return func(_ *http.Request, body interface{}) (interface{}, error) {
+		walkJSONObjects(body, func(object map[string]interface{}) {
+			for _, field := range args {
+				delete(object, field)
+			}
+		})
+		return body, nil
+	}, nil
+}
+
+// newRewriteURLsModifier constructs modifier that replaces URL prefix given
+// as the first argument by the second one
+func newRewriteURLsModifier(_ HTTPServer, args []string) (JSONModifier, error) {
+	if len(args) != 2 || args[0] == "" {
+		return nil, fmt.Errorf("%s requires original and new URL prefix", RewriteURLsModifier)
+	}
+	from, to := args[0], args[1]
+
+	return func(_ *http.Request, body interface{}) (interface{}, error) {
+		return mapJSONStrings(body, func(value string) string {
+			if strings.HasPrefix(value, from) {
+				return to + strings.TrimPrefix(value, from)
+			}
+			return value
+		}), nil
+	}, nil
+}
+
+// newInjectDisplayNamesModifier constructs modifier that adds display names
+// of clusters retrieved from AMS API
+func newInjectDisplayNamesModifier(server HTTPServer, args []string) (JSONModifier, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%s has no arguments", InjectDisplayNamesModifier)
+	}
+
+	return func(_ *http.Request, body interface{}) (interface{}, error) {
+		walkJSONObjects(body, func(object map[string]interface{}) {
+			if _, found := object[clusterNameField]; found {
+				return
+			}
+			for _, field := range []string{"cluster", "cluster_id"} {
+				if clusterID, ok := object[field].(string); ok && clusterID != "" {
+					object[clusterNameField] = server.getClusterInfo(types.ClusterName(clusterID)).DisplayName
+					return
+				}
+			}
+		})
+		return body, nil
+	}, nil
+}
@@ -21,10 +21,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/RedHatInsights/insights-operator-utils/collections"
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog/log"
 
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
 
@@ -37,8 +39,46 @@ const (
 	ImpactingParam = "impacting"
 	// RuleIDParamName parameter name in the URL
 	RuleIDParamName = "rule_id"
+	// ErrorKeyParamName parameter name in the URL
+	ErrorKeyParamName = "error_key"
+	// SearchQueryParam parameter containing free-text query used to search rules
+	SearchQueryParam = "q"
+	// FormatParam parameter selecting format of rule content text fields
+	FormatParam = "format"
+	// IncludeInactiveParam parameter used to include archived and deprovisioned clusters
+	IncludeInactiveParam = "include_inactive"
+	// ExcludeStatusParam parameter containing comma-separated list of additional
+	// cluster subscription statuses to be filtered out
+	ExcludeStatusParam = "exclude_status"
+	// VerboseParam parameter used to include reason, resolution and more
+	// info content fields into the rules in report
+	VerboseParam = "verbose"
+	// FieldsParam parameter containing comma-separated list of fields
+	// (dot-separated paths) to be kept in the JSON response
+	FieldsParam = "fields"
+	// IncludeMissingContentParam parameter used to include rules without
+	// content into the report, the rules are marked by content_status field
+	IncludeMissingContentParam = "include_missing_content"
+	// AllVersionsParam parameter used to include rules not applicable to
+	// the OCP version of the cluster into the report
+	AllVersionsParam = "all_versions"
+	// ResolutionRiskParam parameter containing comma-separated list of
+	// resolution risks (risk of change) of rules returned in the report
+	ResolutionRiskParam = "resolution_risk"
+	// IncludeResolutionRiskParam parameter used to include resolution risk
+	// (risk of change) of rules into the report
+	IncludeResolutionRiskParam = "include_resolution_risk"
+
+	// markdownFormat is the default format of rule content text fields
+	markdownFormat = "markdown"
+	// htmlFormat means that rule content text fields are rendered to HTML
+	htmlFormat = "html"
 )
 
+// compositeRuleIDValidator matches rule ID with error key in
+// rule.plugin.module|ERROR_KEY format
+var compositeRuleIDValidator = regexp.MustCompile(`^([a-zA-Z_0-9.]+)[|]([a-zA-Z_0-9.]+)$`)
+
 func readRuleIDWithErrorKey(writer http.ResponseWriter, request *http.Request) (ctypes.RuleID, ctypes.ErrorKey, error) {
 	ruleIDWithErrorKey, err := httputils.GetRouterParam(request, RuleIDParamName)
 	if err != nil {
@@ -72,7 +112,6 @@ func readCompositeRuleID(request *http.Request) (
 		return
 	}
 
-	compositeRuleIDValidator := regexp.MustCompile(`^([a-zA-Z_0-9.]+)[|]([a-zA-Z_0-9.]+)$`)
 	isCompositeRuleIDValid := compositeRuleIDValidator.MatchString(ruleIDParam)
 
 	if !isCompositeRuleIDValid {
@@ -90,6 +129,30 @@ func readCompositeRuleID(request *http.Request) (
 	return
 }
 
+// readRuleIDAndErrorKey reads rule module and error key from separate path
+// parameters and returns the composite rule ID (rule.module|ERROR_KEY). The
+// ".report" suffix of the rule module is accepted and removed.
+func readRuleIDAndErrorKey(request *http.Request) (ctypes.RuleID, error) {
+	ruleModule, err := httputils.GetRouterParam(request, RuleIDParamName)
+	if err != nil {
+		return "", err
+	}
+	errorKey, err := httputils.GetRouterParam(request, ErrorKeyParamName)
+	if err != nil {
+		return "", err
+	}
+
+	ruleID := strings.TrimSuffix(ruleModule, dotReport) + "|" + errorKey
+	if !compositeRuleIDValidator.MatchString(ruleID) {
+		return "", &RouterParsingError{
+			paramName:  RuleIDParamName,
+			paramValue: ruleID,
+			errString:  "rule ID and error key must contain only latin characters, numbers, underscores or dots",
+		}
+	}
+	return ctypes.RuleID(ruleID), nil
+}
+
 func (server HTTPServer) readParamsGetRecommendations(writer http.ResponseWriter, request *http.Request) (
 	userID ctypes.UserID,
 	orgID ctypes.OrgID,
@@ -132,6 +195,24 @@ func (server HTTPServer) readParamsGetRecommendations(writer http.ResponseWriter
 	return
 }
 
+// readOrgIDQueryParam reads the required ID of organization from query
+// parameter of internal endpoints
+func readOrgIDQueryParam(request *http.Request, paramName string) (ctypes.OrgID, error) {
+	value := request.URL.Query().Get(paramName)
+	if value == "" {
+		return 0, &RouterMissingParamError{paramName: paramName}
+	}
+	orgID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || orgID == 0 {
+		return 0, &RouterParsingError{
+			paramName:  paramName,
+			paramValue: value,
+			errString:  "positive integer is expected",
+		}
+	}
+	return ctypes.OrgID(orgID), nil
+}
+
 // readQueryParam return the value of the parameter in the query. If not found, defaults to false
 func readQueryBoolParam(name string, defaultValue bool, request *http.Request) (bool, error) {
 	value := request.URL.Query().Get(name)
@@ -141,16 +222,170 @@ func readQueryBoolParam(name string, defaultValue bool, request *http.Request) (
 	return strconv.ParseBool(value)
 }
 
+// readClusterStatusFilter returns list of cluster subscription statuses that
+// should be filtered out when the list of clusters for organization is read
+// from AMS API. Archived and deprovisioned clusters are filtered out unless
+// they are included in configuration or by the "include_inactive" parameter.
+// Additional statuses are taken from configuration and "exclude_status"
+// parameter.
+func (server HTTPServer) readClusterStatusFilter(request *http.Request) ([]string, error) {
+	includeInactive, err := readQueryBoolParam(IncludeInactiveParam, server.Config.IncludeInactiveClusters, request)
+	if err != nil {
+		return nil, &RouterParsingError{
+			paramName:  IncludeInactiveParam,
+			paramValue: request.URL.Query().Get(IncludeInactiveParam),
+			errString:  "Unparsable boolean value",
+		}
+	}
+
+	var excluded []string
+	if value := request.URL.Query().Get(ExcludeStatusParam); value != "" {
+		excluded = strings.Split(value, ",")
+	}
+
+	return server.clusterStatusFilter(includeInactive, excluded), nil
+}
+
+// clusterStatusFilter returns statuses of clusters that are filtered out.
+// Statuses excluded by configuration are always filtered out, the given
+// statuses are filtered out in addition to them.
+func (server HTTPServer) clusterStatusFilter(includeInactive bool, excludedStatuses []string) []string {
+	var filter []string
+	if includeInactive {
+		// clusters with reserved resources are never reported
+		filter = []string{amsclient.StatusReserved}
+	} else {
+		filter = append(filter, amsclient.DefaultStatusNegativeFilters...)
+	}
+
+	excluded := append([]string{}, server.Config.ExcludedClusterStatuses...)
+	excluded = append(excluded, excludedStatuses...)
+
+	for _, status := range excluded {
+		status = strings.TrimSpace(status)
+		if status != "" && !collections.StringInSlice(status, filter) {
+			filter = append(filter, status)
+		}
+	}
+
+	return filter
+}
+
 // readGetDisabledParam returns the value of the "get_disabled" parameter in query
 // if available
 func readGetDisabledParam(request *http.Request) (bool, error) {
 	return readQueryBoolParam(GetDisabledParam, false, request)
 }
 
-// readOSDEligibleParam returns the value of the "osd_eligible" parameter in query
-// if available
-func readOSDEligible(request *http.Request) (bool, error) {
-	return readQueryBoolParam(OSDEligibleParam, false, request)
+// readIncludeMissingContentParam returns the value of the
+// "include_missing_content" parameter in query if available
+func readIncludeMissingContentParam(request *http.Request) (bool, error) {
+	includeMissingContent, err := readQueryBoolParam(IncludeMissingContentParam, false, request)
+	if err != nil {
+		return false, &RouterParsingError{
+			paramName:  IncludeMissingContentParam,
+			paramValue: request.URL.Query().Get(IncludeMissingContentParam),
+			errString:  "Unparsable boolean value",
+		}
+	}
+	return includeMissingContent, nil
+}
+
+// readAllVersionsParam returns the value of the "all_versions" parameter in
+// query if available
+func readAllVersionsParam(request *http.Request) (bool, error) {
+	allVersions, err := readQueryBoolParam(AllVersionsParam, false, request)
+	if err != nil {
+		return false, &RouterParsingError{
+			paramName:  AllVersionsParam,
+			paramValue: request.URL.Query().Get(AllVersionsParam),
+			errString:  "Unparsable boolean value",
+		}
+	}
+	return allVersions, nil
+}
+
+// readResolutionRiskParam returns the resolution risks listed in the
+// "resolution_risk" parameter in query. Nil is returned when the parameter
+// is not provided, so the rules are not filtered by resolution risk.
+func readResolutionRiskParam(request *http.Request) ([]int, error) {
+	value := request.URL.Query().Get(ResolutionRiskParam)
+	if value == "" {
+		return nil, nil
+	}
+
+	var risks []int
+	for _, item := range strings.Split(value, ",") {
+		risk, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || risk < 1 || risk > 4 {
+			return nil, &RouterParsingError{
+				paramName:  ResolutionRiskParam,
+				paramValue: value,
+				errString:  "comma-separated list of integers between 1 and 4 is expected",
+			}
+		}
+		risks = append(risks, risk)
+	}
+	return risks, nil
+}
+
+// readIncludeResolutionRiskParam returns the value of the
+// "include_resolution_risk" parameter in query. Resolution risk is included
+// when the report is filtered by it too.
+func readIncludeResolutionRiskParam(request *http.Request) (bool, error) {
+	include, err := readQueryBoolParam(IncludeResolutionRiskParam, false, request)
+	if err != nil {
+		return false, &RouterParsingError{
+			paramName:  IncludeResolutionRiskParam,
+			paramValue: request.URL.Query().Get(IncludeResolutionRiskParam),
+			errString:  "Unparsable boolean value",
+		}
+	}
+	return include || request.URL.Query().Get(ResolutionRiskParam) != "", nil
+}
+
+// readVerboseParam returns the value of the "verbose" parameter in query if
+// available
+func readVerboseParam(request *http.Request) (bool, error) {
+	verbose, err := readQueryBoolParam(VerboseParam, false, request)
+	if err != nil {
+		return false, &RouterParsingError{
+			paramName:  VerboseParam,
+			paramValue: request.URL.Query().Get(VerboseParam),
+			errString:  "Unparsable boolean value",
+		}
+	}
+	return verbose, nil
+}
+
+// readOSDEligibleOrDefault returns the value of the "osd_eligible" parameter
+// in query. The default, usually the managed status of the cluster read from
+// AMS API, is returned when the parameter is missing or unparsable.
+func readOSDEligibleOrDefault(request *http.Request, defaultValue bool) bool {
+	osdFlag, err := readQueryBoolParam(OSDEligibleParam, defaultValue, request)
+	if err != nil {
+		log.Err(err).Msgf("Got error while parsing `%s` value", OSDEligibleParam)
+		return defaultValue
+	}
+	return osdFlag
+}
+
+// readHTMLFormatParam returns true when rule content should be rendered into
+// HTML according to the "format" parameter in query
+func readHTMLFormatParam(request *http.Request) (bool, error) {
+	value := strings.ToLower(request.URL.Query().Get(FormatParam))
+	switch value {
+	case "", markdownFormat:
+		return false, nil
+	case htmlFormat:
+		return true, nil
+	default:
+		return false, &RouterParsingError{
+			paramName:  FormatParam,
+			paramValue: value,
+			errString:  "Unsupported format, use markdown or html",
+		}
+	}
 }
 
 // readImpactingParam returns the value of the "impacting" parameter in query if available
@@ -0,0 +1,126 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// uuidSegmentPattern matches path segments with UUID, e.g. cluster ID
+var uuidSegmentPattern = regexp.MustCompile(
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+)
+
+// RoutingConfiguration represents configuration of matching request paths
+// to endpoints. By default, paths differing from the endpoint by trailing
+// slash are redirected, which breaks clients that don't follow redirects
+// or don't resend the body of POST requests.
+type RoutingConfiguration struct {
+	// IgnoreTrailingSlash serves the endpoint directly, without redirect,
+	// when the path differs by trailing slash only
+	IgnoreTrailingSlash bool `mapstructure:"ignore_trailing_slash" toml:"ignore_trailing_slash"`
+	// CaseInsensitiveParams matches UUID path params, e.g. cluster IDs,
+	// case-insensitively by converting them to lower case
+	CaseInsensitiveParams bool `mapstructure:"case_insensitive_params" toml:"case_insensitive_params"`
+}
+
+// enabled returns true if request paths can be modified before routing
+func (config RoutingConfiguration) enabled() bool {
+	return config.IgnoreTrailingSlash || config.CaseInsensitiveParams
+}
+
+// lowercaseUUIDSegments converts the path segments with UUID to lower case
+func lowercaseUUIDSegments(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if uuidSegmentPattern.MatchString(segment) {
+			segments[i] = strings.ToLower(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// withRequestPath returns the request with path modified by given function.
+// The path part of RequestURI is modified too, because it is checked by
+// authentication and it is used by proxied requests. The original request is
+// returned when the path is not modified.
+func withRequestPath(request *http.Request, modify func(string) string) *http.Request {
+	path := modify(request.URL.Path)
+	if path == request.URL.Path {
+		return request
+	}
+
+	request = request.Clone(request.Context())
+	request.URL.Path = path
+	if request.URL.RawPath != "" {
+		request.URL.RawPath = modify(request.URL.RawPath)
+	}
+	requestPath, query := request.RequestURI, ""
+	if index := strings.Index(requestPath, "?"); index >= 0 {
+		requestPath, query = requestPath[:index], requestPath[index:]
+	}
+	request.RequestURI = modify(requestPath) + query
+	return request
+}
+
+// matchedPathTemplate returns the path template of the route matching the
+// request. HEAD and OPTIONS requests are matched to GET routes, as they are
+// served by methodsHandler.
+func matchedPathTemplate(router *mux.Router, request *http.Request) (string, bool) {
+	probe := request
+	if request.Method == http.MethodHead || request.Method == http.MethodOptions {
+		probe = request.Clone(request.Context())
+		probe.Method = http.MethodGet
+	}
+
+	var match mux.RouteMatch
+	if !router.Match(probe, &match) || match.MatchErr != nil || match.Route == nil {
+		return "", false
+	}
+	template, err := match.Route.GetPathTemplate()
+	return template, err == nil
+}
+
+// routingHandler modifies request paths, so they match the endpoints as
+// configured. It needs to wrap all other handlers, because the path is
+// checked by them too.
+func (server *HTTPServer) routingHandler(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if server.Config.Routing.CaseInsensitiveParams {
+			request = withRequestPath(request, lowercaseUUIDSegments)
+		}
+
+		if server.Config.Routing.IgnoreTrailingSlash {
+			// the router matches paths with or without trailing slash,
+			// but it redirects when the path differs from the template
+			if template, found := matchedPathTemplate(router, request); found {
+				withSlash := strings.HasSuffix(template, "/")
+				request = withRequestPath(request, func(path string) string {
+					path = strings.TrimSuffix(path, "/")
+					if withSlash {
+						path += "/"
+					}
+					return path
+				})
+			}
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// serveRoutingRequest serves the request with given method and path
+func serveRoutingRequest(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// TestIgnoreTrailingSlash checks that paths differing from the endpoints by
+// trailing slash are served without redirect when it is configured
+func TestIgnoreTrailingSlash(t *testing.T) {
+	prefix := serverConfigJWT.APIv2Prefix
+
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+	recorder := serveRoutingRequest(router, http.MethodGet, prefix+server.InfoEndpoint+"/", "")
+	assert.Equal(t, http.StatusMovedPermanently, recorder.Code)
+
+	config := serverConfigJWT
+	config.Routing.IgnoreTrailingSlash = true
+	router = helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	recorder = serveRoutingRequest(router, http.MethodGet, prefix+server.InfoEndpoint+"/", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the main endpoint is registered with trailing slash
+	recorder = serveRoutingRequest(router, http.MethodGet, strings.TrimSuffix(prefix, "/"), "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveRoutingRequest(router, http.MethodHead, prefix+server.InfoEndpoint+"/", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// body of POST request is not lost by redirect
+	recorder = serveRoutingRequest(router, http.MethodPost, prefix+server.RuleContentBatchEndpoint+"/", "{}")
+	assert.NotEqual(t, http.StatusMovedPermanently, recorder.Code)
+}
+
+// TestLowercaseUUIDSegments checks that only path segments with UUID are
+// converted to lower case
+func TestLowercaseUUIDSegments(t *testing.T) {
+	assert.Equal(t,
+		"/api/v2/cluster/34c3ecc5-624a-49a5-bab8-4fdc5e51a266/rules/ccx.Rule|ERROR_KEY",
+		server.LowercaseUUIDSegments("/api/v2/cluster/34C3ECC5-624A-49A5-BAB8-4FDC5E51A266/rules/ccx.Rule|ERROR_KEY"),
+	)
+	assert.Equal(t, "/api/v2/clusters/", server.LowercaseUUIDSegments("/api/v2/clusters/"))
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// maxFeedbackMessageLength is the maximal number of characters of the
+	// feedback message
+	maxFeedbackMessageLength = 2048
+	// votedRuleKey is the key of the rule description added to the vote
+	// returned by aggregator
+	votedRuleKey = "rule"
+	// voteKey is the key of the vote in the response of aggregator
+	voteKey = "vote"
+)
+
+// voteEndpoints maps the votes to aggregator endpoints storing them
+var voteEndpoints = map[types.UserVote]string{
+	types.UserVoteLike:    ira_server.LikeRuleEndpoint,
+	types.UserVoteDislike: ira_server.DislikeRuleEndpoint,
+	types.UserVoteNone:    ira_server.ResetVoteOnRuleEndpoint,
+}
+
+// addVotedRule is JSON modifier adding the description of the rule from the
+// path to the vote returned by aggregator
+func addVotedRule(request *http.Request, body interface{}) (interface{}, error) {
+	object, ok := body.(map[string]interface{})
+	if !ok {
+		return body, nil
+	}
+	if _, found := object[voteKey]; !found {
+		// error response
+		return body, nil
+	}
+
+	vars := mux.Vars(request)
+	rule, err := content.GetRuleWithErrorKeyContent(
+		ctypes.RuleID(vars[RuleIDParamName]), ctypes.ErrorKey(vars["error_key"]),
+	)
+	if err != nil {
+		// the rule has been checked before the request was proxied,
+		// the content must have been updated since then
+		log.Warn().Err(err).Msg("Unable to add rule description to the vote")
+		return body, nil
+	}
+
+	object[votedRuleKey] = types.VotedRule{
+		RuleID:      rule.Module,
+		ErrorKey:    rule.ErrorKey,
+		Description: rule.Description,
+		Generic:     rule.Generic,
+		TotalRisk:   rule.TotalRisk,
+	}
+	return object, nil
+}
+
+// getRuleVoteV2 returns the vote of the user on the rule together with the
+// rule description
+func (server *HTTPServer) getRuleVoteV2(writer http.ResponseWriter, request *http.Request) {
+	server.proxyTo(server.currentServicesConfig().AggregatorBaseEndpoint, &ProxyOptions{
+		RequestModifiers: []RequestModifier{checkRuleIDAndErrorKeyAreValid()},
+		JSONModifiers:    []JSONModifier{addVotedRule},
+		Rewrite:          identityRewrite(ira_server.GetVoteOnRuleEndpoint),
+	})(writer, request)
+}
+
+// putRuleVoteV2 likes or dislikes the rule or resets the vote of the user
+// depending on the vote in the payload
+func (server *HTTPServer) putRuleVoteV2(writer http.ResponseWriter, request *http.Request) {
+	var payload types.RuleVoteRequest
+	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil || payload.Vote == nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	endpoint, found := voteEndpoints[*payload.Vote]
+	if !found {
+		detail := fmt.Sprintf("vote must be %d, %d or %d", types.UserVoteDislike, types.UserVoteNone, types.UserVoteLike)
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	// aggregator takes the vote from the path
+	request.Body = http.NoBody
+	server.proxyTo(server.currentServicesConfig().AggregatorBaseEndpoint, &ProxyOptions{
+		RequestModifiers: []RequestModifier{checkRuleIDAndErrorKeyAreValid()},
+		Rewrite:          identityRewrite(endpoint),
+	})(writer, request)
+}
+
+// postRuleFeedbackV2 stores free-text feedback of the user on the rule
+func (server *HTTPServer) postRuleFeedbackV2(writer http.ResponseWriter, request *http.Request) {
+	var payload types.RuleFeedbackRequest
+	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+		log.Error().Err(err).Msg("wrong payload provided by client")
+		handleServerError(writer, bodyDecodingError(err))
+		return
+	}
+
+	payload.Message = strings.TrimSpace(payload.Message)
+	var detail string
+	switch {
+	case payload.Message == "":
+		detail = "message must not be empty"
+	case utf8.RuneCountInString(payload.Message) > maxFeedbackMessageLength:
+		detail = fmt.Sprintf("message must not be longer than %d characters", maxFeedbackMessageLength)
+	}
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+
+	server.proxyTo(server.currentServicesConfig().AggregatorBaseEndpoint, &ProxyOptions{
+		RequestModifiers: []RequestModifier{checkRuleIDAndErrorKeyAreValid()},
+		Rewrite:          identityRewrite(ira_server.DisableRuleFeedbackEndpoint),
+	})(writer, request)
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// aggregatorRequest is the request received by mocked aggregator
+type aggregatorRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+// newRuleFeedbackTestRouter returns router of the server proxying to mocked
+// aggregator, which records the received requests
+func newRuleFeedbackTestRouter(t *testing.T, aggregatorResponse string) (http.Handler, *[]aggregatorRequest, func()) {
+	received := &[]aggregatorRequest{}
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, err := ioutil.ReadAll(request.Body)
+		assert.NoError(t, err)
+		*received = append(*received, aggregatorRequest{request.Method, request.URL.Path, string(body)})
+		_, _ = writer.Write([]byte(aggregatorResponse))
+	}))
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL + "/"
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, nil, nil)
+	return testServer.Initialize(), received, aggregator.Close
+}
+
+func ruleEndpointV2(endpoint string, ruleID, errorKey string) string {
+	return serverConfigJWT.APIv2Prefix + strings.NewReplacer(
+		"{cluster}", string(testdata.ClusterName),
+		"{rule_id}", ruleID,
+		"{error_key}", errorKey,
+	).Replace(endpoint)
+}
+
+func serveJWTRequest(router http.Handler, method, endpoint, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, endpoint, strings.NewReader(body))
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRuleVoteV2(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	router, received, closeAggregator := newRuleFeedbackTestRouter(t, `{"status":"ok","vote":1}`)
+	defer closeAggregator()
+	endpoint := ruleEndpointV2(server.RuleVoteEndpointV2, string(testdata.Rule1ID), string(testdata.ErrorKey1))
+
+	for _, testCase := range []struct {
+		body   string
+		suffix string
+	}{
+		{`{"vote": 1}`, "/like"},
+		{`{"vote": -1}`, "/dislike"},
+		{`{"vote": 0}`, "/reset_vote"},
+	} {
+		*received = nil
+		recorder := serveJWTRequest(router, http.MethodPut, endpoint, testCase.body)
+		assert.Equal(t, http.StatusOK, recorder.Code, testCase.body)
+		if assert.Len(t, *received, 1, testCase.body) {
+			assert.Equal(t, http.MethodPut, (*received)[0].method)
+			assert.True(t, strings.HasSuffix((*received)[0].path, testCase.suffix), (*received)[0].path)
+		}
+	}
+
+	*received = nil
+	recorder := serveJWTRequest(router, http.MethodGet, endpoint, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.Len(t, *received, 1) {
+		assert.True(t, strings.HasSuffix((*received)[0].path, "/get_vote"), (*received)[0].path)
+	}
+
+	var response struct {
+		Vote int             `json:"vote"`
+		Rule types.VotedRule `json:"rule"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Vote)
+	assert.Equal(t, ctypes.ErrorKey(testdata.ErrorKey1), response.Rule.ErrorKey)
+	assert.Equal(t, testdata.RuleErrorKey1.Description, response.Rule.Description)
+	assert.Equal(t, testdata.RuleErrorKey1.Generic, response.Rule.Generic)
+}
+
+// TestRuleVoteV2Invalid checks that invalid votes and unknown rules are not
+// passed to aggregator
+func TestRuleVoteV2Invalid(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	router, received, closeAggregator := newRuleFeedbackTestRouter(t, `{"status":"ok"}`)
+	defer closeAggregator()
+	endpoint := ruleEndpointV2(server.RuleVoteEndpointV2, string(testdata.Rule1ID), string(testdata.ErrorKey1))
+
+	for _, body := range []string{"", "{}", `{"vote": 2}`, `{"vote": "like"}`} {
+		recorder := serveJWTRequest(router, http.MethodPut, endpoint, body)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+	}
+
+	unknownRule := ruleEndpointV2(server.RuleVoteEndpointV2, string(testdata.Rule1ID), "UNKNOWN_KEY")
+	recorder := serveJWTRequest(router, http.MethodPut, unknownRule, `{"vote": 1}`)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = serveJWTRequest(router, http.MethodGet, unknownRule, "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	assert.Empty(t, *received)
+}
+
+func TestRuleFeedbackV2(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	router, received, closeAggregator := newRuleFeedbackTestRouter(t, `{"status":"ok"}`)
+	defer closeAggregator()
+	endpoint := ruleEndpointV2(server.RuleFeedbackEndpointV2, string(testdata.Rule1ID), string(testdata.ErrorKey1))
+
+	for _, body := range []string{
+		"",
+		`{"message": "   "}`,
+		`{"message": "` + strings.Repeat("x", 2049) + `"}`,
+	} {
+		recorder := serveJWTRequest(router, http.MethodPost, endpoint, body)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+	assert.Empty(t, *received)
+
+	recorder := serveJWTRequest(router, http.MethodPost, endpoint, `{"message": " not relevant for us "}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.Len(t, *received, 1) {
+		assert.Equal(t, http.MethodPost, (*received)[0].method)
+		assert.True(t, strings.HasSuffix((*received)[0].path, "/disable_feedback"), (*received)[0].path)
+		assert.JSONEq(t, `{"message": "not relevant for us"}`, (*received)[0].body)
+	}
+}
@@ -54,8 +54,6 @@ func TestEnableEndpoint(t *testing.T) {
 			&serverConfigJWT,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:             http.MethodPut,
 				Endpoint:           server.EnableRuleForClusterEndpoint,
@@ -97,8 +95,6 @@ func TestDisableEndpoint(t *testing.T) {
 			&serverConfigJWT,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:             http.MethodPut,
 				Endpoint:           server.DisableRuleForClusterEndpoint,
@@ -117,7 +113,13 @@ func TestDisableEndpoint(t *testing.T) {
 func TestEnableEndpointBadErrorKey(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		expectedBody := fmt.Sprintf(
-			`{"status":"Item with ID %s/%s was not found in the storage"}`,
+			`{
+				"type": "urn:insights-results-smart-proxy:error:not_found",
+				"title": "Item not found",
+				"status": 404,
+				"detail": "Item with ID %s/%s was not found in the storage",
+				"code": "not_found"
+			}`,
 			testdata.Rule1ID,
 			testdata.ErrorKey1,
 		)
@@ -126,8 +128,6 @@ func TestEnableEndpointBadErrorKey(t *testing.T) {
 			&serverConfigJWT,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:             http.MethodPut,
 				Endpoint:           server.EnableRuleForClusterEndpoint,
@@ -146,7 +146,13 @@ func TestEnableEndpointBadErrorKey(t *testing.T) {
 func TestDisableEndpointBadErrorKey(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		expectedBody := fmt.Sprintf(
-			`{"status":"Item with ID %s/%s was not found in the storage"}`,
+			`{
+				"type": "urn:insights-results-smart-proxy:error:not_found",
+				"title": "Item not found",
+				"status": 404,
+				"detail": "Item with ID %s/%s was not found in the storage",
+				"code": "not_found"
+			}`,
 			testdata.Rule1ID,
 			testdata.ErrorKey1,
 		)
@@ -155,8 +161,6 @@ func TestDisableEndpointBadErrorKey(t *testing.T) {
 			&serverConfigJWT,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:             http.MethodPut,
 				Endpoint:           server.DisableRuleForClusterEndpoint,
@@ -25,33 +25,36 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	// we just have to import this package in order to expose pprof
-	// interface in debug mode
-	// disable "G108 (CWE-): Profiling endpoint is automatically exposed on /debug/pprof"
-	_ "net/http/pprof" // #nosec G108
-	"path/filepath"
-
 	"github.com/RedHatInsights/insights-content-service/groups"
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
 	ctypes "github.com/RedHatInsights/insights-results-types"
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
+	"github.com/RedHatInsights/insights-results-smart-proxy/cache"
 	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
@@ -91,18 +94,47 @@ const (
 
 	// dotReport ".report" string present in the ruleID in most tables
 	dotReport = ".report"
+
+	// maxConcurrentContentLookups is the number of rules in single report
+	// whose content is resolved in parallel
+	maxConcurrentContentLookups = 8
+
+	// maxConcurrentClusterLookups is the number of clusters looked up in
+	// AMS API in parallel by endpoints working with many clusters
+	maxConcurrentClusterLookups = 8
 )
 
 // HTTPServer is an implementation of Server interface
 type HTTPServer struct {
-	Config            Configuration
-	InfoParams        map[string]string
-	ServicesConfig    services.Configuration
-	amsClient         amsclient.AMSClient
-	GroupsChannel     chan []groups.Group
-	ErrorFoundChannel chan bool
-	ErrorChannel      chan error
-	Serv              *http.Server
+	Config         Configuration
+	InfoParams     map[string]string
+	ServicesConfig services.Configuration
+	amsClient      amsclient.AMSClient
+	GroupsStore    *content.GroupsStore
+	Serv           *http.Server
+	storage        storage.Store
+
+	clusterInfoCache       *clusterInfoCache
+	upgradePredictionCache *upgradePredictionCache
+	reportCache            *reportCache
+	noReportCache          cache.Cache
+	playbookCache          cache.Cache
+	orgOverviewCache       cache.Cache
+	responsePipelines      map[string][]JSONModifier
+	featureFlags           featureflags.Provider
+	orgAccess              *orgAccessList
+	introspector           *tokenIntrospector
+	identityCache          cache.Cache
+	maintenance            *maintenanceMode
+	startupGate            *startupGate
+	upstreamHealth         *upstreamHealth
+	handler                *swappableHandler
+	settings               *reloadedSettings
+	webhookNotifier        *webhookNotifier
+	exportJobs             *exportJobs
+	auditPublisher         audit.Publisher
+	auditLog               audit.Store
+	preferences            preferences.Store
 }
 
 // RequestModifier is a type of function which modifies request when proxying
@@ -112,29 +144,49 @@ type RequestModifier func(request *http.Request) (*http.Request, error)
 type ResponseModifier func(response *http.Response) (*http.Response, error)
 
 // ProxyOptions alters behaviour of proxy server for each endpoint.
-// For example, you can set custom request and response modifiers
+// For example, you can set custom request and response modifiers. JSON
+// modifiers are applied on parsed response body, before the modifiers
+// configured for the endpoint. Rewrite rule is applied after the request
+// modifiers.
 type ProxyOptions struct {
 	RequestModifiers  []RequestModifier
 	ResponseModifiers []ResponseModifier
+	JSONModifiers     []JSONModifier
+	Rewrite           *URLRewrite
 }
 
 // New function constructs new implementation of Server interface.
 func New(config Configuration,
 	servicesConfig services.Configuration,
 	amsClient amsclient.AMSClient,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *content.GroupsStore,
 ) *HTTPServer {
 
 	return &HTTPServer{
-		Config:            config,
-		InfoParams:        make(map[string]string),
-		ServicesConfig:    servicesConfig,
-		amsClient:         amsClient,
-		GroupsChannel:     groupsChannel,
-		ErrorFoundChannel: errorFoundChannel,
-		ErrorChannel:      errorChannel,
+		Config:         config,
+		InfoParams:     make(map[string]string),
+		ServicesConfig: servicesConfig,
+		amsClient:      amsClient,
+		GroupsStore:    groupsStore,
+
+		clusterInfoCache:       newClusterInfoCache(config.ClusterInfoCacheTTL),
+		upgradePredictionCache: newUpgradePredictionCache(servicesConfig.UpgradeRisksPredictionCacheTTL),
+		reportCache:            newReportCache(config.ReportCache),
+		noReportCache:          newNoReportCache(config.NoReportCacheTTL),
+		playbookCache:          newPlaybookCache(servicesConfig.RemediationsCacheTTL),
+		orgOverviewCache:       newOrgOverviewCache(config.OrgOverviewCacheTTL),
+		featureFlags:           featureflags.NewStaticProvider(nil),
+		orgAccess:              newOrgAccessList(config.OrgAccess),
+		introspector:           newTokenIntrospector(config.BearerAuth),
+		identityCache:          newIdentityCache(config.IdentityCacheTTL),
+		maintenance:            newMaintenanceMode(config.Maintenance),
+		startupGate:            newStartupGate(config.StartupTimeout),
+		upstreamHealth:         newUpstreamHealth(config.UpstreamHealth, servicesConfig),
+		handler:                &swappableHandler{},
+		settings:               &reloadedSettings{},
+		webhookNotifier:        newWebhookNotifier(config.Webhooks),
+		exportJobs:             newExportJobs(config.Exports, servicesConfig.ExportStorage),
+		auditPublisher:         audit.NoopPublisher{},
 	}
 }
 
@@ -151,8 +203,19 @@ func (server *HTTPServer) mainEndpoint(writer http.ResponseWriter, _ *http.Reque
 func (server *HTTPServer) Initialize() http.Handler {
 	log.Info().Msgf("Initializing HTTP server at '%s'", server.Config.Address)
 
+	// needs to be done before proxy handlers are registered
+	server.responsePipelines = server.buildResponsePipelines()
+
 	router := mux.NewRouter().StrictSlash(true)
-	router.Use(httputils.LogRequest)
+	router.Use(recoveryMiddleware)
+	router.Use(logRequestMiddleware)
+	router.Use(server.bodySizeLimitMiddleware)
+
+	maintenanceExemptURLs := server.maintenanceExemptURLs()
+	router.Use(func(next http.Handler) http.Handler { return server.maintenanceMiddleware(next, maintenanceExemptURLs) })
+	router.Use(func(next http.Handler) http.Handler { return server.startupGateMiddleware(next, maintenanceExemptURLs) })
+	router.Use(server.staleContentMiddleware)
+	router.Use(fieldSelectionMiddleware)
 
 	apiPrefix := server.Config.APIv1Prefix
 
@@ -161,6 +224,8 @@ func (server *HTTPServer) Initialize() http.Handler {
 	openAPIv2URL := server.Config.APIv2Prefix + filepath.Base(server.Config.APIv2SpecFile)
 	infoV1URL := apiPrefix + InfoEndpoint
 	infoV2URL := server.Config.APIv2Prefix + InfoEndpoint
+	statusV1URL := apiPrefix + StatusEndpoint
+	statusV2URL := server.Config.APIv2Prefix + StatusEndpoint
 	// enable authentication, but only if it is setup in configuration
 	if server.Config.Auth {
 		// we have to enable authentication for all endpoints,
@@ -174,37 +239,49 @@ func (server *HTTPServer) Initialize() http.Handler {
 			openAPIv2URL,
 			infoV1URL,
 			infoV2URL,
+			statusV1URL,
+			statusV2URL,
 			metricsURL + "?",   // to be able to test using Frisby
 			openAPIv1URL + "?", // to be able to test using Frisby
 			openAPIv2URL + "?", // to be able to test using Frisby
 		}
+		if server.Config.APIv3Prefix != "" {
+			openAPIv3URL := server.Config.APIv3Prefix + filepath.Base(server.Config.APIv3SpecFile)
+			noAuthURLs = append(noAuthURLs,
+				openAPIv3URL,
+				server.Config.APIv3Prefix+InfoEndpoint,
+				server.Config.APIv3Prefix+StatusEndpoint,
+				openAPIv3URL+"?", // to be able to test using Frisby
+			)
+		}
 		router.Use(func(next http.Handler) http.Handler { return server.Authentication(next, noAuthURLs) })
+		// needs to be used before organization access control, which
+		// checks the impersonated organization then
+		router.Use(server.impersonationMiddleware)
+
+		if server.Config.OrgAccess.enabled() {
+			router.Use(server.orgAccessMiddleware)
+		}
+		if server.Config.Entitlements.Enabled {
+			router.Use(server.entitlementsMiddleware)
+		}
 	}
 
 	if server.Config.EnableCORS {
-		headersOK := handlers.AllowedHeaders([]string{
-			"Content-Type",
-			"Content-Length",
-			"Accept-Encoding",
-			"X-CSRF-Token",
-			"Authorization",
-		})
-		originsOK := handlers.AllowedOrigins([]string{"*"})
-		methodsOK := handlers.AllowedMethods([]string{
-			http.MethodPost,
-			http.MethodGet,
-			http.MethodOptions,
-			http.MethodPut,
-			http.MethodDelete,
-		})
-		credsOK := handlers.AllowCredentials()
-		corsMiddleware := handlers.CORS(originsOK, headersOK, methodsOK, credsOK)
-		router.Use(corsMiddleware)
+		router.Use(server.corsMiddleware())
 	}
 
+	// the request is made available for error reporting after it has been
+	// authenticated
+	router.Use(errorReportingMiddleware)
+
 	server.addEndpointsToRouter(router)
 
-	return router
+	handler := server.methodsHandler(router)
+	if server.Config.Routing.enabled() {
+		handler = server.routingHandler(router, handler)
+	}
+	return handler
 }
 
 func (server *HTTPServer) addEndpointsToRouter(router *mux.Router) {
@@ -214,21 +291,29 @@ func (server *HTTPServer) addEndpointsToRouter(router *mux.Router) {
 	}
 	server.addV1EndpointsToRouter(router)
 	server.addV2EndpointsToRouter(router)
+	// API V3 is enabled only when it is configured
+	if server.Config.APIv3Prefix != "" {
+		server.addV3EndpointsToRouter(router)
+	}
 }
 
 // Start method starts HTTP or HTTPS server.
 func (server *HTTPServer) Start() error {
 	address := server.Config.Address
 	log.Info().Msgf("Starting HTTP server at '%s'", address)
-	router := server.Initialize()
+	server.instrumentDefaultTransport()
 	server.Serv = &http.Server{
 		Addr:              address,
-		Handler:           router,
+		Handler:           server.Handler(),
 		ReadTimeout:       1 * time.Minute,
 		ReadHeaderTimeout: 5 * time.Second,
 		WriteTimeout:      30 * time.Second,
 	}
-	var err error
+	err := server.configureHTTP2()
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to configure HTTP/2")
+		return err
+	}
 
 	if server.Config.UseHTTPS {
 		err = server.Serv.ListenAndServeTLS("server.crt", "server.key")
@@ -243,6 +328,28 @@ func (server *HTTPServer) Start() error {
 	return nil
 }
 
+// configureHTTP2 enables or disables HTTP/2 on the TLS listener and enables
+// HTTP/2 over cleartext TCP (h2c) when configured. Multiplexed clients can
+// then send parallel requests over single connection.
+func (server *HTTPServer) configureHTTP2() error {
+	if !server.Config.UseHTTPS {
+		if server.Config.EnableH2C {
+			log.Info().Msg("HTTP/2 over cleartext (h2c) is enabled")
+			server.Serv.Handler = h2c.NewHandler(server.Serv.Handler, &http2.Server{})
+		}
+		return nil
+	}
+
+	if !server.Config.EnableHTTP2 {
+		// non-nil map disables HTTP/2 that is otherwise negotiated
+		// automatically
+		server.Serv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		return nil
+	}
+	log.Info().Msg("HTTP/2 is enabled")
+	return http2.ConfigureServer(server.Serv, &http2.Server{})
+}
+
 // Stop method stops server's execution.
 func (server *HTTPServer) Stop(ctx context.Context) error {
 	return server.Serv.Shutdown(ctx)
@@ -282,6 +389,7 @@ func modifyResponse(responseModifiers []ResponseModifier, response *http.Respons
 // service.
 func (server HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(http.ResponseWriter, *http.Request) {
 	return func(writer http.ResponseWriter, request *http.Request) {
+		var rewrite *URLRewrite
 		if options != nil {
 			var err error
 			request, err = modifyRequest(options.RequestModifiers, request)
@@ -289,11 +397,12 @@ func (server HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(htt
 				handleServerError(writer, err)
 				return
 			}
+			rewrite = options.Rewrite
 		}
 
-		log.Info().Msg("Handling response as a proxy")
+		sampledLogger(proxiedRequestsSampler).Info().Msg("Handling response as a proxy")
 
-		endpointURL, err := server.composeEndpoint(baseURL, request.RequestURI)
+		endpointURL, err := server.rewriteURL(server.proxyBaseURL(request, baseURL), request, rewrite)
 		if err != nil {
 			log.Error().Err(err).Msgf("Error during endpoint %s URL parsing", request.RequestURI)
 			handleServerError(writer, err)
@@ -303,10 +412,19 @@ func (server HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(htt
 		client := http.Client{}
 		req, err := http.NewRequest(request.Method, endpointURL.String(), request.Body)
 		if err != nil {
-			panic(err)
+			log.Error().Err(err).Msgf("Error creating request to %s", endpointURL.String())
+			handleServerError(writer, err)
+			return
 		}
 
-		copyHeader(request.Header, req.Header)
+		copyHeader(request.Header, req.Header, server.headerPolicy(baseURL))
+		addForwardingHeaders(request, req.Header, server.Config.ProxyHeaders.Via)
+
+		// don't wait for the timeout when the service is known to be down
+		if server.upstreamHealth.isDown(baseURL) {
+			server.evaluateProxyError(writer, errUpstreamDown, baseURL)
+			return
+		}
 
 		response, body, err := server.sendRequest(client, req, options, writer)
 		if err != nil {
@@ -314,6 +432,13 @@ func (server HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(htt
 			return
 		}
 
+		body, err = modifyJSONBody(server.jsonModifiersForRequest(request, options), request, body)
+		if err != nil {
+			log.Error().Err(err).Msg("Error modifying the response")
+			handleServerError(writer, err)
+			return
+		}
+
 		// Maybe this code should be on responses.SendRaw or something like that
 		err = responses.Send(response.StatusCode, writer, body)
 		if err != nil {
@@ -324,12 +449,40 @@ func (server HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(htt
 	}
 }
 
+// proxyBaseURL returns the base URL of the aggregator instance serving the
+// organization of the requester when the request is proxied to aggregator.
+// GET and HEAD requests are sent to the read replica. Other base URLs are
+// returned as is.
+func (server HTTPServer) proxyBaseURL(request *http.Request, baseURL string) string {
+	if baseURL != server.currentServicesConfig().AggregatorBaseEndpoint {
+		return baseURL
+	}
+
+	// organization is not known without authentication
+	var orgID ctypes.OrgID
+	if identity, err := requestIdentity(request); err == nil {
+		orgID = identity.OrgID
+	}
+
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		return server.currentServicesConfig().AggregatorReadEndpoint(orgID)
+	}
+	return server.currentServicesConfig().AggregatorEndpoint(orgID)
+}
+
 // evaluateProxyError handles detected error in proxyTo
 // according to its type and the requested baseURL
 func (server HTTPServer) evaluateProxyError(writer http.ResponseWriter, err error, baseURL string) {
-	if _, ok := err.(*url.Error); ok {
+	// request body exceeding the limit is client's problem
+	var tooLarge *RequestBodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		handleServerError(writer, tooLarge)
+		return
+	}
+
+	if _, ok := err.(*url.Error); ok || err == errUpstreamDown {
 		switch baseURL {
-		case server.ServicesConfig.AggregatorBaseEndpoint:
+		case server.currentServicesConfig().AggregatorBaseEndpoint:
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
 		case server.ServicesConfig.ContentBaseEndpoint:
 			handleServerError(writer, &ContentServiceUnavailableError{})
@@ -368,25 +521,12 @@ func (server HTTPServer) sendRequest(
 	return response, body, nil
 }
 
-func (server HTTPServer) composeEndpoint(baseEndpoint, currentEndpoint string) (*url.URL, error) {
-	endpoint := strings.TrimPrefix(currentEndpoint, server.Config.APIv1Prefix)
-	return url.Parse(baseEndpoint + endpoint)
-}
-
-func copyHeader(srcHeaders, dstHeaders http.Header) {
-	for headerKey, headerValues := range srcHeaders {
-		for _, value := range headerValues {
-			dstHeaders.Add(headerKey, value)
-		}
-	}
-}
-
-func (server HTTPServer) getClusterInfoFromAMS(orgID ctypes.OrgID) (
+func (server HTTPServer) getClusterInfoFromAMS(orgID ctypes.OrgID, statusNegativeFilter []string) (
 	clusterInfoList []types.ClusterInfo,
 	err error,
 ) {
 	// providing nil filters will mean default filters will be applied
-	clusterInfoList, err = server.amsClient.GetClustersForOrganization(orgID, nil, nil)
+	clusterInfoList, err = server.amsClient.GetClustersForOrganization(orgID, nil, statusNegativeFilter)
 	if err != nil {
 		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Error retrieving clusters from AMS API")
 		return
@@ -396,10 +536,11 @@ func (server HTTPServer) getClusterInfoFromAMS(orgID ctypes.OrgID) (
 }
 
 // readClusterIDsForOrgID reads the list of clusters for a given
-// organization from aggregator
-func (server HTTPServer) readClusterIDsForOrgID(orgID ctypes.OrgID) ([]ctypes.ClusterName, error) {
+// organization from aggregator. Clusters with status listed in
+// statusNegativeFilter are filtered out, nil means default filters.
+func (server HTTPServer) readClusterIDsForOrgID(orgID ctypes.OrgID, statusNegativeFilter []string) ([]ctypes.ClusterName, error) {
 	if server.amsClient != nil {
-		clusterInfoList, err := server.getClusterInfoFromAMS(orgID)
+		clusterInfoList, err := server.getClusterInfoFromAMS(orgID, statusNegativeFilter)
 		if err != nil {
 			log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Error retrieving cluster IDs from AMS API")
 			return []ctypes.ClusterName{}, err
@@ -419,13 +560,14 @@ func (server HTTPServer) readClusterIDsForOrgID(orgID ctypes.OrgID) ([]ctypes.Cl
 	return server.getClusterDetailsFromAggregator(orgID)
 }
 
-// readClusterInfoForOrgID returns a list of cluster info types and a map of cluster display names
-func (server HTTPServer) readClusterInfoForOrgID(orgID ctypes.OrgID) (
+// readClusterInfoForOrgID returns a list of cluster info types and a map of cluster display names.
+// Clusters with status listed in statusNegativeFilter are filtered out, nil means default filters.
+func (server HTTPServer) readClusterInfoForOrgID(orgID ctypes.OrgID, statusNegativeFilter []string) (
 	[]types.ClusterInfo,
 	error,
 ) {
 	if server.amsClient != nil {
-		clusterInfoList, err := server.getClusterInfoFromAMS(orgID)
+		clusterInfoList, err := server.getClusterInfoFromAMS(orgID, statusNegativeFilter)
 		if err != nil {
 			log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Error retrieving cluster info from AMS API")
 			return clusterInfoList, err
@@ -464,7 +606,7 @@ func (server HTTPServer) getClusterDetailsFromAggregator(orgID ctypes.OrgID) ([]
 	log.Info().Msg("retrieving cluster IDs from aggregator")
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ClustersForOrganizationEndpoint,
 		orgID,
 	)
@@ -494,16 +636,24 @@ func (server HTTPServer) getClusterDetailsFromAggregator(orgID ctypes.OrgID) ([]
 func (server HTTPServer) readAggregatorReportForClusterID(
 	orgID ctypes.OrgID, clusterID ctypes.ClusterName, userID ctypes.UserID, writer http.ResponseWriter,
 ) (*ctypes.ReportResponse, bool) {
-	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
-		ira_server.ReportEndpoint,
-		orgID,
-		clusterID,
-		userID,
-	)
+	if server.sendCachedNoReport(writer, orgID, clusterID) {
+		return nil, false
+	}
+
+	reportURL := func(baseEndpoint string) string {
+		return httputils.MakeURLToEndpoint(baseEndpoint, ira_server.ReportEndpoint, orgID, clusterID, userID)
+	}
+	aggregatorURL := reportURL(server.currentServicesConfig().AggregatorReadEndpoint(orgID))
+	canaryMode := server.canaryMode()
+	if canaryMode == canaryModeRoute {
+		aggregatorURL = reportURL(server.Config.Canary.Endpoint)
+	}
 
 	// #nosec G107
 	aggregatorResp, err := http.Get(aggregatorURL)
+	if canaryMode == canaryModeRoute {
+		recordCanaryRequest(canaryModeRoute, aggregatorResp)
+	}
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -525,7 +675,14 @@ func (server HTTPServer) readAggregatorReportForClusterID(
 		return nil, false
 	}
 
+	if canaryMode == canaryModeMirror {
+		go server.mirrorReportRequest(reportURL(server.Config.Canary.Endpoint), aggregatorResp.StatusCode, responseBytes)
+	}
+
 	if aggregatorResp.StatusCode != http.StatusOK {
+		if aggregatorResp.StatusCode == http.StatusNotFound {
+			server.cacheNoReport(orgID, clusterID, responseBytes)
+		}
 		err := responses.Send(aggregatorResp.StatusCode, writer, responseBytes)
 		if err != nil {
 			log.Error().Err(err).Msg(responseDataError)
@@ -551,7 +708,7 @@ func (server HTTPServer) readAggregatorReportMetainfoForClusterID(
 	orgID ctypes.OrgID, clusterID ctypes.ClusterName, userID ctypes.UserID, writer http.ResponseWriter,
 ) (*ctypes.ReportResponseMetainfo, bool) {
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ReportMetainfoEndpoint,
 		orgID,
 		clusterID,
@@ -602,7 +759,7 @@ func (server HTTPServer) readAggregatorReportForClusterList(
 ) (*ctypes.ClusterReports, bool) {
 	clist := strings.Join(clusterList, ",")
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ReportForListOfClustersEndpoint,
 		orgID,
 		clist)
@@ -648,12 +805,46 @@ func (server HTTPServer) readAggregatorReportForClusterListFromBody(
 	orgID ctypes.OrgID, request *http.Request, writer http.ResponseWriter,
 ) (*ctypes.ClusterReports, bool) {
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ReportForListOfClustersPayloadEndpoint,
 		orgID,
 	)
 
-	body, err := io.ReadAll(request.Body)
+	clusterList, detail, err := readClusterListFromBody(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return nil, false
+	}
+	if detail != "" {
+		if err := sendProblem(writer, newProblem(ErrorCodeInvalidBody, detail)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return nil, false
+	}
+
+	clusterNames, rejected, detail, err := server.validateClusterList(orgID, clusterList.Clusters)
+	if err != nil {
+		handleServerError(writer, err)
+		return nil, false
+	}
+	if detail == "" && len(rejected) > 0 {
+		detail = fmt.Sprintf("%d of requested clusters can't be processed", len(rejected))
+	}
+	if detail != "" {
+		problem := newProblem(ErrorCodeInvalidBody, detail)
+		problem.RejectedClusters = rejected
+		if err := sendProblem(writer, problem); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return nil, false
+	}
+
+	// only the validated and deduplicated list is forwarded to aggregator
+	validatedList := ctypes.ClusterListInRequest{Clusters: make([]string, len(clusterNames))}
+	for i, clusterName := range clusterNames {
+		validatedList.Clusters[i] = string(clusterName)
+	}
+	body, err := json.Marshal(validatedList)
 	if err != nil {
 		handleServerError(writer, err)
 		return nil, false
@@ -712,7 +903,7 @@ func (server HTTPServer) readAggregatorRuleForClusterID(
 	orgID ctypes.OrgID, clusterID ctypes.ClusterName, userID ctypes.UserID, ruleID ctypes.RuleID, errorKey ctypes.ErrorKey, writer http.ResponseWriter,
 ) (*ctypes.RuleOnReport, bool) {
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.RuleEndpoint,
 		orgID,
 		clusterID,
@@ -761,8 +952,8 @@ func (server HTTPServer) readAggregatorRuleForClusterID(
 }
 
 func (server HTTPServer) fetchAggregatorReport(
-	writer http.ResponseWriter, request *http.Request,
-) (aggregatorResponse *ctypes.ReportResponse, successful bool, clusterID ctypes.ClusterName) {
+	writer http.ResponseWriter, request *http.Request, endpoint string,
+) (aggregatorResponse *ctypes.ReportResponse, stale, successful bool, clusterID ctypes.ClusterName) {
 	clusterID, successful = httputils.ReadClusterName(writer, request)
 	// Error message handled by function
 	if !successful {
@@ -770,19 +961,51 @@ func (server HTTPServer) fetchAggregatorReport(
 		return
 	}
 
-	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	identity, err := requestIdentity(request)
 	if err != nil {
-		log.Info().Msgf("fetchAggregatorReport unable to get orgID or userID for cluster %v", clusterID)
+		log.Info().Msgf("fetchAggregatorReport unable to get identity for cluster %v", clusterID)
 		handleServerError(writer, err)
+		successful = false
 		return
 	}
+
+	aggregatorResponse, stale, successful = server.fetchAggregatorReportForCluster(writer, identity, clusterID, endpoint)
+	return
+}
+
+// fetchAggregatorReportForCluster method tries to fetch report for given
+// cluster of the organization of the caller. The report cached for the
+// endpoint is used when available, stale report is refreshed in background.
+func (server HTTPServer) fetchAggregatorReportForCluster(
+	writer http.ResponseWriter, identity ctypes.Identity, clusterID ctypes.ClusterName, endpoint string,
+) (aggregatorResponse *ctypes.ReportResponse, stale, successful bool) {
+	orgID, userID := identity.OrgID, identity.User.UserID
 	log.Info().Msgf("fetchAggregatorReport orgID %v userID %v for cluster %v", orgID, userID, clusterID)
 
+	if err := server.checkClusterOrganization(orgID, clusterID); err != nil {
+		handleServerError(writer, err)
+		successful = false
+		return
+	}
+
+	cacheKey := reportCacheKey(orgID, clusterID, userID)
+	if cached, isStale, found := server.reportCache.get(endpoint, cacheKey); found {
+		if isStale {
+			server.reportCache.refresh(cacheKey, func() (*ctypes.ReportResponse, bool) {
+				return server.readAggregatorReportForClusterID(orgID, clusterID, userID, newRecordingWriter())
+			})
+		}
+		return cached, isStale, true
+	}
+
 	aggregatorResponse, successful = server.readAggregatorReportForClusterID(orgID, clusterID, userID, writer)
 	if !successful {
 		log.Info().Msg("fetchAggregatorReport unable to get response from aggregator")
 		return
 	}
+	if server.reportCache.enabled(endpoint) {
+		server.reportCache.set(cacheKey, aggregatorResponse)
+	}
 	return
 }
 
@@ -803,6 +1026,12 @@ func (server HTTPServer) fetchAggregatorReportMetainfo(
 		return
 	}
 
+	if err = server.checkClusterOrganization(orgID, clusterID); err != nil {
+		handleServerError(writer, err)
+		successful = false
+		return
+	}
+
 	aggregatorResponse, successful = server.readAggregatorReportMetainfoForClusterID(orgID, clusterID, userID, writer)
 	if !successful {
 		return
@@ -855,19 +1084,15 @@ func (server HTTPServer) fetchAggregatorReportsUsingRequestBodyClusterList(
 	return aggregatorResponse, true
 }
 
-// SetAMSInfoInReport tries to retrieve the display name and managed status of the cluster using
-// the configured AMS client. If no info is retrieved, it sets the cluster's external
-// ID as display name.
+// SetAMSInfoInReport tries to retrieve the display name, managed status, OpenShift version
+// and last seen time of the cluster using the configured AMS client. If no info is retrieved,
+// it sets the cluster's external ID as display name.
 func (server HTTPServer) SetAMSInfoInReport(clusterID types.ClusterName, report *types.SmartProxyReportV2) {
-	if server.amsClient != nil {
-		clusterInfo := server.amsClient.GetClusterDetailsFromExternalClusterID(clusterID)
-		report.Meta.Managed = clusterInfo.Managed
-		if clusterInfo.DisplayName != "" {
-			report.Meta.DisplayName = clusterInfo.DisplayName
-			return
-		}
-	}
-	report.Meta.DisplayName = string(clusterID)
+	clusterInfo := server.getClusterInfo(clusterID)
+	report.Meta.Managed = clusterInfo.Managed
+	report.Meta.DisplayName = clusterInfo.DisplayName
+	report.Meta.ClusterVersion = clusterInfo.Version
+	report.Meta.LastSeen = clusterInfo.LastSeen
 }
 
 func (server HTTPServer) buildReportEndpointResponse(
@@ -883,6 +1108,29 @@ func (server HTTPServer) buildReportEndpointResponse(
 	}
 	log.Info().Msgf("Cluster ID: %v; %s flag = %t", clusterID, GetDisabledParam, includeDisabled)
 
+	includeMissingContent, err := readIncludeMissingContentParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	allVersions, err := readAllVersionsParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	resolutionRisks, err := readResolutionRiskParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	var clusterVersion string
+	if !allVersions {
+		// cluster info is cached, so the version is usually known already
+		clusterVersion = server.getClusterInfo(clusterID).Version
+	}
+
 	orgID, err := server.GetCurrentOrgID(request)
 	if err != nil {
 		log.Error().Msg(authTokenFormatError)
@@ -900,7 +1148,8 @@ func (server HTTPServer) buildReportEndpointResponse(
 	systemWideRuleDisables := generateRuleAckMap(acks)
 
 	visibleRules, noContentRulesCnt, disabledRulesCnt, err := filterRulesInResponse(
-		aggregatorResponse.Report, osdFlag, includeDisabled, systemWideRuleDisables,
+		aggregatorResponse.Report, osdFlag, includeDisabled, server.includeInternalRules(request), includeMissingContent,
+		clusterVersion, preferredContentLanguage(writer, request), systemWideRuleDisables,
 	)
 	log.Info().Msgf("Cluster ID: %v; visible rules %d, no content rules %d, disabled rules %d", clusterID, len(visibleRules), noContentRulesCnt, disabledRulesCnt)
 
@@ -909,7 +1158,14 @@ func (server HTTPServer) buildReportEndpointResponse(
 		return nil, 0, err
 	}
 
+	// the count includes rules filtered out by resolution risk, it is the
+	// number of issues of the cluster regardless of the filter
 	rulesCount = server.getRuleCount(visibleRules, noContentRulesCnt, disabledRulesCnt, clusterID)
+
+	if resolutionRisks != nil {
+		visibleRules = filterRulesByResolutionRisk(visibleRules, resolutionRisks)
+	}
+	server.setPlaybookAvailability(request, visibleRules)
 	return
 }
 
@@ -922,10 +1178,11 @@ func sendReportReponse(writer http.ResponseWriter, report interface{}) {
 
 // reportEndpointV1 serves /report endpoint without cluster_name field in the metadata.
 // For requests made by the Insights Operator, we need to take managed clusters into account and
-// communicate with the AMS API to retrieve the information about the cluster. Other consumers of this
-// endpoints MUSTN'T be affected. From OCP v4.11, the IO uses the API v2 equivalent, which already works
-// as expected, but we must fix this behaviour for request made by earlier versions. For more info, see
-// https://issues.redhat.com/browse/CCXDEV-9393 and the linked issue.
+// communicate with the AMS API to retrieve the information about the cluster. From OCP v4.11, the IO
+// uses the API v2 equivalent, which already works as expected, but we must fix this behaviour for
+// request made by earlier versions. For more info, see https://issues.redhat.com/browse/CCXDEV-9393
+// and the linked issue. Other consumers get rules for managed clusters by default too, but they can
+// override it by the osd_eligible parameter.
 func (server HTTPServer) reportEndpointV1(writer http.ResponseWriter, request *http.Request) {
 	var managedCluster bool
 
@@ -936,7 +1193,25 @@ func (server HTTPServer) reportEndpointV1(writer http.ResponseWriter, request *h
 		return
 	}
 
-	aggregatorResponse, successful, clusterID := server.fetchAggregatorReport(writer, request)
+	exportFormat, err := readExportFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	verbose, err := readVerboseParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	includeResolutionRisk, err := readIncludeResolutionRiskParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorResponse, stale, successful, clusterID := server.fetchAggregatorReport(writer, request, reportCacheEndpointV1)
 	if !successful {
 		return
 	}
@@ -945,6 +1220,7 @@ func (server HTTPServer) reportEndpointV1(writer http.ResponseWriter, request *h
 	report := types.SmartProxyReportV1{
 		Meta: types.ReportResponseMetaV1{
 			LastCheckedAt: aggregatorResponse.Meta.LastCheckedAt,
+			IsStale:       stale,
 		},
 	}
 
@@ -964,45 +1240,126 @@ func (server HTTPServer) reportEndpointV1(writer http.ResponseWriter, request *h
 			managedCluster = clusterInfo.Managed
 		}
 	} else {
-		// request NOT made by Insights Operator, we're expecting the managed status in the URL param
-		managedCluster, err = readOSDEligible(request)
-		if err != nil {
-			log.Err(err).Msgf("Cluster ID: %v; Got error while parsing `%s` value", clusterID, OSDEligibleParam)
-		}
+		// request NOT made by Insights Operator, the managed status in the URL param overrides the
+		// one read from AMS API
+		managedCluster = readOSDEligibleOrDefault(request, server.getClusterInfo(clusterID).Managed)
 		log.Info().Msgf("Cluster ID: %v; %s flag = %t", clusterID, OSDEligibleParam, managedCluster)
 	}
 
 	if report.Data, report.Meta.Count, err = server.buildReportEndpointResponse(
 		writer, request, aggregatorResponse, clusterID, managedCluster); err == nil {
+		if exportFormat != "" {
+			sendExport(writer, exportFormat, "report-"+string(clusterID),
+				reportExportTable(clusterID, "", report.Data))
+			return
+		}
+		if !verbose {
+			slimRulesInReport(report.Data)
+		}
+		if !includeResolutionRisk {
+			omitResolutionRisk(report.Data)
+		}
 		sendReportReponse(writer, report)
 	}
 }
 
 // reportEndpointV2 serves /report endpoint with cluster_name field in the metadata
 func (server HTTPServer) reportEndpointV2(writer http.ResponseWriter, request *http.Request) {
-	aggregatorResponse, successful, clusterID := server.fetchAggregatorReport(writer, request)
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	// Error message handled by function
+	if !successful {
+		return
+	}
+
+	server.sendClusterReportV2(writer, request, clusterID)
+}
+
+// sendClusterReportV2 sends the report of given cluster in the format of
+// /report endpoint
+func (server HTTPServer) sendClusterReportV2(
+	writer http.ResponseWriter, request *http.Request, clusterID ctypes.ClusterName,
+) {
+	exportFormat, err := readExportFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	verbose, err := readVerboseParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	includeResolutionRisk, err := readIncludeResolutionRiskParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	identity, err := requestIdentity(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorResponse, stale, successful := server.fetchAggregatorReportForCluster(
+		writer, identity, clusterID, reportCacheEndpointV2,
+	)
 	if !successful {
 		return
 	}
 
 	report := types.SmartProxyReportV2{}
+	report.Meta.IsStale = stale
 
 	server.SetAMSInfoInReport(clusterID, &report)
 
-	var err error
+	// managed status read from AMS API can be overridden by the osd_eligible parameter
+	osdFlag := readOSDEligibleOrDefault(request, report.Meta.Managed)
 
 	if report.Data, report.Meta.Count, err = server.buildReportEndpointResponse(
-		writer, request, aggregatorResponse, clusterID, report.Meta.Managed); err == nil {
+		writer, request, aggregatorResponse, clusterID, osdFlag); err == nil {
 
 		// fill in timestamps
 		report.Meta.LastCheckedAt = aggregatorResponse.Meta.LastCheckedAt
 		report.Meta.GatheredAt = aggregatorResponse.Meta.GatheredAt
 
 		fillImpacted(report.Data, aggregatorResponse.Report)
+		if exportFormat != "" {
+			sendExport(writer, exportFormat, "report-"+string(clusterID),
+				reportExportTable(clusterID, report.Meta.DisplayName, report.Data))
+			return
+		}
+		if !verbose {
+			slimRulesInReport(report.Data)
+		}
+		if !includeResolutionRisk {
+			omitResolutionRisk(report.Data)
+		}
 		sendReportReponse(writer, report)
 	}
 }
 
+// slimRulesInReport clears the heavyweight content fields (reason,
+// resolution and more info) of rules in the report, they are sent only when
+// verbose report is requested
+func slimRulesInReport(rules []types.RuleWithContentResponse) {
+	for i := range rules {
+		rules[i].Reason = ""
+		rules[i].Resolution = ""
+		rules[i].MoreInfo = ""
+	}
+}
+
+// omitResolutionRisk clears resolution risk of rules in the report, it is
+// sent only when requested
+func omitResolutionRisk(rules []types.RuleWithContentResponse) {
+	for i := range rules {
+		rules[i].ResolutionRisk = 0
+	}
+}
+
 func fillImpacted(
 	responses []types.RuleWithContentResponse,
 	aggregatorReports []ctypes.RuleOnReport) {
@@ -1095,7 +1452,7 @@ func (server HTTPServer) reportForListOfClustersEndpoint(writer http.ResponseWri
 	}
 
 	// send the response back to client
-	err := responses.Send(http.StatusOK, writer, aggregatorResponse)
+	err := responses.Send(http.StatusOK, writer, server.enrichClusterReports(request, aggregatorResponse))
 	if err != nil {
 		log.Error().Err(err).Msg(responseDataError)
 	}
@@ -1113,37 +1470,54 @@ func (server HTTPServer) reportForListOfClustersPayloadEndpoint(writer http.Resp
 	}
 
 	// send the response back to client
-	err := responses.Send(http.StatusOK, writer, aggregatorResponse)
+	err := responses.Send(http.StatusOK, writer, server.enrichClusterReports(request, aggregatorResponse))
 	if err != nil {
 		log.Error().Err(err).Msg(responseDataError)
 	}
 }
 
+// enrichClusterReports adds display names and managed status of all clusters
+// into reports for list of clusters
+func (server HTTPServer) enrichClusterReports(
+	request *http.Request, aggregatorResponse *ctypes.ClusterReports,
+) types.ClusterReports {
+	reports := types.ClusterReports{ClusterReports: aggregatorResponse}
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		// organization has been checked already when reading reports
+		return reports
+	}
+
+	reports.ClustersInfo = server.getClustersInfo(orgID, aggregatorResponse.ClusterList)
+	return reports
+}
+
 func (server HTTPServer) fetchAggregatorReportRule(
 	writer http.ResponseWriter, request *http.Request,
-) (*ctypes.RuleOnReport, bool) {
+) (*ctypes.RuleOnReport, bool, ctypes.ClusterName) {
 	clusterID, successful := httputils.ReadClusterName(writer, request)
 	// Error message handled by function
 	if !successful {
-		return nil, false
+		return nil, false, clusterID
 	}
 
 	ruleID, errorKey, err := readRuleIDWithErrorKey(writer, request)
 	if err != nil {
-		return nil, false
+		return nil, false, clusterID
 	}
 
 	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
 	if err != nil {
 		handleServerError(writer, err)
-		return nil, false
+		return nil, false, clusterID
 	}
 
 	aggregatorResponse, successful := server.readAggregatorRuleForClusterID(orgID, clusterID, userID, ruleID, errorKey, writer)
 	if !successful {
-		return nil, false
+		return nil, false, clusterID
 	}
-	return aggregatorResponse, true
+	return aggregatorResponse, true, clusterID
 }
 
 func (server HTTPServer) singleRuleEndpoint(writer http.ResponseWriter, request *http.Request) {
@@ -1151,29 +1525,34 @@ func (server HTTPServer) singleRuleEndpoint(writer http.ResponseWriter, request
 	var filtered bool
 	var err error
 
-	aggregatorResponse, successful := server.fetchAggregatorReportRule(writer, request)
+	renderHTML, err := readHTMLFormatParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorResponse, successful, clusterID := server.fetchAggregatorReportRule(writer, request)
 	// Error message handled by function
 	if !successful {
 		return
 	}
 
-	osdFlag, err := readOSDEligible(request)
-	if err != nil {
-		log.Err(err).Msgf("Got error while parsing `%s` value", OSDEligibleParam)
-	}
-	rule, filtered, err = content.FetchRuleContent(*aggregatorResponse, osdFlag)
+	osdFlag := readOSDEligibleOrDefault(request, server.getClusterInfo(clusterID).Managed)
+	rule, filtered, err = content.FetchRuleContent(*aggregatorResponse, osdFlag, preferredContentLanguage(writer, request))
 
 	if err != nil || filtered {
 		handleFetchRuleContentError(writer, err, filtered)
 		return
 	}
 
-	if rule.Internal {
-		err = server.checkInternalRulePermissions(request)
-		if err != nil {
-			handleServerError(writer, err)
-			return
-		}
+	err = server.redactInternalRule(request, rule.Internal, string(rule.RuleID)+"|"+string(rule.ErrorKey))
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if renderHTML {
+		content.RenderRuleWithContentResponseHTML(rule)
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("report", *rule))
@@ -1188,80 +1567,71 @@ func handleFetchRuleContentError(writer http.ResponseWriter, err error, filtered
 		handleServerError(writer, err)
 		return
 	}
-	err = responses.SendNotFound(writer, "Rule was not found")
+	err = sendProblem(writer, newProblem(ErrorCodeNotFound, "Rule was not found"))
 	if err != nil {
-		handleServerError(writer, err)
-		return
+		log.Error().Err(err).Msg(responseDataError)
 	}
 }
 
-// checkInternalRulePermissions method checks if organizations for internal
-// rules are enabled if so, retrieves the org_id from request/token and returns
-// whether that ID is on the list of allowed organizations to access internal
-// rules
-func (server HTTPServer) checkInternalRulePermissions(request *http.Request) error {
-	if !server.Config.EnableInternalRulesOrganizations || !server.Config.Auth {
+// checkInternalEndpointPermissions checks whether the organization of the
+// current user is allowed to use internal endpoints, like on-demand content
+// refresh. The same organizations as for internal rules are allowed.
+func (server HTTPServer) checkInternalEndpointPermissions(request *http.Request) error {
+	if !server.Config.Auth {
 		return nil
 	}
 
+	return server.checkInternalOrganization(request)
+}
+
+// checkInternalOrganization checks that the organization of the user is
+// allowed to use internal endpoints regardless of the authentication setting
+func (server HTTPServer) checkInternalOrganization(request *http.Request) error {
 	requestOrgID, err := server.GetCurrentOrgID(request)
 	if err != nil {
 		log.Error().Err(err).Msg("error retrieving org_id from token")
 		return err
 	}
 
-	log.Info().Msgf("Checking internal rule permissions for Organization ID: %v", requestOrgID)
 	for _, allowedID := range server.Config.InternalRulesOrganizations {
 		if requestOrgID == allowedID {
-			log.Info().Msgf("Organization %v is allowed access to internal rules", requestOrgID)
 			return nil
 		}
 	}
 
-	// If the loop ends without returning nil, then an authentication error should be raised
-	const message = "This organization is not allowed to access this recommendation"
-	log.Error().Msg(message)
+	const message = "This organization is not allowed to use internal endpoints"
+	log.Error().Int(orgIDTag, int(requestOrgID)).Msg(message)
 	return &AuthenticationError{errString: message}
 }
 
-// getGroupsConfig retrieves the groups configuration from a channel to get the
-// latest valid one
+// getGroupsConfig retrieves the latest valid groups configuration from the
+// groups store. No groups are returned when the server has no groups store.
 func (server HTTPServer) getGroupsConfig() (
 	ruleGroups []groups.Group,
 	err error,
 ) {
-	var errorFound bool
-	ruleGroups = []groups.Group{}
-
-	select {
-	case val, ok := <-server.ErrorFoundChannel:
-		if !ok {
-			log.Error().Msgf("errorFound channel is closed")
-			return
-		}
-		errorFound = val
-	default:
-		fmt.Println("errorFound channel is empty")
-		return
+	if server.GroupsStore == nil {
+		return []groups.Group{}, nil
 	}
 
-	if errorFound {
-		err = <-server.ErrorChannel
-		if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-			log.Error().Err(err)
-		}
+	ruleGroups, err = server.GroupsStore.Groups()
+
+	if err != nil {
 		log.Error().Err(err).Msg("Error occurred during groups retrieval from content service")
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, &ContentServiceUnavailableError{}
+		}
 		return nil, err
 	}
 
-	groupsConfig := <-server.GroupsChannel
-	if groupsConfig == nil {
+	if ruleGroups == nil {
 		err := errors.New("no groups retrieved")
 		log.Error().Err(err).Msg("groups cannot be retrieved from content service. Check logs")
 		return nil, err
 	}
 
-	return groupsConfig, nil
+	return ruleGroups, nil
 }
 
 func isDisabledForOrgRule(aggregatorRule ctypes.RuleOnReport, systemWideDisabledRules map[types.RuleID]bool) bool {
@@ -1286,12 +1656,99 @@ func isDisabledRule(aggregatorRule ctypes.RuleOnReport, systemWideDisabledRules
 	return isDisabledForOrgRule(aggregatorRule, systemWideDisabledRules)
 }
 
-// filterRulesInResponse returns an array of RuleWithContentResponse with only the rules that matches 3 criteria:
+// fetchedRuleContent is the result of content lookup of single rule in the
+// report
+type fetchedRuleContent struct {
+	rule            *types.RuleWithContentResponse
+	filtered        bool
+	versionFiltered bool
+	err             error
+}
+
+// fetchRulesContent resolves the content of the rules using at most
+// maxConcurrentContentLookups parallel lookups. Results are returned in the
+// order of the rules, nil is kept for skipped rules. Rules not applicable to
+// the cluster version are marked as filtered when the version is not empty.
+func fetchRulesContent(
+	aggregatorReport []ctypes.RuleOnReport, filterOSD bool, clusterVersion, lang string, skip []bool,
+) []*fetchedRuleContent {
+	results := make([]*fetchedRuleContent, len(aggregatorReport))
+
+	semaphore := make(chan struct{}, maxConcurrentContentLookups)
+	var wg sync.WaitGroup
+
+	for i := range aggregatorReport {
+		if skip[i] {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			rule, filtered, err := content.FetchRuleContent(aggregatorReport[i], filterOSD, lang)
+			result := &fetchedRuleContent{rule: rule, filtered: filtered, err: err}
+			if err == nil && !filtered && clusterVersion != "" {
+				applicable, versionErr := content.IsRuleApplicableToVersion(
+					aggregatorReport[i].Module, aggregatorReport[i].ErrorKey, clusterVersion,
+				)
+				result.versionFiltered = versionErr == nil && !applicable
+			}
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// filterRulesByResolutionRisk returns the rules with any of the given
+// resolution risks, the order of the rules is kept
+func filterRulesByResolutionRisk(rules []types.RuleWithContentResponse, resolutionRisks []int) []types.RuleWithContentResponse {
+	filtered := []types.RuleWithContentResponse{}
+	for _, rule := range rules {
+		for _, risk := range resolutionRisks {
+			if rule.ResolutionRisk == risk {
+				filtered = append(filtered, rule)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// missingContentRule returns the rule hitting the cluster without content,
+// only the data read from the report are filled in
+func missingContentRule(aggregatorRule ctypes.RuleOnReport) types.RuleWithContentResponse {
+	return types.RuleWithContentResponse{
+		RuleID:          aggregatorRule.Module,
+		ErrorKey:        aggregatorRule.ErrorKey,
+		Disabled:        aggregatorRule.Disabled,
+		DisableFeedback: aggregatorRule.DisableFeedback,
+		DisabledAt:      aggregatorRule.DisabledAt,
+		UserVote:        aggregatorRule.UserVote,
+		TemplateData:    aggregatorRule.TemplateData,
+		Tags:            []string{},
+		ContentStatus:   types.RuleContentStatusMissing,
+	}
+}
+
+// filterRulesInResponse returns an array of RuleWithContentResponse with only the rules that matches 5 criteria:
 // - The rule has content from the content-service
 // - The disabled filter is not match
 // - The OSD elegible filter is not match
-func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, getDisabled bool,
-	systemWideDisabledRules map[types.RuleID]bool) (
+// - The rule is not internal or internal rules are included
+// - The rule applies to the cluster version, when the version is not empty
+// The content of the rules is resolved in parallel, the order of the rules is
+// kept. Redacted internal rules are not counted in any of the counters.
+// Rules without content are returned with content_status marker instead of
+// being counted when includeMissingContent is set.
+func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, getDisabled, includeInternal bool,
+	includeMissingContent bool, clusterVersion, lang string, systemWideDisabledRules map[types.RuleID]bool) (
 	okRules []types.RuleWithContentResponse,
 	noContentRulesCnt int,
 	disabledRulesCnt int,
@@ -1301,26 +1758,45 @@ func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, ge
 	okRules = []types.RuleWithContentResponse{}
 	disabledRulesCnt, noContentRulesCnt = 0, 0
 
-	for _, aggregatorRule := range aggregatorReport {
-		if !getDisabled && isDisabledRule(aggregatorRule, systemWideDisabledRules) {
+	redacted := make([]bool, len(aggregatorReport))
+	disabled := make([]bool, len(aggregatorReport))
+	skip := make([]bool, len(aggregatorReport))
+	for i, aggregatorRule := range aggregatorReport {
+		redacted[i] = !includeInternal && content.IsRuleInternal(aggregatorRule.Module)
+		disabled[i] = !redacted[i] && !getDisabled && isDisabledRule(aggregatorRule, systemWideDisabledRules)
+		skip[i] = redacted[i] || disabled[i]
+	}
+	fetched := fetchRulesContent(aggregatorReport, filterOSD, clusterVersion, lang, skip)
+
+	for i, aggregatorRule := range aggregatorReport {
+		if redacted[i] {
+			log.Debug().Msgf("redacted internal rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
+			continue
+		}
+		if disabled[i] {
 			log.Info().Msgf("disabled rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
 			disabledRulesCnt++
 			continue
 		}
 
-		rule, filtered, err := content.FetchRuleContent(aggregatorRule, filterOSD)
+		rule, filtered, err := fetched[i].rule, fetched[i].filtered, fetched[i].err
 		if err != nil {
-			if !filtered {
-				// rule has not been filtered by OSDEligible field
-				log.Info().Msgf("no content rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
-				noContentRulesCnt++
-			}
 			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
 				// error occured during communication with Content Service
 				log.Error().Err(err)
 				contentError = err
 				return
 			}
+			if filtered {
+				continue
+			}
+			// rule has not been filtered by OSDEligible field
+			log.Info().Msgf("no content rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
+			if includeMissingContent {
+				okRules = append(okRules, missingContentRule(aggregatorRule))
+			} else {
+				noContentRulesCnt++
+			}
 			continue
 		}
 
@@ -1330,6 +1806,11 @@ func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, ge
 			continue
 		}
 
+		if fetched[i].versionFiltered {
+			log.Info().Msgf("rule ID %v|%v does not apply to cluster version %v", aggregatorRule.Module, aggregatorRule.ErrorKey, clusterVersion)
+			continue
+		}
+
 		okRules = append(okRules, *rule)
 	}
 
@@ -1346,7 +1827,7 @@ func (server *HTTPServer) readListOfClusterDisabledRules(orgID types.OrgID) ([]c
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ListOfDisabledRules,
 		orgID,
 	)
@@ -1385,7 +1866,7 @@ func (server *HTTPServer) readListOfDisabledRulesForClusters(
 	}
 
 	aggregatorURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.AggregatorBaseEndpoint,
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
 		ira_server.ListOfDisabledRulesForClusters,
 		orgID,
 	)
@@ -1429,14 +1910,32 @@ func (server *HTTPServer) getClusterListAndUserData(
 	writer http.ResponseWriter,
 	orgID types.OrgID,
 	userID types.UserID,
+	statusNegativeFilter []string,
 ) (
 	clusterInfoList []types.ClusterInfo,
 	clusterRecommendationMap ctypes.ClusterRecommendationMap,
 	ackedRulesMap map[ctypes.RuleID]bool,
 	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
 ) {
+	// user data are read from aggregator while the list of clusters and
+	// their recommendations are being retrieved
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(2)
+	go func() {
+		defer waitGroup.Done()
+		// get a map of acknowledged rules
+		ackedRulesMap = server.getRuleAcksMap(orgID)
+	}()
+	go func() {
+		defer waitGroup.Done()
+		// retrieve list of cluster IDs and single disabled rules for each cluster
+		disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
+	}()
+	// the goroutines must not outlive the request
+	defer waitGroup.Wait()
+
 	// get list of clusters from AMS API or aggregator
-	clusterInfoList, err := server.readClusterInfoForOrgID(orgID)
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, statusNegativeFilter)
 	if err != nil {
 		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
 		handleServerError(writer, err)
@@ -1461,11 +1960,5 @@ func (server *HTTPServer) getClusterListAndUserData(
 		"getClusterListAndUserData getting clusters and impacting recommendations from aggregator took %s", time.Since(tStartImpacting),
 	)
 
-	// get a map of acknowledged rules
-	ackedRulesMap = server.getRuleAcksMap(orgID)
-
-	// retrieve list of cluster IDs and single disabled rules for each cluster
-	disabledRulesPerCluster = server.getUserDisabledRulesPerCluster(orgID)
-
 	return
 }
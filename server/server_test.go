@@ -17,8 +17,11 @@ limitations under the License.
 package server_test
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -30,6 +33,7 @@ import (
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
@@ -595,25 +599,28 @@ var (
 	}{
 		Status: "ok",
 		Report: types.RuleWithContentResponse{
-			RuleID:       testdata.Rule1.Module,
-			ErrorKey:     testdata.RuleErrorKey1.ErrorKey,
-			CreatedAt:    testdata.RuleErrorKey1.PublishDate.UTC().Format(time.RFC3339),
-			Description:  testdata.RuleErrorKey1.Description,
-			Generic:      testdata.RuleErrorKey1.Generic,
-			Reason:       testdata.RuleErrorKey1.Reason,
-			Resolution:   testdata.RuleErrorKey1.Resolution,
-			MoreInfo:     testdata.RuleErrorKey1.MoreInfo,
-			TotalRisk:    calculateTotalRisk(testdata.RuleErrorKey1.Impact, testdata.RuleErrorKey1.Likelihood),
-			Disabled:     testdata.Rule1Disabled,
-			UserVote:     types.UserVoteNone,
-			TemplateData: testdata.Rule1ExtraData,
-			Tags:         testdata.RuleErrorKey1.Tags,
+			RuleID:         testdata.Rule1.Module,
+			ErrorKey:       testdata.RuleErrorKey1.ErrorKey,
+			CreatedAt:      testdata.RuleErrorKey1.PublishDate.UTC().Format(time.RFC3339),
+			Description:    testdata.RuleErrorKey1.Description,
+			Generic:        testdata.RuleErrorKey1.Generic,
+			Reason:         testdata.RuleErrorKey1.Reason,
+			Resolution:     testdata.RuleErrorKey1.Resolution,
+			MoreInfo:       testdata.RuleErrorKey1.MoreInfo,
+			TotalRisk:      calculateTotalRisk(testdata.RuleErrorKey1.Impact, testdata.RuleErrorKey1.Likelihood),
+			ResolutionRisk: testdata.RuleErrorKey1.ResolutionRisk,
+			Disabled:       testdata.Rule1Disabled,
+			UserVote:       types.UserVoteNone,
+			TemplateData:   testdata.Rule1ExtraData,
+			Tags:           testdata.RuleErrorKey1.Tags,
 		},
 	}
-	SmartProxyReportResponse3NoRuleFound = struct {
-		Status string `json:"status"`
-	}{
-		Status: "Rule was not found",
+	SmartProxyReportResponse3NoRuleFound = server.Problem{
+		Type:   "urn:insights-results-smart-proxy:error:not_found",
+		Title:  "Item not found",
+		Status: http.StatusNotFound,
+		Detail: "Rule was not found",
+		Code:   server.ErrorCodeNotFound,
 	}
 
 	GetRecommendationsResponse1Rule2Cluster = struct {
@@ -1021,6 +1028,7 @@ var (
 					1: 1,
 					2: 0,
 				},
+				HighestTotalRisk: 1,
 			},
 			{
 				ClusterID:     "",
@@ -1031,6 +1039,7 @@ var (
 					1: 0,
 					2: 2,
 				},
+				HighestTotalRisk: 2,
 			},
 		},
 	}
@@ -1056,6 +1065,7 @@ var (
 					1: 1,
 					2: 0,
 				},
+				HighestTotalRisk: 1,
 			},
 			{
 				ClusterID:     "",
@@ -1066,6 +1076,7 @@ var (
 					1: 1,
 					2: 1,
 				},
+				HighestTotalRisk: 2,
 			},
 		},
 	}
@@ -1090,7 +1101,8 @@ var (
 					1: 1,
 					2: 0,
 				},
-				Version: testdata.ClusterVersion,
+				HighestTotalRisk: 1,
+				Version:          testdata.ClusterVersion,
 			},
 			{
 				ClusterID:     "",
@@ -1101,6 +1113,7 @@ var (
 					1: 1,
 					2: 1,
 				},
+				HighestTotalRisk: 2,
 			},
 		},
 	}
@@ -1136,6 +1149,7 @@ var (
 					1: 0,
 					2: 1,
 				},
+				HighestTotalRisk: 2,
 			},
 		},
 	}
@@ -1161,6 +1175,7 @@ var (
 					1: 1,
 					2: 0,
 				},
+				HighestTotalRisk: 1,
 			},
 			{
 				ClusterID:     "",
@@ -1171,6 +1186,7 @@ var (
 					1: 0,
 					2: 1,
 				},
+				HighestTotalRisk: 2,
 			},
 		},
 	}
@@ -1203,10 +1219,12 @@ var (
 		Metainfo: &ReportResponseMetainfoTwoReports,
 	}
 
-	ReportMetainfoAPIResponseInvalidJSON = struct {
-		Status string `json:"status"`
-	}{
-		Status: "invalid character 'T' looking for beginning of value",
+	ReportMetainfoAPIResponseInvalidJSON = server.Problem{
+		Type:   "urn:insights-results-smart-proxy:error:invalid_body",
+		Title:  "Invalid request body",
+		Status: http.StatusBadRequest,
+		Detail: "invalid character 'T' looking for beginning of value",
+		Code:   server.ErrorCodeInvalidBody,
 	}
 
 	ReportMetainfoAPIResponseInvalidClusterName = struct {
@@ -1264,16 +1282,134 @@ func TestServerStartError(t *testing.T) {
 	},
 		nil,
 		nil,
-		nil,
-		nil,
 	)
 
 	err := testServer.Start()
 	assert.EqualError(t, err, "listen tcp: address 99999: invalid port")
 }
 
+// TestServerStartH2C checks that HTTP/2 requests are served over cleartext
+// connection when h2c is enabled
+func TestServerStartH2C(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	helpers.FailOnError(t, err)
+	address := listener.Addr().String()
+	helpers.FailOnError(t, listener.Close())
+
+	config := helpers.DefaultServerConfig
+	config.Address = address
+	config.Auth = false
+	config.EnableH2C = true
+	testServer := server.New(config, helpers.DefaultServicesConfig, nil, nil)
+	go func() {
+		helpers.FailOnError(t, testServer.Start())
+	}()
+
+	// HTTP/2 with prior knowledge
+	client := http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	var response *http.Response
+	for attempt := 0; attempt < 50; attempt++ {
+		if response, err = client.Get("http://" + address + config.APIv1Prefix + server.MainEndpoint); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	helpers.FailOnError(t, err)
+	defer func() {
+		helpers.FailOnError(t, response.Body.Close())
+	}()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 2, response.ProtoMajor)
+	helpers.FailOnError(t, testServer.Stop(context.Background()))
+}
+
+// TestFilterRulesInResponseKeepsOrder checks that the rules resolved in
+// parallel are returned in the order of the report
+func TestFilterRulesInResponseKeepsOrder(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	noContentRule := testdata.RuleOnReport1
+	noContentRule.Module = "ccx_rules_ocp.external.rules.no_content"
+	disabledRule := testdata.RuleOnReport2
+	disabledRule.Disabled = true
+
+	var report []ctypes.RuleOnReport
+	var expectedModules []types.RuleID
+	for i := 0; i < 10; i++ {
+		report = append(report, testdata.RuleOnReport3, noContentRule, testdata.RuleOnReport1, disabledRule)
+		expectedModules = append(expectedModules, testdata.RuleOnReport3.Module, testdata.RuleOnReport1.Module)
+	}
+
+	okRules, noContentRulesCnt, disabledRulesCnt, err := server.FilterRulesInResponse(report, false, false, true, false, "", "", nil)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, 10, noContentRulesCnt)
+	assert.Equal(t, 10, disabledRulesCnt)
+
+	modules := make([]types.RuleID, 0, len(okRules))
+	for _, rule := range okRules {
+		modules = append(modules, rule.RuleID)
+	}
+	assert.Equal(t, expectedModules, modules)
+}
+
+// TestFilterRulesInResponseMissingContent checks that rules without content
+// are returned with content_status marker when requested
+func TestFilterRulesInResponseMissingContent(t *testing.T) {
+	defer content.ResetContent()
+	helpers.FailOnError(t, loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules))
+
+	noContentRule := testdata.RuleOnReport1
+	noContentRule.Module = "ccx_rules_ocp.external.rules.no_content"
+	report := []ctypes.RuleOnReport{testdata.RuleOnReport1, noContentRule}
+
+	okRules, noContentRulesCnt, _, err := server.FilterRulesInResponse(report, false, false, true, true, "", "", nil)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, 0, noContentRulesCnt)
+	assert.Len(t, okRules, 2)
+	assert.Equal(t, "", okRules[0].ContentStatus)
+	assert.Equal(t, noContentRule.Module, okRules[1].RuleID)
+	assert.Equal(t, noContentRule.ErrorKey, okRules[1].ErrorKey)
+	assert.Equal(t, types.RuleContentStatusMissing, okRules[1].ContentStatus)
+}
+
+// TestFilterRulesByResolutionRisk checks that only the rules with requested
+// resolution risks are kept in the report
+func TestFilterRulesByResolutionRisk(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet, "/report?resolution_risk=1,3", http.NoBody)
+	helpers.FailOnError(t, err)
+	risks, err := server.ReadResolutionRiskParam(request)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []int{1, 3}, risks)
+
+	rules := []types.RuleWithContentResponse{
+		{RuleID: "ccx.rule_low", ResolutionRisk: 1},
+		{RuleID: "ccx.rule_moderate", ResolutionRisk: 2},
+		{RuleID: "ccx.rule_important", ResolutionRisk: 3},
+		{RuleID: "ccx.rule_missing_content"},
+	}
+	filtered := server.FilterRulesByResolutionRisk(rules, risks)
+	assert.Len(t, filtered, 2)
+	assert.Equal(t, types.RuleID("ccx.rule_low"), filtered[0].RuleID)
+	assert.Equal(t, types.RuleID("ccx.rule_important"), filtered[1].RuleID)
+
+	for _, value := range []string{"0", "5", "low", "1,"} {
+		request, err := http.NewRequest(http.MethodGet, "/report?resolution_risk="+value, http.NoBody)
+		helpers.FailOnError(t, err)
+		_, err = server.ReadResolutionRiskParam(request)
+		assert.Error(t, err, value)
+	}
+}
+
 func TestAddCORSHeaders(t *testing.T) {
-	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigCORS, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigCORS, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 		Method:   http.MethodOptions,
 		Endpoint: server.RuleGroupsEndpoint,
 		ExtraHeaders: http.Header{
@@ -1284,7 +1420,7 @@ func TestAddCORSHeaders(t *testing.T) {
 	}, &helpers.APIResponse{
 		StatusCode: http.StatusOK,
 		Headers: map[string]string{
-			"Access-Control-Allow-Origin":      "*",
+			"Access-Control-Allow-Origin":      "http://example.com",
 			"Access-Control-Allow-Credentials": "true",
 			"Access-Control-Allow-Methods":     http.MethodOptions,
 			"Access-Control-Allow-Headers":     "X-Csrf-Token,Content-Type,Content-Length",
@@ -1295,7 +1431,7 @@ func TestAddCORSHeaders(t *testing.T) {
 func TestHTTPServer_SetAMSInfoInReportNoAMSClient(t *testing.T) {
 	report := types.SmartProxyReportV2{}
 	config := helpers.DefaultServerConfig
-	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
 	testServer.SetAMSInfoInReport(testdata.ClusterName, &report)
 	assert.Equal(t, string(testdata.ClusterName), report.Meta.DisplayName)
 }
@@ -1308,15 +1444,41 @@ func TestHTTPServer_SetAMSInfoInReportAMSClientClusterIDFound(t *testing.T) {
 		testdata.OrgID,
 		data.ClusterInfoResult,
 	)
-	testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil)
 	testServer.SetAMSInfoInReport(testdata.ClusterName, &report)
 	assert.Equal(t, data.ClusterDisplayName1, report.Meta.DisplayName)
 }
 
+func TestHTTPServer_SetAMSInfoInReportClusterVersionAndLastSeen(t *testing.T) {
+	report := types.SmartProxyReportV2{}
+	config := helpers.DefaultServerConfig
+	// prepare list of organizations response
+	amsClientMock := helpers.AMSClientWithOrgResults(
+		testdata.OrgID,
+		[]types.ClusterInfo{
+			{
+				ID:          testdata.ClusterName,
+				DisplayName: data.ClusterDisplayName1,
+				Managed:     true,
+				Version:     data.ClusterVersion1,
+				LastSeen:    data.ClusterLastSeen1,
+			},
+		},
+	)
+	testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil)
+	testServer.SetAMSInfoInReport(testdata.ClusterName, &report)
+	assert.Equal(t, types.ReportResponseMetaV2{
+		DisplayName:    data.ClusterDisplayName1,
+		Managed:        true,
+		ClusterVersion: data.ClusterVersion1,
+		LastSeen:       data.ClusterLastSeen1,
+	}, report.Meta)
+}
+
 // TestInfoEndpointNoAuth checks that the info endpoint can be accessed without authenticating
 func TestInfoEndpointNoAuth(t *testing.T) {
 	t.Run("test the info endpoint v1", func(t *testing.T) {
-		helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigAuth, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigAuth, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.InfoEndpoint,
 		}, &helpers.APIResponse{
@@ -1324,7 +1486,7 @@ func TestInfoEndpointNoAuth(t *testing.T) {
 		})
 	})
 	t.Run("test the info endpoint v2", func(t *testing.T) {
-		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigAuth, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigAuth, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.InfoEndpoint,
 		}, &helpers.APIResponse{
@@ -1333,6 +1495,41 @@ func TestInfoEndpointNoAuth(t *testing.T) {
 	})
 }
 
+// TestInfoEndpointServices checks that the info endpoint combines info of
+// Smart Proxy with info of Insights Results Aggregator and Content Service
+func TestInfoEndpointServices(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+
+	helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+		Method:   http.MethodGet,
+		Endpoint: "info",
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       `{"status": "ok", "info": {"BuildVersion": "aggregator"}}`,
+	})
+	helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.ContentBaseEndpoint, &helpers.APIRequest{
+		Method:   http.MethodGet,
+		Endpoint: "info",
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusServiceUnavailable,
+	})
+
+	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigAuth, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
+		Method:   http.MethodGet,
+		Endpoint: server.InfoEndpoint,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body: `{
+			"status": "ok",
+			"info": {
+				"SmartProxy": {"status": "ok"},
+				"Aggregator": {"BuildVersion": "aggregator", "status": "ok"},
+				"ContentService": {"status": "Improper status code 503"}
+			}
+		}`,
+	})
+}
+
 func ruleIDsChecker(t testing.TB, expected, got []byte) {
 	type Response struct {
 		Status string   `json:"status"`
@@ -1488,3 +1685,46 @@ func TestFillImpacted(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotContains(t, string(jsonResp), "0001-01-01T00:00:00Z")
 }
+
+func TestReadClusterStatusFilter(t *testing.T) {
+	testServer := server.HTTPServer{Config: serverConfigJWT}
+
+	for query, expected := range map[string][]string{
+		"":                        {"Archived", "Deprovisioned", "Reserved"},
+		"?include_inactive=false": {"Archived", "Deprovisioned", "Reserved"},
+		"?include_inactive=true":  {"Reserved"},
+		"?exclude_status=Stale":   {"Archived", "Deprovisioned", "Reserved", "Stale"},
+		"?include_inactive=true&exclude_status=Archived,%20Stale": {"Reserved", "Archived", "Stale"},
+	} {
+		request, err := http.NewRequest(http.MethodGet, "/clusters"+query, http.NoBody)
+		assert.NoError(t, err)
+
+		filter, err := server.ReadClusterStatusFilter(testServer, request)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, filter, query)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, "/clusters?include_inactive=maybe", http.NoBody)
+	assert.NoError(t, err)
+	_, err = server.ReadClusterStatusFilter(testServer, request)
+	assert.Error(t, err)
+}
+
+func TestReadClusterStatusFilterFromConfiguration(t *testing.T) {
+	config := serverConfigJWT
+	config.IncludeInactiveClusters = true
+	config.ExcludedClusterStatuses = []string{"Stale"}
+	testServer := server.HTTPServer{Config: config}
+
+	request, err := http.NewRequest(http.MethodGet, "/clusters", http.NoBody)
+	assert.NoError(t, err)
+	filter, err := server.ReadClusterStatusFilter(testServer, request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Reserved", "Stale"}, filter)
+
+	request, err = http.NewRequest(http.MethodGet, "/clusters?include_inactive=false", http.NoBody)
+	assert.NoError(t, err)
+	filter, err = server.ReadClusterStatusFilter(testServer, request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Archived", "Deprovisioned", "Reserved", "Stale"}, filter)
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// defaultServiceLogServiceName is used when the service name of Service
+	// Log entries is not configured
+	defaultServiceLogServiceName = "Insights Advisor"
+
+	// defaultServiceLogTemplate is the template of description of Service
+	// Log entries used when no template is configured
+	defaultServiceLogTemplate = `Insights Advisor detected the issue "{{.Rule.Description}}" on your cluster.
+{{if .Rule.Reason}}
+{{.Rule.Reason}}
+{{end}}{{if .Rule.Resolution}}
+{{.Rule.Resolution}}
+{{end}}{{if .Rule.MoreInfo}}
+{{.Rule.MoreInfo}}
+{{end}}`
+)
+
+// serviceLogSeverities maps total risk of the rule to severity of Service
+// Log entry
+var serviceLogSeverities = map[int]string{
+	1: "Info",
+	2: "Warning",
+	3: "Major",
+	4: "Critical",
+}
+
+// ServiceLogConfiguration represents configuration of sending
+// recommendations to cluster owners via OCM Service Log. Only associates with
+// any of the configured roles can send them.
+type ServiceLogConfiguration struct {
+	Enabled     bool     `mapstructure:"enabled" toml:"enabled"`
+	Roles       []string `mapstructure:"roles" toml:"roles"`
+	ServiceName string   `mapstructure:"service_name" toml:"service_name"`
+	// DescriptionTemplate is the text/template of description of the
+	// entries, the rule and the cluster ID are available in it
+	DescriptionTemplate string `mapstructure:"description_template" toml:"description_template"`
+}
+
+// InternalRoleRequiredError error is used when the operation is allowed to
+// associates with internal roles only
+type InternalRoleRequiredError struct{}
+
+func (*InternalRoleRequiredError) Error() string {
+	return "Only associates with internal roles are allowed to send service logs"
+}
+
+// serviceLogTemplateData is the data available in the template of
+// description of Service Log entries
+type serviceLogTemplateData struct {
+	ClusterID types.ClusterName
+	Rule      *types.RuleWithContentResponse
+}
+
+// newServiceLogTemplate parses the configured template of description of
+// Service Log entries. The default template is used when the configured one
+// is empty or invalid.
+func newServiceLogTemplate(config ServiceLogConfiguration) *template.Template {
+	if config.DescriptionTemplate != "" {
+		tmpl, err := template.New("service_log").Parse(config.DescriptionTemplate)
+		if err == nil {
+			return tmpl
+		}
+		log.Error().Err(err).Msg("Invalid template of service log description, default template is used")
+	}
+	return template.Must(template.New("service_log").Parse(defaultServiceLogTemplate))
+}
+
+// serviceLogEntry converts the rule hitting the cluster into Service Log
+// entry, the content of the rule has been interpolated already
+func (server HTTPServer) serviceLogEntry(
+	clusterID types.ClusterName, rule *types.RuleWithContentResponse,
+) (types.ServiceLogEntry, error) {
+	var description bytes.Buffer
+	err := newServiceLogTemplate(server.Config.ServiceLog).Execute(&description, serviceLogTemplateData{
+		ClusterID: clusterID,
+		Rule:      rule,
+	})
+	if err != nil {
+		return types.ServiceLogEntry{}, err
+	}
+
+	serviceName := server.Config.ServiceLog.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceLogServiceName
+	}
+	severity, found := serviceLogSeverities[rule.TotalRisk]
+	if !found {
+		severity = serviceLogSeverities[1]
+	}
+
+	return types.ServiceLogEntry{
+		ClusterUUID: clusterID,
+		Severity:    severity,
+		ServiceName: serviceName,
+		Summary:     rule.Description,
+		Description: strings.TrimSpace(description.String()),
+	}, nil
+}
+
+// sendServiceLog posts the recommendation hitting the cluster to OCM Service
+// Log, so the owners of the cluster are notified about it
+func (server HTTPServer) sendServiceLog(writer http.ResponseWriter, request *http.Request) {
+	if !hasAnyRole(request, server.Config.ServiceLog.Roles) {
+		handleServerError(writer, &InternalRoleRequiredError{})
+		return
+	}
+	if server.amsClient == nil {
+		log.Error().Msg("AMS API connection is not initialized")
+		handleServerError(writer, &AMSAPIUnavailableError{})
+		return
+	}
+
+	aggregatorResponse, successful, clusterID := server.fetchAggregatorReportRule(writer, request)
+	if !successful {
+		// error has been handled already
+		return
+	}
+
+	rule, filtered, err := content.FetchRuleContent(*aggregatorResponse, false, content.DefaultLanguage)
+	if err != nil || filtered {
+		handleFetchRuleContentError(writer, err, filtered)
+		return
+	}
+
+	entry, err := server.serviceLogEntry(clusterID, rule)
+	if err != nil {
+		log.Error().Err(err).Str(clusterIDTag, string(clusterID)).Msg("Unable to format service log entry")
+		handleServerError(writer, err)
+		return
+	}
+
+	if err := server.amsClient.SendServiceLog(entry); err != nil {
+		handleServerError(writer, &AMSAPIUnavailableError{})
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("service_log", entry))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
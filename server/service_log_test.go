@@ -0,0 +1,84 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestServiceLogEntry checks that the rule content is formatted into Service
+// Log entry by the default and the configured templates
+func TestServiceLogEntry(t *testing.T) {
+	rule := &types.RuleWithContentResponse{
+		Description: "Kubelet version is not up to date",
+		Reason:      "Nodes run old kubelet",
+		Resolution:  "Upgrade the nodes",
+		TotalRisk:   3,
+	}
+
+	config := serverConfigJWT
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil)
+	entry, err := server.ServiceLogEntry(*testServer, testdata.ClusterName, rule)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, testdata.ClusterName, entry.ClusterUUID)
+	assert.Equal(t, "Major", entry.Severity)
+	assert.Equal(t, "Insights Advisor", entry.ServiceName)
+	assert.Equal(t, rule.Description, entry.Summary)
+	assert.True(t, strings.Contains(entry.Description, "Nodes run old kubelet"))
+	assert.True(t, strings.Contains(entry.Description, "Upgrade the nodes"))
+
+	config.ServiceLog = server.ServiceLogConfiguration{
+		ServiceName:         "Advisor",
+		DescriptionTemplate: "{{.ClusterID}}: {{.Rule.Resolution}}",
+	}
+	testServer = helpers.CreateHTTPServer(&config, nil, nil, nil)
+	entry, err = server.ServiceLogEntry(*testServer, testdata.ClusterName, rule)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, "Advisor", entry.ServiceName)
+	assert.Equal(t, string(testdata.ClusterName)+": Upgrade the nodes", entry.Description)
+}
+
+// TestSendServiceLogRequiresInternalRole checks that only associates with
+// the configured roles can send Service Log entries
+func TestSendServiceLogRequiresInternalRole(t *testing.T) {
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	config.ServiceLog = server.ServiceLogConfiguration{
+		Enabled: true,
+		Roles:   []string{"sre"},
+	}
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	endpoint := strings.NewReplacer(
+		"{cluster}", string(testdata.ClusterName),
+		"{rule_id}", string(testdata.Rule1CompositeID),
+	).Replace(server.ServiceLogEndpoint)
+	request := httptest.NewRequest(http.MethodPost, config.APIv2Prefix+endpoint, http.NoBody)
+	request.Header.Set("x-rh-identity", supportIdentity("other"))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), server.ErrorCodeInternalRoleRequired)
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ShadowClustersForOrganization is the name of the clusters for
+	// organization endpoint in shadow mode configuration
+	ShadowClustersForOrganization = "clusters_for_organization"
+
+	// results of comparison of responses of proxied and native
+	// implementation
+	shadowResultMatch          = "match"
+	shadowResultStatusMismatch = "status_mismatch"
+	shadowResultBodyMismatch   = "body_mismatch"
+)
+
+// ShadowComparisons counts requests served in shadow mode by endpoint and by
+// the result of comparison of responses of proxied and native implementation
+var ShadowComparisons = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "shadow_comparisons_total",
+	Help: "The total number of compared responses of proxied and native implementations of endpoints",
+}, []string{"endpoint", "result"})
+
+// ShadowConfiguration represents configuration of the shadow mode. Native
+// implementations of the listed endpoints run alongside the proxied ones for
+// a percentage of requests, their responses are compared in background and
+// users always get the responses of proxied implementations.
+type ShadowConfiguration struct {
+	Endpoints  []string `mapstructure:"endpoints" toml:"endpoints"`
+	Percentage float64  `mapstructure:"percentage" toml:"percentage"`
+}
+
+// detachedContext keeps the values of request context, but it is not
+// cancelled when the response of the request is sent
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// shadowRecorder passes the response to the client and keeps its copy
+type shadowRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (recorder *shadowRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *shadowRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// shadowSelected returns true if the request of endpoint is served in shadow
+// mode
+func (server HTTPServer) shadowSelected(endpoint string) bool {
+	shadow := server.Config.Shadow
+	if shadow.Percentage <= 0 || !collections.StringInSlice(endpoint, shadow.Endpoints) {
+		return false
+	}
+	// #nosec G404 -- cryptographically secure random numbers are not needed
+	return rand.Float64()*100 < shadow.Percentage
+}
+
+// shadowed returns the handler serving the endpoint by the primary (proxied)
+// implementation. Native implementation is run with the copy of the request
+// in shadow mode and its response is compared with the primary one.
+func (server HTTPServer) shadowed(endpoint string, primary, native http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if !server.shadowSelected(endpoint) {
+			primary(writer, request)
+			return
+		}
+
+		var body []byte
+		if request.Body != nil && request.Body != http.NoBody {
+			var err error
+			body, err = ioutil.ReadAll(request.Body)
+			if err != nil {
+				handleServerError(writer, bodyDecodingError(err))
+				return
+			}
+			request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		nativeRequest := request.Clone(detachedContext{request.Context()})
+		nativeRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		recorder := &shadowRecorder{ResponseWriter: writer}
+		primary(recorder, request)
+
+		go runShadow(endpoint, native, nativeRequest, recorder.statusCode, recorder.body.Bytes())
+	}
+}
+
+// runShadow serves the request by native implementation of the endpoint and
+// compares its response with the one of primary implementation
+func runShadow(endpoint string, native http.HandlerFunc, request *http.Request, statusCode int, body []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("endpoint", endpoint).Interface("panic", r).Msg("Native implementation panicked in shadow mode")
+		}
+	}()
+
+	writer := newRecordingWriter()
+	native(writer, request)
+
+	result := compareShadowResponses(statusCode, body, writer.status, writer.body.Bytes())
+	ShadowComparisons.WithLabelValues(endpoint, result).Inc()
+	if result != shadowResultMatch {
+		log.Warn().
+			Str("endpoint", endpoint).
+			Str("result", result).
+			Str("path", request.URL.Path).
+			Int("status", statusCode).
+			Int("native_status", writer.status).
+			Msg("Response of native implementation differs")
+	}
+}
+
+// compareShadowResponses compares responses of primary and native
+// implementation. Bodies of successful responses are compared after decoding,
+// so formatting and order of items in arrays don't matter. Only status codes
+// are compared for other responses.
+func compareShadowResponses(statusCode int, body []byte, nativeStatusCode int, nativeBody []byte) string {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	if statusCode != nativeStatusCode {
+		return shadowResultStatusMismatch
+	}
+	if statusCode != http.StatusOK {
+		return shadowResultMatch
+	}
+
+	var expected, actual interface{}
+	if json.Unmarshal(body, &expected) != nil || json.Unmarshal(nativeBody, &actual) != nil {
+		return shadowResultBodyMismatch
+	}
+	if !reflect.DeepEqual(normalizeJSON(expected), normalizeJSON(actual)) {
+		return shadowResultBodyMismatch
+	}
+	return shadowResultMatch
+}
+
+// normalizeJSON sorts items of all arrays in the decoded JSON value, so the
+// values can be compared regardless of order of items
+func normalizeJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = normalizeJSON(item)
+		}
+	case []interface{}:
+		encoded := make([]string, len(typed))
+		for i, item := range typed {
+			typed[i] = normalizeJSON(item)
+			data, _ := json.Marshal(typed[i])
+			encoded[i] = string(data)
+		}
+		sort.Sort(jsonItems{items: typed, encoded: encoded})
+	}
+	return value
+}
+
+// jsonItems sorts items of JSON array by their encoded form
+type jsonItems struct {
+	items   []interface{}
+	encoded []string
+}
+
+func (items jsonItems) Len() int {
+	return len(items.items)
+}
+
+func (items jsonItems) Less(i, j int) bool {
+	return items.encoded[i] < items.encoded[j]
+}
+
+func (items jsonItems) Swap(i, j int) {
+	items.items[i], items.items[j] = items.items[j], items.items[i]
+	items.encoded[i], items.encoded[j] = items.encoded[j], items.encoded[i]
+}
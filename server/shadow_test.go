@@ -0,0 +1,55 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+)
+
+// TestCompareShadowResponses checks comparison of responses of proxied and
+// native implementation of endpoint
+func TestCompareShadowResponses(t *testing.T) {
+	clusters := []byte(`{"clusters":["34c3ecc5-624a-49a5-bab8-4fdc5e51a266","74ae54aa-6577-4e80-85e7-697cb646ff37"],"status":"ok"}`)
+	reordered := []byte(`{"status": "ok", "clusters": ["74ae54aa-6577-4e80-85e7-697cb646ff37", "34c3ecc5-624a-49a5-bab8-4fdc5e51a266"]}`)
+	missing := []byte(`{"clusters":["34c3ecc5-624a-49a5-bab8-4fdc5e51a266"],"status":"ok"}`)
+
+	for _, testCase := range []struct {
+		name             string
+		statusCode       int
+		body             []byte
+		nativeStatusCode int
+		nativeBody       []byte
+		expected         string
+	}{
+		{"same clusters", http.StatusOK, clusters, http.StatusOK, reordered, "match"},
+		{"implicit status", 0, clusters, http.StatusOK, clusters, "match"},
+		{"missing cluster", http.StatusOK, clusters, http.StatusOK, missing, "body_mismatch"},
+		{"invalid body", http.StatusOK, clusters, http.StatusOK, []byte("{"), "body_mismatch"},
+		{"different status", http.StatusOK, clusters, http.StatusServiceUnavailable, nil, "status_mismatch"},
+		{"same error", http.StatusBadRequest, nil, http.StatusBadRequest, []byte("{}"), "match"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := server.CompareShadowResponses(
+				testCase.statusCode, testCase.body, testCase.nativeStatusCode, testCase.nativeBody,
+			)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
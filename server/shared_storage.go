@@ -0,0 +1,29 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+)
+
+// SetStorage sets the storage shared by all replicas. Export jobs and
+// webhooks are kept in it instead of the memory of the replica and
+// aggregator. It needs to be called before the router is initialized.
+func (server *HTTPServer) SetStorage(store storage.Store) {
+	server.storage = store
+	if server.exportJobs != nil {
+		server.exportJobs.shared = store
+	}
+}
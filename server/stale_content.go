@@ -0,0 +1,36 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+// StaleContentHeader is set in all responses while rule content or groups
+// are served from the on-disk snapshot
+const StaleContentHeader = "X-Content-Stale"
+
+// staleContentMiddleware marks responses by StaleContentHeader when the
+// content service was unreachable at startup and the snapshot is used
+func (server *HTTPServer) staleContentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if content.IsStale(server.GroupsStore) {
+			writer.Header().Set(StaleContentHeader, "true")
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
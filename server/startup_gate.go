@@ -0,0 +1,99 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+const (
+	// startingMessage is used as the detail of 503 responses sent before
+	// rule content and groups are loaded
+	startingMessage = "The service is starting, rule content and groups have not been loaded yet"
+	// startingRetryAfter is the number of seconds sent in Retry-After
+	// header of responses sent before the service is ready
+	startingRetryAfter = "5"
+)
+
+// startupGate keeps the service unready until rule content and groups are
+// loaded for the first time or until the startup timeout elapses
+type startupGate struct {
+	started time.Time
+	timeout time.Duration
+	// ready is set to 1 once the gate is open, it is never closed again
+	ready int32
+}
+
+// newStartupGate constructs the startup gate, zero timeout disables it
+func newStartupGate(timeout time.Duration) *startupGate {
+	gate := &startupGate{
+		started: time.Now(),
+		timeout: timeout,
+	}
+	if timeout <= 0 {
+		gate.ready = 1
+	}
+	return gate
+}
+
+// isReady method returns true when the service can serve requests. The gate
+// is opened when rule content and groups are loaded, or when the startup
+// timeout elapses.
+func (server *HTTPServer) isReady() bool {
+	gate := server.startupGate
+	if atomic.LoadInt32(&gate.ready) == 1 {
+		return true
+	}
+
+	loaded := content.GetStatus().Loaded && server.getGroupsStatus().Loaded
+	timedOut := time.Since(gate.started) >= gate.timeout
+	if !loaded && !timedOut {
+		return false
+	}
+
+	if atomic.CompareAndSwapInt32(&gate.ready, 0, 1) {
+		if loaded {
+			log.Info().Dur("startup_duration", time.Since(gate.started)).Msg("Rule content and groups loaded, service is ready")
+		} else {
+			log.Warn().Dur("startup_timeout", gate.timeout).Msg("Rule content or groups not loaded before startup timeout, service is ready anyway")
+		}
+	}
+	return true
+}
+
+// startupGateMiddleware responds with 503 to all requests except the ones for
+// exempted URLs until the service is ready
+func (server *HTTPServer) startupGateMiddleware(next http.Handler, exemptURLs []string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if server.isReady() || collections.StringInSlice(request.URL.Path, exemptURLs) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		writer.Header().Set("Retry-After", startingRetryAfter)
+		// not sent through handleServerError, startup is not an error that
+		// should be reported
+		if err := sendProblem(writer, newProblem(ErrorCodeStarting, startingMessage)); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestStartupGateNotReady checks that all endpoints except the health ones
+// respond with 503 until groups are loaded
+func TestStartupGateNotReady(t *testing.T) {
+	config := serverConfigJWT
+	config.StartupTimeout = time.Hour
+
+	// groups store is not provided, so groups are never loaded
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	recorder := serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "5", recorder.Header().Get("Retry-After"))
+	assert.Contains(t, recorder.Body.String(), `"code":"service_starting"`)
+
+	recorder = serveV2Request(router, server.InfoEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// TestStartupGateTimeout checks that endpoints are served after the startup
+// timeout elapses even when groups are not loaded
+func TestStartupGateTimeout(t *testing.T) {
+	config := serverConfigJWT
+	config.StartupTimeout = 10 * time.Millisecond
+
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+	time.Sleep(2 * config.StartupTimeout)
+
+	recorder := serveV2Request(router, server.MainEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
@@ -32,6 +33,79 @@ import (
 // UpgradeRisksPredictionServiceEndpoint endpoint for the upgrade prediction service
 const UpgradeRisksPredictionServiceEndpoint = "cluster/{cluster}/upgrade-risks-prediction"
 
+// upgradePredictionCacheEntry is a prediction for single cluster together
+// with the time when it was retrieved from the Data Engineering Service
+type upgradePredictionCacheEntry struct {
+	prediction *types.UpgradeRecommendation
+	fetchedAt  time.Time
+}
+
+// upgradePredictionCache stores upgrade risks predictions for a short time,
+// so the console upgrade flow doesn't hit the Data Engineering Service on
+// every request. Nil cache or cache with zero TTL doesn't store anything.
+type upgradePredictionCache struct {
+	ttl       time.Duration
+	mutex     sync.RWMutex
+	entries   map[types.ClusterName]upgradePredictionCacheEntry
+	lastPurge time.Time
+}
+
+// newUpgradePredictionCache constructs new cache with given TTL of its entries
+func newUpgradePredictionCache(ttl time.Duration) *upgradePredictionCache {
+	return &upgradePredictionCache{
+		ttl:       ttl,
+		entries:   make(map[types.ClusterName]upgradePredictionCacheEntry),
+		lastPurge: time.Now(),
+	}
+}
+
+func (cache *upgradePredictionCache) enabled() bool {
+	return cache != nil && cache.ttl > 0
+}
+
+// get returns cached prediction for the cluster if it has not expired yet
+func (cache *upgradePredictionCache) get(clusterID types.ClusterName) (upgradePredictionCacheEntry, bool) {
+	if !cache.enabled() {
+		return upgradePredictionCacheEntry{}, false
+	}
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	entry, found := cache.entries[clusterID]
+	if !found || time.Since(entry.fetchedAt) > cache.ttl {
+		return upgradePredictionCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// set stores the prediction for the cluster. Expired entries are purged at
+// most once per TTL to keep the size of cache bounded.
+func (cache *upgradePredictionCache) set(
+	clusterID types.ClusterName, prediction *types.UpgradeRecommendation, fetchedAt time.Time,
+) {
+	if !cache.enabled() {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if fetchedAt.Sub(cache.lastPurge) > cache.ttl {
+		for id, entry := range cache.entries {
+			if fetchedAt.Sub(entry.fetchedAt) > cache.ttl {
+				delete(cache.entries, id)
+			}
+		}
+		cache.lastPurge = fetchedAt
+	}
+
+	cache.entries[clusterID] = upgradePredictionCacheEntry{
+		prediction: prediction,
+		fetchedAt:  fetchedAt,
+	}
+}
+
 // method upgradeRisksPrediction returns a recommendation to upgrade or not a cluster
 // and a list of the alerts/operator conditions that were taken into account if the
 // upgrade is not recommended.
@@ -96,21 +170,33 @@ func (server *HTTPServer) upgradeRisksPrediction(writer http.ResponseWriter, req
 		return
 	}
 
-	// Request to Data Engineering Service to retrieve the result
-	predictionResponse, err := server.fetchUpgradePrediction(clusterID, writer)
-	if err != nil || predictionResponse == nil {
-		// Error already handled or not OK status, already returned
-		return
+	cached, found := server.upgradePredictionCache.get(clusterID)
+	if !found {
+		// Request to Data Engineering Service to retrieve the result
+		predictionResponse, err := server.fetchUpgradePrediction(clusterID, writer)
+		if err != nil || predictionResponse == nil {
+			// Error already handled or not OK status, already returned
+			return
+		}
+
+		cached = upgradePredictionCacheEntry{
+			prediction: predictionResponse,
+			fetchedAt:  time.Now(),
+		}
+		server.upgradePredictionCache.set(clusterID, cached.prediction, cached.fetchedAt)
+	} else {
+		log.Debug().Str(clusterIDTag, string(clusterID)).Msg("using cached upgrade risks prediction")
 	}
 
 	response := make(map[string]interface{})
-	response["upgrade_recommendation"] = predictionResponse
+	response["upgrade_recommendation"] = cached.prediction
 	response["status"] = OkMsg
 
 	// TODO: Currently DataEng service doesn't return any timestamp
-	// Getting current time to avoid returning an empty string
+	// Using the time when the prediction was retrieved to avoid
+	// returning an empty string
 	response["meta"] = types.UpgradeRisksMeta{
-		LastCheckedAt: types.Timestamp(time.Now().UTC().Format(time.RFC3339)),
+		LastCheckedAt: types.Timestamp(cached.fetchedAt.UTC().Format(time.RFC3339)),
 	}
 
 	err = responses.SendOK(
@@ -127,7 +213,7 @@ func (server *HTTPServer) fetchUpgradePrediction(
 	writer http.ResponseWriter,
 ) (*types.UpgradeRecommendation, error) {
 	dataEngURL := httputils.MakeURLToEndpoint(
-		server.ServicesConfig.UpgradeRisksPredictionEndpoint,
+		server.currentServicesConfig().UpgradeRisksPredictionEndpoint,
 		UpgradeRisksPredictionServiceEndpoint,
 		cluster,
 	)
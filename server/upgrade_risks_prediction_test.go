@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
@@ -90,7 +91,7 @@ func TestHTTPServer_GetUpgradeRisksPrediction(t *testing.T) {
 			"status":"ok"
 		}
 		`
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(
 			t,
@@ -161,7 +162,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionNotRecommended(t *testing.T) {
 			"status":"ok"
 		}
 		`
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(
 			t,
@@ -197,7 +198,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionNotRecommended(t *testing.T) {
 func TestHTTPServer_GetUpgradeRisksPredictionOfflineAMS(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		cluster := testdata.GetRandomClusterInfoListAllUnManaged(1)[0].ID
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -228,7 +229,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionClusterNotBelonging(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -257,7 +258,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionNotFound(t *testing.T) {
 			testdata.OrgID,
 			clusterInfoList,
 		)
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		helpers.GockExpectAPIRequest(
 			t,
@@ -300,7 +301,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionInvalidResponse(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		helpers.GockExpectAPIRequest(
 			t,
 			helpers.DefaultServicesConfig.UpgradeRisksPredictionEndpoint,
@@ -341,7 +342,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionUnavailableDataEngineering(t *testi
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -371,7 +372,7 @@ func TestHTTPServer_GetUpgradeRisksPredictionManagedCluster(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, amsClientMock, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -388,3 +389,67 @@ func TestHTTPServer_GetUpgradeRisksPredictionManagedCluster(t *testing.T) {
 		)
 	}, testTimeout)
 }
+
+func TestHTTPServer_GetUpgradeRisksPredictionCached(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := testdata.GetRandomClusterInfoListAllUnManaged(3)
+		cluster := clusterInfoList[0].ID
+
+		// prepare response from amsclient for list of clusters
+		amsClientMock := helpers.AMSClientWithOrgResults(
+			testdata.OrgID,
+			clusterInfoList,
+		)
+
+		expectedResponse := `
+		{
+			"upgrade_recommendation": {
+				"upgrade_recommended": true,
+				"upgrade_risks_predictors": {
+					"alerts": null,
+					"operator_conditions": null
+				}
+			},
+			"meta": {},
+			"status":"ok"
+		}
+		`
+		servicesConfig := helpers.DefaultServicesConfig
+		servicesConfig.UpgradeRisksPredictionCacheTTL = time.Minute
+		testServer := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, amsClientMock, nil)
+
+		// Data Engineering Service is expected to be called just once
+		helpers.GockExpectAPIRequest(
+			t,
+			helpers.DefaultServicesConfig.UpgradeRisksPredictionEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     "cluster/{clusterId}/upgrade-risks-prediction",
+				EndpointArgs: []interface{}{cluster},
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       testdata.UpgradeRecommended,
+			},
+		)
+
+		for i := 0; i < 2; i++ {
+			iou_helpers.AssertAPIRequest(
+				t,
+				testServer,
+				serverConfigJWT.APIv2Prefix,
+				&helpers.APIRequest{
+					Method:             http.MethodGet,
+					Endpoint:           server.UpgradeRisksPredictionEndpoint,
+					EndpointArgs:       []interface{}{cluster},
+					AuthorizationToken: goodJWTAuthBearer,
+				}, &helpers.APIResponse{
+					StatusCode:  http.StatusOK,
+					Body:        expectedResponse,
+					BodyChecker: checkBodyWithoutTimestamps,
+				},
+			)
+		}
+	}, testTimeout)
+}
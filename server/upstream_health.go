@@ -0,0 +1,231 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+const (
+	// defaultUpstreamProbeTimeout is used when the probe timeout is not
+	// configured
+	defaultUpstreamProbeTimeout = 5 * time.Second
+	// defaultUpstreamFailureThreshold is used when the failure threshold is
+	// not configured
+	defaultUpstreamFailureThreshold = 3
+
+	upstreamHealthy  upstreamState = "healthy"
+	upstreamDegraded upstreamState = "degraded"
+	upstreamDown     upstreamState = "down"
+)
+
+// errUpstreamDown is used when the request is not sent to the upstream
+// service, because the service is down
+var errUpstreamDown = errors.New("upstream service is down")
+
+// UpstreamHealthConfiguration represents configuration of the background
+// probes of Insights Results Aggregator and Content Service
+type UpstreamHealthConfiguration struct {
+	// ProbeInterval is the time between probes, zero disables them
+	ProbeInterval time.Duration `mapstructure:"probe_interval" toml:"probe_interval"`
+	// Timeout limits the time of single probe
+	Timeout time.Duration `mapstructure:"timeout" toml:"timeout"`
+	// FailureThreshold is the number of consecutive failed probes after
+	// which the upstream service is considered down
+	FailureThreshold int `mapstructure:"failure_threshold" toml:"failure_threshold"`
+}
+
+// upstreamState is the health state of an upstream service. The service is
+// degraded after the first failed probe and down when the failure threshold
+// is reached. Any successful probe makes it healthy again.
+type upstreamState string
+
+// upstreamStatus is the health of an upstream service as reported by the
+// status endpoint
+type upstreamStatus struct {
+	State     upstreamState `json:"state"`
+	LastProbe time.Time     `json:"last_probe"`
+	LastError string        `json:"last_error,omitempty"`
+	// failures is the number of consecutive failed probes
+	failures int
+}
+
+// upstreamHealth holds the health of upstream services shared by all
+// requests. Upstream services that have not been probed yet are healthy.
+type upstreamHealth struct {
+	mutex     sync.RWMutex
+	threshold int
+	// targets are base URLs of the upstream services by their names
+	targets  map[string]string
+	statuses map[string]upstreamStatus
+}
+
+// newUpstreamHealth constructs the health holder for upstream services
+// configured in services configuration
+func newUpstreamHealth(config UpstreamHealthConfiguration, servicesConfig services.Configuration) *upstreamHealth {
+	threshold := config.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultUpstreamFailureThreshold
+	}
+
+	health := &upstreamHealth{
+		threshold: threshold,
+		statuses:  make(map[string]upstreamStatus),
+	}
+	health.setTargets(servicesConfig)
+	return health
+}
+
+// setTargets updates base URLs of the upstream services, it is called when
+// the services configuration is reloaded
+func (health *upstreamHealth) setTargets(servicesConfig services.Configuration) {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	health.targets = map[string]string{
+		upstreamAggregator:     servicesConfig.AggregatorBaseEndpoint,
+		upstreamContentService: servicesConfig.ContentBaseEndpoint,
+	}
+}
+
+// getTargets returns base URLs of the upstream services by their names
+func (health *upstreamHealth) getTargets() map[string]string {
+	health.mutex.RLock()
+	defer health.mutex.RUnlock()
+
+	targets := make(map[string]string, len(health.targets))
+	for name, baseURL := range health.targets {
+		targets[name] = baseURL
+	}
+	return targets
+}
+
+// record updates the state of the upstream service by the result of probe
+func (health *upstreamHealth) record(name string, err error) {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	status := health.statuses[name]
+	previous := status.State
+	status.LastProbe = time.Now().UTC()
+
+	if err == nil {
+		status.State = upstreamHealthy
+		status.LastError = ""
+		status.failures = 0
+	} else {
+		status.LastError = err.Error()
+		status.failures++
+		status.State = upstreamDegraded
+		if status.failures >= health.threshold {
+			status.State = upstreamDown
+		}
+	}
+	health.statuses[name] = status
+
+	if status.State != previous && previous != "" {
+		log.Warn().
+			Str("upstream", name).
+			Str("from", string(previous)).
+			Str("to", string(status.State)).
+			Msg("Upstream service health changed")
+	}
+}
+
+// isDown returns true when the upstream service with given base URL is down
+func (health *upstreamHealth) isDown(baseURL string) bool {
+	health.mutex.RLock()
+	defer health.mutex.RUnlock()
+
+	for name, target := range health.targets {
+		if target == baseURL {
+			return health.statuses[name].State == upstreamDown
+		}
+	}
+	return false
+}
+
+// get returns the current health of all probed upstream services
+func (health *upstreamHealth) get() map[string]upstreamStatus {
+	health.mutex.RLock()
+	defer health.mutex.RUnlock()
+
+	if len(health.statuses) == 0 {
+		return nil
+	}
+
+	statuses := make(map[string]upstreamStatus, len(health.statuses))
+	for name, status := range health.statuses {
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// probeUpstream checks that the upstream service responds to info endpoint.
+// Server errors are considered failures, other status codes are not.
+func probeUpstream(client *http.Client, baseURL string) error {
+	response, err := client.Get(httputils.MakeURLToEndpoint(baseURL, infoEndpoint))
+	if err != nil {
+		return err
+	}
+	defer services.CloseResponseBody(response)
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("info endpoint responded with status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+// RunUpstreamHealthLoop periodically probes Insights Results Aggregator and
+// Content Service. Requests proxied to the upstream services that are down
+// are refused immediately. It returns immediately when the probe interval is
+// not configured.
+func (server *HTTPServer) RunUpstreamHealthLoop() {
+	interval := server.Config.UpstreamHealth.ProbeInterval
+	if interval <= 0 {
+		log.Info().Msg("Upstream health probes are disabled")
+		return
+	}
+
+	timeout := server.Config.UpstreamHealth.Timeout
+	if timeout <= 0 {
+		timeout = defaultUpstreamProbeTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	server.probeUpstreams(client)
+	for range ticker.C {
+		server.probeUpstreams(client)
+	}
+}
+
+// probeUpstreams probes all upstream services and records the results
+func (server *HTTPServer) probeUpstreams(client *http.Client) {
+	for name, baseURL := range server.upstreamHealth.getTargets() {
+		server.upstreamHealth.record(name, probeUpstream(client, baseURL))
+	}
+}
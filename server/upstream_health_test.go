@@ -0,0 +1,63 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestUpstreamHealthStates checks that the upstream service is down after
+// the configured number of failed probes and healthy after successful one
+func TestUpstreamHealthStates(t *testing.T) {
+	servicesConfig := helpers.DefaultServicesConfig
+	health := server.NewUpstreamHealth(server.UpstreamHealthConfiguration{FailureThreshold: 2}, servicesConfig)
+	probeErr := errors.New("connection refused")
+
+	server.UpstreamHealthRecord(health, "aggregator", probeErr)
+	assert.False(t, server.UpstreamHealthIsDown(health, servicesConfig.AggregatorBaseEndpoint))
+
+	server.UpstreamHealthRecord(health, "aggregator", probeErr)
+	assert.True(t, server.UpstreamHealthIsDown(health, servicesConfig.AggregatorBaseEndpoint))
+	assert.False(t, server.UpstreamHealthIsDown(health, servicesConfig.ContentBaseEndpoint))
+
+	server.UpstreamHealthRecord(health, "aggregator", nil)
+	assert.False(t, server.UpstreamHealthIsDown(health, servicesConfig.AggregatorBaseEndpoint))
+}
+
+// TestProbeUpstream checks that only server errors are considered failures
+func TestProbeUpstream(t *testing.T) {
+	statusCode := http.StatusNotFound
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/info", request.URL.Path)
+		writer.WriteHeader(statusCode)
+	}))
+	defer upstream.Close()
+
+	assert.NoError(t, server.ProbeUpstream(upstream.Client(), upstream.URL+"/"))
+
+	statusCode = http.StatusBadGateway
+	assert.Error(t, server.ProbeUpstream(upstream.Client(), upstream.URL+"/"))
+
+	upstream.Close()
+	assert.Error(t, server.ProbeUpstream(upstream.Client(), upstream.URL+"/"))
+}
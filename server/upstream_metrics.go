@@ -0,0 +1,157 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// upstreamOther is the upstream label of requests sent to services
+	// other than aggregator and content service
+	upstreamOther = "other"
+
+	// traceParentHeader is W3C Trace Context header, the trace ID is its
+	// second field
+	traceParentHeader = "traceparent"
+	// b3TraceIDHeader is Zipkin B3 header with the trace ID
+	b3TraceIDHeader = "X-B3-TraceId"
+
+	// traceIDLabel is the exemplar label linking the observation to trace
+	traceIDLabel = "trace_id"
+)
+
+var (
+	// UpstreamRequests counts requests sent to upstream services by status
+	// code of the response, code is empty when no response was received
+	UpstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_requests_total",
+		Help: "The total number of requests sent to upstream services",
+	}, []string{"upstream", "method", "code"})
+
+	// UpstreamRequestErrors counts requests to upstream services that
+	// failed or were answered by server error
+	UpstreamRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_request_errors_total",
+		Help: "The total number of failed requests sent to upstream services",
+	}, []string{"upstream", "method"})
+
+	// UpstreamRequestDuration measures time to response headers of requests
+	// sent to upstream services, observations carry trace ID as exemplar
+	UpstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "upstream_request_duration_seconds",
+		Help: "Duration of requests sent to upstream services",
+	}, []string{"upstream", "method"})
+)
+
+// metricsHandler exposes Prometheus metrics, OpenMetrics format is offered
+// to scrapers supporting it, so exemplars are exposed too
+func metricsHandler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+}
+
+// upstreamMetricsTransport measures requests sent to upstream services
+type upstreamMetricsTransport struct {
+	next   http.RoundTripper
+	health *upstreamHealth
+}
+
+// newUpstreamMetricsTransport constructs the transport measuring requests
+// sent by the next transport, upstream services are recognized by their base
+// URLs kept in the health holder
+func newUpstreamMetricsTransport(next http.RoundTripper, health *upstreamHealth) *upstreamMetricsTransport {
+	return &upstreamMetricsTransport{
+		next:   next,
+		health: health,
+	}
+}
+
+// instrumentDefaultTransport makes all requests sent by the default HTTP
+// transport measured. Calls of aggregator and content service use the
+// default transport. It does nothing when the transport is instrumented
+// already.
+func (server *HTTPServer) instrumentDefaultTransport() {
+	if _, instrumented := http.DefaultTransport.(*upstreamMetricsTransport); instrumented {
+		return
+	}
+	http.DefaultTransport = newUpstreamMetricsTransport(http.DefaultTransport, server.upstreamHealth)
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (transport *upstreamMetricsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := transport.next.RoundTrip(request)
+	duration := time.Since(start)
+
+	upstream := transport.upstreamName(request.URL.String())
+	code := ""
+	if response != nil {
+		code = strconv.Itoa(response.StatusCode)
+	}
+	UpstreamRequests.WithLabelValues(upstream, request.Method, code).Inc()
+	if err != nil || response.StatusCode >= http.StatusInternalServerError {
+		UpstreamRequestErrors.WithLabelValues(upstream, request.Method).Inc()
+	}
+
+	observer := UpstreamRequestDuration.WithLabelValues(upstream, request.Method)
+	if traceID := traceIDFromHeader(request.Header); traceID != "" {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(duration.Seconds(), prometheus.Labels{traceIDLabel: traceID})
+			return response, err
+		}
+	}
+	observer.Observe(duration.Seconds())
+	return response, err
+}
+
+// upstreamName returns the name of the upstream service the URL belongs to
+func (transport *upstreamMetricsTransport) upstreamName(url string) string {
+	for name, baseURL := range transport.health.getTargets() {
+		if baseURL != "" && strings.HasPrefix(url, baseURL) {
+			return name
+		}
+	}
+	return upstreamOther
+}
+
+// traceIDFromHeader returns the trace ID propagated by W3C Trace Context or
+// Zipkin B3 headers, empty string is returned when there is none. Malformed
+// IDs are ignored, because exemplar labels are limited in length.
+func traceIDFromHeader(header http.Header) string {
+	traceID := header.Get(b3TraceIDHeader)
+	// version-trace_id-parent_id-flags
+	if fields := strings.Split(header.Get(traceParentHeader), "-"); len(fields) == 4 {
+		traceID = fields[1]
+	}
+
+	if len(traceID) != 16 && len(traceID) != 32 {
+		return ""
+	}
+	if _, err := hex.DecodeString(traceID); err != nil {
+		return ""
+	}
+	return traceID
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestUpstreamMetricsTransport checks that requests are counted by upstream
+// service and that server errors are counted as errors
+func TestUpstreamMetricsTransport(t *testing.T) {
+	statusCode := http.StatusOK
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(statusCode)
+	}))
+	defer upstream.Close()
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = upstream.URL + "/api/v1/"
+	health := server.NewUpstreamHealth(server.UpstreamHealthConfiguration{}, servicesConfig)
+	client := http.Client{Transport: server.NewUpstreamMetricsTransport(http.DefaultTransport, health)}
+
+	requests := server.UpstreamRequests.WithLabelValues("aggregator", http.MethodGet, "200")
+	failedRequests := server.UpstreamRequests.WithLabelValues("aggregator", http.MethodGet, "502")
+	errors := server.UpstreamRequestErrors.WithLabelValues("aggregator", http.MethodGet)
+	otherRequests := server.UpstreamRequests.WithLabelValues("other", http.MethodGet, "200")
+	requestsBefore := testutil.ToFloat64(requests)
+	failedRequestsBefore := testutil.ToFloat64(failedRequests)
+	errorsBefore := testutil.ToFloat64(errors)
+	otherRequestsBefore := testutil.ToFloat64(otherRequests)
+
+	request, err := http.NewRequest(http.MethodGet, upstream.URL+"/api/v1/info", nil)
+	helpers.FailOnError(t, err)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response, err := client.Do(request)
+	helpers.FailOnError(t, err)
+	helpers.FailOnError(t, response.Body.Close())
+
+	statusCode = http.StatusBadGateway
+	response, err = client.Get(upstream.URL + "/api/v1/info")
+	helpers.FailOnError(t, err)
+	helpers.FailOnError(t, response.Body.Close())
+
+	statusCode = http.StatusOK
+	response, err = client.Get(upstream.URL + "/other")
+	helpers.FailOnError(t, err)
+	helpers.FailOnError(t, response.Body.Close())
+
+	assert.Equal(t, requestsBefore+1, testutil.ToFloat64(requests))
+	assert.Equal(t, failedRequestsBefore+1, testutil.ToFloat64(failedRequests))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(errors))
+	assert.Equal(t, otherRequestsBefore+1, testutil.ToFloat64(otherRequests))
+}
+
+// TestTraceIDFromHeader checks that trace ID is read from W3C Trace Context
+// and B3 headers and that malformed IDs are ignored
+func TestTraceIDFromHeader(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		value    string
+		expected string
+	}{
+		{"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"X-B3-TraceId", "a3ce929d0e0e4736", "a3ce929d0e0e4736"},
+		{"traceparent", "00-not-a-trace-id", ""},
+		{"X-B3-TraceId", "this-is-not-hexadecimal-trace-id", ""},
+		{"X-Request-Id", "4bf92f3577b34da6a3ce929d0e0e4736", ""},
+	} {
+		header := http.Header{}
+		header.Set(tc.header, tc.value)
+		assert.Equal(t, tc.expected, server.TraceIDFromHeader(header), tc.value)
+	}
+}
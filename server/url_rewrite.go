@@ -0,0 +1,117 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// RewriteOrgID maps the param of upstream endpoint to the organization
+	// of the requester
+	RewriteOrgID = "$org_id"
+	// RewriteUserID maps the param of upstream endpoint to the ID of the
+	// requester
+	RewriteUserID = "$user_id"
+)
+
+// URLRewrite is the rule rewriting the URL of proxied request to the URL of
+// upstream endpoint. Query of the request is kept as is. Requests proxied
+// without the rule have APIv1Prefix stripped from the path.
+type URLRewrite struct {
+	// StripPrefix is removed from the beginning of the request path
+	StripPrefix string
+	// AddPrefix is added to the beginning of the path, after StripPrefix is
+	// removed or the path is replaced by Endpoint
+	AddPrefix string
+	// Endpoint replaces the request path when set. Its params, e.g.
+	// {cluster}, are filled by route params of the same name.
+	Endpoint string
+	// Params maps params of Endpoint to route params of different names,
+	// RewriteOrgID or RewriteUserID
+	Params map[string]string
+}
+
+// identityRewrite returns the rule rewriting the request to the upstream
+// endpoint with org_id and user_id params filled by the identity of the
+// requester
+func identityRewrite(endpoint string) *URLRewrite {
+	return &URLRewrite{
+		Endpoint: endpoint,
+		Params: map[string]string{
+			"org_id":  RewriteOrgID,
+			"user_id": RewriteUserID,
+		},
+	}
+}
+
+// rewriteParams returns the values of params of upstream endpoint
+func rewriteParams(request *http.Request, params map[string]string) (map[string]string, error) {
+	vars := mux.Vars(request)
+	args := make(map[string]string, len(vars)+len(params))
+	for name, value := range vars {
+		args[name] = value
+	}
+
+	for name, source := range params {
+		switch source {
+		case RewriteOrgID, RewriteUserID:
+			identity, err := requestIdentity(request)
+			if err != nil {
+				return nil, err
+			}
+			if source == RewriteOrgID {
+				args[name] = fmt.Sprint(identity.OrgID)
+			} else {
+				args[name] = string(identity.User.UserID)
+			}
+		default:
+			value, found := vars[source]
+			if !found {
+				return nil, &RouterMissingParamError{paramName: source}
+			}
+			args[name] = value
+		}
+	}
+	return args, nil
+}
+
+// rewriteURL returns the URL of upstream endpoint the request is proxied to
+func (server HTTPServer) rewriteURL(baseEndpoint string, request *http.Request, rewrite *URLRewrite) (*url.URL, error) {
+	if rewrite == nil {
+		rewrite = &URLRewrite{StripPrefix: server.Config.APIv1Prefix}
+	}
+
+	endpoint := strings.TrimPrefix(request.RequestURI, rewrite.StripPrefix)
+	if rewrite.Endpoint != "" {
+		args, err := rewriteParams(request, rewrite.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoint = strings.TrimPrefix(httputils.MakeURLToEndpointMapString("", rewrite.Endpoint, args), "/")
+		if request.URL.RawQuery != "" {
+			endpoint += "?" + request.URL.RawQuery
+		}
+	}
+
+	return url.Parse(baseEndpoint + rewrite.AddPrefix + endpoint)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestRewriteURL checks rewriting of proxied requests to upstream endpoints
+func TestRewriteURL(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfig, nil, nil, nil)
+	prefix := helpers.DefaultServerConfig.APIv1Prefix
+
+	for _, testCase := range []struct {
+		name        string
+		requestURI  string
+		rewrite     *server.URLRewrite
+		expectedURL string
+	}{
+		{
+			"default strips APIv1 prefix",
+			prefix + "organizations?limit=1",
+			nil,
+			"http://aggregator/api/v1/organizations?limit=1",
+		},
+		{
+			"strip and add prefix",
+			"/api/v2/clusters",
+			&server.URLRewrite{StripPrefix: "/api/v2/", AddPrefix: "internal/"},
+			"http://aggregator/api/v1/internal/clusters",
+		},
+		{
+			"endpoint with mapped params",
+			"/api/v2/cluster/c1/rules?verbose=true",
+			&server.URLRewrite{
+				Endpoint: "organizations/{org_id}/users/{user_id}/clusters/{cluster}/{rule}",
+				Params: map[string]string{
+					"org_id":  server.RewriteOrgID,
+					"user_id": server.RewriteUserID,
+					"rule":    "rule_id",
+				},
+			},
+			"http://aggregator/api/v1/organizations/42/users/user-1/clusters/c1/ccx.rule?verbose=true",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, testCase.requestURI, http.NoBody)
+			request = mux.SetURLVars(request, map[string]string{"cluster": "c1", "rule_id": "ccx.rule"})
+			request = request.WithContext(server.ContextWithIdentity(request.Context(), ctypes.Identity{
+				OrgID: 42,
+				User:  ctypes.User{UserID: "user-1"},
+			}))
+
+			endpointURL, err := server.RewriteURL(*testServer, "http://aggregator/api/v1/", request, testCase.rewrite)
+			helpers.FailOnError(t, err)
+			assert.Equal(t, testCase.expectedURL, endpointURL.String())
+		})
+	}
+}
+
+// TestRewriteURLMissingParam checks that request mapped to unknown route
+// param is refused
+func TestRewriteURLMissingParam(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfig, nil, nil, nil)
+	request := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", http.NoBody)
+
+	_, err := server.RewriteURL(*testServer, "http://aggregator/", request, &server.URLRewrite{
+		Endpoint: "clusters/{cluster}",
+		Params:   map[string]string{"cluster": "cluster_id"},
+	})
+	assert.Error(t, err)
+}
@@ -29,7 +29,7 @@ func logClusterInfos(orgID types.OrgID, clusterID types.ClusterName, response []
 	for _, ruleHit := range response {
 		logMessage += fmt.Sprintf("\n\trule: %s; error key: %s", ruleHit.Module, ruleHit.ErrorKey)
 	}
-	log.Info().Msg(logMessage)
+	sampledLogger(ruleHitsSampler).Info().Msg(logMessage)
 }
 
 func logClusterInfo(orgID types.OrgID, clusterID types.ClusterName, response *types.RuleOnReport) {
@@ -0,0 +1,143 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+const (
+	// defaultWarmUpWorkers is used when the number of organizations warmed
+	// up in parallel is not configured
+	defaultWarmUpWorkers = 2
+	// defaultWarmUpContentWait is used when the time to wait for rule
+	// content is not configured
+	defaultWarmUpContentWait = 5 * time.Minute
+	// warmUpPollInterval is the time between checks of rule content status
+	warmUpPollInterval = time.Second
+
+	// warmUpUserID is sent to aggregator instead of ID of the requester,
+	// the cached overview doesn't depend on the user
+	warmUpUserID = ctypes.UserID("warm-up")
+)
+
+// WarmUpConfiguration represents configuration of the cache warm-up done on
+// startup for the largest organizations
+type WarmUpConfiguration struct {
+	// Organizations whose caches are filled on startup, empty list
+	// disables the warm-up
+	Organizations []ctypes.OrgID `mapstructure:"organizations" toml:"organizations"`
+	// Workers is the number of organizations warmed up in parallel
+	Workers int `mapstructure:"workers" toml:"workers"`
+	// ContentWait limits the time spent waiting for rule content, which
+	// is needed to compute the organization overview
+	ContentWait time.Duration `mapstructure:"content_wait" toml:"content_wait"`
+}
+
+// waitForContent waits until rule content is loaded. It returns false when
+// the content is not loaded in the given time.
+func waitForContent(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !content.GetStatus().Loaded {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(warmUpPollInterval)
+	}
+	return true
+}
+
+// warmUpOrganization reads the clusters of the organization from AMS API and
+// their recommendations from aggregator. Cluster info and the organization
+// overview are stored into caches, the same as when the overview is requested
+// by user with default parameters.
+func (server HTTPServer) warmUpOrganization(orgID ctypes.OrgID) bool {
+	statusFilter := server.clusterStatusFilter(server.Config.IncludeInactiveClusters, nil)
+
+	writer := newRecordingWriter()
+	overview, successful := server.fetchOrgOverview(writer, orgID, warmUpUserID, statusFilter)
+	if !successful {
+		log.Error().Int(orgIDTag, int(orgID)).Str("error", writer.errorMessage()).Msg("Cache warm-up failed")
+		return false
+	}
+
+	if server.orgOverviewCache != nil {
+		server.orgOverviewCache.Set(orgOverviewCacheKey(orgID, statusFilter), overview, 0)
+	}
+	return true
+}
+
+// RunCacheWarmUp fills caches for the configured organizations, so the first
+// requests after deployment are not slow. It waits for rule content first. It
+// returns immediately when no organization is configured.
+func (server HTTPServer) RunCacheWarmUp() {
+	config := server.Config.WarmUp
+	if len(config.Organizations) == 0 {
+		log.Info().Msg("Cache warm-up is disabled")
+		return
+	}
+
+	contentWait := config.ContentWait
+	if contentWait <= 0 {
+		contentWait = defaultWarmUpContentWait
+	}
+	if !waitForContent(contentWait) {
+		log.Error().Dur("content_wait", contentWait).Msg("Rule content not loaded, cache warm-up skipped")
+		return
+	}
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = defaultWarmUpWorkers
+	}
+
+	tStart := time.Now()
+	orgIDs := make(chan ctypes.OrgID)
+	var (
+		waitGroup sync.WaitGroup
+		mutex     sync.Mutex
+		warmedUp  int
+	)
+	for i := 0; i < workers; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for orgID := range orgIDs {
+				if server.warmUpOrganization(orgID) {
+					mutex.Lock()
+					warmedUp++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, orgID := range config.Organizations {
+		orgIDs <- orgID
+	}
+	close(orgIDs)
+	waitGroup.Wait()
+
+	log.Info().
+		Int("organizations", len(config.Organizations)).
+		Int("warmed_up", warmedUp).
+		Dur("duration", time.Since(tStart)).
+		Msg("Cache warm-up finished")
+}
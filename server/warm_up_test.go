@@ -0,0 +1,82 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// TestCacheWarmUp checks that the organization overview computed by the
+// warm-up is served from cache without calling aggregator again
+func TestCacheWarmUp(t *testing.T) {
+	defer content.ResetContent()
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		clusterInfoList := data.GetRandomClusterInfoList(2)
+		reqBody, _ := json.Marshal(types.GetClusterNames(clusterInfoList))
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, "warm-up"},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body: fmt.Sprintf(`{"clusters":{"%v":{"created_at":"%v","recommendations":["%v"]}}}`,
+					clusterInfoList[0].ID, testTimeStr, testdata.Rule1CompositeID),
+			},
+		)
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID, "")
+
+		config := serverConfigJWT
+		config.OrgOverviewCacheTTL = time.Minute
+		testServer := helpers.CreateHTTPServer(
+			&config, nil, helpers.AMSClientWithOrgResults(testdata.OrgID, clusterInfoList), nil,
+		)
+		assert.True(t, server.WarmUpOrganization(*testServer, testdata.OrgID))
+
+		// aggregator is not called again, gock would fail the request
+		recorder := serveV2Request(testServer.Initialize(), server.OverviewEndpoint)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"clusters":2`)
+	}, testTimeout)
+}
+
+// TestCacheWarmUpFailure checks that failed warm-up is reported
+func TestCacheWarmUpFailure(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	assert.False(t, server.WarmUpOrganization(*testServer, testdata.OrgID))
+}
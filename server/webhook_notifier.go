@@ -0,0 +1,333 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// webhookTotalRisk is the total risk of recommendations the webhooks
+	// are notified about
+	webhookTotalRisk = 4
+	// webhookEvent is the event type of notifications sent to webhooks
+	webhookEvent = "new-critical-recommendations"
+	// defaultWebhookTimeout is used when the timeout is not configured
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// webhookNotifier remembers the critical recommendations seen during the
+// previous scan, so only the new ones are sent to webhooks. It is used by
+// the scan loop only, so it does not need to be synchronized.
+type webhookNotifier struct {
+	client   http.Client
+	seenHits map[ctypes.OrgID]map[string]bool
+}
+
+func newWebhookNotifier(config WebhooksConfiguration) *webhookNotifier {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &webhookNotifier{
+		client:   http.Client{Timeout: timeout},
+		seenHits: make(map[ctypes.OrgID]map[string]bool),
+	}
+}
+
+// webhookHitKey identifies the recommendation hitting the cluster
+func webhookHitKey(hit *types.WebhookRuleHit) string {
+	return fmt.Sprintf("%v|%v", hit.ClusterID, hit.RuleID)
+}
+
+// newHits remembers the current critical hits of the organization and
+// returns those that have not been seen during the previous scan. The
+// first scan of the organization only records the current state, so the
+// existing hits are not reported after restart or registration.
+func (notifier *webhookNotifier) newHits(orgID ctypes.OrgID, hits []types.WebhookRuleHit) []types.WebhookRuleHit {
+	seen, scannedBefore := notifier.seenHits[orgID]
+
+	current := make(map[string]bool, len(hits))
+	var newHits []types.WebhookRuleHit
+	for i := range hits {
+		key := webhookHitKey(&hits[i])
+		current[key] = true
+		if scannedBefore && !seen[key] {
+			newHits = append(newHits, hits[i])
+		}
+	}
+	notifier.seenHits[orgID] = current
+
+	return newHits
+}
+
+// forgetOrgsExcept drops the state of organizations without webhooks
+func (notifier *webhookNotifier) forgetOrgsExcept(orgIDs map[ctypes.OrgID][]types.Webhook) {
+	for orgID := range notifier.seenHits {
+		if _, found := orgIDs[orgID]; !found {
+			delete(notifier.seenHits, orgID)
+		}
+	}
+}
+
+// send posts the notification to the webhook
+func (notifier *webhookNotifier) send(webhook *types.Webhook, notification *types.WebhookNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	response, err := notifier.client.Post(webhook.URL, JSONContentType, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer services.CloseResponseBody(response)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+// readAllWebhooks reads webhooks of all organizations from the shared
+// storage or from all aggregator instances
+func (server HTTPServer) readAllWebhooks() ([]types.Webhook, error) {
+	if server.storage != nil {
+		return server.readSharedWebhooks(webhooksKeyPrefix)
+	}
+
+	var webhooks []types.Webhook
+	for _, aggregatorEndpoint := range server.currentServicesConfig().AggregatorEndpoints() {
+		aggregatorWebhooks, err := readAggregatorWebhooks(aggregatorEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, aggregatorWebhooks...)
+	}
+	return webhooks, nil
+}
+
+// readAggregatorWebhooks reads webhooks of all organizations from single
+// aggregator instance
+func readAggregatorWebhooks(aggregatorEndpoint string) ([]types.Webhook, error) {
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		aggregatorEndpoint,
+		aggregatorAllWebhooksEndpoint,
+	)
+
+	// #nosec G107
+	response, err := http.Get(aggregatorURL)
+	if err != nil {
+		return nil, err
+	}
+	defer services.CloseResponseBody(response)
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aggregator responded with status code %d", response.StatusCode)
+	}
+
+	var aggregatorResponse struct {
+		Webhooks []types.Webhook `json:"webhooks"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&aggregatorResponse)
+	return aggregatorResponse.Webhooks, err
+}
+
+// readRecommendationsForClusters reads recommendations hitting the clusters
+// from aggregator
+func (server HTTPServer) readRecommendationsForClusters(
+	orgID ctypes.OrgID, userID ctypes.UserID, clusterList []ctypes.ClusterName,
+) (ctypes.ClusterRecommendationMap, error) {
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorReadEndpoint(orgID),
+		ira_server.ClustersRecommendationsListEndpoint,
+		orgID,
+		userID,
+	)
+
+	body, err := json.Marshal(clusterList)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G107
+	response, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	defer services.CloseResponseBody(response)
+
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aggregator responded with status code %d", response.StatusCode)
+	}
+
+	var aggregatorResponse struct {
+		Clusters ctypes.ClusterRecommendationMap `json:"clusters"`
+	}
+	err = json.Unmarshal(responseBytes, &aggregatorResponse)
+	return aggregatorResponse.Clusters, err
+}
+
+// criticalHits returns enabled recommendations with the highest total risk
+// hitting clusters of the organization
+func criticalHits(
+	clusterInfoList []types.ClusterInfo,
+	clusterRecommendationsMap ctypes.ClusterRecommendationMap,
+	systemWideDisabledRules map[ctypes.RuleID]bool,
+	disabledRulesPerCluster map[ctypes.ClusterName][]ctypes.RuleID,
+) ([]types.WebhookRuleHit, error) {
+	var hits []types.WebhookRuleHit
+
+	for i := range clusterInfoList {
+		clusterInfo := &clusterInfoList[i]
+
+		enabledOnlyRecommendations := filterOutDisabledRules(
+			clusterRecommendationsMap[clusterInfo.ID].Recommendations, clusterInfo.ID,
+			systemWideDisabledRules, disabledRulesPerCluster,
+		)
+
+		for _, ruleID := range enabledOnlyRecommendations {
+			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			if err != nil {
+				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
+					return nil, err
+				}
+				// missing rule content, the rule can't be displayed
+				continue
+			}
+
+			if ruleContent.TotalRisk < webhookTotalRisk ||
+				(clusterInfo.Managed && !ruleContent.OSDCustomer) {
+				continue
+			}
+
+			hits = append(hits, types.WebhookRuleHit{
+				ClusterID:   clusterInfo.ID,
+				ClusterName: clusterInfo.DisplayName,
+				RuleID:      ruleID,
+				Description: ruleContent.Description,
+				TotalRisk:   ruleContent.TotalRisk,
+			})
+		}
+	}
+
+	return hits, nil
+}
+
+// readCriticalHits reads critical recommendations hitting clusters of the
+// organization. The user who registered the webhook is used to read rules
+// disabled per cluster.
+func (server HTTPServer) readCriticalHits(orgID ctypes.OrgID, userID ctypes.UserID) ([]types.WebhookRuleHit, error) {
+	clusterInfoList, err := server.readClusterInfoForOrgID(orgID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterRecommendationMap, err := server.readRecommendationsForClusters(
+		orgID, userID, types.GetClusterNames(clusterInfoList),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return criticalHits(
+		clusterInfoList, clusterRecommendationMap,
+		server.getRuleAcksMap(orgID), server.getUserDisabledRulesPerCluster(orgID),
+	)
+}
+
+// scanWebhooks checks organizations with registered webhooks and notifies
+// the webhooks about new critical recommendations
+func (server HTTPServer) scanWebhooks() {
+	webhooks, err := server.readAllWebhooks()
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to read webhooks from aggregator")
+		return
+	}
+
+	webhooksByOrg := make(map[ctypes.OrgID][]types.Webhook)
+	for _, webhook := range webhooks {
+		webhooksByOrg[webhook.OrgID] = append(webhooksByOrg[webhook.OrgID], webhook)
+	}
+	server.webhookNotifier.forgetOrgsExcept(webhooksByOrg)
+
+	for orgID, orgWebhooks := range webhooksByOrg {
+		hits, err := server.readCriticalHits(orgID, orgWebhooks[0].UserID)
+		if err != nil {
+			log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Unable to read critical recommendations")
+			continue
+		}
+
+		newHits := server.webhookNotifier.newHits(orgID, hits)
+		if len(newHits) == 0 {
+			continue
+		}
+
+		notification := types.WebhookNotification{
+			Event:  webhookEvent,
+			OrgID:  orgID,
+			SentAt: time.Now().UTC(),
+			Hits:   newHits,
+		}
+		for i := range orgWebhooks {
+			if err := server.webhookNotifier.send(&orgWebhooks[i], &notification); err != nil {
+				log.Error().Err(err).Int(orgIDTag, int(orgID)).Str("webhook_id", orgWebhooks[i].ID).Msg("Unable to notify webhook")
+				continue
+			}
+			log.Info().Int(orgIDTag, int(orgID)).Str("webhook_id", orgWebhooks[i].ID).Int("hits", len(newHits)).Msg("Webhook notified")
+		}
+	}
+}
+
+// RunWebhookScanLoop periodically scans the organizations with registered
+// webhooks and notifies them about new critical recommendations. It returns
+// immediately when the scan interval is not configured.
+func (server *HTTPServer) RunWebhookScanLoop() {
+	interval := server.Config.Webhooks.ScanInterval
+	if interval <= 0 {
+		log.Info().Msg("Webhook scan is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !server.featureFlags.IsEnabled(featureflags.Webhooks) {
+			continue
+		}
+		server.scanWebhooks()
+	}
+}
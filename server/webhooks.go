@@ -0,0 +1,303 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// aggregatorWebhooksEndpoint lists and registers webhooks of the
+	// organization
+	aggregatorWebhooksEndpoint = "organizations/{org_id}/webhooks"
+	// aggregatorWebhookEndpoint deletes single webhook of the
+	// organization
+	aggregatorWebhookEndpoint = "organizations/{org_id}/webhooks/{webhook_id}"
+	// aggregatorAllWebhooksEndpoint lists webhooks of all organizations,
+	// it is used by the periodic scan
+	aggregatorAllWebhooksEndpoint = "webhooks"
+
+	// webhookURLScheme is the only scheme allowed in webhook URLs
+	webhookURLScheme = "https"
+
+	// webhooksKeyPrefix is prepended to the keys of webhooks in the shared
+	// storage, the keys are webhooks/{org_id}/{webhook_id}
+	webhooksKeyPrefix = "webhooks/"
+	// webhookIDParam is the ID of the webhook in the path
+	webhookIDParam = "webhook_id"
+)
+
+// WebhooksConfiguration represents configuration of the periodic scan
+// notifying the registered webhooks about new critical recommendations
+type WebhooksConfiguration struct {
+	// ScanInterval is the time between scans, zero disables the scan
+	ScanInterval time.Duration `mapstructure:"scan_interval" toml:"scan_interval"`
+	// Timeout limits the time of single webhook call
+	Timeout time.Duration `mapstructure:"timeout" toml:"timeout"`
+}
+
+// OrgAdminRequiredError error is used when the operation is allowed to
+// organization administrators only
+type OrgAdminRequiredError struct{}
+
+func (*OrgAdminRequiredError) Error() string {
+	return "Only organization administrators are allowed to manage webhooks"
+}
+
+// isOrgAdmin returns true if the requester is administrator of the
+// organization. JWT tokens used for local testing don't contain this
+// information, so all users are administrators then.
+func (server HTTPServer) isOrgAdmin(request *http.Request) bool {
+	if server.Config.AuthType == "jwt" {
+		return true
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(request.Header.Get("x-rh-identity"))
+	if err != nil {
+		return false
+	}
+
+	var token struct {
+		Identity struct {
+			User struct {
+				IsOrgAdmin bool `json:"is_org_admin"`
+			} `json:"user"`
+		} `json:"identity"`
+	}
+	if err := json.Unmarshal(decoded, &token); err != nil {
+		return false
+	}
+	return token.Identity.User.IsOrgAdmin
+}
+
+// orgAdminOnly lets only requests of organization administrators to the
+// handler
+func (server HTTPServer) orgAdminOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if !server.isOrgAdmin(request) {
+			handleServerError(writer, &OrgAdminRequiredError{})
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+// validateWebhookURL checks that the webhook URL is absolute HTTPS URL
+func validateWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err == nil && (parsed.Scheme != webhookURLScheme || parsed.Host == "") {
+		err = fmt.Errorf("absolute %s URL is expected", webhookURLScheme)
+	}
+	if err != nil {
+		return &RouterParsingError{
+			paramName:  "url",
+			paramValue: webhookURL,
+			errString:  err.Error(),
+		}
+	}
+	return nil
+}
+
+// registerWebhook validates the webhook URL from the request body and
+// registers it for the organization of the requester in aggregator
+func (server HTTPServer) registerWebhook(writer http.ResponseWriter, request *http.Request) {
+	orgID, userID, err := server.GetCurrentOrgIDUserIDFromToken(request)
+	if err != nil {
+		log.Err(err).Msg(orgIDTokenError)
+		handleServerError(writer, err)
+		return
+	}
+
+	var webhook types.Webhook
+	if err = json.NewDecoder(request.Body).Decode(&webhook); err != nil {
+		if err == io.EOF {
+			err = &NoBodyError{}
+		}
+		handleServerError(writer, err)
+		return
+	}
+
+	if err = validateWebhookURL(webhook.URL); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	webhook = types.Webhook{
+		OrgID:  orgID,
+		UserID: userID,
+		URL:    webhook.URL,
+	}
+	if server.storage != nil {
+		server.registerSharedWebhook(writer, webhook)
+		return
+	}
+
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	aggregatorURL := httputils.MakeURLToEndpoint(
+		server.currentServicesConfig().AggregatorEndpoint(orgID),
+		aggregatorWebhooksEndpoint,
+		orgID,
+	)
+
+	// #nosec G107
+	aggregatorResp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			handleServerError(writer, &AggregatorServiceUnavailableError{})
+		} else {
+			handleServerError(writer, err)
+		}
+		return
+	}
+	defer services.CloseResponseBody(aggregatorResp)
+
+	responseBytes, err := io.ReadAll(aggregatorResp.Body)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("Webhook registered")
+	err = responses.Send(aggregatorResp.StatusCode, writer, responseBytes)
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// sharedWebhookKey returns the key of the webhook in the shared storage
+func sharedWebhookKey(orgID types.OrgID, webhookID string) string {
+	return fmt.Sprintf("%s%d/%s", webhooksKeyPrefix, orgID, webhookID)
+}
+
+// readSharedWebhooks reads the webhooks with keys starting with the prefix
+// from the shared storage, the oldest webhooks are returned first
+func (server HTTPServer) readSharedWebhooks(prefix string) ([]types.Webhook, error) {
+	values, err := server.storage.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]types.Webhook, 0, len(values))
+	for key, value := range values {
+		var webhook types.Webhook
+		if err := json.Unmarshal(value, &webhook); err != nil {
+			log.Error().Err(err).Str("key", key).Msg("Unable to parse webhook from shared storage")
+			continue
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// registerSharedWebhook stores the webhook into the shared storage
+func (server HTTPServer) registerSharedWebhook(writer http.ResponseWriter, webhook types.Webhook) {
+	webhook.ID = uuid.New().String()
+	webhook.CreatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+	if err = server.storage.Set(sharedWebhookKey(webhook.OrgID, webhook.ID), data, 0); err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(webhook.OrgID)).Msg("Unable to store webhook")
+		handleServerError(writer, err)
+		return
+	}
+
+	log.Info().Int(orgIDTag, int(webhook.OrgID)).Str(userIDTag, string(webhook.UserID)).Msg("Webhook registered")
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("webhook", webhook))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// listSharedWebhooks returns webhooks of the organization of the requester
+// stored in the shared storage
+func (server HTTPServer) listSharedWebhooks(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	webhooks, err := server.readSharedWebhooks(sharedWebhookKey(orgID, ""))
+	if err != nil {
+		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("Unable to read webhooks")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("webhooks", webhooks))
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// deleteSharedWebhook deletes the webhook of the organization of the
+// requester from the shared storage
+func (server HTTPServer) deleteSharedWebhook(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	webhookID := mux.Vars(request)[webhookIDParam]
+	key := sharedWebhookKey(orgID, webhookID)
+	_, found, err := server.storage.Get(key)
+	if err == nil && !found {
+		err = &utypes.ItemNotFoundError{ItemID: webhookID}
+	}
+	if err == nil {
+		err = server.storage.Delete(key)
+	}
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	log.Info().Int(orgIDTag, int(orgID)).Str("webhook_id", webhookID).Msg("Webhook deleted")
+	err = responses.SendOK(writer, responses.BuildOkResponse())
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func postWebhook(router http.Handler, body string, setAuth func(*http.Request)) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(
+		http.MethodPost, serverConfigJWT.APIv2Prefix+server.WebhooksEndpoint, strings.NewReader(body),
+	)
+	setAuth(request)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func jwtAuth(request *http.Request) {
+	request.Header.Set("Authorization", goodJWTAuthBearer)
+}
+
+// TestRegisterWebhook checks that the webhook is stored in aggregator
+// together with the organization and user of the requester
+func TestRegisterWebhook(t *testing.T) {
+	aggregator := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, fmt.Sprintf("/organizations/%v/webhooks", testdata.OrgID), request.URL.Path)
+
+		var webhook types.Webhook
+		helpers.FailOnError(t, json.NewDecoder(request.Body).Decode(&webhook))
+		assert.Equal(t, types.Webhook{
+			OrgID:  testdata.OrgID,
+			UserID: types.UserID(userIDOnGoodJWTAuthBearer),
+			URL:    "https://hooks.example.com/insights",
+		}, webhook)
+
+		_, _ = writer.Write([]byte(`{"status": "ok", "webhook": {"id": "1"}}`))
+	}))
+	defer aggregator.Close()
+
+	servicesConfig := helpers.DefaultServicesConfig
+	servicesConfig.AggregatorBaseEndpoint = aggregator.URL
+	router := helpers.CreateHTTPServer(&serverConfigJWT, &servicesConfig, nil, nil).Initialize()
+
+	recorder := postWebhook(router, `{"url": "https://hooks.example.com/insights", "org_id": 2}`, jwtAuth)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok", "webhook": {"id": "1"}}`, recorder.Body.String())
+}
+
+// TestSharedStorageWebhooks checks that webhooks are kept in the shared
+// storage when it is set instead of aggregator
+func TestSharedStorageWebhooks(t *testing.T) {
+	shared := storage.NewMemoryStore()
+	testServer := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil)
+	testServer.SetStorage(shared)
+	router := testServer.Initialize()
+
+	recorder := postWebhook(router, `{"url": "https://hooks.example.com/insights"}`, jwtAuth)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var registered struct {
+		Webhook types.Webhook `json:"webhook"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &registered))
+	assert.NotEmpty(t, registered.Webhook.ID)
+	assert.Equal(t, testdata.OrgID, registered.Webhook.OrgID)
+	assert.Equal(t, types.UserID(userIDOnGoodJWTAuthBearer), registered.Webhook.UserID)
+
+	values, err := shared.List(fmt.Sprintf("webhooks/%v/", testdata.OrgID))
+	helpers.FailOnError(t, err)
+	assert.Len(t, values, 1)
+
+	recorder = serveV2Request(router, server.WebhooksEndpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var listed struct {
+		Webhooks []types.Webhook `json:"webhooks"`
+	}
+	helpers.FailOnError(t, json.Unmarshal(recorder.Body.Bytes(), &listed))
+	assert.Equal(t, []types.Webhook{registered.Webhook}, listed.Webhooks)
+
+	endpoint := strings.Replace(server.WebhookEndpoint, "{webhook_id}", registered.Webhook.ID, 1)
+	recorder = serveV2RequestWithMethod(router, http.MethodDelete, endpoint)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = serveV2RequestWithMethod(router, http.MethodDelete, endpoint)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestRegisterWebhookInvalidURL checks that only absolute HTTPS URLs are
+// accepted
+func TestRegisterWebhookInvalidURL(t *testing.T) {
+	router := helpers.CreateHTTPServer(&serverConfigJWT, nil, nil, nil).Initialize()
+
+	for _, body := range []string{
+		``,
+		`{"url": ""}`,
+		`{"url": "http://hooks.example.com/insights"}`,
+		`{"url": "/insights"}`,
+		`{"url": "https://"}`,
+	} {
+		recorder := postWebhook(router, body, jwtAuth)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+	}
+}
+
+// TestRegisterWebhookNotOrgAdmin checks that users who are not organization
+// admins can't register webhooks
+func TestRegisterWebhookNotOrgAdmin(t *testing.T) {
+	config := serverConfigJWT
+	config.AuthType = "xrh"
+	router := helpers.CreateHTTPServer(&config, nil, nil, nil).Initialize()
+
+	for isOrgAdmin, expectedStatus := range map[bool]int{
+		false: http.StatusForbidden,
+		// the URL is checked after the requester
+		true: http.StatusBadRequest,
+	} {
+		identity := fmt.Sprintf(
+			`{"identity": {"account_number": "1", "org_id": "%v", "user": {"user_id": "1", "is_org_admin": %v}}}`,
+			testdata.OrgID, isOrgAdmin,
+		)
+		recorder := postWebhook(router, `{"url": "http://hooks.example.com"}`, func(request *http.Request) {
+			request.Header.Set("x-rh-identity", base64.StdEncoding.EncodeToString([]byte(identity)))
+		})
+		assert.Equal(t, expectedStatus, recorder.Code)
+	}
+}
+
+// TestWebhookNotifierNewHits checks that only hits not seen during the
+// previous scan are reported and that the first scan reports nothing
+func TestWebhookNotifierNewHits(t *testing.T) {
+	notifier := server.NewWebhookNotifier(server.WebhooksConfiguration{})
+
+	hit1 := types.WebhookRuleHit{ClusterID: testdata.ClusterName, RuleID: testdata.Rule1CompositeID}
+	hit2 := types.WebhookRuleHit{ClusterID: testdata.ClusterName, RuleID: testdata.Rule2CompositeID}
+
+	assert.Empty(t, server.WebhookNotifierNewHits(notifier, testdata.OrgID, []types.WebhookRuleHit{hit1}))
+	assert.Equal(t,
+		[]types.WebhookRuleHit{hit2},
+		server.WebhookNotifierNewHits(notifier, testdata.OrgID, []types.WebhookRuleHit{hit1, hit2}),
+	)
+	assert.Empty(t, server.WebhookNotifierNewHits(notifier, testdata.OrgID, []types.WebhookRuleHit{hit2}))
+	// the hit disappeared and appeared again
+	assert.Equal(t,
+		[]types.WebhookRuleHit{hit1},
+		server.WebhookNotifierNewHits(notifier, testdata.OrgID, []types.WebhookRuleHit{hit1, hit2}),
+	)
+}
@@ -18,15 +18,143 @@ package services
 
 import (
 	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/objectstorage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
 )
 
+// AggregatorShard is an aggregator instance serving a subset of
+// organizations. Organization belongs to the shard when its ID is in the
+// range or in the list of organizations.
+type AggregatorShard struct {
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint"`
+	// ReadEndpoint is the replica of the shard serving read operations,
+	// Endpoint is used for them when empty
+	ReadEndpoint string `mapstructure:"read_endpoint" toml:"read_endpoint"`
+	// MinOrgID and MaxOrgID are the bounds of the range, zero MaxOrgID
+	// means the range is not bounded from above
+	MinOrgID types.OrgID   `mapstructure:"min_org_id" toml:"min_org_id"`
+	MaxOrgID types.OrgID   `mapstructure:"max_org_id" toml:"max_org_id"`
+	OrgIDs   []types.OrgID `mapstructure:"org_ids" toml:"org_ids"`
+}
+
+// servesOrganization returns true when the organization belongs to the
+// shard. Unknown organization (zero ID) doesn't belong to any shard.
+func (shard *AggregatorShard) servesOrganization(orgID types.OrgID) bool {
+	if orgID == 0 {
+		return false
+	}
+	for _, shardOrgID := range shard.OrgIDs {
+		if shardOrgID == orgID {
+			return true
+		}
+	}
+
+	if shard.MinOrgID == 0 && shard.MaxOrgID == 0 {
+		return false
+	}
+	return orgID >= shard.MinOrgID && (shard.MaxOrgID == 0 || orgID <= shard.MaxOrgID)
+}
+
 // Configuration represents configuration of REST API HTTP server
 type Configuration struct {
 	AggregatorBaseEndpoint string `mapstructure:"aggregator" toml:"aggregator"`
 	ContentBaseEndpoint    string `mapstructure:"content" toml:"content"`
 
+	// AggregatorReadBaseEndpoint is the aggregator replica serving read
+	// operations (reports, rules), AggregatorBaseEndpoint is used for them
+	// when empty. Write operations always use AggregatorBaseEndpoint.
+	AggregatorReadBaseEndpoint string `mapstructure:"aggregator_read" toml:"aggregator_read"`
+
+	// AggregatorShards are aggregator instances serving subsets of
+	// organizations, the first shard serving the organization is used.
+	// Organizations not served by any shard use AggregatorBaseEndpoint.
+	AggregatorShards []AggregatorShard `mapstructure:"aggregator_shards" toml:"aggregator_shards"`
+
 	UpgradeRisksPredictionEndpoint string `mapstructure:"upgrade_risks_prediction" toml:"upgrade_risks_prediction"`
+	// UpgradeRisksPredictionCacheTTL is the time for which predictions
+	// are cached, zero value disables the cache
+	UpgradeRisksPredictionCacheTTL time.Duration `mapstructure:"upgrade_risks_prediction_cache_ttl" toml:"upgrade_risks_prediction_cache_ttl"`
+
+	// RemediationsEndpoint is the base endpoint of the remediations
+	// service generating Ansible playbooks, playbook availability flags
+	// and playbook generation are disabled when it is empty
+	RemediationsEndpoint string `mapstructure:"remediations" toml:"remediations"`
+	// RemediationsCacheTTL is the time for which playbook availability of
+	// rules is cached, zero value disables the cache
+	RemediationsCacheTTL time.Duration `mapstructure:"remediations_cache_ttl" toml:"remediations_cache_ttl"`
 
 	GroupsPollingTime       time.Duration `mapstructure:"groups_poll_time" toml:"groups_poll_time"`
 	ContentDirectoryTimeout time.Duration `mapstructure:"content_directory_timeout" toml:"content_directory_timeout"`
+	ContentRefreshInterval  time.Duration `mapstructure:"content_refresh_interval" toml:"content_refresh_interval"`
+	ContentRefreshJitter    time.Duration `mapstructure:"content_refresh_jitter" toml:"content_refresh_jitter"`
+
+	// ContentCacheSize is the number of rules with error keys kept in the
+	// LRU cache of rule content lookups, zero disables the cache
+	ContentCacheSize int `mapstructure:"content_cache_size" toml:"content_cache_size"`
+
+	// ContentLanguages is list of languages, other than English, for which
+	// localized rule content is retrieved from content service
+	ContentLanguages []string `mapstructure:"content_languages" toml:"content_languages"`
+
+	// ContentSnapshotDir is the directory where the last retrieved rule
+	// content and groups are stored. They are loaded from there when content
+	// service is unreachable at startup. Empty value disables the snapshots.
+	ContentSnapshotDir string `mapstructure:"content_snapshot_dir" toml:"content_snapshot_dir"`
+
+	// ExportStorage is S3-compatible storage of files exported by
+	// asynchronous exports, the files are kept in memory when the bucket
+	// is not set
+	ExportStorage objectstorage.Configuration `mapstructure:"export_storage" toml:"export_storage"`
+
+	// Storage is the storage of data owned by Smart Proxy (user
+	// preferences, export jobs, webhooks) shared by all replicas. The
+	// data are kept by the replicas or by aggregator when it is disabled.
+	Storage storage.Configuration `mapstructure:"storage" toml:"storage"`
+}
+
+// AggregatorEndpoint returns the base endpoint of aggregator instance
+// serving the organization, it is used for write operations
+func (conf *Configuration) AggregatorEndpoint(orgID types.OrgID) string {
+	for i := range conf.AggregatorShards {
+		if conf.AggregatorShards[i].servesOrganization(orgID) {
+			return conf.AggregatorShards[i].Endpoint
+		}
+	}
+	return conf.AggregatorBaseEndpoint
+}
+
+// AggregatorReadEndpoint returns the base endpoint of aggregator instance
+// serving read operations of the organization
+func (conf *Configuration) AggregatorReadEndpoint(orgID types.OrgID) string {
+	for i := range conf.AggregatorShards {
+		shard := &conf.AggregatorShards[i]
+		if shard.servesOrganization(orgID) {
+			if shard.ReadEndpoint != "" {
+				return shard.ReadEndpoint
+			}
+			return shard.Endpoint
+		}
+	}
+
+	if conf.AggregatorReadBaseEndpoint != "" {
+		return conf.AggregatorReadBaseEndpoint
+	}
+	return conf.AggregatorBaseEndpoint
+}
+
+// AggregatorEndpoints returns base endpoints of all aggregator instances,
+// each of them once
+func (conf *Configuration) AggregatorEndpoints() []string {
+	endpoints := []string{conf.AggregatorBaseEndpoint}
+	seen := map[string]bool{conf.AggregatorBaseEndpoint: true}
+	for _, shard := range conf.AggregatorShards {
+		if !seen[shard.Endpoint] {
+			seen[shard.Endpoint] = true
+			endpoints = append(endpoints, shard.Endpoint)
+		}
+	}
+	return endpoints
 }
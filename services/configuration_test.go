@@ -0,0 +1,76 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+// TestAggregatorEndpoint checks selection of aggregator shard serving the
+// organization
+func TestAggregatorEndpoint(t *testing.T) {
+	conf := services.Configuration{
+		AggregatorBaseEndpoint: "http://aggregator/",
+		AggregatorShards: []services.AggregatorShard{
+			{Endpoint: "http://shard-1/", MinOrgID: 1, MaxOrgID: 100},
+			{Endpoint: "http://shard-2/", OrgIDs: []types.OrgID{50, 200}},
+			{Endpoint: "http://shard-3/", MinOrgID: 1000},
+		},
+	}
+
+	for orgID, expected := range map[types.OrgID]string{
+		1:     "http://shard-1/",
+		50:    "http://shard-1/",
+		100:   "http://shard-1/",
+		200:   "http://shard-2/",
+		500:   "http://aggregator/",
+		1000:  "http://shard-3/",
+		99999: "http://shard-3/",
+	} {
+		assert.Equal(t, expected, conf.AggregatorEndpoint(orgID), orgID)
+	}
+
+	assert.Equal(t,
+		[]string{"http://aggregator/", "http://shard-1/", "http://shard-2/", "http://shard-3/"},
+		conf.AggregatorEndpoints(),
+	)
+}
+
+// TestAggregatorReadEndpoint checks that read operations use replicas when
+// they are configured
+func TestAggregatorReadEndpoint(t *testing.T) {
+	conf := services.Configuration{
+		AggregatorBaseEndpoint: "http://aggregator/",
+		AggregatorShards: []services.AggregatorShard{
+			{Endpoint: "http://shard-1/", ReadEndpoint: "http://shard-1-replica/", MinOrgID: 1, MaxOrgID: 100},
+			{Endpoint: "http://shard-2/", MinOrgID: 101, MaxOrgID: 200},
+		},
+	}
+
+	assert.Equal(t, "http://shard-1-replica/", conf.AggregatorReadEndpoint(1))
+	assert.Equal(t, "http://shard-1/", conf.AggregatorEndpoint(1))
+	assert.Equal(t, "http://shard-2/", conf.AggregatorReadEndpoint(150))
+	assert.Equal(t, "http://aggregator/", conf.AggregatorReadEndpoint(500))
+	assert.Equal(t, "http://aggregator/", conf.AggregatorReadEndpoint(0))
+
+	conf.AggregatorReadBaseEndpoint = "http://aggregator-replica/"
+	assert.Equal(t, "http://aggregator-replica/", conf.AggregatorReadEndpoint(500))
+	assert.Equal(t, "http://aggregator/", conf.AggregatorEndpoint(500))
+}
@@ -19,7 +19,9 @@ package services
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -87,30 +89,63 @@ func GetGroups(conf Configuration) ([]groups.Group, error) {
 
 // GetContent get the static rule content from content-service
 func GetContent(conf Configuration) (*types.RuleContentDirectory, error) {
+	receivedContent, _, err := GetContentWithVersion(conf)
+	return receivedContent, err
+}
+
+// GetContentWithVersion get the static rule content from content-service
+// together with its version. The version is a checksum computed from the
+// serialized content, so it changes only when the content itself changes.
+func GetContentWithVersion(conf Configuration) (*types.RuleContentDirectory, string, error) {
 	log.Info().Msg("getting rules static content")
-	resp, err := getFromURL(conf.ContentBaseEndpoint + ContentEndpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	receivedContent, respBytes, err := getContentFromURL(conf.ContentBaseEndpoint + ContentEndpoint)
+	if err != nil {
+		return nil, "", err
+	}
 
+	log.Info().Msgf("Got %d rules from content-service", len(receivedContent.Rules))
+
+	checksum := sha256.Sum256(respBytes)
+	return receivedContent, hex.EncodeToString(checksum[:]), nil
+}
+
+// GetLocalizedContent get the static rule content translated to given
+// language from content-service
+func GetLocalizedContent(conf Configuration, lang string) (*types.RuleContentDirectory, error) {
+	log.Info().Str("language", lang).Msg("getting localized rules static content")
+	receivedContent, _, err := getContentFromURL(conf.ContentBaseEndpoint + ContentEndpoint + "/" + url.PathEscape(lang))
 	if err != nil {
 		return nil, err
 	}
 
+	log.Info().Str("language", lang).Msgf("Got %d localized rules from content-service", len(receivedContent.Rules))
+	return receivedContent, nil
+}
+
+// getContentFromURL retrieves and decodes rule content directory. Raw
+// response body is returned as well.
+func getContentFromURL(endpoint string) (*types.RuleContentDirectory, []byte, error) {
+	resp, err := getFromURL(endpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+
+	if err != nil {
+		return nil, nil, err
+	}
+
 	defer CloseResponseBody(resp)
 
 	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var receivedContent types.RuleContentDirectory
 	err = gob.NewDecoder(bytes.NewReader(respBytes)).Decode(&receivedContent)
 	if err != nil {
 		log.Error().Err(err).Msg("error trying to decode rules content from received answer")
-		return nil, err
+		return nil, nil, err
 	}
 
-	log.Info().Msgf("Got %d rules from content-service", len(receivedContent.Rules))
-
-	return &receivedContent, nil
+	return &receivedContent, respBytes, nil
 }
 
 // CloseResponseBody is used to close the response body so that there are no
@@ -20,23 +20,29 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
-	"net/url"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/RedHatInsights/insights-content-service/groups"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/RedHatInsights/insights-operator-utils/metrics"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/audit"
 	"github.com/RedHatInsights/insights-results-smart-proxy/conf"
+	"github.com/RedHatInsights/insights-results-smart-proxy/egress"
+	"github.com/RedHatInsights/insights-results-smart-proxy/featureflags"
+	"github.com/RedHatInsights/insights-results-smart-proxy/grpcapi"
+	"github.com/RedHatInsights/insights-results-smart-proxy/preferences"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
-	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
 
 	proxy_content "github.com/RedHatInsights/insights-results-smart-proxy/content"
 )
@@ -107,20 +113,29 @@ func printEnv() ExitCode {
 
 // startService function starts service and returns error code.
 func startServer() ExitCode {
-	_ = conf.GetSetupConfiguration()
+	setupCfg := conf.GetSetupConfiguration()
 	serverCfg := conf.GetServerConfiguration()
 	metricsCfg := conf.GetMetricsConfiguration()
 	servicesCfg := conf.GetServicesConfiguration()
 	amsConfig := conf.GetAMSClientConfiguration()
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
+	groupsStore := proxy_content.NewGroupsStore()
 
 	if metricsCfg.Namespace != "" {
 		metrics.AddAPIMetricsWithNamespace(metricsCfg.Namespace)
 	}
 
-	amsClient, err := amsclient.NewAMSClient(amsConfig)
+	// connections to aggregator and content service use the default
+	// transport, AMS client gets it explicitly
+	egressTransport, err := egress.NewTransport(conf.GetEgressConfiguration())
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid egress configuration")
+		return ExitStatusServerError
+	}
+	if egressTransport != nil {
+		http.DefaultTransport = egressTransport
+	}
+
+	amsClient, err := amsclient.NewAMSClientWithTransport(amsConfig, egressTransport)
 	if err != nil {
 		log.Error().Err(err).Msg("Cannot init the AMSClient, using old approach")
 		amsClient = nil
@@ -128,14 +143,80 @@ func startServer() ExitCode {
 		log.Info().Msg("AMSClient successfully created")
 	}
 
-	serverInstance = server.New(serverCfg, servicesCfg, amsClient, groupsChannel, errorFoundChannel, errorChannel)
+	err = server.InitErrorReporting(conf.GetErrorReportingConfiguration(), BuildVersion)
+	if err != nil {
+		log.Error().Err(err).Msg("Server errors won't be reported")
+	}
+	defer server.FlushErrorReporting()
+
+	serverInstance = server.New(serverCfg, servicesCfg, amsClient, groupsStore)
+	featureFlags := featureflags.New(conf.GetFeatureFlagsConfiguration())
+	serverInstance.SetFeatureFlags(featureFlags)
+
+	auditPublisher, err := audit.New(conf.GetAuditConfiguration())
+	if err != nil {
+		log.Error().Err(err).Msg("Audit events won't be published")
+		auditPublisher = audit.NoopPublisher{}
+	}
+	defer func() {
+		if err := auditPublisher.Close(); err != nil {
+			log.Error().Err(err).Msg("Unable to close publisher of audit events")
+		}
+	}()
+	serverInstance.SetAuditPublisher(auditPublisher)
+
+	auditLogStore, err := audit.NewStore(conf.GetAuditConfiguration())
+	if err != nil {
+		log.Error().Err(err).Msg("Mutating operations won't be recorded in audit log")
+	} else {
+		serverInstance.SetAuditLogStore(auditLogStore)
+	}
+
+	sharedStorage, err := storage.New(servicesCfg.Storage)
+	if err != nil {
+		log.Error().Err(err).Msg("Shared storage won't be available")
+	} else if sharedStorage != nil {
+		defer func() {
+			if err := sharedStorage.Close(); err != nil {
+				log.Error().Err(err).Msg("Unable to close shared storage")
+			}
+		}()
+		serverInstance.SetStorage(sharedStorage)
+	}
+
+	preferencesStore, err := preferences.New(conf.GetPreferencesConfiguration(), sharedStorage)
+	if err != nil {
+		log.Error().Err(err).Msg("User preferences won't be available")
+	} else {
+		serverInstance.SetPreferencesStore(preferencesStore)
+	}
 
 	// fill-in additional info used by /info endpoint handler
 	fillInInfoParams(serverInstance.InfoParams)
 
 	proxy_content.SetContentDirectoryTimeout(servicesCfg.ContentDirectoryTimeout)
-	go updateGroupInfo(servicesCfg, groupsChannel, errorFoundChannel, errorChannel)
-	go proxy_content.RunUpdateContentLoop(servicesCfg)
+	proxy_content.SetContentCacheSize(servicesCfg.ContentCacheSize)
+	go proxy_content.RunUpdateContentLoop(servicesCfg, groupsStore)
+	go watchConfiguration(featureFlags, setupCfg.ConfigWatchInterval)
+	go serverInstance.RunWebhookScanLoop()
+	go serverInstance.RunUpstreamHealthLoop()
+	go serverInstance.RunExportWorkers()
+	go serverInstance.RunExportStorageCleanupLoop()
+	go serverInstance.RunCacheWarmUp()
+	go func() {
+		if err := serverInstance.StartDebugListener(); err != nil {
+			log.Error().Err(err).Msg("Debug listener error")
+		}
+	}()
+
+	if grpcCfg := conf.GetGRPCConfiguration(); grpcCfg.Address != "" {
+		grpcServer := grpcapi.NewServer(serverInstance.Handler(), serverCfg.APIv2Prefix)
+		go func() {
+			if err := grpcapi.Serve(grpcCfg, grpcServer); err != nil {
+				log.Error().Err(err).Msg("gRPC server error")
+			}
+		}()
+	}
 
 	err = serverInstance.Start()
 	if err != nil {
@@ -146,70 +227,78 @@ func startServer() ExitCode {
 	return ExitStatusOK
 }
 
-// fillInInfoParams function fills-in additional info used by /info endpoint
-// handler
-func fillInInfoParams(params map[string]string) {
-	params["BuildVersion"] = BuildVersion
-	params["BuildTime"] = BuildTime
-	params["BuildBranch"] = BuildBranch
-	params["BuildCommit"] = BuildCommit
-	params["UtilsVersion"] = UtilsVersion
-}
-
-// updateGroupInfo function is run in a goroutine. It runs forever, waiting for 1 of 2 events: a Ticker or a channel
-// * If ticker comes first, the groups configuration is updated, doing a request to the content-service
-// * If the channel comes first, the latest valid groups configuration is send through the channel
-func updateGroupInfo(servicesConf services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error) {
-	var currentGroups []groups.Group
-	var currentError error
-	var currentErrorFound bool
-	retrievedGroups, err := services.GetGroups(servicesConf)
-
-	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving groups")
-		currentErrorFound = true
-		var e *url.Error
-		if errors.As(err, &e) {
-			currentError = &server.ContentServiceUnavailableError{}
-		} else {
-			currentError = err
-		}
-	} else {
-		currentErrorFound = false
-		currentGroups = retrievedGroups
+// watchConfiguration reloads the configuration when SIGHUP signal is received
+// or, if the interval is positive, when modification of the configuration
+// file is detected
+func watchConfiguration(featureFlags featureflags.Provider, interval time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
 	}
-
-	uptimeTicker := time.NewTicker(servicesConf.GroupsPollingTime)
-	log.Info().Msgf("Updating groups configuration each %f seconds", servicesConf.GroupsPollingTime.Seconds())
+	modified := configFileModTime()
 
 	for {
 		select {
-		case <-uptimeTicker.C:
-			retrievedGroups, err = services.GetGroups(servicesConf)
-			currentGroups, currentErrorFound, currentError = handleGroupError(err, currentGroups, retrievedGroups)
-		case errorFoundChannel <- currentErrorFound:
-		case errorChannel <- currentError:
-		case groupsChannel <- currentGroups:
+		case <-signals:
+			log.Info().Msg("SIGHUP received, reloading configuration")
+		case <-ticks:
+			current := configFileModTime()
+			if !current.After(modified) {
+				continue
+			}
+			modified = current
+			log.Info().Msg("Configuration file modified, reloading configuration")
 		}
+		reloadConfiguration(featureFlags)
 	}
 }
 
-// handleGroupError handles error after retrieving groups info in updateGroupInfo
-func handleGroupError(err error,
-	currentGroups []groups.Group,
-	newGroups []groups.Group) ([]groups.Group, bool, error) {
+// configFileModTime returns modification time of the configuration file,
+// zero time is returned when the file can't be read
+func configFileModTime() time.Time {
+	info, err := os.Stat(conf.ConfigFileUsed())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadConfiguration re-reads the configuration and applies the settings
+// that can be changed without restart: log level, feature flags, URLs of
+// upstream services and limits of requests
+func reloadConfiguration(featureFlags featureflags.Provider) {
+	err := conf.LoadConfiguration(defaultConfigFileName)
 	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving groups")
-		var e *url.Error
-		if errors.As(err, &e) {
-			return currentGroups, true, &server.ContentServiceUnavailableError{}
+		log.Error().Err(err).Msg("Unable to reload configuration, the current one is kept")
+		return
+	}
+
+	if logLevel := conf.GetLoggingConfiguration().LogLevel; logLevel != "" {
+		level, err := zerolog.ParseLevel(strings.ToLower(logLevel))
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid log level, the current one is kept")
+		} else {
+			zerolog.SetGlobalLevel(level)
 		}
-		return currentGroups, true, err
 	}
-	return newGroups, false, nil
+
+	featureflags.Reconfigure(featureFlags, conf.GetFeatureFlagsConfiguration())
+	serverInstance.Reload(conf.GetServerConfiguration(), conf.GetServicesConfiguration())
+}
+
+// fillInInfoParams function fills-in additional info used by /info endpoint
+// handler
+func fillInInfoParams(params map[string]string) {
+	params["BuildVersion"] = BuildVersion
+	params["BuildTime"] = BuildTime
+	params["BuildBranch"] = BuildBranch
+	params["BuildCommit"] = BuildCommit
+	params["UtilsVersion"] = UtilsVersion
 }
 
 // handleCommand select the function to be called depending on command argument
@@ -256,6 +345,8 @@ func main() {
 		panic(err)
 	}
 
+	server.SetLogSampling(conf.GetLogSamplingConfiguration())
+
 	var (
 		showHelp    bool
 		showVersion bool
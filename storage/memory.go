@@ -0,0 +1,105 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryValue is a single value stored in the memory store
+type memoryValue struct {
+	data    []byte
+	expires time.Time
+}
+
+// expired returns true if the value has limited lifetime which has ended
+func (value *memoryValue) expired(now time.Time) bool {
+	return !value.expires.IsZero() && !now.Before(value.expires)
+}
+
+// MemoryStore is an in-memory implementation of Store interface. Data are
+// not shared with other replicas and are lost on restart.
+type MemoryStore struct {
+	mutex  sync.RWMutex
+	values map[string]memoryValue
+}
+
+// NewMemoryStore constructs new empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values: make(map[string]memoryValue),
+	}
+}
+
+// Get returns the value stored under the key
+func (store *MemoryStore) Get(key string) ([]byte, bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	value, found := store.values[key]
+	if !found || value.expired(time.Now()) {
+		return nil, false, nil
+	}
+	return value.data, true, nil
+}
+
+// Set stores the copy of the value under the key
+func (store *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stored := memoryValue{data: append([]byte(nil), value...)}
+	if ttl > 0 {
+		stored.expires = time.Now().Add(ttl)
+	}
+	store.values[key] = stored
+	return nil
+}
+
+// Delete removes the value stored under the key
+func (store *MemoryStore) Delete(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.values, key)
+	return nil
+}
+
+// List returns all values with keys starting with the prefix. Expired
+// values are removed.
+func (store *MemoryStore) List(prefix string) (map[string][]byte, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	values := make(map[string][]byte)
+	for key, value := range store.values {
+		if value.expired(now) {
+			delete(store.values, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			values[key] = value.data
+		}
+	}
+	return values, nil
+}
+
+// Close does nothing, the store does not hold any connections
+func (store *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	redisPoolHits = prometheus.NewDesc(
+		"storage_redis_pool_hits_total",
+		"The total number of times free connection to Redis was found in the pool", nil, nil,
+	)
+	redisPoolMisses = prometheus.NewDesc(
+		"storage_redis_pool_misses_total",
+		"The total number of times free connection to Redis was not found in the pool", nil, nil,
+	)
+	redisPoolTimeouts = prometheus.NewDesc(
+		"storage_redis_pool_timeouts_total",
+		"The total number of times waiting for connection to Redis timed out", nil, nil,
+	)
+	redisPoolConnections = prometheus.NewDesc(
+		"storage_redis_pool_connections",
+		"Number of connections to Redis in the pool by their state", []string{"state"}, nil,
+	)
+)
+
+// redisPoolCollector exports the statistics of the connection pool of Redis
+// client. The statistics are read when the metrics are scraped.
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+// Describe sends the descriptors of all metrics of the pool
+func (collector *redisPoolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- redisPoolHits
+	descs <- redisPoolMisses
+	descs <- redisPoolTimeouts
+	descs <- redisPoolConnections
+}
+
+// Collect sends the current statistics of the pool
+func (collector *redisPoolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats := collector.client.PoolStats()
+
+	metrics <- prometheus.MustNewConstMetric(redisPoolHits, prometheus.CounterValue, float64(stats.Hits))
+	metrics <- prometheus.MustNewConstMetric(redisPoolMisses, prometheus.CounterValue, float64(stats.Misses))
+	metrics <- prometheus.MustNewConstMetric(redisPoolTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	metrics <- prometheus.MustNewConstMetric(
+		redisPoolConnections, prometheus.GaugeValue, float64(stats.TotalConns), "total",
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		redisPoolConnections, prometheus.GaugeValue, float64(stats.IdleConns), "idle",
+	)
+	metrics <- prometheus.MustNewConstMetric(
+		redisPoolConnections, prometheus.GaugeValue, float64(stats.StaleConns), "stale",
+	)
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// PostgreSQL driver registers itself to database/sql
+	_ "github.com/lib/pq"
+)
+
+// postgresTable is the table holding all stored values
+const postgresTable = "smart_proxy_storage"
+
+// SQL statements used by the store
+const (
+	postgresCreateTable = `
+		CREATE TABLE IF NOT EXISTS ` + postgresTable + ` (
+			key        VARCHAR NOT NULL PRIMARY KEY,
+			value      BYTEA NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE
+		)`
+	postgresGet = `
+		SELECT value FROM ` + postgresTable + `
+		 WHERE key = $1 AND (expires_at IS NULL OR expires_at > $2)`
+	postgresSet = `
+		INSERT INTO ` + postgresTable + ` (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at`
+	postgresDeleteExpired = `DELETE FROM ` + postgresTable + ` WHERE expires_at <= $1`
+	postgresDelete        = `DELETE FROM ` + postgresTable + ` WHERE key = $1`
+	postgresList          = `
+		SELECT key, value FROM ` + postgresTable + `
+		 WHERE key LIKE $1 ESCAPE '\' AND (expires_at IS NULL OR expires_at > $2)`
+)
+
+// PostgresStore is the implementation of Store interface keeping the data
+// in single PostgreSQL table, the table is created when it does not exist.
+// Expired rows are deleted when values with limited lifetime are stored.
+type PostgresStore struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewPostgresStore connects to the database and creates the table
+func NewPostgresStore(config Configuration) (*PostgresStore, error) {
+	if config.DSN == "" {
+		return nil, fmt.Errorf("PostgreSQL connection string is not configured")
+	}
+
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	store := &PostgresStore{
+		db:      db,
+		timeout: config.timeout(),
+	}
+
+	ctx, cancel := store.context()
+	defer cancel()
+	if _, err := db.ExecContext(ctx, postgresCreateTable); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// context returns the context limiting the time of single operation
+func (store *PostgresStore) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.timeout)
+}
+
+// Get returns the value stored under the key
+func (store *PostgresStore) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	var value []byte
+	err := store.db.QueryRowContext(ctx, postgresGet, key, time.Now().UTC()).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value under the key
+func (store *PostgresStore) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	now := time.Now().UTC()
+	var expiresAt sql.NullTime
+	if ttl > 0 {
+		expiresAt = sql.NullTime{Time: now.Add(ttl), Valid: true}
+		if _, err := store.db.ExecContext(ctx, postgresDeleteExpired, now); err != nil {
+			return err
+		}
+	}
+
+	_, err := store.db.ExecContext(ctx, postgresSet, key, value, expiresAt)
+	return err
+}
+
+// Delete removes the value stored under the key
+func (store *PostgresStore) Delete(key string) error {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	_, err := store.db.ExecContext(ctx, postgresDelete, key)
+	return err
+}
+
+// List returns all values with keys starting with the prefix
+func (store *PostgresStore) List(prefix string) (map[string][]byte, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	rows, err := store.db.QueryContext(ctx, postgresList, escapeLikePattern(prefix)+"%", time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	values := make(map[string][]byte)
+	for rows.Next() {
+		var (
+			key   string
+			value []byte
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, rows.Err()
+}
+
+// Close closes the connections to the database
+func (store *PostgresStore) Close() error {
+	return store.db.Close()
+}
+
+// escapeLikePattern escapes characters having special meaning in patterns
+// of LIKE operator
+func escapeLikePattern(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+}
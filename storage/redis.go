@@ -0,0 +1,184 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// redisScanCount is the number of keys requested by single SCAN command
+const redisScanCount = 100
+
+// RedisStore is the implementation of Store interface keeping the data in
+// Redis. It implements HashStore interface too. The client keeps a
+// pool of connections, they are opened on demand and reopened after network
+// errors.
+type RedisStore struct {
+	client  *redis.Client
+	timeout time.Duration
+	metrics *redisPoolCollector
+}
+
+// NewRedisStore constructs the store, no connection is opened yet. The
+// statistics of the connection pool are exported as Prometheus metrics.
+func NewRedisStore(config Configuration) *RedisStore {
+	client := redis.NewClient(&redis.Options{
+		Addr:         config.Address,
+		Password:     config.Password,
+		DB:           config.Database,
+		PoolSize:     config.PoolSize,
+		MinIdleConns: config.MinIdleConns,
+		PoolTimeout:  config.PoolTimeout,
+	})
+
+	metrics := &redisPoolCollector{client: client}
+	if err := prometheus.Register(metrics); err != nil {
+		log.Warn().Err(err).Msg("Unable to register metrics of Redis connection pool")
+		metrics = nil
+	}
+
+	return &RedisStore{
+		client:  client,
+		timeout: config.timeout(),
+		metrics: metrics,
+	}
+}
+
+// Client returns the Redis client of the store, so caches can share its
+// connection pool
+func (store *RedisStore) Client() *redis.Client {
+	return store.client
+}
+
+// context returns the context limiting the time of single operation
+func (store *RedisStore) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.timeout)
+}
+
+// Get returns the value stored under the key
+func (store *RedisStore) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	value, err := store.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value under the key, zero TTL means no expiration
+func (store *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	return store.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete removes the value stored under the key
+func (store *RedisStore) Delete(key string) error {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	return store.client.Del(ctx, key).Err()
+}
+
+// List returns all values with keys starting with the prefix
+func (store *RedisStore) List(prefix string) (map[string][]byte, error) {
+	keys, err := store.Keys(escapeRedisPattern(prefix) + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	ctx, cancel := store.context()
+	defer cancel()
+
+	items, err := store.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		// the value may expire between SCAN and MGET
+		if value, ok := item.(string); ok {
+			values[keys[i]] = []byte(value)
+		}
+	}
+	return values, nil
+}
+
+// Keys returns all keys matching the pattern. The keys are found by SCAN, so
+// the listing does not block the server.
+func (store *RedisStore) Keys(pattern string) ([]string, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	var keys []string
+	iterator := store.client.Scan(ctx, 0, pattern, redisScanCount).Iterator()
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Val())
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetHash returns all fields of the hash stored under the key
+func (store *RedisStore) GetHash(key string) (map[string]string, bool, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	fields, err := store.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	// missing hash is reported as empty one
+	return fields, len(fields) > 0, nil
+}
+
+// Close closes all connections to Redis server
+func (store *RedisStore) Close() error {
+	if store.metrics != nil {
+		prometheus.Unregister(store.metrics)
+	}
+	return store.client.Close()
+}
+
+// escapeRedisPattern escapes characters having special meaning in patterns
+// of SCAN command
+func escapeRedisPattern(prefix string) string {
+	var escaped strings.Builder
+	for _, char := range prefix {
+		if strings.ContainsRune(`*?[]\`, char) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
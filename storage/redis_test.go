@@ -0,0 +1,115 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// TestRedisStorePoolMetrics checks that the statistics of connection pool
+// are exported while the store is open
+func TestRedisStorePoolMetrics(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := storage.NewRedisStore(storage.Configuration{
+		Address:      server.Addr(),
+		PoolSize:     2,
+		MinIdleConns: 1,
+	})
+
+	count, err := testutil.GatherAndCount(prometheus.DefaultGatherer,
+		"storage_redis_pool_hits_total", "storage_redis_pool_connections")
+	helpers.FailOnError(t, err)
+	// hits and connections in three states
+	assert.Equal(t, 4, count)
+
+	helpers.FailOnError(t, store.Close())
+	count, err = testutil.GatherAndCount(prometheus.DefaultGatherer, "storage_redis_pool_connections")
+	helpers.FailOnError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+
+	checkStore(t, storage.NewRedisStore(storage.Configuration{
+		Address:  server.Addr(),
+		Password: "secret",
+		Database: 2,
+	}))
+
+	server.Select(2)
+	value, err := server.Get("preferences/2")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, `{"b":2}`, value)
+	assert.Equal(t, time.Hour, server.TTL("preferences/2"))
+}
+
+func TestRedisStoreErrors(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+
+	store := storage.NewRedisStore(storage.Configuration{
+		Address:  server.Addr(),
+		Password: "wrong",
+	})
+	_, _, err := store.GetHash("key")
+	assert.Error(t, err)
+	assert.NoError(t, store.Close())
+
+	// unreachable server
+	store = storage.NewRedisStore(storage.Configuration{Address: "127.0.0.1:1", Timeout: time.Second})
+	_, err = store.Keys("*")
+	assert.Error(t, err)
+	assert.NoError(t, store.Close())
+}
+
+// TestRedisStoreHashes checks that hashes written by other services can be
+// found and read
+func TestRedisStoreHashes(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.HSet("organization:1:cluster:c1:request:r1", "received_timestamp", "2023-01-01T00:00:00Z")
+	server.HSet("organization:1:cluster:c2:request:r2", "received_timestamp", "2023-01-02T00:00:00Z")
+
+	store := storage.NewRedisStore(storage.Configuration{Address: server.Addr()})
+	defer store.Close()
+
+	keys, err := store.Keys("organization:1:cluster:c1:request:*")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []string{"organization:1:cluster:c1:request:r1"}, keys)
+
+	fields, found, err := store.GetHash("organization:1:cluster:c1:request:r1")
+	helpers.FailOnError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"received_timestamp": "2023-01-01T00:00:00Z"}, fields)
+
+	_, found, err = store.GetHash("organization:1:cluster:c1:request:r2")
+	helpers.FailOnError(t, err)
+	assert.False(t, found)
+}
+
+func TestEscapeRedisPattern(t *testing.T) {
+	assert.Equal(t, `webhooks/1/`, storage.EscapeRedisPattern(`webhooks/1/`))
+	assert.Equal(t, `a\*b\?c\[d\]`, storage.EscapeRedisPattern(`a*b?c[d]`))
+}
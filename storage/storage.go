@@ -0,0 +1,135 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage contains the interface of the storage of data owned by
+// Smart Proxy itself (user preferences, export jobs, webhooks) and its
+// implementations. Unlike caches, the storage is shared by all replicas of
+// the service.
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Supported types of storages
+const (
+	// StoreNone disables the storage
+	StoreNone = ""
+	// StoreMemory keeps the data in memory of the replica, it is suitable
+	// for single replica and tests only
+	StoreMemory = "memory"
+	// StoreRedis keeps the data in Redis
+	StoreRedis = "redis"
+	// StorePostgres keeps the data in PostgreSQL table
+	StorePostgres = "postgres"
+)
+
+// defaultTimeout is used when the timeout of storage operations is not
+// configured
+const defaultTimeout = 5 * time.Second
+
+// Store is a key-value storage shared by all replicas. Values are opaque
+// byte slices, callers usually store JSON documents. Keys are slash
+// separated paths, so all values of one kind can be listed by the prefix.
+// All implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under the key. False is returned when
+	// the value does not exist or has expired.
+	Get(key string) ([]byte, bool, error)
+	// Set stores the value under the key, zero TTL means no expiration
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes the value stored under the key, it is not an error
+	// when the value does not exist
+	Delete(key string) error
+	// List returns all values with keys starting with the prefix, keyed by
+	// the full keys
+	List(prefix string) (map[string][]byte, error)
+	// Close releases the connections to the storage
+	Close() error
+}
+
+// HashStore is implemented by storages that can read hashes written by
+// other services, e.g. the status of archives processed by the external data
+// pipeline
+type HashStore interface {
+	// Keys returns all keys matching the glob-style pattern
+	Keys(pattern string) ([]string, error)
+	// GetHash returns all fields of the hash stored under the key. False is
+	// returned when the hash does not exist.
+	GetHash(key string) (map[string]string, bool, error)
+}
+
+// Configuration represents configuration of the storage
+type Configuration struct {
+	// Type is the type of the storage, the storage is disabled when it is
+	// empty
+	Type string `mapstructure:"type" toml:"type"`
+	// Address is host:port of Redis server
+	Address string `mapstructure:"address" toml:"address"`
+	// Password is used to authenticate to Redis server, no authentication
+	// is made when it is empty
+	Password string `mapstructure:"password" toml:"password"`
+	// Database is the number of Redis database
+	Database int `mapstructure:"database" toml:"database"`
+	// PoolSize is the maximal number of connections to Redis server, the
+	// default of Redis client is used when it is zero
+	PoolSize int `mapstructure:"pool_size" toml:"pool_size"`
+	// MinIdleConns is the number of idle connections to Redis server kept
+	// open
+	MinIdleConns int `mapstructure:"min_idle_conns" toml:"min_idle_conns"`
+	// PoolTimeout limits the time of waiting for free connection to Redis
+	// server when all connections are busy
+	PoolTimeout time.Duration `mapstructure:"pool_timeout" toml:"pool_timeout"`
+	// DSN is the PostgreSQL connection string
+	DSN string `mapstructure:"dsn" toml:"dsn"`
+	// Timeout limits the time of single operation
+	Timeout time.Duration `mapstructure:"timeout" toml:"timeout"`
+}
+
+// timeout returns the configured timeout of single operation or the default
+// one
+func (config *Configuration) timeout() time.Duration {
+	if config.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return config.Timeout
+}
+
+// New constructs the storage according to the configuration. Nil is
+// returned when the storage is disabled.
+func New(config Configuration) (Store, error) {
+	switch config.Type {
+	case StoreNone:
+		log.Info().Msg("Shared storage is disabled")
+		return nil, nil
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreRedis:
+		if config.Address == "" {
+			return nil, fmt.Errorf("address of Redis server is not configured")
+		}
+		return NewRedisStore(config), nil
+	case StorePostgres:
+		store, err := NewPostgresStore(config)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", config.Type)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/storage"
+)
+
+func TestNew(t *testing.T) {
+	store, err := storage.New(storage.Configuration{})
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = storage.New(storage.Configuration{Type: storage.StoreMemory})
+	assert.NoError(t, err)
+	assert.IsType(t, &storage.MemoryStore{}, store)
+
+	// Redis store connects lazily
+	store, err = storage.New(storage.Configuration{Type: storage.StoreRedis, Address: "localhost:6379"})
+	assert.NoError(t, err)
+	assert.IsType(t, &storage.RedisStore{}, store)
+	assert.NoError(t, store.Close())
+
+	for _, config := range []storage.Configuration{
+		{Type: "mongo"},
+		{Type: storage.StoreRedis},
+		{Type: storage.StorePostgres},
+	} {
+		_, err = storage.New(config)
+		assert.Error(t, err, config.Type)
+	}
+}
+
+// checkStore runs the checks shared by all store implementations
+func checkStore(t *testing.T, store storage.Store) {
+	_, found, err := store.Get("preferences/1")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, store.Set("preferences/1", []byte(`{"a":1}`), 0))
+	assert.NoError(t, store.Set("preferences/2", []byte(`{"b":2}`), time.Hour))
+	assert.NoError(t, store.Set("webhooks/1/x", []byte(`{}`), 0))
+
+	value, found, err := store.Get("preferences/1")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte(`{"a":1}`), value)
+
+	values, err := store.List("preferences/")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"preferences/1": []byte(`{"a":1}`),
+		"preferences/2": []byte(`{"b":2}`),
+	}, values)
+
+	values, err = store.List("exports/")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	assert.NoError(t, store.Delete("preferences/1"))
+	_, found, err = store.Get("preferences/1")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// deleting missing value is no-op
+	assert.NoError(t, store.Delete("preferences/1"))
+	assert.NoError(t, store.Close())
+}
+
+func TestMemoryStore(t *testing.T) {
+	checkStore(t, storage.NewMemoryStore())
+}
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	store := storage.NewMemoryStore()
+	assert.NoError(t, store.Set("key", []byte("value"), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	_, found, err := store.Get("key")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	values, err := store.List("")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	assert.Equal(t, `exports/`, storage.EscapeLikePattern(`exports/`))
+	assert.Equal(t, `a\%b\_c\\d`, storage.EscapeLikePattern(`a%b_c\d`))
+}
@@ -17,6 +17,7 @@ package helpers
 import (
 	"fmt"
 
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
 	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
@@ -73,6 +74,66 @@ func (m *mockAMSClient) GetSingleClusterInfoForOrganization(
 	return
 }
 
+// GetClusterDetailsFromExternalID method returns cluster info and ID of
+// organization the cluster belongs to if given ID is found in any of the
+// cluster lists
+func (m *mockAMSClient) GetClusterDetailsFromExternalID(
+	id types.ClusterName,
+) (
+	clusterInfo types.ClusterInfo, orgID types.OrgID, err error,
+) {
+
+	for orgID, clusters := range m.clustersPerOrg {
+		for _, info := range clusters {
+			if info.ID == id {
+				return info, orgID, nil
+			}
+		}
+	}
+	return clusterInfo, orgID, &utypes.ItemNotFoundError{ItemID: id}
+}
+
+// FindClustersForOrganization method returns the clusters of the
+// organization with given display name, the mock doesn't know subscription
+// IDs
+func (m *mockAMSClient) FindClustersForOrganization(
+	orgID types.OrgID, reference string,
+) (
+	clusterInfoList []types.ClusterInfo, err error,
+) {
+
+	for _, info := range m.clustersPerOrg[orgID] {
+		if info.DisplayName == reference {
+			clusterInfoList = append(clusterInfoList, info)
+		}
+	}
+	return
+}
+
+// GetClustersInfoForOrganization method returns the clusters of the
+// organization with any of the given IDs
+func (m *mockAMSClient) GetClustersInfoForOrganization(
+	orgID types.OrgID, clusterIDs []types.ClusterName,
+) (
+	clusterInfoList []types.ClusterInfo, err error,
+) {
+
+	for _, info := range m.clustersPerOrg[orgID] {
+		for _, clusterID := range clusterIDs {
+			if info.ID == clusterID {
+				clusterInfoList = append(clusterInfoList, info)
+				break
+			}
+		}
+	}
+	return
+}
+
+// SendServiceLog method accepts every service log entry
+func (m *mockAMSClient) SendServiceLog(types.ServiceLogEntry) error {
+	return nil
+}
+
 // AMSClientWithOrgResults creates a mock of AMSClient interface that returns the results
 // defined by orgID and clusters parameters
 func AMSClientWithOrgResults(orgID types.OrgID, clusters []types.ClusterInfo) amsclient.AMSClient {
@@ -15,14 +15,18 @@
 package helpers
 
 import (
+	"bytes"
+	"io"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
 	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 
-	"github.com/RedHatInsights/insights-content-service/groups"
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	"github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"gopkg.in/h2non/gock.v1"
 
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
@@ -50,10 +54,6 @@ var (
 	// endpoint for gock
 	NewGockAPIEndpointMatcher = helpers.NewGockAPIEndpointMatcher
 
-	// GockExpectAPIRequest function makes gock expect the request with the
-	// baseURL and sends back the response
-	GockExpectAPIRequest = helpers.GockExpectAPIRequest
-
 	// CleanAfterGock function cleans after gock library and prints all
 	// unmatched requests
 	CleanAfterGock = helpers.CleanAfterGock
@@ -95,6 +95,10 @@ var (
 		AuthType:      "jwt",
 		UseHTTPS:      false,
 		EnableCORS:    true,
+		CORS: server.CORSConfiguration{
+			AllowedOrigins:   []string{"http://example.com"},
+			AllowCredentials: true,
+		},
 	}
 
 	// DefaultServerConfigAuth is data structure that represents default
@@ -124,15 +128,13 @@ var (
 
 // AssertAPIRequest function creates new server with provided
 // serverConfig, servicesConfig (you can leave them nil to use the default ones),
-// groupsChannel and errorChannel (can be set to nil as well)
+// groupsStore (can be set to nil as well)
 // sends api request and checks api response (see docs for APIRequest and APIResponse)
 func AssertAPIRequest(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *content.GroupsStore,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
 ) {
@@ -145,9 +147,7 @@ func AssertAPIRequest(
 		t,
 		serverConfig,
 		servicesConfig,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 		serverConfig.APIv1Prefix,
 		request,
 		expectedResponse,
@@ -160,9 +160,7 @@ func AssertAPIv2Request(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *content.GroupsStore,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
 ) {
@@ -175,9 +173,7 @@ func AssertAPIv2Request(
 		t,
 		serverConfig,
 		servicesConfig,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 		serverConfig.APIv2Prefix,
 		request,
 		expectedResponse,
@@ -188,9 +184,7 @@ func assertAPIRequest(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *content.GroupsStore,
 	APIPrefix string,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
@@ -201,9 +195,7 @@ func assertAPIRequest(
 		serverConfig,
 		servicesConfig,
 		nil,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 	)
 
 	// send the request to newly created REST API server and check its
@@ -217,9 +209,7 @@ func CreateHTTPServer(
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
 	amsClient amsclient.AMSClient,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *content.GroupsStore,
 ) *server.HTTPServer {
 	// if custom server configuration is not provided, use default one
 	if serverConfig == nil {
@@ -239,8 +229,60 @@ func CreateHTTPServer(
 		*serverConfig,
 		*servicesConfig,
 		amsClient,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 	)
 }
+
+// bodyBytes returns the body of API request or response as slice of bytes
+func bodyBytes(t testing.TB, body interface{}) []byte {
+	switch body := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return body
+	case string:
+		return []byte(body)
+	case io.Reader:
+		data, err := io.ReadAll(body)
+		helpers.FailOnError(t, err)
+		return data
+	default:
+		t.Fatalf("type %T of API request or response body is not supported", body)
+		return nil
+	}
+}
+
+// GockExpectAPIRequest function makes gock expect the request with the
+// baseURL and sends back the response. Unlike the function from
+// insights-operator-utils, requests with other method or URL are just not
+// matched instead of failing the test, so the expected requests can be sent
+// concurrently in any order. Requests that are not expected at all are
+// reported by CleanAfterGock.
+func GockExpectAPIRequest(t testing.TB, baseURL string, request *APIRequest, response *APIResponse) {
+	url := httputils.MakeURLToEndpoint(baseURL, request.Endpoint, request.EndpointArgs...)
+	expectedBody := bodyBytes(t, request.Body)
+
+	headers := map[string]string{}
+	for key, values := range request.ExtraHeaders {
+		for _, value := range values {
+			headers[key] = value
+		}
+	}
+
+	gock.New(baseURL).
+		AddMatcher(func(httpRequest *http.Request, _ *gock.Request) (bool, error) {
+			if httpRequest.Method != request.Method || httpRequest.URL.String() != url {
+				return false, nil
+			}
+			if request.Body != nil {
+				body, err := io.ReadAll(httpRequest.Body)
+				helpers.FailOnError(t, err)
+				helpers.AssertStringsAreEqualJSON(t, string(expectedBody), string(body))
+			}
+			return true, nil
+		}).
+		MatchHeaders(headers).
+		Reply(response.StatusCode).
+		SetHeaders(response.Headers).
+		Body(bytes.NewBuffer(bodyBytes(t, response.Body)))
+}
@@ -37,6 +37,11 @@ const (
 
 	// ActiveStatus default status for testing AMS clusters
 	ActiveStatus = "Active"
+
+	// ClusterVersion1 represents the OpenShift version of ClusterName1
+	ClusterVersion1 = "4.12.5"
+	// ClusterLastSeen1 represents the last telemetry date of ClusterName1
+	ClusterLastSeen1 = "2023-03-01T10:00:00Z"
 )
 
 var (
@@ -86,6 +91,10 @@ var (
 				"id":                  "1YfQ9bR7LTDz24YzfFmaCdeB0sS",
 				"managed":             true,
 				"status":              ActiveStatus,
+				"last_telemetry_date": ClusterLastSeen1,
+				"metrics": []map[string]interface{}{
+					{"openshift_version": ClusterVersion1},
+				},
 			},
 			{
 				"display_name":        ClusterDisplayName2,
@@ -169,6 +178,8 @@ var (
 			DisplayName: ClusterDisplayName1,
 			Managed:     true,
 			Status:      ActiveStatus,
+			Version:     ClusterVersion1,
+			LastSeen:    ClusterLastSeen1,
 		},
 		{
 			ID:          ClusterName2,
@@ -0,0 +1,48 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// ComparedCluster identifies cluster taking part in the comparison
+type ComparedCluster struct {
+	ClusterID   ClusterName `json:"cluster_id"`
+	DisplayName string      `json:"display_name"`
+	Managed     bool        `json:"managed"`
+	HitCount    int         `json:"hit_count"`
+}
+
+// ComparedRule is the rule hitting at least one of compared clusters
+// together with its content metadata
+type ComparedRule struct {
+	RuleID      RuleID        `json:"rule_id"`
+	Description string        `json:"description"`
+	TotalRisk   int           `json:"total_risk"`
+	Impact      int           `json:"impact"`
+	Likelihood  int           `json:"likelihood"`
+	PublishDate time.Time     `json:"publish_date"`
+	Tags        []string      `json:"tags"`
+	Clusters    []ClusterName `json:"clusters"`
+}
+
+// ClusterComparison is the difference of rule hits between clusters. Rules
+// hitting all clusters are shared, rules hitting just one cluster are unique
+// for that cluster and the remaining ones hit some of the clusters only.
+type ClusterComparison struct {
+	Clusters    []ComparedCluster              `json:"clusters"`
+	SharedHits  []ComparedRule                 `json:"shared_hits"`
+	UniqueHits  map[ClusterName][]ComparedRule `json:"unique_hits"`
+	PartialHits []ComparedRule                 `json:"partial_hits"`
+}
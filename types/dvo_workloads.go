@@ -0,0 +1,101 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// DVOCluster identifies cluster in DVO workload recommendations
+type DVOCluster struct {
+	UUID        ClusterName `json:"uuid"`
+	DisplayName string      `json:"display_name"`
+}
+
+// DVONamespace identifies namespace in DVO workload recommendations
+type DVONamespace struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// DVOMetadata contains summary of DVO recommendations for a namespace
+type DVOMetadata struct {
+	Recommendations int       `json:"recommendations"`
+	Objects         int       `json:"objects"`
+	ReportedAt      Timestamp `json:"reported_at"`
+	LastCheckedAt   Timestamp `json:"last_checked_at"`
+	HighestSeverity int       `json:"highest_severity"`
+}
+
+// DVOWorkloadsForNamespace is a single namespace with DVO recommendations as
+// returned by the aggregator
+type DVOWorkloadsForNamespace struct {
+	Cluster   DVOCluster   `json:"cluster"`
+	Namespace DVONamespace `json:"namespace"`
+	Metadata  DVOMetadata  `json:"metadata"`
+}
+
+// DVOObject is a single workload (Kubernetes object) hit by DVO recommendation
+type DVOObject struct {
+	Kind string `json:"kind"`
+	UID  string `json:"uid"`
+}
+
+// DVORecommendation is a single DVO recommendation hitting workloads in
+// namespace as returned by the aggregator. Check is the rule ID in
+// "rule.module|ERROR_KEY" format.
+type DVORecommendation struct {
+	Check        string      `json:"check"`
+	Details      string      `json:"details"`
+	Resolution   string      `json:"resolution"`
+	Modified     string      `json:"modified"`
+	MoreInfo     string      `json:"more_info"`
+	TemplateData interface{} `json:"extra_data"`
+	Objects      []DVOObject `json:"objects"`
+}
+
+// DVOWorkloadsForCluster is the DVO report for single namespace in cluster as
+// returned by the aggregator
+type DVOWorkloadsForCluster struct {
+	Cluster         DVOCluster          `json:"cluster"`
+	Namespace       DVONamespace        `json:"namespace"`
+	Metadata        DVOMetadata         `json:"metadata"`
+	Recommendations []DVORecommendation `json:"recommendations"`
+}
+
+// DVOWorkload is a single workload in namespace together with number of DVO
+// recommendations hitting it
+type DVOWorkload struct {
+	DVOObject
+	Recommendations int `json:"recommendations"`
+}
+
+// DVORecommendationWithContent is DVO recommendation enriched by rule content
+type DVORecommendationWithContent struct {
+	Check       string      `json:"check"`
+	Description string      `json:"description"`
+	Details     string      `json:"details"`
+	Reason      string      `json:"reason"`
+	Resolution  string      `json:"resolution"`
+	MoreInfo    string      `json:"more_info"`
+	TotalRisk   int         `json:"total_risk"`
+	Modified    string      `json:"modified"`
+	Objects     []DVOObject `json:"objects,omitempty"`
+}
+
+// DVOWorkloadDetail is a single workload with all DVO recommendations hitting
+// it, including their content
+type DVOWorkloadDetail struct {
+	Cluster         DVOCluster                     `json:"cluster"`
+	Namespace       DVONamespace                   `json:"namespace"`
+	Workload        DVOObject                      `json:"workload"`
+	Recommendations []DVORecommendationWithContent `json:"recommendations"`
+}
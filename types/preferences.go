@@ -0,0 +1,38 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// RulePreferences contains settings of the user for single rule. Zero value
+// means the rule is displayed and notified about as usual.
+type RulePreferences struct {
+	// Hidden rules are not displayed by the UI
+	Hidden bool `json:"hidden"`
+	// MuteNotifications disables notifications about new hits of the rule
+	MuteNotifications bool `json:"mute_notifications"`
+}
+
+// UserPreferences contains the Advisor settings of the user. Zero value
+// represents the default settings.
+type UserPreferences struct {
+	// SuppressLowRisk hides rules with low total risk
+	SuppressLowRisk bool `json:"suppress_low_risk"`
+	// DefaultFilters are filters applied by the UI when the user opens
+	// the list of recommendations or clusters, for example
+	// {"total_risk": "3,4"}
+	DefaultFilters map[string]string `json:"default_filters,omitempty"`
+	// Rules contains the settings for rules by rule ID with error key
+	// (rule.module|ERROR_KEY)
+	Rules map[RuleID]RulePreferences `json:"rules,omitempty"`
+}
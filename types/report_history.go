@@ -0,0 +1,24 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// ReportHistoryDay is the number of rules hitting the cluster on single day.
+// Hits are counted also by total risk of the rules.
+type ReportHistoryDay struct {
+	Date            string         `json:"date"`
+	HitCount        int            `json:"hit_count"`
+	HitsByTotalRisk map[string]int `json:"hits_by_total_risk"`
+	ReportFound     bool           `json:"report_found"`
+}
@@ -0,0 +1,40 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	types "github.com/RedHatInsights/insights-results-types"
+)
+
+// RuleVoteRequest is the payload of API V2 endpoint changing the vote of the
+// user on the rule. Vote is 1 for like, -1 for dislike and 0 resets the vote.
+type RuleVoteRequest struct {
+	Vote *UserVote `json:"vote"`
+}
+
+// RuleFeedbackRequest is the payload of API V2 endpoint storing free-text
+// feedback of the user on the rule
+type RuleFeedbackRequest struct {
+	Message string `json:"message"`
+}
+
+// VotedRule describes the rule the vote or feedback belongs to
+type VotedRule struct {
+	RuleID      RuleID         `json:"rule_id"`
+	ErrorKey    types.ErrorKey `json:"error_key"`
+	Description string         `json:"description"`
+	Generic     string         `json:"generic"`
+	TotalRisk   int            `json:"total_risk"`
+}
@@ -59,6 +59,7 @@ type RuleWithContentResponse struct {
 	Resolution      string          `json:"resolution"`
 	MoreInfo        string          `json:"more_info"`
 	TotalRisk       int             `json:"total_risk"`
+	ResolutionRisk  int             `json:"resolution_risk,omitempty"`
 	Disabled        bool            `json:"disabled"`
 	DisableFeedback string          `json:"disable_feedback"`
 	DisabledAt      types.Timestamp `json:"disabled_at"`
@@ -67,8 +68,18 @@ type RuleWithContentResponse struct {
 	TemplateData    interface{}     `json:"extra_data"`
 	Tags            []string        `json:"tags"`
 	Impacted        Timestamp       `json:"impacted,omitempty"`
+	// ContentStatus is set only for rules returned without content, see
+	// RuleContentStatusMissing
+	ContentStatus string `json:"content_status,omitempty"`
+	// PlaybookAvailable tells whether Ansible remediation playbook exists
+	// for the rule, it is set only when remediations service is configured
+	PlaybookAvailable *bool `json:"playbook_available,omitempty"`
 }
 
+// RuleContentStatusMissing marks rule in report that is hitting the cluster,
+// but its content is not available
+const RuleContentStatusMissing = "missing"
+
 // RecommendationContent is a rule content struct used for Insights Advisor,
 type RecommendationContent struct {
 	// RuleSelector = rule.module|ERROR_KEY format
@@ -85,6 +96,48 @@ type RecommendationContent struct {
 	Tags         []string           `json:"tags"`
 }
 
+// ClusterRequest is the state of archive uploaded by the cluster and
+// processed by the external data pipeline
+type ClusterRequest struct {
+	RequestID string `json:"requestID"`
+	Received  string `json:"received"`
+	Processed string `json:"processed"`
+}
+
+// RequestRuleHit is the rule found in the processed archive together with
+// the rule content metadata
+type RequestRuleHit struct {
+	// RuleSelector = rule.module|ERROR_KEY format
+	RuleSelector types.RuleSelector `json:"rule_id"`
+	Description  string             `json:"description"`
+	TotalRisk    uint8              `json:"total_risk"`
+}
+
+// RuleContentBatchRequest is the list of rules whose content is requested at
+// once, rules are identified by rule.module|ERROR_KEY selectors
+type RuleContentBatchRequest struct {
+	Rules []types.RuleSelector `json:"rules"`
+}
+
+// PlaybookRequest is the list of rules hitting the cluster for which the
+// remediation playbook is requested, rules are identified by
+// rule.module|ERROR_KEY selectors
+type PlaybookRequest struct {
+	Rules []types.RuleSelector `json:"rules"`
+}
+
+// RuleRatingContent is the rating of the rule stored for the organization
+// together with the rule content metadata
+type RuleRatingContent struct {
+	// RuleSelector = rule.module|ERROR_KEY format
+	RuleSelector types.RuleSelector `json:"rule_id"`
+	Rating       types.UserVote     `json:"rating"`
+	Description  string             `json:"description"`
+	TotalRisk    uint8              `json:"total_risk"`
+	PublishDate  time.Time          `json:"publish_date"`
+	Tags         []string           `json:"tags"`
+}
+
 // RecommendationContentUserData is a rule content struct with additional Insights Advisor
 // related user data, such as rule acknowledging or rating, which requires access to DB/aggregator
 type RecommendationContentUserData struct {
@@ -110,15 +163,19 @@ type RecommendationContentUserData struct {
 type ReportResponseMetaV1 struct {
 	Count         int       `json:"count"`
 	LastCheckedAt Timestamp `json:"last_checked_at"`
+	IsStale       bool      `json:"is_stale,omitempty"`
 }
 
 // ReportResponseMetaV2 contains metadata for /report endpoint in v2
 type ReportResponseMetaV2 struct {
-	DisplayName   string    `json:"cluster_name"`
-	Managed       bool      `json:"managed"`
-	Count         int       `json:"count"`
-	LastCheckedAt Timestamp `json:"last_checked_at,omitempty"`
-	GatheredAt    Timestamp `json:"gathered_at,omitempty"`
+	DisplayName    string    `json:"cluster_name"`
+	Managed        bool      `json:"managed"`
+	ClusterVersion string    `json:"cluster_version,omitempty"`
+	LastSeen       Timestamp `json:"last_seen,omitempty"`
+	Count          int       `json:"count"`
+	LastCheckedAt  Timestamp `json:"last_checked_at,omitempty"`
+	GatheredAt     Timestamp `json:"gathered_at,omitempty"`
+	IsStale        bool      `json:"is_stale,omitempty"`
 }
 
 // SmartProxyReportV1 represents the response of /report (V1) endpoint for smart proxy
@@ -135,6 +192,13 @@ type SmartProxyReportV2 struct {
 	Data []RuleWithContentResponse `json:"data"`
 }
 
+// ClusterReports represents the response of endpoints returning reports for
+// list of clusters, enriched by display names and managed status of clusters
+type ClusterReports struct {
+	*types.ClusterReports
+	ClustersInfo map[ClusterName]ClusterInfo `json:"clusters_info,omitempty"`
+}
+
 // SmartProxyReport represents the response of /report (V2) endpoint for smart proxy
 type SmartProxyReport struct {
 	Meta types.ReportResponseMeta  `json:"meta"`
@@ -157,6 +221,60 @@ type OrgOverviewResponse struct {
 	ClustersHitByTag       map[string]int `json:"hit_by_tag"`
 }
 
+// DisabledRulesCount contains numbers of rules disabled in organization
+type DisabledRulesCount struct {
+	// SystemWide is the number of rules acknowledged for all clusters
+	SystemWide int `json:"system_wide"`
+	// PerCluster is the number of rules disabled for single clusters
+	PerCluster int `json:"per_cluster"`
+}
+
+// OrgOverviewV2 serves as the API response for API V2 /org_overview
+// endpoint
+type OrgOverviewV2 struct {
+	Clusters                   int                `json:"clusters"`
+	ClustersHit                int                `json:"clusters_hit"`
+	ClustersByHighestTotalRisk map[int]int        `json:"clusters_by_highest_total_risk"`
+	HitsByTotalRisk            map[int]int        `json:"hits_by_total_risk"`
+	HitsByTag                  map[string]int     `json:"hits_by_tag"`
+	DisabledRules              DisabledRulesCount `json:"disabled_rules"`
+}
+
+// Digest is the payload of email digest about recommendations newly hitting
+// clusters of the organization, it is used by the notifications service
+type Digest struct {
+	OrgID       types.OrgID      `json:"org_id"`
+	Since       time.Time        `json:"since"`
+	ClustersHit int              `json:"clusters_hit"`
+	Hits        int              `json:"hits"`
+	BySeverity  []DigestSeverity `json:"by_severity"`
+}
+
+// DigestSeverity contains new hits of recommendations with the same total
+// risk
+type DigestSeverity struct {
+	TotalRisk       int                    `json:"total_risk"`
+	Severity        string                 `json:"severity"`
+	Hits            int                    `json:"hits"`
+	Recommendations []DigestRecommendation `json:"recommendations"`
+}
+
+// DigestRecommendation is the recommendation newly hitting clusters along
+// with the snippet of its content rendered into HTML
+type DigestRecommendation struct {
+	RuleID      types.RuleSelector `json:"rule_id"`
+	Description string             `json:"description"`
+	Snippet     string             `json:"snippet"`
+	Clusters    []DigestCluster    `json:"clusters"`
+}
+
+// DigestCluster is the cluster newly hit by the recommendation
+type DigestCluster struct {
+	ClusterID   ClusterName `json:"cluster_id"`
+	DisplayName string      `json:"display_name"`
+	Impacted    string      `json:"impacted"`
+}
+
 const (
 	// UserVoteDislike shows user's dislike
 	UserVoteDislike = types.UserVoteDislike
@@ -188,6 +306,30 @@ type RuleWithContent struct {
 	Generic        string         `json:"generic"`
 	Tags           []string       `json:"tags"`
 	OSDCustomer    bool           `json:"osd_customer"`
+	// MinOCPVersion and MaxOCPVersion limit OCP versions the rule applies
+	// to, empty when the rule applies to all versions
+	MinOCPVersion string `json:"min_ocp_version,omitempty"`
+	MaxOCPVersion string `json:"max_ocp_version,omitempty"`
+}
+
+// RuleCatalogEntry is single rule with error key in the catalog of all
+// loaded rules
+type RuleCatalogEntry struct {
+	// RuleID is in "|" format
+	RuleID      types.RuleID `json:"rule_id"`
+	Description string       `json:"description"`
+	TotalRisk   int          `json:"total_risk"`
+	Impact      int          `json:"impact"`
+	Likelihood  int          `json:"likelihood"`
+	PublishDate time.Time    `json:"publish_date"`
+	// Active is the publish status of the rule
+	Active      bool     `json:"active"`
+	Internal    bool     `json:"internal"`
+	OSDCustomer bool     `json:"osd_customer"`
+	Tags        []string `json:"tags"`
+	// Groups contains names of groups sharing at least one tag with the
+	// rule
+	Groups []string `json:"groups"`
 }
 
 // RecommendationListView represents the API response for Advisor /rule/ related endpoints
@@ -215,7 +357,10 @@ type ClusterListView struct {
 	LastCheckedAt   Timestamp         `json:"last_checked_at,omitempty"`
 	TotalHitCount   uint32            `json:"total_hit_count"`
 	HitsByTotalRisk map[int]int       `json:"hits_by_total_risk"`
-	Version         types.Version     `json:"cluster_version,omitempty"`
+	// HighestTotalRisk is the total risk of the most severe rule hitting
+	// the cluster, zero when no rule is hitting it
+	HighestTotalRisk int           `json:"highest_total_risk"`
+	Version          types.Version `json:"cluster_version,omitempty"`
 }
 
 // RuleRating structure with the rule identifier and the rating
@@ -246,12 +391,140 @@ type InfoResponse struct {
 	ContentService map[string]string `json:"ContentService"`
 }
 
+// PartialResultMeta is sent by endpoints working with many clusters. The
+// result is partial when some clusters have not been processed within the
+// time budget, those clusters are listed.
+type PartialResultMeta struct {
+	Partial          bool          `json:"partial"`
+	TimedOutClusters []ClusterName `json:"timed_out_clusters,omitempty"`
+}
+
+// OCMRiskSummary is the number of issues with given total risk
+type OCMRiskSummary struct {
+	TotalRisk int    `json:"total_risk"`
+	Label     string `json:"label"`
+	Count     int    `json:"count"`
+}
+
+// OCMBadges contains counts of issues shown as badges in OpenShift Cluster
+// Manager UI
+type OCMBadges struct {
+	Total     int `json:"total"`
+	Critical  int `json:"critical"`
+	Incidents int `json:"incidents"`
+}
+
+// OCMIssue is single recommendation hitting the cluster in the format
+// expected by OpenShift Cluster Manager UI
+type OCMIssue struct {
+	RuleSelector types.RuleSelector `json:"rule_id"`
+	Description  string             `json:"description"`
+	TotalRisk    int                `json:"total_risk"`
+	RiskLabel    string             `json:"risk_label"`
+	Incident     bool               `json:"incident"`
+	Impacted     Timestamp          `json:"impacted,omitempty"`
+}
+
+// OCMReport is the cluster report in the format expected by OpenShift
+// Cluster Manager UI
+type OCMReport struct {
+	ClusterID     ClusterName      `json:"cluster_id"`
+	DisplayName   string           `json:"display_name"`
+	LastCheckedAt Timestamp        `json:"last_checked_at,omitempty"`
+	RiskSummary   []OCMRiskSummary `json:"risk_summary"`
+	Badges        OCMBadges        `json:"badges"`
+	Issues        []OCMIssue       `json:"issues"`
+	IsStale       bool             `json:"is_stale,omitempty"`
+}
+
+// AdvisorSystem is the cluster in the shape of system returned by RHEL
+// Insights Advisor API
+type AdvisorSystem struct {
+	SystemUUID    ClusterName `json:"system_uuid"`
+	DisplayName   string      `json:"display_name"`
+	LastSeen      Timestamp   `json:"last_seen,omitempty"`
+	Hits          int         `json:"hits"`
+	CriticalHits  int         `json:"critical_hits"`
+	ImportantHits int         `json:"important_hits"`
+	ModerateHits  int         `json:"moderate_hits"`
+	LowHits       int         `json:"low_hits"`
+	Version       string      `json:"version,omitempty"`
+}
+
+// AdvisorReport is the rule hitting the cluster in the shape of report of
+// system returned by RHEL Insights Advisor API
+type AdvisorReport struct {
+	Rule         AdvisorRule       `json:"rule"`
+	Details      interface{}       `json:"details"`
+	Resolution   AdvisorResolution `json:"resolution"`
+	ImpactedDate Timestamp         `json:"impacted_date,omitempty"`
+}
+
+// AdvisorRule is the content of rule in RHEL Insights Advisor API
+type AdvisorRule struct {
+	RuleID         types.RuleSelector `json:"rule_id"`
+	Description    string             `json:"description"`
+	Generic        string             `json:"generic"`
+	Reason         string             `json:"reason"`
+	MoreInfo       string             `json:"more_info"`
+	TotalRisk      int                `json:"total_risk"`
+	ResolutionRisk int                `json:"resolution_risk"`
+	// Tags are separated by spaces, the same as in RHEL Insights Advisor
+	Tags string `json:"tags"`
+}
+
+// AdvisorResolution is the resolution of rule in RHEL Insights Advisor API
+type AdvisorResolution struct {
+	Resolution     string                `json:"resolution"`
+	ResolutionRisk AdvisorResolutionRisk `json:"resolution_risk"`
+}
+
+// AdvisorResolutionRisk is the risk of change of resolution in RHEL Insights
+// Advisor API
+type AdvisorResolutionRisk struct {
+	Name string `json:"name"`
+	Risk int    `json:"risk"`
+}
+
+// AdvisorHit is the rule hitting the cluster in the shape of hit exported by
+// RHEL Insights Advisor API
+type AdvisorHit struct {
+	Hostname    string             `json:"hostname"`
+	UUID        ClusterName        `json:"uuid"`
+	LastSeen    Timestamp          `json:"last_seen,omitempty"`
+	RuleID      types.RuleSelector `json:"rule_id"`
+	Title       string             `json:"title"`
+	TotalRisk   int                `json:"total_risk"`
+	Likelihood  int                `json:"likelihood"`
+	PublishDate time.Time          `json:"publish_date"`
+}
+
+// RejectedCluster describes cluster from the list in request body that can't
+// be processed and the reason of its rejection
+type RejectedCluster struct {
+	ClusterID string `json:"cluster_id"`
+	Reason    string `json:"reason"`
+}
+
 // ClusterInfo is a data structure containing some relevant cluster information
 type ClusterInfo struct {
 	ID          ClusterName `json:"cluster_id"`
 	DisplayName string      `json:"display_name"`
 	Managed     bool        `json:"managed"`
 	Status      string      `json:"status"`
+	Version     string      `json:"version,omitempty"`
+	LastSeen    Timestamp   `json:"last_seen,omitempty"`
+}
+
+// ServiceLogEntry is the entry of OCM Service Log sent to the owners of the
+// cluster
+type ServiceLogEntry struct {
+	ClusterUUID  ClusterName `json:"cluster_uuid"`
+	Severity     string      `json:"severity"`
+	ServiceName  string      `json:"service_name"`
+	Summary      string      `json:"summary"`
+	Description  string      `json:"description"`
+	InternalOnly bool        `json:"internal_only"`
 }
 
 // ClustersDetailData is the inner data structure for /clusters_detail
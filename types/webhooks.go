@@ -0,0 +1,44 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// Webhook is the URL registered by organization admin to be notified about
+// new critical recommendations hitting clusters of the organization
+type Webhook struct {
+	ID        string    `json:"id,omitempty"`
+	OrgID     OrgID     `json:"org_id,omitempty"`
+	UserID    UserID    `json:"user_id,omitempty"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// WebhookRuleHit is the recommendation newly hitting the cluster
+type WebhookRuleHit struct {
+	ClusterID   ClusterName `json:"cluster_id"`
+	ClusterName string      `json:"cluster_name"`
+	RuleID      RuleID      `json:"rule_id"`
+	Description string      `json:"description"`
+	TotalRisk   int         `json:"total_risk"`
+}
+
+// WebhookNotification is the payload sent to webhooks
+type WebhookNotification struct {
+	Event  string           `json:"event"`
+	OrgID  OrgID            `json:"org_id"`
+	SentAt time.Time        `json:"sent_at"`
+	Hits   []WebhookRuleHit `json:"hits"`
+}